@@ -1,9 +1,11 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -47,6 +49,7 @@ func loggingMiddleware(logger *logrus.Logger) mux.MiddlewareFunc {
 				"duration":   duration.String(),
 				"user_agent": r.UserAgent(),
 				"remote_ip":  r.RemoteAddr,
+				"request_id": api.RequestIDFromContext(r.Context()),
 			}).Info("Request processed")
 		})
 	}
@@ -62,6 +65,20 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the flushing of event streams
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Hijack hands the connection over to WebSocket handlers, which answer with 101 Switching Protocols
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.status = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
+
 type PolkachuUpgrade struct {
 	Name        string    `json:"name"`
 	Height      int64     `json:"height"`
@@ -84,6 +101,7 @@ func main() {
 	banner.Init(colorable.NewColorableStdout(), true, true, strings.NewReader(bannerText))
 
 	configPath := flag.String("config", "config/config.json", "path to config file")
+	validateConfig := flag.Bool("validate-config", false, "check that every chain in chains.yaml resolves in the chain-registry and exit")
 	flag.Parse()
 
 	logger := logrus.New()
@@ -112,30 +130,155 @@ func main() {
 	logger.Debugf("GitHub API URL: %s", githubAPIURL)
 	logger.Debugf("Chain Registry URL: %s", chainRegistryURL)
 
+	localRegistryPath := cfg.Registry.LocalPath
+	if localRegistryPath == "" {
+		localRegistryPath = os.Getenv("CHAIN_REGISTRY_LOCAL_PATH")
+	}
+	if localRegistryPath != "" {
+		if stat, err := os.Stat(localRegistryPath); err != nil || !stat.IsDir() {
+			logger.Fatalf("Invalid local chain-registry path %q: not a directory", localRegistryPath)
+		}
+		logger.Infof("Reading the chain-registry from %s", localRegistryPath)
+	}
+
+	var cacheBackend chain.Cache
+	if cfg.Cache.Backend == config.CacheBackendRedis {
+		redisCache, err := chain.NewRedisCache(logger, chain.RedisCacheConfig{
+			Address:   cfg.Cache.Address,
+			Password:  cfg.Cache.Password,
+			KeyPrefix: cfg.Cache.KeyPrefix,
+		})
+		if err != nil {
+			logger.Fatalf("Failed to set up the Redis cache: %v", err)
+		}
+		defer redisCache.Close()
+		cacheBackend = redisCache
+		logger.Infof("Caching registry data in Redis at %s", cfg.Cache.Address)
+	}
+
 	registry := chain.NewChainRegistry(
 		logger,
 		githubAPIURL,
 		chainRegistryURL,
+		chain.WithLocalRegistry(localRegistryPath),
+		chain.WithCacheBackend(cacheBackend),
 	)
+	if cfg.GitHub.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.GitHub.Timeout)
+		if err != nil {
+			logger.Fatalf("Invalid GitHub timeout: %v", err)
+		}
+		registry.SetHTTPTimeout(timeout)
+	}
+	registry.SetGitHubToken(cfg.GitHub.Token)
+	registry.SetDegradedThreshold(cfg.Registry.DegradedFailureThreshold)
+	var endpointRefreshInterval time.Duration
+	if cfg.Registry.EndpointRefreshInterval != "" {
+		endpointRefreshInterval, err = time.ParseDuration(cfg.Registry.EndpointRefreshInterval)
+		if err != nil {
+			logger.Fatalf("Invalid endpoint refresh interval: %v", err)
+		}
+	}
+	registry.SetEndpointRefresh(endpointRefreshInterval, cfg.Registry.EndpointRefreshRateLimit)
+	var retryBaseDelay time.Duration
+	if cfg.Registry.RetryBaseDelay != "" {
+		retryBaseDelay, err = time.ParseDuration(cfg.Registry.RetryBaseDelay)
+		if err != nil {
+			logger.Fatalf("Invalid registry retry base delay: %v", err)
+		}
+	}
+	registry.SetRetryPolicy(cfg.Registry.RetryAttempts, retryBaseDelay)
+	var cacheTTL, negativeCacheTTL time.Duration
+	if cfg.Registry.CacheTTL != "" {
+		cacheTTL, err = time.ParseDuration(cfg.Registry.CacheTTL)
+		if err != nil {
+			logger.Fatalf("Invalid registry cache TTL: %v", err)
+		}
+	}
+	if cfg.Registry.NegativeCacheTTL != "" {
+		negativeCacheTTL, err = time.ParseDuration(cfg.Registry.NegativeCacheTTL)
+		if err != nil {
+			logger.Fatalf("Invalid registry negative cache TTL: %v", err)
+		}
+	}
+	registry.SetCacheTTLs(cacheTTL, negativeCacheTTL)
+	// Load has already rejected invalid values
+	chainInfoTTL, upgradeInfoTTL, _ := cfg.Registry.InfoTTLs()
+	registry.SetInfoTTLs(chainInfoTTL, upgradeInfoTTL)
+	chainExistsTTL, _ := cfg.Registry.ExistsTTL()
+	registry.SetChainExistsTTL(chainExistsTTL)
+	registry.SetExistsCheckConcurrency(cfg.Registry.ExistsCheckConcurrency)
+	var discoveryTTL time.Duration
+	if cfg.Registry.DiscoveryCacheTTL != "" {
+		discoveryTTL, err = time.ParseDuration(cfg.Registry.DiscoveryCacheTTL)
+		if err != nil {
+			logger.Fatalf("Invalid registry discovery cache TTL: %v", err)
+		}
+	}
+	registry.SetChainDiscovery(cfg.GitHub.APIURL, discoveryTTL)
+	registry.SetRegistryMirrors(cfg.Registry.Mirrors)
+	if err := registry.SetRegistryRef(cfg.Registry.Ref); err != nil {
+		logger.Fatalf("Invalid registry ref: %v", err)
+	}
+	if err := registry.SetChainInfoSources(cfg.Registry.ChainInfoSources); err != nil {
+		logger.Fatalf("Invalid registry chain info sources: %v", err)
+	}
+	if cfg.Upgrades.InProgressWindow != "" {
+		window, err := time.ParseDuration(cfg.Upgrades.InProgressWindow)
+		if err != nil {
+			logger.Fatalf("Invalid upgrade in-progress window: %v", err)
+		}
+		registry.SetInProgressWindow(window)
+	}
+	if cfg.Upgrades.PolkachuCacheWindow != "" {
+		window, err := time.ParseDuration(cfg.Upgrades.PolkachuCacheWindow)
+		if err != nil {
+			logger.Fatalf("Invalid Polkachu cache window: %v", err)
+		}
+		registry.SetPolkachuCacheWindow(window)
+	}
+	if cfg.Upgrades.ETADriftThreshold != "" {
+		threshold, err := time.ParseDuration(cfg.Upgrades.ETADriftThreshold)
+		if err != nil {
+			logger.Fatalf("Invalid upgrade ETA drift threshold: %v", err)
+		}
+		registry.SetETADriftThreshold(threshold)
+	}
+	registry.SetExplorerOrder(cfg.Upgrades.Explorers)
+	if err := registry.SetUpgradeProviderOrder(cfg.Upgrades.Providers); err != nil {
+		logger.Fatalf("Invalid upgrade providers: %v", err)
+	}
+	if cfg.Upgrades.ResolveReleaseVersions {
+		releasesAPIURL := cfg.GitHub.APIURL
+		if releasesAPIURL == "" {
+			releasesAPIURL = "https://api.github.com"
+		}
+		registry.SetReleaseLookup(releasesAPIURL, cfg.GitHub.Token)
+	}
+	if cfg.Registry.SnapshotPath != "" {
+		if err := registry.SetSnapshotPath(cfg.Registry.SnapshotPath); err != nil {
+			logger.Warnf("Failed to load snapshot, starting without one: %v", err)
+		}
+	}
+
+	if *validateConfig {
+		chainConfig, err := config.LoadChainConfig()
+		if err != nil {
+			logger.Fatalf("Failed to load chain config: %v", err)
+		}
+		os.Exit(validateChainConfig(registry, chainConfig, os.Stdout))
+	}
 
 	handler := api.NewHandler(registry, logger, cfg)
 
 	loadChainsJob := cron.NewLoadChainsJob(registry, logger)
 	handler.Scheduler.RegisterTask("load-chains", loadChainsJob.Run)
 
-	if err := loadChainsJob.Run(); err != nil {
-		logger.Fatalf("Failed to load initial chains: %v", err)
-	}
-
 	router := mux.NewRouter()
+	router.Use(api.RequestIDMiddleware)
 	router.Use(loggingMiddleware(logger))
 
-	router.HandleFunc("/api/v1/health", handler.HealthCheck).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/upgrades", handler.GetUpgrades).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/chains/{chainName}", handler.GetChainInfo).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/jobs", handler.ListJobs).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/scheduler/start", handler.StartScheduler).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/scheduler/stop", handler.StopScheduler).Methods(http.MethodPost)
+	api.SetupRoutes(router, handler)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Server.Port),
@@ -144,11 +287,28 @@ func main() {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	// Served from the start so liveness checks pass during startup, /api/v1/ready tells when the
+	// instance can take traffic
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	if err := loadChainsJob.Run(); err != nil {
+		logger.Fatalf("Failed to load initial chains: %v", err)
+	}
+	handler.SetChainsLoaded()
+
 	interval, err := time.ParseDuration(cfg.Poller.Interval)
 	if err != nil {
 		logger.Fatalf("Invalid poller interval: %v", err)
 	}
 	p := poller.New(registry, logger, interval)
+	// Load has already rejected an invalid minimum
+	stallMinimum, _ := cfg.Poller.StallMinimum()
+	p.SetStallDetection(cfg.Poller.StallBlocks, stallMinimum)
+	p.SetStallNotifier(handler)
 
 	go p.Start()
 
@@ -159,6 +319,9 @@ func main() {
 
 	startupNotifier := notifications.NewStartupNotifier(registry, slack, logger)
 	go startupNotifier.NotifyStartup()
+	go handler.ReplayDeadLetters()
+	go handler.RunNotificationBatches()
+	go handler.RunAutoDiscovery()
 
 	if err := handler.Scheduler.Start(); err != nil {
 		logger.Fatalf("Failed to start scheduler: %v", err)
@@ -167,12 +330,6 @@ func main() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
-	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatalf("Failed to start server: %v", err)
-		}
-	}()
-
 	logger.Infof("Server started on port %s - Press Ctrl+C to stop.", cfg.Server.Port)
 
 	<-stop
@@ -182,7 +339,7 @@ func main() {
 	defer cancel()
 
 	p.Stop()
-	handler.Scheduler.Stop()
+	handler.Shutdown(ctx)
 
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Errorf("Server shutdown failed: %v", err)
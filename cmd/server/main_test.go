@@ -0,0 +1,81 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/api"
+	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/0xPuncker/cosmos-watcher/internal/testutil"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingMiddleware_Status(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+
+	router := mux.NewRouter()
+	router.Use(api.RequestIDMiddleware)
+	router.Use(loggingMiddleware(logger))
+	router.HandleFunc("/api/v1/large", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(strings.Repeat("tea ", 1024)))
+	})
+	api.SetupRoutes(router, api.NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{}))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	logged := func() logrus.Fields {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			entry := hook.LastEntry()
+			return entry != nil && entry.Message == "Request processed"
+		}, time.Second, 10*time.Millisecond)
+		fields := hook.LastEntry().Data
+		hook.Reset()
+		return fields
+	}
+	loggedStatus := func() interface{} {
+		t.Helper()
+		return logged()["status"]
+	}
+
+	// The compressed response's status is the one logged
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/large", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	gz, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, strings.Repeat("tea ", 1024), string(body))
+	fields := logged()
+	assert.Equal(t, http.StatusTeapot, fields["status"])
+	assert.Equal(t, resp.Header.Get(api.RequestIDHeader), fields["request_id"], "the logged request ID is the one returned")
+
+	// Event streams and WebSockets still reach the connection through the middleware
+	resp, err = http.Get(server.URL + "/api/v1/upgrades/stream")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, loggedStatus())
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/v1/ws", nil)
+	require.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, loggedStatus())
+}
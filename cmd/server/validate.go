@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/internal/config"
+)
+
+// validateChainConfig checks that every chain of the chain config resolves in the chain-registry and
+// only lists known upgrade sources, and writes a report to out. It returns the process exit code,
+// non-zero when any chain is invalid.
+func validateChainConfig(registry *chain.ChainRegistry, chainConfig *config.ChainConfig, out io.Writer) int {
+	if chainConfig.Embedded {
+		fmt.Fprintln(out, "No chains.yaml found, nothing to validate")
+		return 1
+	}
+
+	declared := make(map[string]string)
+	sources := make(map[string][]string)
+	var names []string
+	for _, networkChains := range [][]config.Chain{chainConfig.Mainnet, chainConfig.Testnet} {
+		for _, c := range networkChains {
+			names = append(names, c.Name)
+			declared[c.Name] = c.Network
+			sources[c.Name] = c.Sources
+		}
+	}
+
+	// Chains resolve under their registry_name and testnet entries in the testnets directory
+	registry.SetChainAliases(chainConfig.ChainConfigs())
+
+	invalid := 0
+	for _, result := range registry.ValidateChains(context.Background(), names) {
+		if result.Err != nil {
+			invalid++
+			fmt.Fprintf(out, "INVALID  %s: %v\n", result.Name, result.Err)
+			continue
+		}
+		if err := registry.ValidateUpgradeProviderOrder(sources[result.Name]); err != nil {
+			invalid++
+			fmt.Fprintf(out, "INVALID  %s: sources: %v\n", result.Name, err)
+			continue
+		}
+		if network := declared[result.Name]; network != "" && network != result.Network {
+			fmt.Fprintf(out, "VALID    %s (%s, configured as %s)\n", result.Name, result.Network, network)
+			continue
+		}
+		fmt.Fprintf(out, "VALID    %s (%s)\n", result.Name, result.Network)
+	}
+
+	fmt.Fprintf(out, "%d of %d chains valid\n", len(names)-invalid, len(names))
+	if invalid > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateChainConfig(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/cosmos/chain-registry/master/")
+		switch path {
+		case "cosmoshub/chain.json", "osmosis/chain.json", "testnets/osmosistestnet/chain.json":
+			name := strings.TrimSuffix(strings.TrimPrefix(path, "testnets/"), "/chain.json")
+			json.NewEncoder(w).Encode(chain.ChainInfo{Name: name, ChainID: name + "-1"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name     string
+		config   config.ChainConfig
+		exitCode int
+		output   []string
+	}{
+		{
+			name: "all chains valid",
+			config: config.ChainConfig{
+				Mainnet: []config.Chain{{Name: "cosmoshub", Network: "mainnet"}, {Name: "osmosis", Network: "mainnet"}},
+				Testnet: []config.Chain{{Name: "osmosistestnet", Network: "testnet"}},
+			},
+			exitCode: 0,
+			output:   []string{"VALID    cosmoshub (mainnet)", "VALID    osmosistestnet (testnet)", "3 of 3 chains valid"},
+		},
+		{
+			name: "mix of valid and invalid chains",
+			config: config.ChainConfig{
+				Mainnet: []config.Chain{{Name: "cosmoshub", Network: "mainnet"}, {Name: "notachain", Network: "mainnet"}},
+				Testnet: []config.Chain{{Name: "osmosistestnet", Network: "testnet"}, {Name: "typotestnet", Network: "testnet"}},
+			},
+			exitCode: 1,
+			output:   []string{"VALID    cosmoshub", "INVALID  notachain", "INVALID  typotestnet", "2 of 4 chains valid"},
+		},
+		{
+			name: "unknown upgrade source",
+			config: config.ChainConfig{
+				Mainnet: []config.Chain{
+					{Name: "cosmoshub", Network: "mainnet", Sources: []string{"polkachu", "chain-registry"}},
+					{Name: "osmosis", Network: "mainnet", Sources: []string{"onchain"}},
+				},
+			},
+			exitCode: 1,
+			output:   []string{"VALID    cosmoshub", `INVALID  osmosis: sources: unknown upgrade provider "onchain"`, "1 of 2 chains valid"},
+		},
+		{
+			name:     "embedded fallback",
+			config:   config.ChainConfig{Mainnet: []config.Chain{{Name: "cosmoshub"}}, Embedded: true},
+			exitCode: 1,
+			output:   []string{"No chains.yaml found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+
+			var out bytes.Buffer
+			assert.Equal(t, tt.exitCode, validateChainConfig(registry, &tt.config, &out))
+			for _, line := range tt.output {
+				assert.Contains(t, out.String(), line)
+			}
+		})
+	}
+}
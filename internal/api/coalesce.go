@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// upgradesCoalescer shares one upgrades computation between concurrent identical requests. With a
+// window, a finished result is also reused by identical requests arriving within the window.
+type upgradesCoalescer struct {
+	window  time.Duration
+	waiting atomic.Int64
+
+	mu      sync.Mutex
+	flights map[string]*upgradesFlight
+	recent  map[string]coalescedUpgrades
+}
+
+// upgradesFlight is a computation in progress and the number of callers still waiting for it
+type upgradesFlight struct {
+	cancel   context.CancelFunc
+	callers  int
+	done     chan struct{}
+	response *UpgradesResponse
+	err      error
+}
+
+type coalescedUpgrades struct {
+	response *UpgradesResponse
+	expires  time.Time
+}
+
+func newUpgradesCoalescer(window time.Duration) *upgradesCoalescer {
+	return &upgradesCoalescer{
+		window:  window,
+		flights: make(map[string]*upgradesFlight),
+		recent:  make(map[string]coalescedUpgrades),
+	}
+}
+
+// do returns the result of compute for key, running it only once for all callers that arrive while it
+// is in flight. A caller whose ctx is done stops waiting; the computation itself is only cancelled
+// once every caller waiting on it has gone. A fresh caller, such as a forced refresh, never reuses a
+// result that finished within the window. The shared response must not be modified by callers.
+func (c *upgradesCoalescer) do(ctx context.Context, key string, fresh bool, compute func(ctx context.Context) (*UpgradesResponse, error)) (*UpgradesResponse, error) {
+	if response, ok := c.lookup(key); ok && !fresh {
+		return response, nil
+	}
+
+	c.waiting.Add(1)
+	defer c.waiting.Add(-1)
+
+	c.mu.Lock()
+	flight, ok := c.flights[key]
+	if !ok {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		flight = &upgradesFlight{cancel: cancel, done: make(chan struct{})}
+		c.flights[key] = flight
+		go c.run(flightCtx, key, flight, compute)
+	}
+	flight.callers++
+	c.mu.Unlock()
+
+	select {
+	case <-flight.done:
+		return flight.response, flight.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		flight.callers--
+		if flight.callers == 0 {
+			flight.cancel()
+			if c.flights[key] == flight {
+				delete(c.flights, key)
+			}
+		}
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (c *upgradesCoalescer) run(ctx context.Context, key string, flight *upgradesFlight, compute func(ctx context.Context) (*UpgradesResponse, error)) {
+	defer flight.cancel()
+	response, err := compute(ctx)
+
+	c.mu.Lock()
+	if c.flights[key] == flight {
+		delete(c.flights, key)
+	}
+	if err == nil && c.window > 0 {
+		c.recent[key] = coalescedUpgrades{response: response, expires: time.Now().Add(c.window)}
+	}
+	c.mu.Unlock()
+
+	flight.response, flight.err = response, err
+	close(flight.done)
+}
+
+func (c *upgradesCoalescer) lookup(key string) (*UpgradesResponse, bool) {
+	if c.window <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, entry := range c.recent {
+		if now.After(entry.expires) {
+			delete(c.recent, k)
+		}
+	}
+	entry, ok := c.recent[key]
+	return entry.response, ok
+}
+
+// inFlight returns the number of callers currently computing or waiting on a shared computation
+func (c *upgradesCoalescer) inFlight() int {
+	return int(c.waiting.Load())
+}
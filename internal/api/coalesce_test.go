@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradesCoalescer_Window(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   time.Duration
+		expected int
+	}{
+		{"concurrent only", 0, 2},
+		{"within window", time.Minute, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coalescer := newUpgradesCoalescer(tt.window)
+			var computations int
+			compute := func(ctx context.Context) (*UpgradesResponse, error) {
+				computations++
+				return &UpgradesResponse{}, nil
+			}
+
+			for range 2 {
+				response, err := coalescer.do(context.Background(), "group=core", false, compute)
+				require.NoError(t, err)
+				assert.NotNil(t, response)
+			}
+			assert.Equal(t, tt.expected, computations)
+
+			// Other queries are computed separately
+			_, err := coalescer.do(context.Background(), "group=other", false, compute)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected+1, computations)
+
+			_, err = coalescer.do(context.Background(), "group=other", true, compute)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected+2, computations, "a fresh caller never reuses a finished result")
+		})
+	}
+}
+
+func TestUpgradesCoalescer_CancelsOnceAllCallersLeave(t *testing.T) {
+	coalescer := newUpgradesCoalescer(0)
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	compute := func(ctx context.Context) (*UpgradesResponse, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() {
+		_, err := coalescer.do(first, "group=core", false, compute)
+		errs <- err
+	}()
+	<-started
+	go func() {
+		_, err := coalescer.do(second, "group=core", false, compute)
+		errs <- err
+	}()
+	require.Eventually(t, func() bool { return coalescer.inFlight() == 2 }, time.Second, 5*time.Millisecond)
+
+	cancelFirst()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	select {
+	case <-cancelled:
+		t.Fatal("computation cancelled while a caller was still waiting")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancelSecond()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("computation kept running after every caller left")
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultCompressionMinSize is the smallest response body worth compressing
+const defaultCompressionMinSize = 1024
+
+// streamingRoutes are the routes never compressed, whatever the request asks for, since buffering
+// would hold back their events
+var streamingRoutes = map[string]bool{
+	routeUpgradesStream: true,
+	routeWebSocket:      true,
+}
+
+// compressionMiddleware gzips responses for clients sending Accept-Encoding: gzip and sets Vary:
+// Accept-Encoding for them. Bodies smaller than minSize are sent as is, as are the streaming routes,
+// other event streams and protocol upgrades such as WebSockets. Zero uses the default size, a
+// negative value disables compression entirely.
+func compressionMiddleware(minSize int) mux.MiddlewareFunc {
+	if minSize == 0 {
+		minSize = defaultCompressionMinSize
+	}
+
+	return func(next http.Handler) http.Handler {
+		if minSize < 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !acceptsGzip(r) || streaming(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize, status: http.StatusOK}
+			defer gw.Close()
+			w.Header().Add("Vary", "Accept-Encoding")
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// streaming reports whether the request is for a response that must be sent as it is written
+func streaming(r *http.Request) bool {
+	if route := mux.CurrentRoute(r); route != nil && streamingRoutes[route.GetName()] {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream") || r.Header.Get("Upgrade") != ""
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of a response until it is known to be large enough to be
+// worth compressing. Responses that are flushed before that point are streamed uncompressed.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize     int
+	status      int
+	buf         bytes.Buffer
+	gz          *gzip.Writer
+	passthrough bool
+	wroteHeader bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(p)
+	case w.passthrough:
+		return w.ResponseWriter.Write(p)
+	}
+
+	if w.Header().Get("Content-Encoding") != "" || strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		w.startPassthrough()
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf.Write(p)
+	if w.buf.Len() >= w.minSize {
+		if err := w.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if w.gz == nil && !w.passthrough {
+		w.startPassthrough()
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close finishes the response, sending small buffered bodies uncompressed
+func (w *gzipResponseWriter) Close() error {
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	if !w.passthrough {
+		w.startPassthrough()
+	}
+	return nil
+}
+
+func (w *gzipResponseWriter) startGzip() error {
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.writeHeader()
+
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *gzipResponseWriter) startPassthrough() {
+	w.passthrough = true
+	w.writeHeader()
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+func (w *gzipResponseWriter) writeHeader() {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
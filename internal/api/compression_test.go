@@ -0,0 +1,184 @@
+package api
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/0xPuncker/cosmos-watcher/internal/testutil"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionMiddleware(t *testing.T) {
+	large := make([]ChainUpgrade, 100)
+	for i := range large {
+		large[i] = ChainUpgrade{Name: "cosmoshub", Network: "mainnet", Version: "v25.0.0", Height: 25000000}
+	}
+
+	jsonHandler := func(payload interface{}) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(payload)
+		})
+	}
+	streamHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: " + strings.Repeat("x", 4096) + "\n\n"))
+		w.(http.Flusher).Flush()
+	})
+
+	tests := []struct {
+		name           string
+		minSize        int
+		acceptEncoding string
+		handler        http.Handler
+		expectGzip     bool
+	}{
+		{"large JSON with gzip accepted", 0, "gzip, deflate", jsonHandler(large), true},
+		{"large JSON without gzip accepted", 0, "", jsonHandler(large), false},
+		{"gzip explicitly refused", 0, "gzip;q=0", jsonHandler(large), false},
+		{"small JSON", 0, "gzip", jsonHandler(map[string]string{"status": "ok"}), false},
+		{"event stream", 0, "gzip", streamHandler, false},
+		{"compression disabled", -1, "gzip", jsonHandler(large), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/upgrades", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+
+			compressionMiddleware(tt.minSize)(tt.handler).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			body := rr.Body.Bytes()
+			if tt.expectGzip {
+				require.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+				gz, err := gzip.NewReader(rr.Body)
+				require.NoError(t, err)
+				body, err = io.ReadAll(gz)
+				require.NoError(t, err)
+			} else {
+				assert.Empty(t, rr.Header().Get("Content-Encoding"))
+			}
+
+			if rr.Header().Get("Content-Type") == "application/json" {
+				assert.True(t, json.Valid(body), "response should decode to valid JSON")
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware_PreservesStatus(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(strings.Repeat("missing ", 512)))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/chains/unknown", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	compressionMiddleware(0)(handler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+}
+
+func TestCompressionMiddleware_SameJSON(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var chains []*chain.ChainInfo
+	for i := 0; i < 30; i++ {
+		chains = append(chains, &chain.ChainInfo{Name: fmt.Sprintf("chain-%02d", i), Network: "mainnet"})
+	}
+	registry := testutil.NewFakeRegistry(chains...)
+	for i, info := range chains {
+		registry.Upgrades[info.Name] = &types.UpgradeInfo{ChainName: info.Name, Network: "mainnet", Version: fmt.Sprintf("v%d.0.0", i), Height: int64(1000000 + i), Time: time.Now().Add(24 * time.Hour)}
+	}
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	get := func(acceptEncoding string) (*http.Response, UpgradesResponse) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, apiPath+"/upgrades", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		resp := rr.Result()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body := io.Reader(resp.Body)
+		if resp.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(resp.Body)
+			require.NoError(t, err)
+			body = gz
+		}
+		var upgrades UpgradesResponse
+		require.NoError(t, json.NewDecoder(body).Decode(&upgrades))
+		upgrades.LastUpdated = time.Time{}
+		return resp, upgrades
+	}
+
+	compressed, fromGzip := get("gzip")
+	assert.Equal(t, "gzip", compressed.Header.Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", compressed.Header.Get("Vary"))
+	assert.Empty(t, compressed.Header.Get("Content-Length"))
+
+	plain, fromPlain := get("identity")
+	assert.Empty(t, plain.Header.Get("Content-Encoding"))
+	require.Len(t, fromPlain.Chains, 30)
+	assert.Equal(t, fromPlain, fromGzip)
+}
+
+func TestCompressionMiddleware_SkipsStreamingRoutes(t *testing.T) {
+	// The real routes carry the names the middleware skips
+	routes := mux.NewRouter()
+	SetupRoutes(routes, NewHandler(testutil.NewFakeRegistry(), logrus.New(), &config.Config{}))
+	for name, path := range map[string]string{routeUpgradesStream: apiPath + "/upgrades/stream", routeWebSocket: apiPath + "/ws"} {
+		route := routes.Get(name)
+		require.NotNil(t, route, name)
+		template, err := route.GetPathTemplate()
+		require.NoError(t, err)
+		assert.Equal(t, path, template)
+	}
+
+	// Without an Accept or Upgrade header only the route tells the middleware not to wrap the writer
+	var wrapped bool
+	probe := func(w http.ResponseWriter, r *http.Request) {
+		_, wrapped = w.(*gzipResponseWriter)
+		w.Write([]byte(strings.Repeat("data: x\n\n", 512)))
+	}
+	router := mux.NewRouter()
+	router.Use(compressionMiddleware(0))
+	router.HandleFunc("/stream", probe).Name(routeUpgradesStream)
+	router.HandleFunc("/ws", probe).Name(routeWebSocket)
+	router.HandleFunc("/other", probe)
+
+	for path, expectWrapped := range map[string]bool{"/stream": false, "/ws": false, "/other": true} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, expectWrapped, wrapped, path)
+		if expectWrapped {
+			assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"), path)
+		} else {
+			assert.Empty(t, rr.Header().Get("Content-Encoding"), path)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// icsCacheTTL is how long a rendered iCalendar feed is served before the upgrades are fetched again
+const icsCacheTTL = time.Minute
+
+// feedCache keeps rendered iCalendar feeds by URL path, so calendar clients polling a feed do not each
+// fan out to every chain. Rendering holds the lock: clients asking for an expired feed wait for one
+// render instead of starting their own.
+type feedCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	feeds map[string]cachedFeed
+	now   func() time.Time
+}
+
+type cachedFeed struct {
+	body    string
+	expires time.Time
+}
+
+func newFeedCache(ttl time.Duration) *feedCache {
+	return &feedCache{ttl: ttl, feeds: make(map[string]cachedFeed), now: time.Now}
+}
+
+// get returns the feed cached under key, rendering it again once it has expired
+func (c *feedCache) get(key string, render func() string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if feed, ok := c.feeds[key]; ok && now.Before(feed.expires) {
+		return feed.body
+	}
+	for k, feed := range c.feeds {
+		if !now.Before(feed.expires) {
+			delete(c.feeds, k)
+		}
+	}
+
+	body := render()
+	c.feeds[key] = cachedFeed{body: body, expires: now.Add(c.ttl)}
+	return body
+}
@@ -3,55 +3,202 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/0xPuncker/cosmos-watcher/internal/chain"
 	"github.com/0xPuncker/cosmos-watcher/internal/config"
 	"github.com/0xPuncker/cosmos-watcher/internal/cron"
+	"github.com/0xPuncker/cosmos-watcher/internal/events"
 	"github.com/0xPuncker/cosmos-watcher/internal/notifications"
+	"github.com/0xPuncker/cosmos-watcher/pkg/calendar"
 	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/0xPuncker/cosmos-watcher/pkg/version"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
 type Handler struct {
-	registry       *chain.ChainRegistry
+	registry       Registry
 	logger         *logrus.Logger
 	config         *config.Config
 	Scheduler      *cron.Scheduler
 	upgradeChecker *cron.UpgradeChecker
+	// sseLimiter caps concurrent event stream subscribers
+	sseLimiter *connectionLimiter
+	// wsLimiter caps concurrent WebSocket clients
+	wsLimiter *connectionLimiter
+	// events carries the upgrade changes the checker detects and the job runs to the event streams
+	events         *events.Bus
+	sseKeepalive   time.Duration
+	wsPingInterval time.Duration
+	// upgradesCoalescer shares GetUpgrades fan-outs between identical requests
+	upgradesCoalescer *upgradesCoalescer
+	// notificationHistory holds the recently sent notifications, nil when disabled
+	notificationHistory *notifications.NotificationHistory
+	// autoDiscoverJob monitors the chains with a pending Polkachu upgrade
+	autoDiscoverJob *cron.AutoDiscoverJob
+	// refreshLimiter rate-limits the forced refreshes of ?refresh=true per client
+	refreshLimiter *refreshLimiter
+	// icsFeeds caches the rendered iCalendar feeds for a minute
+	icsFeeds *feedCache
+	// chainsLoaded is set once the initial chain load completed, see SetChainsLoaded
+	chainsLoaded atomic.Bool
+	// gatherer collects the client_golang metrics served on /metrics, the registry's among them
+	gatherer prometheus.Gatherer
 }
 
 type ChainUpgrade struct {
-	Name             string `json:"name"`
-	Network          string `json:"network"`
-	Version          string `json:"version"`
-	Height           int64  `json:"height,omitempty"`
-	EstimatedAt      string `json:"estimated_at,omitempty"`
-	Guide            string `json:"guide,omitempty"`
-	ProposalLink     string `json:"proposal_link,omitempty"`
-	BlockLink        string `json:"block_link,omitempty"`
-	CosmovisorFolder string `json:"cosmovisor_folder,omitempty"`
-	GitHash          string `json:"git_hash,omitempty"`
-	Repo             string `json:"repo,omitempty"`
-	RPC              string `json:"rpc,omitempty"`
-	API              string `json:"api,omitempty"`
+	Name             string           `json:"name"`
+	Network          string           `json:"network"`
+	Version          string           `json:"version"`
+	Height           int64            `json:"height,omitempty"`
+	EstimatedAt      string           `json:"estimated_at,omitempty"`
+	Guide            string           `json:"guide,omitempty"`
+	ProposalLink     string           `json:"proposal_link,omitempty"`
+	BlockLink        string           `json:"block_link,omitempty"`
+	CosmovisorFolder string           `json:"cosmovisor_folder,omitempty"`
+	GitHash          string           `json:"git_hash,omitempty"`
+	Repo             string           `json:"repo,omitempty"`
+	RPC              string           `json:"rpc,omitempty"`
+	API              string           `json:"api,omitempty"`
+	Status           string           `json:"status,omitempty"`
+	CurrentVersion   string           `json:"current_version,omitempty"`
+	VoteTally        *types.VoteTally `json:"vote_tally,omitempty"`
+	// Stale marks upgrades served from the last snapshot while no fresh data is available
+	Stale bool `json:"stale,omitempty"`
+	// Source names the upgrade provider that reported the upgrade and Sources every provider whose
+	// data was merged into it
+	Source  string   `json:"source,omitempty"`
+	Sources []string `json:"sources,omitempty"`
+	// ChangeType classifies the upgrade against the current version: major, minor, patch, none, or
+	// unknown when either is not semver
+	ChangeType string `json:"change_type,omitempty"`
+
+	// estimatedTime is the time of EstimatedAt, which upgrades are sorted by
+	estimatedTime time.Time
+}
+
+type StatsResponse struct {
+	MonitoredChains int                 `json:"monitored_chains"`
+	ActiveSnoozes   []cron.SnoozeStatus `json:"active_snoozes"`
+	// ChainErrors holds the latest unresolved fetch error of each chain, explaining stale data
+	ChainErrors []chain.ChainError `json:"chain_errors"`
+}
+
+// ChainInfoResponse is the chain info with the version operators should run lifted to the top level
+// and the live height of the chain in place of the last recorded one
+type ChainInfoResponse struct {
+	*chain.ChainInfo
+	RecommendedVersion string    `json:"recommended_version,omitempty"`
+	Height             int64     `json:"height,omitempty"`
+	LatestBlockTime    time.Time `json:"latest_block_time,omitzero"`
+}
+
+// MonitoredChain is a monitored chain and whether it is configured in chains.yaml ("config") or
+// monitored for its pending Polkachu upgrade ("auto")
+type MonitoredChain struct {
+	Name    string `json:"name"`
+	Network string `json:"network"`
+	Source  string `json:"source"`
+}
+
+type ChainsResponse struct {
+	Chains []MonitoredChain `json:"chains"`
+}
+
+// AddChainRequest is the body of POST /chains. Network defaults to the network of the chain-registry
+// directory the chain is found in and DisplayName to its pretty name. Persist also appends the chain
+// to chains.yaml, without it the chain is monitored until chains.yaml is next loaded.
+type AddChainRequest struct {
+	Name        string `json:"name"`
+	Network     string `json:"network"`
+	DisplayName string `json:"display_name"`
+	Persist     bool   `json:"persist"`
+}
+
+// AddJobRequest is the body of POST /jobs. Task is one of the scheduler's registered tasks and Schedule
+// a cron expression with an optional seconds field. Enabled defaults to true.
+type AddJobRequest struct {
+	Name         string `json:"name"`
+	Schedule     string `json:"schedule"`
+	Task         string `json:"task"`
+	Description  string `json:"description"`
+	Enabled      *bool  `json:"enabled,omitempty"`
+	AllowOverlap bool   `json:"allow_overlap,omitempty"`
+}
+
+// JobRemovedResponse is returned for a job removed through the API
+type JobRemovedResponse struct {
+	Job    string `json:"job"`
+	Origin string `json:"origin"`
+	Status string `json:"status"`
 }
 
+// defaultJobHistoryLimit is how many runs GET /jobs/{name}/history returns without ?limit=
+const defaultJobHistoryLimit = 20
+
+// JobHistoryResponse lists the latest runs of a job, newest first
+type JobHistoryResponse struct {
+	Job  string        `json:"job"`
+	Runs []cron.JobRun `json:"runs"`
+}
+
+// JobStatusResponse is the state of a job
+type JobStatusResponse struct {
+	Name        string `json:"name"`
+	Enabled     bool   `json:"enabled"`
+	Description string `json:"description"`
+}
+
+// JobRunResponse is returned for a job run triggered through the API. The run's job events carry the
+// same run ID.
+type JobRunResponse struct {
+	Job   string `json:"job"`
+	RunID string `json:"run_id"`
+}
+
+// ChainNotFoundResponse is returned for a chain to add that is in none of the chain-registry
+// directories it was looked up in
+type ChainNotFoundResponse struct {
+	Error      string   `json:"error"`
+	Variations []string `json:"variations"`
+}
+
+// PeersResponse lists the chain's seeds and persistent peers as id@address strings
+type PeersResponse struct {
+	Chain           string   `json:"chain"`
+	Seeds           []string `json:"seeds"`
+	PersistentPeers []string `json:"persistent_peers"`
+}
+
+// UpgradesResponse is a page of the upgrades. Total counts the upgrades of every page, Limit is the
+// page size (0 without a limit) and Offset the position of the page's first upgrade.
 type UpgradesResponse struct {
 	Chains      []ChainUpgrade `json:"chains"`
 	LastUpdated time.Time      `json:"last_updated"`
+	Total       int            `json:"total"`
+	Limit       int            `json:"limit"`
+	Offset      int            `json:"offset"`
 }
 
-func NewHandler(registry *chain.ChainRegistry, logger *logrus.Logger, cfg *config.Config) *Handler {
+func NewHandler(registry Registry, logger *logrus.Logger, cfg *config.Config) *Handler {
 	scheduler := cron.NewScheduler(logger, types.JobConfig{
 		MaxConcurrent: cfg.Jobs.MaxConcurrent,
 		Predefined:    cfg.Jobs.Predefined,
+		HistorySize:   cfg.Jobs.HistorySize,
 	})
+	bus := events.NewBus(events.DefaultBufferSize)
+	scheduler.SetEventBus(bus)
 
 	slack, err := notifications.NewSlackService(logger)
 	if err != nil {
@@ -59,6 +206,21 @@ func NewHandler(registry *chain.ChainRegistry, logger *logrus.Logger, cfg *confi
 	}
 
 	upgradeChecker := cron.NewUpgradeChecker(registry, logger, slack)
+	upgradeChecker.SetNotificationsConfig(cfg.Notifications)
+	upgradeChecker.SetDedupHeightTolerance(cfg.Upgrades.DedupHeightTolerance)
+	upgradeChecker.SetGroups(cfg.Groups)
+	upgradeChecker.SetEventBus(bus)
+	if cfg.Notifications.DeadLetterPath != "" {
+		upgradeChecker.SetDeadLetterStore(notifications.NewDeadLetterStore(cfg.Notifications.DeadLetterPath))
+	}
+	var history *notifications.NotificationHistory
+	if cfg.Notifications.HistorySize >= 0 {
+		history, err = notifications.NewNotificationHistory(cfg.Notifications.HistorySize, cfg.Notifications.HistoryPath)
+		if err != nil {
+			logger.Warnf("Failed to load notification history, starting empty: %v", err)
+		}
+		upgradeChecker.SetNotificationHistory(history)
+	}
 
 	scheduler.RegisterTask("check-upgrades", func() error {
 		start := time.Now()
@@ -82,30 +244,211 @@ func NewHandler(registry *chain.ChainRegistry, logger *logrus.Logger, cfg *confi
 	loadChainsJob := cron.NewLoadChainsJob(registry, logger)
 	scheduler.RegisterTask("load-chains", loadChainsJob.Run)
 
+	autoDiscoverJob := cron.NewAutoDiscoverJob(registry, logger)
+	scheduler.RegisterTask("auto-discover", autoDiscoverJob.Run)
+
+	scheduler.RegisterTask("refresh-all", func() error {
+		report, err := registry.RefreshAll(context.Background())
+		if err != nil {
+			return err
+		}
+		logger.WithFields(logrus.Fields{
+			"task":      "refresh-all",
+			"refreshed": report.Refreshed,
+			"failed":    report.Failed,
+			"duration":  (time.Duration(report.DurationMS) * time.Millisecond).String(),
+		}).Info("Refreshed monitored chains")
+		if report.Failed > 0 {
+			return fmt.Errorf("%d of %d chains failed to refresh", report.Failed, len(report.Chains))
+		}
+		return nil
+	})
+
 	if err := scheduler.LoadPredefinedJobs(cfg.Jobs.Predefined); err != nil {
 		logger.Fatalf("Failed to load predefined jobs: %v", err)
 	}
 
+	var coalesceWindow time.Duration
+	if value := cfg.Upgrades.CoalesceWindow; value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			logger.Warnf("Invalid coalesce_window %q, only concurrent requests are coalesced", value)
+		} else {
+			coalesceWindow = d
+		}
+	}
+
 	return &Handler{
-		registry:       registry,
-		logger:         logger,
-		config:         cfg,
-		Scheduler:      scheduler,
-		upgradeChecker: upgradeChecker,
+		registry:            registry,
+		logger:              logger,
+		config:              cfg,
+		Scheduler:           scheduler,
+		upgradeChecker:      upgradeChecker,
+		sseLimiter:          newConnectionLimiter(cfg.Server.MaxSSESubscribers),
+		wsLimiter:           newConnectionLimiter(cfg.Server.MaxWebSocketClients),
+		events:              bus,
+		sseKeepalive:        sseKeepaliveInterval,
+		wsPingInterval:      wsPingInterval,
+		upgradesCoalescer:   newUpgradesCoalescer(coalesceWindow),
+		notificationHistory: history,
+		autoDiscoverJob:     autoDiscoverJob,
+		refreshLimiter:      newRefreshLimiter(defaultRefreshInterval),
+		icsFeeds:            newFeedCache(icsCacheTTL),
+		gatherer:            prometheus.DefaultGatherer,
 	}
 }
 
-func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+// ReplayDeadLetters re-sends notifications left undelivered by the previous run, within the
+// configured age and count limits
+func (h *Handler) ReplayDeadLetters() {
+	maxAge := notifications.DefaultDeadLetterMaxAge
+	if value := h.config.Notifications.DeadLetterMaxAge; value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			h.logger.Warnf("Invalid dead_letter_max_age %q, falling back to %s", value, maxAge)
+		} else {
+			maxAge = d
+		}
+	}
+	h.upgradeChecker.ReplayDeadLetters(maxAge, h.config.Notifications.DeadLetterReplayLimit)
+}
+
+// RunNotificationBatches sends batched upgrade notifications at the end of every
+// notifications.batch_window until shutdown; it returns right away when batching is off
+func (h *Handler) RunNotificationBatches() {
+	h.upgradeChecker.RunBatches()
+}
+
+// NotifyChainStall sends the Slack message about a stalled or resumed chain reported by the poller
+func (h *Handler) NotifyChainStall(event *types.ChainStallEvent) {
+	h.upgradeChecker.NotifyChainStall(event)
+}
+
+// RunAutoDiscovery monitors the chains Polkachu lists a pending upgrade for, checking the list every
+// monitoring.auto_discover_interval until the handler is shut down. It returns right away unless
+// monitoring.auto_discover is enabled.
+func (h *Handler) RunAutoDiscovery() {
+	if !h.config.Monitoring.AutoDiscover {
+		return
+	}
+	// Load has already rejected invalid values
+	interval, _ := h.config.Monitoring.DiscoverInterval()
+	if interval <= 0 {
+		interval = cron.DefaultAutoDiscoverInterval
+	}
+	h.autoDiscoverJob.RunEvery(interval)
+}
+
+// Shutdown stops the scheduler, letting running jobs drain until ctx is done. Notification sends
+// still in flight at that point are cancelled, and the event streams are ended.
+func (h *Handler) Shutdown(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		h.Scheduler.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		h.logger.Warn("Shutdown deadline reached, cancelling in-flight notifications")
+	}
+
+	h.upgradeChecker.Stop()
+	h.autoDiscoverJob.Stop()
+	h.events.Close()
+	<-stopped
+}
+
+// ReadinessResponse tells whether the service is ready for traffic and which of the preconditions are
+// still pending
+type ReadinessResponse struct {
+	Ready  bool            `json:"ready"`
+	Checks ReadinessChecks `json:"checks"`
+}
+
+type ReadinessChecks struct {
+	ChainsLoaded     bool `json:"chains_loaded"`
+	SchedulerRunning bool `json:"scheduler_running"`
+	UpstreamFetched  bool `json:"upstream_fetched"`
+}
+
+// SetChainsLoaded records that the initial chain load completed, one of the preconditions of
+// readiness
+func (h *Handler) SetChainsLoaded() {
+	h.chainsLoaded.Store(true)
+}
+
+// ReadinessCheck answers 200 once the initial chain load completed, the scheduler is running and
+// chain info was fetched from upstream at least once, and 503 before, so that no traffic is routed to
+// an instance that would serve empty upgrade lists. HealthCheck is the liveness check.
+func (h *Handler) ReadinessCheck(w http.ResponseWriter, r *http.Request) {
+	checks := ReadinessChecks{
+		ChainsLoaded:     h.chainsLoaded.Load(),
+		SchedulerRunning: h.Scheduler.IsRunning(),
+		UpstreamFetched:  !h.registry.LastChainInfoFetch().IsZero(),
+	}
+	response := ReadinessResponse{
+		Ready:  checks.ChainsLoaded && checks.SchedulerRunning && checks.UpstreamFetched,
+		Checks: checks,
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
+	if !response.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
 		"status": "ok",
-	})
+	}
+	if status := h.registry.DegradedStatus(); status.Degraded {
+		response["status"] = "degraded"
+		response["degraded"] = status
+	}
+	if status := h.registry.MaintenanceStatus(); status.Enabled {
+		response["status"] = "maintenance"
+		response["maintenance"] = status
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) EnableDegradedMode(w http.ResponseWriter, r *http.Request) {
+	h.registry.SetDegraded(true, r.URL.Query().Get("reason"))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.registry.DegradedStatus())
+}
+
+func (h *Handler) DisableDegradedMode(w http.ResponseWriter, r *http.Request) {
+	h.registry.SetDegraded(false, "")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.registry.DegradedStatus())
+}
+
+// SetMaintenance pauses or resumes all monitoring and notifications, e.g.
+// POST /maintenance?enabled=true&reason=node+migration
+func (h *Handler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+	if err != nil {
+		h.handleError(w, r, fmt.Errorf("enabled must be true or false"), http.StatusBadRequest)
+		return
+	}
+	h.registry.SetMaintenance(enabled, r.URL.Query().Get("reason"))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.registry.MaintenanceStatus())
 }
 
 func (h *Handler) GetMainnetUpgrades(w http.ResponseWriter, r *http.Request) {
-	upgrades, err := h.registry.GetUpgrades("mainnet")
+	upgrades, err := h.registry.GetUpgrades(r.Context(), "mainnet")
 	if err != nil {
-		h.handleError(w, err, http.StatusInternalServerError)
+		h.handleError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -114,9 +457,9 @@ func (h *Handler) GetMainnetUpgrades(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetTestnetUpgrades(w http.ResponseWriter, r *http.Request) {
-	upgrades, err := h.registry.GetUpgrades("testnet")
+	upgrades, err := h.registry.GetUpgrades(r.Context(), "testnet")
 	if err != nil {
-		h.handleError(w, err, http.StatusInternalServerError)
+		h.handleError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -124,18 +467,462 @@ func (h *Handler) GetTestnetUpgrades(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(upgrades)
 }
 
+// GetGroupUpgradesICS serves an iCalendar feed of the upcoming upgrades of a group's chains, optionally
+// limited to one network
+func (h *Handler) GetGroupUpgradesICS(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	group := vars["group"]
+	if _, ok := h.config.Groups[group]; !ok {
+		h.handleError(w, r, fmt.Errorf("group %q not found", group), http.StatusNotFound)
+		return
+	}
+
+	chains, err := h.registry.GetMonitoredChains()
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	var inGroup []string
+	for _, name := range chains {
+		if h.config.Groups.Contains(group, name) {
+			inGroup = append(inGroup, name)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write([]byte(h.renderICS(r, inGroup, vars["network"])))
+}
+
+// GetUpgradesICS serves an iCalendar feed of the upcoming upgrades of every monitored chain, for
+// subscribing from a calendar client
+func (h *Handler) GetUpgradesICS(w http.ResponseWriter, r *http.Request) {
+	chains, err := h.registry.GetMonitoredChains()
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write([]byte(h.renderICS(r, chains, "")))
+}
+
+// renderICS returns the iCalendar feed of the upcoming upgrades of chains on network, cached under the
+// request path. The render outlives a client that disconnects, since its result is shared.
+func (h *Handler) renderICS(r *http.Request, chains []string, network string) string {
+	return h.icsFeeds.get(r.URL.Path, func() string {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 30*time.Second)
+		defer cancel()
+		return calendar.GenerateICS(h.upcomingUpgrades(ctx, chains, network))
+	})
+}
+
+// upcomingUpgrades returns the upgrades of chains that have not happened yet, sorted by time. An empty
+// network includes all networks.
+func (h *Handler) upcomingUpgrades(ctx context.Context, chains []string, network string) []*types.UpgradeInfo {
+	now := time.Now()
+	var upgrades []*types.UpgradeInfo
+	for _, name := range chains {
+		if network != "" {
+			info, err := h.registry.GetChainInfo(ctx, name, false)
+			if err != nil || info.Network != network {
+				continue
+			}
+		}
+
+		upgradeInfo, err := h.registry.GetUpgradeInfo(ctx, name, false)
+		if err != nil {
+			h.logger.Debugf("No upgrade info for %s: %v", name, err)
+			continue
+		}
+		if upgradeInfo.Time.Before(now) && h.registry.UpgradeStatus(name, upgradeInfo) == types.UpgradeStatusCompleted {
+			continue
+		}
+		upgrades = append(upgrades, upgradeInfo)
+	}
+
+	sort.Slice(upgrades, func(i, j int) bool {
+		return upgrades[i].Time.Before(upgrades[j].Time)
+	})
+	return upgrades
+}
+
 func (h *Handler) GetChainInfo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	chainName := vars["chainName"]
 
-	chainInfo, err := h.registry.GetChainInfo(chainName, false)
+	refresh, ok := h.refreshAllowed(w, r, chainName)
+	if !ok {
+		return
+	}
+	chainInfo, err := h.registry.GetChainInfo(r.Context(), chainName, refresh)
+	if err != nil {
+		h.handleError(w, r, err, registryErrorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	response := ChainInfoResponse{ChainInfo: chainInfo, RecommendedVersion: chainInfo.Codebase.RecommendedVersion}
+	// A chain whose nodes are unreachable is still described, just without its height
+	if block, err := h.registry.GetLatestBlock(r.Context(), chainName); err == nil {
+		response.Height, response.LatestBlockTime = block.Height, block.Time
+	} else {
+		h.logger.Debugf("Latest block of %s not available: %v", chainName, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetAssetList returns the denoms the chain publishes in its chain-registry assetlist.json
+func (h *Handler) GetAssetList(w http.ResponseWriter, r *http.Request) {
+	chainName := mux.Vars(r)["chainName"]
+
+	assetList, err := h.registry.GetAssetList(r.Context(), chainName)
+	if err != nil {
+		h.handleError(w, r, err, registryErrorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assetList)
+}
+
+// GetChainVersions returns the chain's version history from the chain-registry
+func (h *Handler) GetChainVersions(w http.ResponseWriter, r *http.Request) {
+	chainName := mux.Vars(r)["chainName"]
+
+	history, err := h.registry.GetVersions(r.Context(), chainName)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		h.handleError(w, r, err, registryErrorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// GetUpgradeBinaries returns the download URLs of the chain's pending upgrade by platform, an empty
+// object when the chain-registry lists none for its version
+func (h *Handler) GetUpgradeBinaries(w http.ResponseWriter, r *http.Request) {
+	chainName := mux.Vars(r)["chainName"]
+
+	binaries, err := h.registry.GetUpgradeBinaries(r.Context(), chainName)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		h.handleError(w, r, err, registryErrorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(binaries)
+}
+
+// GetChainEndpoints returns the latest health probe of the chain's RPC and REST endpoints, ranked
+func (h *Handler) GetChainEndpoints(w http.ResponseWriter, r *http.Request) {
+	chainName := mux.Vars(r)["chainName"]
+
+	report, err := h.registry.GetEndpointReport(r.Context(), chainName)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		h.handleError(w, r, err, registryErrorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetChainPeers returns the seeds and persistent peers from the chain's chain.json. With ?format=config
+// they are rendered as the p2p lines of config.toml.
+func (h *Handler) GetChainPeers(w http.ResponseWriter, r *http.Request) {
+	chainName := mux.Vars(r)["chainName"]
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "config" {
+		h.handleError(w, r, fmt.Errorf("format must be json or config"), http.StatusBadRequest)
+		return
+	}
+
+	chainInfo, err := h.registry.GetChainInfo(r.Context(), chainName, false)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		h.handleError(w, r, err, registryErrorStatus(err, http.StatusNotFound))
+		return
+	}
+
+	response := PeersResponse{
+		Chain:           chainName,
+		Seeds:           chain.PeerAddresses(chainInfo.Peers.Seeds),
+		PersistentPeers: chain.PeerAddresses(chainInfo.Peers.PersistentPeers),
+	}
+
+	if format == "config" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(w, "seeds = %q\npersistent_peers = %q\n",
+			strings.Join(response.Seeds, ","), strings.Join(response.PersistentPeers, ","))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListChains returns the monitored chains, configured ones first, with where each comes from
+func (h *Handler) ListChains(w http.ResponseWriter, r *http.Request) {
+	chains, err := h.registry.GetMonitoredChains()
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := ChainsResponse{Chains: make([]MonitoredChain, 0, len(chains))}
+	for _, name := range chains {
+		response.Chains = append(response.Chains, MonitoredChain{
+			Name:    name,
+			Network: h.chainConfig(r.Context(), name).Network,
+			Source:  h.registry.ChainSource(name),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AddChain starts monitoring a chain of the chain-registry without restarting the watcher, returning
+// its chain info. The chain is prefetched before being added, so it is served from the cache right away.
+func (h *Handler) AddChain(w http.ResponseWriter, r *http.Request) {
+	var request AddChainRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&request); err != nil {
+		h.handleError(w, r, fmt.Errorf("invalid request body: %w", err), http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		h.handleError(w, r, fmt.Errorf("name is required"), http.StatusBadRequest)
+		return
+	}
+	if request.Network != "" && request.Network != "mainnet" && request.Network != "testnet" {
+		h.handleError(w, r, fmt.Errorf("network must be mainnet or testnet"), http.StatusBadRequest)
+		return
+	}
+	if h.registry.ChainSource(name) == chain.ChainSourceConfig {
+		h.handleError(w, r, fmt.Errorf("chain %q is already monitored", name), http.StatusConflict)
+		return
+	}
+
+	if !h.registry.ChainExists(r.Context(), name) {
+		if r.Context().Err() != nil {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ChainNotFoundResponse{
+			Error:      fmt.Sprintf("chain %q not found in the chain-registry", name),
+			Variations: h.registry.RegistryPaths(name),
+		})
+		return
+	}
+	info, err := h.registry.GetChainInfo(r.Context(), name, true)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		h.handleError(w, r, err, registryErrorStatus(err, http.StatusBadGateway))
+		return
+	}
+	if request.Network != "" && info.Network != "" && request.Network != info.Network {
+		h.handleError(w, r, fmt.Errorf("chain %q is a %s in the chain-registry; add it to chains.yaml with a registry_name to pick its %s directory",
+			name, info.Network, request.Network), http.StatusUnprocessableEntity)
+		return
+	}
+
+	entry := config.Chain{Name: name, DisplayName: request.DisplayName, Network: request.Network}
+	if entry.DisplayName == "" {
+		entry.DisplayName = info.PrettyName
+	}
+	if entry.Network == "" {
+		entry.Network = info.Network
+	}
+	// Saved first so a chain that cannot be persisted is not monitored either
+	if request.Persist {
+		if err := config.SaveChain(entry); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, config.ErrChainListed) {
+				status = http.StatusConflict
+			}
+			h.handleError(w, r, err, status)
+			return
+		}
+	}
+	added, err := h.registry.AddMonitoredChain(*entry.ToChainConfig())
 	if err != nil {
-		h.handleError(w, err, http.StatusNotFound)
+		h.handleError(w, r, err, http.StatusInternalServerError)
 		return
 	}
+	if !added {
+		h.handleError(w, r, fmt.Errorf("chain %q is already monitored", name), http.StatusConflict)
+		return
+	}
+	h.logger.WithFields(logrus.Fields{
+		"chain":     name,
+		"network":   entry.Network,
+		"persisted": request.Persist,
+	}).Info("Added chain to monitoring")
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(chainInfo)
+	w.Header().Set("Location", "/api/v1/chains/"+name)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(info)
+}
+
+// chainConfig returns the chains.yaml entry of the chain, or one made from its chain info for chains
+// without an entry
+func (h *Handler) chainConfig(ctx context.Context, name string) types.ChainConfig {
+	if chainConfig, ok := h.registry.GetChainConfig(name); ok {
+		return chainConfig
+	}
+	chainConfig := types.ChainConfig{Name: name, Network: "mainnet"}
+	if info, err := h.registry.GetChainInfo(ctx, name, false); err == nil && info.Network != "" {
+		chainConfig.Network = info.Network
+	}
+	return chainConfig
+}
+
+// ExportChains serializes the currently monitored chains in chains.yaml format. Auto-discovered chains
+// are left out, they are only monitored until their upgrade completes.
+func (h *Handler) ExportChains(w http.ResponseWriter, r *http.Request) {
+	chains, err := h.registry.GetMonitoredChains()
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	var export types.ChainsConfig
+	for _, name := range chains {
+		if h.registry.ChainSource(name) == chain.ChainSourceAuto {
+			continue
+		}
+		chainConfig := h.chainConfig(r.Context(), name)
+
+		if chainConfig.Network == "testnet" {
+			export.Testnet = append(export.Testnet, chainConfig)
+		} else {
+			export.Mainnet = append(export.Mainnet, chainConfig)
+		}
+	}
+
+	data, err := yaml.Marshal(export)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Content-Disposition", `attachment; filename="chains.yaml"`)
+	w.Write(data)
+}
+
+// GetRegistryChains lists the chains published in the chain-registry, which chains.yaml can pick from
+func (h *Handler) GetRegistryChains(w http.ResponseWriter, r *http.Request) {
+	discovered, err := h.registry.DiscoverChains(r.Context())
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		h.handleError(w, r, err, registryErrorStatus(err, http.StatusBadGateway))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(discovered)
+}
+
+// RefreshRegistry force-refreshes the chain and upgrade info of every monitored chain and returns how
+// each went. Chains that failed are listed in the report, the response is a 200 all the same.
+func (h *Handler) RefreshRegistry(w http.ResponseWriter, r *http.Request) {
+	report, err := h.registry.RefreshAll(r.Context())
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		h.handleError(w, r, err, registryErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// SnoozeChain suppresses notifications for a monitored chain for the duration given in the query
+func (h *Handler) SnoozeChain(w http.ResponseWriter, r *http.Request) {
+	chainName := mux.Vars(r)["chainName"]
+
+	duration, err := time.ParseDuration(r.URL.Query().Get("duration"))
+	if err != nil || duration <= 0 {
+		h.handleError(w, r, fmt.Errorf("duration must be a positive duration such as 4h"), http.StatusBadRequest)
+		return
+	}
+
+	if !h.isMonitored(chainName) {
+		h.handleError(w, r, fmt.Errorf("chain %q is not monitored", chainName), http.StatusNotFound)
+		return
+	}
+
+	until := h.upgradeChecker.Snooze(chainName, duration)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cron.SnoozeStatus{Chain: chainName, Until: until})
+}
+
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	chains, err := h.registry.GetMonitoredChains()
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(StatsResponse{
+		MonitoredChains: len(chains),
+		ActiveSnoozes:   h.upgradeChecker.ActiveSnoozes(),
+		ChainErrors:     h.registry.LastErrors(),
+	})
+}
+
+// GetRecentNotifications lists the most recently sent notifications, newest first
+func (h *Handler) GetRecentNotifications(w http.ResponseWriter, r *http.Request) {
+	recent := []notifications.SentNotification{}
+	if h.notificationHistory != nil {
+		recent = h.notificationHistory.Recent()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"notifications": recent,
+		"count":         len(recent),
+	})
+}
+
+func (h *Handler) isMonitored(chainName string) bool {
+	chains, err := h.registry.GetMonitoredChains()
+	if err != nil {
+		return false
+	}
+	for _, name := range chains {
+		if name == chainName {
+			return true
+		}
+	}
+	return false
 }
 
 func (h *Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
@@ -149,25 +936,181 @@ func (h *Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) GetJobStatus(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	jobName := vars["name"]
+	h.writeJobStatus(w, r, vars["name"])
+}
+
+// AddJob schedules a new job without restarting the watcher, answering 201 with its state. Added jobs
+// are kept in memory only.
+func (h *Handler) AddJob(w http.ResponseWriter, r *http.Request) {
+	var request AddJobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&request); err != nil {
+		h.handleError(w, r, fmt.Errorf("invalid request body: %w", err), http.StatusBadRequest)
+		return
+	}
+	job := types.Job{
+		Name:         strings.TrimSpace(request.Name),
+		Schedule:     strings.TrimSpace(request.Schedule),
+		TaskName:     request.Task,
+		Enabled:      request.Enabled == nil || *request.Enabled,
+		Description:  request.Description,
+		AllowOverlap: request.AllowOverlap,
+	}
+
+	if err := h.Scheduler.AddJob(job); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, cron.ErrInvalidJob):
+			status = http.StatusBadRequest
+		case errors.Is(err, cron.ErrJobExists):
+			status = http.StatusConflict
+		}
+		h.handleError(w, r, err, status)
+		return
+	}
+	state, err := h.Scheduler.JobState(job.Name)
+	if err != nil {
+		// Removed in the meantime
+		h.handleError(w, r, err, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/jobs/"+job.Name)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(state)
+}
+
+// RemoveJob removes a job from the schedule. Jobs of jobs.predefined are only removed with
+// ?force=true, until the configuration is next loaded.
+func (h *Handler) RemoveJob(w http.ResponseWriter, r *http.Request) {
+	jobName := mux.Vars(r)["name"]
+
+	force := false
+	if value := r.URL.Query().Get("force"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			h.handleError(w, r, fmt.Errorf("invalid force %q, expected true or false", value), http.StatusBadRequest)
+			return
+		}
+		force = parsed
+	}
 
+	state, err := h.Scheduler.JobState(jobName)
+	if err == nil {
+		err = h.Scheduler.RemoveJob(jobName, force)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, cron.ErrJobNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, cron.ErrPredefinedJob):
+			status = http.StatusForbidden
+		}
+		h.handleError(w, r, err, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(JobRemovedResponse{Job: jobName, Origin: state.Origin, Status: "removed"})
+}
+
+// GetJobHistory returns the latest runs of a job, newest first, up to ?limit= (default 20)
+func (h *Handler) GetJobHistory(w http.ResponseWriter, r *http.Request) {
+	jobName := mux.Vars(r)["name"]
+
+	limit := defaultJobHistoryLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			h.handleError(w, r, fmt.Errorf("invalid limit %q, expected a positive integer", value), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	runs, err := h.Scheduler.JobHistory(jobName, limit)
+	if err != nil {
+		h.handleError(w, r, err, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(JobHistoryResponse{Job: jobName, Runs: runs})
+}
+
+// EnableJob schedules a disabled job again, answering with its status
+func (h *Handler) EnableJob(w http.ResponseWriter, r *http.Request) {
+	jobName := mux.Vars(r)["name"]
+
+	if err := h.Scheduler.EnableJob(jobName); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, cron.ErrJobNotFound) {
+			status = http.StatusNotFound
+		}
+		h.handleError(w, r, err, status)
+		return
+	}
+	h.writeJobStatus(w, r, jobName)
+}
+
+// DisableJob stops scheduling a job until it is enabled again, answering with its status
+func (h *Handler) DisableJob(w http.ResponseWriter, r *http.Request) {
+	jobName := mux.Vars(r)["name"]
+
+	if err := h.Scheduler.DisableJob(jobName); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, cron.ErrJobNotFound) {
+			status = http.StatusNotFound
+		}
+		h.handleError(w, r, err, status)
+		return
+	}
+	h.writeJobStatus(w, r, jobName)
+}
+
+func (h *Handler) writeJobStatus(w http.ResponseWriter, r *http.Request, jobName string) {
 	enabled, description, err := h.Scheduler.GetJobStatus(jobName)
 	if err != nil {
-		h.handleError(w, err, http.StatusNotFound)
+		h.handleError(w, r, err, http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"name":        jobName,
-		"enabled":     enabled,
-		"description": description,
+	json.NewEncoder(w).Encode(JobStatusResponse{
+		Name:        jobName,
+		Enabled:     enabled,
+		Description: description,
 	})
 }
 
+// RunJob triggers a run of a job right away, answering 202 with the run's ID while it runs
+func (h *Handler) RunJob(w http.ResponseWriter, r *http.Request) {
+	jobName := mux.Vars(r)["name"]
+
+	runID, err := h.Scheduler.RunJobNow(jobName)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, cron.ErrJobNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, cron.ErrJobRunning), errors.Is(err, cron.ErrJobDisabled):
+			status = http.StatusConflict
+		case errors.Is(err, cron.ErrMaxConcurrent):
+			status = http.StatusServiceUnavailable
+		}
+		h.handleError(w, r, err, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(JobRunResponse{Job: jobName, RunID: runID})
+}
+
 func (h *Handler) StartScheduler(w http.ResponseWriter, r *http.Request) {
 	if err := h.Scheduler.Start(); err != nil {
-		h.handleError(w, err, http.StatusInternalServerError)
+		h.handleError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -186,10 +1129,6 @@ func (h *Handler) StopScheduler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetUpgrades(w http.ResponseWriter, r *http.Request) {
-
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
-
 	chains, err := h.registry.GetMonitoredChains()
 	if err != nil {
 		h.logger.Errorf("Failed to get monitored chains: %v", err)
@@ -197,8 +1136,105 @@ func (h *Handler) GetUpgrades(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if group := r.URL.Query().Get("group"); group != "" {
+		if _, ok := h.config.Groups[group]; !ok {
+			h.handleError(w, r, fmt.Errorf("group %q not found", group), http.StatusNotFound)
+			return
+		}
+		var inGroup []string
+		for _, name := range chains {
+			if h.config.Groups.Contains(group, name) {
+				inGroup = append(inGroup, name)
+			}
+		}
+		chains = inGroup
+	}
+
+	// Filtered before the fan-out so chains of the other network are not fetched
+	switch network := r.URL.Query().Get("network"); network {
+	case "", "all":
+	case "mainnet", "testnet":
+		var onNetwork []string
+		for _, name := range chains {
+			if h.chainConfig(r.Context(), name).Network == network {
+				onNetwork = append(onNetwork, name)
+			}
+		}
+		chains = onNetwork
+	default:
+		h.handleError(w, r, fmt.Errorf("invalid network %q, expected mainnet, testnet or all", network), http.StatusBadRequest)
+		return
+	}
+
+	var hasGuide *bool
+	if value := r.URL.Query().Get("has_guide"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			h.handleError(w, r, fmt.Errorf("invalid has_guide %q, expected true or false", value), http.StatusBadRequest)
+			return
+		}
+		hasGuide = &parsed
+	}
+
+	var includeCompleted bool
+	if value := r.URL.Query().Get("include_completed"); value != "" {
+		includeCompleted, err = strconv.ParseBool(value)
+		if err != nil {
+			h.handleError(w, r, fmt.Errorf("invalid include_completed %q, expected true or false", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := parseUpgradesPage(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	refresh, ok := h.refreshAllowed(w, r, "")
+	if !ok {
+		return
+	}
+
 	h.logger.Debugf("Found %d monitored chains", len(chains))
 
+	// Identical concurrent requests share one fan-out, which stops once every client waiting on it
+	// has disconnected
+	key := r.URL.Query()
+	for _, param := range upgradesPageParams {
+		key.Del(param)
+	}
+	collected, err := h.upgradesCoalescer.do(r.Context(), key.Encode(), refresh, func(ctx context.Context) (*UpgradesResponse, error) {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		return h.collectUpgrades(ctx, chains, hasGuide, includeCompleted, refresh)
+	})
+	if r.Context().Err() != nil {
+		h.logger.Debugf("Client disconnected before the upgrades were collected: %v", r.Context().Err())
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Request timeout while processing chains: %v", err)
+		http.Error(w, "Request timeout", http.StatusGatewayTimeout)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if err := json.NewEncoder(w).Encode(page.apply(collected)); err != nil {
+		h.logger.Errorf("Failed to encode response: %v", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	h.logRequestProcessed(r, http.StatusOK)
+}
+
+// collectUpgrades fetches the upgrade of every chain concurrently and returns them sorted by chain
+// name and network, leaving out completed upgrades unless includeCompleted is set. With refresh the
+// cached upgrades are bypassed. It fails only when ctx expires before all chains were processed.
+func (h *Handler) collectUpgrades(ctx context.Context, chains []string, hasGuide *bool, includeCompleted, refresh bool) (*UpgradesResponse, error) {
 	response := UpgradesResponse{
 		Chains:      make([]ChainUpgrade, 0),
 		LastUpdated: time.Now(),
@@ -211,45 +1247,74 @@ func (h *Handler) GetUpgrades(w http.ResponseWriter, r *http.Request) {
 	)
 
 	for _, chainName := range chains {
-		select {
-		case <-ctx.Done():
-			h.logger.Error("Request timeout while processing chains")
-			http.Error(w, "Request timeout", http.StatusGatewayTimeout)
-			return
-		default:
-			wg.Add(1)
-			go func(name string) {
-				defer wg.Done()
-				semaphore <- struct{}{}
-				defer func() { <-semaphore }()
-
-				upgradeInfo, err := h.registry.GetUpgradeInfo(name, false)
-				if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			upgradeInfo, err := h.registry.GetUpgradeInfo(ctx, name, refresh)
+			if err != nil {
+				if !errors.Is(err, chain.ErrNoUpgrade) {
 					h.logger.Debugf("Failed to get upgrade info for %s: %v", name, err)
-					return
 				}
+				return
+			}
+
+			if hasGuide != nil && (upgradeInfo.GetGuide() != "") != *hasGuide {
+				return
+			}
+			status := h.registry.UpgradeStatus(name, upgradeInfo)
+			if status == types.UpgradeStatusCompleted && !includeCompleted {
+				return
+			}
 
-				if upgradeInfo != nil {
-					mu.Lock()
-					response.Chains = append(response.Chains, ChainUpgrade{
-						Name:             upgradeInfo.GetChainName(),
-						Network:          upgradeInfo.GetNetwork(),
-						Version:          upgradeInfo.GetVersion(),
-						Height:           upgradeInfo.GetHeight(),
-						EstimatedAt:      upgradeInfo.GetEstimatedUpgradeTime(),
-						Guide:            upgradeInfo.GetGuide(),
-						ProposalLink:     upgradeInfo.GetProposalLink(),
-						BlockLink:        upgradeInfo.GetBlockLink(),
-						CosmovisorFolder: upgradeInfo.GetCosmovisorFolder(),
-						GitHash:          upgradeInfo.GetGitHash(),
-						Repo:             upgradeInfo.GetRepo(),
-						RPC:              upgradeInfo.GetRPC(),
-						API:              upgradeInfo.GetAPI(),
-					})
-					mu.Unlock()
+			// The version a node reports beats the one of the chain.json the registry classified against
+			changeType := upgradeInfo.ChangeType
+			currentVersion, err := h.registry.GetNodeVersion(ctx, name)
+			if err != nil {
+				h.logger.Debugf("Current version not available for %s: %v", name, err)
+			} else if currentVersion != "" {
+				changeType = string(version.Classify(currentVersion, upgradeInfo.GetVersion()))
+			}
+
+			var tally *types.VoteTally
+			if proposal := upgradeInfo.GetProposalLink(); h.config.Upgrades.IncludeVoteTally && proposal != "" {
+				tally, err = h.registry.GetVoteTally(ctx, name, proposal)
+				if err != nil {
+					h.logger.Debugf("Vote tally not available for %s: %v", name, err)
 				}
-			}(chainName)
-		}
+			}
+
+			mu.Lock()
+			response.Chains = append(response.Chains, ChainUpgrade{
+				Name:             upgradeInfo.GetChainName(),
+				Network:          upgradeInfo.GetNetwork(),
+				Version:          upgradeInfo.GetVersion(),
+				Height:           upgradeInfo.GetHeight(),
+				EstimatedAt:      upgradeInfo.GetEstimatedUpgradeTime(),
+				Guide:            upgradeInfo.GetGuide(),
+				ProposalLink:     upgradeInfo.GetProposalLink(),
+				BlockLink:        upgradeInfo.GetBlockLink(),
+				CosmovisorFolder: upgradeInfo.GetCosmovisorFolder(),
+				GitHash:          upgradeInfo.GetGitHash(),
+				Repo:             upgradeInfo.GetRepo(),
+				RPC:              upgradeInfo.GetRPC(),
+				API:              upgradeInfo.GetAPI(),
+				Status:           status,
+				CurrentVersion:   currentVersion,
+				ChangeType:       changeType,
+				VoteTally:        tally,
+				Stale:            upgradeInfo.Stale,
+				Source:           upgradeInfo.Source,
+				Sources:          upgradeInfo.Sources,
+				estimatedTime:    upgradeInfo.Time,
+			})
+			mu.Unlock()
+		}(chainName)
 	}
 
 	done := make(chan struct{})
@@ -260,37 +1325,41 @@ func (h *Handler) GetUpgrades(w http.ResponseWriter, r *http.Request) {
 
 	select {
 	case <-ctx.Done():
-		h.logger.Error("Request timeout while waiting for chains to process")
-		http.Error(w, "Request timeout", http.StatusGatewayTimeout)
-		return
+		return nil, ctx.Err()
 	case <-done:
+	}
 
-		sort.Slice(response.Chains, func(i, j int) bool {
-			if response.Chains[i].Name == response.Chains[j].Name {
-				return response.Chains[i].Network < response.Chains[j].Network
-			}
-			return response.Chains[i].Name < response.Chains[j].Name
-		})
-
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Cache-Control", "no-cache")
-
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			h.logger.Errorf("Failed to encode response: %v", err)
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-			return
+	sort.Slice(response.Chains, func(i, j int) bool {
+		if response.Chains[i].Name == response.Chains[j].Name {
+			return response.Chains[i].Network < response.Chains[j].Network
 		}
+		return response.Chains[i].Name < response.Chains[j].Name
+	})
+	return &response, nil
+}
 
-		h.logRequestProcessed(r, http.StatusOK)
+// registryErrorStatus is the status answered for an error of the registry: 503 while degraded, 404 for
+// chains not in the chain-registry, 502 when the upstream could not be reached and fallback otherwise
+func registryErrorStatus(err error, fallback int) int {
+	var (
+		notFound *chain.ErrChainNotFound
+		network  *chain.ErrNetwork
+	)
+	switch {
+	case errors.Is(err, chain.ErrDegraded):
+		return http.StatusServiceUnavailable
+	case errors.As(err, &notFound):
+		return http.StatusNotFound
+	case errors.As(err, &network):
+		return http.StatusBadGateway
 	}
+	return fallback
 }
 
-func (h *Handler) handleError(w http.ResponseWriter, err error, code int) {
-	h.logger.Error(err)
+func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, err error, code int) {
+	h.logger.WithField("request_id", RequestIDFromContext(r.Context())).Error(err)
 	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(map[string]string{
-		"error": err.Error(),
-	})
+	json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 }
 
 func (h *Handler) logRequestProcessed(r *http.Request, status int) {
@@ -317,14 +1386,6 @@ func (h *Handler) logRequestProcessed(r *http.Request, status int) {
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	router := mux.NewRouter()
-	router.HandleFunc("/api/v1/health", h.HealthCheck).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/upgrades/mainnet", h.GetMainnetUpgrades).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/upgrades/testnet", h.GetTestnetUpgrades).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/chains/{chainName}", h.GetChainInfo).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/jobs", h.ListJobs).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/jobs/{name}", h.GetJobStatus).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/scheduler/start", h.StartScheduler).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/scheduler/stop", h.StopScheduler).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/upgrades", h.GetUpgrades).Methods(http.MethodGet)
+	SetupRoutes(router, h)
 	router.ServeHTTP(w, r)
 }
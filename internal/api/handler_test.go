@@ -1,31 +1,41 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/0xPuncker/cosmos-watcher/internal/chain"
 	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/0xPuncker/cosmos-watcher/internal/cron"
+	"github.com/0xPuncker/cosmos-watcher/internal/notifications"
+	"github.com/0xPuncker/cosmos-watcher/internal/testutil"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 const apiPath = "/api/v1"
 
 func TestHealthCheck(t *testing.T) {
 	logger := logrus.New()
-	logger.SetOutput(nil)
+	logger.SetOutput(io.Discard)
 
-	registry := chain.NewChainRegistry(
-		logger,
-		"https://api.github.com",
-		"/cosmos/chain-registry/master",
-	)
-
-	handler := NewHandler(registry, logger, &config.Config{})
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{})
 
 	req, err := http.NewRequest("GET", apiPath+"/health", nil)
 	if err != nil {
@@ -34,7 +44,7 @@ func TestHealthCheck(t *testing.T) {
 
 	rr := httptest.NewRecorder()
 	router := mux.NewRouter()
-	router.HandleFunc("/health", handler.HealthCheck)
+	router.HandleFunc(apiPath+"/health", handler.HealthCheck)
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
@@ -48,6 +58,45 @@ func TestHealthCheck(t *testing.T) {
 	assert.Equal(t, "ok", response["status"])
 }
 
+func TestReadinessCheck(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry()
+	handler := NewHandler(registry, logger, &config.Config{})
+	ready := func() (int, ReadinessResponse) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/ready", nil))
+		var response ReadinessResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		return rr.Code, response
+	}
+
+	status, response := ready()
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, ReadinessResponse{}, response)
+
+	handler.SetChainsLoaded()
+	require.NoError(t, handler.Scheduler.Start())
+	defer handler.Scheduler.Stop()
+	status, response = ready()
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, ReadinessChecks{ChainsLoaded: true, SchedulerRunning: true}, response.Checks, "no upstream fetch yet")
+
+	registry.LastFetch = time.Now()
+	status, response = ready()
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, ReadinessResponse{
+		Ready:  true,
+		Checks: ReadinessChecks{ChainsLoaded: true, SchedulerRunning: true, UpstreamFetched: true},
+	}, response)
+
+	// Liveness does not wait for any of it
+	rr := httptest.NewRecorder()
+	NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/health", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
 func TestGetChainInfo(t *testing.T) {
 	handler := setupTestHandler()
 
@@ -78,9 +127,36 @@ func TestGetChainInfo(t *testing.T) {
 	}
 }
 
+func TestGetChainInfoErrorStatus(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := testutil.NewFakeRegistry()
+	registry.ChainErrors["osmosis"] = fmt.Errorf("failed to fetch chain info for %q: %w", "osmosis",
+		&chain.ErrNetwork{URL: "https://raw.githubusercontent.com/cosmos/chain-registry/master/osmosis/chain.json", Err: errors.New("connection reset by peer")})
+	registry.ChainErrors["juno"] = chain.ErrDegraded
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	tests := []struct {
+		chain string
+		want  int
+	}{
+		{"notachain", http.StatusNotFound},
+		{"osmosis", http.StatusBadGateway},
+		{"juno", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chain, func(t *testing.T) {
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, apiPath+"/chains/"+tt.chain, nil), map[string]string{"chainName": tt.chain})
+			rr := httptest.NewRecorder()
+			handler.GetChainInfo(rr, req)
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+}
+
 func setupTestHandler() *Handler {
 	logger := logrus.New()
-	logger.SetOutput(nil)
+	logger.SetOutput(io.Discard)
 
 	cfg := &config.Config{
 		Server: config.ServerConfig{
@@ -97,6 +173,1737 @@ func setupTestHandler() *Handler {
 		},
 	}
 
-	registry := chain.NewChainRegistry(logger, cfg.Registry.URL, cfg.GitHub.APIURL)
+	registry := testutil.NewFakeRegistry(&chain.ChainInfo{Name: "cosmoshub", ChainID: "cosmoshub-4", Network: "mainnet"})
 	return NewHandler(registry, logger, cfg)
 }
+
+func TestExportChains(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	// The chains.yaml entries only reach the export through the load-chains job
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "config"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config", "chains.yaml"), []byte(`mainnet:
+  - name: cosmoshub
+    display_name: Cosmos Hub
+    network: mainnet
+  - name: osmosis
+    display_name: Osmosis
+    network: mainnet
+testnet:
+  - name: osmosistestnet
+    display_name: Osmosis Testnet
+    network: testnet
+`), 0o644))
+	t.Chdir(dir)
+
+	registry := testutil.NewFakeRegistry()
+	for _, info := range []*chain.ChainInfo{
+		{Name: "cosmoshub", Network: "mainnet"},
+		{Name: "osmosis", Network: "mainnet"},
+		{Name: "osmosistestnet", Network: "testnet"},
+	} {
+		registry.Chains[info.Name] = info
+	}
+	require.NoError(t, cron.NewLoadChainsJob(registry, logger).Run())
+
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, apiPath+"/chains/export", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/yaml", rr.Header().Get("Content-Type"))
+
+	var exported types.ChainsConfig
+	require.NoError(t, yaml.Unmarshal(rr.Body.Bytes(), &exported))
+	assert.Equal(t, types.ChainsConfig{
+		Mainnet: []types.ChainConfig{
+			{Name: "cosmoshub", DisplayName: "Cosmos Hub", Network: "mainnet"},
+			{Name: "osmosis", DisplayName: "Osmosis", Network: "mainnet"},
+		},
+		Testnet: []types.ChainConfig{
+			{Name: "osmosistestnet", DisplayName: "Osmosis Testnet", Network: "testnet"},
+		},
+	}, exported)
+}
+
+func TestListChains(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(
+		&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"},
+		&chain.ChainInfo{Name: "osmosistestnet", Network: "testnet"},
+	)
+	registry.Chains["stargaze"] = &chain.ChainInfo{Name: "stargaze", Network: "mainnet"}
+	registry.Chains["elystestnet"] = &chain.ChainInfo{Name: "elystestnet", Network: "testnet"}
+	registry.SetAutoDiscoveredChains([]string{"stargaze", "cosmoshub", "elystestnet"})
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response ChainsResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, []MonitoredChain{
+		{Name: "cosmoshub", Network: "mainnet", Source: "config"},
+		{Name: "osmosistestnet", Network: "testnet", Source: "config"},
+		{Name: "stargaze", Network: "mainnet", Source: "auto"},
+		{Name: "elystestnet", Network: "testnet", Source: "auto"},
+	}, response.Chains)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/export", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var exported types.ChainsConfig
+	require.NoError(t, yaml.Unmarshal(rr.Body.Bytes(), &exported))
+	assert.Equal(t, types.ChainsConfig{
+		Mainnet: []types.ChainConfig{{Name: "cosmoshub", Network: "mainnet"}},
+		Testnet: []types.ChainConfig{{Name: "osmosistestnet", Network: "testnet"}},
+	}, exported, "auto-discovered chains are not exported")
+}
+
+func TestAddChain(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"})
+	registry.Chains["dymension"] = &chain.ChainInfo{Name: "dymension", ChainID: "dymension_1100-1", Network: "mainnet", PrettyName: "Dymension Hub"}
+	handler := NewHandler(registry, logger, &config.Config{Server: config.ServerConfig{AdminToken: "secret"}})
+	addChain := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, apiPath+"/chains", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("success", func(t *testing.T) {
+		rr := addChain(`{"name": "dymension", "network": "mainnet", "display_name": "Dymension"}`)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		assert.Equal(t, "/api/v1/chains/dymension", rr.Header().Get("Location"))
+		var info chain.ChainInfo
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &info))
+		assert.Equal(t, "dymension_1100-1", info.ChainID)
+
+		chains, err := registry.GetMonitoredChains()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cosmoshub", "dymension"}, chains)
+		cfg, ok := registry.GetChainConfig("dymension")
+		require.True(t, ok)
+		assert.Equal(t, types.ChainConfig{Name: "dymension", DisplayName: "Dymension", Network: "mainnet"}, cfg)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		for _, name := range []string{"dymension", "cosmoshub"} {
+			rr := addChain(`{"name": "` + name + `"}`)
+			assert.Equal(t, http.StatusConflict, rr.Code, name)
+		}
+		chains, err := registry.GetMonitoredChains()
+		require.NoError(t, err)
+		assert.Len(t, chains, 2)
+	})
+
+	t.Run("unknown chain", func(t *testing.T) {
+		rr := addChain(`{"name": "notachain"}`)
+		require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var response ChainNotFoundResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, []string{"notachain", "testnets/notachain"}, response.Variations)
+		assert.Contains(t, response.Error, "notachain")
+		assert.Empty(t, registry.ChainSource("notachain"))
+	})
+
+	t.Run("defaults from the chain info", func(t *testing.T) {
+		registry.Chains["osmosistestnet"] = &chain.ChainInfo{Name: "osmosistestnet", Network: "testnet", PrettyName: "Osmosis Testnet"}
+		require.Equal(t, http.StatusCreated, addChain(`{"name": "osmosistestnet"}`).Code)
+		cfg, ok := registry.GetChainConfig("osmosistestnet")
+		require.True(t, ok)
+		assert.Equal(t, "Osmosis Testnet", cfg.DisplayName)
+		assert.Equal(t, "testnet", cfg.Network)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		registry.Chains["juno"] = &chain.ChainInfo{Name: "juno", Network: "mainnet"}
+		assert.Equal(t, http.StatusBadRequest, addChain(`{"name": " "}`).Code)
+		assert.Equal(t, http.StatusBadRequest, addChain(`{"name": "juno", "network": "devnet"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, addChain(`not json`).Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, addChain(`{"name": "juno", "network": "testnet"}`).Code,
+			"a mainnet directory is not added as a testnet")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, apiPath+"/chains", strings.NewReader(`{"name": "juno"}`)))
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Empty(t, registry.ChainSource("juno"))
+	})
+}
+
+func TestGetChainInfoLiveFields(t *testing.T) {
+	rpcNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":{"sync_info":{"latest_block_height":"1234567","latest_block_time":"2024-03-20T15:04:05Z"}}}`))
+	}))
+	defer rpcNode.Close()
+
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/master/osmosis/chain.json") {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(chain.ChainInfo{
+			Name:     "osmosis",
+			ChainID:  "osmosis-1",
+			Codebase: chain.Codebase{GitRepo: "https://github.com/osmosis-labs/osmosis", RecommendedVersion: "v25.0.0"},
+			APIs:     chain.APIs{RPC: []chain.Endpoint{{Address: rpcNode.URL}}},
+		})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/osmosis", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "osmosis-1", response["chain_id"])
+	assert.Equal(t, "v25.0.0", response["recommended_version"])
+	assert.Equal(t, float64(1234567), response["height"])
+	assert.Equal(t, "2024-03-20T15:04:05Z", response["latest_block_time"])
+}
+
+func TestRefresh(t *testing.T) {
+	var (
+		chainRequests, upgradeRequests atomic.Int32
+		failChain, failUpgrades        atomic.Bool
+		upgradeName                    atomic.Value
+	)
+	upgradeName.Store("v2")
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cosmos/chain-registry/master/cosmoshub/chain.json":
+			chainRequests.Add(1)
+			if failChain.Load() {
+				http.Error(w, "unavailable", http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(chain.ChainInfo{Name: "cosmoshub", ChainID: "cosmoshub-4"})
+		case "/cosmos/chain-registry/master/cosmoshub/upgrades.json":
+			upgradeRequests.Add(1)
+			if failUpgrades.Load() {
+				http.Error(w, "unavailable", http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"name":   upgradeName.Load(),
+				"height": 1000,
+				"time":   time.Now().Add(24 * time.Hour),
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetDegradedThreshold(-1)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{chain.ProviderChainRegistry}))
+	registry.SetMonitoredChains([]string{"cosmoshub"})
+	// Forced refreshes must not be answered with a response reused within the coalesce window
+	handler := NewHandler(registry, logger, &config.Config{Upgrades: config.UpgradesConfig{CoalesceWindow: "1m"}})
+	now := time.Now()
+	handler.refreshLimiter.now = func() time.Time { return now }
+
+	get := func(path, client string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, apiPath+path, nil)
+		req.RemoteAddr = client + ":51234"
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	chainInfo := func(rr *httptest.ResponseRecorder) ChainInfoResponse {
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var response ChainInfoResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response
+	}
+	upgrades := func(rr *httptest.ResponseRecorder) []ChainUpgrade {
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var response UpgradesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Len(t, response.Chains, 1)
+		return response.Chains
+	}
+
+	t.Run("chain info", func(t *testing.T) {
+		chainInfo(get("/chains/cosmoshub", "192.0.2.1"))
+		chainInfo(get("/chains/cosmoshub", "192.0.2.1"))
+		require.Equal(t, int32(1), chainRequests.Load(), "the second request is served from the cache")
+
+		assert.False(t, chainInfo(get("/chains/cosmoshub?refresh=true", "192.0.2.1")).Stale)
+		assert.Equal(t, int32(2), chainRequests.Load(), "refresh bypasses the cache")
+
+		rr := get("/chains/cosmoshub?refresh=true", "192.0.2.1")
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+		assert.Equal(t, int32(2), chainRequests.Load())
+		chainInfo(get("/chains/cosmoshub?refresh=true", "192.0.2.2"))
+		assert.Equal(t, int32(3), chainRequests.Load(), "the limit is per client")
+
+		now = now.Add(31 * time.Second)
+		failChain.Store(true)
+		response := chainInfo(get("/chains/cosmoshub?refresh=true", "192.0.2.1"))
+		assert.True(t, response.Stale, "a failed refresh serves the cached chain info")
+		assert.Equal(t, "cosmoshub-4", response.ChainID)
+		assert.False(t, chainInfo(get("/chains/cosmoshub", "192.0.2.1")).Stale, "the cache is kept")
+		failChain.Store(false)
+
+		assert.Equal(t, http.StatusBadRequest, get("/chains/cosmoshub?refresh=maybe", "192.0.2.1").Code)
+	})
+
+	t.Run("upgrades", func(t *testing.T) {
+		requests := upgradeRequests.Load()
+		assert.Equal(t, "v2", upgrades(get("/upgrades", "192.0.2.1"))[0].Version)
+		upgradeName.Store("v3")
+		assert.Equal(t, "v2", upgrades(get("/upgrades", "192.0.2.1"))[0].Version)
+		assert.Equal(t, requests+1, upgradeRequests.Load())
+
+		refreshed := upgrades(get("/upgrades?refresh=true", "192.0.2.1"))
+		assert.Equal(t, "v3", refreshed[0].Version)
+		assert.False(t, refreshed[0].Stale)
+		assert.Equal(t, http.StatusTooManyRequests, get("/upgrades?refresh=true", "192.0.2.1").Code)
+
+		now = now.Add(31 * time.Second)
+		failUpgrades.Store(true)
+		stale := upgrades(get("/upgrades?refresh=true", "192.0.2.1"))
+		assert.Equal(t, "v3", stale[0].Version)
+		assert.True(t, stale[0].Stale, "an upgrade no source could refresh is served from the cache")
+
+		now = now.Add(31 * time.Second)
+		failChain.Store(true)
+		stale = upgrades(get("/upgrades?refresh=true", "192.0.2.1"))
+		assert.Equal(t, "v3", stale[0].Version)
+		assert.True(t, stale[0].Stale, "an upgrade whose chain info could not be refreshed is served from the cache")
+		assert.False(t, upgrades(get("/upgrades", "192.0.2.1"))[0].Stale)
+	})
+}
+
+func TestGetChainInfoDisplayFields(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cosmos/chain-registry/master/osmosis/chain.json":
+			http.ServeFile(w, r, "../chain/testdata/chain.json")
+		case "/cosmos/chain-registry/master/juno/chain.json":
+			w.Write([]byte(`{"chain_name": "juno", "chain_id": "juno-1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	tests := []struct {
+		chain string
+		want  map[string]interface{}
+	}{
+		{
+			chain: "osmosis",
+			want: map[string]interface{}{
+				"pretty_name":   "Osmosis",
+				"bech32_prefix": "osmo",
+				"daemon_name":   "osmosisd",
+				"logo_URIs": map[string]interface{}{
+					"png": "https://raw.githubusercontent.com/cosmos/chain-registry/master/osmosis/images/osmo.png",
+					"svg": "https://raw.githubusercontent.com/cosmos/chain-registry/master/osmosis/images/osmo.svg",
+				},
+			},
+		},
+		{
+			chain: "juno",
+			want: map[string]interface{}{
+				"pretty_name":   "",
+				"bech32_prefix": "",
+				"daemon_name":   "",
+				"logo_URIs":     map[string]interface{}{"png": "", "svg": ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chain, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/"+tt.chain, nil))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			for field, want := range tt.want {
+				assert.Equal(t, want, response[field], field)
+			}
+		})
+	}
+}
+
+func TestGetAssetList(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cosmos/chain-registry/master/osmosis/assetlist.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"chain_name": "osmosis", "assets": [{"base": "uosmo", "display": "osmo", "symbol": "OSMO", "denom_units": [{"denom": "osmo", "exponent": 6}]}]}`))
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/osmosis/assets", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var assetList chain.AssetList
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &assetList))
+	assert.Equal(t, []chain.Asset{{Base: "uosmo", Display: "osmo", Symbol: "OSMO", Exponent: 6}}, assetList.Assets)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/unknown/assets", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGetChainEndpoints(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer node.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cosmos/chain-registry/master/osmosis/chain.json" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(chain.ChainInfo{
+			Name: "osmosis",
+			APIs: chain.APIs{
+				RPC:  []chain.Endpoint{{Address: down.URL}, {Address: node.URL}},
+				REST: []chain.Endpoint{{Address: node.URL}},
+			},
+		})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/osmosis/endpoints", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var report chain.EndpointReport
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.Equal(t, chain.EndpointHealth{Total: 3, Healthy: 2}, report.EndpointHealth)
+	require.Len(t, report.RPC, 2)
+	assert.Equal(t, node.URL, report.RPC[0].Address, "healthy endpoints are ranked first")
+	assert.False(t, report.RPC[1].Healthy)
+	assert.NotEmpty(t, report.RPC[1].Error)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/unknown/endpoints", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGetChainVersions(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(&chain.ChainInfo{Name: "osmosis", Network: "mainnet"})
+	registry.Versions["osmosis"] = &chain.VersionHistory{ChainName: "osmosis", Versions: []chain.Version{
+		{Name: "v25", Tag: "v25.0.0", Height: 15305000, NextVersionName: "v26"},
+		{Name: "v26", Tag: "v26.0.0", Height: 16000000, PreviousVersionName: "v25"},
+	}}
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/osmosis/versions", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var history chain.VersionHistory
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &history))
+	assert.Equal(t, *registry.Versions["osmosis"], history)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/cosmoshub/versions", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGetUpgradeBinaries(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(
+		&chain.ChainInfo{Name: "osmosis", Network: "mainnet"},
+		&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"},
+		&chain.ChainInfo{Name: "juno", Network: "mainnet"},
+	)
+	registry.Upgrades["osmosis"] = &types.UpgradeInfo{ChainName: "osmosis", Version: "v26.0.0", Height: 16000000}
+	registry.Upgrades["cosmoshub"] = &types.UpgradeInfo{ChainName: "cosmoshub", Version: "v25.0.0", Height: 23000000}
+	registry.Binaries["osmosis"] = map[string]chain.UpgradeBinary{
+		"linux/amd64": {URL: "https://github.com/osmosis-labs/osmosis/releases/download/v26.0.0/osmosisd-linux-amd64?checksum=sha256:4c9a1f", Checksum: "sha256:4c9a1f"},
+		"linux/arm64": {URL: "https://github.com/osmosis-labs/osmosis/releases/download/v26.0.0/osmosisd-linux-arm64"},
+	}
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/osmosis/upgrade/binaries", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var binaries map[string]chain.UpgradeBinary
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &binaries))
+	assert.Equal(t, registry.Binaries["osmosis"], binaries)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/cosmoshub/upgrade/binaries", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{}`, rr.Body.String(), "an upgrade without binaries is not an error")
+
+	for _, name := range []string{"juno", "notachain"} {
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/chains/"+name+"/upgrade/binaries", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code, name)
+	}
+}
+
+func TestGetRegistryChains(t *testing.T) {
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contents := []map[string]string{{"name": "osmosis", "type": "dir"}, {"name": "testnets", "type": "dir"}}
+		if strings.HasSuffix(r.URL.Path, "/contents/testnets") {
+			contents = []map[string]string{{"name": "osmosistestnet", "type": "dir"}}
+		}
+		json.NewEncoder(w).Encode(contents)
+	}))
+	defer apiServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master")
+	registry.SetChainDiscovery(apiServer.URL, time.Hour)
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/registry/chains", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var discovered chain.DiscoveredChains
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &discovered))
+	assert.Equal(t, chain.DiscoveredChains{Mainnet: []string{"osmosis"}, Testnet: []string{"osmosistestnet"}}, discovered)
+}
+
+func TestDegradedModeToggle(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master")
+	handler := NewHandler(registry, logger, &config.Config{Server: config.ServerConfig{AdminToken: "secret"}})
+	post := func(path string, authorized bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, apiPath+path, nil)
+		if authorized {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	health := func() map[string]interface{} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/health", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response
+	}
+
+	assert.Equal(t, "ok", health()["status"])
+
+	assert.Equal(t, http.StatusUnauthorized, post("/degraded/enable", false).Code)
+	assert.False(t, registry.IsDegraded(), "degraded mode needs the admin token")
+
+	assert.Equal(t, http.StatusOK, post("/degraded/enable?reason=github+outage", true).Code)
+	assert.True(t, registry.IsDegraded())
+
+	response := health()
+	assert.Equal(t, "degraded", response["status"])
+	degraded, ok := response["degraded"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "github outage", degraded["reason"])
+	assert.Equal(t, true, degraded["manual"])
+
+	assert.Equal(t, http.StatusUnauthorized, post("/degraded/disable", false).Code)
+	assert.True(t, registry.IsDegraded())
+	assert.Equal(t, http.StatusOK, post("/degraded/disable", true).Code)
+	assert.False(t, registry.IsDegraded())
+	assert.Equal(t, "ok", health()["status"])
+}
+
+func TestGetRecentNotifications(t *testing.T) {
+	chains := []string{"akash", "cosmoshub", "osmosis"}
+	registryServer := testutil.NewRegistryServer(t)
+
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer slackServer.Close()
+	t.Setenv("SLACK_WEBHOOK_URL", slackServer.URL)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name     string
+		size     int
+		expected []string
+	}{
+		{name: "capped", size: 2, expected: []string{"osmosis", "cosmoshub"}},
+		{name: "all sent", size: 10, expected: []string{"osmosis", "cosmoshub", "akash"}},
+		{name: "disabled", size: -1, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetMonitoredChains(chains)
+			handler := NewHandler(registry, logger, &config.Config{
+				Notifications: config.NotificationsConfig{HistorySize: tt.size},
+			})
+			handler.upgradeChecker.CheckUpgrades()
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/notifications/recent", nil))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Notifications []notifications.SentNotification `json:"notifications"`
+				Count         int                              `json:"count"`
+			}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, len(tt.expected), response.Count)
+			sent := []string{}
+			for _, entry := range response.Notifications {
+				sent = append(sent, entry.Chain)
+				assert.Equal(t, notifications.BackendSlack, entry.Backend)
+				assert.Contains(t, entry.Text, "New Upgrade Scheduled")
+			}
+			assert.Equal(t, tt.expected, sent)
+		})
+	}
+}
+
+func TestGetUpgradesServesSnapshotDuringOutage(t *testing.T) {
+	outage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer outage.Close()
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	snapshot := chain.Snapshot{
+		WrittenAt: time.Now().Add(-time.Hour),
+		Chains:    map[string]*chain.ChainInfo{"osmosis": {Name: "osmosis", ChainID: "osmosis-1", Network: "mainnet"}},
+		Upgrades: map[string]*types.UpgradeInfo{"osmosis": {
+			Name:      "v25",
+			ChainName: "osmosis",
+			Version:   "v25.0.0",
+			Network:   "mainnet",
+			Height:    1000,
+			Time:      time.Now().Add(24 * time.Hour),
+		}},
+	}
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, outage.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"osmosis"})
+	require.NoError(t, registry.SetSnapshotPath(path))
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/upgrades", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response UpgradesResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Chains, 1)
+	assert.Equal(t, "osmosis", response.Chains[0].Name)
+	assert.Equal(t, "v25.0.0", response.Chains[0].Version)
+	assert.True(t, response.Chains[0].Stale)
+}
+
+func TestMaintenanceModeToggle(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/cosmoshub/chain.json"):
+			json.NewEncoder(w).Encode(chain.ChainInfo{Name: "cosmoshub", ChainID: "cosmoshub-4"})
+		case strings.HasSuffix(r.URL.Path, "/cosmoshub/upgrades.json"):
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"name":   "v2",
+				"height": 1000,
+				"time":   time.Now().Add(24 * time.Hour),
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	var messages atomic.Int32
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		messages.Add(1)
+	}))
+	defer slackServer.Close()
+	t.Setenv("SLACK_WEBHOOK_URL", slackServer.URL)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"cosmoshub"})
+	handler := NewHandler(registry, logger, &config.Config{Server: config.ServerConfig{AdminToken: "secret"}})
+
+	maintenance := func(query, token string) int {
+		req := httptest.NewRequest(http.MethodPost, apiPath+"/maintenance"+query, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	health := func() map[string]interface{} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/health", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, maintenance("?enabled=true", ""))
+	assert.Equal(t, http.StatusUnauthorized, maintenance("?enabled=true", "wrong"))
+	assert.Equal(t, http.StatusBadRequest, maintenance("?enabled=maybe", "secret"))
+	assert.False(t, registry.InMaintenance())
+
+	require.Equal(t, http.StatusOK, maintenance("?enabled=true&reason=node+migration", "secret"))
+	response := health()
+	assert.Equal(t, "maintenance", response["status"])
+	status, ok := response["maintenance"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "node migration", status["reason"])
+
+	handler.upgradeChecker.CheckUpgrades()
+	assert.Zero(t, messages.Load(), "monitoring is paused during maintenance")
+
+	require.Equal(t, http.StatusOK, maintenance("?enabled=false", "secret"))
+	assert.Equal(t, "ok", health()["status"])
+
+	handler.upgradeChecker.CheckUpgrades()
+	assert.Equal(t, int32(1), messages.Load(), "monitoring resumes after maintenance")
+}
+
+func TestMaintenanceRequiresAdminToken(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master")
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, apiPath+"/maintenance?enabled=true", nil))
+	assert.Equal(t, http.StatusForbidden, rr.Code, "maintenance is disabled without an admin token")
+	assert.False(t, registry.InMaintenance())
+}
+
+func TestGetUpgradesByGroup(t *testing.T) {
+	registryServer := testutil.NewRegistryServer(t)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"akash", "cosmoshub", "osmosis"})
+
+	handler := NewHandler(registry, logger, &config.Config{
+		Groups: config.GroupsConfig{
+			"p2p-validators": {Chains: []string{"cosmoshub", "osmosis"}},
+			"watch-only":     {Chains: []string{"akash"}},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected []string
+	}{
+		{"no group", "", http.StatusOK, []string{"akash", "cosmoshub", "osmosis"}},
+		{"p2p validators", "?group=p2p-validators", http.StatusOK, []string{"cosmoshub", "osmosis"}},
+		{"watch only", "?group=watch-only", http.StatusOK, []string{"akash"}},
+		{"unknown group", "?group=missing", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, apiPath+"/upgrades"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.GetUpgrades(rr, req)
+
+			require.Equal(t, tt.status, rr.Code)
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response UpgradesResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			var names []string
+			for _, upgrade := range response.Chains {
+				names = append(names, upgrade.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestGetUpgradesByNetwork(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(
+		&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"},
+		&chain.ChainInfo{Name: "osmosis", Network: "mainnet"},
+		&chain.ChainInfo{Name: "osmosistestnet", Network: "testnet"},
+	)
+	for name, network := range map[string]string{"cosmoshub": "mainnet", "osmosis": "mainnet", "osmosistestnet": "testnet"} {
+		registry.Upgrades[name] = &types.UpgradeInfo{ChainName: name, Network: network, Version: "v2", Height: 1000, Time: time.Now().Add(24 * time.Hour)}
+	}
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected []string
+	}{
+		{"default", "", http.StatusOK, []string{"cosmoshub", "osmosis", "osmosistestnet"}},
+		{"all", "?network=all", http.StatusOK, []string{"cosmoshub", "osmosis", "osmosistestnet"}},
+		{"mainnet", "?network=mainnet", http.StatusOK, []string{"cosmoshub", "osmosis"}},
+		{"testnet", "?network=testnet", http.StatusOK, []string{"osmosistestnet"}},
+		{"invalid", "?network=devnet", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.GetUpgrades(rr, httptest.NewRequest(http.MethodGet, apiPath+"/upgrades"+tt.query, nil))
+
+			require.Equal(t, tt.status, rr.Code)
+			if tt.status != http.StatusOK {
+				assert.Contains(t, rr.Body.String(), "expected mainnet, testnet or all")
+				return
+			}
+
+			var response UpgradesResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			var names []string
+			for _, upgrade := range response.Chains {
+				names = append(names, upgrade.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+
+	before := registry.UpgradeLookups("cosmoshub")
+	handler.GetUpgrades(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, apiPath+"/upgrades?network=testnet", nil))
+	assert.Equal(t, before, registry.UpgradeLookups("cosmoshub"), "chains of the other network are not fetched")
+}
+
+func TestGetUpgradesPagination(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	now := time.Now()
+	registry := testutil.NewFakeRegistry(
+		&chain.ChainInfo{Name: "akash", Network: "mainnet"},
+		&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"},
+		&chain.ChainInfo{Name: "juno", Network: "mainnet"},
+		&chain.ChainInfo{Name: "osmosis", Network: "mainnet"},
+	)
+	registry.Upgrades["akash"] = &types.UpgradeInfo{ChainName: "akash", Version: "v1", Height: 3000, Time: now.Add(72 * time.Hour)}
+	registry.Upgrades["cosmoshub"] = &types.UpgradeInfo{ChainName: "cosmoshub", Version: "v2", Height: 1000, Time: now.Add(24 * time.Hour)}
+	// Without an estimated time or height, sorted last by either
+	registry.Upgrades["juno"] = &types.UpgradeInfo{ChainName: "juno", Version: "v3"}
+	registry.Upgrades["osmosis"] = &types.UpgradeInfo{ChainName: "osmosis", Version: "v4", Height: 2000, Time: now.Add(48 * time.Hour)}
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+		limit    int
+		offset   int
+	}{
+		{"default", "", []string{"akash", "cosmoshub", "juno", "osmosis"}, 0, 0},
+		{"name descending", "?order=desc", []string{"osmosis", "juno", "cosmoshub", "akash"}, 0, 0},
+		{"soonest first", "?sort=time", []string{"cosmoshub", "osmosis", "akash", "juno"}, 0, 0},
+		{"latest first", "?sort=time&order=desc", []string{"akash", "osmosis", "cosmoshub", "juno"}, 0, 0},
+		{"highest first", "?sort=height&order=desc", []string{"akash", "osmosis", "cosmoshub", "juno"}, 0, 0},
+		{"first page", "?sort=time&limit=2", []string{"cosmoshub", "osmosis"}, 2, 0},
+		{"second page", "?sort=time&limit=2&offset=2", []string{"akash", "juno"}, 2, 2},
+		{"last upgrade", "?limit=2&offset=3", []string{"osmosis"}, 2, 3},
+		{"offset without limit", "?offset=1", []string{"cosmoshub", "juno", "osmosis"}, 0, 1},
+		{"offset at the end", "?offset=4", []string{}, 0, 4},
+		{"out-of-range page", "?limit=10&offset=40", []string{}, 10, 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.GetUpgrades(rr, httptest.NewRequest(http.MethodGet, apiPath+"/upgrades"+tt.query, nil))
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+			var response UpgradesResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			names := []string{}
+			for _, upgrade := range response.Chains {
+				names = append(names, upgrade.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+			assert.Equal(t, 4, response.Total)
+			assert.Equal(t, tt.limit, response.Limit)
+			assert.Equal(t, tt.offset, response.Offset)
+		})
+	}
+
+	for _, query := range []string{"?limit=0", "?limit=-1", "?limit=ten", "?offset=-1", "?offset=1.5", "?sort=version", "?order=up"} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.GetUpgrades(rr, httptest.NewRequest(http.MethodGet, apiPath+"/upgrades"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestGetUpgradesByGuide(t *testing.T) {
+	registryServer := testutil.NewRegistryServer(t, testutil.WithUpgrade(func(chainName string) map[string]interface{} {
+		upgrade := testutil.StubUpgrade(chainName)
+		if chainName == "cosmoshub" {
+			upgrade["info"] = "https://github.com/cosmos/gaia/blob/main/UPGRADING.md"
+		}
+		return upgrade
+	}))
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"akash", "cosmoshub"})
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected []string
+	}{
+		{"no filter", "", http.StatusOK, []string{"akash", "cosmoshub"}},
+		{"with guide", "?has_guide=true", http.StatusOK, []string{"cosmoshub"}},
+		{"missing guide", "?has_guide=false", http.StatusOK, []string{"akash"}},
+		{"invalid", "?has_guide=maybe", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.GetUpgrades(rr, httptest.NewRequest(http.MethodGet, apiPath+"/upgrades"+tt.query, nil))
+
+			require.Equal(t, tt.status, rr.Code)
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response UpgradesResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			var names []string
+			for _, upgrade := range response.Chains {
+				names = append(names, upgrade.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestGetUpgradesCoalescesConcurrentRequests(t *testing.T) {
+	var upgradeRequests atomic.Int32
+	release := make(chan struct{})
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/cosmoshub/chain.json"):
+			json.NewEncoder(w).Encode(chain.ChainInfo{Name: "cosmoshub", ChainID: "cosmoshub-4"})
+		case strings.HasSuffix(r.URL.Path, "/cosmoshub/upgrades.json"):
+			upgradeRequests.Add(1)
+			<-release
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"name":   "v2",
+				"height": 1000,
+				"time":   time.Now().Add(24 * time.Hour),
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"cosmoshub"})
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	const callers = 5
+	var wg sync.WaitGroup
+	recorders := make([]*httptest.ResponseRecorder, callers)
+	for i := range recorders {
+		recorders[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(rr *httptest.ResponseRecorder) {
+			defer wg.Done()
+			handler.GetUpgrades(rr, httptest.NewRequest(http.MethodGet, apiPath+"/upgrades", nil))
+		}(recorders[i])
+	}
+
+	// Hold the fan-out until every caller has joined it
+	require.Eventually(t, func() bool { return handler.upgradesCoalescer.inFlight() == callers }, 2*time.Second, 5*time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), upgradeRequests.Load())
+	for _, rr := range recorders {
+		require.Equal(t, http.StatusOK, rr.Code)
+		var response UpgradesResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Chains, 1)
+		assert.Equal(t, "cosmoshub", response.Chains[0].Name)
+	}
+}
+
+func TestGetUpgradesStopsFetchingWhenClientDisconnects(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	aborted := make(chan struct{}, 1)
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/cosmoshub/chain.json"):
+			json.NewEncoder(w).Encode(chain.ChainInfo{Name: "cosmoshub", ChainID: "cosmoshub-4"})
+		case strings.HasSuffix(r.URL.Path, "/cosmoshub/upgrades.json"):
+			requested <- struct{}{}
+			select {
+			case <-r.Context().Done():
+				aborted <- struct{}{}
+			case <-time.After(5 * time.Second):
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"cosmoshub"})
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{chain.ProviderChainRegistry}))
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.GetUpgrades(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, apiPath+"/upgrades", nil).WithContext(ctx))
+	}()
+
+	<-requested
+	cancel()
+	select {
+	case <-aborted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream request kept running after the client disconnected")
+	}
+	<-done
+}
+
+func TestGetGroupUpgradesICS(t *testing.T) {
+	// Testnets are only served from the chain-registry's testnets directory
+	registryServer := testutil.NewRegistryServer(t)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"akash", "cosmoshub", "osmosistestnet"})
+	handler := NewHandler(registry, logger, &config.Config{
+		Groups: config.GroupsConfig{
+			"p2p-validators": {Chains: []string{"cosmoshub", "osmosistestnet"}},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		expected []string
+	}{
+		{"group feed", "/groups/p2p-validators/upgrades.ics", http.StatusOK, []string{"cosmoshub", "osmosistestnet"}},
+		{"mainnet feed", "/groups/p2p-validators/upgrades/mainnet.ics", http.StatusOK, []string{"cosmoshub"}},
+		{"testnet feed", "/groups/p2p-validators/upgrades/testnet.ics", http.StatusOK, []string{"osmosistestnet"}},
+		{"unknown group", "/groups/missing/upgrades.ics", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+tt.path, nil))
+
+			require.Equal(t, tt.status, rr.Code)
+			if tt.status != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "text/calendar; charset=utf-8", rr.Header().Get("Content-Type"))
+
+			var chains []string
+			for _, line := range strings.Split(rr.Body.String(), "\r\n") {
+				if summary, ok := strings.CutPrefix(line, "SUMMARY:"); ok {
+					chains = append(chains, strings.Fields(summary)[0])
+				}
+			}
+			assert.Equal(t, tt.expected, chains)
+			assert.NotContains(t, rr.Body.String(), "akash")
+		})
+	}
+}
+
+func TestGetUpgradesICS(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(
+		&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"},
+		&chain.ChainInfo{Name: "osmosis", Network: "mainnet"},
+	)
+	registry.Upgrades["cosmoshub"] = &types.UpgradeInfo{
+		ChainName:    "cosmoshub",
+		Network:      "mainnet",
+		Name:         "v25",
+		Version:      "v25.0.0",
+		Height:       1000000,
+		Time:         time.Now().Add(24 * time.Hour),
+		ProposalLink: "https://www.mintscan.io/cosmos/proposals/924",
+		Guide:        "https://docs.cosmos.network/upgrades/v25",
+	}
+	registry.Upgrades["osmosis"] = &types.UpgradeInfo{ChainName: "osmosis", Name: "v26", Version: "v26.0.0", Height: 500, Time: time.Now().Add(-time.Hour)}
+	registry.Blocks["osmosis"] = chain.LatestBlock{Height: 510}
+	handler := NewHandler(registry, logger, &config.Config{})
+	now := time.Now()
+	handler.icsFeeds.now = func() time.Time { return now }
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/upgrades.ics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", rr.Header().Get("Content-Type"))
+	body := rr.Body.String()
+	assert.Equal(t, 1, strings.Count(body, "BEGIN:VEVENT"), "past upgrades are left out")
+	assert.Contains(t, body, "UID:cosmoshub-v25-1000000@cosmos-watcher")
+	assert.Contains(t, body, "DURATION:PT1H")
+	unfolded := strings.ReplaceAll(body, "\r\n ", "")
+	for _, detail := range []string{"Height: 1000000", "Version: v25.0.0", "Proposal: https://www.mintscan.io/cosmos/proposals/924", "Guide: https://docs.cosmos.network/upgrades/v25"} {
+		assert.Contains(t, unfolded, detail)
+	}
+
+	lookups := registry.UpgradeLookups("cosmoshub")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/upgrades.ics", nil))
+	assert.Equal(t, body, rr.Body.String())
+	assert.Equal(t, lookups, registry.UpgradeLookups("cosmoshub"), "the feed is served from the cache")
+
+	now = now.Add(icsCacheTTL)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, apiPath+"/upgrades.ics", nil))
+	assert.Greater(t, registry.UpgradeLookups("cosmoshub"), lookups, "an expired feed is rendered again")
+}
+
+func TestGetUpgradesVoteTally(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cosmos/gov/v1/proposals/7":
+			w.Write([]byte(`{"proposal":{"id":"7","status":"PROPOSAL_STATUS_VOTING_PERIOD"}}`))
+		case "/cosmos/gov/v1/proposals/7/tally":
+			w.Write([]byte(`{"tally":{"yes_count":"700","no_count":"200","abstain_count":"50","no_with_veto_count":"50"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer node.Close()
+
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json"):
+			json.NewEncoder(w).Encode(chain.ChainInfo{
+				Name:    "testchain",
+				ChainID: "testchain-1",
+				APIs:    chain.APIs{REST: []chain.Endpoint{{Address: node.URL}}},
+			})
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json"):
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"name":          "v2",
+				"height":        1000,
+				"time":          time.Now().Add(24 * time.Hour),
+				"proposal_link": "https://www.mintscan.io/testchain/proposals/7",
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, include := range []bool{false, true} {
+		registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+		registry.SetMonitoredChains([]string{"testchain"})
+		handler := NewHandler(registry, logger, &config.Config{
+			Upgrades: config.UpgradesConfig{IncludeVoteTally: include},
+		})
+
+		rr := httptest.NewRecorder()
+		handler.GetUpgrades(rr, httptest.NewRequest(http.MethodGet, apiPath+"/upgrades", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response UpgradesResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Chains, 1)
+
+		if !include {
+			assert.Nil(t, response.Chains[0].VoteTally)
+			continue
+		}
+		require.NotNil(t, response.Chains[0].VoteTally)
+		assert.Equal(t, types.VoteTally{
+			ProposalID: "7", Status: "voting_period", Yes: 70, No: 20, Abstain: 5, NoWithVeto: 5,
+		}, *response.Chains[0].VoteTally)
+	}
+}
+
+func TestSnoozeChain(t *testing.T) {
+	registryServer := testutil.NewRegistryServer(t)
+
+	var (
+		mu       sync.Mutex
+		messages []string
+	)
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		messages = append(messages, string(body))
+		mu.Unlock()
+	}))
+	defer slackServer.Close()
+	t.Setenv("SLACK_WEBHOOK_URL", slackServer.URL)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{chain.ProviderChainRegistry}))
+	registry.SetMonitoredChains([]string{"cosmoshub", "osmosis"})
+	handler := NewHandler(registry, logger, &config.Config{Server: config.ServerConfig{AdminToken: "secret"}})
+	router := mux.NewRouter()
+	SetupRoutes(router, handler)
+	snooze := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for path, status := range map[string]int{
+			apiPath + "/chains/cosmoshub/snooze":              http.StatusBadRequest,
+			apiPath + "/chains/cosmoshub/snooze?duration=-1h": http.StatusBadRequest,
+			apiPath + "/chains/unknown/snooze?duration=4h":    http.StatusNotFound,
+		} {
+			assert.Equal(t, status, snooze(path).Code, path)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, apiPath+"/chains/osmosis/snooze?duration=4h", nil))
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "snoozing needs the admin token")
+	})
+
+	rr := snooze(apiPath + "/chains/cosmoshub/snooze?duration=300ms")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	handler.upgradeChecker.CheckUpgrades()
+
+	mu.Lock()
+	require.Len(t, messages, 1, "only the chain that is not snoozed is notified")
+	assert.Contains(t, messages[0], "Osmosis")
+	mu.Unlock()
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/stats", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var stats StatsResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&stats))
+	assert.Equal(t, 2, stats.MonitoredChains)
+	require.Len(t, stats.ActiveSnoozes, 1)
+	assert.Equal(t, "cosmoshub", stats.ActiveSnoozes[0].Chain)
+
+	time.Sleep(350 * time.Millisecond)
+
+	_, snoozed := handler.upgradeChecker.SnoozedUntil("cosmoshub")
+	assert.False(t, snoozed, "snooze should expire")
+	assert.Empty(t, handler.upgradeChecker.ActiveSnoozes())
+
+	handler.upgradeChecker.CheckUpgrades()
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, messages, 1, "upgrades seen while snoozed are tracked and not announced later")
+}
+
+func TestGetUpgradesSource(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(
+		&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"},
+		&chain.ChainInfo{Name: "osmosis", Network: "mainnet"},
+	)
+	registry.Upgrades["cosmoshub"] = &types.UpgradeInfo{ChainName: "cosmoshub", Network: "mainnet", Version: "v25.0.0", Height: 1000, Source: chain.ProviderChainRegistry}
+	registry.Upgrades["osmosis"] = &types.UpgradeInfo{ChainName: "osmosis", Network: "mainnet", Version: "v28.0.0", Height: 900, Source: chain.ProviderPolkachu,
+		Sources: []string{chain.ProviderPolkachu, chain.ProviderChainRegistry}}
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, apiPath+"/upgrades", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response UpgradesResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	sources := make(map[string]string)
+	for _, upgrade := range response.Chains {
+		sources[upgrade.Name] = upgrade.Source
+	}
+	assert.Equal(t, map[string]string{"cosmoshub": chain.ProviderChainRegistry, "osmosis": chain.ProviderPolkachu}, sources)
+	for _, upgrade := range response.Chains {
+		if upgrade.Name == "osmosis" {
+			assert.Equal(t, []string{chain.ProviderPolkachu, chain.ProviderChainRegistry}, upgrade.Sources)
+		}
+	}
+}
+
+func TestGetUpgradesChangeType(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(
+		&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"},
+		&chain.ChainInfo{Name: "osmosis", Network: "mainnet"},
+	)
+	registry.Upgrades["cosmoshub"] = &types.UpgradeInfo{ChainName: "cosmoshub", Network: "mainnet", Version: "v25.1.0", Height: 1000, ChangeType: "minor"}
+	registry.Upgrades["osmosis"] = &types.UpgradeInfo{ChainName: "osmosis", Network: "mainnet", Version: "v28.0.0", Height: 900, ChangeType: "minor"}
+	registry.NodeVersions["osmosis"] = "v27.1.0"
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, apiPath+"/upgrades", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response UpgradesResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	changeTypes := make(map[string]string)
+	for _, upgrade := range response.Chains {
+		changeTypes[upgrade.Name] = upgrade.ChangeType
+	}
+	assert.Equal(t, map[string]string{"cosmoshub": "minor", "osmosis": "major"}, changeTypes, "the version the node runs is classified against when known")
+}
+
+func TestRefreshRegistry(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(
+		&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"},
+		&chain.ChainInfo{Name: "osmosis", Network: "mainnet"},
+	)
+	registry.ChainErrors["osmosis"] = &chain.ErrNetwork{URL: "https://raw.githubusercontent.com/cosmos/chain-registry/master/osmosis/chain.json", Err: errors.New("connection reset by peer")}
+	handler := NewHandler(registry, logger, &config.Config{Server: config.ServerConfig{AdminToken: "secret"}})
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, apiPath+"/registry/refresh", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, refresh("").Code)
+
+	rr := refresh("secret")
+	require.Equal(t, http.StatusOK, rr.Code, "failing chains are reported, not an error")
+	var report chain.RefreshReport
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+	assert.Equal(t, 1, report.Refreshed)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Chains, 2)
+	assert.Equal(t, "osmosis", report.Chains[1].Chain)
+	assert.Contains(t, report.Chains[1].Error, "connection reset by peer")
+
+	registry.SetDegraded(true, "test")
+	assert.Equal(t, http.StatusServiceUnavailable, refresh("secret").Code)
+}
+
+func TestGetChainPeers(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(&chain.ChainInfo{Name: "osmosis", Network: "mainnet", Peers: chain.Peers{
+		Seeds: []chain.Peer{
+			{ID: "f515a8599b40f0e84dfad935ba414674ab11a668", Address: "osmosis.blockpane.com:26656", Provider: "blockpane"},
+			{Address: "seed.osmosis.example:26656", Provider: "missing id"},
+		},
+		PersistentPeers: []chain.Peer{
+			{ID: "8f67a2fcdd7ade970b1983bf1697111d35dfdd6f", Address: "52.79.199.137:26656", Provider: "cosmostation"},
+			{ID: "00c328a33578466c711874ec5ee7ada75951f99a", Address: "35.82.201.64:26656", Provider: "notional"},
+		},
+	}})
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+path, nil))
+		return rr
+	}
+
+	rr := get("/chains/osmosis/peers")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response PeersResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, PeersResponse{
+		Chain: "osmosis",
+		Seeds: []string{"f515a8599b40f0e84dfad935ba414674ab11a668@osmosis.blockpane.com:26656"},
+		PersistentPeers: []string{
+			"8f67a2fcdd7ade970b1983bf1697111d35dfdd6f@52.79.199.137:26656",
+			"00c328a33578466c711874ec5ee7ada75951f99a@35.82.201.64:26656",
+		},
+	}, response)
+
+	rr = get("/chains/osmosis/peers?format=config")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `seeds = "f515a8599b40f0e84dfad935ba414674ab11a668@osmosis.blockpane.com:26656"`+"\n"+
+		`persistent_peers = "8f67a2fcdd7ade970b1983bf1697111d35dfdd6f@52.79.199.137:26656,00c328a33578466c711874ec5ee7ada75951f99a@35.82.201.64:26656"`+"\n", rr.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, get("/chains/osmosis/peers?format=toml").Code)
+	assert.Equal(t, http.StatusNotFound, get("/chains/notachain/peers").Code)
+}
+
+func TestGetUpgradesIncludeCompleted(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(
+		&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"},
+		&chain.ChainInfo{Name: "osmosis", Network: "mainnet"},
+	)
+	registry.Upgrades["cosmoshub"] = &types.UpgradeInfo{ChainName: "cosmoshub", Network: "mainnet", Version: "v25.0.0", Height: 1000, Time: time.Now().Add(time.Hour)}
+	registry.Upgrades["osmosis"] = &types.UpgradeInfo{ChainName: "osmosis", Network: "mainnet", Version: "v28.0.0", Height: 900, Time: time.Now().Add(time.Hour)}
+	registry.Blocks["cosmoshub"] = chain.LatestBlock{Height: 990}
+	registry.Blocks["osmosis"] = chain.LatestBlock{Height: 905}
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	statuses := func(query string) map[string]string {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/upgrades"+query, nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var response UpgradesResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		statuses := make(map[string]string)
+		for _, upgrade := range response.Chains {
+			statuses[upgrade.Name] = upgrade.Status
+		}
+		return statuses
+	}
+
+	assert.Equal(t, map[string]string{"cosmoshub": types.UpgradeStatusScheduled}, statuses(""), "upgrades the chain has passed are hidden")
+	assert.Equal(t, map[string]string{
+		"cosmoshub": types.UpgradeStatusScheduled,
+		"osmosis":   types.UpgradeStatusCompleted,
+	}, statuses("?include_completed=true"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/upgrades?include_completed=maybe", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestRunJob(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{
+		Server: config.ServerConfig{AdminToken: "secret"},
+		Jobs:   types.JobConfig{MaxConcurrent: 5},
+	})
+	invoked := make(chan struct{}, 1)
+	release := make(chan struct{})
+	handler.Scheduler.RegisterTask("recording-task", func() error {
+		invoked <- struct{}{}
+		<-release
+		return nil
+	})
+	require.NoError(t, handler.Scheduler.LoadPredefinedJobs([]types.Job{
+		{Name: "check-now", Schedule: "0 0 * * * *", TaskName: "recording-task", Enabled: true},
+	}))
+	defer close(release)
+
+	run := func(name, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, apiPath+"/jobs/"+name+"/run", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, run("check-now", "").Code)
+
+	rr := run("check-now", "secret")
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	var response JobRunResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "check-now", response.Job)
+	assert.NotEmpty(t, response.RunID)
+	select {
+	case <-invoked:
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "the job was not run")
+	}
+
+	assert.Equal(t, http.StatusConflict, run("check-now", "secret").Code, "the job is still running")
+	assert.Equal(t, http.StatusNotFound, run("unknown", "secret").Code)
+}
+
+func TestEnableDisableJob(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{
+		Server: config.ServerConfig{AdminToken: "secret"},
+		Jobs:   types.JobConfig{MaxConcurrent: 5},
+	})
+	handler.Scheduler.RegisterTask("noop-task", func() error { return nil })
+	require.NoError(t, handler.Scheduler.LoadPredefinedJobs([]types.Job{
+		{Name: "check-now", Schedule: "0 0 * * * *", TaskName: "noop-task", Enabled: true, Description: "Checks now"},
+	}))
+
+	post := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, apiPath+path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	status := func(rr *httptest.ResponseRecorder) JobStatusResponse {
+		t.Helper()
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var response JobStatusResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response
+	}
+
+	assert.Equal(t, JobStatusResponse{Name: "check-now", Enabled: false, Description: "Checks now"}, status(post("/jobs/check-now/disable")))
+	req := httptest.NewRequest(http.MethodGet, apiPath+"/jobs/check-now", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.False(t, status(rr).Enabled, "the status reflects the runtime state")
+	assert.Equal(t, http.StatusConflict, post("/jobs/check-now/run").Code, "a disabled job cannot be run")
+
+	assert.True(t, status(post("/jobs/check-now/enable")).Enabled)
+	assert.Equal(t, http.StatusNotFound, post("/jobs/unknown/enable").Code)
+	assert.Equal(t, http.StatusNotFound, post("/jobs/unknown/disable").Code)
+}
+
+func TestGetJobHistory(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{
+		Server: config.ServerConfig{AdminToken: "secret"},
+		Jobs:   types.JobConfig{MaxConcurrent: 5},
+	})
+	done := make(chan struct{}, 1)
+	handler.Scheduler.RegisterTask("failing-task", func() error {
+		defer func() { done <- struct{}{} }()
+		return errors.New("registry unreachable")
+	})
+	require.NoError(t, handler.Scheduler.LoadPredefinedJobs([]types.Job{
+		{Name: "check-now", Schedule: "0 0 * * * *", TaskName: "failing-task", Enabled: true},
+	}))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+path, nil))
+		return rr
+	}
+
+	runID, err := handler.Scheduler.RunJobNow("check-now")
+	require.NoError(t, err)
+	<-done
+	var history JobHistoryResponse
+	require.Eventually(t, func() bool {
+		rr := get("/jobs/check-now/history?limit=5")
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &history))
+		return len(history.Runs) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "check-now", history.Job)
+	assert.Equal(t, runID, history.Runs[0].RunID)
+	assert.Equal(t, cron.RunFailed, history.Runs[0].Status)
+	assert.Equal(t, "registry unreachable", history.Runs[0].Error)
+
+	var jobs struct {
+		Jobs []map[string]any `json:"jobs"`
+	}
+	require.NoError(t, json.Unmarshal(get("/jobs").Body.Bytes(), &jobs))
+	require.Len(t, jobs.Jobs, 1)
+	assert.Equal(t, "failed", jobs.Jobs[0]["last_status"])
+	assert.Contains(t, jobs.Jobs[0], "last_run")
+	assert.Contains(t, jobs.Jobs[0], "last_duration")
+
+	assert.Equal(t, http.StatusBadRequest, get("/jobs/check-now/history?limit=0").Code)
+	assert.Equal(t, http.StatusNotFound, get("/jobs/unknown/history").Code)
+}
+
+func TestAddRemoveJob(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{
+		Server: config.ServerConfig{AdminToken: "secret"},
+		Jobs:   types.JobConfig{MaxConcurrent: 5},
+	})
+	handler.Scheduler.RegisterTask("noop-task", func() error { return nil })
+	require.NoError(t, handler.Scheduler.LoadPredefinedJobs([]types.Job{
+		{Name: "check-now", Schedule: "0 0 * * * *", TaskName: "noop-task", Enabled: true},
+	}))
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, apiPath+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send(http.MethodPost, "/jobs", `{"name":"nightly","schedule":"0 0 3 * * *","task":"noop-task","description":"Nightly check"}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.Equal(t, "/api/v1/jobs/nightly", rr.Header().Get("Location"))
+	var state cron.JobState
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &state))
+	assert.Equal(t, types.Job{Name: "nightly", Schedule: "0 0 3 * * *", TaskName: "noop-task", Enabled: true, Description: "Nightly check"}, state.Job)
+	assert.Equal(t, cron.OriginAPI, state.Origin)
+
+	assert.Equal(t, http.StatusConflict, send(http.MethodPost, "/jobs", `{"name":"nightly","schedule":"@every 1h","task":"noop-task"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/jobs", `{"name":"bad","schedule":"nightly","task":"noop-task"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/jobs", `{"name":"bad","schedule":"@every 1h","task":"unknown-task"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/jobs", `{"name":`).Code)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, apiPath+"/jobs", nil))
+	assert.Contains(t, rr.Body.String(), `"origin":"api"`)
+	assert.Contains(t, rr.Body.String(), `"origin":"predefined"`)
+
+	rr = send(http.MethodDelete, "/jobs/nightly", "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.JSONEq(t, `{"job":"nightly","origin":"api","status":"removed"}`, rr.Body.String())
+	assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, "/jobs/nightly", "").Code)
+
+	assert.Equal(t, http.StatusForbidden, send(http.MethodDelete, "/jobs/check-now", "").Code, "predefined jobs need force")
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodDelete, "/jobs/check-now?force=maybe", "").Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, "/jobs/check-now?force=true", "").Code)
+	assert.Empty(t, handler.Scheduler.ListJobs())
+
+	req := httptest.NewRequest(http.MethodPost, apiPath+"/jobs", strings.NewReader(`{}`))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "adding jobs needs the admin token")
+}
+
+func TestSchedulerControl(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{
+		Server: config.ServerConfig{AdminToken: "secret"},
+		Jobs:   types.JobConfig{MaxConcurrent: 5},
+	})
+	post := func(path, token string) int {
+		req := httptest.NewRequest(http.MethodPost, apiPath+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post("/scheduler/start", ""))
+	assert.Equal(t, http.StatusUnauthorized, post("/scheduler/start", "wrong"))
+	assert.False(t, handler.Scheduler.IsRunning())
+
+	assert.Equal(t, http.StatusOK, post("/scheduler/start", "secret"))
+	assert.True(t, handler.Scheduler.IsRunning())
+	assert.Equal(t, http.StatusUnauthorized, post("/scheduler/stop", ""))
+	assert.True(t, handler.Scheduler.IsRunning())
+	assert.Equal(t, http.StatusOK, post("/scheduler/stop", "secret"))
+	assert.False(t, handler.Scheduler.IsRunning())
+}
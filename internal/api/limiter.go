@@ -0,0 +1,163 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultMaxConnections is how many event stream, or WebSocket, clients may be connected at once
+const defaultMaxConnections = 100
+
+// connectionLimiter caps the number of concurrently served long-lived connections, such as event
+// stream subscribers. A nil limiter admits everyone.
+type connectionLimiter struct {
+	mu     sync.Mutex
+	max    int
+	active int
+}
+
+// newConnectionLimiter returns a limiter for max connections. Zero uses the default, a negative value
+// disables the limit and returns nil.
+func newConnectionLimiter(max int) *connectionLimiter {
+	if max == 0 {
+		max = defaultMaxConnections
+	}
+	if max < 0 {
+		return nil
+	}
+	return &connectionLimiter{max: max}
+}
+
+// acquire takes a connection slot. The returned release func is safe to call more than once.
+func (l *connectionLimiter) acquire() (release func(), ok bool) {
+	if l == nil {
+		return func() {}, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active >= l.max {
+		return nil, false
+	}
+	l.active++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.active--
+			l.mu.Unlock()
+		})
+	}, true
+}
+
+// inUse returns the number of connections currently holding a slot
+func (l *connectionLimiter) inUse() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active
+}
+
+// limit wraps a streaming handler so that connections beyond the cap are rejected with 503. The slot
+// is released when the handler returns, including when it panics, so handlers must return once the
+// client disconnects (r.Context() is done).
+func (l *connectionLimiter) limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		release, ok := l.acquire()
+		if !ok {
+			w.Header().Set("Retry-After", "5")
+			http.Error(w, "Too many subscribers", http.StatusServiceUnavailable)
+			return
+		}
+		defer release()
+		next(w, r)
+	}
+}
+
+// defaultRefreshInterval is how often a client may force the refresh of the same chain
+const defaultRefreshInterval = 30 * time.Second
+
+// refreshLimiter allows each client one forced refresh of a chain, or of the whole upgrade list, per
+// interval, so ?refresh=true cannot be used to hammer the upstreams
+type refreshLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     map[refreshKey]time.Time
+	now      func() time.Time
+}
+
+type refreshKey struct {
+	client string
+	target string
+}
+
+func newRefreshLimiter(interval time.Duration) *refreshLimiter {
+	return &refreshLimiter{interval: interval, last: make(map[refreshKey]time.Time), now: time.Now}
+}
+
+// allow records a forced refresh of target by the client. A client that refreshed target within the
+// interval is refused, with how long until it may refresh again.
+func (l *refreshLimiter) allow(client, target string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	key := refreshKey{client, target}
+	if last, ok := l.last[key]; ok && now.Sub(last) < l.interval {
+		return false, l.interval - now.Sub(last)
+	}
+	// Entries past the interval no longer limit anything
+	if len(l.last) >= 1024 {
+		for k, last := range l.last {
+			if now.Sub(last) >= l.interval {
+				delete(l.last, k)
+			}
+		}
+	}
+	l.last[key] = now
+	return true, 0
+}
+
+// refreshAllowed tells whether the request asks for a forced refresh with ?refresh=true and, when it
+// does, takes the refresh of target from the client's allowance. A malformed value or a client over
+// its allowance is answered here, with 400 or 429, and reported as not ok.
+func (h *Handler) refreshAllowed(w http.ResponseWriter, r *http.Request, target string) (refresh, ok bool) {
+	value := r.URL.Query().Get("refresh")
+	if value == "" {
+		return false, true
+	}
+	refresh, err := strconv.ParseBool(value)
+	if err != nil {
+		h.handleError(w, r, fmt.Errorf("invalid refresh %q, expected true or false", value), http.StatusBadRequest)
+		return false, false
+	}
+	if !refresh {
+		return false, true
+	}
+
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+	if allowed, retryAfter := h.refreshLimiter.allow(client, target); !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		h.handleError(w, r, fmt.Errorf("refresh of %s already forced in the last %s", refreshTargetName(target), h.refreshLimiter.interval), http.StatusTooManyRequests)
+		return false, false
+	}
+	return true, true
+}
+
+// refreshTargetName names a refresh target in errors, all monitored chains for the empty target
+func refreshTargetName(target string) string {
+	if target == "" {
+		return "all chains"
+	}
+	return target
+}
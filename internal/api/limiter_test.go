@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionLimiter_RejectsExcessSubscribers(t *testing.T) {
+	limiter := newConnectionLimiter(2)
+	ts := httptest.NewServer(limiter.limit(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	subscribe := func(ctx context.Context) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	var cancels []context.CancelFunc
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+	for range 2 {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancels = append(cancels, cancel)
+		resp := subscribe(ctx)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, 2, limiter.inUse())
+
+	rejected := subscribe(context.Background())
+	rejected.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, rejected.StatusCode)
+	assert.NotEmpty(t, rejected.Header.Get("Retry-After"))
+
+	// A disconnecting subscriber frees its slot for the next one
+	cancels[0]()
+	require.Eventually(t, func() bool { return limiter.inUse() == 1 }, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancels = append(cancels, cancel)
+	resp := subscribe(ctx)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestNewConnectionLimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		max      int
+		expected int
+	}{
+		{"default", 0, defaultMaxConnections},
+		{"configured", 5, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newConnectionLimiter(tt.max)
+			require.NotNil(t, limiter)
+			assert.Equal(t, tt.expected, limiter.max)
+		})
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		limiter := newConnectionLimiter(-1)
+		assert.Nil(t, limiter)
+		release, ok := limiter.acquire()
+		assert.True(t, ok)
+		release()
+	})
+
+	t.Run("release is idempotent", func(t *testing.T) {
+		limiter := newConnectionLimiter(1)
+		release, ok := limiter.acquire()
+		require.True(t, ok)
+		release()
+		release()
+		assert.Equal(t, 0, limiter.inUse())
+	})
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/cron"
+	"github.com/prometheus/common/expfmt"
+	"gopkg.in/yaml.v3"
+)
+
+// Metric names exposed on /metrics, which the alerting rules on /metrics/rules are built from
+const (
+	metricSchedulerRunning      = "cosmos_watcher_scheduler_running"
+	metricUpgradeTimestamp      = "cosmos_watcher_upgrade_timestamp_seconds"
+	metricChainStale            = "cosmos_watcher_chain_stale"
+	metricChainHealthyEndpoints = "cosmos_watcher_chain_healthy_endpoints"
+	metricChainProbedEndpoints  = "cosmos_watcher_chain_probed_endpoints"
+)
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// PrometheusRules is a Prometheus alerting rules file
+type PrometheusRules struct {
+	Groups []PrometheusRuleGroup `yaml:"groups"`
+}
+
+type PrometheusRuleGroup struct {
+	Name  string           `yaml:"name"`
+	Rules []PrometheusRule `yaml:"rules"`
+}
+
+type PrometheusRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// GetMetrics exposes the state of the monitored chains in the Prometheus text format
+func (h *Handler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	chains, err := h.registry.GetMonitoredChains()
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	var b strings.Builder
+	writeMetricHeader(&b, metricSchedulerRunning, "Whether the job scheduler is running (1) or stopped (0).")
+	running := 0
+	if h.Scheduler.IsRunning() {
+		running = 1
+	}
+	fmt.Fprintf(&b, "%s %d\n", metricSchedulerRunning, running)
+
+	writeMetricHeader(&b, metricUpgradeTimestamp, "Estimated time of the next upgrade of the chain, in seconds since the epoch.")
+	for _, upgrade := range h.upcomingUpgrades(r.Context(), chains, "") {
+		fmt.Fprintf(&b, "%s{chain=\"%s\",network=\"%s\",version=\"%s\"} %d\n", metricUpgradeTimestamp,
+			labelValueEscaper.Replace(upgrade.ChainName),
+			labelValueEscaper.Replace(upgrade.Network),
+			labelValueEscaper.Replace(upgrade.Version),
+			upgrade.Time.Unix())
+	}
+
+	writeMetricHeader(&b, metricChainStale, "Whether the chain's data is served from the last snapshot or unavailable (1) or fresh (0).")
+	for _, name := range chains {
+		stale := 0
+		if info, err := h.registry.GetChainInfo(r.Context(), name, false); err != nil || info.Stale {
+			stale = 1
+		} else if upgrade, err := h.registry.GetUpgradeInfo(r.Context(), name, false); err == nil && upgrade.Stale {
+			stale = 1
+		}
+		fmt.Fprintf(&b, "%s{chain=\"%s\"} %d\n", metricChainStale, labelValueEscaper.Replace(name), stale)
+	}
+
+	writeMetricHeader(&b, metricChainHealthyEndpoints, "Public endpoints of the chain that responded to the latest probe.")
+	var probed strings.Builder
+	writeMetricHeader(&probed, metricChainProbedEndpoints, "Public endpoints of the chain checked by the latest probe.")
+	for _, name := range chains {
+		health, ok := h.registry.LastEndpointHealth(name)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "%s{chain=\"%s\"} %d\n", metricChainHealthyEndpoints, labelValueEscaper.Replace(name), health.Healthy)
+		fmt.Fprintf(&probed, "%s{chain=\"%s\"} %d\n", metricChainProbedEndpoints, labelValueEscaper.Replace(name), health.Total)
+	}
+	b.WriteString(probed.String())
+
+	// A failing collector leaves the others' families to write
+	families, err := h.gatherer.Gather()
+	if err != nil {
+		h.logger.Warnf("Failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(&b, family); err != nil {
+			h.logger.Warnf("Failed to write metric %s: %v", family.GetName(), err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+// GetMetricRules serves recommended Prometheus alerting rules for the metrics on /metrics
+func (h *Handler) GetMetricRules(w http.ResponseWriter, r *http.Request) {
+	data, err := yaml.Marshal(h.prometheusRules())
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Content-Disposition", `inline; filename="cosmos-watcher-rules.yml"`)
+	w.Write(data)
+}
+
+// prometheusRules builds the recommended alerting rules. Endpoints are only probed while an upgrade
+// is within the urgent window, so the endpoint rule looks no further ahead than that.
+func (h *Handler) prometheusRules() PrometheusRules {
+	urgentWindow := cron.DefaultUrgentWindow
+	if h.config != nil && h.config.Notifications.UrgentWindow != "" {
+		if d, err := time.ParseDuration(h.config.Notifications.UrgentWindow); err == nil {
+			urgentWindow = d
+		}
+	}
+	untilUpgrade := fmt.Sprintf("(%s - time())", metricUpgradeTimestamp)
+
+	return PrometheusRules{Groups: []PrometheusRuleGroup{{
+		Name: "cosmos-watcher",
+		Rules: []PrometheusRule{
+			{
+				Alert:  "CosmosUpgradeWithinOneHour",
+				Expr:   fmt.Sprintf("%s > 0 and %s < %d", untilUpgrade, untilUpgrade, int(time.Hour.Seconds())),
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "{{ $labels.chain }} upgrades to {{ $labels.version }} within the hour",
+					"description": "The {{ $labels.network }} upgrade of {{ $labels.chain }} to {{ $labels.version }} is estimated in {{ $value | humanizeDuration }}.",
+				},
+			},
+			{
+				Alert:  "CosmosChainDataStale",
+				Expr:   fmt.Sprintf("%s == 1", metricChainStale),
+				For:    "30m",
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "Data for {{ $labels.chain }} is stale",
+					"description": "cosmos-watcher has no fresh data for {{ $labels.chain }} and serves its last snapshot, if any. Check /api/v1/stats for the latest fetch error.",
+				},
+			},
+			{
+				Alert:  "CosmosWatcherSchedulerNotRunning",
+				Expr:   fmt.Sprintf("%s == 0 or absent(%s)", metricSchedulerRunning, metricSchedulerRunning),
+				For:    "5m",
+				Labels: map[string]string{"severity": "critical"},
+				Annotations: map[string]string{
+					"summary":     "cosmos-watcher is not checking for upgrades",
+					"description": "The job scheduler is stopped or cosmos-watcher is not being scraped, so no upgrade checks run.",
+				},
+			},
+			{
+				Alert: "CosmosChainAllEndpointsDown",
+				Expr: fmt.Sprintf("%s == 0 and on(chain) %s > 0 and on(chain) %s > 0 and on(chain) %s < %d",
+					metricChainHealthyEndpoints, metricChainProbedEndpoints, untilUpgrade, untilUpgrade, int(urgentWindow.Seconds())),
+				Labels: map[string]string{"severity": "critical"},
+				Annotations: map[string]string{
+					"summary":     "No endpoint of {{ $labels.chain }} responds ahead of its upgrade",
+					"description": "None of the public endpoints probed for {{ $labels.chain }} responded and an upgrade is due within " + urgentWindow.String() + ".",
+				},
+			},
+		},
+	}}}
+}
+
+func writeMetricHeader(b *strings.Builder, name, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMetricRulesReferenceExportedMetrics(t *testing.T) {
+	outage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer outage.Close()
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	snapshot := chain.Snapshot{
+		WrittenAt: time.Now().Add(-time.Hour),
+		Chains: map[string]*chain.ChainInfo{"osmosis": {
+			Name:    "osmosis",
+			ChainID: "osmosis-1",
+			Network: "mainnet",
+			APIs:    chain.APIs{RPC: []chain.Endpoint{{Address: outage.URL}}},
+		}},
+		Upgrades: map[string]*types.UpgradeInfo{"osmosis": {
+			Name:      "v25",
+			ChainName: "osmosis",
+			Version:   "v25.0.0",
+			Network:   "mainnet",
+			Height:    1000,
+			Time:      time.Now().Add(30 * time.Minute),
+		}},
+	}
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, outage.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"osmosis"})
+	require.NoError(t, registry.SetSnapshotPath(path))
+	health, err := registry.ProbeEndpoints(context.Background(), "osmosis")
+	require.NoError(t, err)
+	require.Equal(t, chain.EndpointHealth{Total: 1}, health)
+	handler := NewHandler(registry, logger, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	metrics := rr.Body.String()
+	assert.Contains(t, metrics, `cosmos_watcher_upgrade_timestamp_seconds{chain="osmosis",network="mainnet",version="v25.0.0"}`)
+	assert.Contains(t, metrics, `cosmos_watcher_chain_stale{chain="osmosis"} 1`)
+	assert.Contains(t, metrics, `cosmos_watcher_chain_healthy_endpoints{chain="osmosis"} 0`)
+	assert.Contains(t, metrics, "cosmos_watcher_scheduler_running 0")
+	assert.Contains(t, metrics, "# TYPE "+chain.MetricCacheLookups+" counter", "the registry's instrumentation is exported too")
+
+	exported := make(map[string]bool)
+	for _, match := range regexp.MustCompile(`(?m)^# TYPE (\S+) gauge$`).FindAllStringSubmatch(metrics, -1) {
+		exported[match[1]] = true
+	}
+	require.NotEmpty(t, exported)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics/rules", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var rules PrometheusRules
+	require.NoError(t, yaml.Unmarshal(rr.Body.Bytes(), &rules))
+	require.Len(t, rules.Groups, 1)
+
+	metricName := regexp.MustCompile(`cosmos_watcher_[a-z_]+`)
+	var alerts []string
+	for _, rule := range rules.Groups[0].Rules {
+		alerts = append(alerts, rule.Alert)
+		referenced := metricName.FindAllString(rule.Expr, -1)
+		require.NotEmpty(t, referenced, rule.Alert)
+		for _, name := range referenced {
+			assert.True(t, exported[name], "%s references %s, which /metrics does not export", rule.Alert, name)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"CosmosUpgradeWithinOneHour",
+		"CosmosChainDataStale",
+		"CosmosWatcherSchedulerNotRunning",
+		"CosmosChainAllEndpointsDown",
+	}, alerts)
+}
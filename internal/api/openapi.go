@@ -0,0 +1,429 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/internal/cron"
+	"github.com/0xPuncker/cosmos-watcher/internal/notifications"
+)
+
+// Version is the version of the watcher reported by the OpenAPI document, set at build time with
+// -ldflags "-X github.com/0xPuncker/cosmos-watcher/internal/api.Version=v1.2.3". Without it the
+// module version from the build info is used.
+var Version string
+
+// ErrorResponse is the body of the error responses
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// apiParameter is a query parameter of an API operation
+type apiParameter struct {
+	name        string
+	description string
+	// kind is the JSON schema type, string when empty
+	kind string
+	enum []string
+}
+
+// apiOperation describes a route of SetupRoutes for the OpenAPI document
+type apiOperation struct {
+	method string
+	// path is the route's path template as registered with the router
+	path    string
+	id      string
+	tag     string
+	summary string
+	query   []apiParameter
+	// body is a value of the request body's type, nil without a body
+	body any
+	// status is the success status, 200 when zero
+	status int
+	// response is a value of the JSON response's type; contentType is set instead for other responses
+	response    any
+	contentType string
+	// admin operations require the admin token
+	admin bool
+}
+
+var (
+	refreshParameter = apiParameter{name: "refresh", kind: "boolean", description: "Bypass the cache, rate-limited per client"}
+	networkParameter = apiParameter{name: "network", description: "Only the chains of this network", enum: []string{"mainnet", "testnet", "all"}}
+)
+
+// apiOperations are the operations of every route SetupRoutes registers, in the same order
+var apiOperations = []apiOperation{
+	{method: http.MethodGet, path: "/api/v1/health", id: "HealthCheck", tag: "health", summary: "Liveness check, reporting degraded and maintenance mode",
+		response: struct {
+			Status      string                   `json:"status"`
+			Degraded    *chain.DegradedStatus    `json:"degraded,omitempty"`
+			Maintenance *chain.MaintenanceStatus `json:"maintenance,omitempty"`
+		}{}},
+	{method: http.MethodGet, path: "/api/v1/ready", id: "ReadinessCheck", tag: "health", summary: "Readiness check, 503 until the initial load completed",
+		response: ReadinessResponse{}},
+	{method: http.MethodGet, path: "/api/v1/upgrades", id: "GetUpgrades", tag: "upgrades", summary: "Pending upgrades of the monitored chains",
+		query: []apiParameter{
+			{name: "group", description: "Only the chains of this group"},
+			networkParameter,
+			{name: "has_guide", kind: "boolean", description: "Only upgrades with or without a guide"},
+			{name: "include_completed", kind: "boolean", description: "Include the upgrades that already happened"},
+			{name: "limit", kind: "integer", description: "Page size"},
+			{name: "offset", kind: "integer", description: "Position of the page's first upgrade"},
+			{name: "sort", enum: []string{"name", "time", "height"}},
+			{name: "order", enum: []string{"asc", "desc"}},
+			refreshParameter,
+		},
+		response: UpgradesResponse{}},
+	{method: http.MethodGet, path: "/api/v1/upgrades/mainnet", id: "GetMainnetUpgrades", tag: "upgrades", summary: "Upgrades of the mainnet chains",
+		response: []chain.Upgrade{}},
+	{method: http.MethodGet, path: "/api/v1/upgrades/testnet", id: "GetTestnetUpgrades", tag: "upgrades", summary: "Upgrades of the testnet chains",
+		response: []chain.Upgrade{}},
+	{method: http.MethodGet, path: "/api/v1/upgrades/stream", id: "StreamUpgrades", tag: "upgrades", summary: "Server-sent events of the upgrade changes, starting with a snapshot",
+		contentType: "text/event-stream"},
+	{method: http.MethodGet, path: "/api/v1/ws", id: "ServeWebSocket", tag: "upgrades", summary: "WebSocket of the upgrade and job events", status: http.StatusSwitchingProtocols},
+	{method: http.MethodGet, path: "/api/v1/upgrades.ics", id: "GetUpgradesICS", tag: "upgrades", summary: "iCalendar feed of the upcoming upgrades",
+		contentType: "text/calendar"},
+	{method: http.MethodGet, path: "/api/v1/groups/{group}/upgrades.ics", id: "GetGroupUpgradesICS", tag: "upgrades", summary: "iCalendar feed of the upcoming upgrades of a group",
+		contentType: "text/calendar"},
+	{method: http.MethodGet, path: "/api/v1/groups/{group}/upgrades/{network:mainnet|testnet}.ics", id: "GetGroupNetworkUpgradesICS", tag: "upgrades", summary: "iCalendar feed of the upcoming upgrades of a group on one network",
+		contentType: "text/calendar"},
+	{method: http.MethodGet, path: "/api/v1/chains", id: "ListChains", tag: "chains", summary: "Monitored chains",
+		response: ChainsResponse{}},
+	{method: http.MethodPost, path: "/api/v1/chains", id: "AddChain", tag: "chains", summary: "Start monitoring a chain of the chain-registry",
+		body: AddChainRequest{}, status: http.StatusCreated, response: chain.ChainInfo{}, admin: true},
+	{method: http.MethodGet, path: "/api/v1/chains/export", id: "ExportChains", tag: "chains", summary: "Monitored chains in chains.yaml format",
+		contentType: "application/yaml"},
+	{method: http.MethodGet, path: "/api/v1/chains/{chainName}", id: "GetChainInfo", tag: "chains", summary: "Chain info with the live height",
+		query: []apiParameter{refreshParameter}, response: ChainInfoResponse{}},
+	{method: http.MethodPost, path: "/api/v1/chains/{chainName}/snooze", id: "SnoozeChain", tag: "chains", summary: "Suppress the chain's notifications for a while",
+		query: []apiParameter{{name: "duration", description: "Snooze duration, such as 4h"}}, response: cron.SnoozeStatus{}, admin: true},
+	{method: http.MethodGet, path: "/api/v1/chains/{chainName}/assets", id: "GetAssetList", tag: "chains", summary: "Denoms of the chain's assetlist.json",
+		response: chain.AssetList{}},
+	{method: http.MethodGet, path: "/api/v1/chains/{chainName}/versions", id: "GetChainVersions", tag: "chains", summary: "Version history of the chain",
+		response: chain.VersionHistory{}},
+	{method: http.MethodGet, path: "/api/v1/chains/{chainName}/upgrade/binaries", id: "GetUpgradeBinaries", tag: "chains", summary: "Download URLs of the pending upgrade by platform",
+		response: map[string]chain.UpgradeBinary{}},
+	{method: http.MethodGet, path: "/api/v1/chains/{chainName}/endpoints", id: "GetChainEndpoints", tag: "chains", summary: "Latest health probe of the chain's endpoints",
+		response: chain.EndpointReport{}},
+	{method: http.MethodGet, path: "/api/v1/chains/{chainName}/peers", id: "GetChainPeers", tag: "chains", summary: "Seeds and persistent peers of the chain",
+		query:    []apiParameter{{name: "format", description: "config renders the p2p lines of config.toml as text", enum: []string{"json", "config"}}},
+		response: PeersResponse{}},
+	{method: http.MethodGet, path: "/api/v1/registry/chains", id: "GetRegistryChains", tag: "registry", summary: "Chains published in the chain-registry",
+		response: chain.DiscoveredChains{}},
+	{method: http.MethodPost, path: "/api/v1/registry/refresh", id: "RefreshRegistry", tag: "registry", summary: "Force-refresh every monitored chain",
+		response: chain.RefreshReport{}, admin: true},
+	{method: http.MethodGet, path: "/api/v1/stats", id: "GetStats", tag: "stats", summary: "Monitoring statistics",
+		response: StatsResponse{}},
+	{method: http.MethodGet, path: "/api/v1/notifications/recent", id: "GetRecentNotifications", tag: "stats", summary: "Recently sent notifications, newest first",
+		response: struct {
+			Notifications []notifications.SentNotification `json:"notifications"`
+			Count         int                              `json:"count"`
+		}{}},
+	{method: http.MethodGet, path: "/metrics", id: "GetMetrics", tag: "metrics", summary: "Metrics in the Prometheus text format",
+		contentType: "text/plain"},
+	{method: http.MethodGet, path: "/metrics/rules", id: "GetMetricRules", tag: "metrics", summary: "Recommended Prometheus alerting rules",
+		contentType: "application/yaml"},
+	{method: http.MethodGet, path: "/api/v1/jobs", id: "ListJobs", tag: "jobs", summary: "Scheduled jobs",
+		response: struct {
+			Jobs       []cron.JobState `json:"jobs"`
+			ActiveJobs int             `json:"active_jobs"`
+		}{}},
+	{method: http.MethodPost, path: "/api/v1/jobs", id: "AddJob", tag: "jobs", summary: "Schedule a new job",
+		body: AddJobRequest{}, status: http.StatusCreated, response: cron.JobState{}, admin: true},
+	{method: http.MethodGet, path: "/api/v1/jobs/{name}", id: "GetJobStatus", tag: "jobs", summary: "Status of a job",
+		response: JobStatusResponse{}},
+	{method: http.MethodDelete, path: "/api/v1/jobs/{name}", id: "RemoveJob", tag: "jobs", summary: "Remove a job from the schedule",
+		query: []apiParameter{{name: "force", kind: "boolean", description: "Also remove a predefined job"}}, response: JobRemovedResponse{}, admin: true},
+	{method: http.MethodGet, path: "/api/v1/jobs/{name}/history", id: "GetJobHistory", tag: "jobs", summary: "Latest runs of a job, newest first",
+		query: []apiParameter{{name: "limit", kind: "integer", description: "How many runs, 20 by default"}}, response: JobHistoryResponse{}},
+	{method: http.MethodPost, path: "/api/v1/jobs/{name}/run", id: "RunJob", tag: "jobs", summary: "Run a job right away",
+		status: http.StatusAccepted, response: JobRunResponse{}, admin: true},
+	{method: http.MethodPost, path: "/api/v1/jobs/{name}/enable", id: "EnableJob", tag: "jobs", summary: "Schedule a disabled job again",
+		response: JobStatusResponse{}, admin: true},
+	{method: http.MethodPost, path: "/api/v1/jobs/{name}/disable", id: "DisableJob", tag: "jobs", summary: "Stop scheduling a job",
+		response: JobStatusResponse{}, admin: true},
+	{method: http.MethodPost, path: "/api/v1/scheduler/start", id: "StartScheduler", tag: "jobs", summary: "Start the scheduler",
+		response: struct {
+			Status string `json:"status"`
+		}{}, admin: true},
+	{method: http.MethodPost, path: "/api/v1/scheduler/stop", id: "StopScheduler", tag: "jobs", summary: "Stop the scheduler",
+		response: struct {
+			Status string `json:"status"`
+		}{}, admin: true},
+	{method: http.MethodPost, path: "/api/v1/degraded/enable", id: "EnableDegradedMode", tag: "modes", summary: "Pause external fetching",
+		query: []apiParameter{{name: "reason"}}, response: chain.DegradedStatus{}, admin: true},
+	{method: http.MethodPost, path: "/api/v1/degraded/disable", id: "DisableDegradedMode", tag: "modes", summary: "Resume external fetching",
+		response: chain.DegradedStatus{}, admin: true},
+	{method: http.MethodPost, path: "/api/v1/maintenance", id: "SetMaintenance", tag: "modes", summary: "Pause or resume all monitoring and notifications",
+		query: []apiParameter{{name: "enabled", kind: "boolean"}, {name: "reason"}}, response: chain.MaintenanceStatus{}, admin: true},
+	{method: http.MethodGet, path: "/api/v1/openapi.json", id: "GetOpenAPI", tag: "docs", summary: "This OpenAPI document",
+		contentType: "application/json"},
+	{method: http.MethodGet, path: "/api/v1/docs", id: "GetDocs", tag: "docs", summary: "API documentation page",
+		contentType: "text/html"},
+}
+
+// routeVariable matches the variables of mux path templates, with their optional pattern
+var routeVariable = regexp.MustCompile(`\{([^}:]+)(?::([^}]+))?\}`)
+
+// openAPIPath turns a mux path template into an OpenAPI path, returning its path parameters. A
+// variable whose pattern is a list of alternatives becomes an enum.
+func openAPIPath(template string) (string, []map[string]any) {
+	var parameters []map[string]any
+	for _, match := range routeVariable.FindAllStringSubmatch(template, -1) {
+		schema := map[string]any{"type": "string"}
+		if match[2] != "" && regexp.MustCompile(`^[\w-]+(\|[\w-]+)*$`).MatchString(match[2]) {
+			schema["enum"] = strings.Split(match[2], "|")
+		}
+		parameters = append(parameters, map[string]any{"name": match[1], "in": "path", "required": true, "schema": schema})
+	}
+	return routeVariable.ReplaceAllString(template, "{$1}"), parameters
+}
+
+// buildVersion returns the version of the watcher, "dev" when neither set nor known from the build
+func buildVersion() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// openAPIDocument builds the OpenAPI 3 document of apiOperations. The paths are absolute, so serverURL
+// is the root the API is served at.
+func openAPIDocument(serverURL, version string) map[string]any {
+	schemas := newSchemaBuilder()
+	errorResponse := map[string]any{
+		"description": "Error",
+		"content":     map[string]any{"application/json": map[string]any{"schema": schemas.schema(reflect.TypeOf(ErrorResponse{}))}},
+	}
+
+	paths := map[string]any{}
+	for _, op := range apiOperations {
+		path, parameters := openAPIPath(op.path)
+		for _, param := range op.query {
+			schema := map[string]any{"type": "string"}
+			if param.kind != "" {
+				schema["type"] = param.kind
+			}
+			if param.enum != nil {
+				schema["enum"] = param.enum
+			}
+			parameter := map[string]any{"name": param.name, "in": "query", "schema": schema}
+			if param.description != "" {
+				parameter["description"] = param.description
+			}
+			parameters = append(parameters, parameter)
+		}
+
+		status := op.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]any{"description": http.StatusText(status)}
+		switch {
+		case op.response != nil:
+			success["content"] = map[string]any{"application/json": map[string]any{"schema": schemas.schema(reflect.TypeOf(op.response))}}
+		case op.contentType != "":
+			success["content"] = map[string]any{op.contentType: map[string]any{"schema": map[string]any{"type": "string"}}}
+		}
+		operation := map[string]any{
+			"operationId": op.id,
+			"summary":     op.summary,
+			"tags":        []string{op.tag},
+			"responses": map[string]any{
+				strconv.Itoa(status): success,
+				"default":            errorResponse,
+			},
+		}
+		if parameters != nil {
+			operation["parameters"] = parameters
+		}
+		if op.body != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": schemas.schema(reflect.TypeOf(op.body))}},
+			}
+		}
+		if op.admin {
+			operation["security"] = []map[string][]string{{"adminToken": {}}}
+		}
+
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[strings.ToLower(op.method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Cosmos Watcher API",
+			"description": "Upgrades and chain info of the Cosmos chains monitored by the watcher",
+			"version":     version,
+		},
+		"servers": []map[string]any{{"url": serverURL}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]any{
+				"adminToken": map[string]any{"type": "http", "scheme": "bearer", "description": "server.admin_token"},
+			},
+		},
+	}
+}
+
+// GetOpenAPI serves the OpenAPI document of the API. Its server is server.public_url, the host the
+// document was requested from without it.
+func (h *Handler) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	serverURL := "/"
+	if h.config != nil && h.config.Server.PublicURL != "" {
+		serverURL = strings.TrimSuffix(h.config.Server.PublicURL, "/")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(openAPIDocument(serverURL, buildVersion()))
+}
+
+// docsPage renders the OpenAPI document with ReDoc
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Cosmos Watcher API</title>
+</head>
+<body>
+<redoc spec-url="openapi.json"></redoc>
+<script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+// GetDocs serves a page documenting the API from its OpenAPI document
+func (h *Handler) GetDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(docsPage))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaBuilder derives JSON schemas from Go types as encoding/json marshals them. Named structs are
+// added to components and referenced.
+type schemaBuilder struct {
+	components map[string]any
+	names      map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{components: map[string]any{}, names: map[reflect.Type]string{}}
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		name, ok := b.names[t]
+		if !ok {
+			name = b.componentName(t)
+			b.names[t] = name
+			// Registered before its fields are walked, so recursive types terminate
+			b.components[name] = nil
+			b.components[name] = b.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	// Interfaces and anything else may hold any value
+	return map[string]any{}
+}
+
+// componentName names the component of t after the type, prefixed with its package when another type
+// of the same name was added already
+func (b *schemaBuilder) componentName(t reflect.Type) string {
+	name := t.Name()
+	if _, taken := b.components[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	return name
+}
+
+// object returns the schema of a struct, with the fields of embedded structs inlined. Fields without
+// omitempty or omitzero are required.
+func (b *schemaBuilder) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded := b.object(fieldType)
+			for key, value := range embedded["properties"].(map[string]any) {
+				properties[key] = value
+			}
+			if fields, ok := embedded["required"].([]string); ok {
+				required = append(required, fields...)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schema(field.Type)
+		if !strings.Contains(options, "omitempty") && !strings.Contains(options, "omitzero") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if required != nil {
+		schema["required"] = required
+	}
+	return schema
+}
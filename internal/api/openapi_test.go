@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/0xPuncker/cosmos-watcher/internal/testutil"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPIDoc is the part of the OpenAPI document the tests look at
+type openAPIDoc struct {
+	OpenAPI string `json:"openapi"`
+	Info    struct {
+		Version string `json:"version"`
+	} `json:"info"`
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Type       string                     `json:"type"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func TestGetOpenAPI(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	cfg := &config.Config{Server: config.ServerConfig{PublicURL: "https://watcher.example.com/"}}
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, cfg)
+	Version = "v1.2.3"
+	defer func() { Version = "" }()
+
+	req := httptest.NewRequest(http.MethodGet, apiPath+"/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var doc openAPIDoc
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, "v1.2.3", doc.Info.Version)
+	require.Len(t, doc.Servers, 1)
+	assert.Equal(t, "https://watcher.example.com", doc.Servers[0].URL)
+
+	// Every route of the router is documented, and nothing else is
+	router := mux.NewRouter()
+	SetupRoutes(router, handler)
+	routes := map[string]bool{}
+	require.NoError(t, router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		require.NoError(t, err)
+		methods, err := route.GetMethods()
+		require.NoError(t, err)
+		path, _ := openAPIPath(template)
+		for _, method := range methods {
+			routes[strings.ToLower(method)+" "+path] = true
+			assert.Contains(t, doc.Paths[path], strings.ToLower(method), "%s %s is not documented", method, template)
+		}
+		return nil
+	}))
+	for path, item := range doc.Paths {
+		for method := range item {
+			assert.True(t, routes[method+" "+path], "%s %s is documented but not routed", method, path)
+		}
+	}
+
+	for _, name := range []string{"UpgradesResponse", "ChainUpgrade", "ChainInfo", "ErrorResponse"} {
+		require.Contains(t, doc.Components.Schemas, name)
+		assert.Equal(t, "object", doc.Components.Schemas[name].Type)
+	}
+	assert.Contains(t, doc.Components.Schemas["UpgradesResponse"].Properties, "chains")
+	assert.Contains(t, doc.Components.Schemas["ChainUpgrade"].Properties, "estimated_at")
+	assert.Contains(t, doc.Components.Schemas["ErrorResponse"].Properties, "error")
+	assert.JSONEq(t, `{"type":"string","enum":["mainnet","testnet"]}`,
+		string(pathParameterSchema(t, doc, "/api/v1/groups/{group}/upgrades/{network}.ics", "network")), "route patterns become enums")
+}
+
+// pathParameterSchema returns the schema of a path parameter of the GET operation of path
+func pathParameterSchema(t *testing.T, doc openAPIDoc, path, name string) json.RawMessage {
+	t.Helper()
+	var operation struct {
+		Parameters []struct {
+			Name   string          `json:"name"`
+			In     string          `json:"in"`
+			Schema json.RawMessage `json:"schema"`
+		} `json:"parameters"`
+	}
+	require.NoError(t, json.Unmarshal(doc.Paths[path]["get"], &operation))
+	for _, parameter := range operation.Parameters {
+		if parameter.In == "path" && parameter.Name == name {
+			return parameter.Schema
+		}
+	}
+	t.Fatalf("no path parameter %s on %s", name, path)
+	return nil
+}
+
+func TestGetDocs(t *testing.T) {
+	handler := NewHandler(testutil.NewFakeRegistry(), logrus.New(), &config.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, apiPath+"/docs", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), `spec-url="openapi.json"`)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// upgradesPageParams are the query parameters of GET /upgrades that only sort and slice the collected
+// upgrades, so requests differing by them share one fan-out
+var upgradesPageParams = []string{"limit", "offset", "sort", "order"}
+
+// upgradesPage is how the upgrades are sorted and which of them are returned
+type upgradesPage struct {
+	// sort is name, time or height
+	sort   string
+	desc   bool
+	limit  int
+	offset int
+}
+
+// parseUpgradesPage reads ?limit=, ?offset=, ?sort=time|name|height and ?order=asc|desc. Without them
+// every upgrade is returned in name order.
+func parseUpgradesPage(query url.Values) (upgradesPage, error) {
+	page := upgradesPage{sort: "name"}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return page, fmt.Errorf("invalid limit %q, expected a positive integer", value)
+		}
+		page.limit = limit
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return page, fmt.Errorf("invalid offset %q, expected a non-negative integer", value)
+		}
+		page.offset = offset
+	}
+	switch value := query.Get("sort"); value {
+	case "", "name":
+	case "time", "height":
+		page.sort = value
+	default:
+		return page, fmt.Errorf("invalid sort %q, expected time, name or height", value)
+	}
+	switch value := query.Get("order"); value {
+	case "", "asc":
+	case "desc":
+		page.desc = true
+	default:
+		return page, fmt.Errorf("invalid order %q, expected asc or desc", value)
+	}
+	return page, nil
+}
+
+// apply returns the page of the collected upgrades, which are left untouched since the response may
+// be shared between requests. An offset past the last upgrade gives an empty page.
+func (p upgradesPage) apply(collected *UpgradesResponse) *UpgradesResponse {
+	chains := append([]ChainUpgrade(nil), collected.Chains...)
+	sort.SliceStable(chains, func(i, j int) bool { return p.less(chains[i], chains[j]) })
+
+	total := len(chains)
+	start := min(p.offset, total)
+	end := total
+	if p.limit > 0 {
+		end = min(start+p.limit, total)
+	}
+	return &UpgradesResponse{
+		Chains:      chains[start:end],
+		LastUpdated: collected.LastUpdated,
+		Total:       total,
+		Limit:       p.limit,
+		Offset:      p.offset,
+	}
+}
+
+// less orders upgrades by the page's sort key, upgrades without a time or height last whatever the
+// order, and by name and network between equal keys
+func (p upgradesPage) less(a, b ChainUpgrade) bool {
+	var result int
+	switch p.sort {
+	case "time":
+		if a.estimatedTime.IsZero() != b.estimatedTime.IsZero() {
+			return b.estimatedTime.IsZero()
+		}
+		result = a.estimatedTime.Compare(b.estimatedTime)
+	case "height":
+		if (a.Height == 0) != (b.Height == 0) {
+			return b.Height == 0
+		}
+		result = cmp.Compare(a.Height, b.Height)
+	default:
+		result = cmp.Compare(a.Name, b.Name)
+	}
+	if p.desc {
+		result = -result
+	}
+	if result == 0 {
+		result = cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Network, b.Network))
+	}
+	return result < 0
+}
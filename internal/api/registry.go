@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/internal/cron"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// Registry is the part of the chain registry the handlers depend on, on top of what the jobs they
+// schedule need
+type Registry interface {
+	cron.Registry
+	AddMonitoredChain(cfg types.ChainConfig) (bool, error)
+	RegistryPaths(chainName string) []string
+	GetUpgrades(ctx context.Context, chainID string) ([]chain.Upgrade, error)
+	GetLatestBlock(ctx context.Context, chainName string) (chain.LatestBlock, error)
+	GetAssetList(ctx context.Context, chainName string) (*chain.AssetList, error)
+	GetVersions(ctx context.Context, chainName string) (*chain.VersionHistory, error)
+	GetEndpointReport(ctx context.Context, chainName string) (*chain.EndpointReport, error)
+	LastEndpointHealth(chainName string) (chain.EndpointHealth, bool)
+	DiscoverChains(ctx context.Context) (*chain.DiscoveredChains, error)
+	RefreshAll(ctx context.Context) (chain.RefreshReport, error)
+	LastErrors() []chain.ChainError
+	DegradedStatus() chain.DegradedStatus
+	LastChainInfoFetch() time.Time
+	SetDegraded(enabled bool, reason string)
+	MaintenanceStatus() chain.MaintenanceStatus
+	SetMaintenance(enabled bool, reason string)
+}
+
+var _ Registry = (*chain.ChainRegistry)(nil)
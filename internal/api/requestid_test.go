@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/0xPuncker/cosmos-watcher/internal/testutil"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		provided string
+		echoed   bool
+	}{
+		{"provided ID is echoed", "client-42", true},
+		{"missing ID is generated", "", false},
+		{"ID with spaces is replaced", "two words", false},
+		{"overlong ID is replaced", strings.Repeat("x", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+			if tt.provided != "" {
+				req.Header.Set(RequestIDHeader, tt.provided)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			id := rr.Header().Get(RequestIDHeader)
+			if tt.echoed {
+				assert.Equal(t, tt.provided, id)
+			} else {
+				assert.Regexp(t, uuidPattern, id)
+			}
+			assert.Equal(t, id, seen, "the handler sees the ID returned to the client")
+		})
+	}
+
+	first, second := httptest.NewRecorder(), httptest.NewRecorder()
+	handler.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+	handler.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+	assert.NotEqual(t, first.Header().Get(RequestIDHeader), second.Header().Get(RequestIDHeader))
+}
+
+func TestHandleError_LogsRequestID(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, apiPath+"/chains/unknown", nil)
+	req.Header.Set(RequestIDHeader, "client-42")
+	rr := httptest.NewRecorder()
+	RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.handleError(w, r, errors.New("chain not found"), http.StatusNotFound)
+	})).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, "chain not found", entry.Message)
+	assert.Equal(t, "client-42", entry.Data["request_id"])
+}
@@ -1,11 +1,63 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/gorilla/mux"
 )
 
+// Names of the routes streaming their responses, which are never compressed
+const (
+	routeUpgradesStream = "upgrades-stream"
+	routeWebSocket      = "websocket"
+)
+
 func SetupRoutes(router *mux.Router, handler *Handler) {
-	router.HandleFunc("/api/v1/health", handler.HealthCheck).Methods("GET")
-	router.HandleFunc("/api/v1/upgrades", handler.GetUpgrades).Methods("GET")
-	router.HandleFunc("/api/v1/chains/{chainName}", handler.GetChainInfo).Methods("GET")
+	compressionMinSize := 0
+	if handler.config != nil {
+		compressionMinSize = handler.config.Server.CompressionMinSize
+	}
+	router.Use(compressionMiddleware(compressionMinSize))
+
+	router.HandleFunc("/api/v1/health", handler.HealthCheck).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/ready", handler.ReadinessCheck).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/upgrades", handler.GetUpgrades).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/upgrades/mainnet", handler.GetMainnetUpgrades).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/upgrades/testnet", handler.GetTestnetUpgrades).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/upgrades/stream", handler.sseLimiter.limit(handler.StreamUpgrades)).Methods(http.MethodGet).Name(routeUpgradesStream)
+	router.HandleFunc("/api/v1/ws", handler.wsLimiter.limit(handler.ServeWebSocket)).Methods(http.MethodGet).Name(routeWebSocket)
+	router.HandleFunc("/api/v1/upgrades.ics", handler.GetUpgradesICS).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/groups/{group}/upgrades.ics", handler.GetGroupUpgradesICS).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/groups/{group}/upgrades/{network:mainnet|testnet}.ics", handler.GetGroupUpgradesICS).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/chains", handler.ListChains).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/chains", handler.requireAdminToken(handler.AddChain)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/chains/export", handler.ExportChains).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/chains/{chainName}", handler.GetChainInfo).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/chains/{chainName}/snooze", handler.requireAdminToken(handler.SnoozeChain)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/chains/{chainName}/assets", handler.GetAssetList).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/chains/{chainName}/versions", handler.GetChainVersions).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/chains/{chainName}/upgrade/binaries", handler.GetUpgradeBinaries).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/chains/{chainName}/endpoints", handler.GetChainEndpoints).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/chains/{chainName}/peers", handler.GetChainPeers).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/registry/chains", handler.GetRegistryChains).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/registry/refresh", handler.requireAdminToken(handler.RefreshRegistry)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/stats", handler.GetStats).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/notifications/recent", handler.GetRecentNotifications).Methods(http.MethodGet)
+	router.HandleFunc("/metrics", handler.GetMetrics).Methods(http.MethodGet)
+	router.HandleFunc("/metrics/rules", handler.GetMetricRules).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/jobs", handler.ListJobs).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/jobs", handler.requireAdminToken(handler.AddJob)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/jobs/{name}", handler.GetJobStatus).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/jobs/{name}", handler.requireAdminToken(handler.RemoveJob)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/jobs/{name}/history", handler.GetJobHistory).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/jobs/{name}/run", handler.requireAdminToken(handler.RunJob)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/jobs/{name}/enable", handler.requireAdminToken(handler.EnableJob)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/jobs/{name}/disable", handler.requireAdminToken(handler.DisableJob)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/scheduler/start", handler.requireAdminToken(handler.StartScheduler)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/scheduler/stop", handler.requireAdminToken(handler.StopScheduler)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/degraded/enable", handler.requireAdminToken(handler.EnableDegradedMode)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/degraded/disable", handler.requireAdminToken(handler.DisableDegradedMode)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/maintenance", handler.requireAdminToken(handler.SetMaintenance)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/openapi.json", handler.GetOpenAPI).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/docs", handler.GetDocs).Methods(http.MethodGet)
 }
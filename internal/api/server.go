@@ -1,8 +1,10 @@
 package api
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,13 +17,11 @@ import (
 func StartServer(ctx context.Context, handler *Handler, port string) error {
 	router := mux.NewRouter()
 
+	router.Use(RequestIDMiddleware)
 	router.Use(loggingMiddleware(handler.logger))
 	router.Use(corsMiddleware)
 
-	router.HandleFunc("/api/v1/health", handler.HealthCheck).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/upgrades/mainnet", handler.GetMainnetUpgrades).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/upgrades/testnet", handler.GetTestnetUpgrades).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/chains/{chainName}", handler.GetChainInfo).Methods(http.MethodGet)
+	SetupRoutes(router, handler)
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%s", port),
@@ -77,6 +77,7 @@ func loggingMiddleware(logger *logrus.Logger) mux.MiddlewareFunc {
 				"duration":   durationStr,
 				"user_agent": r.UserAgent(),
 				"remote_ip":  r.RemoteAddr,
+				"request_id": RequestIDFromContext(r.Context()),
 			}).Info("Request processed")
 		})
 	}
@@ -86,7 +87,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -110,3 +112,16 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.status = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Hijack hands the connection over to WebSocket handlers, which answer with 101 Switching Protocols
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.status = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
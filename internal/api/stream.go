@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/events"
+)
+
+// sseKeepaliveInterval is how often an idle event stream sends a comment, so proxies and clients do
+// not take it for a dead connection
+const sseKeepaliveInterval = 15 * time.Second
+
+// snapshotEvent is the event an upgrade stream starts with
+const snapshotEvent = "snapshot"
+
+// StreamUpgrades streams the changes to the upgrades of the monitored chains as server-sent events. The
+// stream starts with a snapshot event holding what GET /upgrades returns, followed by upgrade_added,
+// upgrade_changed and upgrade_removed events as the upgrade checker detects them. A client falling
+// too far behind is disconnected and gets a fresh snapshot when it reconnects.
+func (h *Handler) StreamUpgrades(w http.ResponseWriter, r *http.Request) {
+	// Subscribed before the snapshot is taken, so no change made while it is collected is missed
+	subscription := h.events.Subscribe()
+	defer subscription.Close()
+
+	chains, err := h.registry.GetMonitoredChains()
+	if err != nil {
+		h.handleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	snapshot, err := h.collectUpgrades(r.Context(), chains, nil, false, false)
+	if err != nil {
+		// The client went away while the snapshot was collected
+		return
+	}
+	snapshot.Total = len(snapshot.Chains)
+
+	rc := http.NewResponseController(w)
+	// The stream is meant to outlive the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Debugf("Failed to clear the write deadline of an event stream: %v", err)
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := writeEvent(w, 0, snapshotEvent, snapshot); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		h.logger.Debugf("Event stream cannot be flushed: %v", err)
+		return
+	}
+
+	keepalive := time.NewTicker(h.sseKeepalive)
+	defer keepalive.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				// Dropped for falling behind, or the server is shutting down
+				return
+			}
+			if event.Type.Topic() != events.TopicUpgrades {
+				continue
+			}
+			err = writeEvent(w, event.ID, string(event.Type), event)
+		case <-keepalive.C:
+			_, err = io.WriteString(w, ": keepalive\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes data as a server-sent event frame of name, led by an id line unless id is zero
+func writeEvent(w io.Writer, id uint64, name string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+	return err
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/0xPuncker/cosmos-watcher/internal/events"
+	"github.com/0xPuncker/cosmos-watcher/internal/testutil"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readFrame reads the lines of the next server-sent event frame, up to the blank line ending it
+func readFrame(t *testing.T, r *bufio.Reader) []string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestStreamUpgrades(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"})
+	registry.Upgrades["cosmoshub"] = &types.UpgradeInfo{ChainName: "cosmoshub", Network: "mainnet", Version: "v25.0.0", Height: 1000000, Time: time.Now().Add(24 * time.Hour)}
+	handler := NewHandler(registry, logger, &config.Config{})
+	handler.sseKeepalive = 50 * time.Millisecond
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	// The client asks for gzip by default, event streams must still be sent as they are written
+	resp, err := http.Get(server.URL + apiPath + "/upgrades/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+	body := bufio.NewReader(resp.Body)
+
+	frame := readFrame(t, body)
+	require.Len(t, frame, 2)
+	assert.Equal(t, "event: snapshot", frame[0])
+	var snapshot UpgradesResponse
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(frame[1], "data: ")), &snapshot))
+	require.Len(t, snapshot.Chains, 1)
+	assert.Equal(t, "v25.0.0", snapshot.Chains[0].Version)
+	assert.Equal(t, 1, snapshot.Total)
+
+	changed := &types.UpgradeInfo{ChainName: "cosmoshub", Network: "mainnet", Version: "v25.1.0", Height: 1000500}
+	handler.events.Publish(events.Event{Type: events.UpgradeChanged, Chain: "cosmoshub", Upgrade: changed})
+	frame = readFrame(t, body)
+	for frame[0] == ": keepalive" {
+		frame = readFrame(t, body)
+	}
+	require.Len(t, frame, 3)
+	assert.Equal(t, "id: 1", frame[0])
+	assert.Equal(t, "event: upgrade_changed", frame[1])
+	require.True(t, strings.HasPrefix(frame[2], "data: "))
+	var event events.Event
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(frame[2], "data: ")), &event))
+	assert.Equal(t, events.UpgradeChanged, event.Type)
+	assert.Equal(t, "cosmoshub", event.Chain)
+	assert.Equal(t, "v25.1.0", event.Upgrade.Version)
+	assert.Equal(t, int64(1000500), event.Upgrade.Height)
+
+	assert.Equal(t, []string{": keepalive"}, readFrame(t, body), "an idle stream sends keepalive comments")
+
+	resp.Body.Close()
+	assert.Eventually(t, func() bool { return handler.events.Subscribers() == 0 }, time.Second, 10*time.Millisecond,
+		"a disconnected client is unsubscribed")
+	assert.Eventually(t, func() bool { return handler.sseLimiter.inUse() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestStreamUpgrades_Shutdown(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + apiPath + "/upgrades/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body := bufio.NewReader(resp.Body)
+	assert.Equal(t, "event: snapshot", readFrame(t, body)[0])
+
+	handler.events.Close()
+	_, err = body.ReadString('\n')
+	assert.ErrorIs(t, err, io.EOF, "closing the bus ends the stream")
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/events"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsPingInterval is how often the server pings WebSocket clients; a client that has not answered
+	// for two intervals is disconnected
+	wsPingInterval = 30 * time.Second
+	// wsWriteWait is how long a write to a WebSocket client may take
+	wsWriteWait = 10 * time.Second
+	// wsMaxMessageSize is the largest message a WebSocket client may send
+	wsMaxMessageSize = 4096
+	// wsSubscriptionTopic is the topic of the replies to subscribe messages
+	wsSubscriptionTopic = "subscription"
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// The API serves every origin, as its CORS headers tell browsers
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// wsEnvelope is every message sent to WebSocket clients:
+//
+//	{"topic":"upgrades","type":"changed","data":{...}}
+//
+// Topic is the topic of the event, "upgrades" or "jobs", and Type what happened in it: added, changed
+// or removed for upgrades, where Data is the upgrade (the one that went away for removed), and
+// started, succeeded or failed for jobs, where Data is the job run. Replies to subscribe messages have
+// the topic "subscription" and the type "subscribed", with the subscription as Data, or "error", with
+// {"error":"..."} as Data.
+type wsEnvelope struct {
+	Topic string `json:"topic"`
+	Type  string `json:"type"`
+	Data  any    `json:"data"`
+}
+
+// wsSubscription is the subscribe message of a WebSocket client, which replaces the previous one.
+// Empty topics subscribe to every topic and empty chains to the upgrades of every chain; chains do
+// not filter job events.
+type wsSubscription struct {
+	Topics []events.Topic `json:"topics"`
+	Chains []string       `json:"chains"`
+}
+
+// matches reports whether event is one the subscription asked for
+func (s *wsSubscription) matches(event events.Event) bool {
+	topic := event.Type.Topic()
+	if len(s.Topics) > 0 && !slices.Contains(s.Topics, topic) {
+		return false
+	}
+	return topic != events.TopicUpgrades || len(s.Chains) == 0 || slices.Contains(s.Chains, event.Chain)
+}
+
+// ServeWebSocket streams upgrade and job events over a WebSocket. Nothing is sent until the client
+// subscribes with a message such as {"topics":["upgrades","jobs"],"chains":["osmosis"]}, which it may
+// send again to change its subscription. Events are filtered server-side and sent as wsEnvelope
+// messages. The server pings the client and drops it when it stops answering, and a client falling
+// too far behind the events is disconnected with a 1013 (try again later) close frame.
+func (h *Handler) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already answered the request
+		h.logger.Debugf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// The subscription's buffer bounds what is queued for the client
+	subscription := h.events.Subscribe()
+	defer subscription.Close()
+
+	pongWait := 2 * h.wsPingInterval
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	// Reads happen on their own goroutine, every write on this one
+	messages := make(chan []byte)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(messages)
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			select {
+			case messages <- message:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(h.wsPingInterval)
+	defer ping.Stop()
+	var filter *wsSubscription
+	for {
+		var err error
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				// The client went away or stopped answering pings
+				return
+			}
+			var reply wsEnvelope
+			filter, reply = parseWSSubscription(message, filter)
+			err = writeWS(conn, reply)
+		case event, ok := <-subscription.Events():
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "event stream ended"),
+					time.Now().Add(wsWriteWait))
+				return
+			}
+			if filter == nil || !filter.matches(event) {
+				continue
+			}
+			err = writeWS(conn, wsEventEnvelope(event))
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// parseWSSubscription returns the subscription a client's message asks for along with the reply to
+// send, keeping the current subscription when the message is invalid
+func parseWSSubscription(message []byte, current *wsSubscription) (*wsSubscription, wsEnvelope) {
+	fail := func(err error) (*wsSubscription, wsEnvelope) {
+		return current, wsEnvelope{Topic: wsSubscriptionTopic, Type: "error", Data: map[string]string{"error": err.Error()}}
+	}
+
+	var subscription wsSubscription
+	if err := json.Unmarshal(message, &subscription); err != nil {
+		return fail(fmt.Errorf("invalid subscribe message: %w", err))
+	}
+	for _, topic := range subscription.Topics {
+		if topic != events.TopicUpgrades && topic != events.TopicJobs {
+			return fail(fmt.Errorf("unknown topic %q, expected upgrades or jobs", topic))
+		}
+	}
+	if subscription.Topics == nil {
+		subscription.Topics = []events.Topic{}
+	}
+	if subscription.Chains == nil {
+		subscription.Chains = []string{}
+	}
+	return &subscription, wsEnvelope{Topic: wsSubscriptionTopic, Type: "subscribed", Data: subscription}
+}
+
+// wsEventEnvelope wraps a bus event for WebSocket clients
+func wsEventEnvelope(event events.Event) wsEnvelope {
+	envelope := wsEnvelope{Topic: string(event.Type.Topic()), Type: event.Type.Kind()}
+	if event.Job != nil {
+		envelope.Data = event.Job
+	} else {
+		envelope.Data = event.Upgrade
+	}
+	return envelope
+}
+
+func writeWS(conn *websocket.Conn, envelope wsEnvelope) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return conn.WriteJSON(envelope)
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/0xPuncker/cosmos-watcher/internal/events"
+	"github.com/0xPuncker/cosmos-watcher/internal/testutil"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wsMessage is a wsEnvelope as received by a client
+type wsMessage struct {
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+func TestServeWebSocket(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{})
+	handler.wsPingInterval = 50 * time.Millisecond
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	// Asking for gzip must not stop the upgrade
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + apiPath + "/ws"
+	conn, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Accept-Encoding": {"gzip"}})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	var pings atomic.Int32
+	conn.SetPingHandler(func(data string) error {
+		pings.Add(1)
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	read := func() wsMessage {
+		t.Helper()
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		var message wsMessage
+		require.NoError(t, conn.ReadJSON(&message))
+		return message
+	}
+	subscribe := func(message string) wsMessage {
+		t.Helper()
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(message)))
+		return read()
+	}
+
+	reply := subscribe(`{"topics":["upgrades"],"chains":["osmosis"]}`)
+	assert.Equal(t, wsMessage{Topic: "subscription", Type: "subscribed", Data: json.RawMessage(`{"topics":["upgrades"],"chains":["osmosis"]}`)}, reply)
+
+	// Events of other chains and topics are filtered out, so the osmosis upgrade comes first
+	handler.events.Publish(events.Event{Type: events.UpgradeAdded, Chain: "cosmoshub", Upgrade: &types.UpgradeInfo{ChainName: "cosmoshub"}})
+	handler.events.Publish(events.Event{Type: events.JobStarted, Job: &events.JobRun{Name: "checker"}})
+	handler.events.Publish(events.Event{Type: events.UpgradeChanged, Chain: "osmosis", Upgrade: &types.UpgradeInfo{ChainName: "osmosis", Version: "v26.0.0", Height: 2000000}})
+	message := read()
+	assert.Equal(t, "upgrades", message.Topic)
+	assert.Equal(t, "changed", message.Type)
+	var upgrade types.UpgradeInfo
+	require.NoError(t, json.Unmarshal(message.Data, &upgrade))
+	assert.Equal(t, "osmosis", upgrade.ChainName)
+	assert.Equal(t, "v26.0.0", upgrade.Version)
+	assert.Equal(t, int64(2000000), upgrade.Height)
+
+	reply = subscribe(`{"topics":["jobs","blocks"]}`)
+	assert.Equal(t, "error", reply.Type)
+	assert.Contains(t, string(reply.Data), `unknown topic \"blocks\"`)
+
+	reply = subscribe(`{"topics":["jobs"],"chains":["osmosis"]}`)
+	assert.Equal(t, "subscribed", reply.Type)
+	handler.events.Publish(events.Event{Type: events.UpgradeRemoved, Chain: "osmosis", Upgrade: &types.UpgradeInfo{ChainName: "osmosis"}})
+	handler.events.Publish(events.Event{Type: events.JobFailed, Job: &events.JobRun{Name: "checker", Task: "check-upgrades", Error: "timeout"}})
+	message = read()
+	assert.Equal(t, "jobs", message.Topic)
+	assert.Equal(t, "failed", message.Type)
+	var run events.JobRun
+	require.NoError(t, json.Unmarshal(message.Data, &run))
+	assert.Equal(t, events.JobRun{Name: "checker", Task: "check-upgrades", Error: "timeout"}, run)
+
+	// Control frames are handled while reading, nothing else is sent meanwhile
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(300*time.Millisecond)))
+	_, _, err = conn.ReadMessage()
+	assert.Error(t, err)
+	assert.Positive(t, pings.Load(), "the server pings its clients")
+
+	conn.Close()
+	assert.Eventually(t, func() bool { return handler.events.Subscribers() == 0 }, time.Second, 10*time.Millisecond,
+		"a disconnected client is unsubscribed")
+	assert.Eventually(t, func() bool { return handler.wsLimiter.inUse() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestServeWebSocket_OwnConnectionLimit(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{
+		Server: config.ServerConfig{MaxSSESubscribers: 1, MaxWebSocketClients: 1},
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + apiPath + "/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "WebSockets beyond max_websocket_clients are rejected")
+
+	// The WebSocket does not take the slot of the event stream
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+apiPath+"/upgrades/stream", nil)
+	require.NoError(t, err)
+	stream, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	assert.Equal(t, http.StatusOK, stream.StatusCode)
+}
+
+func TestServeWebSocket_NothingBeforeSubscribing(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	handler := NewHandler(testutil.NewFakeRegistry(), logger, &config.Config{})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+apiPath+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return handler.events.Subscribers() == 1 }, time.Second, 10*time.Millisecond)
+
+	handler.events.Publish(events.Event{Type: events.UpgradeAdded, Chain: "osmosis", Upgrade: &types.UpgradeInfo{ChainName: "osmosis"}})
+	handler.events.Close()
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr, "the unsubscribed event was not sent")
+	assert.Equal(t, websocket.CloseTryAgainLater, closeErr.Code)
+}
@@ -0,0 +1,128 @@
+package chain
+
+import (
+	"strings"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+const (
+	networkMainnet = "mainnet"
+	networkTestnet = "testnet"
+)
+
+// registryDir is a chain directory of the chain-registry, at its root for mainnets and under
+// testnets/ for testnets
+type registryDir struct {
+	name    string
+	network string
+}
+
+// path returns the directory relative to the registry root, with a leading slash
+func (d registryDir) path() string {
+	if d.network == networkTestnet {
+		return "/testnets/" + d.name
+	}
+	return "/" + d.name
+}
+
+// SetChainAliases records the registry_name and polkachu_name of the configured chains and which of
+// them are testnets, replacing the previous ones. SetChainConfigs calls it; it is exported for
+// commands that resolve chains without loading the rest of their config.
+func (r *ChainRegistry) SetChainAliases(configs []types.ChainConfig) {
+	registryNames := make(map[string]string)
+	polkachuNames := make(map[string]string)
+	testnets := make(map[string]bool)
+	for _, cfg := range configs {
+		if cfg.RegistryName != "" && cfg.RegistryName != cfg.Name {
+			registryNames[cfg.Name] = cfg.RegistryName
+		}
+		if cfg.PolkachuName != "" && cfg.PolkachuName != cfg.Name {
+			polkachuNames[cfg.Name] = cfg.PolkachuName
+		}
+		if cfg.Network == networkTestnet {
+			testnets[cfg.Name] = true
+		}
+	}
+	r.aliasMu.Lock()
+	defer r.aliasMu.Unlock()
+	r.registryNames, r.polkachuNames, r.testnets = registryNames, polkachuNames, testnets
+}
+
+// registryName returns the chain-registry directory of the chain, its configured name unless
+// chains.yaml sets a registry_name
+func (r *ChainRegistry) registryName(chainName string) string {
+	r.aliasMu.RLock()
+	defer r.aliasMu.RUnlock()
+	if alias, ok := r.registryNames[chainName]; ok {
+		return alias
+	}
+	return chainName
+}
+
+// registryDirs returns the chain-registry directories the chain is looked up in, in order. Chains
+// configured as testnets are looked up in testnets/<name>, then testnets/<name>testnet, unless a
+// registry_name gives their directory. Other chains are looked up in the mainnet directory first and
+// the testnets one after.
+func (r *ChainRegistry) registryDirs(chainName string) []registryDir {
+	r.aliasMu.RLock()
+	alias, aliased := r.registryNames[chainName]
+	testnet := r.testnets[chainName]
+	r.aliasMu.RUnlock()
+
+	name := chainName
+	if aliased {
+		name = alias
+	}
+	if !testnet {
+		return []registryDir{{name, networkMainnet}, {name, networkTestnet}}
+	}
+	dirs := []registryDir{{name, networkTestnet}}
+	if !aliased && !strings.HasSuffix(name, networkTestnet) {
+		dirs = append(dirs, registryDir{name + networkTestnet, networkTestnet})
+	}
+	return dirs
+}
+
+// RegistryPaths returns the chain-registry directories the chain is looked up in, in order, relative to
+// the registry root, e.g. ["osmosis", "testnets/osmosis"]
+func (r *ChainRegistry) RegistryPaths(chainName string) []string {
+	dirs := r.registryDirs(chainName)
+	paths := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		paths = append(paths, strings.TrimPrefix(dir.path(), "/"))
+	}
+	return paths
+}
+
+// recordChainDir remembers the directory the chain was found in, where its other files are read
+func (r *ChainRegistry) recordChainDir(chainName string, dir registryDir) {
+	r.aliasMu.Lock()
+	defer r.aliasMu.Unlock()
+	if r.chainDirs == nil {
+		r.chainDirs = make(map[string]registryDir)
+	}
+	r.chainDirs[chainName] = dir
+}
+
+// chainDir returns the directory the chain was found in, or the first one it is looked up in
+func (r *ChainRegistry) chainDir(chainName string) registryDir {
+	r.aliasMu.RLock()
+	dir, ok := r.chainDirs[chainName]
+	r.aliasMu.RUnlock()
+	if ok {
+		return dir
+	}
+	return r.registryDirs(chainName)[0]
+}
+
+// polkachuName returns the name Polkachu lists the chain under, its configured name unless
+// chains.yaml sets a polkachu_name
+func (r *ChainRegistry) polkachuName(chainName string) string {
+	r.aliasMu.RLock()
+	defer r.aliasMu.RUnlock()
+	if alias, ok := r.polkachuNames[chainName]; ok {
+		return alias
+	}
+	return chainName
+}
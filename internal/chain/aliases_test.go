@@ -0,0 +1,137 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_ChainAliases(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/cosmos/chain-registry/master/cosmoshub/chain.json":
+			json.NewEncoder(w).Encode(ChainInfo{Name: "cosmoshub", ChainID: "cosmoshub-4"})
+		case "/cosmos/chain-registry/master/cosmoshub/assetlist.json":
+			w.Write([]byte(`{"chain_name": "cosmoshub", "assets": [{"base": "uatom", "display": "atom", "symbol": "ATOM", "denom_units": [{"denom": "atom", "exponent": 6}]}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	eta := time.Now().Add(24 * time.Hour).Format(time.RFC3339)
+	polkachuServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]PolkachuUpgrade{
+			{Network: "mainnet", ChainName: "hub", NodeVersion: "v1.0.0", Block: 50, EstimatedUpgradeTime: eta},
+			{Network: "mainnet", ChainName: "cosmos", NodeVersion: "v20.0.0", Block: 100, EstimatedUpgradeTime: eta},
+		})
+	}))
+	defer polkachuServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master", WithPolkachuURL(polkachuServer.URL))
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetETADriftThreshold(-1)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderPolkachu}))
+	require.NoError(t, registry.SetChainConfigs([]types.ChainConfig{
+		{Name: "hub", Network: "mainnet", RegistryName: "cosmoshub", PolkachuName: "cosmos"},
+	}))
+	ctx := context.Background()
+
+	info, err := registry.GetChainInfo(ctx, "hub", false)
+	require.NoError(t, err)
+	assert.Equal(t, "hub", info.Name, "aliased chains keep their configured name")
+	assert.Equal(t, "cosmoshub-4", info.ChainID)
+	_, _, found := registry.cachedChainInfo("hub")
+	assert.True(t, found, "chain info is cached under the configured name")
+
+	upgrade, err := registry.GetUpgradeInfo(ctx, "hub", true)
+	require.NoError(t, err)
+	require.NotNil(t, upgrade)
+	assert.Equal(t, "hub", upgrade.ChainName)
+	assert.Equal(t, "v20.0.0", upgrade.Version, "the upgrade is the one Polkachu lists under the alias")
+
+	assert.True(t, registry.ChainExists(ctx, "hub"))
+
+	assetList, err := registry.GetAssetList(ctx, "hub")
+	require.NoError(t, err)
+	assert.Equal(t, "hub", assetList.ChainName)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range requested {
+		assert.False(t, strings.Contains(path, "/hub/"), "%s was requested by the configured name", path)
+	}
+}
+
+func TestChainRegistry_ChainAliasesReplaced(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, "https://chain-registry.invalid", "/cosmos/chain-registry/master")
+
+	require.NoError(t, registry.SetChainConfigs([]types.ChainConfig{{Name: "cosmos", RegistryName: "cosmoshub", PolkachuName: "cosmos"}}))
+	assert.Equal(t, "cosmoshub", registry.registryName("cosmos"))
+	assert.Equal(t, "cosmos", registry.polkachuName("cosmos"))
+	assert.Equal(t, "osmosis", registry.registryName("osmosis"), "chains without an alias use their name")
+
+	require.NoError(t, registry.SetChainConfigs([]types.ChainConfig{{Name: "cosmos"}}))
+	assert.Equal(t, "cosmos", registry.registryName("cosmos"), "reloading the config drops removed aliases")
+}
+
+func TestChainRegistry_AddMonitoredChain(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, "https://chain-registry.invalid", "/cosmos/chain-registry/master")
+	require.NoError(t, registry.SetChainConfigs([]types.ChainConfig{{Name: "cosmos", RegistryName: "cosmoshub"}}))
+	configured := append(make([]string, 0, 2), "cosmos")
+	registry.SetMonitoredChains(configured)
+	registry.SetAutoDiscoveredChains([]string{"stargaze"})
+
+	added, err := registry.AddMonitoredChain(types.ChainConfig{Name: "elys", DisplayName: "Elys Testnet", Network: "testnet"})
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = registry.AddMonitoredChain(types.ChainConfig{Name: "stargaze", Network: "mainnet"})
+	require.NoError(t, err)
+	assert.True(t, added, "an auto-discovered chain can be configured")
+
+	chains, err := registry.GetMonitoredChains()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cosmos", "elys", "stargaze"}, chains)
+	assert.Equal(t, ChainSourceConfig, registry.ChainSource("stargaze"))
+	assert.Empty(t, configured[:2][1], "the slice given to SetMonitoredChains is left alone")
+
+	cfg, ok := registry.GetChainConfig("elys")
+	require.True(t, ok)
+	assert.Equal(t, "Elys Testnet", cfg.DisplayName)
+	assert.Equal(t, []string{"testnets/elys", "testnets/elystestnet"}, registry.RegistryPaths("elys"))
+	assert.Equal(t, []string{"cosmoshub", "testnets/cosmoshub"}, registry.RegistryPaths("cosmos"), "existing aliases are kept")
+
+	added, err = registry.AddMonitoredChain(types.ChainConfig{Name: "elys", DisplayName: "Elys"})
+	require.NoError(t, err)
+	assert.False(t, added, "a chain is added once")
+	cfg, _ = registry.GetChainConfig("elys")
+	assert.Equal(t, "Elys Testnet", cfg.DisplayName)
+
+	_, err = registry.AddMonitoredChain(types.ChainConfig{Name: "juno", Sources: []string{"unknown"}})
+	assert.Error(t, err)
+	assert.Empty(t, registry.ChainSource("juno"))
+}
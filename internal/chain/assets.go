@@ -0,0 +1,128 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrAssetListNotFound is returned for chains that publish no assetlist.json
+var ErrAssetListNotFound = errors.New("asset list not found in chain-registry")
+
+// AssetList is the denom metadata a chain publishes in its assetlist.json
+type AssetList struct {
+	ChainName string  `json:"chain_name"`
+	Assets    []Asset `json:"assets"`
+}
+
+// Asset describes a token of the chain. Exponent is the exponent of the display denom, the number of
+// decimals between the base and the display denom.
+type Asset struct {
+	Name     string `json:"name"`
+	Base     string `json:"base"`
+	Display  string `json:"display"`
+	Symbol   string `json:"symbol"`
+	Exponent int    `json:"exponent"`
+}
+
+type assetListFile struct {
+	ChainName string `json:"chain_name"`
+	Assets    []struct {
+		Name       string `json:"name"`
+		Base       string `json:"base"`
+		Display    string `json:"display"`
+		Symbol     string `json:"symbol"`
+		DenomUnits []struct {
+			Denom    string   `json:"denom"`
+			Exponent int      `json:"exponent"`
+			Aliases  []string `json:"aliases"`
+		} `json:"denom_units"`
+	} `json:"assets"`
+}
+
+// GetAssetList returns the chain's assetlist.json, looked up in the mainnet registry first and the
+// testnets one after. It is cached apart from the chain info, so a missing or malformed asset list
+// never affects chain lookups.
+func (r *ChainRegistry) GetAssetList(ctx context.Context, chainName string) (*AssetList, error) {
+	cacheKey := fmt.Sprintf(assetListCacheKey, chainName)
+	if assetList, missing, found := cacheLookup[*AssetList](r, cacheKey); found {
+		if missing || assetList == nil {
+			return nil, fmt.Errorf("chain %q: %w", chainName, ErrAssetListNotFound)
+		}
+		return assetList, nil
+	}
+	if r.IsDegraded() {
+		return nil, ErrDegraded
+	}
+
+	var err error
+	for _, dir := range r.registryDirs(chainName) {
+		var assetList *AssetList
+		if assetList, err = r.source.assetList(ctx, dir); err == nil {
+			if dir.name != chainName {
+				assetList.ChainName = chainName
+			}
+			r.cache.Set(cacheKey, assetList, time.Duration(r.cacheTTL.Load()))
+			return assetList, nil
+		}
+		if !errors.Is(err, ErrAssetListNotFound) {
+			break
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if errors.Is(err, ErrAssetListNotFound) {
+		r.cacheNotFound(assetListCacheKey, chainName)
+	}
+	return nil, fmt.Errorf("chain %q: %w", chainName, err)
+}
+
+func (r *ChainRegistry) fetchAssetList(ctx context.Context, url string) (*AssetList, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.doWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrAssetListNotFound
+	}
+	var file assetListFile
+	if err := decodeJSON(resp, &file); err != nil {
+		return nil, err
+	}
+	return file.assetList(), nil
+}
+
+// assetList returns the assets of the file with the exponent of their display denom
+func (file *assetListFile) assetList() *AssetList {
+	assetList := &AssetList{ChainName: file.ChainName, Assets: make([]Asset, 0, len(file.Assets))}
+	for _, a := range file.Assets {
+		asset := Asset{Name: a.Name, Base: a.Base, Display: a.Display, Symbol: a.Symbol}
+		for _, unit := range a.DenomUnits {
+			if unit.Denom == a.Display || containsFold(unit.Aliases, a.Display) {
+				asset.Exponent = unit.Exponent
+				break
+			}
+		}
+		assetList.Assets = append(assetList.Assets, asset)
+	}
+	return assetList
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
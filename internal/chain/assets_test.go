@@ -0,0 +1,115 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const osmosisAssetList = `{
+  "chain_name": "osmosis",
+  "assets": [
+    {
+      "description": "The native token of Osmosis",
+      "denom_units": [
+        {"denom": "uosmo", "exponent": 0},
+        {"denom": "osmo", "exponent": 6}
+      ],
+      "base": "uosmo",
+      "name": "Osmosis",
+      "display": "osmo",
+      "symbol": "OSMO"
+    },
+    {
+      "denom_units": [
+        {"denom": "uion", "exponent": 0},
+        {"denom": "ION", "exponent": 6, "aliases": ["ion"]}
+      ],
+      "base": "uion",
+      "name": "Ion",
+      "display": "ion",
+      "symbol": "ION"
+    }
+  ]
+}`
+
+func TestChainRegistry_GetAssetList(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    *AssetList
+		wantErr error
+	}{
+		{
+			name:  "mainnet",
+			files: map[string]string{"/cosmos/chain-registry/master/osmosis/assetlist.json": osmosisAssetList},
+			want: &AssetList{ChainName: "osmosis", Assets: []Asset{
+				{Name: "Osmosis", Base: "uosmo", Display: "osmo", Symbol: "OSMO", Exponent: 6},
+				{Name: "Ion", Base: "uion", Display: "ion", Symbol: "ION", Exponent: 6},
+			}},
+		},
+		{
+			name:  "testnet fallback",
+			files: map[string]string{"/cosmos/chain-registry/master/testnets/osmosis/assetlist.json": `{"chain_name": "osmosistestnet", "assets": []}`},
+			want:  &AssetList{ChainName: "osmosistestnet", Assets: []Asset{}},
+		},
+		{
+			name:    "missing",
+			files:   map[string]string{},
+			wantErr: ErrAssetListNotFound,
+		},
+		{
+			name:  "malformed",
+			files: map[string]string{"/cosmos/chain-registry/master/osmosis/assetlist.json": `{"assets": [`},
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/cosmos/chain-registry/master/osmosis/chain.json" {
+					json.NewEncoder(w).Encode(ChainInfo{Name: "osmosis", ChainID: "osmosis-1"})
+					return
+				}
+				file, ok := tt.files[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(file))
+			}))
+			defer registryServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetRetryPolicy(-1, 0)
+
+			assetList, err := registry.GetAssetList(context.Background(), "osmosis")
+			switch {
+			case tt.want != nil:
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, assetList)
+			case tt.wantErr != nil:
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			default:
+				assert.Error(t, err)
+				assert.False(t, errors.Is(err, ErrAssetListNotFound))
+			}
+
+			// Asset list failures leave the chain info alone
+			info, err := registry.GetChainInfo(context.Background(), "osmosis", false)
+			require.NoError(t, err)
+			assert.Equal(t, "osmosis-1", info.ChainID)
+		})
+	}
+}
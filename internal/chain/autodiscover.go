@@ -0,0 +1,108 @@
+package chain
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Where a monitored chain comes from, see ChainSource
+const (
+	// ChainSourceConfig is a chain listed in chains.yaml
+	ChainSourceConfig = "config"
+	// ChainSourceAuto is a chain monitored because Polkachu lists a pending upgrade for it
+	ChainSourceAuto = "auto"
+)
+
+// PendingPolkachuChains returns the chain-registry names of the chains Polkachu lists an upgrade for
+// that is still ahead: one with a block whose estimated time has not passed, or cannot be read. Each
+// entry is resolved through its network slug first and its chain name after, and entries matching no
+// chain in the chain-registry are left out. The names are sorted.
+func (r *ChainRegistry) PendingPolkachuChains(ctx context.Context) ([]string, error) {
+	index, err := r.polkachuIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	seen := make(map[*PolkachuUpgrade]bool)
+	found := make(map[string]bool)
+	for _, upgrades := range index {
+		for _, upgrade := range upgrades {
+			if seen[upgrade] {
+				continue
+			}
+			seen[upgrade] = true
+			if upgrade.Block <= 0 {
+				continue
+			}
+			if estimated, err := time.Parse(time.RFC3339, upgrade.EstimatedUpgradeTime); err == nil && estimated.Before(now) {
+				continue
+			}
+			for _, name := range []string{upgrade.Network, upgrade.ChainName} {
+				name = normalizePolkachuName(name)
+				if name == "" {
+					continue
+				}
+				if exists, _ := r.chainExists(ctx, name); exists {
+					found[name] = true
+					break
+				}
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	chains := make([]string, 0, len(found))
+	for name := range found {
+		chains = append(chains, name)
+	}
+	slices.Sort(chains)
+	return chains, nil
+}
+
+// SetAutoDiscoveredChains replaces the chains monitored on top of the configured ones. Chains that are
+// also configured are monitored as configured chains.
+func (r *ChainRegistry) SetAutoDiscoveredChains(chains []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.autoChains = slices.Clone(chains)
+}
+
+// AutoDiscoveredChains returns the chains set by SetAutoDiscoveredChains, configured ones included
+func (r *ChainRegistry) AutoDiscoveredChains() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.autoChains)
+}
+
+// ChainSource tells whether a monitored chain is configured, ChainSourceConfig, or auto-discovered,
+// ChainSourceAuto. Chains that are not monitored give an empty string.
+func (r *ChainRegistry) ChainSource(chainName string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	switch {
+	case slices.Contains(r.monitoredChains, chainName):
+		return ChainSourceConfig
+	case slices.Contains(r.autoChains, chainName):
+		return ChainSourceAuto
+	}
+	return ""
+}
+
+// monitoredLocked returns the configured chains followed by the auto-discovered ones that are not
+// configured. Callers must hold mu.
+func (r *ChainRegistry) monitoredLocked() []string {
+	if len(r.autoChains) == 0 {
+		return r.monitoredChains
+	}
+	chains := slices.Clone(r.monitoredChains)
+	for _, name := range r.autoChains {
+		if !slices.Contains(r.monitoredChains, name) {
+			chains = append(chains, name)
+		}
+	}
+	return chains
+}
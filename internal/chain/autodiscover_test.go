@@ -0,0 +1,83 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_PendingPolkachuChains(t *testing.T) {
+	future := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	polkachuServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]PolkachuUpgrade{
+			{Network: "osmosis", ChainName: "Osmosis", Block: 17000000, EstimatedUpgradeTime: future},
+			{Network: "cosmos", ChainName: "Cosmos Hub", Block: 23000000, EstimatedUpgradeTime: future},
+			{Network: "juno", ChainName: "Juno", Block: 21000000, EstimatedUpgradeTime: past},
+			{Network: "stargaze", ChainName: "Stargaze", Block: 14000000},
+			{Network: "akash", ChainName: "Akash", EstimatedUpgradeTime: future},
+			{Network: "notachain", ChainName: "Not A Chain", Block: 100, EstimatedUpgradeTime: future},
+			{Network: "osmosis", ChainName: "Osmosis", Block: 17500000, EstimatedUpgradeTime: future},
+		})
+	}))
+	defer polkachuServer.Close()
+
+	existing := map[string]bool{
+		"/cosmos/chain-registry/master/osmosis/chain.json":   true,
+		"/cosmos/chain-registry/master/cosmoshub/chain.json": true,
+		"/cosmos/chain-registry/master/juno/chain.json":      true,
+		"/cosmos/chain-registry/master/stargaze/chain.json":  true,
+		"/cosmos/chain-registry/master/akash/chain.json":     true,
+	}
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !existing[r.URL.Path] {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master", WithPolkachuURL(polkachuServer.URL))
+	registry.SetRetryPolicy(-1, 0)
+
+	chains, err := registry.PendingPolkachuChains(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cosmoshub", "osmosis", "stargaze"}, chains,
+		"upgrades ahead are resolved by network slug or chain name; past upgrades, entries without a block and unknown chains are left out")
+}
+
+func TestChainRegistry_AutoDiscoveredChains(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"cosmoshub", "osmosis"})
+	registry.SetAutoDiscoveredChains([]string{"stargaze", "osmosis", "juno"})
+
+	chains, err := registry.GetMonitoredChains()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cosmoshub", "osmosis", "stargaze", "juno"}, chains)
+	assert.Equal(t, ChainSourceConfig, registry.ChainSource("osmosis"), "configured chains take precedence")
+	assert.Equal(t, ChainSourceAuto, registry.ChainSource("juno"))
+	assert.Empty(t, registry.ChainSource("akash"))
+
+	registry.SetMonitoredChains([]string{"cosmoshub"})
+	chains, err = registry.GetMonitoredChains()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cosmoshub", "stargaze", "osmosis", "juno"}, chains, "loading chains.yaml leaves the auto-discovered chains alone")
+
+	registry.SetAutoDiscoveredChains(nil)
+	chains, err = registry.GetMonitoredChains()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cosmoshub"}, chains)
+}
@@ -0,0 +1,94 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/version"
+)
+
+// PlatformLinuxAMD64 is the platform of the binary linked in upgrade notifications
+const PlatformLinuxAMD64 = "linux/amd64"
+
+// UpgradeBinary is where the binary of an upgrade is downloaded from for one platform
+type UpgradeBinary struct {
+	URL string `json:"url"`
+	// Checksum is the checksum the URL carries in its checksum parameter, e.g. "sha256:<hex>", which
+	// cosmovisor verifies the download against
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// GetUpgradeBinaries returns the download URLs of the chain's pending upgrade by platform, such as
+// "linux/amd64", taken from the binaries of the version history entry or the chain.json codebase whose
+// tags match the upgrade's version. Tags are matched exactly first and as the same semver version
+// after (v19 matches v19.0.0). A pending upgrade without matching binaries gives an empty map.
+func (r *ChainRegistry) GetUpgradeBinaries(ctx context.Context, chainName string) (map[string]UpgradeBinary, error) {
+	upgrade, err := r.GetUpgradeInfo(ctx, chainName, false)
+	if err != nil {
+		return nil, err
+	}
+	binaries := make(map[string]UpgradeBinary)
+	if upgrade.Version == "" {
+		return binaries, nil
+	}
+
+	var candidates []Version
+	history, err := r.GetVersions(ctx, chainName)
+	if err == nil {
+		candidates = append(candidates, history.Versions...)
+	} else if !errors.Is(err, ErrVersionsNotFound) {
+		r.logger.Debugf("Versions of %s not available, matching binaries against the codebase only: %v", chainName, err)
+	}
+	if info, err := r.GetChainInfo(ctx, chainName, false); err == nil {
+		codebase := info.Codebase
+		candidates = append(candidates, Version{
+			Tag:                codebase.RecommendedVersion,
+			RecommendedVersion: codebase.RecommendedVersion,
+			CompatibleVersions: codebase.CompatibleVersions,
+			Binaries:           codebase.Binaries,
+		})
+	}
+
+	match, ok := matchBinaries(candidates, func(tag string) bool { return strings.EqualFold(tag, upgrade.Version) })
+	if !ok {
+		target, err := version.Parse(upgrade.Version)
+		if err != nil {
+			return binaries, nil
+		}
+		match, _ = matchBinaries(candidates, func(tag string) bool {
+			v, err := version.Parse(tag)
+			return err == nil && v == target
+		})
+	}
+	for platform, rawURL := range match {
+		binaries[platform] = UpgradeBinary{URL: rawURL, Checksum: binaryChecksum(rawURL)}
+	}
+	return binaries, nil
+}
+
+// matchBinaries returns the binaries of the first candidate with binaries one of whose tags matches
+func matchBinaries(candidates []Version, matches func(tag string) bool) (map[string]string, bool) {
+	for _, v := range candidates {
+		if len(v.Binaries) == 0 {
+			continue
+		}
+		tags := append([]string{v.Tag, v.RecommendedVersion}, v.CompatibleVersions...)
+		for _, tag := range tags {
+			if tag != "" && matches(tag) {
+				return v.Binaries, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// binaryChecksum returns the checksum parameter of a binary URL, empty when it has none
+func binaryChecksum(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("checksum")
+}
@@ -0,0 +1,112 @@
+package chain
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_GetUpgradeBinaries(t *testing.T) {
+	const releases = "https://github.com/osmosis-labs/osmosis/releases/download/"
+	chainJSON := func(recommended string) string {
+		return `{"chain_name": "osmosis", "chain_id": "osmosis-1", "codebase": {"recommended_version": "` + recommended + `",
+			"binaries": {"linux/amd64": "` + releases + recommended + `/osmosisd-linux-amd64"}}}`
+	}
+	versionsJSON := `{"chain_name": "osmosis", "versions": [
+		{"name": "v18", "tag": "v18", "binaries": {"linux/amd64": "` + releases + `v18/osmosisd-linux-amd64"}},
+		{"name": "v19", "tag": "v19.0.0", "height": 17000000, "binaries": {
+			"linux/amd64": "` + releases + `v19.0.0/osmosisd-linux-amd64?checksum=sha256:4c9a1f",
+			"darwin/arm64": "` + releases + `v19.0.0/osmosisd-darwin-arm64"}}
+	]}`
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		want     map[string]UpgradeBinary
+		wantErr  error
+		upgrades string
+	}{
+		{
+			name:     "exact tag",
+			files:    map[string]string{"chain.json": chainJSON("v18.0.0"), "versions.json": versionsJSON},
+			upgrades: `{"name": "v19", "version": "v19.0.0", "height": 17000000}`,
+			want: map[string]UpgradeBinary{
+				"linux/amd64":  {URL: releases + "v19.0.0/osmosisd-linux-amd64?checksum=sha256:4c9a1f", Checksum: "sha256:4c9a1f"},
+				"darwin/arm64": {URL: releases + "v19.0.0/osmosisd-darwin-arm64"},
+			},
+		},
+		{
+			name:     "same version under another tag",
+			files:    map[string]string{"chain.json": chainJSON("v19.0.0")},
+			upgrades: `{"name": "v19", "version": "v19", "height": 17000000}`,
+			want: map[string]UpgradeBinary{
+				"linux/amd64": {URL: releases + "v19.0.0/osmosisd-linux-amd64"},
+			},
+		},
+		{
+			name:     "exact tag before the same version",
+			files:    map[string]string{"chain.json": chainJSON("v18.0.0"), "versions.json": versionsJSON},
+			upgrades: `{"name": "v18.0.0", "version": "v18.0.0", "height": 16000000}`,
+			want: map[string]UpgradeBinary{
+				"linux/amd64": {URL: releases + "v18.0.0/osmosisd-linux-amd64"},
+			},
+		},
+		{
+			name:     "no binaries for the version",
+			files:    map[string]string{"chain.json": chainJSON("v18.0.0"), "versions.json": versionsJSON},
+			upgrades: `{"name": "v20", "version": "v20.0.0", "height": 18000000}`,
+			want:     map[string]UpgradeBinary{},
+		},
+		{
+			name:     "not a semver version",
+			files:    map[string]string{"chain.json": chainJSON("v18.0.0"), "versions.json": versionsJSON},
+			upgrades: `{"name": "v20", "version": "6c0f9b4a", "height": 18000000}`,
+			want:     map[string]UpgradeBinary{},
+		},
+		{
+			name:    "no pending upgrade",
+			files:   map[string]string{"chain.json": chainJSON("v18.0.0"), "versions.json": versionsJSON},
+			wantErr: ErrNoUpgrade,
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{"upgrades.json": tt.upgrades}
+			for file, content := range tt.files {
+				files[file] = content
+			}
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for file, content := range files {
+					if content != "" && r.URL.Path == "/cosmos/chain-registry/master/osmosis/"+file {
+						w.Write([]byte(content))
+						return
+					}
+				}
+				http.NotFound(w, r)
+			}))
+			defer registryServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetRetryPolicy(-1, 0)
+			registry.SetETADriftThreshold(-1)
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+
+			binaries, err := registry.GetUpgradeBinaries(context.Background(), "osmosis")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, binaries)
+		})
+	}
+}
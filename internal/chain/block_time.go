@@ -0,0 +1,155 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+const (
+	// blockTimeTTL is long since a chain's block time barely changes between two upgrades
+	blockTimeTTL = time.Hour
+	// blockTimeSampleBlocks is how far behind the latest block the earlier sample is taken
+	blockTimeSampleBlocks = 1000
+	rpcBlockPath          = "/block?height=%d"
+	restBlockPath         = "/cosmos/base/tendermint/v1beta1/blocks/%d"
+	// defaultBlockTime is assumed for chains whose average block time cannot be measured
+	defaultBlockTime = 6 * time.Second
+	// defaultETADriftThreshold is how far a provider's upgrade time may be from the one computed from
+	// the chain's height before the computed one is preferred
+	defaultETADriftThreshold = time.Hour
+)
+
+// ErrBlockTimeUnavailable is returned when the average block time of a chain cannot be measured
+var ErrBlockTimeUnavailable = errors.New("block time not available")
+
+type rpcBlockResponse struct {
+	Result latestBlockResponse `json:"result"`
+}
+
+// EstimateUpgradeTime returns when targetHeight is reached if the chain, now at currentHeight, keeps
+// producing a block every avgBlockTime. A target already passed gives the time it was reached by the
+// same estimate.
+func EstimateUpgradeTime(currentHeight, targetHeight int64, avgBlockTime time.Duration) time.Time {
+	return estimateUpgradeTime(time.Now(), currentHeight, targetHeight, avgBlockTime)
+}
+
+func estimateUpgradeTime(at time.Time, currentHeight, targetHeight int64, avgBlockTime time.Duration) time.Time {
+	return at.Add(time.Duration(targetHeight-currentHeight) * avgBlockTime)
+}
+
+// SetETADriftThreshold sets how far a provider's upgrade time may be from the one computed from the
+// chain's height and average block time before the computed one replaces it. Zero keeps the default
+// of 1 hour, a negative value disables computing upgrade times.
+func (r *ChainRegistry) SetETADriftThreshold(threshold time.Duration) {
+	if threshold == 0 {
+		threshold = defaultETADriftThreshold
+	}
+	r.etaDriftThreshold.Store(int64(threshold))
+}
+
+// AverageBlockTime returns the chain's block time averaged over its last 1000 blocks, cached for an
+// hour. ErrBlockTimeUnavailable is returned when the chain's nodes cannot tell it.
+func (r *ChainRegistry) AverageBlockTime(ctx context.Context, chainName string) (time.Duration, error) {
+	return r.averageBlockTime(ctx, chainName)
+}
+
+// averageBlockTime measures the chain's block time between its latest block and the one
+// blockTimeSampleBlocks before it
+func (r *ChainRegistry) averageBlockTime(ctx context.Context, chainName string) (time.Duration, error) {
+	cacheKey := fmt.Sprintf(blockTimeCacheKey, chainName)
+	if blockTime, negative, found := cacheLookup[time.Duration](r, cacheKey); found && !negative {
+		return blockTime, nil
+	}
+
+	latest, err := r.GetLatestBlock(ctx, chainName)
+	if err != nil {
+		return 0, err
+	}
+	sampleHeight := max(latest.Height-blockTimeSampleBlocks, 1)
+	if sampleHeight >= latest.Height || latest.Time.IsZero() {
+		return 0, ErrBlockTimeUnavailable
+	}
+
+	info, err := r.GetChainInfo(ctx, chainName, false)
+	if err != nil {
+		return 0, err
+	}
+	sample, err := r.fetchBlock(ctx, chainName, info, ErrBlockTimeUnavailable,
+		func(ctx context.Context, address string) (LatestBlock, error) {
+			var block rpcBlockResponse
+			if err := r.getRESTJSON(ctx, address+fmt.Sprintf(rpcBlockPath, sampleHeight), &block); err != nil {
+				return LatestBlock{}, err
+			}
+			return parseLatestBlock(block.Result.Block.Header.Height, block.Result.Block.Header.Time)
+		},
+		func(ctx context.Context, address string) (LatestBlock, error) {
+			var block latestBlockResponse
+			if err := r.getRESTJSON(ctx, address+fmt.Sprintf(restBlockPath, sampleHeight), &block); err != nil {
+				return LatestBlock{}, err
+			}
+			return parseLatestBlock(block.Block.Header.Height, block.Block.Header.Time)
+		})
+	if err != nil {
+		return 0, err
+	}
+	if sample.Height >= latest.Height || !sample.Time.Before(latest.Time) {
+		return 0, ErrBlockTimeUnavailable
+	}
+
+	blockTime := latest.Time.Sub(sample.Time) / time.Duration(latest.Height-sample.Height)
+	r.cache.Set(cacheKey, blockTime, blockTimeTTL)
+	return blockTime, nil
+}
+
+// blockTimeOrDefault is the chain's average block time, or defaultBlockTime when it cannot be measured
+func (r *ChainRegistry) blockTimeOrDefault(ctx context.Context, chainName string) time.Duration {
+	blockTime, err := r.averageBlockTime(ctx, chainName)
+	if err != nil {
+		r.logger.Debugf("Average block time of %s not available, assuming %s: %v", chainName, defaultBlockTime, err)
+		return defaultBlockTime
+	}
+	return blockTime
+}
+
+// reconcileUpgradeTime computes the upgrade's time from the chain's height and average block time and
+// prefers it over the provider's when the two are further apart than the drift threshold. Both are
+// kept on the upgrade as SourceTime and ComputedTime.
+func (r *ChainRegistry) reconcileUpgradeTime(ctx context.Context, chainName string, upgrade *types.UpgradeInfo) {
+	threshold := time.Duration(r.etaDriftThreshold.Load())
+	if threshold < 0 || upgrade.Height <= 0 {
+		return
+	}
+	block, err := r.GetLatestBlock(ctx, chainName)
+	if err != nil {
+		r.logger.Debugf("Latest block of %s not available, keeping the upgrade time of %s: %v", chainName, upgrade.Name, err)
+		return
+	}
+	at := block.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	computed := estimateUpgradeTime(at, block.Height, upgrade.Height, r.blockTimeOrDefault(ctx, chainName))
+
+	upgrade.SourceTime = upgrade.Time
+	upgrade.ComputedTime = computed
+	drift := computed.Sub(upgrade.Time).Abs()
+	if !upgrade.Time.IsZero() && drift <= threshold {
+		return
+	}
+
+	note := "time estimated from block height"
+	if !upgrade.Time.IsZero() {
+		note = fmt.Sprintf("%s, source estimate was %s", note, upgrade.Time.UTC().Format(time.RFC3339))
+	}
+	if upgrade.Info == "" {
+		upgrade.Info = note
+	} else {
+		upgrade.Info = fmt.Sprintf("%s (%s)", upgrade.Info, note)
+	}
+	upgrade.Time = computed
+	upgrade.Estimated = true
+}
@@ -0,0 +1,180 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateUpgradeTime(t *testing.T) {
+	at := time.Date(2024, 3, 20, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		currentHeight int64
+		targetHeight  int64
+		avgBlockTime  time.Duration
+		want          time.Time
+	}{
+		{
+			name:          "sub-second blocks",
+			currentHeight: 1000000,
+			targetHeight:  1036000,
+			avgBlockTime:  500 * time.Millisecond,
+			want:          at.Add(5 * time.Hour),
+		},
+		{
+			name:          "6 second blocks",
+			currentHeight: 1000000,
+			targetHeight:  1014400,
+			avgBlockTime:  6 * time.Second,
+			want:          at.Add(24 * time.Hour),
+		},
+		{
+			name:          "target already passed",
+			currentHeight: 1000600,
+			targetHeight:  1000000,
+			avgBlockTime:  6 * time.Second,
+			want:          at.Add(-time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, estimateUpgradeTime(at, tt.currentHeight, tt.targetHeight, tt.avgBlockTime))
+			assert.WithinDuration(t, time.Now().Add(tt.want.Sub(at)), EstimateUpgradeTime(tt.currentHeight, tt.targetHeight, tt.avgBlockTime), time.Second)
+		})
+	}
+}
+
+// newBlockNode serves an RPC node whose blocks are blockTime apart, the latest being latestHeight at
+// latestTime
+func newBlockNode(latestHeight int64, latestTime time.Time, blockTime time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case rpcStatusPath:
+			fmt.Fprintf(w, `{"result":{"sync_info":{"latest_block_height":"%d","latest_block_time":%q}}}`,
+				latestHeight, latestTime.Format(time.RFC3339Nano))
+		case "/block":
+			height, err := strconv.ParseInt(r.URL.Query().Get("height"), 10, 64)
+			if err != nil || height > latestHeight {
+				http.Error(w, "invalid height", http.StatusInternalServerError)
+				return
+			}
+			blockTimeAt := latestTime.Add(-time.Duration(latestHeight-height) * blockTime)
+			fmt.Fprintf(w, `{"result":{"block":{"header":{"height":"%d","time":%q}}}}`, height, blockTimeAt.Format(time.RFC3339Nano))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestChainRegistry_AverageBlockTime(t *testing.T) {
+	latestTime := time.Date(2024, 3, 20, 15, 4, 5, 0, time.UTC)
+
+	for _, blockTime := range []time.Duration{400 * time.Millisecond, 6 * time.Second} {
+		t.Run(blockTime.String(), func(t *testing.T) {
+			node := newBlockNode(1050000, latestTime, blockTime)
+			defer node.Close()
+
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", APIs: APIs{RPC: []Endpoint{{Address: node.URL}}}})
+			}))
+			defer registryServer.Close()
+
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+
+			measured, err := registry.averageBlockTime(context.Background(), "testchain")
+			require.NoError(t, err)
+			assert.Equal(t, blockTime, measured)
+		})
+	}
+}
+
+func TestChainRegistry_ReconcileUpgradeTime(t *testing.T) {
+	latestTime := time.Now().UTC().Truncate(time.Second)
+	computed := latestTime.Add(10000 * 6 * time.Second)
+
+	tests := []struct {
+		name       string
+		sourceTime time.Time
+		threshold  time.Duration
+		wantTime   time.Time
+		wantInfo   string
+	}{
+		{
+			name:       "stale source estimate",
+			sourceTime: computed.Add(-72 * time.Hour),
+			wantTime:   computed,
+			wantInfo:   "https://example.com/guide (time estimated from block height, source estimate was " + computed.Add(-72*time.Hour).Format(time.RFC3339) + ")",
+		},
+		{
+			name:       "source estimate within the threshold",
+			sourceTime: computed.Add(-20 * time.Minute),
+			wantTime:   computed.Add(-20 * time.Minute),
+			wantInfo:   "https://example.com/guide",
+		},
+		{
+			name:       "disabled",
+			sourceTime: computed.Add(-72 * time.Hour),
+			threshold:  -1,
+			wantTime:   computed.Add(-72 * time.Hour),
+			wantInfo:   "https://example.com/guide",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newBlockNode(1050000, latestTime, 6*time.Second)
+			defer node.Close()
+
+			polkachuServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode([]PolkachuUpgrade{{
+					Network:              "mainnet",
+					ChainName:            "testchain",
+					NodeVersion:          "v2.0.0",
+					Block:                1060000,
+					EstimatedUpgradeTime: tt.sourceTime.Format(time.RFC3339),
+					Guide:                "https://example.com/guide",
+				}})
+			}))
+			defer polkachuServer.Close()
+
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", APIs: APIs{RPC: []Endpoint{{Address: node.URL}}}})
+			}))
+			defer registryServer.Close()
+
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master", WithPolkachuURL(polkachuServer.URL))
+			registry.SetETADriftThreshold(tt.threshold)
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderPolkachu}))
+
+			upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", true)
+			require.NoError(t, err)
+			require.NotNil(t, upgrade)
+			assert.True(t, tt.wantTime.Equal(upgrade.Time), "got %s, want %s", upgrade.Time, tt.wantTime)
+			assert.Equal(t, tt.wantInfo, upgrade.Info)
+			assert.Equal(t, "https://example.com/guide", upgrade.Guide)
+			if tt.threshold < 0 {
+				assert.True(t, upgrade.ComputedTime.IsZero())
+				return
+			}
+			assert.True(t, tt.sourceTime.Equal(upgrade.SourceTime))
+			assert.True(t, computed.Equal(upgrade.ComputedTime))
+		})
+	}
+}
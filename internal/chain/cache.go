@@ -0,0 +1,207 @@
+package chain
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+const (
+	// defaultCacheTTL is how long fetched chain and upgrade info is reused
+	defaultCacheTTL = 5 * time.Minute
+	// defaultCacheCleanupInterval is how often expired cache entries are swept
+	defaultCacheCleanupInterval = 10 * time.Second
+	// defaultNegativeCacheTTL is short so a chain that was just added to chains.yaml or merged into the
+	// chain-registry is picked up soon after a failed lookup
+	defaultNegativeCacheTTL = 30 * time.Second
+	// defaultChainExistsTTL is long, chains are rarely removed from the chain-registry
+	defaultChainExistsTTL = time.Hour
+)
+
+// Cache key formats of the registry's entries. Each key holds values of a single type, read back with
+// cacheLookup.
+const (
+	chainInfoCacheKey   = "chain_info:%s"
+	upgradeInfoCacheKey = "upgrade_info:%s"
+	chainExistsCacheKey = "chain_exists:%s"
+	// validatorsCacheKey is keyed by URL rather than by chain
+	validatorsCacheKey    = "validators:%s"
+	currentHeightCacheKey = "current_height:%s"
+	blockTimeCacheKey     = "block_time:%s"
+	nodeVersionCacheKey   = "node_version:%s"
+	assetListCacheKey     = "asset_list:%s"
+	versionsCacheKey      = "versions:%s"
+	// voteTallyCacheKey is keyed by chain and proposal, releaseVersionCacheKey by repository and version
+	voteTallyCacheKey        = "vote_tally:%s:%s"
+	releaseVersionCacheKey   = "release_version:%s:%s"
+	discoveredChainsCacheKey = "discovered_chains"
+	// polkachuUpgradesCacheKey holds the whole Polkachu payload, apart from the per-chain keys so that
+	// refreshing one chain does not refetch it
+	polkachuUpgradesCacheKey = "polkachu_upgrades"
+)
+
+// cacheValueTypes are the types stored under the keys above. Caches keeping values as JSON, like the
+// Redis one, decode them back into these.
+var cacheValueTypes = []interface{}{
+	notFound{},
+	&ChainInfo{},
+	&types.UpgradeInfo{},
+	true,
+	&conditionalEntry{},
+	LatestBlock{},
+	time.Duration(0),
+	"",
+	&AssetList{},
+	&VersionHistory{},
+	&types.VoteTally{},
+	&DiscoveredChains{},
+	map[string][]*PolkachuUpgrade{},
+	polkachuFailure{},
+}
+
+// Cache stores the registry's cached data under string keys until their TTL passes. A zero TTL uses
+// the cache's default and a negative one never expires. The default is an in-memory go-cache, which
+// NewRedisCache replaces with one shared by replicas.
+type Cache interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{}, ttl time.Duration)
+	Delete(key string)
+	Flush()
+	ItemCount() int
+}
+
+// notFound is cached for a chain or upgrade lookup that failed, so the read path can tell a known
+// missing entry from one that was never looked up. Failure holds the error of a lookup that failed
+// without establishing that the entry is missing, such as an outage.
+type notFound struct {
+	Failure string `json:"failure,omitempty"`
+}
+
+// SetCacheTTLs sets how long fetched chain and upgrade info and failed lookups are cached. Zero or
+// negative values keep the defaults.
+func (r *ChainRegistry) SetCacheTTLs(ttl, negativeTTL time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	if negativeTTL <= 0 {
+		negativeTTL = defaultNegativeCacheTTL
+	}
+	r.cacheTTL.Store(int64(ttl))
+	r.negativeCacheTTL.Store(int64(negativeTTL))
+}
+
+// SetInfoTTLs sets how long fetched chain info and upgrade info are cached, apart from the TTL set by
+// SetCacheTTLs. Zero or negative values use that TTL.
+func (r *ChainRegistry) SetInfoTTLs(chainInfoTTL, upgradeInfoTTL time.Duration) {
+	r.chainInfoTTL.Store(int64(max(chainInfoTTL, 0)))
+	r.upgradeInfoTTL.Store(int64(max(upgradeInfoTTL, 0)))
+}
+
+// SetChainExistsTTL sets how long ChainExists remembers a chain it found in the chain-registry without
+// its info being cached. Zero or negative values keep the default of an hour.
+func (r *ChainRegistry) SetChainExistsTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultChainExistsTTL
+	}
+	r.chainExistsTTL.Store(int64(ttl))
+}
+
+// infoTTL is the TTL stored in ttl, or the general cache TTL when none is set
+func (r *ChainRegistry) infoTTL(ttl *atomic.Int64) time.Duration {
+	if d := time.Duration(ttl.Load()); d > 0 {
+		return d
+	}
+	return time.Duration(r.cacheTTL.Load())
+}
+
+// cacheLookup returns the value cached under key, with negative set when the entry records a failed
+// lookup. An entry of any other type, which only a key collision can store, is logged and evicted and
+// reported as not cached, so the caller fetches again instead of failing.
+func cacheLookup[T any](r *ChainRegistry, key string) (value T, negative, found bool) {
+	cached, found := r.cache.Get(key)
+	if !found {
+		return value, false, false
+	}
+	switch v := cached.(type) {
+	case notFound:
+		return value, true, true
+	case T:
+		return v, false, true
+	}
+	r.evictCacheEntry(key, cached)
+	return value, false, false
+}
+
+// evictCacheEntry drops an entry whose value does not belong under its key
+func (r *ChainRegistry) evictCacheEntry(key string, cached interface{}) {
+	r.logger.Warnf("Evicting cache entry %q holding an unexpected %T", key, cached)
+	r.cache.Delete(key)
+}
+
+func (r *ChainRegistry) cacheChainInfo(chainName string, info *ChainInfo) {
+	if info == nil {
+		return
+	}
+	r.cache.Set(fmt.Sprintf(chainInfoCacheKey, chainName), info, r.infoTTL(&r.chainInfoTTL))
+}
+
+func (r *ChainRegistry) cacheUpgradeInfo(chainName string, upgradeInfo *types.UpgradeInfo) {
+	if upgradeInfo == nil {
+		return
+	}
+	r.cache.Set(fmt.Sprintf(upgradeInfoCacheKey, chainName), upgradeInfo, r.infoTTL(&r.upgradeInfoTTL))
+}
+
+// cacheNotFound remembers a failed lookup under the cache key format for chainName
+func (r *ChainRegistry) cacheNotFound(keyFormat, chainName string) {
+	r.cache.Set(fmt.Sprintf(keyFormat, chainName), notFound{}, time.Duration(r.negativeCacheTTL.Load()))
+}
+
+// cacheFailure is cacheNotFound for a lookup that failed with err without telling whether the entry
+// exists
+func (r *ChainRegistry) cacheFailure(keyFormat, chainName string, err error) {
+	r.cache.Set(fmt.Sprintf(keyFormat, chainName), notFound{Failure: err.Error()}, time.Duration(r.negativeCacheTTL.Load()))
+}
+
+// cachedChainInfoError is the error of a chain info lookup answered from the negative cache: the
+// failure cached by cacheFailure, or ErrChainNotFound
+func (r *ChainRegistry) cachedChainInfoError(chainName string) error {
+	if cached, ok := r.cache.Get(fmt.Sprintf(chainInfoCacheKey, chainName)); ok {
+		if entry, ok := cached.(notFound); ok && entry.Failure != "" {
+			return fmt.Errorf("fetching chain info for %s failed: %s (cached result)", chainName, entry.Failure)
+		}
+	}
+	return fmt.Errorf("%w (cached result)", &ErrChainNotFound{Chain: chainName})
+}
+
+// cachedChainInfo returns the cached chain info, with missing set when the last lookup failed and
+// found unset when the chain was not looked up recently. A block recorded for the chain since it was
+// cached is set on a copy.
+func (r *ChainRegistry) cachedChainInfo(chainName string) (info *ChainInfo, missing, found bool) {
+	key := fmt.Sprintf(chainInfoCacheKey, chainName)
+	if info, missing, found = cacheLookup[*ChainInfo](r, key); found && !missing && info == nil {
+		r.evictCacheEntry(key, info)
+		return nil, false, false
+	}
+	if found && !missing {
+		if known, ok := r.loadChain(chainName); ok && known.Height > info.Height {
+			updated := *info
+			updated.Height, updated.LatestBlockTime = known.Height, known.LatestBlockTime
+			info = &updated
+		}
+	}
+	return info, missing, found
+}
+
+// cachedUpgradeInfo is cachedChainInfo for upgrade info. A missing upgrade means the chain has no
+// upcoming upgrade.
+func (r *ChainRegistry) cachedUpgradeInfo(chainName string) (upgradeInfo *types.UpgradeInfo, missing, found bool) {
+	key := fmt.Sprintf(upgradeInfoCacheKey, chainName)
+	if upgradeInfo, missing, found = cacheLookup[*types.UpgradeInfo](r, key); found && !missing && upgradeInfo == nil {
+		r.evictCacheEntry(key, upgradeInfo)
+		return nil, false, false
+	}
+	return upgradeInfo, missing, found
+}
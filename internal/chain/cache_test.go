@@ -0,0 +1,372 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_NegativeCacheTTL(t *testing.T) {
+	var published atomic.Bool
+	var chainID, upgradeVersion atomic.Value
+	chainID.Store("testchain-1")
+	upgradeVersion.Store("")
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json") && published.Load():
+			json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: chainID.Load().(string)})
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json") && upgradeVersion.Load() != "":
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "v2", "version": upgradeVersion.Load(), "height": 1000})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	const negativeTTL = 50 * time.Millisecond
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetCacheTTLs(time.Hour, negativeTTL)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+
+	// A chain that 404s is cached as missing for the short negative TTL only
+	_, err := registry.GetChainInfo(context.Background(), "testchain", false)
+	require.Error(t, err)
+	_, missing, found := registry.cachedChainInfo("testchain")
+	assert.True(t, found)
+	assert.True(t, missing)
+
+	published.Store(true)
+	_, err = registry.GetChainInfo(context.Background(), "testchain", false)
+	assert.Error(t, err, "the failure is still cached")
+
+	time.Sleep(2 * negativeTTL)
+	info, err := registry.GetChainInfo(context.Background(), "testchain", false)
+	require.NoError(t, err)
+	assert.Equal(t, "testchain-1", info.ChainID)
+
+	// The successful lookup outlives the negative TTL
+	chainID.Store("testchain-2")
+	time.Sleep(2 * negativeTTL)
+	info, missing, found = registry.cachedChainInfo("testchain")
+	require.True(t, found)
+	assert.False(t, missing)
+	assert.Equal(t, "testchain-1", info.ChainID)
+
+	// The same holds for upgrade info
+	upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", false)
+	assert.ErrorIs(t, err, ErrNoUpgrade)
+	assert.Nil(t, upgrade)
+
+	upgradeVersion.Store("v2.0.0")
+	upgrade, err = registry.GetUpgradeInfo(context.Background(), "testchain", false)
+	assert.ErrorIs(t, err, ErrNoUpgrade, "the missing upgrade is still cached")
+	assert.Nil(t, upgrade)
+
+	time.Sleep(2 * negativeTTL)
+	upgrade, err = registry.GetUpgradeInfo(context.Background(), "testchain", false)
+	require.NoError(t, err)
+	require.NotNil(t, upgrade)
+	assert.Equal(t, "v2.0.0", upgrade.Version)
+
+	upgradeVersion.Store("v3.0.0")
+	time.Sleep(2 * negativeTTL)
+	upgrade, err = registry.GetUpgradeInfo(context.Background(), "testchain", false)
+	require.NoError(t, err)
+	require.NotNil(t, upgrade)
+	assert.Equal(t, "v2.0.0", upgrade.Version)
+}
+
+func TestChainRegistry_InfoTTLs(t *testing.T) {
+	var chainRequests, upgradeRequests atomic.Int32
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json"):
+			chainRequests.Add(1)
+			json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json"):
+			upgradeRequests.Add(1)
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "v2", "version": "v2.0.0", "height": 1000})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetInfoTTLs(50*time.Millisecond, 300*time.Millisecond)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+
+	lookup := func() {
+		t.Helper()
+		_, err := registry.GetChainInfo(context.Background(), "testchain", false)
+		require.NoError(t, err)
+		upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", false)
+		require.NoError(t, err)
+		require.NotNil(t, upgrade)
+	}
+
+	lookup()
+	assert.Equal(t, int32(1), chainRequests.Load())
+	assert.Equal(t, int32(1), upgradeRequests.Load())
+
+	lookup()
+	assert.Equal(t, int32(1), chainRequests.Load(), "chain info is cached within its TTL")
+	assert.Equal(t, int32(1), upgradeRequests.Load(), "upgrade info is cached within its TTL")
+
+	time.Sleep(100 * time.Millisecond)
+	_, _, found := registry.cachedChainInfo("testchain")
+	assert.False(t, found, "chain info expires after 50ms")
+	_, missing, found := registry.cachedUpgradeInfo("testchain")
+	assert.True(t, found && !missing, "upgrade info outlives the chain info TTL")
+
+	time.Sleep(250 * time.Millisecond)
+	_, _, found = registry.cachedUpgradeInfo("testchain")
+	assert.False(t, found, "upgrade info expires after 300ms")
+	lookup()
+	assert.Equal(t, int32(2), upgradeRequests.Load())
+}
+
+func TestChainRegistry_ConcurrentFetchesShared(t *testing.T) {
+	var chainRequests, upgradeRequests atomic.Int32
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Slow enough for every caller to arrive while the first fetch is in flight
+		time.Sleep(50 * time.Millisecond)
+		switch r.URL.Path {
+		case "/cosmos/chain-registry/master/osmosis/chain.json":
+			chainRequests.Add(1)
+			json.NewEncoder(w).Encode(ChainInfo{Name: "osmosis", ChainID: "osmosis-1"})
+		case "/cosmos/chain-registry/master/osmosis/upgrades.json":
+			upgradeRequests.Add(1)
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "v26", "version": "v26.0.0", "height": 1000})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetETADriftThreshold(-1)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+
+	run := func(call func() error) {
+		var wg sync.WaitGroup
+		errs := make(chan error, 50)
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- call()
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+	}
+
+	run(func() error {
+		info, err := registry.GetChainInfo(context.Background(), "osmosis", false)
+		if err == nil && info.ChainID != "osmosis-1" {
+			t.Errorf("unexpected chain id %q", info.ChainID)
+		}
+		return err
+	})
+	assert.Equal(t, int32(1), chainRequests.Load(), "concurrent callers share one chain.json download")
+
+	registry.cache.Flush()
+	run(func() error {
+		_, err := registry.GetUpgradeInfo(context.Background(), "osmosis", false)
+		return err
+	})
+	assert.Equal(t, int32(1), upgradeRequests.Load(), "concurrent callers share one upgrades.json download")
+}
+
+func TestChainRegistry_ChainExistsCached(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		heads = make(map[string]int)
+	)
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			mu.Lock()
+			heads[strings.Split(strings.TrimPrefix(r.URL.Path, "/cosmos/chain-registry/master/"), "/chain.json")[0]]++
+			mu.Unlock()
+		}
+		switch {
+		case r.URL.Path == "/cosmos/chain-registry/master/osmosis/chain.json":
+			json.NewEncoder(w).Encode(ChainInfo{Name: "osmosis", ChainID: "osmosis-1"})
+		case r.URL.Path == "/cosmos/chain-registry/master/testnets/testchain/chain.json":
+			w.WriteHeader(http.StatusOK)
+		case strings.Contains(r.URL.Path, "/flaky/"):
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+	probes := func(dirs ...string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		var n int
+		for _, dir := range dirs {
+			n += heads[dir]
+		}
+		return n
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	metrics := NewMetrics(prometheus.NewRegistry())
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master", WithMetrics(metrics))
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetDegradedThreshold(-1)
+	registry.SetCacheTTLs(0, 50*time.Millisecond)
+	registry.SetChainExistsTTL(150 * time.Millisecond)
+	ctx := context.Background()
+
+	for range 5 {
+		assert.True(t, registry.ChainExists(ctx, "testchain"))
+		assert.False(t, registry.ChainExists(ctx, "notachain"))
+	}
+	assert.Equal(t, 2, probes("testchain", "testnets/testchain"), "one pair of HEAD requests within the TTL")
+	assert.Equal(t, 2, probes("notachain", "testnets/notachain"), "the missing chain is cached as well")
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues(CacheEntityChainExists, CacheMiss)))
+	assert.Equal(t, 8.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues(CacheEntityChainExists, CacheHit)))
+
+	for range 2 {
+		assert.False(t, registry.ChainExists(ctx, "flaky"))
+	}
+	assert.Equal(t, 4, probes("flaky", "testnets/flaky"), "failed probes are not cached")
+
+	_, err := registry.GetChainInfo(ctx, "osmosis", false)
+	require.NoError(t, err)
+	assert.True(t, registry.ChainExists(ctx, "osmosis"))
+	assert.Zero(t, probes("osmosis"), "cached chain info answers without a probe")
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, registry.ChainExists(ctx, "notachain"))
+	assert.True(t, registry.ChainExists(ctx, "testchain"))
+	assert.Equal(t, 4, probes("notachain", "testnets/notachain"), "the missing chain is probed again after the negative TTL")
+	assert.Equal(t, 2, probes("testchain", "testnets/testchain"), "the chain found outlives the negative TTL")
+
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, registry.ChainExists(ctx, "testchain"))
+	assert.Equal(t, 4, probes("testchain", "testnets/testchain"), "the chain is probed again after its TTL")
+}
+
+func TestChainRegistry_PoisonedCache(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cosmos/chain-registry/master/testchain/chain.json":
+			json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+		case "/cosmos/chain-registry/master/testchain/upgrades.json":
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "v2", "version": "v2.0.0", "height": 1000})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	tests := []struct {
+		name  string
+		key   string
+		value interface{}
+		call  func(t *testing.T, registry *ChainRegistry)
+	}{
+		{
+			name:  "chain info holding upgrade info",
+			key:   fmt.Sprintf(chainInfoCacheKey, "testchain"),
+			value: &types.UpgradeInfo{Name: "v1"},
+			call: func(t *testing.T, registry *ChainRegistry) {
+				info, err := registry.GetChainInfo(context.Background(), "testchain", false)
+				require.NoError(t, err)
+				assert.Equal(t, "testchain-1", info.ChainID)
+			},
+		},
+		{
+			name:  "chain info holding a nil pointer",
+			key:   fmt.Sprintf(chainInfoCacheKey, "testchain"),
+			value: (*ChainInfo)(nil),
+			call: func(t *testing.T, registry *ChainRegistry) {
+				info, err := registry.GetChainInfo(context.Background(), "testchain", false)
+				require.NoError(t, err)
+				assert.Equal(t, "testchain-1", info.ChainID)
+			},
+		},
+		{
+			name:  "upgrade info holding chain info",
+			key:   fmt.Sprintf(upgradeInfoCacheKey, "testchain"),
+			value: &ChainInfo{Name: "testchain"},
+			call: func(t *testing.T, registry *ChainRegistry) {
+				upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", false)
+				require.NoError(t, err)
+				assert.Equal(t, "v2.0.0", upgrade.Version)
+			},
+		},
+		{
+			name:  "upgrade info holding nil",
+			key:   fmt.Sprintf(upgradeInfoCacheKey, "testchain"),
+			value: nil,
+			call: func(t *testing.T, registry *ChainRegistry) {
+				upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", false)
+				require.NoError(t, err)
+				assert.Equal(t, "v2.0.0", upgrade.Version)
+			},
+		},
+		{
+			name:  "chain exists holding a string",
+			key:   fmt.Sprintf(chainExistsCacheKey, "testchain"),
+			value: "yes",
+			call: func(t *testing.T, registry *ChainRegistry) {
+				assert.True(t, registry.ChainExists(context.Background(), "testchain"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := logrus.New()
+			logger.SetOutput(&logs)
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetRetryPolicy(-1, 0)
+			registry.SetETADriftThreshold(-1)
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+			registry.cache.Set(tt.key, tt.value, time.Hour)
+
+			require.NotPanics(t, func() { tt.call(t, registry) })
+			assert.Contains(t, logs.String(), "Evicting cache entry")
+			assert.Contains(t, logs.String(), tt.key)
+
+			cached, found := registry.cache.Get(tt.key)
+			if found {
+				assert.NotEqual(t, tt.value, cached, "the poisoned entry is replaced")
+			}
+		})
+	}
+}
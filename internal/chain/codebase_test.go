@@ -0,0 +1,105 @@
+package chain
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_ParsesCodebase(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/master/osmosis/chain.json") {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, "testdata/chain.json")
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	info, err := registry.GetChainInfo(context.Background(), "osmosis", false)
+	require.NoError(t, err)
+
+	assert.Equal(t, Codebase{
+		GitRepo:            "https://github.com/osmosis-labs/osmosis",
+		RecommendedVersion: "v25.0.0",
+		CompatibleVersions: []string{"v25.0.0", "v25.0.1"},
+		Binaries: map[string]string{
+			"linux/amd64": "https://github.com/osmosis-labs/osmosis/releases/download/v25.0.0/osmosisd-25.0.0-linux-amd64",
+			"linux/arm64": "https://github.com/osmosis-labs/osmosis/releases/download/v25.0.0/osmosisd-25.0.0-linux-arm64",
+		},
+	}, info.Codebase)
+	assert.Equal(t, "v25.0.0", info.RunningVersion())
+	assert.Equal(t, "Osmosis", info.PrettyName)
+	assert.Equal(t, "osmo", info.Bech32Prefix)
+	assert.Equal(t, "osmosisd", info.DaemonName)
+	assert.Equal(t, LogoURIs{
+		PNG: "https://raw.githubusercontent.com/cosmos/chain-registry/master/osmosis/images/osmo.png",
+		SVG: "https://raw.githubusercontent.com/cosmos/chain-registry/master/osmosis/images/osmo.svg",
+	}, info.LogoURIs)
+
+	tests := []struct {
+		name    string
+		upgrade *PolkachuUpgrade
+		repo    string
+	}{
+		{name: "falls back to the codebase repo", upgrade: &PolkachuUpgrade{NodeVersion: "v26.0.0"}, repo: "https://github.com/osmosis-labs/osmosis"},
+		{name: "keeps the Polkachu repo", upgrade: &PolkachuUpgrade{NodeVersion: "v26.0.0", Repo: "https://github.com/osmosis-labs/osmosis-fork"}, repo: "https://github.com/osmosis-labs/osmosis-fork"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgrade := registry.convertUpgradeInfo("osmosis", info, tt.upgrade)
+			require.NotNil(t, upgrade)
+			assert.Equal(t, tt.repo, upgrade.Repo)
+		})
+	}
+}
+
+func TestChainRegistry_UpgradeChangeType(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "v26.0.0", want: "major"},
+		{version: "v25.1.0", want: "minor"},
+		{version: "v25.0.1", want: "patch"},
+		{version: "6c0f9b4a", want: "unknown"},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/cosmos/chain-registry/master/osmosis/chain.json":
+					http.ServeFile(w, r, "testdata/chain.json")
+				case "/cosmos/chain-registry/master/osmosis/upgrades.json":
+					w.Write([]byte(`{"name": "upgrade", "version": "` + tt.version + `", "height": 17000000}`))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer registryServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetRetryPolicy(-1, 0)
+			registry.SetETADriftThreshold(-1)
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+
+			upgrade, err := registry.GetUpgradeInfo(context.Background(), "osmosis", false)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, upgrade.ChangeType, "against the recommended v25.0.0")
+		})
+	}
+}
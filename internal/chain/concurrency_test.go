@@ -0,0 +1,97 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChainRegistry_ConcurrentGetChainInfo is meant for go test -race: lookups, forced refreshes,
+// upgrade fetches and height updates of the same chains race each other
+func TestChainRegistry_ConcurrentGetChainInfo(t *testing.T) {
+	rpcNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":-1,"result":{"sync_info":{"latest_block_height":"1000","latest_block_time":"2024-03-20T15:04:05Z","catching_up":false}}}`))
+	}))
+	defer rpcNode.Close()
+
+	chains := []string{"chain0", "chain1", "chain2", "chain3", "chain4"}
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, name := range chains {
+			if r.URL.Path == "/cosmos/chain-registry/master/"+name+"/chain.json" {
+				json.NewEncoder(w).Encode(ChainInfo{
+					Name:    name,
+					ChainID: name + "-1",
+					APIs:    APIs{RPC: []Endpoint{{Address: rpcNode.URL}}},
+				})
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetDegradedThreshold(-1)
+	registry.SetETADriftThreshold(-1)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+	registry.SetMonitoredChains(chains)
+	ctx := context.Background()
+	upgrade := &types.UpgradeInfo{Height: 2000}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chainName := chains[i%len(chains)]
+			for j := range 20 {
+				info, err := registry.GetChainInfo(ctx, chainName, (i+j)%7 == 0)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if info.ChainID != chainName+"-1" || info.Height < 0 || !strings.HasPrefix(info.Name, "chain") {
+					errs <- fmt.Errorf("unexpected chain info %+v", info)
+					return
+				}
+				switch j % 4 {
+				case 1:
+					registry.GetLatestBlock(ctx, chainName)
+				case 2:
+					registry.GetUpgradeInfo(ctx, chainName, j%8 == 2)
+				case 3:
+					registry.UpgradeStatus(chainName, upgrade)
+					registry.GetAllChains()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for _, chainName := range chains {
+		block, err := registry.GetLatestBlock(ctx, chainName)
+		require.NoError(t, err)
+		info, err := registry.GetChainInfo(ctx, chainName, false)
+		require.NoError(t, err)
+		assert.Equal(t, block.Height, info.Height, "the height is recorded on the cached chain info")
+	}
+}
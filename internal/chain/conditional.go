@@ -0,0 +1,116 @@
+package chain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// validatorsTTL outlives the chain info entries so an expired entry can still be refetched conditionally
+const validatorsTTL = time.Hour
+
+// conditionalEntry keeps the ETag/Last-Modified validators returned with a chain.json or upgrades.json
+// together with the parsed result, so a 304 on refetch can reuse it without downloading or parsing the
+// file again.
+type conditionalEntry struct {
+	etag         string
+	lastModified string
+	chainInfo    ChainInfo
+	upgradeInfo  types.UpgradeInfo
+}
+
+// conditionalEntryJSON is a conditionalEntry as kept by caches storing JSON
+type conditionalEntryJSON struct {
+	ETag         string            `json:"etag,omitempty"`
+	LastModified string            `json:"last_modified,omitempty"`
+	ChainInfo    ChainInfo         `json:"chain_info"`
+	UpgradeInfo  types.UpgradeInfo `json:"upgrade_info"`
+}
+
+func (e conditionalEntry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(conditionalEntryJSON{
+		ETag:         e.etag,
+		LastModified: e.lastModified,
+		ChainInfo:    e.chainInfo,
+		UpgradeInfo:  e.upgradeInfo,
+	})
+}
+
+func (e *conditionalEntry) UnmarshalJSON(data []byte) error {
+	var v conditionalEntryJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*e = conditionalEntry{etag: v.ETag, lastModified: v.LastModified, chainInfo: v.ChainInfo, upgradeInfo: v.UpgradeInfo}
+	return nil
+}
+
+// ConditionalFetchStats counts the chain-registry requests sent with validators and how many of them
+// were answered with 304 Not Modified
+type ConditionalFetchStats struct {
+	Sent        int64
+	NotModified int64
+}
+
+type conditionalCounters struct {
+	sent        atomic.Int64
+	notModified atomic.Int64
+}
+
+// ConditionalFetchStats returns the totals since the registry was created
+func (r *ChainRegistry) ConditionalFetchStats() ConditionalFetchStats {
+	return ConditionalFetchStats{
+		Sent:        r.conditional.sent.Load(),
+		NotModified: r.conditional.notModified.Load(),
+	}
+}
+
+// Since returns the fetches counted after previous was taken
+func (s ConditionalFetchStats) Since(previous ConditionalFetchStats) ConditionalFetchStats {
+	return ConditionalFetchStats{
+		Sent:        s.Sent - previous.Sent,
+		NotModified: s.NotModified - previous.NotModified,
+	}
+}
+
+// conditionalRequest adds the validators remembered for the request's URL and returns the entry they
+// came from, nil when the URL was not fetched with validators before
+func (r *ChainRegistry) conditionalRequest(req *http.Request) *conditionalEntry {
+	entry, _, found := cacheLookup[*conditionalEntry](r, fmt.Sprintf(validatorsCacheKey, req.URL.String()))
+	if !found || entry == nil {
+		return nil
+	}
+
+	if entry.etag != "" {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+	if entry.lastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+	r.conditional.sent.Add(1)
+	return entry
+}
+
+// notModified reports whether resp confirmed the previous entry, keeping the entry cached for another
+// validatorsTTL when it did
+func (r *ChainRegistry) notModified(req *http.Request, resp *http.Response, previous *conditionalEntry) bool {
+	if resp.StatusCode != http.StatusNotModified || previous == nil {
+		return false
+	}
+	r.conditional.notModified.Add(1)
+	r.cache.Set(fmt.Sprintf(validatorsCacheKey, req.URL.String()), previous, validatorsTTL)
+	return true
+}
+
+// storeValidators remembers the validators of a full response along with its parsed result in entry
+func (r *ChainRegistry) storeValidators(req *http.Request, resp *http.Response, entry conditionalEntry) {
+	entry.etag, entry.lastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if entry.etag == "" && entry.lastModified == "" {
+		return
+	}
+	r.cache.Set(fmt.Sprintf(validatorsCacheKey, req.URL.String()), &entry, validatorsTTL)
+}
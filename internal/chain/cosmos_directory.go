@@ -0,0 +1,169 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Chain info sources, asked in the order set with SetChainInfoSources
+const (
+	ChainInfoSourceChainRegistry   = "chain-registry"
+	ChainInfoSourceCosmosDirectory = "cosmos-directory"
+)
+
+const (
+	defaultCosmosDirectoryURL        = "https://chains.cosmos.directory"
+	defaultCosmosDirectoryTestnetURL = "https://chains.testcosmos.directory"
+)
+
+var knownChainInfoSources = []string{ChainInfoSourceChainRegistry, ChainInfoSourceCosmosDirectory}
+
+// WithCosmosDirectoryURLs sets the cosmos.directory chain APIs of mainnets and testnets. An empty URL
+// keeps the public one.
+func WithCosmosDirectoryURLs(mainnet, testnet string) Option {
+	return func(r *ChainRegistry) {
+		if mainnet != "" {
+			r.cosmosDirectoryURL = strings.TrimRight(mainnet, "/")
+		}
+		if testnet != "" {
+			r.cosmosDirectoryTestnetURL = strings.TrimRight(testnet, "/")
+		}
+	}
+}
+
+// SetChainInfoSources selects where chain info is fetched from and in which order. The next source is
+// only asked when the previous one times out, cannot be reached, answers 5xx or is rate limited; a chain
+// missing from one source is missing from all of them. An empty list restores the default of the
+// chain-registry alone.
+func (r *ChainRegistry) SetChainInfoSources(names []string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		known := false
+		for _, source := range knownChainInfoSources {
+			known = known || name == source
+		}
+		if !known {
+			return fmt.Errorf("unknown chain info source %q, expected one of %s", name, strings.Join(knownChainInfoSources, ", "))
+		}
+		if seen[name] {
+			return fmt.Errorf("chain info source %q listed more than once", name)
+		}
+		seen[name] = true
+	}
+	sources := append([]string(nil), names...)
+	if len(sources) == 0 {
+		sources = []string{ChainInfoSourceChainRegistry}
+	}
+	r.chainInfoSourceOrder.Store(&sources)
+	return nil
+}
+
+// chainInfoSources returns the chain info sources in the order they are asked
+func (r *ChainRegistry) chainInfoSources() []string {
+	if sources := r.chainInfoSourceOrder.Load(); sources != nil {
+		return *sources
+	}
+	return []string{ChainInfoSourceChainRegistry}
+}
+
+// sourceUnavailable reports whether err means the source could not answer, so that the next chain info
+// source may be asked
+func sourceUnavailable(err error) bool {
+	var rateLimit *RateLimitError
+	return failsOver(err) || errors.As(err, &rateLimit)
+}
+
+// cosmosDirectoryResponse is the envelope cosmos.directory wraps a chain in
+type cosmosDirectoryResponse struct {
+	Chain *cosmosDirectoryChain `json:"chain"`
+}
+
+// cosmosDirectoryChain is the part of a cosmos.directory chain mapped onto ChainInfo. Besides the
+// chain.json fields it carries the APIs cosmos.directory found healthy.
+type cosmosDirectoryChain struct {
+	Name      string     `json:"name"`
+	ChainName string     `json:"chain_name"`
+	ChainID   string     `json:"chain_id"`
+	Codebase  Codebase   `json:"codebase"`
+	APIs      APIs       `json:"apis"`
+	BestAPIs  APIs       `json:"best_apis"`
+	Explorers []Explorer `json:"explorers"`
+	Peers     Peers      `json:"peers"`
+
+	PrettyName   string   `json:"pretty_name"`
+	Bech32Prefix string   `json:"bech32_prefix"`
+	DaemonName   string   `json:"daemon_name"`
+	LogoURIs     LogoURIs `json:"logo_URIs"`
+	// Image is the chain's logo, used when logo_URIs has no PNG
+	Image string `json:"image"`
+}
+
+// chainInfo maps the chain onto ChainInfo, using the healthy APIs when no others are listed
+func (c *cosmosDirectoryChain) chainInfo() ChainInfo {
+	info := ChainInfo{
+		Name:      c.ChainName,
+		ChainID:   c.ChainID,
+		Codebase:  c.Codebase,
+		APIs:      c.APIs,
+		Explorers: c.Explorers,
+		Peers:     c.Peers,
+
+		PrettyName:   c.PrettyName,
+		Bech32Prefix: c.Bech32Prefix,
+		DaemonName:   c.DaemonName,
+		LogoURIs:     c.LogoURIs,
+	}
+	if info.Name == "" {
+		info.Name = c.Name
+	}
+	if info.LogoURIs.PNG == "" {
+		info.LogoURIs.PNG = c.Image
+	}
+	if len(info.APIs.RPC) == 0 {
+		info.APIs.RPC = c.BestAPIs.RPC
+	}
+	if len(info.APIs.REST) == 0 {
+		info.APIs.REST = c.BestAPIs.REST
+	}
+	return info
+}
+
+// cosmosDirectoryChainURL returns the cosmos.directory URL of the chain in dir
+func (r *ChainRegistry) cosmosDirectoryChainURL(dir registryDir) string {
+	if dir.network == networkTestnet {
+		return r.cosmosDirectoryTestnetURL + "/" + dir.name
+	}
+	return r.cosmosDirectoryURL + "/" + dir.name
+}
+
+// fetchCosmosDirectoryChain fetches the chain in dir from cosmos.directory
+func (r *ChainRegistry) fetchCosmosDirectoryChain(ctx context.Context, dir registryDir) (*ChainInfo, error) {
+	defer r.metrics.observeFetch(FetchSourceCosmosDirectory, time.Now())
+	url := r.cosmosDirectoryChainURL(dir)
+	r.logger.Debugf("Attempting to fetch chain info from cosmos.directory: %s", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.doWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var envelope cosmosDirectoryResponse
+	if err := decodeJSON(resp, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Chain == nil {
+		return nil, fmt.Errorf("cosmos.directory response for %s holds no chain", dir.name)
+	}
+	info := envelope.Chain.chainInfo()
+	info.LastUpdated = time.Now()
+	return &info, nil
+}
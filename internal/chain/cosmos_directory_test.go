@@ -0,0 +1,152 @@
+package chain
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_CosmosDirectorySource(t *testing.T) {
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/notachain/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer github.Close()
+
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	directory := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/mainnet/osmosis":
+			http.ServeFile(w, r, "testdata/cosmos_directory_osmosis.json")
+		case "/testnet/osmosistestnet":
+			http.ServeFile(w, r, "testdata/cosmos_directory_osmosistestnet.json")
+		case "/mainnet/empty":
+			w.Write([]byte(`{"repository": {}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer directory.Close()
+	directoryRequests := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requested...)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	newRegistry := func(t *testing.T, sources ...string) *ChainRegistry {
+		mu.Lock()
+		requested = nil
+		mu.Unlock()
+		registry := NewChainRegistry(logger, github.URL, "/cosmos/chain-registry/master",
+			WithCosmosDirectoryURLs(directory.URL+"/mainnet", directory.URL+"/testnet"))
+		registry.SetRetryPolicy(-1, 0)
+		registry.SetDegradedThreshold(-1)
+		require.NoError(t, registry.SetChainInfoSources(sources))
+		return registry
+	}
+	ctx := context.Background()
+
+	t.Run("mainnet", func(t *testing.T) {
+		registry := newRegistry(t, ChainInfoSourceChainRegistry, ChainInfoSourceCosmosDirectory)
+
+		info, err := registry.GetChainInfo(ctx, "osmosis", false)
+		require.NoError(t, err)
+		assert.Equal(t, "osmosis", info.Name)
+		assert.Equal(t, "osmosis-1", info.ChainID)
+		assert.Equal(t, "mainnet", info.Network)
+		assert.Equal(t, "https://github.com/osmosis-labs/osmosis", info.Codebase.GitRepo)
+		assert.Equal(t, "v26.0.1", info.Codebase.RecommendedVersion)
+		assert.Equal(t, []string{"v26.0.0", "v26.0.1"}, info.Codebase.CompatibleVersions)
+		assert.Equal(t, []Endpoint{{Address: "https://rpc.osmosis.zone/"}, {Address: "https://osmosis-rpc.polkachu.com"}}, info.APIs.RPC)
+		assert.Len(t, info.APIs.REST, 2)
+		require.Len(t, info.Explorers, 1)
+		assert.Equal(t, "mintscan", info.Explorers[0].Kind)
+		assert.Equal(t, []string{"f515a8599b40f0e84dfad935ba414674ab11a668@osmosis.blockpane.com:26656"}, PeerAddresses(info.Peers.Seeds))
+		assert.Equal(t, "Osmosis", info.PrettyName)
+		assert.Equal(t, "osmo", info.Bech32Prefix)
+		assert.Empty(t, info.DaemonName)
+		assert.Equal(t, LogoURIs{PNG: "https://raw.githubusercontent.com/cosmos/chain-registry/master/osmosis/images/osmo.png"}, info.LogoURIs, "the image stands in for a missing logo_URIs")
+		assert.False(t, info.LastUpdated.IsZero())
+		assert.Equal(t, []string{"/mainnet/osmosis"}, directoryRequests())
+		assert.Equal(t, uint64(1), fetchCount(t, registry.Metrics(), FetchSourceCosmosDirectory))
+
+		_, err = registry.GetChainInfo(ctx, "osmosis", false)
+		require.NoError(t, err)
+		assert.Len(t, directoryRequests(), 1, "what cosmos.directory served is cached")
+	})
+
+	t.Run("testnet", func(t *testing.T) {
+		registry := newRegistry(t, ChainInfoSourceChainRegistry, ChainInfoSourceCosmosDirectory)
+
+		info, err := registry.GetChainInfo(ctx, "osmosistestnet", false)
+		require.NoError(t, err)
+		assert.Equal(t, "osmo-test-5", info.ChainID)
+		assert.Equal(t, "testnet", info.Network)
+		assert.Equal(t, "v26.0.0-rc1", info.Codebase.RecommendedVersion)
+		assert.Equal(t, []Endpoint{{Address: "https://rpc.testnet.osmosis.zone/"}}, info.APIs.RPC, "the healthy APIs stand in for a missing apis")
+		assert.Equal(t, []Endpoint{{Address: "https://lcd.testnet.osmosis.zone/"}}, info.APIs.REST)
+		assert.Empty(t, info.Peers.Seeds)
+		assert.Equal(t, []string{"/mainnet/osmosistestnet", "/testnet/osmosistestnet"}, directoryRequests())
+	})
+
+	t.Run("chain missing from the registry", func(t *testing.T) {
+		registry := newRegistry(t, ChainInfoSourceChainRegistry, ChainInfoSourceCosmosDirectory)
+
+		_, err := registry.GetChainInfo(ctx, "notachain", false)
+		var notFound *ErrChainNotFound
+		require.ErrorAs(t, err, &notFound)
+		assert.Empty(t, directoryRequests(), "a 404 is never sent on to cosmos.directory")
+	})
+
+	t.Run("envelope without a chain", func(t *testing.T) {
+		registry := newRegistry(t, ChainInfoSourceCosmosDirectory)
+
+		_, err := registry.GetChainInfo(ctx, "empty", false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "holds no chain")
+	})
+
+	t.Run("default sources", func(t *testing.T) {
+		registry := newRegistry(t)
+
+		_, err := registry.GetChainInfo(ctx, "osmosis", false)
+		require.Error(t, err)
+		assert.Empty(t, directoryRequests(), "cosmos.directory is only asked when configured")
+	})
+}
+
+func TestChainRegistry_SetChainInfoSources(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master")
+	assert.Equal(t, []string{ChainInfoSourceChainRegistry}, registry.chainInfoSources())
+
+	require.NoError(t, registry.SetChainInfoSources([]string{ChainInfoSourceCosmosDirectory, ChainInfoSourceChainRegistry}))
+	assert.Equal(t, []string{ChainInfoSourceCosmosDirectory, ChainInfoSourceChainRegistry}, registry.chainInfoSources())
+
+	assert.ErrorContains(t, registry.SetChainInfoSources([]string{"github"}), `unknown chain info source "github"`)
+	assert.ErrorContains(t, registry.SetChainInfoSources([]string{ChainInfoSourceCosmosDirectory, ChainInfoSourceCosmosDirectory}), "listed more than once")
+	assert.Equal(t, []string{ChainInfoSourceCosmosDirectory, ChainInfoSourceChainRegistry}, registry.chainInfoSources(), "an invalid list keeps the previous order")
+
+	require.NoError(t, registry.SetChainInfoSources(nil))
+	assert.Equal(t, []string{ChainInfoSourceChainRegistry}, registry.chainInfoSources())
+}
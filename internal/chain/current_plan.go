@@ -0,0 +1,60 @@
+package chain
+
+import (
+	"context"
+	"strings"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+const currentPlanPath = "/cosmos/upgrade/v1beta1/current_plan"
+
+type currentPlanResponse struct {
+	Plan *upgradePlan `json:"plan"`
+}
+
+// currentPlanProvider reads the upgrade plan scheduled on chain once its proposal has passed
+type currentPlanProvider struct {
+	registry *ChainRegistry
+}
+
+func (p *currentPlanProvider) Name() string { return ProviderCurrentPlan }
+
+func (p *currentPlanProvider) FetchUpgrade(ctx context.Context, chainName string) (*types.UpgradeInfo, error) {
+	return p.registry.fetchCurrentPlan(ctx, p.registry.knownChain(chainName))
+}
+
+// fetchCurrentPlan asks the chain's REST endpoints for the scheduled upgrade plan, moving on to the
+// next endpoint whenever one fails. A chain without a plan has no upgrade.
+func (r *ChainRegistry) fetchCurrentPlan(ctx context.Context, chainInfo *ChainInfo) (*types.UpgradeInfo, error) {
+	endpoints := r.chainAPIs(chainInfo.Name, chainInfo).REST
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+	if len(endpoints) > maxGovernanceEndpoints {
+		endpoints = endpoints[:maxGovernanceEndpoints]
+	}
+
+	for _, endpoint := range endpoints {
+		if endpoint.Address == "" {
+			continue
+		}
+		var current currentPlanResponse
+		if err := r.getRESTJSON(ctx, strings.TrimRight(endpoint.Address, "/")+currentPlanPath, &current); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			r.logger.Debugf("Current plan not available from %s for %s: %v", endpoint.Address, chainInfo.Name, err)
+			continue
+		}
+		plan, ok := current.Plan.parse()
+		if !ok {
+			return nil, nil
+		}
+		// The plan stays in place while a halted chain waits for the upgrade, so it is reported even
+		// once the chain has reached its height
+		block, blockErr := r.GetLatestBlock(ctx, chainInfo.Name)
+		return r.planUpgradeInfo(ctx, chainInfo.Name, chainInfo, plan, block, blockErr), nil
+	}
+	return nil, ErrGovernanceUnavailable
+}
@@ -0,0 +1,104 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_FetchCurrentPlan(t *testing.T) {
+	blockTime := time.Date(2024, 3, 20, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		plan       string
+		wantName   string
+		wantHeight int64
+		wantTime   time.Time
+	}{
+		{
+			name:       "populated plan",
+			plan:       `{"plan":{"name":"v25","time":"0001-01-01T00:00:00Z","height":"1080000","info":"https://example.com/v25.json","upgraded_client_state":null}}`,
+			wantName:   "v25",
+			wantHeight: 1080000,
+			wantTime:   blockTime.Add(30000 * defaultBlockTime),
+		},
+		{
+			name:       "chain halted at the plan height",
+			plan:       `{"plan":{"name":"v24","time":"0001-01-01T00:00:00Z","height":"1050000","info":"https://example.com/v24.json","upgraded_client_state":null}}`,
+			wantName:   "v24",
+			wantHeight: 1050000,
+			wantTime:   blockTime,
+		},
+		{
+			name: "empty plan",
+			plan: `{"plan":null}`,
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case latestBlockPath:
+					w.Write([]byte(`{"block":{"header":{"chain_id":"testchain-1","height":"1050000","time":"2024-03-20T15:04:05Z"}}}`))
+				case currentPlanPath:
+					w.Write([]byte(tt.plan))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer node.Close()
+
+			down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+			defer down.Close()
+
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/cosmos/chain-registry/master/testchain/chain.json" {
+					http.NotFound(w, r)
+					return
+				}
+				json.NewEncoder(w).Encode(ChainInfo{
+					Name:     "testchain",
+					ChainID:  "testchain-1",
+					Network:  "mainnet",
+					Codebase: Codebase{GitRepo: "https://github.com/test/testchain"},
+					APIs:     APIs{REST: []Endpoint{{Address: down.URL}, {Address: node.URL}}},
+				})
+			}))
+			defer registryServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetRetryPolicy(-1, 0)
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry, ProviderCurrentPlan}))
+
+			upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", true)
+			if tt.wantName == "" {
+				assert.ErrorIs(t, err, ErrNoUpgrade, "an empty plan is no upgrade rather than a failure")
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, upgrade)
+			assert.Equal(t, tt.wantName, upgrade.Name)
+			assert.Equal(t, tt.wantHeight, upgrade.Height)
+			assert.Equal(t, "upgrades/"+tt.wantName, upgrade.CosmovisorFolder)
+			assert.Equal(t, "https://github.com/test/testchain", upgrade.Repo)
+			assert.Empty(t, upgrade.Proposal)
+			assert.True(t, upgrade.Estimated)
+			assert.Equal(t, tt.wantTime, upgrade.Time)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrDegraded is returned instead of contacting upstream sources while the registry is in degraded mode
+var ErrDegraded = errors.New("registry is in degraded mode, external sources are paused")
+
+const defaultDegradedThreshold = 10
+
+// DegradedStatus describes whether external fetching is paused and why
+type DegradedStatus struct {
+	Degraded            bool      `json:"degraded"`
+	Manual              bool      `json:"manual"`
+	Reason              string    `json:"reason,omitempty"`
+	Since               time.Time `json:"since,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+}
+
+// SetDegradedThreshold sets how many consecutive upstream failures switch the registry into degraded
+// mode automatically. Zero keeps the default and a negative value disables automatic degradation.
+func (r *ChainRegistry) SetDegradedThreshold(threshold int) {
+	if threshold == 0 {
+		threshold = defaultDegradedThreshold
+	}
+	r.degradedMu.Lock()
+	defer r.degradedMu.Unlock()
+	r.degradedThreshold = threshold
+}
+
+// SetDegraded manually enables or disables degraded mode. A manual degraded mode is never
+// left automatically; it has to be disabled explicitly.
+func (r *ChainRegistry) SetDegraded(enabled bool, reason string) {
+	r.degradedMu.Lock()
+	defer r.degradedMu.Unlock()
+
+	if !enabled {
+		if r.degraded.Degraded {
+			r.logger.Info("Leaving degraded mode, external sources resumed")
+		}
+		r.degraded = DegradedStatus{}
+		return
+	}
+
+	if reason == "" {
+		reason = "enabled manually"
+	}
+	r.degraded = DegradedStatus{
+		Degraded:            true,
+		Manual:              true,
+		Reason:              reason,
+		Since:               time.Now(),
+		ConsecutiveFailures: r.degraded.ConsecutiveFailures,
+	}
+	r.logger.Warnf("Entering degraded mode: %s", reason)
+}
+
+func (r *ChainRegistry) IsDegraded() bool {
+	r.degradedMu.RLock()
+	defer r.degradedMu.RUnlock()
+	return r.degraded.Degraded
+}
+
+func (r *ChainRegistry) DegradedStatus() DegradedStatus {
+	r.degradedMu.RLock()
+	defer r.degradedMu.RUnlock()
+	return r.degraded
+}
+
+// ProbeSources checks whether the chain registry answers with a 2xx again and leaves automatic
+// degraded mode when it does. It reports whether the registry is operating normally afterwards.
+func (r *ChainRegistry) ProbeSources(ctx context.Context) bool {
+	status := r.DegradedStatus()
+	if !status.Degraded {
+		return true
+	}
+	if status.Manual {
+		return false
+	}
+
+	probeChain := "cosmoshub"
+	if chains, _ := r.GetMonitoredChains(); len(chains) > 0 {
+		probeChain = chains[0]
+	}
+	probeURL := r.registryURL(r.registryName(probeChain), "chain.json")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, probeURL, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		r.logger.Debugf("Degraded mode probe failed: %v", err)
+		return false
+	}
+	resp.Body.Close()
+	// Anything but a 2xx, such as the 404 of a proxy in front of a broken upstream, is no recovery
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		r.logger.Debugf("Degraded mode probe returned status %d", resp.StatusCode)
+		return false
+	}
+
+	r.degradedMu.Lock()
+	defer r.degradedMu.Unlock()
+	if r.degraded.Manual {
+		return false
+	}
+	r.degraded = DegradedStatus{}
+	r.logger.Info("Upstream sources reachable again, leaving degraded mode")
+	return true
+}
+
+// do sends an outbound request unless the registry is degraded, recording the outcome so that
+// sustained upstream failures switch the registry into degraded mode.
+func (r *ChainRegistry) do(req *http.Request) (*http.Response, error) {
+	if r.IsDegraded() {
+		return nil, ErrDegraded
+	}
+
+	r.authorize(req)
+	resp, err := r.client.Do(req)
+	// A request the caller cancelled says nothing about the health of the upstream
+	if err != nil && req.Context().Err() != nil {
+		return nil, err
+	}
+	r.metrics.recordOutbound(req, resp, err)
+	r.recordFetchResult(err == nil && resp.StatusCode < http.StatusInternalServerError)
+	if err != nil {
+		return nil, &ErrNetwork{URL: req.URL.String(), Err: err}
+	}
+	if err := r.rateLimited(req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *ChainRegistry) recordFetchResult(ok bool) {
+	r.degradedMu.Lock()
+	defer r.degradedMu.Unlock()
+
+	if ok {
+		r.degraded.ConsecutiveFailures = 0
+		return
+	}
+
+	r.degraded.ConsecutiveFailures++
+	if r.degraded.Degraded || r.degradedThreshold < 0 || r.degraded.ConsecutiveFailures < r.degradedThreshold {
+		return
+	}
+
+	r.degraded.Degraded = true
+	r.degraded.Reason = fmt.Sprintf("%d consecutive upstream failures", r.degraded.ConsecutiveFailures)
+	r.degraded.Since = time.Now()
+	r.logger.Warnf("Entering degraded mode: %s", r.degraded.Reason)
+}
@@ -0,0 +1,143 @@
+package chain
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_DegradedMode(t *testing.T) {
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusBadGateway)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(int(status.Load()))
+			return
+		}
+		w.Write([]byte(`{"name": "testchain", "chain_id": "testchain-1"}`))
+	}))
+	defer ts.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, ts.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetDegradedThreshold(2)
+	registry.SetMonitoredChains([]string{"testchain"})
+
+	healthy.Store(true)
+	info, err := registry.GetChainInfo(context.Background(), "testchain", true)
+	require.NoError(t, err)
+	assert.Equal(t, "testchain-1", info.ChainID)
+
+	// Sustained upstream failures switch into degraded mode, the failed refresh serving the known info
+	healthy.Store(false)
+	info, err = registry.GetChainInfo(context.Background(), "testchain", true)
+	require.NoError(t, err)
+	assert.True(t, info.Stale)
+	assert.True(t, registry.IsDegraded())
+	assert.False(t, registry.DegradedStatus().Manual)
+
+	// While degraded no requests go out and cached data is served
+	before := requests.Load()
+	info, err = registry.GetChainInfo(context.Background(), "testchain", true)
+	require.NoError(t, err)
+	assert.Equal(t, "testchain-1", info.ChainID)
+	_, err = registry.GetChainInfo(context.Background(), "unknownchain", false)
+	assert.ErrorIs(t, err, ErrDegraded)
+	assert.Equal(t, before, requests.Load())
+
+	// A failing probe keeps the registry degraded, a successful one recovers
+	assert.False(t, registry.ProbeSources(context.Background()))
+	assert.True(t, registry.IsDegraded())
+	status.Store(http.StatusNotFound)
+	assert.False(t, registry.ProbeSources(context.Background()), "a 404 is no recovery")
+	assert.True(t, registry.IsDegraded())
+
+	healthy.Store(true)
+	assert.True(t, registry.ProbeSources(context.Background()))
+	assert.False(t, registry.IsDegraded())
+	assert.Zero(t, registry.DegradedStatus().ConsecutiveFailures)
+}
+
+func TestChainRegistry_ManualDegradedMode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, ts.URL, "/cosmos/chain-registry/master")
+
+	registry.SetDegraded(true, "maintenance at upstream")
+	status := registry.DegradedStatus()
+	assert.True(t, status.Degraded)
+	assert.True(t, status.Manual)
+	assert.Equal(t, "maintenance at upstream", status.Reason)
+
+	// A successful probe does not override an operator's decision
+	assert.False(t, registry.ProbeSources(context.Background()))
+	assert.True(t, registry.IsDegraded())
+
+	registry.SetDegraded(false, "")
+	assert.False(t, registry.IsDegraded())
+}
+
+func TestChainRegistry_DegradedModeDisabled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, ts.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetDegradedThreshold(-1)
+
+	for i := 0; i < defaultDegradedThreshold+1; i++ {
+		registry.GetChainInfo(context.Background(), "testchain", true)
+	}
+	assert.False(t, registry.IsDegraded())
+}
+
+func TestChainRegistry_LastChainInfoFetch(t *testing.T) {
+	var healthy atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"name": "testchain", "chain_id": "testchain-1"}`))
+	}))
+	defer ts.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, ts.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetDegradedThreshold(-1)
+	assert.True(t, registry.LastChainInfoFetch().IsZero())
+
+	_, err := registry.GetChainInfo(context.Background(), "testchain", true)
+	require.Error(t, err)
+	assert.True(t, registry.LastChainInfoFetch().IsZero(), "a failed fetch is not recorded")
+
+	healthy.Store(true)
+	before := time.Now()
+	_, err = registry.GetChainInfo(context.Background(), "testchain", true)
+	require.NoError(t, err)
+	assert.False(t, registry.LastChainInfoFetch().Before(before))
+}
@@ -0,0 +1,124 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDiscoveryAPIURL = "https://api.github.com"
+	// defaultDiscoveryTTL is long since the listing takes several requests and new chains are rare
+	defaultDiscoveryTTL = 6 * time.Hour
+	contentsPath        = "/repos/%s/%s/contents%s?ref=%s&per_page=100"
+)
+
+var nextPageLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// DiscoveredChains lists the chains published in the chain-registry repository
+type DiscoveredChains struct {
+	Mainnet []string `json:"mainnet"`
+	Testnet []string `json:"testnet"`
+}
+
+type githubContent struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// SetChainDiscovery sets the GitHub API used to list the chain-registry repository and how long a
+// listing is cached. An empty apiURL or a non-positive ttl keeps the default.
+func (r *ChainRegistry) SetChainDiscovery(apiURL string, ttl time.Duration) {
+	if apiURL == "" {
+		apiURL = defaultDiscoveryAPIURL
+	}
+	if ttl <= 0 {
+		ttl = defaultDiscoveryTTL
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.discoveryAPIURL = strings.TrimRight(apiURL, "/")
+	r.discoveryTTL = ttl
+}
+
+// DiscoverChains lists the chain directories at the top level of the chain-registry repository and of
+// its testnets directory. Directories starting with "_" or "." hold registry metadata rather than
+// chains and are left out.
+func (r *ChainRegistry) DiscoverChains(ctx context.Context) (*DiscoveredChains, error) {
+	if discovered, _, found := cacheLookup[*DiscoveredChains](r, discoveredChainsCacheKey); found && discovered != nil {
+		return discovered, nil
+	}
+
+	r.mu.RLock()
+	ttl := r.discoveryTTL
+	r.mu.RUnlock()
+
+	mainnet, err := r.source.listChains(ctx, networkMainnet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mainnet chains: %w", err)
+	}
+	testnet, err := r.source.listChains(ctx, networkTestnet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list testnet chains: %w", err)
+	}
+
+	discovered := &DiscoveredChains{Mainnet: mainnet, Testnet: testnet}
+	r.cache.Set(discoveredChainsCacheKey, discovered, ttl)
+	return discovered, nil
+}
+
+// listChainDirs returns the sorted chain directories of a contents listing, following its pages
+func (r *ChainRegistry) listChainDirs(ctx context.Context, pageURL string) ([]string, error) {
+	chains := []string{}
+	for pageURL != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+
+		resp, err := r.doWithRetry(req)
+		if err != nil {
+			return nil, err
+		}
+		var contents []githubContent
+		err = decodeJSON(resp, &contents)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range contents {
+			if content.Type != "dir" || !isChainDirName(content.Name) {
+				continue
+			}
+			chains = append(chains, content.Name)
+		}
+
+		pageURL = ""
+		if match := nextPageLink.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+			pageURL = match[1]
+		}
+	}
+	sort.Strings(chains)
+	return chains, nil
+}
+
+// parseRegistryRepo extracts the repository and branch from a chain-registry path such as
+// "/cosmos/chain-registry/master", which may also be given as a full raw.githubusercontent.com URL
+func parseRegistryRepo(registryURL string) (owner, repo, ref string, err error) {
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return "", "", "", err
+	}
+	parts := strings.SplitN(strings.Trim(u.Path, "/"), "/", 3)
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("chain registry URL %q does not name a repository and branch", registryURL)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
@@ -0,0 +1,104 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_DiscoverChains(t *testing.T) {
+	var requests atomic.Int32
+	var apiServer *httptest.Server
+	apiServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Query().Get("ref") != "master" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var contents []githubContent
+		switch fmt.Sprintf("%s?page=%s", r.URL.Path, r.URL.Query().Get("page")) {
+		case "/repos/cosmos/chain-registry/contents?page=":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/cosmos/chain-registry/contents?ref=master&per_page=100&page=2>; rel="next", <%s/repos/cosmos/chain-registry/contents?ref=master&per_page=100&page=2>; rel="last"`, apiServer.URL, apiServer.URL))
+			contents = []githubContent{
+				{Name: ".github", Type: "dir"},
+				{Name: "_IBC", Type: "dir"},
+				{Name: "osmosis", Type: "dir"},
+				{Name: "cosmoshub", Type: "dir"},
+				{Name: "README.md", Type: "file"},
+			}
+		case "/repos/cosmos/chain-registry/contents?page=2":
+			contents = []githubContent{
+				{Name: "testnets", Type: "dir"},
+				{Name: "_template", Type: "dir"},
+				{Name: "juno", Type: "dir"},
+			}
+		case "/repos/cosmos/chain-registry/contents/testnets?page=":
+			contents = []githubContent{
+				{Name: "_IBC", Type: "dir"},
+				{Name: "osmosistestnet", Type: "dir"},
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(contents)
+	}))
+	defer apiServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master")
+	registry.SetChainDiscovery(apiServer.URL, time.Hour)
+
+	discovered, err := registry.DiscoverChains(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &DiscoveredChains{
+		Mainnet: []string{"cosmoshub", "juno", "osmosis"},
+		Testnet: []string{"osmosistestnet"},
+	}, discovered)
+	assert.Equal(t, int32(3), requests.Load())
+
+	_, err = registry.DiscoverChains(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), requests.Load(), "the listing is served from the cache")
+}
+
+func TestParseRegistryRepo(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		owner   string
+		repo    string
+		ref     string
+		wantErr bool
+	}{
+		{name: "path", url: "/cosmos/chain-registry/master", owner: "cosmos", repo: "chain-registry", ref: "master"},
+		{name: "full URL", url: "https://raw.githubusercontent.com/cosmos/chain-registry/master", owner: "cosmos", repo: "chain-registry", ref: "master"},
+		{name: "branch with slash", url: "/cosmos/chain-registry/release/v2", owner: "cosmos", repo: "chain-registry", ref: "release/v2"},
+		{name: "missing branch", url: "/cosmos/chain-registry", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, repo, ref, err := parseRegistryRepo(tt.url)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.owner, tt.repo, tt.ref}, []string{owner, repo, ref})
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package chain
+
+import (
+	"time"
+)
+
+const (
+	// defaultEndpointRefreshInterval is how long a chain's endpoint list is used before chain.json is
+	// fetched again
+	defaultEndpointRefreshInterval = time.Hour
+	// defaultEndpointRefreshRate caps how many chains have their endpoint list re-fetched per minute
+	defaultEndpointRefreshRate = 10
+)
+
+// endpointRefresher decides when the endpoint list of a known chain is re-fetched. Refreshes are
+// spread out with a per-minute budget so a large chain list does not burst against the registry.
+type endpointRefresher struct {
+	interval time.Duration
+	// perMinute is the refresh budget, unlimited when negative
+	perMinute   int
+	windowStart time.Time
+	used        int
+	// attempts records the last refresh attempt per chain so a failing chain is not retried early
+	attempts map[string]time.Time
+	now      func() time.Time
+}
+
+func newEndpointRefresher() *endpointRefresher {
+	return &endpointRefresher{
+		interval:  defaultEndpointRefreshInterval,
+		perMinute: defaultEndpointRefreshRate,
+		attempts:  make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+// SetEndpointRefresh sets how often the endpoint list of a chain is re-fetched from its chain.json and
+// how many chains may be refreshed per minute. A zero value keeps the default; a negative interval
+// disables refreshing and a negative rate removes the limit.
+func (r *ChainRegistry) SetEndpointRefresh(interval time.Duration, perMinute int) {
+	if interval == 0 {
+		interval = defaultEndpointRefreshInterval
+	}
+	if perMinute == 0 {
+		perMinute = defaultEndpointRefreshRate
+	}
+
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	r.endpointRefresh.interval = interval
+	r.endpointRefresh.perMinute = perMinute
+}
+
+// claimEndpointRefresh reports whether the known info of a chain is due for a refresh of its endpoint
+// list and, if so, records the attempt so concurrent callers keep using the known info meanwhile
+func (r *ChainRegistry) claimEndpointRefresh(chainName string, known *ChainInfo) bool {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	refresher := r.endpointRefresh
+	if refresher.interval < 0 || known == nil {
+		return false
+	}
+
+	now := refresher.now()
+	last := known.LastUpdated
+	if attempt := refresher.attempts[chainName]; attempt.After(last) {
+		last = attempt
+	}
+	if now.Sub(last) < refresher.interval {
+		return false
+	}
+
+	if refresher.perMinute > 0 {
+		if now.Sub(refresher.windowStart) >= time.Minute {
+			refresher.windowStart = now
+			refresher.used = 0
+		}
+		if refresher.used >= refresher.perMinute {
+			r.logger.Debugf("Endpoint refresh budget used up, refreshing %s later", chainName)
+			return false
+		}
+		refresher.used++
+	}
+
+	refresher.attempts[chainName] = now
+	return true
+}
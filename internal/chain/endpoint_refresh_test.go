@@ -0,0 +1,95 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_EndpointRefresh(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		rpc       = map[string]string{"chaina": "https://rpc-1.chaina.example", "chainb": "https://rpc-1.chainb.example"}
+		available = true
+		fetches   = make(map[string]int)
+	)
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/cosmos/chain-registry/master/"), "/")
+		mu.Lock()
+		defer mu.Unlock()
+		if len(parts) != 2 || parts[1] != "chain.json" || rpc[parts[0]] == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fetches[parts[0]]++
+		json.NewEncoder(w).Encode(ChainInfo{
+			Name:    parts[0],
+			ChainID: parts[0] + "-1",
+			APIs:    APIs{RPC: []Endpoint{{Address: rpc[parts[0]]}}},
+		})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetEndpointRefresh(time.Hour, 1)
+	registry.SetRetryPolicy(-1, 0)
+	now := time.Now()
+	registry.endpointRefresh.now = func() time.Time { return now }
+
+	endpoint := func(name string) string {
+		// Skip the short-lived chain info cache so only the refresh interval applies
+		registry.cache.Delete(fmt.Sprintf(chainInfoCacheKey, name))
+		info, err := registry.GetChainInfo(context.Background(), name, false)
+		require.NoError(t, err)
+		require.Len(t, info.APIs.RPC, 1)
+		return info.APIs.RPC[0].Address
+	}
+
+	assert.Equal(t, "https://rpc-1.chaina.example", endpoint("chaina"))
+	assert.Equal(t, "https://rpc-1.chainb.example", endpoint("chainb"))
+
+	mu.Lock()
+	rpc["chaina"] = "https://rpc-2.chaina.example"
+	rpc["chainb"] = "https://rpc-2.chainb.example"
+	mu.Unlock()
+
+	now = now.Add(30 * time.Minute)
+	assert.Equal(t, "https://rpc-1.chaina.example", endpoint("chaina"), "endpoints are kept within the refresh interval")
+
+	now = now.Add(31 * time.Minute)
+	assert.Equal(t, "https://rpc-2.chaina.example", endpoint("chaina"), "endpoints are refreshed after the interval")
+	assert.Equal(t, "https://rpc-1.chainb.example", endpoint("chainb"), "the per-minute refresh budget is used up")
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, "https://rpc-2.chainb.example", endpoint("chainb"))
+
+	mu.Lock()
+	available = false
+	mu.Unlock()
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, "https://rpc-2.chaina.example", endpoint("chaina"), "a failed refresh keeps the known endpoints")
+	_, hasError := registry.LastError("chaina")
+	assert.True(t, hasError)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]int{"chaina": 2, "chainb": 2}, fetches)
+}
@@ -0,0 +1,256 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rpcStatusPath = "/status"
+	// endpointProbeTimeout is short so a chain with many dead endpoints does not stall a check
+	endpointProbeTimeout = 3 * time.Second
+	// defaultProbeConcurrency caps the endpoint probes in flight across all chains, so probing dozens
+	// of chains with a dozen endpoints each does not open hundreds of connections at once
+	defaultProbeConcurrency = 16
+
+	EndpointKindRPC  = "rpc"
+	EndpointKindREST = "rest"
+)
+
+// EndpointHealth summarizes a probe of a chain's public RPC and REST endpoints
+type EndpointHealth struct {
+	Total   int `json:"total"`
+	Healthy int `json:"healthy"`
+}
+
+// EndpointStatus is the result of probing one endpoint
+type EndpointStatus struct {
+	Address   string `json:"address"`
+	Kind      string `json:"kind"`
+	Healthy   bool   `json:"healthy"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// EndpointReport is the latest probe of a chain's endpoints. Each kind is ranked with the healthy
+// endpoints first, fastest first, and this order is used for the chain's on-chain queries.
+type EndpointReport struct {
+	EndpointHealth
+	ChainName string           `json:"chain_name"`
+	CheckedAt time.Time        `json:"checked_at"`
+	RPC       []EndpointStatus `json:"rpc"`
+	REST      []EndpointStatus `json:"rest"`
+}
+
+// chainAPIs returns the endpoints used for on-chain queries of a chain, ranked by the latest endpoint
+// probe. Endpoints not probed yet come after the healthy ones and endpoints found down come last.
+func (r *ChainRegistry) chainAPIs(chainName string, info *ChainInfo) APIs {
+	apis := r.configuredAPIs(chainName, info)
+
+	r.mu.RLock()
+	report, ok := r.endpointReports[chainName]
+	r.mu.RUnlock()
+	if ok {
+		apis.RPC = rankEndpoints(apis.RPC, report.RPC)
+		apis.REST = rankEndpoints(apis.REST, report.REST)
+	}
+	return apis
+}
+
+// configuredAPIs returns the chain's endpoints in their published order. Endpoints configured for the
+// chain in chains.yaml replace the chain-registry ones of the same kind.
+func (r *ChainRegistry) configuredAPIs(chainName string, info *ChainInfo) APIs {
+	apis := info.APIs
+	chainConfig, ok := r.GetChainConfig(chainName)
+	if !ok {
+		return apis
+	}
+	if len(chainConfig.RPC) > 0 {
+		apis.RPC = configuredEndpoints(chainConfig.RPC)
+	}
+	if len(chainConfig.REST) > 0 {
+		apis.REST = configuredEndpoints(chainConfig.REST)
+	}
+	return apis
+}
+
+func configuredEndpoints(addresses []string) []Endpoint {
+	endpoints := make([]Endpoint, 0, len(addresses))
+	for _, address := range addresses {
+		endpoints = append(endpoints, Endpoint{Address: address})
+	}
+	return endpoints
+}
+
+// rankEndpoints orders endpoints by their probe results, leaving the given slice untouched
+func rankEndpoints(endpoints []Endpoint, ranked []EndpointStatus) []Endpoint {
+	const (
+		healthy = iota
+		unprobed
+		down
+	)
+	type position struct{ class, index int }
+	positions := make(map[string]position, len(ranked))
+	for i, status := range ranked {
+		class := down
+		if status.Healthy {
+			class = healthy
+		}
+		positions[status.Address] = position{class, i}
+	}
+	positionOf := func(endpoint Endpoint) position {
+		if p, ok := positions[endpoint.Address]; ok {
+			return p
+		}
+		return position{class: unprobed}
+	}
+
+	sorted := append([]Endpoint(nil), endpoints...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := positionOf(sorted[i]), positionOf(sorted[j])
+		if a.class != b.class {
+			return a.class < b.class
+		}
+		return a.index < b.index
+	})
+	return sorted
+}
+
+// ProbeEndpoints checks which of the chain's RPC and REST endpoints respond and how fast. RPC endpoints
+// are probed on /status and REST endpoints on node_info, with at most defaultProbeConcurrency probes in
+// flight across all chains. The results rank the endpoints used for the chain's on-chain queries.
+func (r *ChainRegistry) ProbeEndpoints(ctx context.Context, chainName string) (EndpointHealth, error) {
+	if r.IsDegraded() {
+		return EndpointHealth{}, ErrDegraded
+	}
+
+	info, err := r.GetChainInfo(ctx, chainName, false)
+	if err != nil {
+		return EndpointHealth{}, err
+	}
+
+	apis := r.configuredAPIs(chainName, info)
+	report := &EndpointReport{ChainName: chainName}
+	var wg sync.WaitGroup
+	for _, kind := range []struct {
+		list     []Endpoint
+		name     string
+		path     string
+		statuses *[]EndpointStatus
+	}{
+		{apis.RPC, EndpointKindRPC, rpcStatusPath, &report.RPC},
+		{apis.REST, EndpointKindREST, nodeInfoPath, &report.REST},
+	} {
+		*kind.statuses = make([]EndpointStatus, 0, len(kind.list))
+		for _, endpoint := range kind.list {
+			if endpoint.Address == "" {
+				continue
+			}
+			*kind.statuses = append(*kind.statuses, EndpointStatus{Address: endpoint.Address, Kind: kind.name})
+		}
+		for i := range *kind.statuses {
+			status := &(*kind.statuses)[i]
+			wg.Add(1)
+			go func(url string) {
+				defer wg.Done()
+				r.probeEndpoint(ctx, url, status)
+				if !status.Healthy {
+					r.logger.Debugf("Endpoint %s of %s is not responding: %s", url, chainName, status.Error)
+				}
+			}(strings.TrimRight(status.Address, "/") + kind.path)
+		}
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return EndpointHealth{}, ctx.Err()
+	}
+
+	for _, statuses := range [][]EndpointStatus{report.RPC, report.REST} {
+		sort.SliceStable(statuses, func(i, j int) bool {
+			if statuses[i].Healthy != statuses[j].Healthy {
+				return statuses[i].Healthy
+			}
+			return statuses[i].Healthy && statuses[i].LatencyMS < statuses[j].LatencyMS
+		})
+		for _, status := range statuses {
+			report.Total++
+			if status.Healthy {
+				report.Healthy++
+			}
+		}
+	}
+	report.CheckedAt = time.Now()
+
+	r.mu.Lock()
+	r.endpointReports[chainName] = report
+	r.mu.Unlock()
+	return report.EndpointHealth, nil
+}
+
+// LastEndpointHealth returns the result of the latest completed endpoint probe of the chain
+func (r *ChainRegistry) LastEndpointHealth(chainName string) (EndpointHealth, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	report, ok := r.endpointReports[chainName]
+	if !ok {
+		return EndpointHealth{}, false
+	}
+	return report.EndpointHealth, true
+}
+
+// GetEndpointReport returns the latest endpoint probe of the chain, probing its endpoints first when
+// they have not been probed yet
+func (r *ChainRegistry) GetEndpointReport(ctx context.Context, chainName string) (*EndpointReport, error) {
+	r.mu.RLock()
+	report, ok := r.endpointReports[chainName]
+	r.mu.RUnlock()
+	if !ok {
+		if _, err := r.ProbeEndpoints(ctx, chainName); err != nil {
+			return nil, err
+		}
+		r.mu.RLock()
+		report = r.endpointReports[chainName]
+		r.mu.RUnlock()
+	}
+	return report, nil
+}
+
+// probeEndpoint requests url within endpointProbeTimeout, waiting for a free probe slot first, and
+// records the outcome on status
+func (r *ChainRegistry) probeEndpoint(ctx context.Context, url string, status *EndpointStatus) {
+	select {
+	case r.probeSlots <- struct{}{}:
+		defer func() { <-r.probeSlots }()
+	case <-ctx.Done():
+		status.Error = ctx.Err().Error()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, endpointProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		status.Error = err.Error()
+		return
+	}
+	start := time.Now()
+	resp, err := r.client.Do(req)
+	if err != nil {
+		status.Error = err.Error()
+		return
+	}
+	resp.Body.Close()
+	status.LatencyMS = time.Since(start).Milliseconds()
+	if resp.StatusCode != http.StatusOK {
+		status.Error = fmt.Sprintf("status code %d", resp.StatusCode)
+		return
+	}
+	status.Healthy = true
+}
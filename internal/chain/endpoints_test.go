@@ -0,0 +1,175 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_EndpointOverrides(t *testing.T) {
+	var publicRequests atomic.Int32
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		publicRequests.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer public.Close()
+
+	ownNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case nodeInfoPath:
+			w.Write([]byte(`{"default_node_info":{"network":"testchain-1"},"application_version":{"version":"v24.0.2"}}`))
+		case latestBlockPath:
+			w.Write([]byte(`{"block":{"header":{"chain_id":"testchain-1","height":"1234567"}}}`))
+		case rpcStatusPath:
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ownNode.Close()
+
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChainInfo{
+			Name:    "testchain",
+			ChainID: "testchain-1",
+			APIs:    APIs{RPC: []Endpoint{{Address: public.URL}}, REST: []Endpoint{{Address: public.URL}}},
+		})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetChainConfigs([]types.ChainConfig{
+		{Name: "testchain", Network: "mainnet", RPC: []string{ownNode.URL}, REST: []string{ownNode.URL + "/"}},
+	})
+
+	version, err := registry.GetNodeVersion(context.Background(), "testchain")
+	require.NoError(t, err)
+	assert.Equal(t, "v24.0.2", version)
+
+	height, err := registry.GetCurrentHeight(context.Background(), "testchain")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234567), height)
+
+	health, err := registry.ProbeEndpoints(context.Background(), "testchain")
+	require.NoError(t, err)
+	assert.Equal(t, EndpointHealth{Total: 2, Healthy: 2}, health)
+
+	assert.Zero(t, publicRequests.Load(), "chain-registry endpoints are not queried when overridden")
+}
+
+func TestChainRegistry_RankedEndpoints(t *testing.T) {
+	var downRequests atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downRequests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	newNode := func(delay time.Duration) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			switch r.URL.Path {
+			case nodeInfoPath:
+				w.Write([]byte(`{"default_node_info":{"network":"testchain-1"},"application_version":{"version":"v24.0.2"}}`))
+			case latestBlockPath:
+				w.Write([]byte(`{"block":{"header":{"chain_id":"testchain-1","height":"1234567"}}}`))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	}
+	slow := newNode(100 * time.Millisecond)
+	defer slow.Close()
+	fast := newNode(0)
+	defer fast.Close()
+
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChainInfo{
+			Name:    "testchain",
+			ChainID: "testchain-1",
+			APIs:    APIs{REST: []Endpoint{{Address: down.URL}, {Address: slow.URL}, {Address: fast.URL}}},
+		})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+
+	health, err := registry.ProbeEndpoints(context.Background(), "testchain")
+	require.NoError(t, err)
+	assert.Equal(t, EndpointHealth{Total: 3, Healthy: 2}, health)
+
+	info, err := registry.GetChainInfo(context.Background(), "testchain", false)
+	require.NoError(t, err)
+	var ranked []string
+	for _, endpoint := range registry.chainAPIs("testchain", info).REST {
+		ranked = append(ranked, endpoint.Address)
+	}
+	assert.Equal(t, []string{fast.URL, slow.URL, down.URL}, ranked)
+	assert.Equal(t, down.URL, info.APIs.REST[0].Address, "the published order is left alone")
+
+	// On-chain queries start from the best endpoint instead of the first published one
+	probes := downRequests.Load()
+	height, err := registry.GetCurrentHeight(context.Background(), "testchain")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234567), height)
+	assert.Equal(t, probes, downRequests.Load())
+}
+
+func TestChainRegistry_ProbeConcurrencyBounded(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			current := maxInFlight.Load()
+			if n <= current || maxInFlight.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer node.Close()
+
+	endpoints := make([]Endpoint, 12)
+	for i := range endpoints {
+		endpoints[i] = Endpoint{Address: fmt.Sprintf("%s/node%d", node.URL, i)}
+	}
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", APIs: APIs{RPC: endpoints, REST: endpoints}})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.probeSlots = make(chan struct{}, 4)
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := registry.ProbeEndpoints(context.Background(), "testchain")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(4))
+}
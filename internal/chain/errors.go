@@ -0,0 +1,122 @@
+package chain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	errorSourceChainInfo = "chain-info"
+	errorSourceNodeInfo  = "node-info"
+)
+
+// ErrChainNotFound is returned for chains that are in none of the chain-registry directories they
+// are looked up in
+type ErrChainNotFound struct {
+	Chain string
+}
+
+func (e *ErrChainNotFound) Error() string {
+	return fmt.Sprintf("chain %q not found in either mainnet or testnet registry", e.Chain)
+}
+
+// ErrNetwork is returned when a request to URL got no response, e.g. because the host did not resolve
+// or the connection was reset
+type ErrNetwork struct {
+	URL string
+	Err error
+}
+
+func (e *ErrNetwork) Error() string {
+	return fmt.Sprintf("network error fetching %s: %v", e.URL, e.Err)
+}
+
+func (e *ErrNetwork) Unwrap() error {
+	return e.Err
+}
+
+// ErrBadResponse is returned for responses that are not the JSON asked for, such as the HTML error
+// page of a proxy or CDN
+type ErrBadResponse struct {
+	ContentType string
+}
+
+func (e *ErrBadResponse) Error() string {
+	if e.ContentType == "" {
+		return "received a response that is not JSON"
+	}
+	return fmt.Sprintf("received %s response instead of JSON", e.ContentType)
+}
+
+// ChainError is the most recent error encountered while fetching data for a chain
+type ChainError struct {
+	Chain   string    `json:"chain"`
+	Source  string    `json:"source"`
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+}
+
+// recordError remembers err as the latest error for the chain, replacing any earlier one
+func (r *ChainRegistry) recordError(chainName, source string, err error) {
+	if err == nil {
+		return
+	}
+	r.errorsMu.Lock()
+	defer r.errorsMu.Unlock()
+	r.lastErrors[chainName] = ChainError{
+		Chain:   chainName,
+		Source:  source,
+		Message: err.Error(),
+		At:      time.Now(),
+	}
+}
+
+// clearError forgets the chain's latest error after a successful fetch from source. An empty source
+// clears the error whatever its source.
+func (r *ChainRegistry) clearError(chainName, source string) {
+	r.errorsMu.Lock()
+	defer r.errorsMu.Unlock()
+	if last, ok := r.lastErrors[chainName]; ok && (source == "" || last.Source == source) {
+		delete(r.lastErrors, chainName)
+	}
+}
+
+// LastError returns the most recent unresolved error encountered for the chain
+func (r *ChainRegistry) LastError(chainName string) (ChainError, bool) {
+	r.errorsMu.RLock()
+	defer r.errorsMu.RUnlock()
+	last, ok := r.lastErrors[chainName]
+	return last, ok
+}
+
+// UpgradeFetchFailed reports whether an upgrade provider failed in the latest upgrade fetch for the
+// chain, so finding no upgrade does not prove that none is scheduled
+func (r *ChainRegistry) UpgradeFetchFailed(chainName string) bool {
+	r.errorsMu.RLock()
+	defer r.errorsMu.RUnlock()
+	return r.upgradeFetchFailed[chainName]
+}
+
+// setUpgradeFetchFailed records the outcome of the chain's latest upgrade fetch for UpgradeFetchFailed
+func (r *ChainRegistry) setUpgradeFetchFailed(chainName string, failed bool) {
+	r.errorsMu.Lock()
+	defer r.errorsMu.Unlock()
+	if failed {
+		r.upgradeFetchFailed[chainName] = true
+	} else {
+		delete(r.upgradeFetchFailed, chainName)
+	}
+}
+
+// LastErrors returns the most recent unresolved error of every chain that has one, sorted by chain
+func (r *ChainRegistry) LastErrors() []ChainError {
+	r.errorsMu.RLock()
+	defer r.errorsMu.RUnlock()
+	errs := make([]ChainError, 0, len(r.lastErrors))
+	for _, last := range r.lastErrors {
+		errs = append(errs, last)
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Chain < errs[j].Chain })
+	return errs
+}
@@ -0,0 +1,118 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_LastError(t *testing.T) {
+	var listed, upgradesUp atomic.Bool
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json") && listed.Load():
+			json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json"):
+			if !upgradesUp.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "v2", "height": 1000})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+
+	_, err := registry.GetChainInfo(context.Background(), "testchain", true)
+	require.Error(t, err)
+	last, ok := registry.LastError("testchain")
+	require.True(t, ok)
+	assert.Equal(t, errorSourceChainInfo, last.Source)
+	assert.NotEmpty(t, last.Message)
+	assert.False(t, last.At.IsZero())
+
+	listed.Store(true)
+	_, err = registry.GetChainInfo(context.Background(), "testchain", true)
+	require.NoError(t, err)
+	_, ok = registry.LastError("testchain")
+	assert.False(t, ok, "a successful fetch from the same source clears the error")
+
+	_, err = registry.GetUpgradeInfo(context.Background(), "testchain", true)
+	assert.ErrorIs(t, err, ErrNoUpgrade)
+	last, ok = registry.LastError("testchain")
+	require.True(t, ok)
+	assert.Equal(t, ProviderChainRegistry, last.Source)
+	assert.Contains(t, last.Message, "500")
+	assert.Equal(t, []ChainError{last}, registry.LastErrors())
+
+	upgradesUp.Store(true)
+	upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", true)
+	require.NoError(t, err)
+	require.NotNil(t, upgrade)
+	_, ok = registry.LastError("testchain")
+	assert.False(t, ok)
+	assert.Empty(t, registry.LastErrors())
+}
+
+func TestChainRegistry_TypedFetchErrors(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/master/proxied/chain.json") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, "<html><body>Bad gateway</body></html>")
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer registryServer.Close()
+	closedServer := httptest.NewServer(http.NotFoundHandler())
+	closedServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	newRegistry := func(url string) *ChainRegistry {
+		registry := NewChainRegistry(logger, url, "/cosmos/chain-registry/master")
+		registry.SetRetryPolicy(-1, 0)
+		return registry
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		registry := newRegistry(registryServer.URL)
+		for range 2 {
+			_, err := registry.GetChainInfo(context.Background(), "notachain", false)
+			var notFound *ErrChainNotFound
+			require.ErrorAs(t, err, &notFound, "fresh and cached lookups")
+			assert.Equal(t, "notachain", notFound.Chain)
+		}
+	})
+
+	t.Run("network", func(t *testing.T) {
+		_, err := newRegistry(closedServer.URL).GetChainInfo(context.Background(), "testchain", false)
+		var network *ErrNetwork
+		require.ErrorAs(t, err, &network)
+		assert.Contains(t, network.URL, "/testchain/chain.json")
+		assert.Error(t, network.Unwrap())
+	})
+
+	t.Run("bad response", func(t *testing.T) {
+		_, err := newRegistry(registryServer.URL).GetChainInfo(context.Background(), "proxied", false)
+		var bad *ErrBadResponse
+		require.ErrorAs(t, err, &bad)
+		assert.Equal(t, "text/html; charset=utf-8", bad.ContentType)
+	})
+}
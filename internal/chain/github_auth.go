@@ -0,0 +1,75 @@
+package chain
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// githubHosts are the GitHub hosts the token is sent to, besides the configured registry host
+var githubHosts = map[string]bool{
+	"github.com":                true,
+	"api.github.com":            true,
+	"raw.githubusercontent.com": true,
+}
+
+// RateLimitError is returned when GitHub answers with an exhausted rate limit
+type RateLimitError struct {
+	URL   string
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("GitHub rate limit exceeded fetching %s", e.URL)
+	}
+	return fmt.Sprintf("GitHub rate limit exceeded fetching %s, resets at %s", e.URL, e.Reset.Format(time.RFC3339))
+}
+
+// SetGitHubToken sets the token sent to GitHub with chain-registry requests, raising the rate limit
+// from the unauthenticated one. An empty token sends requests unauthenticated.
+func (r *ChainRegistry) SetGitHubToken(token string) {
+	r.githubToken.Store(&token)
+}
+
+// authorize adds the GitHub token to requests for GitHub or the configured registry host. Other hosts,
+// such as Polkachu or a chain's RPC endpoints, never see it.
+func (r *ChainRegistry) authorize(req *http.Request) {
+	token := r.githubToken.Load()
+	if token == nil || *token == "" || !r.isGitHubURL(req.URL) {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+*token)
+}
+
+func (r *ChainRegistry) isGitHubURL(u *url.URL) bool {
+	if githubHosts[strings.ToLower(u.Hostname())] {
+		return true
+	}
+	registry, err := url.Parse(r.githubAPIURL)
+	return err == nil && registry.Host != "" && strings.EqualFold(registry.Host, u.Host)
+}
+
+// rateLimited returns a RateLimitError, closing the response, when resp reports an exhausted GitHub
+// rate limit
+func (r *ChainRegistry) rateLimited(req *http.Request, resp *http.Response) error {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	err := &RateLimitError{URL: req.URL.String()}
+	if reset, parseErr := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); parseErr == nil {
+		err.Reset = time.Unix(reset, 0)
+	}
+	r.logger.Warn(err)
+	return err
+}
@@ -0,0 +1,93 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_GitHubToken(t *testing.T) {
+	var mu sync.Mutex
+	var authorizations []string
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		mu.Unlock()
+		if !strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json") {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetGitHubToken("secret")
+
+	_, err := registry.GetChainInfo(context.Background(), "testchain", false)
+	require.NoError(t, err)
+	require.NotEmpty(t, authorizations)
+	for _, authorization := range authorizations {
+		assert.Equal(t, "Bearer secret", authorization)
+	}
+
+	tests := []struct {
+		name       string
+		url        string
+		authorized bool
+	}{
+		{name: "registry", url: registryServer.URL + "/cosmos/chain-registry/master/osmosis/chain.json", authorized: true},
+		{name: "raw GitHub", url: "https://raw.githubusercontent.com/cosmos/chain-registry/master/osmosis/chain.json", authorized: true},
+		{name: "GitHub API", url: "https://api.github.com/repos/osmosis-labs/osmosis/releases", authorized: true},
+		{name: "Polkachu", url: "https://polkachu.com/api/v2/chain_upgrades"},
+		{name: "RPC endpoint", url: "https://rpc.osmosis.zone/status"},
+		{name: "lookalike host", url: "https://github.com.example.org/chain.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			require.NoError(t, err)
+			registry.authorize(req)
+			if tt.authorized {
+				assert.Equal(t, "Bearer secret", req.Header.Get("Authorization"))
+			} else {
+				assert.Empty(t, req.Header.Get("Authorization"))
+			}
+		})
+	}
+}
+
+func TestChainRegistry_GitHubRateLimit(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	_, err := registry.fetchChainInfoFromURL(context.Background(), registryServer.URL+"/cosmos/chain-registry/master/testchain/chain.json")
+
+	var rateLimit *RateLimitError
+	require.ErrorAs(t, err, &rateLimit)
+	assert.True(t, reset.Equal(rateLimit.Reset))
+	assert.Contains(t, err.Error(), reset.Format(time.RFC3339))
+}
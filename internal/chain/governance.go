@@ -0,0 +1,204 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+const (
+	govV1ProposalsPath      = "/cosmos/gov/v1/proposals?proposal_status=PROPOSAL_STATUS_VOTING_PERIOD"
+	govV1beta1ProposalsPath = "/cosmos/gov/v1beta1/proposals?proposal_status=PROPOSAL_STATUS_VOTING_PERIOD"
+	// maxGovernanceEndpoints caps how many REST endpoints are asked before governance counts as unavailable
+	maxGovernanceEndpoints = 3
+)
+
+// ErrGovernanceUnavailable is returned when none of a chain's REST endpoints answer governance queries
+var ErrGovernanceUnavailable = errors.New("governance not available")
+
+type upgradePlan struct {
+	Name   string `json:"name"`
+	Height string `json:"height"`
+	Info   string `json:"info"`
+}
+
+// govContent is a proposal message or legacy content. Software upgrades carry their plan directly,
+// while a gov v1 MsgExecLegacyContent wraps a SoftwareUpgradeProposal in content.
+type govContent struct {
+	Type    string       `json:"@type"`
+	Plan    *upgradePlan `json:"plan"`
+	Content *govContent  `json:"content"`
+}
+
+type govV1ProposalsResponse struct {
+	Proposals []struct {
+		ID       string       `json:"id"`
+		Messages []govContent `json:"messages"`
+	} `json:"proposals"`
+}
+
+type govV1beta1ProposalsResponse struct {
+	Proposals []struct {
+		ProposalID string     `json:"proposal_id"`
+		Content    govContent `json:"content"`
+	} `json:"proposals"`
+}
+
+// governancePlan is an upgrade plan found on chain, with the proposal it comes from if it is still
+// being voted on
+type governancePlan struct {
+	upgradePlan
+	height     int64
+	proposalID string
+}
+
+// parse validates the plan, dropping plans without a name or a positive height
+func (p *upgradePlan) parse() (governancePlan, bool) {
+	if p == nil || p.Name == "" {
+		return governancePlan{}, false
+	}
+	height, err := strconv.ParseInt(p.Height, 10, 64)
+	if err != nil || height <= 0 {
+		return governancePlan{}, false
+	}
+	return governancePlan{upgradePlan: *p, height: height}, true
+}
+
+// upgradePlanOf returns the software upgrade plan in a proposal message, if it is one
+func upgradePlanOf(content *govContent) *upgradePlan {
+	for content != nil {
+		if (strings.HasSuffix(content.Type, ".MsgSoftwareUpgrade") || strings.HasSuffix(content.Type, ".SoftwareUpgradeProposal")) && content.Plan != nil {
+			return content.Plan
+		}
+		content = content.Content
+	}
+	return nil
+}
+
+// governanceProvider reads the software upgrade proposals in their voting period from the chain's
+// own governance
+type governanceProvider struct {
+	registry *ChainRegistry
+}
+
+func (p *governanceProvider) Name() string { return ProviderGovernance }
+
+func (p *governanceProvider) FetchUpgrade(ctx context.Context, chainName string) (*types.UpgradeInfo, error) {
+	return p.registry.fetchGovernanceUpgrade(ctx, chainName)
+}
+
+func (r *ChainRegistry) fetchGovernanceUpgrade(ctx context.Context, chainName string) (*types.UpgradeInfo, error) {
+	chain := r.knownChain(chainName)
+	endpoints := r.chainAPIs(chainName, chain).REST
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+	if len(endpoints) > maxGovernanceEndpoints {
+		endpoints = endpoints[:maxGovernanceEndpoints]
+	}
+
+	for _, endpoint := range endpoints {
+		if endpoint.Address == "" {
+			continue
+		}
+		plans, err := r.fetchGovernancePlans(ctx, strings.TrimRight(endpoint.Address, "/"))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			r.logger.Debugf("Governance not available from %s for %s: %v", endpoint.Address, chainName, err)
+			continue
+		}
+		return r.nextGovernanceUpgrade(ctx, chainName, chain, plans), nil
+	}
+	return nil, ErrGovernanceUnavailable
+}
+
+// fetchGovernancePlans returns the plans of the software upgrade proposals in their voting period.
+// Nodes without gov v1 are asked through the legacy v1beta1 API.
+func (r *ChainRegistry) fetchGovernancePlans(ctx context.Context, address string) ([]governancePlan, error) {
+	var plans []governancePlan
+	add := func(plan *upgradePlan, proposalID string) {
+		if p, ok := plan.parse(); ok {
+			p.proposalID = proposalID
+			plans = append(plans, p)
+		}
+	}
+
+	var v1 govV1ProposalsResponse
+	if err := r.getRESTJSON(ctx, address+govV1ProposalsPath, &v1); err == nil {
+		for _, proposal := range v1.Proposals {
+			for i := range proposal.Messages {
+				add(upgradePlanOf(&proposal.Messages[i]), proposal.ID)
+			}
+		}
+		return plans, nil
+	} else if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var v1beta1 govV1beta1ProposalsResponse
+	if err := r.getRESTJSON(ctx, address+govV1beta1ProposalsPath, &v1beta1); err != nil {
+		return nil, err
+	}
+	for _, proposal := range v1beta1.Proposals {
+		add(upgradePlanOf(&proposal.Content), proposal.ProposalID)
+	}
+	return plans, nil
+}
+
+// nextGovernanceUpgrade converts the lowest plan still ahead of the chain into an upgrade
+func (r *ChainRegistry) nextGovernanceUpgrade(ctx context.Context, chainName string, chain *ChainInfo, plans []governancePlan) *types.UpgradeInfo {
+	block, blockErr := r.GetLatestBlock(ctx, chainName)
+
+	var next *governancePlan
+	for i := range plans {
+		if blockErr == nil && plans[i].height <= block.Height {
+			continue
+		}
+		if next == nil || plans[i].height < next.height {
+			next = &plans[i]
+		}
+	}
+	if next == nil {
+		return nil
+	}
+	return r.planUpgradeInfo(ctx, chainName, chain, *next, block, blockErr)
+}
+
+// planUpgradeInfo converts an on-chain plan into an upgrade, estimating its time from the latest block
+// and the chain's average block time
+func (r *ChainRegistry) planUpgradeInfo(ctx context.Context, chainName string, chain *ChainInfo, plan governancePlan, block LatestBlock, blockErr error) *types.UpgradeInfo {
+	estimated := time.Now()
+	if blockErr == nil {
+		if !block.Time.IsZero() {
+			estimated = block.Time
+		}
+		estimated = estimateUpgradeTime(estimated, block.Height, plan.height, r.blockTimeOrDefault(ctx, chainName))
+	} else {
+		r.logger.Debugf("Latest block of %s not available, cannot estimate the time of %s: %v", chainName, plan.Name, blockErr)
+	}
+
+	repo := chain.Codebase.GitRepo
+	upgradeInfo := &types.UpgradeInfo{
+		Name:             plan.Name,
+		ChainName:        chainName,
+		Height:           plan.height,
+		Info:             plan.Info,
+		Time:             estimated,
+		Version:          r.resolveVersion(ctx, plan.Name, repo),
+		Estimated:        true,
+		Network:          chain.Network,
+		Proposal:         plan.proposalID,
+		Guide:            plan.Info,
+		CosmovisorFolder: fmt.Sprintf("upgrades/%s", plan.Name),
+		Repo:             repo,
+	}
+	r.fillExplorerLinks(chain, upgradeInfo)
+	return upgradeInfo
+}
@@ -0,0 +1,143 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_GovernanceUpgrade(t *testing.T) {
+	blockTime := time.Date(2024, 3, 20, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		v1           string
+		v1beta1      string
+		wantName     string
+		wantHeight   int64
+		wantProposal string
+		wantInfo     string
+	}{
+		{
+			name:         "v1 proposals",
+			v1:           "testdata/gov_v1_proposals.json",
+			wantName:     "v25",
+			wantHeight:   1100000,
+			wantProposal: "911",
+			wantInfo:     `{"binaries":{}}`,
+		},
+		{
+			name:         "legacy v1beta1 proposals",
+			v1beta1:      "testdata/gov_v1beta1_proposals.json",
+			wantName:     "v12",
+			wantHeight:   5500000,
+			wantProposal: "88",
+			wantInfo:     "https://example.com/v12.json",
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case latestBlockPath:
+					w.Write([]byte(`{"block":{"header":{"chain_id":"testchain-1","height":"1050000","time":"2024-03-20T15:04:05Z"}}}`))
+				case "/cosmos/gov/v1/proposals":
+					if tt.v1 == "" {
+						// Nodes older than gov v1 do not implement the route
+						w.WriteHeader(http.StatusNotImplemented)
+						return
+					}
+					assert.Equal(t, "PROPOSAL_STATUS_VOTING_PERIOD", r.URL.Query().Get("proposal_status"))
+					http.ServeFile(w, r, tt.v1)
+				case "/cosmos/gov/v1beta1/proposals":
+					if tt.v1beta1 == "" {
+						http.NotFound(w, r)
+						return
+					}
+					http.ServeFile(w, r, tt.v1beta1)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer node.Close()
+
+			down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+			defer down.Close()
+
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/cosmos/chain-registry/master/testchain/chain.json" {
+					http.NotFound(w, r)
+					return
+				}
+				json.NewEncoder(w).Encode(ChainInfo{
+					Name:     "testchain",
+					ChainID:  "testchain-1",
+					Network:  "mainnet",
+					Codebase: Codebase{GitRepo: "https://github.com/test/testchain"},
+					APIs:     APIs{REST: []Endpoint{{Address: down.URL}, {Address: node.URL + "/"}}},
+				})
+			}))
+			defer registryServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetRetryPolicy(-1, 0)
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry, ProviderGovernance}))
+
+			upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", true)
+			require.NoError(t, err)
+			require.NotNil(t, upgrade)
+			assert.Equal(t, tt.wantName, upgrade.Name)
+			assert.Equal(t, tt.wantHeight, upgrade.Height)
+			assert.Equal(t, tt.wantProposal, upgrade.Proposal)
+			assert.Equal(t, tt.wantInfo, upgrade.Info)
+			assert.Equal(t, "mainnet", upgrade.Network)
+			assert.Equal(t, "upgrades/"+tt.wantName, upgrade.CosmovisorFolder)
+			assert.Equal(t, "https://github.com/test/testchain", upgrade.Repo)
+			assert.True(t, upgrade.Estimated)
+			assert.Equal(t, blockTime.Add(time.Duration(tt.wantHeight-1050000)*defaultBlockTime), upgrade.Time)
+		})
+	}
+}
+
+func TestChainRegistry_GovernanceUnavailable(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChainInfo{
+			Name:    "testchain",
+			ChainID: "testchain-1",
+			APIs:    APIs{REST: []Endpoint{{Address: down.URL}}},
+		})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	_, err := registry.GetChainInfo(context.Background(), "testchain", false)
+	require.NoError(t, err)
+
+	upgrade, err := registry.fetchGovernanceUpgrade(context.Background(), "testchain")
+	assert.Nil(t, upgrade)
+	assert.True(t, errors.Is(err, ErrGovernanceUnavailable), "got %v", err)
+}
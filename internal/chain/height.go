@@ -0,0 +1,159 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// currentHeightTTL is short since a chain produces a block every few seconds
+	currentHeightTTL   = 30 * time.Second
+	latestBlockPath    = "/cosmos/base/tendermint/v1beta1/blocks/latest"
+	maxHeightEndpoints = 3
+)
+
+// ErrHeightUnavailable is returned when none of a chain's RPC or REST endpoints report its latest block
+var ErrHeightUnavailable = errors.New("current height not available")
+
+// LatestBlock is the chain's most recent block as reported by one of its nodes
+type LatestBlock struct {
+	Height int64     `json:"height"`
+	Time   time.Time `json:"time"`
+}
+
+type rpcStatusResponse struct {
+	Result struct {
+		SyncInfo struct {
+			LatestBlockHeight string    `json:"latest_block_height"`
+			LatestBlockTime   time.Time `json:"latest_block_time"`
+		} `json:"sync_info"`
+	} `json:"result"`
+}
+
+type latestBlockResponse struct {
+	Block struct {
+		Header struct {
+			Height string    `json:"height"`
+			Time   time.Time `json:"time"`
+		} `json:"header"`
+	} `json:"block"`
+}
+
+// GetCurrentHeight returns the latest block height of the chain, see GetLatestBlock
+func (r *ChainRegistry) GetCurrentHeight(ctx context.Context, chainName string) (int64, error) {
+	block, err := r.GetLatestBlock(ctx, chainName)
+	if err != nil {
+		return 0, err
+	}
+	return block.Height, nil
+}
+
+// GetLatestBlock returns the latest block of the chain from the RPC /status of its nodes, falling back
+// to their REST APIs. The block is also recorded on the chain's info so upgrade statuses can confirm
+// applied upgrades.
+func (r *ChainRegistry) GetLatestBlock(ctx context.Context, chainName string) (LatestBlock, error) {
+	cacheKey := fmt.Sprintf(currentHeightCacheKey, chainName)
+	if block, negative, found := cacheLookup[LatestBlock](r, cacheKey); found && !negative {
+		return block, nil
+	}
+
+	if r.IsDegraded() {
+		return LatestBlock{}, ErrDegraded
+	}
+
+	info, err := r.GetChainInfo(ctx, chainName, false)
+	if err != nil {
+		return LatestBlock{}, err
+	}
+
+	block, err := r.fetchCurrentHeight(ctx, chainName, info)
+	if err != nil {
+		return LatestBlock{}, err
+	}
+
+	r.cache.Set(cacheKey, block, currentHeightTTL)
+	r.recordLatestBlock(chainName, block)
+	return block, nil
+}
+
+// recordLatestBlock records the block on the known info of the chain, replacing it by an updated copy
+// since callers of GetChainInfo read it without a lock. Cached chain info gets it from there.
+func (r *ChainRegistry) recordLatestBlock(chainName string, block LatestBlock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if chain, ok := r.chains[chainName]; ok && chain != nil {
+		updated := *chain
+		updated.Height, updated.LatestBlockTime = block.Height, block.Time
+		r.chains[chainName] = &updated
+	}
+}
+
+// fetchCurrentHeight asks the chain's RPC endpoints and then its REST endpoints for the latest block,
+// moving on to the next endpoint whenever one fails
+func (r *ChainRegistry) fetchCurrentHeight(ctx context.Context, chainName string, info *ChainInfo) (LatestBlock, error) {
+	return r.fetchBlock(ctx, chainName, info, ErrHeightUnavailable, r.fetchRPCStatus, r.fetchRESTLatestBlock)
+}
+
+// blockFetcher reads a block from the node at address
+type blockFetcher func(ctx context.Context, address string) (LatestBlock, error)
+
+// fetchBlock reads a block from the chain's RPC endpoints and then its REST endpoints, up to
+// maxHeightEndpoints of each, returning unavailable when none answers
+func (r *ChainRegistry) fetchBlock(ctx context.Context, chainName string, info *ChainInfo, unavailable error, rpc, rest blockFetcher) (LatestBlock, error) {
+	apis := r.chainAPIs(chainName, info)
+	for _, source := range []struct {
+		endpoints []Endpoint
+		fetch     blockFetcher
+	}{
+		{apis.RPC, rpc},
+		{apis.REST, rest},
+	} {
+		endpoints := source.endpoints
+		if len(endpoints) > maxHeightEndpoints {
+			endpoints = endpoints[:maxHeightEndpoints]
+		}
+		for _, endpoint := range endpoints {
+			if endpoint.Address == "" {
+				continue
+			}
+			block, err := source.fetch(ctx, strings.TrimRight(endpoint.Address, "/"))
+			if err != nil {
+				if ctx.Err() != nil {
+					return LatestBlock{}, ctx.Err()
+				}
+				r.logger.Debugf("Block not available from %s for %s: %v", endpoint.Address, chainName, err)
+				continue
+			}
+			return block, nil
+		}
+	}
+	return LatestBlock{}, unavailable
+}
+
+func (r *ChainRegistry) fetchRPCStatus(ctx context.Context, address string) (LatestBlock, error) {
+	var status rpcStatusResponse
+	if err := r.getRESTJSON(ctx, address+rpcStatusPath, &status); err != nil {
+		return LatestBlock{}, err
+	}
+	return parseLatestBlock(status.Result.SyncInfo.LatestBlockHeight, status.Result.SyncInfo.LatestBlockTime)
+}
+
+func (r *ChainRegistry) fetchRESTLatestBlock(ctx context.Context, address string) (LatestBlock, error) {
+	var block latestBlockResponse
+	if err := r.getRESTJSON(ctx, address+latestBlockPath, &block); err != nil {
+		return LatestBlock{}, err
+	}
+	return parseLatestBlock(block.Block.Header.Height, block.Block.Header.Time)
+}
+
+func parseLatestBlock(height string, blockTime time.Time) (LatestBlock, error) {
+	parsed, err := strconv.ParseInt(height, 10, 64)
+	if err != nil || parsed <= 0 {
+		return LatestBlock{}, fmt.Errorf("invalid block height %q", height)
+	}
+	return LatestBlock{Height: parsed, Time: blockTime}, nil
+}
@@ -0,0 +1,95 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_GetCurrentHeight(t *testing.T) {
+	var blockRequests int
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != latestBlockPath {
+			http.NotFound(w, r)
+			return
+		}
+		blockRequests++
+		w.Write([]byte(`{"block":{"header":{"chain_id":"testchain-1","height":"1234567"}}}`))
+	}))
+	defer node.Close()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChainInfo{
+			Name:    "testchain",
+			ChainID: "testchain-1",
+			APIs:    APIs{REST: []Endpoint{{Address: down.URL}, {Address: node.URL}}},
+		})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+
+	height, err := registry.GetCurrentHeight(context.Background(), "testchain")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234567), height)
+
+	height, err = registry.GetCurrentHeight(context.Background(), "testchain")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234567), height)
+	assert.Equal(t, 1, blockRequests, "height should be served from cache")
+}
+
+func TestChainRegistry_GetLatestBlockFromRPC(t *testing.T) {
+	blockTime := time.Date(2024, 3, 20, 15, 4, 5, 0, time.UTC)
+	rpcNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != rpcStatusPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":-1,"result":{"sync_info":{"latest_block_height":"7654321","latest_block_time":"2024-03-20T15:04:05Z","catching_up":false}}}`))
+	}))
+	defer rpcNode.Close()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChainInfo{
+			Name:    "testchain",
+			ChainID: "testchain-1",
+			APIs:    APIs{RPC: []Endpoint{{Address: down.URL}, {Address: rpcNode.URL + "/"}}, REST: []Endpoint{{Address: down.URL}}},
+		})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+
+	block, err := registry.GetLatestBlock(context.Background(), "testchain")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7654321), block.Height)
+	assert.True(t, blockTime.Equal(block.Time))
+
+	info, err := registry.GetChainInfo(context.Background(), "testchain", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7654321), info.Height)
+	assert.True(t, blockTime.Equal(info.LatestBlockTime))
+}
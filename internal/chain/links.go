@@ -0,0 +1,133 @@
+package chain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// defaultExplorerOrder is the explorer preference when none is configured. Chains listing neither use
+// the first of their explorers that can render the link.
+var defaultExplorerOrder = []string{"mintscan", "ping.pub"}
+
+// explorerPlaceholder matches the ${...} placeholder of a chain-registry page template such as
+// https://www.mintscan.io/cosmos/proposals/${proposalId}
+var explorerPlaceholder = regexp.MustCompile(`\$\{[^}]*\}`)
+
+// explorerPaths are the block and proposal paths under an explorer's URL
+type explorerPaths struct {
+	block    string
+	proposal string
+}
+
+// knownExplorerPaths are the paths of explorer kinds whose chain.json entries have no page templates
+var knownExplorerPaths = map[string]explorerPaths{
+	"mintscan": {block: "/block/%s", proposal: "/proposals/%s"},
+	"ping.pub": {block: "/block/%s", proposal: "/gov/%s"},
+}
+
+// LinkBuilder renders block and proposal links from the explorers a chain lists in its chain.json
+type LinkBuilder struct {
+	order []string
+}
+
+// NewLinkBuilder returns a LinkBuilder preferring the explorers of the given kinds or names, in order.
+// An empty order uses mintscan and then ping.pub.
+func NewLinkBuilder(order []string) *LinkBuilder {
+	if len(order) == 0 {
+		order = defaultExplorerOrder
+	}
+	normalized := make([]string, 0, len(order))
+	for _, name := range order {
+		normalized = append(normalized, strings.ToLower(strings.TrimSpace(name)))
+	}
+	return &LinkBuilder{order: normalized}
+}
+
+// SetExplorerOrder sets which explorers block and proposal links point to, by kind or name in order
+// of preference. An empty list restores the default of mintscan and then ping.pub.
+func (r *ChainRegistry) SetExplorerOrder(order []string) {
+	r.links.Store(NewLinkBuilder(order))
+}
+
+// Links returns the registry's LinkBuilder
+func (r *ChainRegistry) Links() *LinkBuilder {
+	return r.links.Load()
+}
+
+// BlockLink returns the link to the block at height on the chain's preferred explorer, or "" when
+// none of its explorers can render one
+func (b *LinkBuilder) BlockLink(chain *ChainInfo, height int64) string {
+	if height <= 0 {
+		return ""
+	}
+	return b.render(chain, strconv.FormatInt(height, 10),
+		func(explorer Explorer) string { return explorer.BlockPage },
+		func(paths explorerPaths) string { return paths.block })
+}
+
+// ProposalLink returns the link to the governance proposal on the chain's preferred explorer, or ""
+// when none of its explorers can render one
+func (b *LinkBuilder) ProposalLink(chain *ChainInfo, proposalID string) string {
+	if proposalID == "" {
+		return ""
+	}
+	return b.render(chain, proposalID,
+		func(explorer Explorer) string { return explorer.ProposalPage },
+		func(paths explorerPaths) string { return paths.proposal })
+}
+
+// render fills the page template of the first preferred explorer able to render the link, falling
+// back to the known paths of the explorer's kind
+func (b *LinkBuilder) render(chain *ChainInfo, value string, page func(Explorer) string, path func(explorerPaths) string) string {
+	if chain == nil {
+		return ""
+	}
+	for _, explorer := range b.ranked(chain.Explorers) {
+		if template := page(explorer); explorerPlaceholder.MatchString(template) {
+			return explorerPlaceholder.ReplaceAllLiteralString(template, value)
+		}
+		if paths, ok := knownExplorerPaths[explorerKind(explorer)]; ok && explorer.URL != "" {
+			return strings.TrimRight(explorer.URL, "/") + fmt.Sprintf(path(paths), value)
+		}
+	}
+	return ""
+}
+
+// ranked returns the preferred explorers in order, followed by the others as listed
+func (b *LinkBuilder) ranked(explorers []Explorer) []Explorer {
+	ranked := make([]Explorer, 0, len(explorers))
+	used := make([]bool, len(explorers))
+	for _, preferred := range b.order {
+		for i, explorer := range explorers {
+			if !used[i] && (explorerKind(explorer) == preferred || strings.EqualFold(explorer.Name, preferred)) {
+				ranked = append(ranked, explorer)
+				used[i] = true
+			}
+		}
+	}
+	for i, explorer := range explorers {
+		if !used[i] {
+			ranked = append(ranked, explorer)
+		}
+	}
+	return ranked
+}
+
+func explorerKind(explorer Explorer) string {
+	return strings.ToLower(strings.TrimSpace(explorer.Kind))
+}
+
+// fillExplorerLinks sets the block and proposal links the upgrade's source left empty
+func (r *ChainRegistry) fillExplorerLinks(chain *ChainInfo, upgradeInfo *types.UpgradeInfo) {
+	links := r.Links()
+	if upgradeInfo.BlockLink == "" {
+		upgradeInfo.BlockLink = links.BlockLink(chain, upgradeInfo.Height)
+	}
+	if upgradeInfo.ProposalLink == "" {
+		upgradeInfo.ProposalLink = links.ProposalLink(chain, parseProposalID(upgradeInfo.Proposal))
+	}
+}
@@ -0,0 +1,90 @@
+package chain
+
+import (
+	"io"
+	"testing"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkBuilder(t *testing.T) {
+	mintscan := Explorer{Kind: "mintscan", URL: "https://www.mintscan.io/osmosis", TxPage: "https://www.mintscan.io/osmosis/txs/${txHash}"}
+	pingPub := Explorer{Kind: "ping.pub", URL: "https://ping.pub/osmosis/", TxPage: "https://ping.pub/osmosis/tx/${txHash}"}
+	custom := Explorer{
+		Kind:         "bigdipper",
+		URL:          "https://explorer.example.com",
+		BlockPage:    "https://explorer.example.com/chain?block=${blockHeight}&tab=overview",
+		ProposalPage: "https://explorer.example.com/governance/${proposalId}/votes",
+	}
+	atomscan := Explorer{Kind: "atomscan", URL: "https://atomscan.com/osmosis"}
+
+	tests := []struct {
+		name         string
+		order        []string
+		explorers    []Explorer
+		wantBlock    string
+		wantProposal string
+	}{
+		{
+			name:         "mintscan",
+			explorers:    []Explorer{pingPub, mintscan},
+			wantBlock:    "https://www.mintscan.io/osmosis/block/1200000",
+			wantProposal: "https://www.mintscan.io/osmosis/proposals/912",
+		},
+		{
+			name:         "ping.pub",
+			explorers:    []Explorer{atomscan, pingPub},
+			wantBlock:    "https://ping.pub/osmosis/block/1200000",
+			wantProposal: "https://ping.pub/osmosis/gov/912",
+		},
+		{
+			name:         "nonstandard templates",
+			explorers:    []Explorer{atomscan, custom},
+			wantBlock:    "https://explorer.example.com/chain?block=1200000&tab=overview",
+			wantProposal: "https://explorer.example.com/governance/912/votes",
+		},
+		{
+			name:         "configured order",
+			order:        []string{"BigDipper", "mintscan"},
+			explorers:    []Explorer{mintscan, custom},
+			wantBlock:    "https://explorer.example.com/chain?block=1200000&tab=overview",
+			wantProposal: "https://explorer.example.com/governance/912/votes",
+		},
+		{
+			name:      "no usable explorer",
+			explorers: []Explorer{atomscan},
+		},
+		{
+			name: "no explorers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := NewLinkBuilder(tt.order)
+			chain := &ChainInfo{Name: "osmosis", Explorers: tt.explorers}
+			assert.Equal(t, tt.wantBlock, links.BlockLink(chain, 1200000))
+			assert.Equal(t, tt.wantProposal, links.ProposalLink(chain, "912"))
+		})
+	}
+}
+
+func TestChainRegistry_FillExplorerLinks(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master")
+	chain := &ChainInfo{Name: "osmosis", Explorers: []Explorer{{Kind: "mintscan", URL: "https://www.mintscan.io/osmosis"}}}
+
+	upgrade := &types.UpgradeInfo{Height: 1200000, Proposal: "912"}
+	registry.fillExplorerLinks(chain, upgrade)
+	assert.Equal(t, "https://www.mintscan.io/osmosis/block/1200000", upgrade.BlockLink)
+	assert.Equal(t, "https://www.mintscan.io/osmosis/proposals/912", upgrade.ProposalLink)
+
+	// Links given by the upgrade's source are kept
+	upgrade = &types.UpgradeInfo{Height: 1200000, BlockLink: "https://polkachu.com/block", ProposalLink: "https://polkachu.com/proposal"}
+	registry.fillExplorerLinks(chain, upgrade)
+	assert.Equal(t, "https://polkachu.com/block", upgrade.BlockLink)
+	assert.Equal(t, "https://polkachu.com/proposal", upgrade.ProposalLink)
+}
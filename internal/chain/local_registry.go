@@ -0,0 +1,144 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// WithLocalRegistry makes the registry read chain.json, upgrades.json, versions.json and asset lists
+// from a local clone of the chain-registry repository at path instead of over HTTP, for hosts that
+// cannot reach GitHub. Chains are looked up in <path>/<chain> and <path>/testnets/<chain>. An empty path
+// keeps reading over HTTP.
+func WithLocalRegistry(path string) Option {
+	return func(r *ChainRegistry) {
+		if path != "" {
+			r.source = &localRegistrySource{root: filepath.Clean(path)}
+		}
+	}
+}
+
+// localRegistrySource reads the chain-registry from a directory on disk
+type localRegistrySource struct {
+	root string
+}
+
+// filePath returns the path of the file in dir. Directory names that would leave the registry root
+// are refused as missing.
+func (s *localRegistrySource) filePath(dir registryDir, file string) (string, error) {
+	if dir.name == "" || dir.name == "." || dir.name == ".." || strings.ContainsAny(dir.name, `/\`) {
+		return "", errNotFound
+	}
+	return filepath.Join(s.root, filepath.FromSlash(dir.path()), file), nil
+}
+
+// readJSON decodes the file in dir into v, errNotFound when it does not exist
+func (s *localRegistrySource) readJSON(dir registryDir, file string, v interface{}) error {
+	path, err := s.filePath(dir, file)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return errNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func (s *localRegistrySource) chainInfo(ctx context.Context, dir registryDir) (*ChainInfo, error) {
+	var info ChainInfo
+	if err := s.readJSON(dir, "chain.json", &info); err != nil {
+		return nil, err
+	}
+	info.LastUpdated = time.Now()
+	return &info, nil
+}
+
+func (s *localRegistrySource) upgradeInfo(ctx context.Context, dir registryDir) (*types.UpgradeInfo, error) {
+	var upgradeInfo types.UpgradeInfo
+	err := s.readJSON(dir, "upgrades.json", &upgradeInfo)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &upgradeInfo, nil
+}
+
+func (s *localRegistrySource) versions(ctx context.Context, dir registryDir) (*VersionHistory, error) {
+	var history VersionHistory
+	err := s.readJSON(dir, "versions.json", &history)
+	if errors.Is(err, errNotFound) || (err == nil && len(history.Versions) == 0) {
+		return nil, ErrVersionsNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+func (s *localRegistrySource) assetList(ctx context.Context, dir registryDir) (*AssetList, error) {
+	var file assetListFile
+	err := s.readJSON(dir, "assetlist.json", &file)
+	if errors.Is(err, errNotFound) {
+		return nil, ErrAssetListNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file.assetList(), nil
+}
+
+func (s *localRegistrySource) exists(ctx context.Context, dir registryDir) (bool, error) {
+	path, err := s.filePath(dir, "chain.json")
+	if err != nil {
+		return false, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// listChains lists the chain directories of the network. A clone without a testnets directory has
+// no testnets.
+func (s *localRegistrySource) listChains(ctx context.Context, network string) ([]string, error) {
+	root := s.root
+	if network == networkTestnet {
+		root = filepath.Join(root, "testnets")
+	}
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, os.ErrNotExist) && network == networkTestnet {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	chains := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && isChainDirName(entry.Name()) {
+			chains = append(chains, entry.Name())
+		}
+	}
+	sort.Strings(chains)
+	return chains, nil
+}
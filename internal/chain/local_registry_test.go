@@ -0,0 +1,96 @@
+package chain
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_LocalRegistry(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(file, content string) {
+		t.Helper()
+		path := filepath.Join(root, filepath.FromSlash(file))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	copyFixture := func(file, fixture string) {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join("testdata", fixture))
+		require.NoError(t, err)
+		writeFile(file, string(data))
+	}
+	upgradeTime := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	copyFixture("osmosis/chain.json", "chain.json")
+	copyFixture("osmosis/versions.json", "versions.json")
+	writeFile("osmosis/upgrades.json", `{"name": "v27", "version": "v27.0.0", "height": 17000000, "time": "`+upgradeTime.Format(time.RFC3339)+`"}`)
+	writeFile("testnets/elystestnet/chain.json", `{"chain_name": "elystestnet", "chain_id": "elysicstestnet-1"}`)
+	writeFile("_IBC/osmosis-elys.json", `{}`)
+	writeFile(".github/workflows/ci.yml", ``)
+	writeFile("testnets/_template/chain.json", `{}`)
+	writeFile("README.md", ``)
+
+	// Every request fails the test, the local registry is read from disk only
+	transport := &stubTransport{handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master",
+		WithHTTPClient(&http.Client{Transport: transport}), WithLocalRegistry(root))
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetETADriftThreshold(-1)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+	ctx := context.Background()
+
+	info, err := registry.GetChainInfo(ctx, "osmosis", false)
+	require.NoError(t, err)
+	assert.Equal(t, "osmosis-1", info.ChainID)
+	assert.Equal(t, "mainnet", info.Network)
+	assert.NotEmpty(t, info.APIs.RPC)
+
+	testnet, err := registry.GetChainInfo(ctx, "elystestnet", false)
+	require.NoError(t, err)
+	assert.Equal(t, "elysicstestnet-1", testnet.ChainID)
+	assert.Equal(t, "testnet", testnet.Network)
+
+	_, err = registry.GetChainInfo(ctx, "notachain", false)
+	var notFound *ErrChainNotFound
+	assert.ErrorAs(t, err, &notFound)
+	_, err = registry.GetChainInfo(ctx, "..", false)
+	assert.ErrorAs(t, err, &notFound, "names leaving the registry root are not looked up")
+
+	upgrade, err := registry.GetUpgradeInfo(ctx, "osmosis", false)
+	require.NoError(t, err)
+	assert.Equal(t, "v27.0.0", upgrade.Version)
+	assert.Equal(t, int64(17000000), upgrade.Height)
+	_, err = registry.GetUpgradeInfo(ctx, "elystestnet", false)
+	assert.ErrorIs(t, err, ErrNoUpgrade)
+
+	assert.True(t, registry.ChainExists(ctx, "osmosis"))
+	assert.False(t, registry.ChainExists(ctx, "elys-1"))
+	dir, found := registry.tryChainNameVariations(ctx, "osmosis-1")
+	require.True(t, found)
+	assert.Equal(t, registryDir{name: "osmosis", network: "mainnet"}, dir)
+
+	discovered, err := registry.DiscoverChains(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"osmosis"}, discovered.Mainnet)
+	assert.Equal(t, []string{"elystestnet"}, discovered.Testnet)
+
+	history, err := registry.GetVersions(ctx, "osmosis")
+	require.NoError(t, err)
+	assert.Len(t, history.Versions, 3)
+	_, err = registry.GetAssetList(ctx, "osmosis")
+	assert.ErrorIs(t, err, ErrAssetListNotFound)
+
+	assert.Empty(t, transport.requested(), "nothing is fetched over the network")
+}
@@ -0,0 +1,47 @@
+package chain
+
+import "time"
+
+// MaintenanceStatus describes whether monitoring is paused for an operator maintenance window
+type MaintenanceStatus struct {
+	Enabled bool      `json:"enabled"`
+	Reason  string    `json:"reason,omitempty"`
+	Since   time.Time `json:"since,omitempty"`
+}
+
+// SetMaintenance enables or disables maintenance mode. While enabled the pollers and the upgrade
+// checker skip their cycles and no notifications are sent; the API keeps serving cached data.
+func (r *ChainRegistry) SetMaintenance(enabled bool, reason string) {
+	r.maintenanceMu.Lock()
+	defer r.maintenanceMu.Unlock()
+
+	if !enabled {
+		if r.maintenance.Enabled {
+			r.logger.Info("Leaving maintenance mode, monitoring resumed")
+		}
+		r.maintenance = MaintenanceStatus{}
+		return
+	}
+
+	if reason == "" {
+		reason = "enabled manually"
+	}
+	if !r.maintenance.Enabled {
+		r.maintenance.Since = time.Now()
+	}
+	r.maintenance.Enabled = true
+	r.maintenance.Reason = reason
+	r.logger.Warnf("Entering maintenance mode: %s", reason)
+}
+
+func (r *ChainRegistry) InMaintenance() bool {
+	r.maintenanceMu.RLock()
+	defer r.maintenanceMu.RUnlock()
+	return r.maintenance.Enabled
+}
+
+func (r *ChainRegistry) MaintenanceStatus() MaintenanceStatus {
+	r.maintenanceMu.RLock()
+	defer r.maintenanceMu.RUnlock()
+	return r.maintenance
+}
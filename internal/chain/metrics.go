@@ -0,0 +1,132 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metric names of the registry's instrumentation
+const (
+	MetricCacheLookups     = "cosmos_watcher_registry_cache_lookups_total"
+	MetricOutboundRequests = "cosmos_watcher_registry_outbound_requests_total"
+	MetricFetchDuration    = "cosmos_watcher_registry_fetch_duration_seconds"
+)
+
+// Cache entities and results counted by MetricCacheLookups
+const (
+	CacheEntityChainInfo   = "chain_info"
+	CacheEntityUpgradeInfo = "upgrade_info"
+	CacheEntityPolkachu    = "polkachu"
+	CacheEntityChainExists = "chain_exists"
+
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// Fetch sources timed by MetricFetchDuration
+const (
+	FetchSourceChainRegistry   = "chain_registry"
+	FetchSourcePolkachu        = "polkachu"
+	FetchSourceCosmosDirectory = "cosmos_directory"
+)
+
+// fetchDurationBuckets are the upper bounds, in seconds, of the fetch duration histogram
+var fetchDurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics counts the registry's cache lookups and outbound requests and times its fetches. One Metrics
+// can be shared by several registries with WithMetrics.
+type Metrics struct {
+	cacheLookups   *prometheus.CounterVec
+	outbound       *prometheus.CounterVec
+	fetchDurations *prometheus.HistogramVec
+}
+
+// NewMetrics creates the registry's collectors and registers them on reg, or on
+// prometheus.DefaultRegisterer when reg is nil. Collectors already registered on reg by another
+// Metrics are reused, so both count into the same series.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	m := &Metrics{
+		cacheLookups: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: MetricCacheLookups,
+			Help: "Registry cache lookups by entity and result (hit or miss).",
+		}, []string{"entity", "result"})),
+		outbound: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: MetricOutboundRequests,
+			Help: "Outbound registry requests by host and status class.",
+		}, []string{"host", "status"})),
+		fetchDurations: register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    MetricFetchDuration,
+			Help:    "Duration of chain-registry and Polkachu fetches, retries included.",
+			Buckets: fetchDurationBuckets,
+		}, []string{"source"})),
+	}
+
+	// The series of known label values are exported from the start, at zero
+	for _, entity := range []string{CacheEntityChainInfo, CacheEntityUpgradeInfo, CacheEntityPolkachu, CacheEntityChainExists} {
+		m.cacheLookups.WithLabelValues(entity, CacheHit)
+		m.cacheLookups.WithLabelValues(entity, CacheMiss)
+	}
+	for _, source := range []string{FetchSourceChainRegistry, FetchSourcePolkachu, FetchSourceCosmosDirectory} {
+		m.fetchDurations.WithLabelValues(source)
+	}
+	return m
+}
+
+// register registers collector on reg, returning the collector registered before it when there is one
+func register[C prometheus.Collector](reg prometheus.Registerer, collector C) C {
+	if err := reg.Register(collector); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if errors.As(err, &registered) {
+			if existing, ok := registered.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		panic(fmt.Sprintf("failed to register registry metrics: %v", err))
+	}
+	return collector
+}
+
+// WithMetrics makes the registry record into metrics instead of the collectors registered on
+// prometheus.DefaultRegisterer
+func WithMetrics(metrics *Metrics) Option {
+	return func(r *ChainRegistry) {
+		if metrics != nil {
+			r.metrics = metrics
+		}
+	}
+}
+
+// Metrics returns the registry's instrumentation
+func (r *ChainRegistry) Metrics() *Metrics {
+	return r.metrics
+}
+
+func (m *Metrics) recordCacheLookup(entity string, hit bool) {
+	result := CacheMiss
+	if hit {
+		result = CacheHit
+	}
+	m.cacheLookups.WithLabelValues(entity, result).Inc()
+}
+
+// recordOutbound counts one request to the host of req, by the class of its status or "error" when
+// no response was received
+func (m *Metrics) recordOutbound(req *http.Request, resp *http.Response, err error) {
+	class := "error"
+	if err == nil {
+		class = fmt.Sprintf("%dxx", resp.StatusCode/100)
+	}
+	m.outbound.WithLabelValues(req.URL.Host, class).Inc()
+}
+
+// observeFetch records how long a fetch from source took, retries included
+func (m *Metrics) observeFetch(source string, start time.Time) {
+	m.fetchDurations.WithLabelValues(source).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,134 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_Metrics(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cosmos/chain-registry/master/testchain/chain.json" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+	}))
+	defer registryServer.Close()
+
+	polkachuServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer polkachuServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master",
+		WithPolkachuURL(polkachuServer.URL), WithMetrics(metrics))
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetETADriftThreshold(-1)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderPolkachu}))
+	require.Same(t, metrics, registry.Metrics())
+	ctx := context.Background()
+
+	for range 3 {
+		_, err := registry.GetChainInfo(ctx, "testchain", false)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues(CacheEntityChainInfo, CacheMiss)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues(CacheEntityChainInfo, CacheHit)))
+	assert.InDelta(t, 2.0/3, cacheHitRatio(metrics, CacheEntityChainInfo), 1e-9)
+
+	_, err := registry.GetChainInfo(ctx, "notachain", false)
+	require.Error(t, err)
+	registryHost := mustHost(t, registryServer.URL)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.outbound.WithLabelValues(registryHost, "2xx")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.outbound.WithLabelValues(registryHost, "4xx")), "mainnet and testnet paths of the unknown chain")
+	assert.Equal(t, uint64(3), fetchCount(t, metrics, FetchSourceChainRegistry))
+
+	// Existence checks probe the mainnet and testnet directories with HEAD requests
+	assert.False(t, registry.ChainExists(ctx, "otherchain"))
+	assert.Equal(t, 4.0, testutil.ToFloat64(metrics.outbound.WithLabelValues(registryHost, "4xx")), "HEAD probes of the unknown chain")
+
+	for range 2 {
+		_, err := registry.GetUpgradeInfo(ctx, "testchain", false)
+		assert.ErrorIs(t, err, ErrNoUpgrade)
+	}
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues(CacheEntityUpgradeInfo, CacheMiss)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues(CacheEntityUpgradeInfo, CacheHit)), "the empty result is served from the cache")
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.outbound.WithLabelValues(mustHost(t, polkachuServer.URL), "5xx")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues(CacheEntityPolkachu, CacheMiss)))
+	assert.Equal(t, uint64(1), fetchCount(t, metrics, FetchSourcePolkachu))
+
+	_, err = registry.GetUpgradeInfo(ctx, "testchain", true)
+	assert.ErrorIs(t, err, ErrNoUpgrade)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues(CacheEntityPolkachu, CacheHit)), "the failed download is cached for the negative TTL")
+	assert.Equal(t, uint64(1), fetchCount(t, metrics, FetchSourcePolkachu))
+}
+
+func TestNewMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	assert.Zero(t, cacheHitRatio(metrics, CacheEntityChainInfo))
+
+	shared := NewMetrics(reg)
+	shared.recordCacheLookup(CacheEntityChainInfo, true)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues(CacheEntityChainInfo, CacheHit)),
+		"a second Metrics on the same registerer counts into the same series")
+
+	// Known label values are exported before the first lookup
+	count, err := testutil.GatherAndCount(reg, MetricCacheLookups)
+	require.NoError(t, err)
+	assert.Equal(t, 8, count)
+}
+
+// cacheHitRatio returns the share of the entity's lookups served from the cache, 0 before any lookup
+func cacheHitRatio(m *Metrics, entity string) float64 {
+	hits := testutil.ToFloat64(m.cacheLookups.WithLabelValues(entity, CacheHit))
+	total := hits + testutil.ToFloat64(m.cacheLookups.WithLabelValues(entity, CacheMiss))
+	if total == 0 {
+		return 0
+	}
+	return hits / total
+}
+
+// fetchCount returns how many fetches from source were timed
+func fetchCount(t *testing.T, m *Metrics, source string) uint64 {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(m.fetchDurations))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != MetricFetchDuration {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "source" && label.GetValue() == source {
+					return metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func mustHost(t *testing.T, rawURL string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return u.Host
+}
@@ -0,0 +1,121 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultChainProbeTimeout bounds each HEAD request looking for a chain's directory, so a slow answer
+// for one directory leaves the next one its full time
+const defaultChainProbeTimeout = 3 * time.Second
+
+// statusError is the error of decodeJSON for responses other than 200 and 404
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP request failed with status code: %d", e.code)
+}
+
+// SetRegistryMirrors sets the base URLs of copies of the chain-registry, such as
+// "https://cdn.jsdelivr.net/gh/cosmos/chain-registry@master", tried in order when the registry or the
+// previous mirror times out, cannot be reached or answers 5xx. A 404 is never retried on a mirror,
+// the file is missing from every copy.
+func (r *ChainRegistry) SetRegistryMirrors(mirrors []string) {
+	bases := make([]string, 0, len(mirrors))
+	for _, mirror := range mirrors {
+		if mirror = strings.TrimRight(strings.TrimSpace(mirror), "/"); mirror != "" {
+			bases = append(bases, mirror)
+		}
+	}
+	r.mirrors.Store(&bases)
+}
+
+// mirrorURLs returns url followed by the same file on each mirror. URLs outside the registry have no
+// mirrors.
+func (r *ChainRegistry) mirrorURLs(url string) []string {
+	mirrors := r.mirrors.Load()
+	if mirrors == nil || len(*mirrors) == 0 {
+		return []string{url}
+	}
+	var file string
+	if base := r.registryURL(); strings.HasPrefix(url, base) {
+		file = strings.TrimPrefix(url, base)
+	} else {
+		return []string{url}
+	}
+
+	urls := make([]string, 0, len(*mirrors)+1)
+	urls = append(urls, url)
+	for _, mirror := range *mirrors {
+		urls = append(urls, mirror+file)
+	}
+	return urls
+}
+
+// failsOver reports whether err is a failure of one copy of the registry that another may not share:
+// no response at all or a 5xx
+func failsOver(err error) bool {
+	var (
+		network *ErrNetwork
+		status  *statusError
+	)
+	if errors.As(err, &status) {
+		return status.code >= http.StatusInternalServerError
+	}
+	return errors.As(err, &network)
+}
+
+// fromMirrors calls fetch with url, then with the same file on each mirror for as long as the previous
+// copy fails over
+func fromMirrors[T any](ctx context.Context, r *ChainRegistry, url string, fetch func(url string) (T, error)) (T, error) {
+	var (
+		result T
+		err    error
+	)
+	for i, mirrorURL := range r.mirrorURLs(url) {
+		if i > 0 {
+			r.logger.Debugf("Chain-registry copy unavailable (%v), trying %s", err, mirrorURL)
+		}
+		result, err = fetch(mirrorURL)
+		if err == nil || ctx.Err() != nil || !failsOver(err) {
+			break
+		}
+	}
+	return result, err
+}
+
+// headStatus returns the status of a HEAD request for the registry file at url, asking the mirrors
+// when the registry fails over. A positive timeout bounds the request to each copy.
+func (r *ChainRegistry) headStatus(ctx context.Context, url string, timeout time.Duration) (int, error) {
+	return fromMirrors(ctx, r, url, func(url string) (int, error) {
+		ctx := ctx
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return 0, err
+		}
+		resp, err := r.do(req)
+		if errors.Is(err, context.DeadlineExceeded) && timeout > 0 {
+			// The copy timing out is no reason to keep the next one from being asked
+			return 0, &ErrNetwork{URL: url, Err: err}
+		}
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return resp.StatusCode, &statusError{code: resp.StatusCode}
+		}
+		return resp.StatusCode, nil
+	})
+}
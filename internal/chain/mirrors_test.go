@@ -0,0 +1,133 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_Mirrors(t *testing.T) {
+	upgradeTime := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	var (
+		mu             sync.Mutex
+		mirrorRequests []string
+	)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		mirrorRequests = append(mirrorRequests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/gh/cosmos/chain-registry@master/testchain/chain.json":
+			json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+		case "/gh/cosmos/chain-registry@master/testchain/upgrades.json":
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "v2", "version": "v2.0.0", "height": 1000, "time": upgradeTime})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mirror.Close()
+	requested := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), mirrorRequests...)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	t.Run("primary answers 5xx", func(t *testing.T) {
+		mu.Lock()
+		mirrorRequests = nil
+		mu.Unlock()
+		primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.URL.Path, "/notachain/") {
+				http.NotFound(w, r)
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer primary.Close()
+
+		metrics := NewMetrics(prometheus.NewRegistry())
+		registry := NewChainRegistry(logger, primary.URL, "/cosmos/chain-registry/master", WithMetrics(metrics))
+		registry.SetRetryPolicy(-1, 0)
+		registry.SetDegradedThreshold(-1)
+		registry.SetETADriftThreshold(-1)
+		registry.SetRegistryMirrors([]string{mirror.URL + "/gh/cosmos/chain-registry@master/"})
+		require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+		ctx := context.Background()
+
+		info, err := registry.GetChainInfo(ctx, "testchain", false)
+		require.NoError(t, err)
+		assert.Equal(t, "testchain-1", info.ChainID)
+		assert.Equal(t, "mainnet", info.Network)
+		assert.Equal(t, []string{"GET /gh/cosmos/chain-registry@master/testchain/chain.json"}, requested())
+
+		_, err = registry.GetChainInfo(ctx, "testchain", false)
+		require.NoError(t, err)
+		assert.Len(t, requested(), 1, "what the mirror served is cached")
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues(CacheEntityChainInfo, CacheHit)))
+
+		assert.True(t, registry.ChainExists(ctx, "testchain"))
+
+		upgrade, err := registry.GetUpgradeInfo(ctx, "testchain", false)
+		require.NoError(t, err)
+		assert.Equal(t, "v2.0.0", upgrade.Version)
+		assert.Equal(t, int64(1000), upgrade.Height)
+		assert.Contains(t, requested(), "GET /gh/cosmos/chain-registry@master/testchain/upgrades.json")
+
+		before := len(requested())
+		_, err = registry.GetChainInfo(ctx, "notachain", false)
+		var notFound *ErrChainNotFound
+		require.ErrorAs(t, err, &notFound)
+		assert.Len(t, requested(), before, "a 404 is never sent on to a mirror")
+	})
+
+	t.Run("primary times out", func(t *testing.T) {
+		release := make(chan struct{})
+		primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer primary.Close()
+		defer close(release)
+
+		registry := NewChainRegistry(logger, primary.URL, "/cosmos/chain-registry/master",
+			WithHTTPClient(&http.Client{Timeout: 100 * time.Millisecond}))
+		registry.SetRetryPolicy(-1, 0)
+		registry.SetDegradedThreshold(-1)
+		registry.SetRegistryMirrors([]string{mirror.URL + "/gh/cosmos/chain-registry@master"})
+
+		info, err := registry.GetChainInfo(context.Background(), "testchain", false)
+		require.NoError(t, err)
+		assert.Equal(t, "testchain-1", info.ChainID)
+	})
+}
+
+func TestChainRegistry_MirrorURLs(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master")
+
+	const url = "https://raw.githubusercontent.com/cosmos/chain-registry/master/testnets/elystestnet/chain.json"
+	assert.Equal(t, []string{url}, registry.mirrorURLs(url), "no mirrors configured")
+
+	registry.SetRegistryMirrors([]string{" https://cdn.jsdelivr.net/gh/cosmos/chain-registry@master/ ", ""})
+	assert.Equal(t, []string{
+		url,
+		"https://cdn.jsdelivr.net/gh/cosmos/chain-registry@master/testnets/elystestnet/chain.json",
+	}, registry.mirrorURLs(url))
+	assert.Equal(t, []string{"https://polkachu.com/api/v2/chain_upgrades"},
+		registry.mirrorURLs("https://polkachu.com/api/v2/chain_upgrades"), "URLs outside the registry have no mirrors")
+}
@@ -0,0 +1,101 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	nodeVersionTTL = 10 * time.Minute
+	nodeInfoPath   = "/cosmos/base/tendermint/v1beta1/node_info"
+	// maxNodeInfoEndpoints caps how many REST endpoints are tried before giving up on a chain
+	maxNodeInfoEndpoints = 3
+)
+
+// ErrNodeInfoUnavailable is returned when none of a chain's REST endpoints expose node_info
+var ErrNodeInfoUnavailable = errors.New("node_info not available")
+
+type nodeInfoResponse struct {
+	ApplicationVersion struct {
+		Name    string `json:"name"`
+		AppName string `json:"app_name"`
+		Version string `json:"version"`
+	} `json:"application_version"`
+}
+
+// GetNodeVersion returns the application version the chain is running, as reported by the
+// node_info endpoint of its REST APIs. Results, including unavailability, are cached briefly so that
+// endpoints without node_info are not queried on every call.
+func (r *ChainRegistry) GetNodeVersion(ctx context.Context, chainName string) (string, error) {
+	cacheKey := fmt.Sprintf(nodeVersionCacheKey, chainName)
+	if version, _, found := cacheLookup[string](r, cacheKey); found {
+		if version != "" {
+			return version, nil
+		}
+		return "", ErrNodeInfoUnavailable
+	}
+
+	if r.IsDegraded() {
+		return "", ErrDegraded
+	}
+
+	info, err := r.GetChainInfo(ctx, chainName, false)
+	if err != nil {
+		return "", err
+	}
+
+	endpoints := r.chainAPIs(chainName, info).REST
+	if len(endpoints) > maxNodeInfoEndpoints {
+		endpoints = endpoints[:maxNodeInfoEndpoints]
+	}
+	var lastErr error
+	for _, endpoint := range endpoints {
+		version, err := r.fetchNodeVersion(ctx, endpoint.Address)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			r.logger.Debugf("node_info not available from %s for %s: %v", endpoint.Address, chainName, err)
+			lastErr = fmt.Errorf("%s: %w", endpoint.Address, err)
+			continue
+		}
+		r.cache.Set(cacheKey, version, nodeVersionTTL)
+		r.clearError(chainName, errorSourceNodeInfo)
+		return version, nil
+	}
+	r.recordError(chainName, errorSourceNodeInfo, lastErr)
+
+	r.cache.Set(cacheKey, "", nodeVersionTTL)
+	return "", ErrNodeInfoUnavailable
+}
+
+func (r *ChainRegistry) fetchNodeVersion(ctx context.Context, address string) (string, error) {
+	if address == "" {
+		return "", fmt.Errorf("empty REST address")
+	}
+	url := strings.TrimRight(address, "/") + nodeInfoPath
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	// Chain REST endpoints are third-party nodes, so their failures do not count towards degraded mode
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var nodeInfo nodeInfoResponse
+	if err := decodeJSON(resp, &nodeInfo); err != nil {
+		return "", err
+	}
+	if nodeInfo.ApplicationVersion.Version == "" {
+		return "", fmt.Errorf("node_info has no application version")
+	}
+	return nodeInfo.ApplicationVersion.Version, nil
+}
@@ -0,0 +1,92 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_GetNodeVersion(t *testing.T) {
+	exposing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != nodeInfoPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"default_node_info":{"network":"testchain-1"},"application_version":{"name":"gaia","app_name":"gaiad","version":"v24.0.2"}}`))
+	}))
+	defer exposing.Close()
+
+	notImplemented := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotImplemented)
+	}))
+	defer notImplemented.Close()
+
+	tests := []struct {
+		name      string
+		endpoints []string
+		expected  string
+		wantErr   error
+	}{
+		{"exposes node_info", []string{exposing.URL}, "v24.0.2", nil},
+		{"falls back to next endpoint", []string{notImplemented.URL, exposing.URL + "/"}, "v24.0.2", nil},
+		{"not exposed", []string{notImplemented.URL}, "", ErrNodeInfoUnavailable},
+		{"no REST endpoints", nil, "", ErrNodeInfoUnavailable},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rest []Endpoint
+			for _, address := range tt.endpoints {
+				rest = append(rest, Endpoint{Address: address})
+			}
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1", APIs: APIs{REST: rest}})
+			}))
+			defer registryServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+
+			version, err := registry.GetNodeVersion(context.Background(), "testchain")
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+}
+
+func TestChainRegistry_GetNodeVersionCached(t *testing.T) {
+	var calls int
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer node.Close()
+
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", APIs: APIs{REST: []Endpoint{{Address: node.URL}}}})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+
+	for i := 0; i < 3; i++ {
+		_, err := registry.GetNodeVersion(context.Background(), "testchain")
+		assert.ErrorIs(t, err, ErrNodeInfoUnavailable)
+	}
+	assert.Equal(t, 1, calls, "unavailable node_info should be cached")
+}
@@ -0,0 +1,59 @@
+package chain
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// Option configures a ChainRegistry at construction
+type Option func(*ChainRegistry)
+
+// WithHTTPClient makes the registry send every outbound request through client instead of its own
+// client with a 5s timeout. A nil client keeps the default.
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *ChainRegistry) {
+		if client != nil {
+			r.client = client
+		}
+	}
+}
+
+// WithCache sets how long fetched chain and upgrade info is cached and how often expired entries are
+// swept. Zero or negative values keep the defaults of 5 minutes and 10 seconds. A cache backend set by
+// WithCacheBackend is kept, only its TTL changes.
+func WithCache(ttl, cleanupInterval time.Duration) Option {
+	return func(r *ChainRegistry) {
+		if ttl <= 0 {
+			ttl = defaultCacheTTL
+		}
+		if cleanupInterval <= 0 {
+			cleanupInterval = defaultCacheCleanupInterval
+		}
+		if _, inMemory := r.cache.(*cache.Cache); inMemory {
+			r.cache = cache.New(ttl, cleanupInterval)
+		}
+		r.cacheTTL.Store(int64(ttl))
+	}
+}
+
+// WithCacheBackend makes the registry keep its cached data in c instead of its own in-memory cache,
+// e.g. a Redis cache shared by replicas. A nil cache keeps the in-memory one.
+func WithCacheBackend(c Cache) Option {
+	return func(r *ChainRegistry) {
+		if c != nil {
+			r.cache = c
+		}
+	}
+}
+
+// WithPolkachuURL sets the Polkachu chain upgrades endpoint the polkachu provider queries. An empty
+// URL keeps the public API.
+func WithPolkachuURL(url string) Option {
+	return func(r *ChainRegistry) {
+		if url != "" {
+			r.polkachuURL = url
+		}
+	}
+}
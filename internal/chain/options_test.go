@@ -0,0 +1,102 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubTransport answers every request with handler and records the URLs it was asked for
+type stubTransport struct {
+	handler http.Handler
+	mu      sync.Mutex
+	urls    []string
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	s.urls = append(s.urls, req.URL.String())
+	s.mu.Unlock()
+
+	rr := httptest.NewRecorder()
+	s.handler.ServeHTTP(rr, req)
+	return rr.Result(), nil
+}
+
+func (s *stubTransport) requested() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.urls...)
+}
+
+func TestNewChainRegistry_Options(t *testing.T) {
+	const (
+		registryURL = "https://chain-registry.invalid"
+		polkachuURL = "https://polkachu.invalid/api/v2/chain_upgrades"
+	)
+	transport := &stubTransport{handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Host + r.URL.Path {
+		case "chain-registry.invalid/cosmos/chain-registry/master/testchain/chain.json":
+			json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1", Network: "mainnet"})
+		case "polkachu.invalid/api/v2/chain_upgrades":
+			json.NewEncoder(w).Encode([]PolkachuUpgrade{{
+				Network:              "mainnet",
+				ChainName:            "testchain",
+				NodeVersion:          "v2.0.0",
+				Block:                1000,
+				EstimatedUpgradeTime: time.Now().Add(time.Hour).Format(time.RFC3339),
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	})}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryURL, "/cosmos/chain-registry/master",
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithPolkachuURL(polkachuURL),
+		WithCache(50*time.Millisecond, 10*time.Millisecond),
+	)
+	registry.SetETADriftThreshold(-1)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderPolkachu}))
+
+	_, err := registry.GetChainInfo(context.Background(), "testchain", false)
+	require.NoError(t, err)
+	upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", false)
+	require.NoError(t, err)
+	require.NotNil(t, upgrade)
+	assert.Equal(t, "v2.0.0", upgrade.Version)
+	assert.Equal(t, int64(1000), upgrade.Height)
+
+	requested := transport.requested()
+	assert.Contains(t, requested, registryURL+"/cosmos/chain-registry/master/testchain/chain.json")
+	assert.Contains(t, requested, polkachuURL, "the polkachu provider queries the configured URL")
+
+	_, _, found := registry.cachedChainInfo("testchain")
+	assert.True(t, found)
+	time.Sleep(100 * time.Millisecond)
+	_, _, found = registry.cachedChainInfo("testchain")
+	assert.False(t, found, "chain info expires after the 50ms cache TTL")
+}
+
+func TestNewChainRegistry_DefaultOptions(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master",
+		WithHTTPClient(nil), WithPolkachuURL(""), WithCache(0, 0))
+
+	assert.Equal(t, defaultHTTPTimeout, registry.client.Timeout)
+	assert.Equal(t, defaultPolkachuURL, registry.polkachuURL)
+	assert.Equal(t, int64(defaultCacheTTL), registry.cacheTTL.Load())
+}
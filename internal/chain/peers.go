@@ -0,0 +1,37 @@
+package chain
+
+import "strings"
+
+// Peers are the seeds and persistent peers chain.json lists for bootstrapping a node
+type Peers struct {
+	Seeds           []Peer `json:"seeds,omitempty"`
+	PersistentPeers []Peer `json:"persistent_peers,omitempty"`
+}
+
+// Peer is a node of the chain's p2p network
+type Peer struct {
+	ID       string `json:"id"`
+	Address  string `json:"address"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// String returns the peer as id@address, as config.toml lists it, or "" when the ID or the address
+// is missing
+func (p Peer) String() string {
+	id, address := strings.TrimSpace(p.ID), strings.TrimSpace(p.Address)
+	if id == "" || address == "" {
+		return ""
+	}
+	return id + "@" + address
+}
+
+// PeerAddresses returns the peers as id@address strings, skipping peers without an ID or address
+func PeerAddresses(peers []Peer) []string {
+	addresses := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		if address := peer.String(); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,60 @@
+package chain
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_ParsesPeers(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/master/osmosis/chain.json") {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, "testdata/chain.json")
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	info, err := registry.GetChainInfo(context.Background(), "osmosis", false)
+	require.NoError(t, err)
+
+	require.Len(t, info.Peers.Seeds, 3)
+	assert.Equal(t, Peer{ID: "f515a8599b40f0e84dfad935ba414674ab11a668", Address: "osmosis.blockpane.com:26656", Provider: "blockpane"}, info.Peers.Seeds[0])
+	assert.Equal(t, []string{
+		"f515a8599b40f0e84dfad935ba414674ab11a668@osmosis.blockpane.com:26656",
+		"ade4d8bc8cbe014af6ebdf3cb7b1e9ad36f412c0@seeds.polkachu.com:12556",
+	}, PeerAddresses(info.Peers.Seeds), "the seed without an ID is skipped")
+	assert.Equal(t, []string{
+		"8f67a2fcdd7ade970b1983bf1697111d35dfdd6f@52.79.199.137:26656",
+		"00c328a33578466c711874ec5ee7ada75951f99a@35.82.201.64:26656",
+	}, PeerAddresses(info.Peers.PersistentPeers), "the peer without an address is skipped")
+}
+
+func TestPeerAddresses(t *testing.T) {
+	tests := []struct {
+		name  string
+		peers []Peer
+		want  []string
+	}{
+		{name: "no peers", peers: nil, want: []string{}},
+		{name: "trims whitespace", peers: []Peer{{ID: " abc ", Address: "1.2.3.4:26656\n"}}, want: []string{"abc@1.2.3.4:26656"}},
+		{name: "skips malformed peers", peers: []Peer{{ID: "abc"}, {Address: "1.2.3.4:26656"}, {ID: "def", Address: "5.6.7.8:26656"}}, want: []string{"def@5.6.7.8:26656"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PeerAddresses(tt.peers))
+		})
+	}
+}
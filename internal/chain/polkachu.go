@@ -0,0 +1,185 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPolkachuURL = "https://polkachu.com/api/v2/chain_upgrades"
+	// defaultPolkachuCacheWindow is how long one Polkachu payload answers the lookups of all chains
+	defaultPolkachuCacheWindow = 2 * time.Minute
+)
+
+// polkachuFailure is cached for a failed Polkachu fetch, so an outage costs one request per negative
+// cache TTL rather than one per chain
+type polkachuFailure struct {
+	err error
+}
+
+// polkachuFailureJSON is a polkachuFailure as kept by caches storing JSON, which keep the message of
+// the error only
+type polkachuFailureJSON struct {
+	Error string `json:"error"`
+}
+
+func (f polkachuFailure) MarshalJSON() ([]byte, error) {
+	var v polkachuFailureJSON
+	if f.err != nil {
+		v.Error = f.err.Error()
+	}
+	return json.Marshal(v)
+}
+
+func (f *polkachuFailure) UnmarshalJSON(data []byte) error {
+	var v polkachuFailureJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	f.err = errors.New(v.Error)
+	return nil
+}
+
+// SetPolkachuCacheWindow sets how long the Polkachu upgrade list is reused for the lookups of all
+// chains. Zero or negative keeps the default of 2 minutes.
+func (r *ChainRegistry) SetPolkachuCacheWindow(window time.Duration) {
+	if window <= 0 {
+		window = defaultPolkachuCacheWindow
+	}
+	r.polkachuMu.Lock()
+	defer r.polkachuMu.Unlock()
+	r.polkachuWindow = window
+}
+
+// polkachuNameReplacer drops the separators of pretty names, so "Cosmos Hub" matches cosmoshub
+var polkachuNameReplacer = strings.NewReplacer(" ", "", "-", "", "_", "")
+
+func normalizePolkachuName(name string) string {
+	return polkachuNameReplacer.Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// polkachuIndex returns Polkachu's upcoming upgrades by normalized network slug and chain name,
+// fetching them at most once per cache window however many chains are looked up concurrently
+func (r *ChainRegistry) polkachuIndex(ctx context.Context) (map[string][]*PolkachuUpgrade, error) {
+	if index, err, found := r.cachedPolkachuIndex(); found {
+		r.metrics.recordCacheLookup(CacheEntityPolkachu, true)
+		return index, err
+	}
+
+	r.polkachuMu.Lock()
+	defer r.polkachuMu.Unlock()
+	// Another lookup may have fetched the list while this one waited
+	if index, err, found := r.cachedPolkachuIndex(); found {
+		r.metrics.recordCacheLookup(CacheEntityPolkachu, true)
+		return index, err
+	}
+	r.metrics.recordCacheLookup(CacheEntityPolkachu, false)
+
+	index, err := r.fetchPolkachuIndex(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.cache.Set(polkachuUpgradesCacheKey, polkachuFailure{err: err}, time.Duration(r.negativeCacheTTL.Load()))
+		}
+		return nil, err
+	}
+	r.cache.Set(polkachuUpgradesCacheKey, index, r.polkachuWindow)
+	return index, nil
+}
+
+func (r *ChainRegistry) cachedPolkachuIndex() (map[string][]*PolkachuUpgrade, error, bool) {
+	cached, found := r.cache.Get(polkachuUpgradesCacheKey)
+	if !found {
+		return nil, nil, false
+	}
+	switch v := cached.(type) {
+	case map[string][]*PolkachuUpgrade:
+		return v, nil, true
+	case polkachuFailure:
+		return nil, v.err, true
+	default:
+		r.evictCacheEntry(polkachuUpgradesCacheKey, cached)
+		return nil, nil, false
+	}
+}
+
+func (r *ChainRegistry) fetchPolkachuIndex(ctx context.Context) (map[string][]*PolkachuUpgrade, error) {
+	defer r.metrics.observeFetch(FetchSourcePolkachu, time.Now())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.polkachuURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Polkachu request: %w", err)
+	}
+	resp, err := r.doWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch from Polkachu API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("polkachu API returned non-200 status code: %d", resp.StatusCode)
+	}
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Check if response looks like HTML
+	if strings.HasPrefix(strings.TrimSpace(string(bodyBytes)), "<") {
+		return nil, &ErrBadResponse{ContentType: resp.Header.Get("Content-Type")}
+	}
+
+	// First try to unmarshal as direct array
+	var upgrades []PolkachuUpgrade
+	err = json.Unmarshal(bodyBytes, &upgrades)
+	if err != nil {
+		// If direct array fails, try wrapped response
+		var response PolkachuResponse
+		if err := json.Unmarshal(bodyBytes, &response); err != nil {
+			r.logger.WithFields(logrus.Fields{
+				"error": err,
+				"body":  string(bodyBytes[:min(len(bodyBytes), 1000)]), // Log first 1000 chars of response
+			}).Debug("Failed to parse Polkachu response")
+			return nil, fmt.Errorf("failed to parse Polkachu response: %w", err)
+		}
+		upgrades = response.Data
+	}
+
+	r.logger.WithField("upgrades_count", len(upgrades)).Debug("Retrieved upgrades from Polkachu")
+
+	index := make(map[string][]*PolkachuUpgrade, len(upgrades))
+	for i := range upgrades {
+		// Entries are listed under both their network slug and their chain name, once when the two agree
+		network := normalizePolkachuName(upgrades[i].Network)
+		name := normalizePolkachuName(upgrades[i].ChainName)
+		if network != "" {
+			index[network] = append(index[network], &upgrades[i])
+		}
+		if name != "" && name != network {
+			index[name] = append(index[name], &upgrades[i])
+		}
+	}
+	return index, nil
+}
+
+// nearestPolkachuUpgrade picks the upgrade with the lowest block among those not estimated in the
+// past, or among all of them when every estimate has passed
+func nearestPolkachuUpgrade(candidates []*PolkachuUpgrade, now time.Time) *PolkachuUpgrade {
+	var nearest *PolkachuUpgrade
+	nearestPassed := true
+	for _, upgrade := range candidates {
+		estimated, err := time.Parse(time.RFC3339, upgrade.EstimatedUpgradeTime)
+		passed := err == nil && estimated.Before(now)
+		if nearest == nil || (nearestPassed && !passed) || (passed == nearestPassed && upgrade.Block < nearest.Block) {
+			nearest, nearestPassed = upgrade, passed
+		}
+	}
+	return nearest
+}
@@ -0,0 +1,167 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_PolkachuFetchedOncePerWindow(t *testing.T) {
+	const chainCount = 12
+	chains := make([]string, chainCount)
+	polkachuUpgrades := make([]PolkachuUpgrade, chainCount)
+	for i := range chains {
+		chains[i] = fmt.Sprintf("chain%d", i)
+		polkachuUpgrades[i] = PolkachuUpgrade{
+			Network:              "mainnet",
+			ChainName:            strings.ToUpper(chains[i]),
+			NodeVersion:          fmt.Sprintf("v%d.0.0", i+1),
+			Block:                int64(1000 + i),
+			EstimatedUpgradeTime: time.Now().Add(time.Duration(i+1) * time.Hour).Format(time.RFC3339),
+		}
+	}
+
+	var polkachuRequests atomic.Int32
+	polkachuServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polkachuRequests.Add(1)
+		json.NewEncoder(w).Encode(polkachuUpgrades)
+	}))
+	defer polkachuServer.Close()
+
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/cosmos/chain-registry/master/"), "/chain.json")
+		if !strings.HasPrefix(name, "chain") || strings.Contains(name, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(ChainInfo{Name: name, ChainID: name + "-1"})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master", WithPolkachuURL(polkachuServer.URL))
+	registry.SetPolkachuCacheWindow(time.Hour)
+	registry.SetMonitoredChains(chains)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderPolkachu}))
+
+	upgrades, err := registry.GetMainnetUpgrades(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, upgrades, chainCount)
+	assert.Equal(t, int32(1), polkachuRequests.Load())
+
+	// Refreshing one chain reads the cached list too
+	upgrade, err := registry.GetUpgradeInfo(context.Background(), "chain3", true)
+	require.NoError(t, err)
+	require.NotNil(t, upgrade)
+	assert.Equal(t, "v4.0.0", upgrade.Version)
+	assert.Equal(t, int32(1), polkachuRequests.Load())
+
+	// Once the window has passed the list is fetched again
+	registry.cache.Delete(polkachuUpgradesCacheKey)
+	_, err = registry.GetUpgradeInfo(context.Background(), "chain3", true)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), polkachuRequests.Load())
+}
+
+func TestChainRegistry_PolkachuFailureCachedBriefly(t *testing.T) {
+	var polkachuRequests atomic.Int32
+	polkachuServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polkachuRequests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer polkachuServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, polkachuServer.URL, "/cosmos/chain-registry/master", WithPolkachuURL(polkachuServer.URL))
+	registry.SetRetryPolicy(-1, 0)
+
+	for _, name := range []string{"osmosis", "cosmoshub", "juno"} {
+		_, err := registry.fetchPolkachuUpgrades(context.Background(), name)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, int32(1), polkachuRequests.Load())
+}
+
+func TestChainRegistry_PolkachuNameMatching(t *testing.T) {
+	future := func(d time.Duration) string { return time.Now().Add(d).Format(time.RFC3339) }
+	polkachuServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]PolkachuUpgrade{
+			{Network: "cosmos", ChainName: "Cosmos", NodeVersion: "v25.0.0", Block: 25000000, EstimatedUpgradeTime: future(24 * time.Hour)},
+			{Network: "terra2", ChainName: "Terra", NodeVersion: "v2.12.0", Block: 14000000, EstimatedUpgradeTime: future(24 * time.Hour)},
+			{Network: "crypto-org", ChainName: "Crypto.org Chain", NodeVersion: "v5.0.0", Block: 20000000, EstimatedUpgradeTime: future(24 * time.Hour)},
+			{Network: "juno", ChainName: "Juno", NodeVersion: "v27.0.0", Block: 1500, EstimatedUpgradeTime: future(-time.Hour)},
+			{Network: "juno", ChainName: "Juno", NodeVersion: "v29.0.0", Block: 3000, EstimatedUpgradeTime: future(48 * time.Hour)},
+			{Network: "junoclassic", ChainName: "JUNO", NodeVersion: "v28.0.0", Block: 2000, EstimatedUpgradeTime: future(24 * time.Hour)},
+		})
+	}))
+	defer polkachuServer.Close()
+
+	var logs bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logs)
+	registry := NewChainRegistry(logger, "http://127.0.0.1:0", "/cosmos/chain-registry/master", WithPolkachuURL(polkachuServer.URL))
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetChainAliases([]types.ChainConfig{
+		{Name: "cosmoshub", Network: "mainnet", PolkachuName: "cosmos"},
+		{Name: "terra-classic", Network: "mainnet", PolkachuName: "Terra Classic"},
+	})
+
+	tests := []struct {
+		name      string
+		chain     string
+		version   string
+		ambiguous bool
+	}{
+		{name: "polkachu_name matches the network slug", chain: "cosmoshub", version: "v25.0.0"},
+		{name: "name matches the network slug", chain: "terra2", version: "v2.12.0"},
+		{name: "name matches the chain name ignoring case", chain: "terra", version: "v2.12.0"},
+		{name: "hyphens are ignored", chain: "cryptoorg", version: "v5.0.0"},
+		{name: "ambiguous match picks the nearest future block", chain: "juno", version: "v28.0.0", ambiguous: true},
+		{name: "chain not listed", chain: "akash"},
+		{name: "polkachu_name not listed", chain: "terra-classic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			upgrade, err := registry.fetchPolkachuUpgrades(context.Background(), tt.chain)
+			if tt.version == "" {
+				assert.Error(t, err)
+				assert.Nil(t, upgrade)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.version, upgrade.NodeVersion)
+			assert.Equal(t, tt.ambiguous, strings.Contains(logs.String(), "Several Polkachu upgrades match"))
+		})
+	}
+}
+
+func TestNormalizePolkachuName(t *testing.T) {
+	tests := map[string]string{
+		"Cosmos Hub": "cosmoshub",
+		" terra-2 ":  "terra2",
+		"Crypto_Org": "cryptoorg",
+		"osmosis":    "osmosis",
+		"Terra":      "terra",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, normalizePolkachuName(name), name)
+	}
+}
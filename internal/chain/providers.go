@@ -0,0 +1,249 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+const (
+	ProviderChainRegistry = "chain-registry"
+	ProviderCurrentPlan   = "current-plan"
+	ProviderPolkachu      = "polkachu"
+	ProviderGovernance    = "governance"
+)
+
+// UpgradeProvider is a source of scheduled chain upgrades. Providers are all asked in the configured
+// order, the first one reporting an upgrade gives its fields and the others fill in what it left
+// empty, so a provider should return an error, or a nil upgrade, when it knows of no upgrade for the
+// chain.
+type UpgradeProvider interface {
+	// Name identifies the provider in the upgrades.providers configuration
+	Name() string
+	FetchUpgrade(ctx context.Context, chainName string) (*types.UpgradeInfo, error)
+}
+
+// RegisterUpgradeProvider makes a provider available under its name, replacing a provider of the
+// same name. Unless an order was configured with SetUpgradeProviderOrder, new providers are asked
+// after the ones registered before them.
+func (r *ChainRegistry) RegisterUpgradeProvider(provider UpgradeProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := provider.Name()
+	if _, exists := r.upgradeProviders[name]; !exists {
+		r.registeredProviders = append(r.registeredProviders, name)
+	}
+	r.upgradeProviders[name] = provider
+}
+
+// SetUpgradeProviderOrder selects which registered providers are asked for upgrades and in which
+// order. An empty list restores the default of all providers in registration order.
+func (r *ChainRegistry) SetUpgradeProviderOrder(names []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.validateProviderOrder(names); err != nil {
+		return err
+	}
+	r.providerOrder = append([]string(nil), names...)
+	return nil
+}
+
+// ValidateUpgradeProviderOrder checks that names only lists registered providers, each once, as
+// upgrades.providers and the sources of a chains.yaml entry must
+func (r *ChainRegistry) ValidateUpgradeProviderOrder(names []string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.validateProviderOrder(names)
+}
+
+// validateProviderOrder checks that names only lists registered providers, each once. The caller
+// must hold r.mu.
+func (r *ChainRegistry) validateProviderOrder(names []string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := r.upgradeProviders[name]; !ok {
+			return fmt.Errorf("unknown upgrade provider %q, expected one of %s", name, strings.Join(r.registeredProviders, ", "))
+		}
+		if seen[name] {
+			return fmt.Errorf("upgrade provider %q listed more than once", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// UpgradeProviders returns the providers in the order they are asked
+func (r *ChainRegistry) UpgradeProviders() []UpgradeProvider {
+	return r.chainUpgradeProviders("")
+}
+
+// chainUpgradeProviders returns the providers in the order they are asked for the chain: the sources
+// of its chains.yaml entry when it lists any, the configured order otherwise
+func (r *ChainRegistry) chainUpgradeProviders(chainName string) []UpgradeProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order := r.chainConfigs[chainName].Sources
+	if len(order) == 0 {
+		order = r.providerOrder
+	}
+	if len(order) == 0 {
+		order = r.registeredProviders
+	}
+	providers := make([]UpgradeProvider, 0, len(order))
+	for _, name := range order {
+		providers = append(providers, r.upgradeProviders[name])
+	}
+	return providers
+}
+
+// fetchUpgrade asks all of the chain's providers and merges the upgrades they report for the same plan
+// as the first one, see mergeUpgrade. Source names the provider of the first and Sources every
+// provider merged in. answered reports whether any provider answered, with an upgrade or without one,
+// and failed whether any provider failed to.
+func (r *ChainRegistry) fetchUpgrade(ctx context.Context, chainName string) (merged *types.UpgradeInfo, answered, failed bool) {
+	for _, provider := range r.chainUpgradeProviders(chainName) {
+		upgradeInfo, err := provider.FetchUpgrade(ctx, chainName)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, false, true
+			}
+			r.logger.Debugf("Failed to get upgrade info from %s for %s: %v", provider.Name(), chainName, err)
+			r.recordError(chainName, provider.Name(), err)
+			failed = true
+			continue
+		}
+		answered = true
+		if upgradeInfo == nil {
+			r.clearError(chainName, provider.Name())
+			continue
+		}
+		if upgradeInfo.Source == "" {
+			upgradeInfo.Source = provider.Name()
+		}
+		if merged == nil {
+			merged = upgradeInfo
+			merged.Sources = []string{merged.Source}
+			continue
+		}
+		r.mergeUpgrade(chainName, merged, upgradeInfo)
+	}
+	if merged != nil {
+		// Fresh upgrade data resolves whatever error was recorded for the chain before
+		r.clearError(chainName, "")
+	}
+	return merged, answered, failed
+}
+
+// heightAuthorities are the providers reading the upgrade height from the chain or the chain-registry,
+// whose height is kept when another provider reports a different one for the same plan
+var heightAuthorities = map[string]bool{
+	ProviderChainRegistry: true,
+	ProviderCurrentPlan:   true,
+	ProviderGovernance:    true,
+}
+
+// upgradePlanName is the upgrade's plan name, from its Cosmovisor folder when it has one since Polkachu
+// names its upgrades after the chain
+func upgradePlanName(upgradeInfo *types.UpgradeInfo) string {
+	if upgradeInfo.CosmovisorFolder != "" {
+		return path.Base(upgradeInfo.CosmovisorFolder)
+	}
+	return upgradeInfo.Name
+}
+
+// mergeUpgrade fills the fields merged is missing from the upgrade of a provider asked after the ones
+// merged so far. An upgrade for another plan, sharing neither the plan name, the version nor the
+// height, is left out. When the two disagree on the height of the plan the height of a
+// heightAuthorities provider is kept, along with its time.
+func (r *ChainRegistry) mergeUpgrade(chainName string, merged, upgradeInfo *types.UpgradeInfo) {
+	samePlan := (upgradePlanName(merged) != "" && upgradePlanName(merged) == upgradePlanName(upgradeInfo)) ||
+		(merged.Version != "" && merged.Version == upgradeInfo.Version)
+	if !samePlan && (merged.Height == 0 || merged.Height != upgradeInfo.Height) {
+		r.logger.Debugf("Ignoring the %s upgrade %s of %s, %s reports %s", upgradeInfo.Source, upgradeInfo.Version, chainName, merged.Source, merged.Version)
+		return
+	}
+
+	// The time of a provider is only worth anything with its height
+	conflict := merged.Height != 0 && upgradeInfo.Height != 0 && merged.Height != upgradeInfo.Height
+	if conflict {
+		r.logger.Warnf("Upgrade sources disagree on the height of the %s upgrade of %s: %s reports %d, %s reports %d",
+			upgradePlanName(merged), chainName, merged.Source, merged.Height, upgradeInfo.Source, upgradeInfo.Height)
+		if !heightAuthorities[merged.Source] && heightAuthorities[upgradeInfo.Source] {
+			merged.Height, merged.Time, merged.Estimated = upgradeInfo.Height, upgradeInfo.Time, upgradeInfo.Estimated
+		}
+	}
+
+	mergeString := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	mergeString(&merged.Name, upgradeInfo.Name)
+	mergeString(&merged.Version, upgradeInfo.Version)
+	mergeString(&merged.Network, upgradeInfo.Network)
+	mergeString(&merged.Info, upgradeInfo.Info)
+	mergeString(&merged.Proposal, upgradeInfo.Proposal)
+	mergeString(&merged.ProposalLink, upgradeInfo.ProposalLink)
+	mergeString(&merged.Guide, upgradeInfo.Guide)
+	mergeString(&merged.BlockLink, upgradeInfo.BlockLink)
+	mergeString(&merged.CosmovisorFolder, upgradeInfo.CosmovisorFolder)
+	mergeString(&merged.GitHash, upgradeInfo.GitHash)
+	mergeString(&merged.Repo, upgradeInfo.Repo)
+	mergeString(&merged.RPC, upgradeInfo.RPC)
+	mergeString(&merged.API, upgradeInfo.API)
+	if merged.Height == 0 {
+		merged.Height = upgradeInfo.Height
+	}
+	if !conflict && merged.Time.IsZero() && !upgradeInfo.Time.IsZero() {
+		merged.Time, merged.Estimated = upgradeInfo.Time, upgradeInfo.Estimated
+	}
+	merged.Sources = append(merged.Sources, upgradeInfo.Source)
+}
+
+// knownChain returns the chain info loaded for chainName, or an empty one with just the name
+func (r *ChainRegistry) knownChain(chainName string) *ChainInfo {
+	if chain, ok := r.loadChain(chainName); ok {
+		return chain
+	}
+	return &ChainInfo{Name: chainName}
+}
+
+// chainRegistryProvider reads the upgrades.json published next to a chain's chain.json
+type chainRegistryProvider struct {
+	registry *ChainRegistry
+}
+
+func (p *chainRegistryProvider) Name() string { return ProviderChainRegistry }
+
+func (p *chainRegistryProvider) FetchUpgrade(ctx context.Context, chainName string) (*types.UpgradeInfo, error) {
+	upgrade, err := p.registry.getUpgradeInfoFromChain(ctx, chainName)
+	if err != nil || upgrade == nil {
+		return nil, err
+	}
+	upgradeInfo := p.registry.convertUpgradeInfo(chainName, p.registry.knownChain(chainName), upgrade)
+	if upgradeInfo.Version == "" {
+		upgradeInfo.Version = p.registry.resolveVersion(ctx, upgradeInfo.Name, upgradeInfo.Repo)
+	}
+	return upgradeInfo, nil
+}
+
+// polkachuProvider looks the chain up in Polkachu's list of upcoming upgrades
+type polkachuProvider struct {
+	registry *ChainRegistry
+}
+
+func (p *polkachuProvider) Name() string { return ProviderPolkachu }
+
+func (p *polkachuProvider) FetchUpgrade(ctx context.Context, chainName string) (*types.UpgradeInfo, error) {
+	upgrade, err := p.registry.fetchPolkachuUpgrades(ctx, chainName)
+	if err != nil || upgrade == nil {
+		return nil, err
+	}
+	return p.registry.convertUpgradeInfo(chainName, p.registry.knownChain(chainName), upgrade), nil
+}
@@ -0,0 +1,304 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockProvider struct {
+	name    string
+	upgrade *types.UpgradeInfo
+	err     error
+	calls   int
+}
+
+func (p *mockProvider) Name() string { return p.name }
+
+func (p *mockProvider) FetchUpgrade(ctx context.Context, chainName string) (*types.UpgradeInfo, error) {
+	p.calls++
+	if p.upgrade == nil {
+		return nil, p.err
+	}
+	upgrade := *p.upgrade
+	upgrade.ChainName = chainName
+	return &upgrade, p.err
+}
+
+// newTestchainServer serves a chain-registry that only lists testchain, without upgrades. Tests of
+// this package cannot use testutil.NewRegistryServer, as testutil imports it.
+func newTestchainServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/testchain/chain.json") {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChainRegistry_UpgradeProviderOrder(t *testing.T) {
+	registryServer := newTestchainServer(t)
+
+	tests := []struct {
+		name     string
+		order    []string
+		expected string
+		sources  []string
+		calls    map[string]int
+	}{
+		{
+			name:     "failing provider falls through",
+			order:    []string{"broken", "mintscan", "directory"},
+			expected: "v2-mintscan",
+			sources:  []string{"mintscan", "directory"},
+			calls:    map[string]int{"broken": 1, "mintscan": 1, "directory": 1},
+		},
+		{
+			name:     "empty provider falls through",
+			order:    []string{"empty", "directory", "mintscan"},
+			expected: "v2-directory",
+			sources:  []string{"directory", "mintscan"},
+			calls:    map[string]int{"empty": 1, "directory": 1, "mintscan": 1},
+		},
+		{
+			name:     "first provider wins",
+			order:    []string{"mintscan", "directory"},
+			expected: "v2-mintscan",
+			sources:  []string{"mintscan", "directory"},
+			calls:    map[string]int{"mintscan": 1, "directory": 1},
+		},
+		{
+			name:  "no provider knows the upgrade",
+			order: []string{"broken", "empty"},
+			calls: map[string]int{"broken": 1, "empty": 1},
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := map[string]*mockProvider{
+				"broken":    {name: "broken", err: errors.New("unavailable")},
+				"empty":     {name: "empty"},
+				"mintscan":  {name: "mintscan", upgrade: &types.UpgradeInfo{Name: "v2", Version: "v2-mintscan", Height: 1000}},
+				"directory": {name: "directory", upgrade: &types.UpgradeInfo{Name: "v2", Version: "v2-directory", Height: 1000}},
+			}
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			for _, provider := range providers {
+				registry.RegisterUpgradeProvider(provider)
+			}
+			require.NoError(t, registry.SetUpgradeProviderOrder(tt.order))
+
+			upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", true)
+			if tt.expected == "" {
+				assert.ErrorIs(t, err, ErrNoUpgrade)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, upgrade)
+				assert.Equal(t, tt.expected, upgrade.Version)
+				assert.Equal(t, "testchain", upgrade.ChainName)
+				assert.Equal(t, strings.TrimPrefix(tt.expected, "v2-"), upgrade.Source)
+				assert.Equal(t, tt.sources, upgrade.Sources)
+			}
+			for name, calls := range tt.calls {
+				assert.Equal(t, calls, providers[name].calls, name)
+			}
+		})
+	}
+}
+
+func TestChainRegistry_UpgradeFetchFailed(t *testing.T) {
+	registryServer := newTestchainServer(t)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.RegisterUpgradeProvider(&mockProvider{name: "broken", err: errors.New("unavailable")})
+	registry.RegisterUpgradeProvider(&mockProvider{name: "empty"})
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{"broken", "empty"}))
+
+	_, err := registry.GetUpgradeInfo(context.Background(), "testchain", true)
+	require.ErrorIs(t, err, ErrNoUpgrade)
+	assert.True(t, registry.UpgradeFetchFailed("testchain"))
+
+	// Errors of other sources do not hide the failed upgrade fetch
+	registry.recordError("testchain", errorSourceNodeInfo, errors.New("node unreachable"))
+	assert.True(t, registry.UpgradeFetchFailed("testchain"))
+	registry.clearError("testchain", "")
+	assert.True(t, registry.UpgradeFetchFailed("testchain"))
+
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{"empty"}))
+	_, err = registry.GetUpgradeInfo(context.Background(), "testchain", true)
+	require.ErrorIs(t, err, ErrNoUpgrade)
+	assert.False(t, registry.UpgradeFetchFailed("testchain"), "every provider answered the latest fetch")
+}
+
+func TestChainRegistry_SetUpgradeProviderOrder(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master")
+
+	var names []string
+	for _, provider := range registry.UpgradeProviders() {
+		names = append(names, provider.Name())
+	}
+	assert.Equal(t, []string{ProviderChainRegistry, ProviderCurrentPlan, ProviderPolkachu, ProviderGovernance}, names, "built-in providers in their default order")
+
+	registry.RegisterUpgradeProvider(&mockProvider{name: "custom"})
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{"custom", ProviderChainRegistry}))
+	names = nil
+	for _, provider := range registry.UpgradeProviders() {
+		names = append(names, provider.Name())
+	}
+	assert.Equal(t, []string{"custom", ProviderChainRegistry}, names)
+
+	assert.Error(t, registry.SetUpgradeProviderOrder([]string{"mintscan"}))
+	assert.Error(t, registry.SetUpgradeProviderOrder([]string{ProviderPolkachu, ProviderPolkachu}))
+}
+
+func TestChainRegistry_ChainUpgradeSources(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, file, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/cosmos/chain-registry/master/"), "/")
+		switch file {
+		case "chain.json":
+			json.NewEncoder(w).Encode(ChainInfo{Name: name, ChainID: name + "-1", Network: "mainnet"})
+		case "upgrades.json":
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "v2", "version": "v2.0.0", "height": 1000})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	// Polkachu still lists a stale height for every chain
+	polkachuServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var upgrades []PolkachuUpgrade
+		for _, name := range []string{"registryfirst", "polkachufirst", "unconfigured"} {
+			upgrades = append(upgrades, PolkachuUpgrade{Network: "mainnet", ChainName: name, NodeVersion: "v2.0.0", Block: 900})
+		}
+		json.NewEncoder(w).Encode(upgrades)
+	}))
+	defer polkachuServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master", WithPolkachuURL(polkachuServer.URL))
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetETADriftThreshold(-1)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderPolkachu, ProviderChainRegistry}))
+	require.NoError(t, registry.SetChainConfigs([]types.ChainConfig{
+		{Name: "registryfirst", Sources: []string{ProviderChainRegistry, ProviderPolkachu}},
+		{Name: "polkachufirst", Sources: []string{ProviderPolkachu}},
+		{Name: "unconfigured"},
+	}))
+
+	tests := []struct {
+		chain   string
+		height  int64
+		source  string
+		sources []string
+	}{
+		{chain: "registryfirst", height: 1000, source: ProviderChainRegistry, sources: []string{ProviderChainRegistry, ProviderPolkachu}},
+		{chain: "polkachufirst", height: 900, source: ProviderPolkachu, sources: []string{ProviderPolkachu}},
+		// Polkachu comes first but the registry's height wins the conflict
+		{chain: "unconfigured", height: 1000, source: ProviderPolkachu, sources: []string{ProviderPolkachu, ProviderChainRegistry}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.chain, func(t *testing.T) {
+			upgrade, err := registry.GetUpgradeInfo(context.Background(), tt.chain, true)
+			require.NoError(t, err)
+			require.NotNil(t, upgrade)
+			assert.Equal(t, tt.height, upgrade.Height)
+			assert.Equal(t, tt.source, upgrade.Source)
+			assert.Equal(t, tt.sources, upgrade.Sources)
+		})
+	}
+
+	err := registry.SetChainConfigs([]types.ChainConfig{{Name: "registryfirst", Sources: []string{"onchain"}}})
+	assert.ErrorContains(t, err, `invalid sources of chain registryfirst: unknown upgrade provider "onchain"`)
+	config, _ := registry.GetChainConfig("registryfirst")
+	assert.Equal(t, []string{ProviderChainRegistry, ProviderPolkachu}, config.Sources, "invalid configs are not applied")
+}
+
+func TestChainRegistry_MergeUpgradeSources(t *testing.T) {
+	upgradeTime := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cosmos/chain-registry/master/osmosis/chain.json":
+			json.NewEncoder(w).Encode(ChainInfo{Name: "osmosis", ChainID: "osmosis-1", Network: "mainnet"})
+		case "/cosmos/chain-registry/master/osmosis/upgrades.json":
+			w.Write([]byte(`{"name": "v27", "version": "v27.0.0", "height": 17000000, "repo": "https://github.com/osmosis-labs/osmosis", "git_hash": "6c0f9b4a"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+	polkachuServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]PolkachuUpgrade{{
+			Network:              "osmosis",
+			ChainName:            "osmosis",
+			NodeVersion:          "v27.0.0",
+			CosmovisorFolder:     "v27",
+			Block:                17000000,
+			BlockLink:            "https://www.mintscan.io/osmosis/block/17000000",
+			EstimatedUpgradeTime: upgradeTime.Format(time.RFC3339),
+		}})
+	}))
+	defer polkachuServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	newRegistry := func(t *testing.T, order ...string) *ChainRegistry {
+		registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master", WithPolkachuURL(polkachuServer.URL))
+		registry.SetRetryPolicy(-1, 0)
+		registry.SetETADriftThreshold(-1)
+		registry.RegisterUpgradeProvider(&mockProvider{name: "other-plan", upgrade: &types.UpgradeInfo{Name: "v28", Version: "v28.0.0", Height: 18000000, GitHash: "ffffffff"}})
+		require.NoError(t, registry.SetUpgradeProviderOrder(order))
+		return registry
+	}
+
+	t.Run("registry and polkachu", func(t *testing.T) {
+		registry := newRegistry(t, ProviderChainRegistry, "other-plan", ProviderPolkachu)
+
+		upgrade, err := registry.GetUpgradeInfo(context.Background(), "osmosis", false)
+		require.NoError(t, err)
+		assert.Equal(t, "v27", upgrade.Name)
+		assert.Equal(t, int64(17000000), upgrade.Height)
+		assert.Equal(t, "https://github.com/osmosis-labs/osmosis", upgrade.Repo)
+		assert.Equal(t, "6c0f9b4a", upgrade.GitHash)
+		assert.Equal(t, "https://www.mintscan.io/osmosis/block/17000000", upgrade.BlockLink)
+		assert.True(t, upgradeTime.Equal(upgrade.Time), "the time is Polkachu's estimate")
+		assert.Equal(t, ProviderChainRegistry, upgrade.Source)
+		assert.Equal(t, []string{ProviderChainRegistry, ProviderPolkachu}, upgrade.Sources, "the upgrade of another plan is left out")
+	})
+
+	t.Run("polkachu first", func(t *testing.T) {
+		registry := newRegistry(t, ProviderPolkachu, ProviderChainRegistry)
+
+		upgrade, err := registry.GetUpgradeInfo(context.Background(), "osmosis", false)
+		require.NoError(t, err)
+		assert.Equal(t, "6c0f9b4a", upgrade.GitHash)
+		assert.Equal(t, "https://www.mintscan.io/osmosis/block/17000000", upgrade.BlockLink)
+		assert.Equal(t, "v27", upgrade.CosmovisorFolder, "the fields Polkachu reports come first")
+		assert.Equal(t, []string{ProviderPolkachu, ProviderChainRegistry}, upgrade.Sources)
+	})
+}
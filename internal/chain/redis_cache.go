@@ -0,0 +1,225 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultRedisKeyPrefix namespaces the registry's keys in a Redis database shared with other data
+	DefaultRedisKeyPrefix = "cosmos-watcher:"
+	// redisTimeout bounds every Redis command, so an unreachable Redis slows lookups down to a fetch
+	// from upstream rather than stalling them
+	redisTimeout = 2 * time.Second
+	// redisScanCount is how many keys Flush and ItemCount ask Redis for per SCAN
+	redisScanCount = 1000
+)
+
+// RedisCacheConfig is the Redis server a RedisCache keeps its entries in
+type RedisCacheConfig struct {
+	// Address is the host:port of the server
+	Address  string
+	Password string
+	// KeyPrefix is prepended to every key, DefaultRedisKeyPrefix when empty
+	KeyPrefix string
+}
+
+// RedisCache is a Cache kept in Redis, so replicas of the watcher share what any of them fetched.
+// Values are stored as JSON along with their type, which must be one of the registry's cache value
+// types. Redis errors are logged and make lookups miss, as if the entry was not cached.
+type RedisCache struct {
+	client     *redis.Client
+	prefix     string
+	defaultTTL time.Duration
+	logger     *logrus.Logger
+	// types maps the type names stored with the values to the types they are decoded into
+	types map[string]reflect.Type
+}
+
+// redisEntry is how a value is stored in Redis
+type redisEntry struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// NewRedisCache connects to the Redis server of cfg, returning an error when it cannot be reached
+func NewRedisCache(logger *logrus.Logger, cfg RedisCacheConfig) (*RedisCache, error) {
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("redis address cannot be empty")
+	}
+	prefix := cfg.KeyPrefix
+	if prefix == "" {
+		prefix = DefaultRedisKeyPrefix
+	}
+
+	c := &RedisCache{
+		client: redis.NewClient(&redis.Options{
+			Addr:         cfg.Address,
+			Password:     cfg.Password,
+			DialTimeout:  redisTimeout,
+			ReadTimeout:  redisTimeout,
+			WriteTimeout: redisTimeout,
+		}),
+		prefix:     prefix,
+		defaultTTL: defaultCacheTTL,
+		logger:     logger,
+		types:      make(map[string]reflect.Type, len(cacheValueTypes)),
+	}
+	for _, value := range cacheValueTypes {
+		t := reflect.TypeOf(value)
+		c.types[t.String()] = t
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		c.client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %w", cfg.Address, err)
+	}
+	return c, nil
+}
+
+// Close closes the connections to Redis
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
+
+func (c *RedisCache) Get(key string) (interface{}, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	data, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false
+	}
+	if err != nil {
+		c.logger.Warnf("Failed to read cache entry %q from Redis: %v", key, err)
+		return nil, false
+	}
+
+	var entry redisEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		c.logger.Warnf("Failed to decode cache entry %q from Redis: %v", key, err)
+		return nil, false
+	}
+	t, ok := c.types[entry.Type]
+	if !ok {
+		c.logger.Warnf("Cache entry %q in Redis holds unknown type %s", key, entry.Type)
+		return nil, false
+	}
+	value, err := decodeRedisValue(t, entry.Value)
+	if err != nil {
+		c.logger.Warnf("Failed to decode cache entry %q from Redis: %v", key, err)
+		return nil, false
+	}
+	return value, true
+}
+
+// decodeRedisValue decodes data into a new value of type t, allocating what a pointer type points to
+func decodeRedisValue(t reflect.Type, data []byte) (interface{}, error) {
+	if t.Kind() == reflect.Pointer {
+		value := reflect.New(t.Elem())
+		if err := json.Unmarshal(data, value.Interface()); err != nil {
+			return nil, err
+		}
+		return value.Interface(), nil
+	}
+	value := reflect.New(t)
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
+
+func (c *RedisCache) Set(key string, value interface{}, ttl time.Duration) {
+	typeName := fmt.Sprintf("%T", value)
+	if _, ok := c.types[typeName]; !ok {
+		c.logger.Warnf("Not caching %q in Redis: %s is not a cache value type", key, typeName)
+		return
+	}
+	data, err := json.Marshal(value)
+	if err == nil {
+		data, err = json.Marshal(redisEntry{Type: typeName, Value: data})
+	}
+	if err != nil {
+		c.logger.Warnf("Failed to encode cache entry %q for Redis: %v", key, err)
+		return
+	}
+
+	switch {
+	case ttl == 0:
+		ttl = c.defaultTTL
+	case ttl < 0:
+		// Redis keeps keys set without an expiration forever
+		ttl = 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := c.client.Set(ctx, c.prefix+key, data, ttl).Err(); err != nil {
+		c.logger.Warnf("Failed to write cache entry %q to Redis: %v", key, err)
+	}
+}
+
+func (c *RedisCache) Delete(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := c.client.Del(ctx, c.prefix+key).Err(); err != nil {
+		c.logger.Warnf("Failed to delete cache entry %q from Redis: %v", key, err)
+	}
+}
+
+// Flush deletes the keys under the cache's prefix, leaving the rest of the database alone
+func (c *RedisCache) Flush() {
+	err := c.scan(func(ctx context.Context, keys []string) error {
+		return c.client.Del(ctx, keys...).Err()
+	})
+	if err != nil {
+		c.logger.Warnf("Failed to flush the Redis cache: %v", err)
+	}
+}
+
+// ItemCount counts the keys under the cache's prefix, zero when Redis cannot be reached
+func (c *RedisCache) ItemCount() int {
+	count := 0
+	err := c.scan(func(ctx context.Context, keys []string) error {
+		count += len(keys)
+		return nil
+	})
+	if err != nil {
+		c.logger.Warnf("Failed to count the Redis cache entries: %v", err)
+		return 0
+	}
+	return count
+}
+
+// scan calls fn with every batch of keys under the cache's prefix
+func (c *RedisCache) scan(fn func(ctx context.Context, keys []string) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	match := redisGlobEscaper.Replace(c.prefix) + "*"
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, match, redisScanCount).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(ctx, keys); err != nil {
+				return err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// redisGlobEscaper escapes the characters SCAN's MATCH pattern treats specially
+var redisGlobEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
@@ -0,0 +1,215 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/patrickmn/go-cache"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCacheBackend checks that c behaves as the registry expects of a Cache. advance lets the entries
+// of c age by d.
+func testCacheBackend(t *testing.T, c Cache, advance func(d time.Duration)) {
+	blockTime := time.Date(2024, 3, 20, 15, 4, 5, 0, time.UTC)
+	values := map[string]interface{}{
+		"not_found": notFound{},
+		"chain_info": &ChainInfo{
+			Name:            "osmosis",
+			ChainID:         "osmosis-1",
+			Network:         networkMainnet,
+			Codebase:        Codebase{GitRepo: "https://github.com/osmosis-labs/osmosis", RecommendedVersion: "v26.0.1"},
+			Height:          17000000,
+			LatestBlockTime: blockTime,
+			APIs:            APIs{RPC: []Endpoint{{Address: "https://rpc.osmosis.zone"}}},
+			LastUpdated:     blockTime,
+		},
+		"upgrade_info":  &types.UpgradeInfo{Name: "v27", ChainName: "osmosis", Version: "v27.0.0", Height: 17500000, Time: blockTime},
+		"chain_exists":  true,
+		"validators":    &conditionalEntry{etag: `"abc"`, chainInfo: ChainInfo{Name: "osmosis"}},
+		"latest_block":  LatestBlock{Height: 17000000, Time: blockTime},
+		"block_time":    6 * time.Second,
+		"node_version":  "v26.0.1",
+		"asset_list":    &AssetList{ChainName: "osmosis", Assets: []Asset{{Name: "Osmosis", Base: "uosmo", Display: "osmo", Symbol: "OSMO", Exponent: 6}}},
+		"versions":      &VersionHistory{ChainName: "osmosis", Versions: []Version{{Name: "v26", Height: 16000000}}},
+		"vote_tally":    &types.VoteTally{ProposalID: "42", Status: "passed", Yes: 0.9},
+		"discovered":    &DiscoveredChains{Mainnet: []string{"osmosis"}, Testnet: []string{}},
+		"polkachu":      map[string][]*PolkachuUpgrade{"osmosis": {{Network: "osmosis", NodeVersion: "v27.0.0", Block: 17500000}}},
+		"polkachu_fail": polkachuFailure{err: errors.New("polkachu API returned non-200 status code: 503")},
+	}
+	require.Len(t, values, len(cacheValueTypes), "every cache value type is checked")
+
+	t.Run("values survive the round-trip", func(t *testing.T) {
+		c.Flush()
+		for key, value := range values {
+			c.Set(key, value, time.Hour)
+		}
+		assert.Equal(t, len(values), c.ItemCount())
+		for key, value := range values {
+			cached, found := c.Get(key)
+			require.True(t, found, key)
+			if failure, ok := value.(polkachuFailure); ok {
+				require.IsType(t, polkachuFailure{}, cached)
+				assert.EqualError(t, cached.(polkachuFailure).err, failure.err.Error())
+				continue
+			}
+			assert.Equal(t, value, cached, key)
+		}
+	})
+
+	t.Run("set replaces and delete removes", func(t *testing.T) {
+		c.Flush()
+		c.Set("node_version:osmosis", "v26.0.0", time.Hour)
+		c.Set("node_version:osmosis", "v26.0.1", time.Hour)
+		cached, found := c.Get("node_version:osmosis")
+		require.True(t, found)
+		assert.Equal(t, "v26.0.1", cached)
+
+		c.Delete("node_version:osmosis")
+		_, found = c.Get("node_version:osmosis")
+		assert.False(t, found)
+		c.Delete("node_version:osmosis")
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		c.Flush()
+		c.Set("short", "v1", 50*time.Millisecond)
+		c.Set("forever", "v1", cache.NoExpiration)
+		c.Set("default", "v1", cache.DefaultExpiration)
+		advance(100 * time.Millisecond)
+		_, found := c.Get("short")
+		assert.False(t, found)
+		_, found = c.Get("forever")
+		assert.True(t, found, "a negative TTL never expires")
+		_, found = c.Get("default")
+		assert.True(t, found, "a zero TTL uses the default")
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		c.Set("a", "v1", time.Hour)
+		c.Set("b", "v1", time.Hour)
+		c.Flush()
+		assert.Zero(t, c.ItemCount())
+		_, found := c.Get("a")
+		assert.False(t, found)
+	})
+}
+
+func TestMemoryCache(t *testing.T) {
+	testCacheBackend(t, cache.New(defaultCacheTTL, defaultCacheCleanupInterval), func(d time.Duration) { time.Sleep(d) })
+}
+
+func newTestRedisCache(t *testing.T, server *miniredis.Miniredis, prefix string) *RedisCache {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	c, err := NewRedisCache(logger, RedisCacheConfig{Address: server.Addr(), KeyPrefix: prefix})
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestRedisCache(t *testing.T) {
+	server := miniredis.RunT(t)
+	c := newTestRedisCache(t, server, "")
+	testCacheBackend(t, c, server.FastForward)
+
+	t.Run("keys are prefixed", func(t *testing.T) {
+		other := newTestRedisCache(t, server, "other:")
+		c.Set("node_version:osmosis", "v26.0.1", time.Hour)
+		other.Set("node_version:osmosis", "v25.0.0", time.Hour)
+		assert.True(t, server.Exists(DefaultRedisKeyPrefix+"node_version:osmosis"))
+
+		other.Flush()
+		cached, found := c.Get("node_version:osmosis")
+		require.True(t, found, "flushing one prefix leaves the others alone")
+		assert.Equal(t, "v26.0.1", cached)
+		assert.Zero(t, other.ItemCount())
+	})
+
+	t.Run("unknown values", func(t *testing.T) {
+		c.Set("unknown", struct{ Name string }{"osmosis"}, time.Hour)
+		_, found := c.Get("unknown")
+		assert.False(t, found, "only cache value types are stored")
+
+		require.NoError(t, server.Set(DefaultRedisKeyPrefix+"garbage", "not json"))
+		_, found = c.Get("garbage")
+		assert.False(t, found)
+		require.NoError(t, server.Set(DefaultRedisKeyPrefix+"renamed", `{"type": "*chain.Renamed", "value": {}}`))
+		_, found = c.Get("renamed")
+		assert.False(t, found)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		c := newTestRedisCache(t, server, "")
+		c.Set("node_version:osmosis", "v26.0.1", time.Hour)
+		server.Close()
+
+		_, found := c.Get("node_version:osmosis")
+		assert.False(t, found, "a Redis error is a miss")
+		assert.Zero(t, c.ItemCount())
+	})
+
+	t.Run("no server", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		addr := server.Addr()
+		server.Close()
+		_, err := NewRedisCache(logrus.New(), RedisCacheConfig{Address: addr})
+		assert.ErrorContains(t, err, "failed to connect to Redis")
+	})
+}
+
+func TestChainRegistry_SharedRedisCache(t *testing.T) {
+	var requests atomic.Int32
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/cosmos/chain-registry/master/osmosis/chain.json" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, "testdata/chain.json")
+	}))
+	defer registryServer.Close()
+
+	server := miniredis.RunT(t)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	newReplica := func() *ChainRegistry {
+		registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master",
+			WithCacheBackend(newTestRedisCache(t, server, "")), WithCache(time.Minute, 0))
+		registry.SetRetryPolicy(-1, 0)
+		registry.SetDegradedThreshold(-1)
+		return registry
+	}
+	first, second := newReplica(), newReplica()
+	ctx := context.Background()
+
+	info, err := first.GetChainInfo(ctx, "osmosis", false)
+	require.NoError(t, err)
+	fetched := requests.Load()
+
+	shared, err := second.GetChainInfo(ctx, "osmosis", false)
+	require.NoError(t, err)
+	assert.Equal(t, fetched, requests.Load(), "the second replica reads what the first one fetched")
+	assert.Equal(t, info.ChainID, shared.ChainID)
+	assert.Equal(t, info.APIs, shared.APIs)
+
+	_, err = first.GetChainInfo(ctx, "notachain", false)
+	var notFound *ErrChainNotFound
+	require.ErrorAs(t, err, &notFound)
+	fetched = requests.Load()
+	_, err = second.GetChainInfo(ctx, "notachain", false)
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, fetched, requests.Load(), "failed lookups are shared too")
+}
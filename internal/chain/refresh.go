@@ -0,0 +1,114 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// refreshConcurrency bounds the chains RefreshAll refreshes at once
+const refreshConcurrency = 5
+
+// ChainRefresh is the outcome of force-refreshing one chain
+type ChainRefresh struct {
+	Chain      string `json:"chain"`
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// RefreshReport is the outcome of RefreshAll, with the chains in monitoring order
+type RefreshReport struct {
+	StartedAt  time.Time      `json:"started_at"`
+	DurationMS int64          `json:"duration_ms"`
+	Refreshed  int            `json:"refreshed"`
+	Failed     int            `json:"failed"`
+	Chains     []ChainRefresh `json:"chains"`
+}
+
+// RefreshAll force-refreshes the chain and upgrade info of every monitored chain, a few chains at a
+// time, and reports how each went. A chain failing does not stop the others; an error is only returned
+// while degraded, when the monitored chains are unavailable or once ctx is done.
+func (r *ChainRegistry) RefreshAll(ctx context.Context) (RefreshReport, error) {
+	report := RefreshReport{StartedAt: time.Now()}
+	if r.IsDegraded() {
+		return report, ErrDegraded
+	}
+	chains, err := r.GetMonitoredChains()
+	if err != nil {
+		return report, fmt.Errorf("failed to get monitored chains: %w", err)
+	}
+
+	report.Chains = make([]ChainRefresh, len(chains))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(refreshConcurrency, len(chains)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				report.Chains[i] = r.refreshChain(ctx, chains[i])
+			}
+		}()
+	}
+	for i := range chains {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	report.DurationMS = time.Since(report.StartedAt).Milliseconds()
+	for _, refresh := range report.Chains {
+		if refresh.OK {
+			report.Refreshed++
+		} else {
+			report.Failed++
+		}
+	}
+	if ctx.Err() != nil {
+		return report, ctx.Err()
+	}
+	return report, nil
+}
+
+func (r *ChainRegistry) refreshChain(ctx context.Context, chainName string) ChainRefresh {
+	start := time.Now()
+	refresh := ChainRefresh{Chain: chainName}
+	err := r.refreshChainInfo(ctx, chainName)
+	refresh.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		refresh.Error = err.Error()
+		r.logger.Warnf("Failed to refresh %s: %v", chainName, err)
+		return refresh
+	}
+	refresh.OK = true
+	return refresh
+}
+
+// refreshChainInfo re-fetches the chain's info and upgrade. Data served from the snapshot means the
+// fetch failed, and a chain without an upgrade is refreshed all the same.
+func (r *ChainRegistry) refreshChainInfo(ctx context.Context, chainName string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	info, err := r.GetChainInfo(ctx, chainName, true)
+	if err != nil {
+		return fmt.Errorf("chain info: %w", err)
+	}
+	if info.Stale {
+		return errors.New("chain info: only the snapshot is available")
+	}
+	upgrade, err := r.GetUpgradeInfo(ctx, chainName, true)
+	if errors.Is(err, ErrNoUpgrade) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("upgrade info: %w", err)
+	}
+	if upgrade.Stale {
+		return errors.New("upgrade info: only the snapshot is available")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_RefreshAll(t *testing.T) {
+	var osmosisFetches atomic.Int32
+	var brokenDown atomic.Bool
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/master/osmosis/chain.json"):
+			osmosisFetches.Add(1)
+			json.NewEncoder(w).Encode(ChainInfo{Name: "osmosis", ChainID: "osmosis-1"})
+		case strings.HasSuffix(r.URL.Path, "/master/osmosis/upgrades.json"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": "v26", "height": 1000, "time": time.Now().Add(time.Hour)})
+		case strings.HasSuffix(r.URL.Path, "/master/juno/chain.json"):
+			json.NewEncoder(w).Encode(ChainInfo{Name: "juno", ChainID: "juno-1"})
+		case strings.HasSuffix(r.URL.Path, "/master/broken/chain.json") && !brokenDown.Load():
+			json.NewEncoder(w).Encode(ChainInfo{Name: "broken", ChainID: "broken-1"})
+		case strings.Contains(r.URL.Path, "/broken/"):
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetDegradedThreshold(-1)
+	registry.SetETADriftThreshold(-1)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+	registry.SetMonitoredChains([]string{"osmosis", "broken", "juno", "notachain"})
+
+	_, err := registry.GetChainInfo(context.Background(), "osmosis", false)
+	require.NoError(t, err)
+	brokenDown.Store(true)
+
+	report, err := registry.RefreshAll(context.Background())
+	require.NoError(t, err, "failing chains do not fail the run")
+	assert.Greater(t, osmosisFetches.Load(), int32(1), "cached chains are fetched again")
+	assert.Equal(t, 2, report.Refreshed)
+	assert.Equal(t, 2, report.Failed)
+
+	require.Len(t, report.Chains, 4)
+	var chains []string
+	for _, refresh := range report.Chains {
+		chains = append(chains, refresh.Chain)
+		assert.GreaterOrEqual(t, refresh.DurationMS, int64(0))
+	}
+	assert.Equal(t, []string{"osmosis", "broken", "juno", "notachain"}, chains, "chains are reported in monitoring order")
+	assert.True(t, report.Chains[0].OK)
+	assert.False(t, report.Chains[1].OK)
+	assert.Contains(t, report.Chains[1].Error, "chain info")
+	assert.True(t, report.Chains[2].OK, "a chain without an upgrade is refreshed")
+	assert.False(t, report.Chains[3].OK)
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"chain":"broken","ok":false`)
+
+	registry.SetDegraded(true, "test")
+	_, err = registry.RefreshAll(context.Background())
+	assert.ErrorIs(t, err, ErrDegraded)
+}
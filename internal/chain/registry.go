@@ -2,20 +2,26 @@ package chain
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"maps"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/0xPuncker/cosmos-watcher/internal/notifications"
 	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/0xPuncker/cosmos-watcher/pkg/utils"
+	"github.com/0xPuncker/cosmos-watcher/pkg/version"
 	"github.com/joho/godotenv"
 	"github.com/patrickmn/go-cache"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 )
 
 type Upgrade interface {
@@ -37,30 +43,158 @@ type Upgrade interface {
 	GetAPI() string
 }
 
+// ChainRegistry fetches chain and upgrade data from the chain-registry and upgrade sources. It never
+// sends notifications itself; that is owned by cron.UpgradeChecker, which decides what is new and
+// where it is routed.
 type ChainRegistry struct {
-	cache            *cache.Cache
+	cache            Cache
 	logger           *logrus.Logger
 	client           *http.Client
-	baseURL          string
-	slack            *notifications.SlackService
-	chainName        string
+	metrics          *Metrics
 	chains           map[string]*ChainInfo
 	mu               sync.RWMutex
 	monitoredChains  []string
+	chainConfigs     map[string]types.ChainConfig
 	githubAPIURL     string
 	chainRegistryURL string
-	API              string
+	inProgressWindow time.Duration
+	releasesAPIURL   string
+	releasesToken    string
+	discoveryAPIURL  string
+	discoveryTTL     time.Duration
+	// upgradeProviders holds the registered upgrade sources by name, registeredProviders their
+	// registration order and providerOrder the configured order, if any
+	upgradeProviders    map[string]UpgradeProvider
+	registeredProviders []string
+	providerOrder       []string
+
+	// flight shares one fetch of a chain's info or upgrade between concurrent callers, keyed by the
+	// cache entity and the chain name
+	flight singleflight.Group
+
+	degradedMu        sync.RWMutex
+	degraded          DegradedStatus
+	degradedThreshold int
+
+	maintenanceMu sync.RWMutex
+	maintenance   MaintenanceStatus
+
+	// lastErrors keeps the latest fetch error per chain until a fetch from the same source succeeds,
+	// upgradeFetchFailed the chains an upgrade provider failed for in their latest upgrade fetch
+	errorsMu           sync.RWMutex
+	lastErrors         map[string]ChainError
+	upgradeFetchFailed map[string]bool
+
+	// registryNames and polkachuNames map configured chain names to the chain-registry directory and
+	// Polkachu name of chains where they differ, testnets holds the chains configured as testnets and
+	// chainDirs the directory each chain was found in. They have their own lock so fetches read them
+	// without taking mu.
+	aliasMu       sync.RWMutex
+	registryNames map[string]string
+	polkachuNames map[string]string
+	testnets      map[string]bool
+	chainDirs     map[string]registryDir
+
+	// retryAttempts and retryBaseDelay control retries of registry fetches. They have their own lock
+	// so fetches read them without taking mu.
+	retryMu        sync.RWMutex
+	retryAttempts  int
+	retryBaseDelay time.Duration
+
+	// cacheTTL and negativeCacheTTL are how long fetched info and failed lookups are cached. They are
+	// atomic so fetches read them without taking mu.
+	cacheTTL         atomic.Int64
+	negativeCacheTTL atomic.Int64
+	// chainInfoTTL and upgradeInfoTTL override cacheTTL for chain and upgrade info when positive
+	chainInfoTTL   atomic.Int64
+	upgradeInfoTTL atomic.Int64
+	// chainExistsTTL is how long a chain found by ChainExists is remembered
+	chainExistsTTL atomic.Int64
+	// existsCheckConcurrency is how many chains FilterExistingChains checks at once
+	existsCheckConcurrency atomic.Int32
+	// etaDriftThreshold is how far a provider's upgrade time may drift from the computed one, negative
+	// when upgrade times are not computed
+	etaDriftThreshold atomic.Int64
+
+	// polkachuMu serializes Polkachu fetches so concurrent chain lookups share one download of the
+	// list, which is cached for polkachuWindow
+	polkachuMu     sync.Mutex
+	polkachuURL    string
+	polkachuWindow time.Duration
+
+	// chainProbeTimeout bounds each HEAD request looking for the directory of a chain
+	chainProbeTimeout time.Duration
+	// source reads the chain-registry files, over HTTP unless WithLocalRegistry is given
+	source registrySource
+	// lastChainInfoFetch is when chain info was last fetched from one of its sources, in unix
+	// nanoseconds, zero before the first fetch
+	lastChainInfoFetch atomic.Int64
+
+	// cosmosDirectoryURL and cosmosDirectoryTestnetURL are the cosmos.directory chain APIs, asked for
+	// chain info when chainInfoSourceOrder lists it. The order is atomic so fetches read it without
+	// taking mu.
+	cosmosDirectoryURL        string
+	cosmosDirectoryTestnetURL string
+	chainInfoSourceOrder      atomic.Pointer[[]string]
+
+	// githubToken is sent with requests to GitHub. It is atomic so fetches read it without taking mu.
+	githubToken atomic.Pointer[string]
+	// links renders explorer links of upgrades, replaced by SetExplorerOrder
+	links atomic.Pointer[LinkBuilder]
+	// mirrors are the base URLs of chain-registry copies tried when the registry is unavailable
+	mirrors atomic.Pointer[[]string]
+	// registryPath is chainRegistryURL with its ref replaced by SetRegistryRef
+	registryPath atomic.Pointer[string]
+
+	// conditional counts conditional chain-registry requests and their 304 answers
+	conditional conditionalCounters
+
+	// endpointReports keeps the result of the latest endpoint probe per chain
+	endpointReports map[string]*EndpointReport
+	// probeSlots bounds the endpoint probes in flight across all chains
+	probeSlots chan struct{}
+
+	// endpointRefresh schedules re-fetching the chain.json of known chains for fresh endpoints
+	refreshMu       sync.Mutex
+	endpointRefresh *endpointRefresher
+
+	// snapshot is the last known good state, served stale when nothing fresher is available
+	snapshotMu   sync.RWMutex
+	snapshotPath string
+	snapshot     *Snapshot
+
+	// autoChains are the chains monitored because Polkachu lists a pending upgrade for them, on top of
+	// monitoredChains. Guarded by mu.
+	autoChains []string
 }
 
 type ChainInfo struct {
-	Name        string     `json:"name"`
-	ChainID     string     `json:"chain_id"`
-	Network     string     `json:"network"`
-	Version     string     `json:"version"`
-	Height      int64      `json:"height"`
-	APIs        APIs       `json:"apis"`
-	Explorers   []Explorer `json:"explorers"`
-	LastUpdated time.Time  `json:"last_updated"`
+	Name     string   `json:"name"`
+	ChainID  string   `json:"chain_id"`
+	Network  string   `json:"network"`
+	Version  string   `json:"version"`
+	Codebase Codebase `json:"codebase"`
+	Height   int64    `json:"height"`
+	// LatestBlockTime is the time of the block at Height, both read from the chain's nodes
+	LatestBlockTime time.Time  `json:"latest_block_time,omitzero"`
+	APIs            APIs       `json:"apis"`
+	Explorers       []Explorer `json:"explorers"`
+	Peers           Peers      `json:"peers,omitzero"`
+	LastUpdated     time.Time  `json:"last_updated"`
+	// Stale is set on chain info served from the snapshot because no fresh data was available
+	Stale bool `json:"stale,omitempty"`
+	// PrettyName, Bech32Prefix, DaemonName and LogoURIs describe the chain for dashboards, empty when
+	// its chain.json leaves them out
+	PrettyName   string   `json:"pretty_name"`
+	Bech32Prefix string   `json:"bech32_prefix"`
+	DaemonName   string   `json:"daemon_name"`
+	LogoURIs     LogoURIs `json:"logo_URIs"`
+}
+
+// LogoURIs are the chain's logo images listed in its chain.json
+type LogoURIs struct {
+	PNG string `json:"png"`
+	SVG string `json:"svg"`
 }
 
 type UpgradeInfo struct {
@@ -150,6 +284,24 @@ type APIs struct {
 	REST []Endpoint `json:"rest"`
 }
 
+type Codebase struct {
+	GitRepo            string   `json:"git_repo"`
+	RecommendedVersion string   `json:"recommended_version"`
+	CompatibleVersions []string `json:"compatible_versions"`
+	// Binaries maps platforms such as "linux/amd64" to download URLs of the recommended version
+	Binaries map[string]string `json:"binaries,omitempty"`
+	// Versions is the version history of chains that keep it in chain.json rather than versions.json
+	Versions []Version `json:"versions,omitempty"`
+}
+
+// RunningVersion returns the version the chain currently runs according to its chain.json codebase
+func (c *ChainInfo) RunningVersion() string {
+	if c.Codebase.RecommendedVersion != "" {
+		return c.Codebase.RecommendedVersion
+	}
+	return c.Version
+}
+
 type Endpoint struct {
 	Address string `json:"address"`
 }
@@ -159,6 +311,10 @@ type Explorer struct {
 	URL    string `json:"url"`
 	TxPage string `json:"tx_page"`
 	Kind   string `json:"kind"`
+	// BlockPage and ProposalPage are page templates with a ${...} placeholder for the height or
+	// proposal ID, listed by some chains
+	BlockPage    string `json:"block_page,omitempty"`
+	ProposalPage string `json:"proposal_page,omitempty"`
 }
 
 type PolkachuUpgrade struct {
@@ -181,140 +337,252 @@ type PolkachuResponse struct {
 	Data []PolkachuUpgrade `json:"data"`
 }
 
-const (
-	chainInfoCacheKey   = "chain_info:%s"
-	upgradeInfoCacheKey = "upgrade_info:%s"
-)
+// defaultHTTPTimeout bounds outbound requests so a hung connection cannot stall a poller cycle
+const defaultHTTPTimeout = 5 * time.Second
 
-func NewChainRegistry(logger *logrus.Logger, githubAPIURL, chainRegistryURL string) *ChainRegistry {
-	godotenv.Load()
-	baseURL := githubAPIURL
-	if strings.HasPrefix(chainRegistryURL, "http") {
-		baseURL = chainRegistryURL
-	} else if !strings.HasSuffix(baseURL, "/") {
-		baseURL = baseURL + "/"
-	}
+// ErrNoUpgrade is returned by GetUpgradeInfo for chains without an upcoming upgrade, whether the
+// sources were asked or the empty result was cached
+var ErrNoUpgrade = types.ErrNoUpgrade
 
+// NewChainRegistry returns a registry reading chain.json files from chainRegistryURL. The options
+// replace its HTTP client, cache or upstream URLs; without any it uses the public APIs.
+func NewChainRegistry(logger *logrus.Logger, githubAPIURL, chainRegistryURL string, opts ...Option) *ChainRegistry {
+	godotenv.Load()
 	chainRegistryURL = strings.TrimRight(chainRegistryURL, "/")
 
-	logger.Debugf("Base URL: %s", baseURL)
+	logger.Debugf("GitHub API URL: %s", githubAPIURL)
 	logger.Debugf("Chain Registry URL: %s", chainRegistryURL)
 
-	slackService, err := notifications.NewSlackService(logger)
+	minTLSVersion, err := utils.MinTLSVersion()
 	if err != nil {
-		logger.Warnf("Failed to initialize Slack service: %v", err)
-		slackService = nil
+		logger.Warnf("%v, falling back to TLS 1.2", err)
 	}
 
 	// Configure HTTP client with timeouts
 	client := &http.Client{
-		Timeout: 5 * time.Second,
+		Timeout: defaultHTTPTimeout,
 		Transport: &http.Transport{
 			MaxIdleConns:        100,
 			MaxIdleConnsPerHost: 100,
 			IdleConnTimeout:     90 * time.Second,
 			TLSHandshakeTimeout: 5 * time.Second,
+			TLSClientConfig:     &tls.Config{MinVersion: minTLSVersion},
 		},
 	}
 
-	return &ChainRegistry{
-		cache:            cache.New(5*time.Minute, 10*time.Second),
-		logger:           logger,
-		client:           client,
-		baseURL:          baseURL,
-		slack:            slackService,
-		chains:           make(map[string]*ChainInfo),
-		monitoredChains:  []string{},
-		githubAPIURL:     githubAPIURL,
-		chainRegistryURL: chainRegistryURL,
+	r := &ChainRegistry{
+		cache:              cache.New(defaultCacheTTL, defaultCacheCleanupInterval),
+		logger:             logger,
+		client:             client,
+		chains:             make(map[string]*ChainInfo),
+		monitoredChains:    []string{},
+		chainConfigs:       make(map[string]types.ChainConfig),
+		githubAPIURL:       githubAPIURL,
+		chainRegistryURL:   chainRegistryURL,
+		degradedThreshold:  defaultDegradedThreshold,
+		inProgressWindow:   defaultInProgressWindow,
+		retryAttempts:      defaultRetryAttempts,
+		retryBaseDelay:     defaultRetryBaseDelay,
+		discoveryAPIURL:    defaultDiscoveryAPIURL,
+		discoveryTTL:       defaultDiscoveryTTL,
+		polkachuURL:        defaultPolkachuURL,
+		polkachuWindow:     defaultPolkachuCacheWindow,
+		chainProbeTimeout:  defaultChainProbeTimeout,
+		upgradeProviders:   make(map[string]UpgradeProvider),
+		lastErrors:         make(map[string]ChainError),
+		upgradeFetchFailed: make(map[string]bool),
+		endpointReports:    make(map[string]*EndpointReport),
+		probeSlots:         make(chan struct{}, defaultProbeConcurrency),
+		snapshot:           &Snapshot{},
+		endpointRefresh:    newEndpointRefresher(),
+
+		cosmosDirectoryURL:        defaultCosmosDirectoryURL,
+		cosmosDirectoryTestnetURL: defaultCosmosDirectoryTestnetURL,
 	}
+	r.source = &httpRegistrySource{registry: r}
+	r.registryPath.Store(&chainRegistryURL)
+	r.SetCacheTTLs(defaultCacheTTL, defaultNegativeCacheTTL)
+	r.SetChainExistsTTL(defaultChainExistsTTL)
+	r.SetExistsCheckConcurrency(defaultExistsCheckConcurrency)
+	r.SetETADriftThreshold(defaultETADriftThreshold)
+	r.SetExplorerOrder(nil)
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.metrics == nil {
+		r.metrics = NewMetrics(nil)
+	}
+	r.RegisterUpgradeProvider(&chainRegistryProvider{registry: r})
+	r.RegisterUpgradeProvider(&currentPlanProvider{registry: r})
+	r.RegisterUpgradeProvider(&polkachuProvider{registry: r})
+	r.RegisterUpgradeProvider(&governanceProvider{registry: r})
+	return r
 }
 
-func (r *ChainRegistry) SetChainName(chainName string) {
-	r.chainName = chainName
+// SetHTTPTimeout sets the deadline of every outbound request, including reading the response body.
+// Zero or negative keeps the default of 5s. It must be called before the registry is used.
+func (r *ChainRegistry) SetHTTPTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+	r.client.Timeout = timeout
 }
 
+// GetMonitoredChains returns the configured chains followed by the auto-discovered ones
 func (r *ChainRegistry) GetMonitoredChains() ([]string, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	return r.monitoredChains, nil
+	return r.monitoredLocked(), nil
 }
 
-func (r *ChainRegistry) GetUpgradeInfo(chainName string, forceRefresh bool) (*types.UpgradeInfo, error) {
+// GetUpgradeInfo returns the chain's upcoming upgrade, merged from the upgrade sources reporting it.
+// Chains without one get an error wrapping ErrNoUpgrade.
+func (r *ChainRegistry) GetUpgradeInfo(ctx context.Context, chainName string, forceRefresh bool) (*types.UpgradeInfo, error) {
 	if chainName == "" {
 		return nil, fmt.Errorf("chain name cannot be empty")
 	}
 
 	// Try to get from cache first if not forcing refresh
 	if !forceRefresh {
-		if cached, found := r.cache.Get(fmt.Sprintf(upgradeInfoCacheKey, chainName)); found {
+		if upgradeInfo, found, err := r.cachedUpgradeResult(chainName); found {
 			r.logger.Debugf("Found cached upgrade info for %s", chainName)
-			if cached == nil {
-				return nil, nil
-			}
-			return cached.(*types.UpgradeInfo), nil
+			r.metrics.recordCacheLookup(CacheEntityUpgradeInfo, true)
+			return upgradeInfo, err
 		}
 	}
 
-	// Get chain info under a read lock first
-	r.mu.RLock()
-	chain, exists := r.chains[chainName]
-	r.mu.RUnlock()
+	// While degraded, serve whatever is cached even when a refresh was requested
+	if r.IsDegraded() {
+		if upgradeInfo, found, err := r.cachedUpgradeResult(chainName); found {
+			return upgradeInfo, err
+		}
+		return r.staleUpgradeInfo(chainName, ErrDegraded)
+	}
+	r.metrics.recordCacheLookup(CacheEntityUpgradeInfo, false)
 
-	// If we need to refresh or chain doesn't exist, get it under a write lock
+	result, err := r.shareFetch(ctx, CacheEntityUpgradeInfo, chainName, func() (interface{}, error) {
+		// A fetch finishing since the lookup above has cached its result
+		if upgradeInfo, found, err := r.cachedUpgradeResult(chainName); found && !forceRefresh {
+			return upgradeInfo, err
+		}
+		return r.loadUpgradeInfo(ctx, chainName, forceRefresh)
+	})
+	upgradeInfo, _ := result.(*types.UpgradeInfo)
+	return upgradeInfo, err
+}
+
+// cachedUpgradeResult returns the cached upgrade of the chain, or an error wrapping ErrNoUpgrade when
+// the cache records that it has none
+func (r *ChainRegistry) cachedUpgradeResult(chainName string) (*types.UpgradeInfo, bool, error) {
+	upgradeInfo, missing, found := r.cachedUpgradeInfo(chainName)
+	if !found {
+		return nil, false, nil
+	}
+	if missing {
+		return nil, true, noUpgradeError(chainName)
+	}
+	return upgradeInfo, true, nil
+}
+
+// loadUpgradeInfo fetches the chain's info when it is unknown or a refresh was requested, then asks
+// the upgrade sources for its upgrade and caches the result
+func (r *ChainRegistry) loadUpgradeInfo(ctx context.Context, chainName string, forceRefresh bool) (*types.UpgradeInfo, error) {
+	chain, exists := r.loadChain(chainName)
 	if forceRefresh || !exists {
-		r.mu.Lock()
 		var err error
-		chain, err = r.fetchChainInfo(chainName)
+		chain, err = r.fetchChainInfo(ctx, chainName)
 		if err != nil {
-			r.mu.Unlock()
-			// Cache the nil result to prevent repeated failed lookups
-			r.cache.Set(fmt.Sprintf(chainInfoCacheKey, chainName), nil, 5*time.Minute)
-			return nil, err
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			r.recordError(chainName, errorSourceChainInfo, err)
+			if forceRefresh && exists {
+				if upgradeInfo, found, err := r.previousUpgradeResult(chainName); found {
+					r.logger.Warnf("Failed to refresh chain info of %s, keeping its cached upgrade info", chainName)
+					return upgradeInfo, err
+				}
+			}
+			if r.IsDegraded() {
+				return r.staleUpgradeInfo(chainName, ErrDegraded)
+			}
+			// Cache the failure to prevent repeated failed lookups
+			r.cacheChainInfoFailure(chainName, err)
+			return r.staleUpgradeInfo(chainName, err)
 		}
-		r.chains[chainName] = chain
-		r.mu.Unlock()
+		r.storeChain(chainName, chain)
+		r.clearError(chainName, errorSourceChainInfo)
 	}
 
 	if chain == nil {
-		// Cache the nil result to prevent repeated failed lookups
-		r.cache.Set(fmt.Sprintf(chainInfoCacheKey, chainName), nil, 5*time.Minute)
-		return nil, fmt.Errorf("chain %q not found", chainName)
+		// Cache the failure to prevent repeated failed lookups
+		r.cacheNotFound(chainInfoCacheKey, chainName)
+		return nil, &ErrChainNotFound{Chain: chainName}
 	}
 
-	// Try to get upgrade info from chain registry first
-	chainUpgrade, err := r.getUpgradeInfoFromChain(chainName)
-	if err != nil {
-		r.logger.Debugf("Failed to get upgrade info from Chain Registry for %s: %v", chainName, err)
-	} else if chainUpgrade != nil {
-		upgradeInfo := r.convertUpgradeInfo(chainName, chain, chainUpgrade)
-		// Cache the result
-		r.cache.Set(fmt.Sprintf(upgradeInfoCacheKey, chainName), upgradeInfo, 5*time.Minute)
+	upgradeInfo, answered, failed := r.fetchUpgrade(ctx, chainName)
+	if ctx.Err() == nil {
+		r.setUpgradeFetchFailed(chainName, failed)
+	}
+	if upgradeInfo != nil {
+		r.enrichFromVersions(ctx, chainName, chain, upgradeInfo)
+		upgradeInfo.ChangeType = string(version.Classify(chain.RunningVersion(), upgradeInfo.Version))
+		r.reconcileUpgradeTime(ctx, chainName, upgradeInfo)
+		r.cacheUpgradeInfo(chainName, upgradeInfo)
 		return upgradeInfo, nil
 	}
+	// A cancelled caller proves nothing about the chain, so nothing is cached for it
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	// A forced refresh no provider answered keeps the result it meant to replace
+	if forceRefresh && !answered {
+		if upgradeInfo, found, err := r.previousUpgradeResult(chainName); found {
+			r.logger.Warnf("No upgrade source answered the refresh of %s, keeping its cached upgrade info", chainName)
+			return upgradeInfo, err
+		}
+	}
 
-	// If that fails, try Polkachu
-	polkachuUpgrade, err := r.fetchPolkachuUpgrades(chainName)
-	if err != nil {
-		r.logger.Debugf("Failed to get upgrade info from Polkachu for %s: %v", chainName, err)
-	} else if polkachuUpgrade != nil {
-		upgradeInfo := r.convertUpgradeInfo(chainName, chain, polkachuUpgrade)
-		// Cache the result
-		r.cache.Set(fmt.Sprintf(upgradeInfoCacheKey, chainName), upgradeInfo, 5*time.Minute)
-		return upgradeInfo, nil
+	if r.IsDegraded() {
+		return nil, ErrDegraded
 	}
 
 	r.logger.Debugf("No upgrade information found for chain %s", chainName)
-	// Cache the nil result to prevent repeated failed lookups
-	r.cache.Set(fmt.Sprintf(upgradeInfoCacheKey, chainName), nil, 5*time.Minute)
-	return nil, nil
+	// Cache the empty result to prevent repeated failed lookups
+	r.cacheNotFound(upgradeInfoCacheKey, chainName)
+	return nil, noUpgradeError(chainName)
+}
+
+// previousUpgradeResult is cachedUpgradeResult for a forced refresh that failed, the cached upgrade
+// marked stale
+func (r *ChainRegistry) previousUpgradeResult(chainName string) (*types.UpgradeInfo, bool, error) {
+	upgradeInfo, found, err := r.cachedUpgradeResult(chainName)
+	if !found || err != nil {
+		return nil, found, err
+	}
+	stale := *upgradeInfo
+	stale.Stale = true
+	return &stale, true, nil
+}
+
+func noUpgradeError(chainName string) error {
+	return fmt.Errorf("chain %s: %w", chainName, ErrNoUpgrade)
+}
+
+// shareFetch runs fetch once for the concurrent callers asking for the same entity of the chain, who
+// all get its result. A caller still waiting when the one running the fetch was cancelled fetches
+// again with its own context.
+func (r *ChainRegistry) shareFetch(ctx context.Context, entity, chainName string, fetch func() (interface{}, error)) (interface{}, error) {
+	result, err, _ := r.flight.Do(entity+":"+chainName, fetch)
+	if err != nil && ctx.Err() == nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return fetch()
+	}
+	return result, err
 }
 
-func (r *ChainRegistry) GetMainnetUpgrades() ([]*types.UpgradeInfo, error) {
+func (r *ChainRegistry) GetMainnetUpgrades(ctx context.Context) ([]*types.UpgradeInfo, error) {
 	// Get a copy of monitored chains under lock
 	r.mu.RLock()
-	chains := make([]string, len(r.monitoredChains))
-	copy(chains, r.monitoredChains)
+	chains := slices.Clone(r.monitoredLocked())
 	r.mu.RUnlock()
 
 	var (
@@ -331,12 +599,12 @@ func (r *ChainRegistry) GetMainnetUpgrades() ([]*types.UpgradeInfo, error) {
 			semaphore <- struct{}{}        // Acquire semaphore
 			defer func() { <-semaphore }() // Release semaphore
 
-			info, err := r.GetUpgradeInfo(chain, false)
+			info, err := r.GetUpgradeInfo(ctx, chain, false)
 			if err != nil {
 				r.logger.Debugf("Skipping chain %q: %v", chain, err)
 				return
 			}
-			if info != nil && info.Network == "mainnet" {
+			if info.Network == "mainnet" {
 				mu.Lock()
 				upgrades = append(upgrades, info)
 				mu.Unlock()
@@ -348,11 +616,10 @@ func (r *ChainRegistry) GetMainnetUpgrades() ([]*types.UpgradeInfo, error) {
 	return upgrades, nil
 }
 
-func (r *ChainRegistry) GetTestnetUpgrades() ([]*types.UpgradeInfo, error) {
+func (r *ChainRegistry) GetTestnetUpgrades(ctx context.Context) ([]*types.UpgradeInfo, error) {
 	// Get a copy of monitored chains under lock
 	r.mu.RLock()
-	chains := make([]string, len(r.monitoredChains))
-	copy(chains, r.monitoredChains)
+	chains := slices.Clone(r.monitoredLocked())
 	r.mu.RUnlock()
 
 	var (
@@ -369,12 +636,12 @@ func (r *ChainRegistry) GetTestnetUpgrades() ([]*types.UpgradeInfo, error) {
 			semaphore <- struct{}{}        // Acquire semaphore
 			defer func() { <-semaphore }() // Release semaphore
 
-			info, err := r.GetUpgradeInfo(chain, false)
+			info, err := r.GetUpgradeInfo(ctx, chain, false)
 			if err != nil {
 				r.logger.Debugf("Skipping chain %q: %v", chain, err)
 				return
 			}
-			if info != nil && info.Network == "testnet" {
+			if info.Network == "testnet" {
 				mu.Lock()
 				upgrades = append(upgrades, info)
 				mu.Unlock()
@@ -386,73 +653,143 @@ func (r *ChainRegistry) GetTestnetUpgrades() ([]*types.UpgradeInfo, error) {
 	return upgrades, nil
 }
 
-func (r *ChainRegistry) GetChainInfo(chainName string, forceRefresh bool) (*ChainInfo, error) {
+func (r *ChainRegistry) GetChainInfo(ctx context.Context, chainName string, forceRefresh bool) (*ChainInfo, error) {
 	// Try to get from cache first if not forcing refresh
 	if !forceRefresh {
-		if cached, found := r.cache.Get(fmt.Sprintf(chainInfoCacheKey, chainName)); found {
+		if info, missing, found := r.cachedChainInfo(chainName); found {
 			r.logger.Debugf("Found cached chain info for %s", chainName)
-			if cached == nil {
-				return nil, fmt.Errorf("chain %q not found (cached result)", chainName)
+			r.metrics.recordCacheLookup(CacheEntityChainInfo, true)
+			if missing {
+				return nil, r.cachedChainInfoError(chainName)
 			}
-			return cached.(*ChainInfo), nil
+			return info, nil
 		}
 	}
 
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	result, err := r.shareFetch(ctx, CacheEntityChainInfo, chainName, func() (interface{}, error) {
+		// A fetch finishing since the lookup above has cached its result
+		if info, missing, found := r.cachedChainInfo(chainName); found && !forceRefresh {
+			if missing {
+				return nil, r.cachedChainInfoError(chainName)
+			}
+			return info, nil
+		}
+		return r.loadChainInfo(ctx, chainName, forceRefresh)
+	})
+	info, _ := result.(*ChainInfo)
+	return info, err
+}
 
-	if !forceRefresh {
-		if info, ok := r.chains[chainName]; ok {
+// loadChainInfo fetches the chain.json of the chain from the first registry directory holding it, or
+// serves the known info while degraded or between endpoint refreshes
+func (r *ChainRegistry) loadChainInfo(ctx context.Context, chainName string, forceRefresh bool) (*ChainInfo, error) {
+	known, isKnown := r.loadChain(chainName)
+	if r.IsDegraded() {
+		if info, missing, found := r.cachedChainInfo(chainName); found && !missing {
 			return info, nil
 		}
+		if isKnown {
+			return known, nil
+		}
+		return r.staleChainInfo(chainName, ErrDegraded)
 	}
 
-	// Try mainnet path first
-	mainnetURL := fmt.Sprintf("%s%s/%s/chain.json", r.githubAPIURL, r.chainRegistryURL, chainName)
-	r.logger.Debugf("Attempting to fetch chain info from mainnet registry: %s", mainnetURL)
-	info, err := r.fetchChainInfoFromURL(mainnetURL)
+	// Known chains are re-fetched once in a while so their endpoint lists follow the registry
+	refreshing := false
+	if !forceRefresh && isKnown {
+		if !r.claimEndpointRefresh(chainName, known) {
+			r.metrics.recordCacheLookup(CacheEntityChainInfo, true)
+			return known, nil
+		}
+		refreshing = true
+		r.logger.Debugf("Refreshing endpoints of %s from the chain-registry", chainName)
+	}
+
+	r.metrics.recordCacheLookup(CacheEntityChainInfo, false)
+	var (
+		info *ChainInfo
+		dir  registryDir
+		err  error
+	)
+	for _, dir = range r.registryDirs(chainName) {
+		var fetchErr error
+		if info, fetchErr = r.fetchChainInfoFromDir(ctx, dir); fetchErr == nil || ctx.Err() != nil {
+			err = fetchErr
+			break
+		}
+		// A missing chain.json in one directory says less than any other failure, which is reported
+		if err == nil || errors.Is(err, errNotFound) {
+			err = fetchErr
+		}
+	}
 	if err != nil {
-		// If mainnet fails, try testnet path
-		testnetURL := fmt.Sprintf("%s%s/testnets/%s/chain.json", r.githubAPIURL, r.chainRegistryURL, chainName)
-		r.logger.Debugf("Mainnet fetch failed, trying testnet registry: %s", testnetURL)
-		info, err = r.fetchChainInfoFromURL(testnetURL)
-		if err != nil {
-			// Cache the nil result to prevent repeated failed lookups
-			r.cache.Set(fmt.Sprintf(chainInfoCacheKey, chainName), nil, 5*time.Minute)
-
-			// Check if either error was due to network issues
-			if strings.Contains(err.Error(), "connection reset by peer") ||
-				strings.Contains(err.Error(), "no such host") ||
-				strings.Contains(err.Error(), "i/o timeout") {
-				return nil, fmt.Errorf("network error while fetching chain info for %q: %v", chainName, err)
-			}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if refreshing {
+			r.logger.Warnf("Failed to refresh endpoints of %s, keeping the known ones: %v", chainName, err)
+			r.recordError(chainName, errorSourceChainInfo, err)
+			return known, nil
+		}
+		if errors.Is(err, ErrDegraded) {
+			return r.staleChainInfo(chainName, err)
+		}
+		// A forced refresh that fails keeps the chain info it meant to replace, cached as it was
+		if forceRefresh && isKnown {
+			r.logger.Warnf("Failed to refresh chain info of %s, serving the known one: %v", chainName, err)
+			r.recordError(chainName, errorSourceChainInfo, err)
+			stale := *known
+			stale.Stale = true
+			return &stale, nil
+		}
 
-			// If chain is not found in either registry, provide a clear message
-			if strings.Contains(err.Error(), "404") {
-				return nil, fmt.Errorf("chain %q not found in either mainnet or testnet registry", chainName)
-			}
+		r.recordError(chainName, errorSourceChainInfo, err)
+		// Cache the failure to prevent repeated failed lookups
+		if stale, staleErr := r.cacheChainInfoFailure(chainName, err); staleErr == nil {
+			return stale, nil
+		}
 
-			return nil, fmt.Errorf("failed to fetch chain info for %q: %v", chainName, err)
+		if errors.Is(err, errNotFound) {
+			return nil, &ErrChainNotFound{Chain: chainName}
 		}
-		info.Network = "testnet"
-		r.logger.Debugf("Successfully fetched chain info for %q from testnet registry", chainName)
-	} else {
-		info.Network = "mainnet"
-		r.logger.Debugf("Successfully fetched chain info for %q from mainnet registry", chainName)
+		return nil, fmt.Errorf("failed to fetch chain info for %q: %w", chainName, err)
 	}
+	info.Network = dir.network
+	r.recordChainDir(chainName, dir)
+	r.logger.Debugf("Successfully fetched chain info for %q from %s registry", chainName, dir.network)
 
-	// Set the chain name if it's not already set
-	if info.Name == "" {
+	// Set the chain name if it's not already set, chains found under another name keep their configured one
+	if info.Name == "" || dir.name != chainName {
 		info.Name = chainName
 	}
 
-	r.chains[chainName] = info
-	// Cache the result
-	r.cache.Set(fmt.Sprintf(chainInfoCacheKey, chainName), info, 5*time.Minute)
+	r.storeChain(chainName, info)
+	r.clearError(chainName, errorSourceChainInfo)
 	return info, nil
 }
 
-func (r *ChainRegistry) fetchChainInfo(chainName string) (*ChainInfo, error) {
+// loadChain returns the chain info last fetched for chainName. It is shared with other callers and
+// must not be modified.
+func (r *ChainRegistry) loadChain(chainName string) (*ChainInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.chains[chainName]
+	return info, ok && info != nil
+}
+
+// storeChain records and caches freshly fetched chain info, which must not be shared yet. Until the
+// chain's height is read again, the latest block recorded for it is carried over.
+func (r *ChainRegistry) storeChain(chainName string, info *ChainInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if known, ok := r.chains[chainName]; ok && known != nil && info.Height == 0 {
+		info.Height, info.LatestBlockTime = known.Height, known.LatestBlockTime
+	}
+	r.chains[chainName] = info
+	r.cacheChainInfo(chainName, info)
+}
+
+func (r *ChainRegistry) fetchChainInfo(ctx context.Context, chainName string) (*ChainInfo, error) {
 	// Store original chain name for error messages
 	originalName := chainName
 
@@ -462,42 +799,20 @@ func (r *ChainRegistry) fetchChainInfo(chainName string) (*ChainInfo, error) {
 		return nil, fmt.Errorf("invalid chain name: %q", originalName)
 	}
 
-	chainBaseName, network, exists := r.tryChainNameVariations(chainName)
-	if !exists {
-		if strings.HasSuffix(chainName, "testnet") {
-			baseChainName := strings.TrimSuffix(chainName, "testnet")
-			chainBaseName, network, exists = r.tryChainNameVariations(baseChainName)
-		}
-
-		if !exists {
-			r.logger.Infof("Chain %q not found in chain-registry. Tried variations: %q, %q",
-				chainName,
-				chainName,
-				strings.TrimSuffix(chainName, "testnet"))
-			return nil, fmt.Errorf("chain %q not found in chain-registry", chainName)
-		}
-	}
-
-	var url string
-	if network == "mainnet" {
-		url = fmt.Sprintf("%s/%s/%s/chain.json",
-			strings.TrimRight(r.githubAPIURL, "/"),
-			strings.TrimLeft(r.chainRegistryURL, "/"),
-			chainBaseName)
-	} else {
-		url = fmt.Sprintf("%s/%s/testnets/%s/chain.json",
-			strings.TrimRight(r.githubAPIURL, "/"),
-			strings.TrimLeft(r.chainRegistryURL, "/"),
-			chainBaseName)
+	dir, err := r.findChainDir(ctx, chainName)
+	if err != nil {
+		return nil, err
 	}
 
-	chainInfo, err := r.fetchChainInfoFromURL(url)
+	chainInfo, err := r.fetchChainInfoFromDir(ctx, dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch chain info for %q from %s: %v", chainName, network, err)
+		return nil, fmt.Errorf("failed to fetch chain info for %q from %s: %w", chainName, dir.network, err)
 	}
 
-	chainInfo.Network = network
-	if chainInfo.Name == "" {
+	// The network is the one of the directory the chain was found in, whatever its config says
+	chainInfo.Network = dir.network
+	r.recordChainDir(chainName, dir)
+	if chainInfo.Name == "" || dir.name != chainName {
 		chainInfo.Name = chainName
 	}
 
@@ -530,204 +845,190 @@ func (r *ChainRegistry) cleanChainName(name string) string {
 	return name
 }
 
-func (r *ChainRegistry) tryChainNameVariations(chainName string) (string, string, bool) {
+// tryChainNameVariations returns the directory findChainDir finds, reporting whether it found one
+func (r *ChainRegistry) tryChainNameVariations(ctx context.Context, chainName string) (registryDir, bool) {
+	dir, err := r.findChainDir(ctx, chainName)
+	return dir, err == nil
+}
+
+// findChainDir returns the first registry directory of the chain that exists, trying the name without
+// a numeric suffix (e.g. "osmosis-1" -> "osmosis") after the directories of chains without a
+// registry_name. Over HTTP each directory is probed within chainProbeTimeout of its own, whatever
+// earlier probes took. The chain is only reported missing, with ErrChainNotFound, when every
+// directory answered that it does not hold it; a probe that failed otherwise returns its error, so an
+// outage is not mistaken for a missing chain.
+func (r *ChainRegistry) findChainDir(ctx context.Context, chainName string) (registryDir, error) {
 	// Clean up chain name
 	chainName = r.cleanChainName(chainName)
 	if chainName == "" {
-		return "", "", false
+		return registryDir{}, &ErrChainNotFound{Chain: chainName}
 	}
 
-	// Ensure base URLs are properly formatted
-	githubBase := strings.TrimRight(r.githubAPIURL, "/")
-	registryBase := strings.TrimLeft(r.chainRegistryURL, "/")
-
-	// Try mainnet first
-	mainnetURL := fmt.Sprintf("%s/%s/%s/chain.json", githubBase, registryBase, chainName)
-	r.logger.Debugf("Checking mainnet URL: %s", mainnetURL)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, "HEAD", mainnetURL, nil)
-	if err != nil {
-		r.logger.Debugf("Failed to create request for mainnet URL: %v", err)
-		return "", "", false
+	dirs := r.registryDirs(chainName)
+	if baseName, ok := trimNumericSuffix(chainName); ok && r.registryName(chainName) == chainName {
+		for _, dir := range dirs {
+			if dir.name == chainName {
+				dirs = append(dirs, registryDir{baseName, dir.network})
+			}
+		}
 	}
 
-	resp, err := r.client.Do(req)
-	if err == nil && resp.StatusCode == http.StatusOK {
-		resp.Body.Close()
-		r.logger.Debugf("Found chain %q in mainnet registry", chainName)
-		return chainName, "mainnet", true
-	}
-	if resp != nil {
-		resp.Body.Close()
+	var probeErr error
+	variations := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		r.logger.Debugf("Checking %s directory %s", dir.network, dir.path())
+		exists, err := r.source.exists(ctx, dir)
+		if err == nil && exists {
+			r.logger.Debugf("Found chain %q in %s registry as %q", chainName, dir.network, dir.name)
+			return dir, nil
+		}
+		if err != nil {
+			probeErr = err
+		}
+		variations = append(variations, strings.TrimPrefix(dir.path(), "/"))
 	}
 
-	// Try testnet
-	testnetURL := fmt.Sprintf("%s/%s/testnets/%s/chain.json", githubBase, registryBase, chainName)
-	r.logger.Debugf("Checking testnet URL: %s", testnetURL)
-
-	req, err = http.NewRequestWithContext(ctx, "HEAD", testnetURL, nil)
-	if err != nil {
-		r.logger.Debugf("Failed to create request for testnet URL: %v", err)
-		return "", "", false
+	if probeErr != nil {
+		r.logger.Debugf("Could not look up chain %q in chain registry: %v", chainName, probeErr)
+		return registryDir{}, fmt.Errorf("failed to look up chain %q in the chain registry: %w", chainName, probeErr)
 	}
+	// Log all attempted variations at once
+	r.logger.Infof("Chain %q not found in chain registry. Attempted variations: %s", chainName, strings.Join(variations, ", "))
+	return registryDir{}, &ErrChainNotFound{Chain: chainName}
+}
 
-	resp, err = r.client.Do(req)
-	if err == nil && resp.StatusCode == http.StatusOK {
-		resp.Body.Close()
-		r.logger.Debugf("Found chain %q in testnet registry", chainName)
-		return chainName, "testnet", true
+// trimNumericSuffix returns name without a numeric suffix such as the "-1" of "osmosis-1", reporting
+// whether it had one
+func trimNumericSuffix(name string) (string, bool) {
+	idx := strings.LastIndexAny(name, "-_")
+	if idx == -1 {
+		return name, false
 	}
-	if resp != nil {
-		resp.Body.Close()
+	if _, err := strconv.Atoi(name[idx+1:]); err != nil {
+		return name, false
 	}
+	return name[:idx], true
+}
 
-	// Try removing numeric suffixes (e.g., "osmosis-1" -> "osmosis")
-	baseChainName := chainName
-	if idx := strings.LastIndexAny(chainName, "-_"); idx != -1 {
-		suffix := chainName[idx+1:]
-		if _, err := strconv.Atoi(suffix); err == nil {
-			baseChainName = chainName[:idx]
-			r.logger.Debugf("Trying base chain name without numeric suffix: %q", baseChainName)
-
-			// Try mainnet with base name
-			mainnetURL = fmt.Sprintf("%s/%s/%s/chain.json", githubBase, registryBase, baseChainName)
-			r.logger.Debugf("Checking mainnet URL with base name: %s", mainnetURL)
-
-			req, err = http.NewRequestWithContext(ctx, "HEAD", mainnetURL, nil)
-			if err != nil {
-				r.logger.Debugf("Failed to create request for base name mainnet URL: %v", err)
-				return "", "", false
-			}
-
-			resp, err = r.client.Do(req)
-			if err == nil && resp.StatusCode == http.StatusOK {
-				resp.Body.Close()
-				r.logger.Debugf("Found chain %q in mainnet registry using base name %q", chainName, baseChainName)
-				return baseChainName, "mainnet", true
-			}
-			if resp != nil {
-				resp.Body.Close()
-			}
-
-			// Try testnet with base name
-			testnetURL = fmt.Sprintf("%s/%s/testnets/%s/chain.json", githubBase, registryBase, baseChainName)
-			r.logger.Debugf("Checking testnet URL with base name: %s", testnetURL)
-
-			req, err = http.NewRequestWithContext(ctx, "HEAD", testnetURL, nil)
-			if err != nil {
-				r.logger.Debugf("Failed to create request for base name testnet URL: %v", err)
-				return "", "", false
-			}
-
-			resp, err = r.client.Do(req)
-			if err == nil && resp.StatusCode == http.StatusOK {
-				resp.Body.Close()
-				r.logger.Debugf("Found chain %q in testnet registry using base name %q", chainName, baseChainName)
-				return baseChainName, "testnet", true
-			}
-			if resp != nil {
-				resp.Body.Close()
-			}
+// fetchChainInfoFromDir fetches the info of the chain in dir from each chain info source in turn, for
+// as long as the previous source is unavailable
+func (r *ChainRegistry) fetchChainInfoFromDir(ctx context.Context, dir registryDir) (*ChainInfo, error) {
+	var (
+		info *ChainInfo
+		err  error
+	)
+	for i, source := range r.chainInfoSources() {
+		if i > 0 {
+			r.logger.Debugf("Chain info source unavailable (%v), trying %s", err, source)
+		}
+		switch source {
+		case ChainInfoSourceCosmosDirectory:
+			info, err = r.fetchCosmosDirectoryChain(ctx, dir)
+		default:
+			info, err = r.source.chainInfo(ctx, dir)
+		}
+		if err == nil || ctx.Err() != nil || !sourceUnavailable(err) {
+			break
 		}
 	}
+	if err == nil {
+		r.lastChainInfoFetch.Store(time.Now().UnixNano())
+	}
+	return info, err
+}
 
-	// Log all attempted variations at once
-	variations := []string{chainName}
-	if baseChainName != chainName {
-		variations = append(variations, baseChainName)
+// LastChainInfoFetch returns when chain info was last fetched from the chain-registry, its mirrors or
+// cosmos.directory, zero while no fetch has succeeded yet
+func (r *ChainRegistry) LastChainInfoFetch() time.Time {
+	if last := r.lastChainInfoFetch.Load(); last != 0 {
+		return time.Unix(0, last)
 	}
-	r.logger.Infof("Chain %q not found in chain registry. Attempted variations: %s", chainName, strings.Join(variations, ", "))
-	return "", "", false
+	return time.Time{}
+}
+
+// fetchChainInfoFromURL fetches the chain.json at url, or at the same path of the mirrors when the
+// registry times out, cannot be reached or answers 5xx
+func (r *ChainRegistry) fetchChainInfoFromURL(ctx context.Context, url string) (*ChainInfo, error) {
+	defer r.metrics.observeFetch(FetchSourceChainRegistry, time.Now())
+	return fromMirrors(ctx, r, url, func(url string) (*ChainInfo, error) {
+		return r.fetchChainInfoCopy(ctx, url)
+	})
 }
 
-func (r *ChainRegistry) fetchChainInfoFromURL(url string) (*ChainInfo, error) {
-	resp, err := http.Get(url)
+func (r *ChainRegistry) fetchChainInfoCopy(ctx context.Context, url string) (*ChainInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := r.conditionalRequest(req)
+	resp, err := r.doWithRetry(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP request failed with status code: %d", resp.StatusCode)
+	if r.notModified(req, resp, previous) {
+		r.logger.Debugf("Chain info at %s not modified, reusing cached copy", url)
+		chainInfo := previous.chainInfo
+		chainInfo.LastUpdated = time.Now()
+		return &chainInfo, nil
 	}
 
 	var chainInfo ChainInfo
-	if err := json.NewDecoder(resp.Body).Decode(&chainInfo); err != nil {
+	if err := decodeJSON(resp, &chainInfo); err != nil {
 		return nil, err
 	}
+	chainInfo.LastUpdated = time.Now()
 
+	r.storeValidators(req, resp, conditionalEntry{chainInfo: chainInfo})
 	return &chainInfo, nil
 }
 
-func (r *ChainRegistry) fetchPolkachuUpgrades(chainName string) (*PolkachuUpgrade, error) {
-	polkachuURL := "https://polkachu.com/api/v2/chain_upgrades"
-	resp, err := r.client.Get(polkachuURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch from Polkachu API: %w", err)
-	}
-	defer resp.Body.Close()
+// errNotFound is the error of decodeJSON for 404 responses
+var errNotFound = errors.New("HTTP request failed with status code: 404")
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("polkachu API returned non-200 status code: %d", resp.StatusCode)
+// decodeJSON decodes the body of a 200 response into v. Any other status is returned as an error, as
+// is an HTML body.
+func decodeJSON(resp *http.Response, v interface{}) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
 	}
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	if resp.StatusCode != http.StatusOK {
+		return &statusError{code: resp.StatusCode}
 	}
-
-	// Check if response looks like HTML
-	if strings.HasPrefix(strings.TrimSpace(string(bodyBytes)), "<") {
-		return nil, fmt.Errorf("received HTML response instead of JSON")
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		if contentType := resp.Header.Get("Content-Type"); strings.Contains(contentType, "html") {
+			return &ErrBadResponse{ContentType: contentType}
+		}
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
+	return nil
+}
 
-	// First try to unmarshal as direct array
-	var upgrades []PolkachuUpgrade
-	err = json.Unmarshal(bodyBytes, &upgrades)
+// fetchPolkachuUpgrades returns Polkachu's upgrade of the chain, matching its polkachu_name or its name
+// against the network slug and the chain name of each entry. When several entries match, the one with
+// the nearest future block is used.
+func (r *ChainRegistry) fetchPolkachuUpgrades(ctx context.Context, chainName string) (*PolkachuUpgrade, error) {
+	index, err := r.polkachuIndex(ctx)
 	if err != nil {
-		// If direct array fails, try wrapped response
-		var response PolkachuResponse
-		if err := json.Unmarshal(bodyBytes, &response); err != nil {
-			r.logger.WithFields(logrus.Fields{
-				"chain": chainName,
-				"error": err,
-				"body":  string(bodyBytes[:min(len(bodyBytes), 1000)]), // Log first 1000 chars of response
-			}).Debug("Failed to parse Polkachu response")
-			return nil, fmt.Errorf("failed to parse Polkachu response: %w", err)
-		}
-		upgrades = response.Data
+		return nil, err
 	}
-
-	// Log the number of upgrades found
-	r.logger.WithFields(logrus.Fields{
-		"chain":          chainName,
-		"upgrades_count": len(upgrades),
-	}).Debug("Retrieved upgrades from Polkachu")
-
-	// Find matching upgrade
-	for _, upgrade := range upgrades {
-		// Try exact match first
-		if upgrade.ChainName == chainName {
-			return &upgrade, nil
-		}
-
-		// Try case-insensitive match
-		if strings.EqualFold(upgrade.ChainName, chainName) {
-			r.logger.WithFields(logrus.Fields{
-				"chain":          chainName,
-				"polkachu_chain": upgrade.ChainName,
-				"version":        upgrade.NodeVersion,
-				"block":          upgrade.Block,
-				"estimated_time": upgrade.EstimatedUpgradeTime,
-			}).Debug("Found chain with case-insensitive match")
-			return &upgrade, nil
-		}
+	candidates := index[normalizePolkachuName(r.polkachuName(chainName))]
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("no upgrade found for chain %s", chainName)
+	case 1:
+		return candidates[0], nil
 	}
-
-	return nil, fmt.Errorf("no upgrade found for chain %s", chainName)
+	upgrade := nearestPolkachuUpgrade(candidates, time.Now())
+	r.logger.WithFields(logrus.Fields{
+		"chain":   chainName,
+		"matches": len(candidates),
+		"network": upgrade.Network,
+		"block":   upgrade.Block,
+	}).Warn("Several Polkachu upgrades match the chain, using the nearest one; set polkachu_name in chains.yaml to pick another")
+	return upgrade, nil
 }
 
 func min(a, b int) int {
@@ -748,7 +1049,7 @@ func (r *ChainRegistry) GetAllChains() ([]string, error) {
 	return chains, nil
 }
 
-func (r *ChainRegistry) GetUpgrades(chainID string) ([]Upgrade, error) {
+func (r *ChainRegistry) GetUpgrades(ctx context.Context, chainID string) ([]Upgrade, error) {
 	chains, err := r.GetMonitoredChains()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get monitored chains: %w", err)
@@ -756,7 +1057,7 @@ func (r *ChainRegistry) GetUpgrades(chainID string) ([]Upgrade, error) {
 
 	var upgrades []Upgrade
 	for _, chainName := range chains {
-		chainInfo, err := r.GetChainInfo(chainName, false)
+		chainInfo, err := r.GetChainInfo(ctx, chainName, false)
 		if err != nil {
 			r.logger.Warnf("Failed to get chain info for %s: %v", chainName, err)
 			continue
@@ -766,15 +1067,19 @@ func (r *ChainRegistry) GetUpgrades(chainID string) ([]Upgrade, error) {
 			continue
 		}
 
-		upgradeInfo, err := r.GetUpgradeInfo(chainName, false)
+		upgradeInfo, err := r.GetUpgradeInfo(ctx, chainName, false)
+		if errors.Is(err, ErrNoUpgrade) {
+			r.logger.Debugf("No upgrade scheduled for %s", chainName)
+			continue
+		}
 		if err != nil {
 			r.logger.Warnf("Failed to get upgrade info for %s: %v", chainName, err)
 			continue
 		}
 
-		if upgradeInfo != nil {
-			upgrades = append(upgrades, upgradeInfo)
-		}
+		withStatus := *upgradeInfo
+		withStatus.Status = r.UpgradeStatus(chainName, upgradeInfo)
+		upgrades = append(upgrades, &withStatus)
 	}
 
 	return upgrades, nil
@@ -786,86 +1091,246 @@ func (r *ChainRegistry) SetMonitoredChains(chains []string) {
 	r.monitoredChains = chains
 }
 
-func (r *ChainRegistry) ChainExists(chainName string) bool {
-	if chainName == "" {
-		return false
+// AddMonitoredChain appends the chain of cfg to the configured chains and records cfg as its entry,
+// reporting false without changing anything when the chain is already configured. An auto-discovered
+// chain becomes a configured one. The next SetMonitoredChains, such as the load-chains job reloading
+// chains.yaml, drops chains added this way unless the file lists them.
+func (r *ChainRegistry) AddMonitoredChain(cfg types.ChainConfig) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.Contains(r.monitoredChains, cfg.Name) {
+		return false, nil
+	}
+	if err := r.validateProviderOrder(cfg.Sources); err != nil {
+		return false, fmt.Errorf("invalid sources of chain %s: %w", cfg.Name, err)
 	}
 
-	mainnetURL := fmt.Sprintf("%s%s/%s/chain.json", r.githubAPIURL, r.chainRegistryURL, chainName)
-	resp, err := r.client.Head(mainnetURL)
-	if err == nil && resp.StatusCode == http.StatusOK {
-		resp.Body.Close()
-		return true
+	configs := make(map[string]types.ChainConfig, len(r.chainConfigs)+1)
+	for name, existing := range r.chainConfigs {
+		configs[name] = existing
 	}
-	if resp != nil {
-		resp.Body.Close()
+	configs[cfg.Name] = cfg
+	r.chainConfigs = configs
+	r.SetChainAliases(slices.Collect(maps.Values(configs)))
+	// Copied so the slice given to SetMonitoredChains is never appended to
+	r.monitoredChains = append(slices.Clone(r.monitoredChains), cfg.Name)
+	return true, nil
+}
+
+// SetChainConfigs records the chains.yaml entries (display name, network, endpoint overrides, upgrade
+// sources, registry and Polkachu names) for monitored chains. It fails, keeping the previous entries,
+// when a chain lists an unknown upgrade source.
+func (r *ChainRegistry) SetChainConfigs(configs []types.ChainConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cfg := range configs {
+		if err := r.validateProviderOrder(cfg.Sources); err != nil {
+			return fmt.Errorf("invalid sources of chain %s: %w", cfg.Name, err)
+		}
+	}
+	r.chainConfigs = make(map[string]types.ChainConfig, len(configs))
+	for _, cfg := range configs {
+		r.chainConfigs[cfg.Name] = cfg
+	}
+	r.SetChainAliases(configs)
+	return nil
+}
+
+func (r *ChainRegistry) GetChainConfig(chainName string) (types.ChainConfig, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cfg, ok := r.chainConfigs[chainName]
+	return cfg, ok
+}
+
+// ChainExists reports whether the chain has a chain.json in the chain-registry. Cached chain info, or
+// a cached failed lookup of it, answers without a request. Otherwise the registry directories are
+// probed with HEAD requests and the answer is cached: a chain found for SetChainExistsTTL, a chain
+// missing from every directory for the negative cache TTL. Probes that fail are not cached.
+func (r *ChainRegistry) ChainExists(ctx context.Context, chainName string) bool {
+	exists, _ := r.chainExists(ctx, chainName)
+	return exists
+}
+
+// chainExists is ChainExists returning the error of the probes when the chain was not found in any
+// directory and at least one probe failed, so whether it exists is unknown
+func (r *ChainRegistry) chainExists(ctx context.Context, chainName string) (bool, error) {
+	if chainName == "" {
+		return false, nil
 	}
 
-	testnetURL := fmt.Sprintf("%s%s/testnets/%s/chain.json", r.githubAPIURL, r.chainRegistryURL, chainName)
-	resp, err = r.client.Head(testnetURL)
-	if err == nil && resp.StatusCode == http.StatusOK {
-		resp.Body.Close()
-		return true
+	if _, missing, found := r.cachedChainInfo(chainName); found {
+		r.metrics.recordCacheLookup(CacheEntityChainExists, true)
+		if !missing {
+			return true, nil
+		}
+		err := r.cachedChainInfoError(chainName)
+		var missingErr *ErrChainNotFound
+		if errors.As(err, &missingErr) {
+			return false, nil
+		}
+		return false, err
+	}
+	cacheKey := fmt.Sprintf(chainExistsCacheKey, chainName)
+	if exists, _, found := cacheLookup[bool](r, cacheKey); found {
+		r.metrics.recordCacheLookup(CacheEntityChainExists, true)
+		return exists, nil
+	}
+	r.metrics.recordCacheLookup(CacheEntityChainExists, false)
+
+	var probeErr error
+	for _, dir := range r.registryDirs(chainName) {
+		exists, err := r.source.exists(ctx, dir)
+		if exists {
+			r.cache.Set(cacheKey, true, time.Duration(r.chainExistsTTL.Load()))
+			return true, nil
+		}
+		if err != nil && probeErr == nil {
+			probeErr = err
+		}
 	}
-	if resp != nil {
-		resp.Body.Close()
+
+	// Only a chain every directory is known to be missing from is cached as missing
+	if probeErr == nil {
+		r.cache.Set(cacheKey, false, time.Duration(r.negativeCacheTTL.Load()))
 	}
+	return false, probeErr
+}
+
+// defaultExistsCheckConcurrency is how many chains FilterExistingChains checks at once, each with up to
+// two HEAD requests
+const defaultExistsCheckConcurrency = 8
 
-	return false
+// ChainCheckFailure is a chain FilterExistingChains could not tell exists or not
+type ChainCheckFailure struct {
+	Chain string
+	Err   error
 }
 
-func (r *ChainRegistry) FilterExistingChains(chains []string) []string {
-	var existingChains []string
-	for _, chain := range chains {
-		if r.ChainExists(chain) {
-			existingChains = append(existingChains, chain)
+// SetExistsCheckConcurrency sets how many chains FilterExistingChains checks at once. Zero or negative
+// keeps the default of 8.
+func (r *ChainRegistry) SetExistsCheckConcurrency(n int) {
+	if n <= 0 {
+		n = defaultExistsCheckConcurrency
+	}
+	r.existsCheckConcurrency.Store(int32(n))
+}
+
+// FilterExistingChains returns the chains that exist in the chain-registry, in the order given,
+// checking a few of them at a time with ChainExists and so answering from its cache when it can.
+// Chains whose probes failed, or that were not checked before ctx was done, are left out and returned
+// as failures instead, in the order given too; chains known to be missing are simply left out.
+func (r *ChainRegistry) FilterExistingChains(ctx context.Context, chains []string) ([]string, []ChainCheckFailure) {
+	type result struct {
+		exists bool
+		err    error
+	}
+	results := make([]result, len(chains))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(int(r.existsCheckConcurrency.Load()), len(chains)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[i].err = err
+					continue
+				}
+				exists, err := r.chainExists(ctx, chains[i])
+				if err != nil && ctx.Err() != nil {
+					err = ctx.Err()
+				}
+				results[i] = result{exists: exists, err: err}
+			}
+		}()
+	}
+	for i := range chains {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var (
+		existingChains []string
+		failures       []ChainCheckFailure
+	)
+	for i, res := range results {
+		switch {
+		case res.exists:
+			existingChains = append(existingChains, chains[i])
+		case res.err != nil:
+			failures = append(failures, ChainCheckFailure{Chain: chains[i], Err: res.err})
 		}
 	}
-	return existingChains
+	return existingChains, failures
+}
+
+func (r *ChainRegistry) getUpgradeInfoFromChain(ctx context.Context, chainName string) (*types.UpgradeInfo, error) {
+	return r.source.upgradeInfo(ctx, r.chainDir(chainName))
 }
 
-func (r *ChainRegistry) getUpgradeInfoFromChain(chainName string) (*types.UpgradeInfo, error) {
-	url := fmt.Sprintf("%s%s/%s/upgrades.json", r.githubAPIURL, r.chainRegistryURL, chainName)
-	resp, err := r.client.Get(url)
+func (r *ChainRegistry) fetchUpgradesCopy(ctx context.Context, url string) (*types.UpgradeInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	previous := r.conditionalRequest(req)
+	resp, err := r.doWithRetry(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP request failed with status code: %d", resp.StatusCode)
+	if r.notModified(req, resp, previous) {
+		r.logger.Debugf("Upgrade info at %s not modified, reusing cached copy", url)
+		upgradeInfo := previous.upgradeInfo
+		return &upgradeInfo, nil
+	}
+	// Most chains publish no upgrades.json at all, which means no upgrade rather than a failure
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
 	}
-
 	var upgradeInfo types.UpgradeInfo
-	if err := json.NewDecoder(resp.Body).Decode(&upgradeInfo); err != nil {
+	if err := decodeJSON(resp, &upgradeInfo); err != nil {
 		return nil, err
 	}
 
+	r.storeValidators(req, resp, conditionalEntry{upgradeInfo: upgradeInfo})
 	return &upgradeInfo, nil
 }
 
 func (r *ChainRegistry) convertUpgradeInfo(chainName string, chain *ChainInfo, upgrade interface{}) *types.UpgradeInfo {
+	var upgradeInfo *types.UpgradeInfo
 	switch u := upgrade.(type) {
 	case *types.UpgradeInfo:
-		return &types.UpgradeInfo{
+		repo := u.Repo
+		if repo == "" && chain != nil {
+			repo = chain.Codebase.GitRepo
+		}
+		upgradeInfo = &types.UpgradeInfo{
 			Name:             u.Name,
 			ChainName:        chainName,
 			Height:           u.Height,
 			Info:             u.Info,
 			Time:             u.Time,
-			Version:          u.Name,
+			Version:          u.Version,
 			Estimated:        true,
 			Network:          chain.Network,
 			ProposalLink:     u.ProposalLink,
 			Guide:            u.Info,
 			BlockLink:        "",
 			CosmovisorFolder: fmt.Sprintf("upgrades/%s", u.Name),
-			GitHash:          "",
-			Repo:             "",
+			GitHash:          u.GitHash,
+			Repo:             repo,
 			RPC:              "",
 			API:              "",
 		}
 	case *PolkachuUpgrade:
+		repo := u.Repo
+		if repo == "" && chain != nil {
+			repo = chain.Codebase.GitRepo
+		}
 		estimatedTime, err := time.Parse(time.RFC3339, u.EstimatedUpgradeTime)
 		if err != nil {
 			r.logger.Warnf("Failed to parse time from Polkachu response: %v", err)
@@ -886,7 +1351,7 @@ func (r *ChainRegistry) convertUpgradeInfo(chainName string, chain *ChainInfo, u
 			"api":            u.API,
 		}).Debug("Processing Polkachu upgrade info")
 
-		return &types.UpgradeInfo{
+		upgradeInfo = &types.UpgradeInfo{
 			Name:             chainName,
 			ChainName:        chainName,
 			Height:           u.Block,
@@ -900,16 +1365,18 @@ func (r *ChainRegistry) convertUpgradeInfo(chainName string, chain *ChainInfo, u
 			BlockLink:        u.BlockLink,
 			CosmovisorFolder: u.CosmovisorFolder,
 			GitHash:          u.GitHash,
-			Repo:             u.Repo,
+			Repo:             repo,
 			RPC:              u.RPC,
 			API:              u.API,
 		}
 	default:
 		return nil
 	}
+	r.fillExplorerLinks(chain, upgradeInfo)
+	return upgradeInfo
 }
 
 func (r *ChainRegistry) IsUpgradeCached(chainName string) bool {
-	_, found := r.cache.Get(fmt.Sprintf("upgrade_info:%s", chainName))
+	_, _, found := r.cachedUpgradeInfo(chainName)
 	return found
 }
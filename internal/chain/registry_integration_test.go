@@ -1,6 +1,7 @@
 package chain
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -45,11 +46,11 @@ func TestChainRegistry_ChainExists(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			exists := registry.ChainExists(tc.chainName)
+			exists := registry.ChainExists(context.Background(), tc.chainName)
 			assert.Equal(t, tc.shouldExist, exists, "ChainExists returned unexpected result")
 
 
-			info, err := registry.GetChainInfo(tc.chainName, false)
+			info, err := registry.GetChainInfo(context.Background(), tc.chainName, false)
 			if tc.expectedError {
 				assert.Error(t, err)
 				assert.Nil(t, info)
@@ -62,7 +63,8 @@ func TestChainRegistry_ChainExists(t *testing.T) {
 	}
 
 	chainsToValidate := []string{"osmosis", "astriamainnet", "cosmoshub", "berachain"}
-	existingChains := registry.FilterExistingChains(chainsToValidate)
+	existingChains, failures := registry.FilterExistingChains(context.Background(), chainsToValidate)
+	assert.Empty(t, failures)
 
 	assert.Equal(t, 2, len(existingChains))
 	assert.Contains(t, existingChains, "osmosis")
@@ -109,12 +111,15 @@ func TestChainRegistry_NonExistentChains(t *testing.T) {
 
 	for _, tc := range nonExistentChains {
 		t.Run(tc.name, func(t *testing.T) {
-			info, err := registry.GetChainInfo(tc.name, false)
+			info, err := registry.GetChainInfo(context.Background(), tc.name, false)
 			assert.Error(t, err)
 			assert.Nil(t, info)
-			assert.Contains(t, err.Error(), "404 Not Found")
+			var notFound *ErrChainNotFound
+			if assert.ErrorAs(t, err, &notFound) {
+				assert.Equal(t, tc.name, notFound.Chain)
+			}
 
-			upgradeInfo, err := registry.GetUpgradeInfo(tc.name, false)
+			upgradeInfo, err := registry.GetUpgradeInfo(context.Background(), tc.name, false)
 			assert.Error(t, err)
 			assert.Nil(t, upgradeInfo)
 		})
@@ -131,7 +136,7 @@ func TestChainRegistry_NonExistentChains(t *testing.T) {
 
 	for _, tc := range existingChains {
 		t.Run(tc.name, func(t *testing.T) {
-			info, err := registry.GetChainInfo(tc.name, true)
+			info, err := registry.GetChainInfo(context.Background(), tc.name, true)
 			if err != nil {
 				t.Logf("Warning: Failed to fetch chain info for %s: %v", tc.name, err)
 				t.SkipNow()
@@ -154,11 +159,11 @@ func TestChainRegistry_ErrorHandling(t *testing.T) {
 
 	registry := NewChainRegistry(logger, "https://invalid.example.com", "/invalid/path")
 
-	info, err := registry.GetChainInfo("nonexistentchain", false)
+	info, err := registry.GetChainInfo(context.Background(), "nonexistentchain", false)
 	assert.Error(t, err)
 	assert.Nil(t, info)
 
-	upgradeInfo, err := registry.GetUpgradeInfo("nonexistentchain", false)
+	upgradeInfo, err := registry.GetUpgradeInfo(context.Background(), "nonexistentchain", false)
 	assert.Error(t, err)
 	assert.Nil(t, upgradeInfo)
 
@@ -0,0 +1,95 @@
+package chain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultRegistryRef is the chain-registry branch read unless another ref is pinned
+const DefaultRegistryRef = "master"
+
+// registryRefCacheKeys are the cache entries of a chain read from the chain-registry files, which
+// another ref may hold differently
+var registryRefCacheKeys = []string{
+	chainInfoCacheKey,
+	upgradeInfoCacheKey,
+	chainExistsCacheKey,
+	assetListCacheKey,
+	versionsCacheKey,
+}
+
+// SetRegistryRef pins the chain-registry to ref, a branch, tag or commit SHA put in place of the ref
+// of the registry URL, so the data the watcher saw can be reproduced. An empty ref goes back to the
+// ref of the registry URL. Changing the ref drops the cached chain-registry data of every chain the
+// registry knows. Mirrors and a local registry keep the ref they were set up with.
+func (r *ChainRegistry) SetRegistryRef(ref string) error {
+	ref = strings.Trim(strings.TrimSpace(ref), "/")
+	path := r.chainRegistryURL
+	if ref != "" {
+		owner, repo, _, err := parseRegistryRepo(r.chainRegistryURL)
+		if err != nil {
+			return fmt.Errorf("cannot pin the chain-registry ref: %w", err)
+		}
+		path = "/" + owner + "/" + repo + "/" + ref
+	}
+
+	if previous := r.registryPath.Swap(&path); previous != nil && *previous != path {
+		r.logger.Infof("Chain-registry ref changed, reading %s", path)
+		r.invalidateRegistryData()
+	}
+	return nil
+}
+
+// RegistryRef returns the chain-registry ref being read
+func (r *ChainRegistry) RegistryRef() string {
+	if _, _, ref, err := parseRegistryRepo(*r.registryPath.Load()); err == nil {
+		return ref
+	}
+	return ""
+}
+
+// registryURL returns the URL of the chain-registry file or directory at the path made of parts, such
+// as registryURL("testnets/osmosistestnet", "chain.json"), at the current ref. Without parts it is
+// the base URL of the registry.
+func (r *ChainRegistry) registryURL(parts ...string) string {
+	url := strings.TrimRight(r.githubAPIURL, "/") + "/" + strings.Trim(*r.registryPath.Load(), "/")
+	for _, part := range parts {
+		if part = strings.Trim(part, "/"); part != "" {
+			url += "/" + part
+		}
+	}
+	return url
+}
+
+// invalidateRegistryData forgets the fetched chain info and drops the cached chain-registry data and
+// the directories chains were found in for the configured, monitored and fetched chains, along with
+// the discovered chain list
+func (r *ChainRegistry) invalidateRegistryData() {
+	chains := make(map[string]bool)
+	r.mu.Lock()
+	for _, name := range r.monitoredLocked() {
+		chains[name] = true
+	}
+	for name := range r.chains {
+		chains[name] = true
+	}
+	for name := range r.chainConfigs {
+		chains[name] = true
+	}
+	r.chains = make(map[string]*ChainInfo)
+	r.mu.Unlock()
+
+	r.aliasMu.Lock()
+	for name := range r.chainDirs {
+		chains[name] = true
+	}
+	r.chainDirs = nil
+	r.aliasMu.Unlock()
+
+	for name := range chains {
+		for _, keyFormat := range registryRefCacheKeys {
+			r.cache.Delete(fmt.Sprintf(keyFormat, name))
+		}
+	}
+	r.cache.Delete(discoveredChainsCacheKey)
+}
@@ -0,0 +1,113 @@
+package chain
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_RegistryURL(t *testing.T) {
+	const sha = "4f3c2a1b9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b"
+	tests := []struct {
+		name        string
+		ref         string
+		wantRef     string
+		wantMainnet string
+		wantTestnet string
+	}{
+		{
+			name:        "master",
+			wantRef:     "master",
+			wantMainnet: "https://raw.githubusercontent.com/cosmos/chain-registry/master/osmosis/chain.json",
+			wantTestnet: "https://raw.githubusercontent.com/cosmos/chain-registry/master/testnets/osmosistestnet/upgrades.json",
+		},
+		{
+			name:        "tag",
+			ref:         "v0.1.0",
+			wantRef:     "v0.1.0",
+			wantMainnet: "https://raw.githubusercontent.com/cosmos/chain-registry/v0.1.0/osmosis/chain.json",
+			wantTestnet: "https://raw.githubusercontent.com/cosmos/chain-registry/v0.1.0/testnets/osmosistestnet/upgrades.json",
+		},
+		{
+			name:        "commit SHA",
+			ref:         " " + sha + " ",
+			wantRef:     sha,
+			wantMainnet: "https://raw.githubusercontent.com/cosmos/chain-registry/" + sha + "/osmosis/chain.json",
+			wantTestnet: "https://raw.githubusercontent.com/cosmos/chain-registry/" + sha + "/testnets/osmosistestnet/upgrades.json",
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewChainRegistry(logger, "https://raw.githubusercontent.com/", "/cosmos/chain-registry/master/")
+			require.NoError(t, registry.SetRegistryRef(tt.ref))
+			assert.Equal(t, tt.wantRef, registry.RegistryRef())
+
+			source := &httpRegistrySource{registry: registry}
+			assert.Equal(t, tt.wantMainnet, source.fileURL(registryDir{"osmosis", networkMainnet}, "chain.json"))
+			assert.Equal(t, tt.wantTestnet, source.fileURL(registryDir{"osmosistestnet", networkTestnet}, "upgrades.json"))
+			assert.Equal(t, tt.wantMainnet, registry.registryURL("/osmosis/", "", "chain.json"))
+		})
+	}
+
+	t.Run("registry URL without a ref", func(t *testing.T) {
+		registry := NewChainRegistry(logger, "https://raw.githubusercontent.com", "/chain-registry")
+		assert.Error(t, registry.SetRegistryRef("v0.1.0"))
+		assert.Equal(t, "https://raw.githubusercontent.com/chain-registry/osmosis/chain.json", registry.registryURL("osmosis", "chain.json"))
+	})
+}
+
+func TestChainRegistry_SetRegistryRef(t *testing.T) {
+	chainJSON := map[string]string{
+		"/cosmos/chain-registry/master/osmosis/chain.json": `{"chain_name": "osmosis", "chain_id": "osmosis-1", "codebase": {"recommended_version": "v26.0.1"}}`,
+		"/cosmos/chain-registry/v0.1.0/osmosis/chain.json": `{"chain_name": "osmosis", "chain_id": "osmosis-1", "codebase": {"recommended_version": "v25.0.0"}}`,
+	}
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := chainJSON[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fetches.Add(1)
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, server.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetDegradedThreshold(-1)
+	ctx := context.Background()
+
+	info, err := registry.GetChainInfo(ctx, "osmosis", false)
+	require.NoError(t, err)
+	assert.Equal(t, "v26.0.1", info.Codebase.RecommendedVersion)
+
+	require.NoError(t, registry.SetRegistryRef("v0.1.0"))
+	info, err = registry.GetChainInfo(ctx, "osmosis", false)
+	require.NoError(t, err)
+	assert.Equal(t, "v25.0.0", info.Codebase.RecommendedVersion, "the cached info of the previous ref is dropped")
+
+	fetched := fetches.Load()
+	require.NoError(t, registry.SetRegistryRef("v0.1.0"))
+	_, err = registry.GetChainInfo(ctx, "osmosis", false)
+	require.NoError(t, err)
+	assert.Equal(t, fetched, fetches.Load(), "setting the same ref keeps the cache")
+
+	require.NoError(t, registry.SetRegistryRef(""))
+	assert.Equal(t, DefaultRegistryRef, registry.RegistryRef())
+	info, err = registry.GetChainInfo(ctx, "osmosis", false)
+	require.NoError(t, err)
+	assert.Equal(t, "v26.0.1", info.Codebase.RecommendedVersion)
+}
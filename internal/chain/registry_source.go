@@ -0,0 +1,102 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// registrySource reads the files of the chain-registry. The registry reads them over HTTP from GitHub
+// and its mirrors, or from a local clone of the repository given with WithLocalRegistry.
+type registrySource interface {
+	// chainInfo reads the chain.json in dir, errNotFound when there is none
+	chainInfo(ctx context.Context, dir registryDir) (*ChainInfo, error)
+	// upgradeInfo reads the upgrades.json in dir, nil when the chain publishes none
+	upgradeInfo(ctx context.Context, dir registryDir) (*types.UpgradeInfo, error)
+	// versions reads the versions.json in dir, ErrVersionsNotFound when there is none
+	versions(ctx context.Context, dir registryDir) (*VersionHistory, error)
+	// assetList reads the assetlist.json in dir, ErrAssetListNotFound when there is none
+	assetList(ctx context.Context, dir registryDir) (*AssetList, error)
+	// exists reports whether dir holds a chain.json, with an error when that could not be told
+	exists(ctx context.Context, dir registryDir) (bool, error)
+	// listChains returns the sorted chain directories of the network
+	listChains(ctx context.Context, network string) ([]string, error)
+}
+
+// isChainDirName reports whether a directory at the top level of the registry or of its testnets
+// directory holds a chain. Directories starting with "_" or "." hold registry metadata.
+func isChainDirName(name string) bool {
+	return name != "testnets" && !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, ".")
+}
+
+// httpRegistrySource reads the chain-registry from GitHub, falling back to the mirrors when it is
+// unavailable
+type httpRegistrySource struct {
+	registry *ChainRegistry
+}
+
+// fileURL returns the URL of the file in dir
+func (s *httpRegistrySource) fileURL(dir registryDir, file string) string {
+	return s.registry.registryURL(dir.path(), file)
+}
+
+func (s *httpRegistrySource) chainInfo(ctx context.Context, dir registryDir) (*ChainInfo, error) {
+	url := s.fileURL(dir, "chain.json")
+	s.registry.logger.Debugf("Attempting to fetch chain info from %s registry: %s", dir.network, url)
+	return s.registry.fetchChainInfoFromURL(ctx, url)
+}
+
+func (s *httpRegistrySource) upgradeInfo(ctx context.Context, dir registryDir) (*types.UpgradeInfo, error) {
+	return fromMirrors(ctx, s.registry, s.fileURL(dir, "upgrades.json"), func(url string) (*types.UpgradeInfo, error) {
+		return s.registry.fetchUpgradesCopy(ctx, url)
+	})
+}
+
+func (s *httpRegistrySource) versions(ctx context.Context, dir registryDir) (*VersionHistory, error) {
+	return fromMirrors(ctx, s.registry, s.fileURL(dir, "versions.json"), func(url string) (*VersionHistory, error) {
+		return s.registry.fetchVersions(ctx, url)
+	})
+}
+
+func (s *httpRegistrySource) assetList(ctx context.Context, dir registryDir) (*AssetList, error) {
+	return fromMirrors(ctx, s.registry, s.fileURL(dir, "assetlist.json"), func(url string) (*AssetList, error) {
+		return s.registry.fetchAssetList(ctx, url)
+	})
+}
+
+// exists sends a HEAD request for the chain.json, within chainProbeTimeout for each copy of the
+// registry asked
+func (s *httpRegistrySource) exists(ctx context.Context, dir registryDir) (bool, error) {
+	status, err := s.registry.headStatus(ctx, s.fileURL(dir, "chain.json"), s.registry.chainProbeTimeout)
+	switch {
+	case err != nil:
+		return false, err
+	case status == http.StatusOK:
+		return true, nil
+	case status == http.StatusNotFound:
+		return false, nil
+	}
+	return false, &statusError{code: status}
+}
+
+// listChains lists the registry repository through the GitHub contents API
+func (s *httpRegistrySource) listChains(ctx context.Context, network string) ([]string, error) {
+	r := s.registry
+	r.mu.RLock()
+	apiURL := r.discoveryAPIURL
+	r.mu.RUnlock()
+
+	owner, repo, ref, err := parseRegistryRepo(*r.registryPath.Load())
+	if err != nil {
+		return nil, err
+	}
+	path := ""
+	if network == networkTestnet {
+		path = "/testnets"
+	}
+	return r.listChainDirs(ctx, apiURL+fmt.Sprintf(contentsPath, owner, repo, path, url.QueryEscape(ref)))
+}
@@ -1,15 +1,22 @@
 package chain
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/patrickmn/go-cache"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestChainRegistry_GetChainInfo(t *testing.T) {
@@ -70,7 +77,7 @@ func TestChainRegistry_GetChainInfo(t *testing.T) {
 			logger := logrus.New()
 			registry := NewChainRegistry(logger, "https://api.github.com", ts.URL)
 
-			info, err := registry.GetChainInfo("testchain", false)
+			info, err := registry.GetChainInfo(context.Background(), "testchain", false)
 			if tt.expectedError {
 				assert.Error(t, err)
 				assert.Nil(t, info)
@@ -132,7 +139,7 @@ func TestChainRegistry_GetUpgradeInfo(t *testing.T) {
 				Network: "mainnet",
 			}, cache.DefaultExpiration)
 
-			upgrade, err := registry.GetUpgradeInfo("testchain", false)
+			upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", false)
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
@@ -225,3 +232,306 @@ func TestChainRegistry_GetAllChains(t *testing.T) {
 		})
 	}
 }
+
+func TestChainRegistry_MinTLSVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		expected uint16
+	}{
+		{"default", "", tls.VersionTLS12},
+		{"configured tls 1.3", "1.3", tls.VersionTLS13},
+		{"invalid falls back to default", "ssl3", tls.VersionTLS12},
+		{"tls 1.0 falls back to default", "1.0", tls.VersionTLS12},
+		{"tls 1.1 falls back to default", "TLS1.1", tls.VersionTLS12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TLS_MIN_VERSION", tt.env)
+
+			registry := NewChainRegistry(logrus.New(), "https://raw.githubusercontent.com", "/cosmos/chain-registry/master")
+
+			transport, ok := registry.client.Transport.(*http.Transport)
+			assert.True(t, ok, "expected *http.Transport")
+			assert.NotNil(t, transport.TLSClientConfig)
+			assert.Equal(t, tt.expected, transport.TLSClientConfig.MinVersion)
+		})
+	}
+}
+
+func TestChainRegistry_GetChainInfoConditionalFetch(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator string
+		value     string
+		condition string
+	}{
+		{"etag", "ETag", `"abc123"`, "If-None-Match"},
+		{"last modified", "Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT", "If-Modified-Since"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fullFetches, notModified int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(tt.condition) == tt.value {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				fullFetches++
+				w.Header().Set(tt.validator, tt.value)
+				json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+			}))
+			defer ts.Close()
+
+			registry := NewChainRegistry(logrus.New(), ts.URL, "/cosmos/chain-registry/master")
+
+			first, err := registry.GetChainInfo(context.Background(), "testchain", true)
+			assert.NoError(t, err)
+
+			second, err := registry.GetChainInfo(context.Background(), "testchain", true)
+			assert.NoError(t, err)
+
+			assert.Equal(t, 1, fullFetches, "chain.json should only be downloaded once")
+			assert.Equal(t, 1, notModified, "refetch should be answered with 304")
+			assert.Equal(t, first.ChainID, second.ChainID)
+			assert.Equal(t, "mainnet", second.Network)
+			assert.False(t, second.LastUpdated.Before(first.LastUpdated))
+		})
+	}
+}
+
+func TestChainRegistry_GetUpgradeInfoConditionalFetch(t *testing.T) {
+	upgradeTime := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	var fullFetches, notModified int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/testchain/chain.json"):
+			json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+		case strings.HasSuffix(r.URL.Path, "/testchain/upgrades.json"):
+			if r.Header.Get("If-None-Match") == `"v2"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			fullFetches++
+			w.Header().Set("ETag", `"v2"`)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"name":    "v2",
+				"version": "v2.0.0",
+				"height":  1000000,
+				"time":    upgradeTime,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, ts.URL, "/cosmos/chain-registry/master")
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+
+	first, err := registry.GetUpgradeInfo(context.Background(), "testchain", true)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+
+	second, err := registry.GetUpgradeInfo(context.Background(), "testchain", true)
+	require.NoError(t, err)
+	require.NotNil(t, second)
+
+	assert.Equal(t, 1, fullFetches, "upgrades.json should only be downloaded once")
+	assert.Equal(t, 1, notModified, "refetch should be answered with 304")
+	assert.Equal(t, "v2.0.0", second.Version)
+	assert.Equal(t, int64(1000000), second.Height)
+	assert.True(t, upgradeTime.Equal(second.Time))
+	assert.Equal(t, ConditionalFetchStats{Sent: 1, NotModified: 1}, registry.ConditionalFetchStats())
+}
+
+// The registry only fetches data; sending notifications is left to cron.UpgradeChecker
+func TestChainRegistry_DoesNotSendNotifications(t *testing.T) {
+	var slackRequests atomic.Int32
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slackRequests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slackServer.Close()
+	t.Setenv("SLACK_WEBHOOK_URL", slackServer.URL)
+
+	upgradeTime := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json"):
+			json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1", Network: "mainnet"})
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json"):
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"name":   "v2.0.0",
+				"height": 1000000,
+				"time":   upgradeTime,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+
+	info, err := registry.GetChainInfo(context.Background(), "testchain", false)
+	require.NoError(t, err)
+	assert.Equal(t, "testchain-1", info.ChainID)
+
+	upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", false)
+	require.NoError(t, err)
+	assert.Equal(t, "v2.0.0", upgrade.Version)
+	assert.Equal(t, int64(1000000), upgrade.Height)
+	assert.True(t, upgradeTime.Equal(upgrade.Time))
+
+	assert.Zero(t, slackRequests.Load(), "registry must not send notifications")
+}
+
+func TestChainRegistry_CancelledFetch(t *testing.T) {
+	tests := []struct {
+		name  string
+		slow  string
+		fetch func(ctx context.Context, registry *ChainRegistry) error
+	}{
+		{
+			name: "chain info",
+			slow: "/testchain/chain.json",
+			fetch: func(ctx context.Context, registry *ChainRegistry) error {
+				_, err := registry.GetChainInfo(ctx, "testchain", false)
+				return err
+			},
+		},
+		{
+			name: "upgrade info",
+			slow: "/testchain/upgrades.json",
+			fetch: func(ctx context.Context, registry *ChainRegistry) error {
+				upgrade, err := registry.GetUpgradeInfo(ctx, "testchain", false)
+				if err == nil && upgrade == nil {
+					return fmt.Errorf("no upgrade")
+				}
+				return err
+			},
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				slow    atomic.Bool
+				aborted atomic.Int32
+			)
+			slow.Store(true)
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if slow.Load() && strings.HasSuffix(r.URL.Path, tt.slow) {
+					select {
+					case <-r.Context().Done():
+						aborted.Add(1)
+					case <-time.After(5 * time.Second):
+					}
+					return
+				}
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/testchain/chain.json"):
+					json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+				case strings.HasSuffix(r.URL.Path, "/testchain/upgrades.json"):
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"name":   "v2.0.0",
+						"height": 1000000,
+						"time":   time.Now().Add(24 * time.Hour),
+					})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer registryServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			err := tt.fetch(ctx, registry)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Less(t, time.Since(start), 2*time.Second, "the fetch must stop with its context")
+			assert.Eventually(t, func() bool { return aborted.Load() > 0 }, time.Second, 5*time.Millisecond,
+				"the outbound request must be cancelled")
+
+			_, recorded := registry.LastError("testchain")
+			assert.False(t, recorded, "a cancelled fetch is not an upstream error")
+			assert.Zero(t, registry.DegradedStatus().ConsecutiveFailures)
+
+			// Nothing was cached for the cancelled fetch
+			slow.Store(false)
+			assert.NoError(t, tt.fetch(context.Background(), registry))
+		})
+	}
+}
+
+func TestChainRegistry_FilterExistingChains(t *testing.T) {
+	const latency = 30 * time.Millisecond
+	var probes atomic.Int32
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		time.Sleep(latency)
+		switch {
+		case strings.Contains(r.URL.Path, "/flaky/"):
+			w.WriteHeader(http.StatusBadGateway)
+		case strings.Contains(r.URL.Path, "/found"), strings.Contains(r.URL.Path, "/testnets/testfound"):
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	var chains, want []string
+	for i := range 12 {
+		chains = append(chains, fmt.Sprintf("found%02d", i), fmt.Sprintf("missing%02d", i))
+		want = append(want, fmt.Sprintf("found%02d", i))
+	}
+	chains = append(chains, "flaky", "testfound")
+	want = append(want, "testfound")
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetDegradedThreshold(-1)
+
+	start := time.Now()
+	existing, failures := registry.FilterExistingChains(context.Background(), chains)
+	elapsed := time.Since(start)
+	assert.Equal(t, want, existing, "the chains found keep the order they were given in")
+	require.Len(t, failures, 1)
+	assert.Equal(t, "flaky", failures[0].Chain)
+	assert.Error(t, failures[0].Err)
+	sequential := time.Duration(probes.Load()) * latency
+	assert.Less(t, elapsed, sequential/2, "checked concurrently rather than one chain after the other (%s sequentially)", sequential)
+
+	probes.Store(0)
+	existing, failures = registry.FilterExistingChains(context.Background(), chains)
+	assert.Equal(t, want, existing)
+	assert.Len(t, failures, 1)
+	assert.Equal(t, int32(2), probes.Load(), "only the chain that could not be checked is probed again")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	existing, failures = registry.FilterExistingChains(ctx, []string{"found99", "missing99"})
+	assert.Empty(t, existing)
+	require.Len(t, failures, 2)
+	for _, failure := range failures {
+		assert.ErrorIs(t, failure.Err, context.Canceled, failure.Chain)
+	}
+}
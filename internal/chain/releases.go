@@ -0,0 +1,134 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// releaseVersionTTL is long since releases rarely change once published, and the GitHub API is
+	// rate limited
+	releaseVersionTTL = time.Hour
+	releasesPath      = "/repos/%s/%s/releases?per_page=100"
+)
+
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// SetReleaseLookup enables resolving the node version of upgrades that only name their plan from the
+// releases of the chain's GitHub repository. An empty apiURL disables the lookup.
+func (r *ChainRegistry) SetReleaseLookup(apiURL, token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.releasesAPIURL = strings.TrimRight(apiURL, "/")
+	r.releasesToken = token
+}
+
+// resolveVersion fills in a missing upgrade version from the repository's releases, falling back to
+// the upgrade name when no release matches or the lookup is disabled
+func (r *ChainRegistry) resolveVersion(ctx context.Context, name, repo string) string {
+	r.mu.RLock()
+	apiURL, token := r.releasesAPIURL, r.releasesToken
+	r.mu.RUnlock()
+
+	if apiURL == "" || repo == "" || name == "" {
+		return name
+	}
+
+	cacheKey := fmt.Sprintf(releaseVersionCacheKey, repo, name)
+	if tag, _, found := cacheLookup[string](r, cacheKey); found {
+		if tag != "" {
+			return tag
+		}
+		return name
+	}
+
+	tag, err := r.lookupReleaseTag(ctx, apiURL, token, repo, name)
+	if err != nil {
+		// Not cached, so a rate limited or unreachable API is retried on the next refresh
+		r.logger.Debugf("Failed to resolve release of %s for %s: %v", repo, name, err)
+		return name
+	}
+	// Misses are cached too, to avoid refetching the releases of repos that do not tag their upgrades
+	r.cache.Set(cacheKey, tag, releaseVersionTTL)
+	if tag == "" {
+		return name
+	}
+	return tag
+}
+
+func (r *ChainRegistry) lookupReleaseTag(ctx context.Context, apiURL, token, repo, name string) (string, error) {
+	owner, project, err := parseGitHubRepo(repo)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+fmt.Sprintf(releasesPath, owner, project), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var releases []githubRelease
+	if err := decodeJSON(resp, &releases); err != nil {
+		return "", err
+	}
+	return matchReleaseTag(releases, name), nil
+}
+
+// matchReleaseTag picks the release tag for an upgrade name. A tag equal to the name, ignoring case
+// and a leading "v", wins; otherwise the newest stable release within the named version (e.g.
+// "v25.0.1" for "v25") is used. Releases are expected newest first, as the GitHub API returns them.
+func matchReleaseTag(releases []githubRelease, name string) string {
+	normalized := normalizeTag(name)
+	for _, release := range releases {
+		if !release.Draft && normalizeTag(release.TagName) == normalized {
+			return release.TagName
+		}
+	}
+	for _, release := range releases {
+		if release.Draft || release.Prerelease {
+			continue
+		}
+		if strings.HasPrefix(normalizeTag(release.TagName), normalized+".") {
+			return release.TagName
+		}
+	}
+	return ""
+}
+
+func normalizeTag(tag string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(tag)), "v")
+}
+
+// parseGitHubRepo extracts the owner and repository name from a GitHub repository URL
+func parseGitHubRepo(repo string) (owner, name string, err error) {
+	u, err := url.Parse(strings.TrimSpace(repo))
+	if err != nil {
+		return "", "", err
+	}
+	if !strings.EqualFold(strings.TrimPrefix(u.Host, "www."), "github.com") {
+		return "", "", fmt.Errorf("not a GitHub repository: %q", repo)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid GitHub repository: %q", repo)
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), nil
+}
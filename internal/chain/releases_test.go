@@ -0,0 +1,118 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_ResolveVersionFromReleases(t *testing.T) {
+	tests := []struct {
+		name     string
+		lookup   bool
+		upgrade  string
+		expected string
+	}{
+		{"exact tag", true, "v26.0.0", "v26.0.0"},
+		{"newest patch of named version", true, "v25", "v25.0.1"},
+		{"no matching release", true, "v99", "v99"},
+		{"lookup disabled", false, "v25", "v25"},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var releaseRequests int
+			github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/repos/osmosis-labs/osmosis/releases" {
+					http.NotFound(w, r)
+					return
+				}
+				releaseRequests++
+				assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+				json.NewEncoder(w).Encode([]map[string]interface{}{
+					{"tag_name": "v26.0.0-rc1", "prerelease": true},
+					{"tag_name": "v26.0.0"},
+					{"tag_name": "v25.0.2-rc0", "prerelease": true},
+					{"tag_name": "v25.0.1"},
+					{"tag_name": "v25.0.0"},
+				})
+			}))
+			defer github.Close()
+
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/osmosis/chain.json"):
+					json.NewEncoder(w).Encode(ChainInfo{
+						Name:     "osmosis",
+						ChainID:  "osmosis-1",
+						Codebase: Codebase{GitRepo: "https://github.com/osmosis-labs/osmosis"},
+					})
+				case strings.HasSuffix(r.URL.Path, "/osmosis/upgrades.json"):
+					json.NewEncoder(w).Encode(map[string]interface{}{"name": tt.upgrade, "height": 1000000})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer registryServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			if tt.lookup {
+				registry.SetReleaseLookup(github.URL, "test-token")
+			}
+
+			upgrade, err := registry.GetUpgradeInfo(context.Background(), "osmosis", true)
+			require.NoError(t, err)
+			require.NotNil(t, upgrade)
+			assert.Equal(t, tt.expected, upgrade.Version)
+			assert.Equal(t, "https://github.com/osmosis-labs/osmosis", upgrade.Repo)
+
+			// Resolved versions, including misses, are cached
+			upgrade, err = registry.GetUpgradeInfo(context.Background(), "osmosis", true)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, upgrade.Version)
+			if tt.lookup {
+				assert.Equal(t, 1, releaseRequests)
+			} else {
+				assert.Zero(t, releaseRequests)
+			}
+		})
+	}
+}
+
+func TestParseGitHubRepo(t *testing.T) {
+	tests := []struct {
+		repo    string
+		owner   string
+		name    string
+		wantErr bool
+	}{
+		{"https://github.com/cosmos/gaia", "cosmos", "gaia", false},
+		{"https://github.com/osmosis-labs/osmosis.git", "osmosis-labs", "osmosis", false},
+		{"https://www.github.com/cosmos/gaia/tree/main", "cosmos", "gaia", false},
+		{"https://gitlab.com/cosmos/gaia", "", "", true},
+		{"https://github.com/cosmos", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repo, func(t *testing.T) {
+			owner, name, err := parseGitHubRepo(tt.repo)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.owner, owner)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package chain
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultRetryAttempts is how many times a registry fetch is tried before it counts as failed
+	defaultRetryAttempts = 3
+	// defaultRetryBaseDelay is the wait before the first retry, doubled for every further one
+	defaultRetryBaseDelay = 500 * time.Millisecond
+)
+
+// SetRetryPolicy sets how many attempts the chain-registry and Polkachu fetches get and the backoff
+// before the first retry. Zero values keep the defaults; a negative attempt count disables retrying.
+func (r *ChainRegistry) SetRetryPolicy(attempts int, baseDelay time.Duration) {
+	if attempts == 0 {
+		attempts = defaultRetryAttempts
+	}
+	if attempts < 0 {
+		attempts = 1
+	}
+	if baseDelay <= 0 {
+		baseDelay = defaultRetryBaseDelay
+	}
+
+	r.retryMu.Lock()
+	defer r.retryMu.Unlock()
+	r.retryAttempts = attempts
+	r.retryBaseDelay = baseDelay
+}
+
+// doWithRetry sends an outbound request like do, retrying transport errors and 5xx responses with
+// exponential backoff and jitter. Only the outcome of the last attempt counts towards degraded mode,
+// and 4xx responses such as a 404 are returned right away.
+func (r *ChainRegistry) doWithRetry(req *http.Request) (*http.Response, error) {
+	if r.IsDegraded() {
+		return nil, ErrDegraded
+	}
+
+	r.retryMu.RLock()
+	attempts, delay := r.retryAttempts, r.retryBaseDelay
+	r.retryMu.RUnlock()
+
+	r.authorize(req)
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := r.client.Do(req.Clone(ctx))
+		if ctx.Err() != nil {
+			// A request the caller cancelled says nothing about the health of the upstream
+			if err == nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+		r.metrics.recordOutbound(req, resp, err)
+		transient := err != nil || resp.StatusCode >= http.StatusInternalServerError
+		if !transient || attempt >= attempts {
+			r.recordFetchResult(!transient)
+			if err != nil {
+				return nil, &ErrNetwork{URL: req.URL.String(), Err: err}
+			}
+			if err := r.rateLimited(req, resp); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+
+		if err != nil {
+			r.logger.Debugf("Attempt %d of %d for %s failed, retrying: %v", attempt, attempts, req.URL, err)
+		} else {
+			r.logger.Debugf("Attempt %d of %d for %s returned status %d, retrying", attempt, attempts, req.URL, resp.StatusCode)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		// Jitter of ±50% around the doubling delay keeps concurrent fetches from retrying in lockstep
+		wait := delay/2 + time.Duration(rand.Int64N(int64(delay)))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,114 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_RetryPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		failures int32
+		status   int
+		requests int32
+		found    bool
+	}{
+		{name: "fails twice then succeeds", failures: 2, status: http.StatusBadGateway, requests: 3, found: true},
+		{name: "all attempts fail", failures: 3, status: http.StatusServiceUnavailable, requests: 3},
+		{name: "not found is not retried", failures: 3, status: http.StatusNotFound, requests: 1},
+		{name: "retries disabled", attempts: -1, failures: 1, status: http.StatusBadGateway, requests: 1},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chainRequests, upgradeRequests atomic.Int32
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json"):
+					if r.Method == http.MethodGet && chainRequests.Add(1) <= tt.failures {
+						w.WriteHeader(tt.status)
+						return
+					}
+					json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json"):
+					if upgradeRequests.Add(1) <= tt.failures {
+						w.WriteHeader(tt.status)
+						return
+					}
+					json.NewEncoder(w).Encode(map[string]interface{}{"name": "v2", "version": "v2.0.0", "height": 1000})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer registryServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetRetryPolicy(tt.attempts, time.Millisecond)
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderChainRegistry}))
+
+			info, err := registry.GetChainInfo(context.Background(), "testchain", false)
+			assert.Equal(t, tt.requests, chainRequests.Load(), "chain.json requests")
+			if tt.found {
+				require.NoError(t, err)
+				assert.Equal(t, "testchain-1", info.ChainID)
+			} else {
+				assert.Error(t, err)
+				_, cached := registry.cache.Get("chain_info:testchain")
+				assert.True(t, cached, "the failure is negative-cached once all attempts failed")
+			}
+
+			chainRequests.Store(tt.failures)
+			upgrade, err := registry.GetUpgradeInfo(context.Background(), "testchain", true)
+			assert.Equal(t, tt.requests, upgradeRequests.Load(), "upgrades.json requests")
+			if tt.found {
+				require.NoError(t, err)
+				assert.Equal(t, "v2.0.0", upgrade.Version)
+			} else {
+				assert.ErrorIs(t, err, ErrNoUpgrade)
+			}
+		})
+	}
+}
+
+func TestChainRegistry_HTTPTimeout(t *testing.T) {
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer hung.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, hung.URL, "/cosmos/chain-registry/master")
+	registry.SetHTTPTimeout(50 * time.Millisecond)
+	registry.SetRetryPolicy(-1, 0)
+
+	start := time.Now()
+	_, err := registry.fetchChainInfoFromURL(context.Background(), hung.URL+"/cosmos/chain-registry/master/testchain/chain.json")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+
+	var netErr net.Error
+	require.True(t, errors.As(err, &netErr), "got %v", err)
+	assert.True(t, netErr.Timeout())
+}
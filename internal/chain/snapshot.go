@@ -0,0 +1,188 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// Snapshot is the last known good state of the monitored chains. It is the final fallback when
+// neither the cache nor the upstream sources have data, e.g. on a cold start during an outage.
+type Snapshot struct {
+	WrittenAt time.Time                     `json:"written_at"`
+	Chains    map[string]*ChainInfo         `json:"chains"`
+	Upgrades  map[string]*types.UpgradeInfo `json:"upgrades"`
+}
+
+// SetSnapshotPath enables the snapshot fallback, loading the snapshot an earlier run left at path.
+// The snapshot is only rewritten by WriteSnapshot.
+func (r *ChainRegistry) SetSnapshotPath(path string) error {
+	snapshot, err := readSnapshot(path)
+
+	r.snapshotMu.Lock()
+	defer r.snapshotMu.Unlock()
+	r.snapshotPath = path
+	r.snapshot = snapshot
+	return err
+}
+
+// WriteSnapshot saves the current state of the monitored chains to the snapshot file. Chains without
+// fresh data keep their entry from the previous snapshot, so writing during an outage loses nothing.
+func (r *ChainRegistry) WriteSnapshot() error {
+	r.snapshotMu.RLock()
+	path, previous := r.snapshotPath, r.snapshot
+	r.snapshotMu.RUnlock()
+	if path == "" {
+		return nil
+	}
+
+	chains, err := r.GetMonitoredChains()
+	if err != nil {
+		return err
+	}
+
+	snapshot := &Snapshot{
+		WrittenAt: time.Now(),
+		Chains:    make(map[string]*ChainInfo),
+		Upgrades:  make(map[string]*types.UpgradeInfo),
+	}
+	r.mu.RLock()
+	for _, name := range chains {
+		if info, ok := r.chains[name]; ok && info != nil {
+			current := *info
+			snapshot.Chains[name] = &current
+		} else if info, ok := previous.Chains[name]; ok {
+			snapshot.Chains[name] = info
+		}
+	}
+	r.mu.RUnlock()
+	for _, name := range chains {
+		if upgradeInfo, missing, found := r.cachedUpgradeInfo(name); found && !missing {
+			snapshot.Upgrades[name] = upgradeInfo
+		} else if upgrade, ok := previous.Upgrades[name]; ok {
+			snapshot.Upgrades[name] = upgrade
+		}
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	r.snapshotMu.Lock()
+	r.snapshot = snapshot
+	r.snapshotMu.Unlock()
+	return nil
+}
+
+// snapshotFallback reports whether the snapshot may stand in for upstream after err: network errors,
+// 5xx responses and degraded mode. A chain or upgrade that is missing upstream is never served from it.
+func snapshotFallback(err error) bool {
+	var (
+		network *ErrNetwork
+		status  *statusError
+	)
+	switch {
+	case errors.Is(err, ErrDegraded), errors.As(err, &network):
+		return true
+	case errors.As(err, &status):
+		return status.code >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// staleChainInfo returns the snapshot's chain info marked stale, or err when the snapshot has none or
+// err is not one it stands in for
+func (r *ChainRegistry) staleChainInfo(chainName string, err error) (*ChainInfo, error) {
+	if !snapshotFallback(err) {
+		return nil, err
+	}
+	r.snapshotMu.RLock()
+	info, ok := r.snapshot.Chains[chainName]
+	writtenAt := r.snapshot.WrittenAt
+	r.snapshotMu.RUnlock()
+	if !ok || info == nil {
+		return nil, err
+	}
+
+	r.logger.Warnf("Serving chain info for %s from the snapshot of %s: %v", chainName, writtenAt.Format(time.RFC3339), err)
+	stale := *info
+	stale.Stale = true
+	return &stale, nil
+}
+
+// cacheChainInfoFailure caches a failed chain info lookup, with cacheNotFound when the chain is
+// missing and cacheFailure otherwise, and returns the snapshot's chain info when it stands in for
+// the chain. That info is cached instead of the failure,
+// so that it keeps being served until the failure would have expired.
+func (r *ChainRegistry) cacheChainInfoFailure(chainName string, err error) (*ChainInfo, error) {
+	stale, err := r.staleChainInfo(chainName, err)
+	if err != nil {
+		var missing *ErrChainNotFound
+		if errors.As(err, &missing) || errors.Is(err, errNotFound) {
+			r.cacheNotFound(chainInfoCacheKey, chainName)
+		} else {
+			r.cacheFailure(chainInfoCacheKey, chainName, err)
+		}
+		return nil, err
+	}
+	r.cache.Set(fmt.Sprintf(chainInfoCacheKey, chainName), stale, time.Duration(r.negativeCacheTTL.Load()))
+	return stale, nil
+}
+
+// staleUpgradeInfo returns the snapshot's upgrade marked stale, or err when the snapshot has none or
+// err is not one it stands in for
+func (r *ChainRegistry) staleUpgradeInfo(chainName string, err error) (*types.UpgradeInfo, error) {
+	if !snapshotFallback(err) {
+		return nil, err
+	}
+	r.snapshotMu.RLock()
+	upgrade, ok := r.snapshot.Upgrades[chainName]
+	writtenAt := r.snapshot.WrittenAt
+	r.snapshotMu.RUnlock()
+	if !ok || upgrade == nil {
+		return nil, err
+	}
+
+	r.logger.Warnf("Serving upgrade info for %s from the snapshot of %s: %v", chainName, writtenAt.Format(time.RFC3339), err)
+	stale := *upgrade
+	stale.Stale = true
+	return &stale, nil
+}
+
+func readSnapshot(path string) (*Snapshot, error) {
+	empty := &Snapshot{}
+	if path == "" {
+		return empty, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return empty, nil
+	}
+	if err != nil {
+		return empty, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return empty, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
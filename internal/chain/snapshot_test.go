@@ -0,0 +1,143 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_SnapshotFallback(t *testing.T) {
+	upgradeTime := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/osmosis/chain.json"):
+			json.NewEncoder(w).Encode(ChainInfo{Name: "osmosis", ChainID: "osmosis-1"})
+		case strings.HasSuffix(r.URL.Path, "/osmosis/upgrades.json"):
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"name":   "v25",
+				"height": 1000,
+				"time":   upgradeTime,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer healthy.Close()
+	outage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer outage.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	// A previous run saw the chain while upstream was healthy
+	warm := NewChainRegistry(logger, healthy.URL, "/cosmos/chain-registry/master")
+	warm.SetMonitoredChains([]string{"osmosis", "juno"})
+	require.NoError(t, warm.SetSnapshotPath(path))
+	upgrade, err := warm.GetUpgradeInfo(context.Background(), "osmosis", false)
+	require.NoError(t, err)
+	require.NotNil(t, upgrade)
+	assert.False(t, upgrade.Stale)
+	require.NoError(t, warm.WriteSnapshot())
+
+	// Writing again during an outage keeps the last known good data
+	warm.cache.Flush()
+	warm.mu.Lock()
+	delete(warm.chains, "osmosis")
+	warm.mu.Unlock()
+	require.NoError(t, warm.WriteSnapshot())
+
+	// Cold start while upstream is down: nothing cached and every fetch fails
+	bare := NewChainRegistry(logger, outage.URL, "/cosmos/chain-registry/master")
+	bare.SetMonitoredChains([]string{"osmosis"})
+	_, err = bare.GetUpgradeInfo(context.Background(), "osmosis", false)
+	assert.Error(t, err, "without a snapshot there is nothing to serve")
+
+	cold := NewChainRegistry(logger, outage.URL, "/cosmos/chain-registry/master")
+	cold.SetMonitoredChains([]string{"osmosis", "juno"})
+	require.NoError(t, cold.SetSnapshotPath(path))
+	upgrade, err = cold.GetUpgradeInfo(context.Background(), "osmosis", false)
+	require.NoError(t, err)
+	require.NotNil(t, upgrade)
+	assert.True(t, upgrade.Stale)
+	assert.Equal(t, "v25", upgrade.Name)
+	assert.Equal(t, int64(1000), upgrade.Height)
+	assert.True(t, upgradeTime.Equal(upgrade.Time))
+
+	info, err := cold.GetChainInfo(context.Background(), "osmosis", false)
+	require.NoError(t, err)
+	assert.True(t, info.Stale)
+	assert.Equal(t, "osmosis-1", info.ChainID)
+
+	_, err = cold.GetUpgradeInfo(context.Background(), "juno", false)
+	assert.Error(t, err, "chains missing from the snapshot still fail")
+
+	// The snapshot keeps serving the chain for as long as its failure is cached
+	info, err = cold.GetChainInfo(context.Background(), "osmosis", false)
+	require.NoError(t, err)
+	assert.True(t, info.Stale)
+
+	// A chain removed upstream is not served from the snapshot
+	gone := httptest.NewServer(http.NotFoundHandler())
+	defer gone.Close()
+	removed := NewChainRegistry(logger, gone.URL, "/cosmos/chain-registry/master")
+	removed.SetMonitoredChains([]string{"osmosis"})
+	require.NoError(t, removed.SetSnapshotPath(path))
+	var notFound *ErrChainNotFound
+	_, err = removed.GetChainInfo(context.Background(), "osmosis", false)
+	assert.ErrorAs(t, err, &notFound)
+	_, err = removed.GetChainInfo(context.Background(), "osmosis", false)
+	assert.ErrorAs(t, err, &notFound, "nor is a cached not found")
+	_, err = removed.GetUpgradeInfo(context.Background(), "osmosis", false)
+	assert.Error(t, err)
+}
+
+func TestChainRegistry_OutageNotCachedAsNotFound(t *testing.T) {
+	outage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer outage.Close()
+	missing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer missing.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := NewChainRegistry(logger, outage.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"osmosis"})
+	for _, lookup := range []string{"fresh", "cached"} {
+		_, err := registry.GetChainInfo(context.Background(), "osmosis", false)
+		require.Error(t, err, lookup)
+		var notFound *ErrChainNotFound
+		assert.False(t, errors.As(err, &notFound), "%s lookup during an outage is not a not-found", lookup)
+	}
+	exists, err := registry.chainExists(context.Background(), "osmosis")
+	assert.False(t, exists)
+	assert.Error(t, err, "whether the chain exists is unknown during an outage")
+
+	registry = NewChainRegistry(logger, missing.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"osmosis"})
+	for _, lookup := range []string{"fresh", "cached"} {
+		_, err := registry.GetChainInfo(context.Background(), "osmosis", false)
+		var notFound *ErrChainNotFound
+		assert.True(t, errors.As(err, &notFound), "%s lookup of a missing chain is a not-found", lookup)
+	}
+	exists, err = registry.chainExists(context.Background(), "osmosis")
+	assert.False(t, exists)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,34 @@
+package chain
+
+import (
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+const defaultInProgressWindow = 2 * time.Hour
+
+// SetInProgressWindow sets how long after its scheduled time an unconfirmed upgrade is reported as
+// in progress. Non-positive values restore the default.
+func (r *ChainRegistry) SetInProgressWindow(window time.Duration) {
+	if window <= 0 {
+		window = defaultInProgressWindow
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inProgressWindow = window
+}
+
+// UpgradeStatus computes the current status of an upgrade, using the last known height of the chain
+// to confirm that it was applied
+func (r *ChainRegistry) UpgradeStatus(chainName string, upgrade *types.UpgradeInfo) string {
+	r.mu.RLock()
+	window := r.inProgressWindow
+	var height int64
+	if info, ok := r.chains[chainName]; ok && info != nil {
+		height = info.Height
+	}
+	r.mu.RUnlock()
+
+	return upgrade.StatusAt(time.Now(), height, window)
+}
@@ -0,0 +1,48 @@
+package chain
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainRegistry_UpgradeStatus(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name          string
+		scheduledIn   time.Duration
+		window        time.Duration
+		currentHeight int64
+		expected      string
+	}{
+		{"before scheduled time", time.Hour, 0, 0, types.UpgradeStatusScheduled},
+		{"within default window", -30 * time.Minute, 0, 0, types.UpgradeStatusInProgress},
+		{"within configured window", -5 * time.Hour, 6 * time.Hour, 0, types.UpgradeStatusInProgress},
+		{"after window without height", -3 * time.Hour, 0, 0, types.UpgradeStatusUnknown},
+		{"after window below height", -3 * time.Hour, 0, 999999, types.UpgradeStatusInProgress},
+		{"after window at height", -3 * time.Hour, 0, 1000000, types.UpgradeStatusCompleted},
+		{"height confirmed within window", -30 * time.Minute, 0, 1000000, types.UpgradeStatusCompleted},
+		{"height not reached within window", -30 * time.Minute, 0, 999999, types.UpgradeStatusInProgress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewChainRegistry(logger, "http://localhost", "/cosmos/chain-registry/master")
+			registry.SetInProgressWindow(tt.window)
+			registry.chains["testchain"] = &ChainInfo{Name: "testchain", Height: tt.currentHeight}
+
+			upgrade := &types.UpgradeInfo{
+				ChainName: "testchain",
+				Height:    1000000,
+				Time:      time.Now().Add(tt.scheduledIn),
+			}
+			assert.Equal(t, tt.expected, registry.UpgradeStatus("testchain", upgrade))
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+const (
+	voteTallyTTL = 5 * time.Minute
+	proposalPath = "/cosmos/gov/v1/proposals/%s"
+	// maxTallyEndpoints caps how many REST endpoints are tried before giving up on a proposal
+	maxTallyEndpoints = 3
+
+	proposalStatusVotingPeriod = "voting_period"
+)
+
+// ErrTallyUnavailable is returned when none of a chain's REST endpoints can report a proposal's tally
+var ErrTallyUnavailable = errors.New("vote tally not available")
+
+var proposalIDPattern = regexp.MustCompile(`(\d+)/?$`)
+
+type tallyResult struct {
+	YesCount        string `json:"yes_count"`
+	AbstainCount    string `json:"abstain_count"`
+	NoCount         string `json:"no_count"`
+	NoWithVetoCount string `json:"no_with_veto_count"`
+}
+
+type proposalResponse struct {
+	Proposal struct {
+		ID               string      `json:"id"`
+		Status           string      `json:"status"`
+		FinalTallyResult tallyResult `json:"final_tally_result"`
+	} `json:"proposal"`
+}
+
+type tallyResponse struct {
+	Tally tallyResult `json:"tally"`
+}
+
+// parseProposalID extracts the proposal number from a proposal link such as
+// https://www.mintscan.io/cosmos/proposals/924, or returns "" if there is none
+func parseProposalID(proposal string) string {
+	match := proposalIDPattern.FindStringSubmatch(strings.TrimSpace(proposal))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// GetVoteTally returns the vote on a governance proposal of the chain, given as an ID or a link to it.
+// Proposals still in their voting period are tallied live; for finished proposals the final tally is
+// used.
+func (r *ChainRegistry) GetVoteTally(ctx context.Context, chainName, proposal string) (*types.VoteTally, error) {
+	proposalID := parseProposalID(proposal)
+	if proposalID == "" {
+		return nil, fmt.Errorf("no proposal ID in %q", proposal)
+	}
+
+	cacheKey := fmt.Sprintf(voteTallyCacheKey, chainName, proposalID)
+	if tally, _, found := cacheLookup[*types.VoteTally](r, cacheKey); found && tally != nil {
+		copied := *tally
+		return &copied, nil
+	}
+
+	if r.IsDegraded() {
+		return nil, ErrDegraded
+	}
+
+	info, err := r.GetChainInfo(ctx, chainName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := r.chainAPIs(chainName, info).REST
+	if len(endpoints) > maxTallyEndpoints {
+		endpoints = endpoints[:maxTallyEndpoints]
+	}
+	for _, endpoint := range endpoints {
+		tally, err := r.fetchVoteTally(ctx, endpoint.Address, proposalID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			r.logger.Debugf("Vote tally not available from %s for %s proposal %s: %v", endpoint.Address, chainName, proposalID, err)
+			continue
+		}
+		r.cache.Set(cacheKey, tally, voteTallyTTL)
+		copied := *tally
+		return &copied, nil
+	}
+
+	return nil, ErrTallyUnavailable
+}
+
+func (r *ChainRegistry) fetchVoteTally(ctx context.Context, address, proposalID string) (*types.VoteTally, error) {
+	if address == "" {
+		return nil, fmt.Errorf("empty REST address")
+	}
+	base := strings.TrimRight(address, "/") + fmt.Sprintf(proposalPath, proposalID)
+
+	var proposal proposalResponse
+	if err := r.getRESTJSON(ctx, base, &proposal); err != nil {
+		return nil, err
+	}
+
+	status := strings.ToLower(strings.TrimPrefix(proposal.Proposal.Status, "PROPOSAL_STATUS_"))
+	result := proposal.Proposal.FinalTallyResult
+	if status == proposalStatusVotingPeriod {
+		var tally tallyResponse
+		if err := r.getRESTJSON(ctx, base+"/tally", &tally); err != nil {
+			return nil, err
+		}
+		result = tally.Tally
+	}
+
+	return tallyPercentages(proposalID, status, result)
+}
+
+// getRESTJSON decodes a response from a chain REST endpoint. These are third-party nodes, so their
+// failures do not count towards degraded mode.
+func (r *ChainRegistry) getRESTJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeJSON(resp, v)
+}
+
+func tallyPercentages(proposalID, status string, result tallyResult) (*types.VoteTally, error) {
+	counts := make([]float64, 4)
+	for i, value := range []string{result.YesCount, result.NoCount, result.AbstainCount, result.NoWithVetoCount} {
+		if value == "" {
+			continue
+		}
+		count, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tally count %q: %w", value, err)
+		}
+		counts[i] = count
+	}
+
+	tally := &types.VoteTally{ProposalID: proposalID, Status: status}
+	total := counts[0] + counts[1] + counts[2] + counts[3]
+	if total == 0 {
+		return tally, nil
+	}
+	tally.Yes = percent(counts[0], total)
+	tally.No = percent(counts[1], total)
+	tally.Abstain = percent(counts[2], total)
+	tally.NoWithVeto = percent(counts[3], total)
+	return tally, nil
+}
+
+// percent rounds to two decimals
+func percent(count, total float64) float64 {
+	return math.Round(count/total*10000) / 100
+}
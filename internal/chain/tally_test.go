@@ -0,0 +1,95 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_GetVoteTally(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		final    map[string]string
+		live     map[string]string
+		expected types.VoteTally
+	}{
+		{
+			name:   "voting period uses live tally",
+			status: "PROPOSAL_STATUS_VOTING_PERIOD",
+			final:  map[string]string{"yes_count": "0", "no_count": "0", "abstain_count": "0", "no_with_veto_count": "0"},
+			live:   map[string]string{"yes_count": "600", "no_count": "100", "abstain_count": "250", "no_with_veto_count": "50"},
+			expected: types.VoteTally{
+				ProposalID: "42", Status: "voting_period", Yes: 60, No: 10, Abstain: 25, NoWithVeto: 5,
+			},
+		},
+		{
+			name:   "passed proposal uses final tally",
+			status: "PROPOSAL_STATUS_PASSED",
+			final:  map[string]string{"yes_count": "2", "no_count": "1", "abstain_count": "0", "no_with_veto_count": "0"},
+			expected: types.VoteTally{
+				ProposalID: "42", Status: "passed", Yes: 66.67, No: 33.33,
+			},
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tallyRequests int
+			node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/cosmos/gov/v1/proposals/42":
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"proposal": map[string]interface{}{"id": "42", "status": tt.status, "final_tally_result": tt.final},
+					})
+				case "/cosmos/gov/v1/proposals/42/tally":
+					tallyRequests++
+					json.NewEncoder(w).Encode(map[string]interface{}{"tally": tt.live})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer node.Close()
+
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(ChainInfo{Name: "testchain", ChainID: "testchain-1", APIs: APIs{REST: []Endpoint{{Address: node.URL}}}})
+			}))
+			defer registryServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+
+			tally, err := registry.GetVoteTally(context.Background(), "testchain", "https://www.mintscan.io/testchain/proposals/42")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *tally)
+			assert.Equal(t, tt.live != nil, tallyRequests == 1, "the tally endpoint is only queried during the voting period")
+		})
+	}
+}
+
+func TestParseProposalID(t *testing.T) {
+	tests := []struct {
+		proposal string
+		expected string
+	}{
+		{"924", "924"},
+		{"https://www.mintscan.io/cosmos/proposals/924", "924"},
+		{"https://www.mintscan.io/cosmos/proposals/924/", "924"},
+		{"https://forum.cosmos.network/t/upgrade", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, parseProposalID(tt.proposal), tt.proposal)
+	}
+}
@@ -1,6 +1,8 @@
 package chain
 
 import (
+	"context"
+
 	"github.com/0xPuncker/cosmos-watcher/pkg/types"
 	"github.com/sirupsen/logrus"
 )
@@ -10,6 +12,6 @@ func GetTestUpgradeInfo() *types.UpgradeInfo {
 	logger.SetOutput(nil)
 
 	registry := NewChainRegistry(logger, "https://api.github.com", "/cosmos/chain-registry/master")
-	info, _ := registry.GetUpgradeInfo("neutron", false)
+	info, _ := registry.GetUpgradeInfo(context.Background(), "neutron", false)
 	return info
 }
@@ -0,0 +1,158 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_TestnetDirectories(t *testing.T) {
+	// Both layouts: testnets named like their mainnet and testnets with a "testnet" suffix
+	dirs := map[string]bool{
+		"/osmosis":                   true,
+		"/testnets/osmosistestnet":   true,
+		"/cosmoshub":                 true,
+		"/testnets/cosmoshubtestnet": true,
+		"/testnets/elystestnet":      true,
+		"/testnets/celestia":         true,
+		"/testnets/mocha":            true,
+	}
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dir, file := path.Split(strings.TrimPrefix(r.URL.Path, "/cosmos/chain-registry/master"))
+		dir = strings.TrimSuffix(dir, "/")
+		switch {
+		case !dirs[dir]:
+			http.NotFound(w, r)
+		case file == "upgrades.json":
+			json.NewEncoder(w).Encode(types.UpgradeInfo{Name: dir, Height: 1000, Time: time.Now().Add(time.Hour)})
+		default:
+			json.NewEncoder(w).Encode(ChainInfo{ChainID: dir})
+		}
+	}))
+	defer registryServer.Close()
+
+	tests := []struct {
+		name    string
+		config  types.ChainConfig
+		dir     string
+		network string
+	}{
+		{name: "mainnet", config: types.ChainConfig{Name: "osmosis", Network: "mainnet"}, dir: "/osmosis", network: "mainnet"},
+		{name: "testnet named with its suffix", config: types.ChainConfig{Name: "osmosistestnet", Network: "testnet"}, dir: "/testnets/osmosistestnet", network: "testnet"},
+		{name: "testnet named after its mainnet", config: types.ChainConfig{Name: "elys", Network: "testnet"}, dir: "/testnets/elystestnet", network: "testnet"},
+		{name: "testnet directory preferred to the mainnet one", config: types.ChainConfig{Name: "cosmoshub", Network: "testnet"}, dir: "/testnets/cosmoshubtestnet", network: "testnet"},
+		{name: "testnet directory without suffix", config: types.ChainConfig{Name: "celestia", Network: "testnet"}, dir: "/testnets/celestia", network: "testnet"},
+		{name: "testnet with a registry name", config: types.ChainConfig{Name: "celestia-mocha-4", Network: "testnet", RegistryName: "mocha"}, dir: "/testnets/mocha", network: "testnet"},
+		{name: "network from the directory found", config: types.ChainConfig{Name: "celestia", Network: "mainnet"}, dir: "/testnets/celestia", network: "testnet"},
+		{name: "unconfigured chain", dir: "/testnets/elystestnet", config: types.ChainConfig{Name: "elystestnet"}, network: "testnet"},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetRetryPolicy(-1, 0)
+			if tt.config.Network != "" {
+				require.NoError(t, registry.SetChainConfigs([]types.ChainConfig{tt.config}))
+			}
+
+			info, err := registry.GetChainInfo(context.Background(), tt.config.Name, false)
+			require.NoError(t, err)
+			assert.Equal(t, tt.dir, info.ChainID)
+			assert.Equal(t, tt.network, info.Network)
+			assert.Equal(t, tt.config.Name, info.Name)
+			assert.True(t, registry.ChainExists(context.Background(), tt.config.Name))
+
+			info, err = registry.fetchChainInfo(context.Background(), tt.config.Name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.dir, info.ChainID, "upgrade lookups resolve the same directory")
+			assert.Equal(t, tt.network, info.Network)
+
+			upgrade, err := registry.getUpgradeInfoFromChain(context.Background(), tt.config.Name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.dir, upgrade.Name, "upgrades.json is read next to the chain.json found")
+		})
+	}
+
+	t.Run("testnets never fall back to the mainnet directory", func(t *testing.T) {
+		registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+		registry.SetRetryPolicy(-1, 0)
+		require.NoError(t, registry.SetChainConfigs([]types.ChainConfig{{Name: "osmosis", Network: "testnet"}}))
+
+		info, err := registry.GetChainInfo(context.Background(), "osmosis", false)
+		require.NoError(t, err)
+		assert.Equal(t, "/testnets/osmosistestnet", info.ChainID)
+
+		require.NoError(t, registry.SetChainConfigs([]types.ChainConfig{{Name: "mocha", Network: "testnet", RegistryName: "mochatestnet"}}))
+		_, err = registry.fetchChainInfo(context.Background(), "mocha")
+		assert.Error(t, err, "a registry name is the only directory tried")
+	})
+}
+
+func TestChainRegistry_ChainProbeDeadlines(t *testing.T) {
+	var probes atomic.Int32
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		switch strings.TrimPrefix(r.URL.Path, "/cosmos/chain-registry/master") {
+		case "/elys-1/chain.json":
+			// The first probe outlives its deadline
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		case "/testnets/elys/chain.json":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetDegradedThreshold(-1)
+	registry.chainProbeTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	dir, found := registry.tryChainNameVariations(context.Background(), "elys-1")
+	require.True(t, found, "the probes after the slow one get their own deadline")
+	assert.Equal(t, registryDir{name: "elys", network: "testnet"}, dir)
+	assert.Greater(t, probes.Load(), int32(2))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestTrimNumericSuffix(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{name: "osmosis-1", want: "osmosis", ok: true},
+		{name: "elys_2", want: "elys", ok: true},
+		{name: "cosmoshub", want: "cosmoshub", ok: false},
+		{name: "axelar-dojo", want: "axelar-dojo", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := trimNumericSuffix(tt.name)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
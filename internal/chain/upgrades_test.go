@@ -1,6 +1,7 @@
 package chain
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -96,10 +97,9 @@ func TestFetchUpgrades(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			registry.SetChainName(tc.chainName)
 
 			t.Log("Testing Polkachu API...")
-			polkachuUpgrade, err := registry.fetchPolkachuUpgrades(tc.chainName)
+			polkachuUpgrade, err := registry.fetchPolkachuUpgrades(context.Background(), tc.chainName)
 			if err != nil {
 				t.Logf("Notice: Polkachu API error: %v", err)
 			}
@@ -116,7 +116,7 @@ func TestFetchUpgrades(t *testing.T) {
 				t.Logf("Notice: No upgrades found from %s in Polkachu", tc.chainName)
 			}
 
-			upgradeInfo, err := registry.GetUpgradeInfo(tc.chainName, false)
+			upgradeInfo, err := registry.GetUpgradeInfo(context.Background(), tc.chainName, false)
 			if err != nil {
 				t.Logf("Notice: Combined upgrade info error: %v", err)
 			}
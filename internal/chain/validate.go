@@ -0,0 +1,48 @@
+package chain
+
+import (
+	"context"
+	"sync"
+)
+
+// maxValidationWorkers caps concurrent chain-registry lookups while validating a chain list
+const maxValidationWorkers = 5
+
+// ChainValidation is the outcome of resolving one configured chain in the chain-registry
+type ChainValidation struct {
+	Name string
+	// Network is the network the chain resolved on, "mainnet" or "testnet"
+	Network string
+	Err     error
+}
+
+// ValidateChains resolves every chain in the chain-registry concurrently, bypassing cached results.
+// Results are returned in the order of names.
+func (r *ChainRegistry) ValidateChains(ctx context.Context, names []string) []ChainValidation {
+	results := make([]ChainValidation, len(names))
+
+	var (
+		wg        sync.WaitGroup
+		semaphore = make(chan struct{}, maxValidationWorkers)
+	)
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result := ChainValidation{Name: name}
+			info, err := r.GetChainInfo(ctx, name, true)
+			if err != nil {
+				result.Err = err
+			} else {
+				result.Network = info.Network
+			}
+			results[i] = result
+		}(i, name)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,154 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// ErrVersionsNotFound is returned for chains that publish neither a versions.json nor codebase versions
+var ErrVersionsNotFound = errors.New("versions not found in chain-registry")
+
+// Version is one release line of a chain as the chain-registry lists it: the upgrade name, the height
+// it took effect at and the tags compatible with it
+type Version struct {
+	Name                string            `json:"name"`
+	Tag                 string            `json:"tag,omitempty"`
+	Height              int64             `json:"height,omitempty"`
+	Proposal            int64             `json:"proposal,omitempty"`
+	PreviousVersionName string            `json:"previous_version_name,omitempty"`
+	NextVersionName     string            `json:"next_version_name,omitempty"`
+	RecommendedVersion  string            `json:"recommended_version,omitempty"`
+	CompatibleVersions  []string          `json:"compatible_versions,omitempty"`
+	Binaries            map[string]string `json:"binaries,omitempty"`
+}
+
+// VersionHistory is the chain's versions.json, or the versions of its chain.json codebase for chains
+// without one
+type VersionHistory struct {
+	ChainName string    `json:"chain_name"`
+	Versions  []Version `json:"versions"`
+}
+
+// GetVersions returns the chain's version history from its versions.json, looked up in the mainnet
+// registry first and the testnets one after, or from the codebase versions of its chain.json. It is
+// cached apart from the chain info like the asset list.
+func (r *ChainRegistry) GetVersions(ctx context.Context, chainName string) (*VersionHistory, error) {
+	cacheKey := fmt.Sprintf(versionsCacheKey, chainName)
+	if history, missing, found := cacheLookup[*VersionHistory](r, cacheKey); found {
+		if missing || history == nil {
+			return nil, fmt.Errorf("chain %q: %w", chainName, ErrVersionsNotFound)
+		}
+		return history, nil
+	}
+	if r.IsDegraded() {
+		return nil, ErrDegraded
+	}
+
+	var err error
+	for _, dir := range r.registryDirs(chainName) {
+		var history *VersionHistory
+		if history, err = r.source.versions(ctx, dir); err == nil {
+			if dir.name != chainName {
+				history.ChainName = chainName
+			}
+			r.cache.Set(cacheKey, history, time.Duration(r.cacheTTL.Load()))
+			return history, nil
+		}
+		if !errors.Is(err, ErrVersionsNotFound) {
+			break
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if errors.Is(err, ErrVersionsNotFound) {
+		if info, infoErr := r.GetChainInfo(ctx, chainName, false); infoErr == nil && len(info.Codebase.Versions) > 0 {
+			history := &VersionHistory{ChainName: chainName, Versions: info.Codebase.Versions}
+			r.cache.Set(cacheKey, history, time.Duration(r.cacheTTL.Load()))
+			return history, nil
+		}
+		r.cacheNotFound(versionsCacheKey, chainName)
+	}
+	return nil, fmt.Errorf("chain %q: %w", chainName, err)
+}
+
+func (r *ChainRegistry) fetchVersions(ctx context.Context, url string) (*VersionHistory, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.doWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrVersionsNotFound
+	}
+	var history VersionHistory
+	if err := decodeJSON(resp, &history); err != nil {
+		return nil, err
+	}
+	if len(history.Versions) == 0 {
+		return nil, ErrVersionsNotFound
+	}
+	return &history, nil
+}
+
+// matchVersion returns the version entry of the upgrade: the one at its height, else the one named
+// like it, else the one whose tags include its version
+func (h *VersionHistory) matchVersion(upgrade *types.UpgradeInfo) (Version, bool) {
+	if upgrade.Height > 0 {
+		for _, v := range h.Versions {
+			if v.Height == upgrade.Height {
+				return v, true
+			}
+		}
+	}
+	for _, v := range h.Versions {
+		if v.Name != "" && (strings.EqualFold(v.Name, upgrade.Name) || strings.EqualFold(v.Name, upgrade.Version)) {
+			return v, true
+		}
+	}
+	if upgrade.Version != "" {
+		for _, v := range h.Versions {
+			if v.Tag == upgrade.Version || v.RecommendedVersion == upgrade.Version || containsFold(v.CompatibleVersions, upgrade.Version) {
+				return v, true
+			}
+		}
+	}
+	return Version{}, false
+}
+
+// enrichFromVersions fills the upgrade's version, repository and cosmovisor folder from the matching
+// entry of the chain's version history. Chains without one keep the upgrade as it is.
+func (r *ChainRegistry) enrichFromVersions(ctx context.Context, chainName string, chain *ChainInfo, upgrade *types.UpgradeInfo) {
+	history, err := r.GetVersions(ctx, chainName)
+	if err != nil {
+		r.logger.Debugf("Versions of %s not available, keeping the upgrade as reported: %v", chainName, err)
+		return
+	}
+	version, ok := history.matchVersion(upgrade)
+	if !ok {
+		return
+	}
+
+	if tag := version.Tag; tag != "" && (upgrade.Version == "" || upgrade.Version == version.Name) {
+		upgrade.Version = tag
+	} else if upgrade.Version == "" {
+		upgrade.Version = version.RecommendedVersion
+	}
+	if upgrade.Repo == "" && chain != nil {
+		upgrade.Repo = chain.Codebase.GitRepo
+	}
+	if version.Name != "" {
+		upgrade.CosmovisorFolder = "upgrades/" + version.Name
+	}
+}
@@ -0,0 +1,194 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_GetVersions(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		chainJSON ChainInfo
+		want      []string
+		wantErr   error
+	}{
+		{
+			name:      "versions.json",
+			file:      "/cosmos/chain-registry/master/osmosis/versions.json",
+			chainJSON: ChainInfo{Name: "osmosis"},
+			want:      []string{"v24", "v25", "v26"},
+		},
+		{
+			name:      "testnet versions.json",
+			file:      "/cosmos/chain-registry/master/testnets/osmosis/versions.json",
+			chainJSON: ChainInfo{Name: "osmosis"},
+			want:      []string{"v24", "v25", "v26"},
+		},
+		{
+			name:      "chain.json codebase",
+			chainJSON: ChainInfo{Name: "osmosis", Codebase: Codebase{Versions: []Version{{Name: "v25", Tag: "v25.0.0"}}}},
+			want:      []string{"v25"},
+		},
+		{
+			name:      "missing",
+			chainJSON: ChainInfo{Name: "osmosis"},
+			wantErr:   ErrVersionsNotFound,
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case tt.file:
+					http.ServeFile(w, r, "testdata/versions.json")
+				case "/cosmos/chain-registry/master/osmosis/chain.json":
+					json.NewEncoder(w).Encode(tt.chainJSON)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer registryServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetRetryPolicy(-1, 0)
+			history, err := registry.GetVersions(context.Background(), "osmosis")
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			var names []string
+			for _, v := range history.Versions {
+				names = append(names, v.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestVersionHistory_MatchVersion(t *testing.T) {
+	var history VersionHistory
+	data, err := os.ReadFile("testdata/versions.json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &history))
+
+	tests := []struct {
+		name    string
+		upgrade types.UpgradeInfo
+		want    string
+	}{
+		{name: "by height", upgrade: types.UpgradeInfo{Height: 16000000, Version: "v99.0.0"}, want: "v26"},
+		{name: "by name", upgrade: types.UpgradeInfo{Name: "V25"}, want: "v25"},
+		{name: "by tag", upgrade: types.UpgradeInfo{Version: "v26.0.0"}, want: "v26"},
+		{name: "by compatible version", upgrade: types.UpgradeInfo{Version: "v24.0.0"}, want: "v24"},
+		{name: "no match", upgrade: types.UpgradeInfo{Height: 1, Version: "v1.0.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, ok := history.matchVersion(&tt.upgrade)
+			assert.Equal(t, tt.want != "", ok)
+			assert.Equal(t, tt.want, version.Name)
+		})
+	}
+}
+
+func TestChainRegistry_UpgradeEnrichedFromVersions(t *testing.T) {
+	tests := []struct {
+		name       string
+		versions   bool
+		upgrade    PolkachuUpgrade
+		version    string
+		repo       string
+		cosmovisor string
+	}{
+		{
+			name:       "matching version",
+			versions:   true,
+			upgrade:    PolkachuUpgrade{NodeVersion: "v26.0.0", Block: 16000000},
+			version:    "v26.0.0",
+			repo:       "https://github.com/osmosis-labs/osmosis",
+			cosmovisor: "upgrades/v26",
+		},
+		{
+			name:       "version named after the upgrade",
+			versions:   true,
+			upgrade:    PolkachuUpgrade{NodeVersion: "v26", Block: 16000001},
+			version:    "v26.0.0",
+			repo:       "https://github.com/osmosis-labs/osmosis",
+			cosmovisor: "upgrades/v26",
+		},
+		{
+			name:     "no matching version",
+			versions: true,
+			upgrade:  PolkachuUpgrade{NodeVersion: "v27.0.0", Block: 17000000, Repo: "https://github.com/osmosis-labs/osmosis-fork"},
+			version:  "v27.0.0",
+			repo:     "https://github.com/osmosis-labs/osmosis-fork",
+		},
+		{
+			name:    "no versions.json",
+			upgrade: PolkachuUpgrade{NodeVersion: "v26.0.0", Block: 16000000},
+			version: "v26.0.0",
+			repo:    "https://github.com/osmosis-labs/osmosis",
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/cosmos/chain-registry/master/osmosis/chain.json":
+					http.ServeFile(w, r, "testdata/chain.json")
+				case "/cosmos/chain-registry/master/osmosis/versions.json":
+					if tt.versions {
+						http.ServeFile(w, r, "testdata/versions.json")
+						return
+					}
+					http.NotFound(w, r)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer registryServer.Close()
+
+			upgrade := tt.upgrade
+			upgrade.Network, upgrade.ChainName = "mainnet", "osmosis"
+			upgrade.EstimatedUpgradeTime = time.Now().Add(24 * time.Hour).Format(time.RFC3339)
+			polkachuServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode([]PolkachuUpgrade{upgrade})
+			}))
+			defer polkachuServer.Close()
+
+			registry := NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master", WithPolkachuURL(polkachuServer.URL))
+			registry.SetRetryPolicy(-1, 0)
+			registry.SetETADriftThreshold(-1)
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{ProviderPolkachu}))
+
+			info, err := registry.GetUpgradeInfo(context.Background(), "osmosis", false)
+			require.NoError(t, err)
+			require.NotNil(t, info)
+			assert.Equal(t, tt.version, info.Version)
+			assert.Equal(t, tt.repo, info.Repo)
+			assert.Equal(t, tt.cosmovisor, info.CosmovisorFolder)
+		})
+	}
+}
@@ -1,10 +1,18 @@
 package config
 
 import (
+	"bytes"
+	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/0xPuncker/cosmos-watcher/pkg/types"
 	"github.com/joho/godotenv"
@@ -12,18 +20,38 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig    `json:"server"`
-	GitHub   GitHubConfig    `json:"github"`
-	Registry RegistryConfig  `json:"registry"`
-	Poller   PollerConfig    `json:"poller"`
-	Slack    SlackConfig     `json:"slack"`
-	Jobs     types.JobConfig `json:"jobs"`
+	Server        ServerConfig        `json:"server"`
+	GitHub        GitHubConfig        `json:"github"`
+	Registry      RegistryConfig      `json:"registry"`
+	Cache         CacheConfig         `json:"cache"`
+	Monitoring    MonitoringConfig    `json:"monitoring"`
+	Poller        PollerConfig        `json:"poller"`
+	Slack         SlackConfig         `json:"slack"`
+	Notifications NotificationsConfig `json:"notifications"`
+	Upgrades      UpgradesConfig      `json:"upgrades"`
+	Groups        GroupsConfig        `json:"groups"`
+	Jobs          types.JobConfig     `json:"jobs"`
 }
 
 type ServerConfig struct {
 	Port         string `json:"port"`
 	ReadTimeout  string `json:"read_timeout"`
 	WriteTimeout string `json:"write_timeout"`
+	// CompressionMinSize is the smallest response body that is gzipped for clients accepting it. Zero
+	// uses the default of 1 KiB, a negative value disables compression.
+	CompressionMinSize int `json:"compression_min_size"`
+	// MaxSSESubscribers caps concurrent event stream connections. Zero uses the default of 100, a
+	// negative value removes the cap.
+	MaxSSESubscribers int `json:"max_sse_subscribers"`
+	// MaxWebSocketClients caps concurrent WebSocket connections, apart from the event streams. Zero
+	// uses the default of 100, a negative value removes the cap.
+	MaxWebSocketClients int `json:"max_websocket_clients"`
+	// AdminToken authorizes administrative endpoints such as maintenance mode, sent as a bearer token.
+	// Those endpoints are disabled while it is empty.
+	AdminToken string `json:"admin_token"`
+	// PublicURL is the URL the watcher is reached at, such as https://watcher.example.com, which the
+	// OpenAPI document names as its server. Empty uses the host the document is requested from.
+	PublicURL string `json:"public_url"`
 }
 
 type GitHubConfig struct {
@@ -35,11 +63,165 @@ type GitHubConfig struct {
 type RegistryConfig struct {
 	URL             string `json:"url"`
 	RefreshInterval string `json:"refresh_interval"`
+	// DegradedFailureThreshold is the number of consecutive upstream failures before external
+	// fetching is paused. Zero uses the default, a negative value disables automatic degradation.
+	DegradedFailureThreshold int `json:"degraded_failure_threshold"`
+	// EndpointRefreshInterval is how often a chain's chain.json is re-fetched for fresh RPC and REST
+	// endpoints, 1h by default; a negative duration disables refreshing
+	EndpointRefreshInterval string `json:"endpoint_refresh_interval"`
+	// EndpointRefreshRateLimit caps how many chains are refreshed per minute. Zero uses the default of
+	// 10, a negative value removes the limit.
+	EndpointRefreshRateLimit int `json:"endpoint_refresh_rate_limit"`
+	// SnapshotPath is where the last known state of the monitored chains is written after every poller
+	// cycle and read back, marked stale, when nothing fresher is available; empty disables it
+	SnapshotPath string `json:"snapshot_path"`
+	// RetryAttempts is how many times a chain-registry or Polkachu fetch is tried when it fails with a
+	// timeout, connection error or 5xx. Zero uses the default of 3, a negative value disables retries.
+	RetryAttempts int `json:"retry_attempts"`
+	// RetryBaseDelay is the backoff before the first retry, doubled for every further one, 500ms by default
+	RetryBaseDelay string `json:"retry_base_delay"`
+	// CacheTTL is how long fetched chain and upgrade info is cached, 5m by default
+	CacheTTL string `json:"cache_ttl"`
+	// ChainInfoTTL and UpgradeInfoTTL override CacheTTL for chain info and upgrade info. Empty or zero
+	// uses CacheTTL.
+	ChainInfoTTL   string `json:"chain_info_ttl"`
+	UpgradeInfoTTL string `json:"upgrade_info_ttl"`
+	// NegativeCacheTTL is how long a failed chain lookup or a chain without upgrades is cached before
+	// the chain-registry is asked again, 30s by default
+	NegativeCacheTTL string `json:"negative_cache_ttl"`
+	// DiscoveryCacheTTL is how long the chain list served by /api/v1/registry/chains is cached, 6h
+	// by default
+	DiscoveryCacheTTL string `json:"discovery_cache_ttl"`
+	// ChainExistsTTL is how long a chain found in the chain-registry is remembered when checking that a
+	// monitored chain exists, 1h by default
+	ChainExistsTTL string `json:"chain_exists_ttl"`
+	// Mirrors are base URLs of copies of the chain-registry, e.g.
+	// https://cdn.jsdelivr.net/gh/cosmos/chain-registry@master, tried in order when the registry times
+	// out, cannot be reached or answers 5xx
+	Mirrors []string `json:"mirrors"`
+	// ChainInfoSources lists where chain info is fetched from, in order, e.g. ["chain-registry",
+	// "cosmos-directory"]. The next source is only asked when the previous one is unavailable. Empty
+	// uses the chain-registry alone.
+	ChainInfoSources []string `json:"chain_info_sources"`
+	// LocalPath is a local clone of the chain-registry repository read instead of GitHub, for hosts
+	// without access to it. CHAIN_REGISTRY_LOCAL_PATH sets it when empty.
+	LocalPath string `json:"local_path"`
+	// ExistsCheckConcurrency is how many chains are checked against the chain-registry at once when a
+	// chain list is filtered down to the chains it has. Zero uses the default of 8.
+	ExistsCheckConcurrency int `json:"exists_check_concurrency"`
+	// Ref is the branch, tag or commit SHA of the chain-registry read, in place of the ref of the
+	// registry URL (master by default). CHAIN_REGISTRY_REF sets it without a config file.
+	Ref string `json:"ref"`
+}
+
+// Cache backends selectable with cache.backend
+const (
+	CacheBackendMemory = "memory"
+	CacheBackendRedis  = "redis"
+)
+
+// CacheConfig selects where the registry caches what it fetched. The default in-memory cache is private
+// to each process; replicas behind a load balancer share a Redis one.
+type CacheConfig struct {
+	// Backend is "memory", the default when empty, or "redis"
+	Backend string `json:"backend"`
+	// Address is the host:port of the Redis server
+	Address  string `json:"address"`
+	Password string `json:"password"`
+	// KeyPrefix namespaces the keys in Redis, "cosmos-watcher:" when empty
+	KeyPrefix string `json:"key_prefix"`
+}
+
+// validate rejects unknown backends and a Redis backend without an address
+func (c CacheConfig) validate() error {
+	switch c.Backend {
+	case "", CacheBackendMemory:
+		return nil
+	case CacheBackendRedis:
+		if c.Address == "" {
+			return fmt.Errorf("cache.address is required by the redis cache backend")
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid cache.backend %q: must be %q or %q", c.Backend, CacheBackendMemory, CacheBackendRedis)
+}
+
+// MonitoringConfig controls which chains are monitored besides the ones in chains.yaml
+type MonitoringConfig struct {
+	// AutoDiscover monitors every chain Polkachu lists a pending upgrade for, until the upgrade completes
+	AutoDiscover bool `json:"auto_discover"`
+	// AutoDiscoverInterval is how often the Polkachu list is checked for such chains, 30m by default
+	AutoDiscoverInterval string `json:"auto_discover_interval"`
+}
+
+// DiscoverInterval returns the parsed auto_discover_interval, zero when unset
+func (c MonitoringConfig) DiscoverInterval() (time.Duration, error) {
+	return parseTTL("monitoring.auto_discover_interval", c.AutoDiscoverInterval)
+}
+
+// validateRef rejects refs that cannot be put in a URL path as they are
+func (c RegistryConfig) validateRef() error {
+	if strings.ContainsAny(strings.TrimSpace(c.Ref), " \t\n?#%") {
+		return fmt.Errorf("invalid registry.ref %q: must be a branch, tag or commit SHA", c.Ref)
+	}
+	return nil
+}
+
+// validateMirrors rejects mirrors that are not absolute http(s) URLs
+func (c RegistryConfig) validateMirrors() error {
+	for _, mirror := range c.Mirrors {
+		u, err := url.Parse(mirror)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid registry.mirrors entry %q: must be an http or https URL", mirror)
+		}
+	}
+	return nil
+}
+
+// InfoTTLs returns the parsed chain_info_ttl and upgrade_info_ttl, zero when unset
+func (c RegistryConfig) InfoTTLs() (chainInfoTTL, upgradeInfoTTL time.Duration, err error) {
+	if chainInfoTTL, err = parseTTL("registry.chain_info_ttl", c.ChainInfoTTL); err != nil {
+		return 0, 0, err
+	}
+	if upgradeInfoTTL, err = parseTTL("registry.upgrade_info_ttl", c.UpgradeInfoTTL); err != nil {
+		return 0, 0, err
+	}
+	return chainInfoTTL, upgradeInfoTTL, nil
+}
+
+// ExistsTTL returns the parsed chain_exists_ttl, zero when unset
+func (c RegistryConfig) ExistsTTL() (time.Duration, error) {
+	return parseTTL("registry.chain_exists_ttl", c.ChainExistsTTL)
+}
+
+func parseTTL(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", field, value, err)
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must not be negative", field, value)
+	}
+	return ttl, nil
 }
 
 type PollerConfig struct {
 	Interval string `json:"interval"`
 	Timeout  string `json:"timeout"`
+	// StallBlocks is how many average block times a chain may go without a new block before it is
+	// reported stalled, 20 when zero. Negative disables stall detection.
+	StallBlocks int `json:"stall_blocks"`
+	// StallMinDuration is the shortest a chain may go without a new block before it is reported
+	// stalled, 5m by default
+	StallMinDuration string `json:"stall_min_duration"`
+}
+
+// StallMinimum returns the parsed stall_min_duration, zero when unset
+func (c PollerConfig) StallMinimum() (time.Duration, error) {
+	return parseTTL("poller.stall_min_duration", c.StallMinDuration)
 }
 
 type SlackConfig struct {
@@ -47,15 +229,161 @@ type SlackConfig struct {
 	NotificationThreshold string `json:"notification_threshold"`
 }
 
+type NotificationsConfig struct {
+	NotifyOnChainResolved bool `json:"notify_on_chain_resolved"`
+	// MajorVersionWarning flags upgrades that jump a major version of the chain's running binary
+	MajorVersionWarning bool `json:"major_version_warning"`
+	// NotifyOnCancellation announces when a reported upgrade that has not happened yet disappears from
+	// every upgrade source. A fetch in which any source failed never counts as a cancellation.
+	NotifyOnCancellation bool `json:"notify_on_cancellation"`
+	// DowngradeWarning sends a separate alert when an upgrade targets an older version than the chain runs
+	DowngradeWarning bool `json:"downgrade_warning"`
+	// Language is the default language of notification messages, "en" (default) or "pt-BR"
+	Language string `json:"language"`
+	// IncludeVoteTally adds the governance vote tally to notifications of proposal-backed upgrades
+	IncludeVoteTally bool `json:"include_vote_tally"`
+	// BlocksRemainingGate holds back upgrade notifications for the listed chains until the upgrade is
+	// within the given number of blocks of the chain's current height
+	BlocksRemainingGate map[string]int64 `json:"blocks_remaining_gate"`
+	// NotifyNoHealthyEndpoints raises a critical alert when an upgrade is within UrgentWindow and none
+	// of the chain's public endpoints respond
+	NotifyNoHealthyEndpoints bool `json:"notify_no_healthy_endpoints"`
+	// UrgentWindow is how close an upgrade has to be to count as imminent, 6h by default
+	UrgentWindow string `json:"urgent_window"`
+	// BatchWindow collects new upgrades and announces them in one message at the end of each window,
+	// e.g. "15m"; empty sends every upgrade right away
+	BatchWindow string `json:"batch_window"`
+	// DeadLetterPath is where undelivered notifications are kept for replay on startup; empty disables it
+	DeadLetterPath string `json:"dead_letter_path"`
+	// DeadLetterMaxAge is how old a dead-lettered notification may be and still be replayed
+	DeadLetterMaxAge string `json:"dead_letter_max_age"`
+	// DeadLetterReplayLimit caps how many notifications are replayed per startup
+	DeadLetterReplayLimit int `json:"dead_letter_replay_limit"`
+	// HistorySize is how many sent notifications are kept for /api/v1/notifications/recent, 100 by
+	// default; a negative value disables the history
+	HistorySize int `json:"history_size"`
+	// HistoryPath persists the sent notification history across restarts; empty keeps it in memory
+	HistoryPath string `json:"history_path"`
+}
+
+type UpgradesConfig struct {
+	// InProgressWindow is how long after its scheduled time an unconfirmed upgrade is reported as in progress
+	InProgressWindow string `json:"in_progress_window"`
+	// IncludeVoteTally adds the governance vote tally of proposal-backed upgrades to API responses
+	IncludeVoteTally bool `json:"include_vote_tally"`
+	// Providers lists the upgrade sources to ask, in order, e.g. ["chain-registry", "polkachu"].
+	// Empty asks all built-in providers in that default order.
+	Providers []string `json:"providers"`
+	// Explorers lists the explorer kinds or names block and proposal links point to, in order of
+	// preference. Empty prefers mintscan and then ping.pub.
+	Explorers []string `json:"explorers"`
+	// ResolveReleaseVersions looks up the node version of upgrades that only name their plan in the
+	// GitHub releases of the chain's repository, using github.api_url and github.token
+	ResolveReleaseVersions bool `json:"resolve_release_versions"`
+	// CoalesceWindow is how long a finished /upgrades response is reused for identical requests.
+	// Concurrent identical requests always share one computation.
+	CoalesceWindow string `json:"coalesce_window"`
+	// DedupHeightTolerance is how many blocks apart two reports of an upgrade are still considered the
+	// same upgrade. Zero uses the default, a negative value requires identical heights.
+	DedupHeightTolerance int64 `json:"dedup_height_tolerance"`
+	// PolkachuCacheWindow is how long one download of Polkachu's upgrade list answers the lookups of
+	// all chains, 2m by default
+	PolkachuCacheWindow string `json:"polkachu_cache_window"`
+	// ETADriftThreshold is how far a provider's upgrade time may be from the one computed from the
+	// chain's height before the computed one is used, 1h by default. A negative value disables it.
+	ETADriftThreshold string `json:"eta_drift_threshold"`
+}
+
+// GroupConfig is a named set of chains that can be queried together and have their notifications
+// routed to a dedicated Slack channel
+type GroupConfig struct {
+	Chains          []string `json:"chains"`
+	SlackWebhookURL string   `json:"slack_webhook_url,omitempty"`
+	// Language overrides notifications.language for the group's channel
+	Language string `json:"language,omitempty"`
+}
+
+// GroupsConfig maps group names to their configuration
+type GroupsConfig map[string]GroupConfig
+
+// Contains reports whether the named group lists the chain. Unknown groups contain nothing.
+func (g GroupsConfig) Contains(group, chainName string) bool {
+	for _, name := range g[group].Chains {
+		if name == chainName {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupsOf returns the names of the groups listing the chain, sorted
+func (g GroupsConfig) GroupsOf(chainName string) []string {
+	var groups []string
+	for name := range g {
+		if g.Contains(name, chainName) {
+			groups = append(groups, name)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
 type ChainConfig struct {
 	Mainnet []Chain `yaml:"mainnet"`
 	Testnet []Chain `yaml:"testnet"`
+	// Embedded is set when no chains.yaml was found and the built-in fallback list was loaded
+	Embedded bool `yaml:"-"`
 }
 
 type Chain struct {
 	Name        string `yaml:"name"`
 	DisplayName string `yaml:"display_name"`
 	Network     string `yaml:"network"`
+	Mainnet     string `yaml:"mainnet,omitempty"`
+	// RPC and REST override the chain-registry endpoints for on-chain queries
+	RPC  []string `yaml:"rpc,omitempty"`
+	REST []string `yaml:"rest,omitempty"`
+	// Sources lists the upgrade providers asked for this chain, in order, e.g. [polkachu, chain-registry].
+	// Empty uses the upgrades.providers order.
+	Sources []string `yaml:"sources,omitempty"`
+	// RegistryName is the chain's chain-registry directory, e.g. cosmoshub for a chain named cosmos.
+	// PolkachuName is the name Polkachu lists it under. Both default to Name.
+	RegistryName string `yaml:"registry_name,omitempty"`
+	PolkachuName string `yaml:"polkachu_name,omitempty"`
+}
+
+// ChainConfigs returns the mainnet entries followed by the testnet ones as registry chain configs.
+// Entries without a network get the one of their section, so testnet entries are looked up in the
+// testnets directory of the chain-registry.
+func (c *ChainConfig) ChainConfigs() []types.ChainConfig {
+	configs := make([]types.ChainConfig, 0, len(c.Mainnet)+len(c.Testnet))
+	for _, section := range []struct {
+		network string
+		chains  []Chain
+	}{{"mainnet", c.Mainnet}, {"testnet", c.Testnet}} {
+		for _, chain := range section.chains {
+			cfg := *chain.ToChainConfig()
+			if cfg.Network == "" {
+				cfg.Network = section.network
+			}
+			configs = append(configs, cfg)
+		}
+	}
+	return configs
+}
+
+func (c *Chain) ToChainConfig() *types.ChainConfig {
+	return &types.ChainConfig{
+		Name:         c.Name,
+		DisplayName:  c.DisplayName,
+		Network:      c.Network,
+		Mainnet:      c.Mainnet,
+		RPC:          c.RPC,
+		REST:         c.REST,
+		Sources:      c.Sources,
+		RegistryName: c.RegistryName,
+		PolkachuName: c.PolkachuName,
+	}
 }
 
 func Load(configPath string) (*Config, error) {
@@ -71,20 +399,29 @@ func Load(configPath string) (*Config, error) {
 			"PORT",
 			"GITHUB_API_URL",
 			"CHAIN_REGISTRY_BASE_URL",
+			"CHAIN_REGISTRY_LOCAL_PATH",
+			"CHAIN_REGISTRY_REF",
 			"POLLER_INTERVAL",
 		} {
 			fmt.Printf("%s=%s\n", env, os.Getenv(env))
 		}
+		if _, err := embeddedFallbackEnabled(); err != nil {
+			return nil, err
+		}
 
 		return &Config{
 			Server: ServerConfig{
-				Port: getEnv("PORT", "8080"),
+				Port:       getEnv("PORT", "8080"),
+				AdminToken: os.Getenv("ADMIN_TOKEN"),
+				PublicURL:  os.Getenv("PUBLIC_URL"),
 			},
 			GitHub: GitHubConfig{
 				APIURL: getEnv("GITHUB_API_URL", "https://raw.githubusercontent.com"),
+				Token:  os.Getenv("GITHUB_TOKEN"),
 			},
 			Registry: RegistryConfig{
 				URL: getEnv("CHAIN_REGISTRY_BASE_URL", "/cosmos/chain-registry/master"),
+				Ref: os.Getenv("CHAIN_REGISTRY_REF"),
 			},
 			Poller: PollerConfig{
 				Interval: getEnv("POLLER_INTERVAL", "1m"),
@@ -96,6 +433,30 @@ func Load(configPath string) (*Config, error) {
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if _, _, err := config.Registry.InfoTTLs(); err != nil {
+		return nil, err
+	}
+	if _, err := config.Registry.ExistsTTL(); err != nil {
+		return nil, err
+	}
+	if err := config.Registry.validateMirrors(); err != nil {
+		return nil, err
+	}
+	if err := config.Registry.validateRef(); err != nil {
+		return nil, err
+	}
+	if err := config.Cache.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := config.Monitoring.DiscoverInterval(); err != nil {
+		return nil, err
+	}
+	if _, err := config.Poller.StallMinimum(); err != nil {
+		return nil, err
+	}
+	if _, err := embeddedFallbackEnabled(); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
@@ -117,43 +478,188 @@ func DefaultConfig() *Config {
 	}
 }
 
+//go:embed default_chains.yaml
+var defaultChains []byte
+
+// LoadChainConfig reads chains.yaml from the config directory of the working directory or one of its
+// parents. When no file exists it falls back to the chain list embedded in the binary, unless
+// EMBEDDED_CHAINS_FALLBACK is set to false; the returned config is then marked as Embedded.
 func LoadChainConfig() (*ChainConfig, error) {
+	configPath, err := findChainConfig()
+	if err != nil {
+		enabled, envErr := embeddedFallbackEnabled()
+		if envErr != nil {
+			return nil, envErr
+		}
+		if !enabled {
+			return nil, err
+		}
+		config, embedErr := DefaultChainConfig()
+		if embedErr != nil {
+			return nil, embedErr
+		}
+		return config, nil
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config ChainConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	return &config, nil
+}
+
+// DefaultChainConfig returns the minimal chain list embedded in the binary
+func DefaultChainConfig() (*ChainConfig, error) {
+	var config ChainConfig
+	if err := yaml.Unmarshal(defaultChains, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse embedded chain list: %w", err)
+	}
+	config.Embedded = true
+	return &config, nil
+}
+
+// ErrChainListed is returned by SaveChain for a chain chains.yaml already lists
+var ErrChainListed = errors.New("chain already listed in chains.yaml")
+
+// SaveChain appends chain to the section of its network in chains.yaml, keeping the rest of the file
+// and its comments. It fails when no chains.yaml exists, such as when the embedded chain list is
+// used, and when the file already lists the chain.
+func SaveChain(chain Chain) error {
+	configPath, err := findChainConfig()
+	if err != nil {
+		return err
+	}
+	return appendChain(configPath, chain)
+}
+
+// appendChain adds chain to the mainnet or testnet section of the chains.yaml at path, replacing the
+// file only once the new content is written
+func appendChain(path string, chain Chain) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var existing ChainConfig
+	if err := yaml.Unmarshal(data, &existing); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	for _, listed := range append(existing.Mainnet, existing.Testnet...) {
+		if listed.Name == chain.Name {
+			return fmt.Errorf("%w: %s", ErrChainListed, chain.Name)
+		}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to parse config file: %s is not a mapping", path)
+	}
+
+	section := "mainnet"
+	if chain.Network == "testnet" {
+		section = "testnet"
+	}
+	var chains *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == section {
+			chains = root.Content[i+1]
+			break
+		}
+	}
+	if chains == nil {
+		chains = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: section}, chains)
+	}
+	if chains.Kind != yaml.SequenceNode {
+		// An empty section, "testnet:" with nothing under it, parses as null
+		*chains = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	var entry yaml.Node
+	if err := entry.Encode(chain); err != nil {
+		return fmt.Errorf("failed to encode chain %s: %w", chain.Name, err)
+	}
+	chains.Content = append(chains.Content, &entry)
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".chains-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(out.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+func findChainConfig() (string, error) {
 	wd, err := os.Getwd()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get working directory: %w", err)
+		return "", fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	var configPath string
 	for i := 0; i < 3; i++ {
-		configPath = filepath.Join(wd, "config", "chains.yaml")
+		configPath := filepath.Join(wd, "config", "chains.yaml")
 		if _, err := os.Stat(configPath); err == nil {
-			break
+			return configPath, nil
 		}
 
 		if i == 0 {
 			configPath = filepath.Join(wd, "chains.yaml")
 			if _, err := os.Stat(configPath); err == nil {
-				break
+				return configPath, nil
 			}
 		}
 
 		wd = filepath.Dir(wd)
 		if wd == "/" {
-			return nil, fmt.Errorf("config directory not found")
+			break
 		}
 	}
 
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
+	return "", fmt.Errorf("config directory not found")
+}
 
-	var config ChainConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+// embeddedFallbackEnabled reads EMBEDDED_CHAINS_FALLBACK, true when unset
+func embeddedFallbackEnabled() (bool, error) {
+	value := getEnv("EMBEDDED_CHAINS_FALLBACK", "true")
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid EMBEDDED_CHAINS_FALLBACK %q: must be true or false", value)
 	}
-
-	return &config, nil
+	return enabled, nil
 }
 
 func GetChainInfo(chainName string) (*Chain, error) {
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadChainConfig_EmbeddedFallback(t *testing.T) {
+	t.Run("no config file", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+
+		chainConfig, err := LoadChainConfig()
+		require.NoError(t, err)
+		assert.True(t, chainConfig.Embedded)
+
+		var names []string
+		for _, chain := range chainConfig.Mainnet {
+			names = append(names, chain.Name)
+			assert.Equal(t, "mainnet", chain.Network)
+		}
+		assert.Contains(t, names, "cosmoshub")
+		assert.Contains(t, names, "osmosis")
+	})
+
+	t.Run("fallback disabled", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		t.Setenv("EMBEDDED_CHAINS_FALLBACK", "false")
+
+		_, err := LoadChainConfig()
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid fallback setting", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		t.Setenv("EMBEDDED_CHAINS_FALLBACK", "flase")
+
+		_, err := LoadChainConfig()
+		assert.ErrorContains(t, err, "invalid EMBEDDED_CHAINS_FALLBACK")
+		_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+		assert.ErrorContains(t, err, "invalid EMBEDDED_CHAINS_FALLBACK", "the setting is checked at startup")
+	})
+
+	t.Run("config file present", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "config"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "config", "chains.yaml"),
+			[]byte("mainnet:\n  - name: akash\n    network: mainnet\n"), 0o644))
+		t.Chdir(dir)
+
+		chainConfig, err := LoadChainConfig()
+		require.NoError(t, err)
+		assert.False(t, chainConfig.Embedded)
+		require.Len(t, chainConfig.Mainnet, 1)
+		assert.Equal(t, "akash", chainConfig.Mainnet[0].Name)
+	})
+}
+
+func TestSaveChain(t *testing.T) {
+	t.Run("no config file", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		assert.Error(t, SaveChain(Chain{Name: "dymension", Network: "mainnet"}))
+	})
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "config"), 0o755))
+	path := filepath.Join(dir, "config", "chains.yaml")
+	require.NoError(t, os.WriteFile(path,
+		[]byte("# Chains we run validators on\nmainnet:\n  - name: akash\n    display_name: Akash\n    network: mainnet\n"), 0o644))
+	t.Chdir(dir)
+
+	require.NoError(t, SaveChain(Chain{Name: "dymension", DisplayName: "Dymension", Network: "mainnet"}))
+	require.NoError(t, SaveChain(Chain{Name: "elystestnet", DisplayName: "Elys Testnet", Network: "testnet"}))
+	assert.Error(t, SaveChain(Chain{Name: "akash", Network: "mainnet"}), "a listed chain is not added twice")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `# Chains we run validators on
+mainnet:
+  - name: akash
+    display_name: Akash
+    network: mainnet
+  - name: dymension
+    display_name: Dymension
+    network: mainnet
+testnet:
+  - name: elystestnet
+    display_name: Elys Testnet
+    network: testnet
+`, string(data))
+
+	chainConfig, err := LoadChainConfig()
+	require.NoError(t, err)
+	assert.Len(t, chainConfig.Mainnet, 2)
+	assert.Len(t, chainConfig.Testnet, 1)
+}
+
+func TestChainConfig_ChainConfigs(t *testing.T) {
+	chainConfig := ChainConfig{
+		Mainnet: []Chain{{Name: "cosmoshub", Network: "mainnet"}, {Name: "osmosis"}},
+		Testnet: []Chain{{Name: "elys", RegistryName: "elystestnet"}, {Name: "celestia", Network: "testnet"}},
+	}
+
+	var networks []string
+	for _, cfg := range chainConfig.ChainConfigs() {
+		networks = append(networks, cfg.Name+"/"+cfg.Network)
+	}
+	assert.Equal(t, []string{"cosmoshub/mainnet", "osmosis/mainnet", "elys/testnet", "celestia/testnet"}, networks)
+	assert.Equal(t, "elystestnet", chainConfig.ChainConfigs()[2].RegistryName)
+}
+
+func TestLoad_Mirrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		mirrors string
+		wantErr bool
+	}{
+		{name: "unset", mirrors: `[]`},
+		{name: "https", mirrors: `["https://cdn.jsdelivr.net/gh/cosmos/chain-registry@master"]`},
+		{name: "relative", mirrors: `["/cosmos/chain-registry/master"]`, wantErr: true},
+		{name: "other scheme", mirrors: `["ftp://mirror.example.com/chain-registry"]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(t, os.WriteFile(path, []byte(`{"registry": {"mirrors": `+tt.mirrors+`}}`), 0o644))
+
+			_, err := Load(path)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "registry.mirrors")
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoad_Cache(t *testing.T) {
+	tests := []struct {
+		name    string
+		cache   string
+		wantErr string
+	}{
+		{name: "unset", cache: `{}`},
+		{name: "memory", cache: `{"backend": "memory"}`},
+		{name: "redis", cache: `{"backend": "redis", "address": "localhost:6379", "key_prefix": "watcher:"}`},
+		{name: "redis without address", cache: `{"backend": "redis"}`, wantErr: "cache.address"},
+		{name: "unknown backend", cache: `{"backend": "memcached"}`, wantErr: "cache.backend"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(t, os.WriteFile(path, []byte(`{"cache": `+tt.cache+`}`), 0o644))
+
+			_, err := Load(path)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoad_Monitoring(t *testing.T) {
+	tests := []struct {
+		name         string
+		monitoring   string
+		wantInterval time.Duration
+		wantErr      string
+	}{
+		{name: "unset", monitoring: `{}`},
+		{name: "interval", monitoring: `{"auto_discover": true, "auto_discover_interval": "15m"}`, wantInterval: 15 * time.Minute},
+		{name: "negative", monitoring: `{"auto_discover_interval": "-1m"}`, wantErr: "monitoring.auto_discover_interval"},
+		{name: "unparsable", monitoring: `{"auto_discover_interval": "hourly"}`, wantErr: "monitoring.auto_discover_interval"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(t, os.WriteFile(path, []byte(`{"monitoring": `+tt.monitoring+`}`), 0o644))
+
+			cfg, err := Load(path)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			interval, err := cfg.Monitoring.DiscoverInterval()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantInterval, interval)
+		})
+	}
+}
+
+func TestLoad_RegistryRef(t *testing.T) {
+	for _, ref := range []string{"", "master", "v0.1.0", "4f3c2a1b9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b"} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"registry": {"ref": "`+ref+`"}}`), 0o644))
+		cfg, err := Load(path)
+		require.NoError(t, err, ref)
+		assert.Equal(t, ref, cfg.Registry.Ref)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"registry": {"ref": "master?token=x"}}`), 0o644))
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "registry.ref")
+}
+
+func TestLoad_InfoTTLs(t *testing.T) {
+	tests := []struct {
+		name        string
+		registry    string
+		wantChain   time.Duration
+		wantUpgrade time.Duration
+		wantErr     string
+	}{
+		{name: "unset", registry: `{}`},
+		{
+			name:        "durations",
+			registry:    `{"chain_info_ttl": "30m", "upgrade_info_ttl": "50ms"}`,
+			wantChain:   30 * time.Minute,
+			wantUpgrade: 50 * time.Millisecond,
+		},
+		{
+			name:     "negative",
+			registry: `{"chain_info_ttl": "-1m"}`,
+			wantErr:  "registry.chain_info_ttl",
+		},
+		{
+			name:     "unparsable",
+			registry: `{"upgrade_info_ttl": "soon"}`,
+			wantErr:  "registry.upgrade_info_ttl",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(t, os.WriteFile(path, []byte(`{"registry": `+tt.registry+`}`), 0o644))
+
+			cfg, err := Load(path)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			chainInfoTTL, upgradeInfoTTL, err := cfg.Registry.InfoTTLs()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantChain, chainInfoTTL)
+			assert.Equal(t, tt.wantUpgrade, upgradeInfoTTL)
+		})
+	}
+}
+
+func TestLoad_ChainExistsTTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"registry": {"chain_exists_ttl": "2h"}}`), 0o644))
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	ttl, err := cfg.Registry.ExistsTTL()
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, ttl)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"registry": {"chain_exists_ttl": "-1h"}}`), 0o644))
+	_, err = Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "registry.chain_exists_ttl")
+}
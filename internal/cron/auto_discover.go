@@ -0,0 +1,124 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultAutoDiscoverInterval is how often the Polkachu upgrade list is checked for chains to
+// auto-monitor
+const DefaultAutoDiscoverInterval = 30 * time.Minute
+
+// AutoDiscoverJob monitors the chains Polkachu lists a pending upgrade for on top of the configured
+// ones, and stops monitoring them once their upgrade has completed
+type AutoDiscoverJob struct {
+	registry Registry
+	logger   *logrus.Logger
+	// mu serializes runs, so a scheduled run never races the periodic one
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewAutoDiscoverJob(registry Registry, logger *logrus.Logger) *AutoDiscoverJob {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AutoDiscoverJob{
+		registry: registry,
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Run updates the auto-discovered chains. Chains with a pending Polkachu upgrade are added, and a chain
+// added earlier is only dropped once its upgrade is completed or no source reports one anymore; a
+// chain whose upgrade cannot be fetched is kept. Nothing changes when the Polkachu list is unavailable.
+func (j *AutoDiscoverJob) Run() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.registry.InMaintenance() || j.registry.IsDegraded() {
+		j.logger.Info("Registry in maintenance or degraded mode, skipping chain auto-discovery")
+		return nil
+	}
+
+	discovered, err := j.registry.PendingPolkachuChains(j.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to discover chains with pending upgrades: %w", err)
+	}
+	previous := j.registry.AutoDiscoveredChains()
+
+	var chains, added, dropped []string
+	for _, name := range mergeChainNames(previous, discovered) {
+		if j.registry.ChainSource(name) == chain.ChainSourceConfig {
+			continue
+		}
+		listed := slices.Contains(discovered, name)
+		upgrade, err := j.registry.GetUpgradeInfo(j.ctx, name, false)
+		switch {
+		case errors.Is(err, chain.ErrNoUpgrade) && !listed,
+			err == nil && j.registry.UpgradeStatus(name, upgrade) == types.UpgradeStatusCompleted:
+			if slices.Contains(previous, name) {
+				dropped = append(dropped, name)
+			}
+			continue
+		case err != nil && !errors.Is(err, chain.ErrNoUpgrade):
+			j.logger.WithFields(logrus.Fields{
+				"chain": name,
+				"error": err,
+			}).Debug("Upgrade of auto-discovered chain not available, keeping the chain")
+		}
+		chains = append(chains, name)
+		if !slices.Contains(previous, name) {
+			added = append(added, name)
+		}
+	}
+	j.registry.SetAutoDiscoveredChains(chains)
+
+	j.logger.WithFields(logrus.Fields{
+		"chains":  len(chains),
+		"added":   added,
+		"dropped": dropped,
+	}).Info("Updated auto-discovered chains")
+	return nil
+}
+
+// RunEvery runs the job now and then every interval until Stop is called
+func (j *AutoDiscoverJob) RunEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := j.Run(); err != nil {
+			j.logger.Warnf("Chain auto-discovery failed: %v", err)
+		}
+		select {
+		case <-j.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop ends RunEvery and cancels a run in progress
+func (j *AutoDiscoverJob) Stop() {
+	j.cancel()
+}
+
+// mergeChainNames returns the names of a followed by those of b missing from a
+func mergeChainNames(a, b []string) []string {
+	merged := slices.Clone(a)
+	for _, name := range b {
+		if !slices.Contains(merged, name) {
+			merged = append(merged, name)
+		}
+	}
+	return merged
+}
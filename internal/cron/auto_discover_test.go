@@ -0,0 +1,78 @@
+package cron
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/internal/testutil"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoDiscoverJob(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"})
+	for _, name := range []string{"osmosis", "juno", "stargaze", "akash"} {
+		registry.Chains[name] = &chain.ChainInfo{Name: name, Network: "mainnet"}
+	}
+	upcoming := time.Now().Add(48 * time.Hour)
+	registry.Upgrades["cosmoshub"] = &types.UpgradeInfo{ChainName: "cosmoshub", Height: 23000000, Time: upcoming}
+	registry.Upgrades["osmosis"] = &types.UpgradeInfo{ChainName: "osmosis", Height: 17000000, Time: upcoming}
+	registry.Upgrades["juno"] = &types.UpgradeInfo{ChainName: "juno", Height: 21000000, Time: upcoming}
+	job := NewAutoDiscoverJob(registry, logger)
+
+	t.Run("discovery", func(t *testing.T) {
+		registry.PendingChains = []string{"cosmoshub", "juno", "osmosis"}
+		require.NoError(t, job.Run())
+		assert.Equal(t, []string{"juno", "osmosis"}, registry.AutoDiscoveredChains(), "configured chains are not auto-discovered")
+		assert.Equal(t, chain.ChainSourceAuto, registry.ChainSource("osmosis"))
+
+		chains, err := registry.GetMonitoredChains()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cosmoshub", "juno", "osmosis"}, chains)
+	})
+
+	t.Run("unavailable list", func(t *testing.T) {
+		registry.PendingChainsErr = errors.New("polkachu API returned non-200 status code: 503")
+		defer func() { registry.PendingChainsErr = nil }()
+		assert.Error(t, job.Run())
+		assert.Equal(t, []string{"juno", "osmosis"}, registry.AutoDiscoveredChains(), "an outage drops nothing")
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		// osmosis upgraded: its height is past the upgrade and Polkachu still lists it
+		registry.Blocks["osmosis"] = chain.LatestBlock{Height: 17000100, Time: time.Now()}
+		registry.Upgrades["osmosis"].Time = time.Now().Add(-time.Hour)
+		// juno is no longer listed but its upgrade is still ahead, and stargaze is listed before any
+		// upgrade source reports its upgrade
+		registry.PendingChains = []string{"osmosis", "stargaze"}
+		require.NoError(t, job.Run())
+		assert.Equal(t, []string{"juno", "stargaze"}, registry.AutoDiscoveredChains())
+
+		// juno's upgrade went away with the listing
+		delete(registry.Upgrades, "juno")
+		registry.PendingChains = []string{"stargaze"}
+		require.NoError(t, job.Run())
+		assert.Equal(t, []string{"stargaze"}, registry.AutoDiscoveredChains())
+	})
+
+	t.Run("configured later", func(t *testing.T) {
+		registry.SetMonitoredChains([]string{"cosmoshub", "stargaze"})
+		require.NoError(t, job.Run())
+		assert.Empty(t, registry.AutoDiscoveredChains())
+		assert.Equal(t, chain.ChainSourceConfig, registry.ChainSource("stargaze"))
+
+		registry.PendingChains = nil
+		require.NoError(t, job.Run())
+		chains, err := registry.GetMonitoredChains()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cosmoshub", "stargaze"}, chains, "configured chains are never removed")
+	})
+}
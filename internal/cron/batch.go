@@ -0,0 +1,86 @@
+package cron
+
+import (
+	"context"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/notifications"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// RunBatches sends the upgrades collected by the checker at the end of every batch window until the
+// checker is stopped. It returns right away when batching is not configured.
+func (uc *UpgradeChecker) RunBatches() {
+	uc.mu.RLock()
+	window := uc.batchWindow
+	uc.mu.RUnlock()
+	if window <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-uc.ctx.Done():
+			return
+		case <-ticker.C:
+			uc.flushBatch(uc.ctx)
+		}
+	}
+}
+
+// queueUpgrade holds a new upgrade back for the batch sent at the end of the current window.
+// Callers must hold uc.mu.
+func (uc *UpgradeChecker) queueUpgrade(chain string, upgradeInfo *types.UpgradeInfo) {
+	if uc.suppressed(chain, "upgrade") {
+		return
+	}
+	uc.pendingBatch = append(uc.pendingBatch, upgradeInfo)
+	uc.logger.WithFields(logrus.Fields{
+		"chain":   chain,
+		"pending": len(uc.pendingBatch),
+	}).Debug("Upgrade queued for the next batch")
+}
+
+// flushBatch sends the queued upgrades as one message per channel; an empty window sends nothing.
+// The batch is taken under uc.mu and sent after releasing it, so a slow webhook does not hold up
+// upgrade checks.
+func (uc *UpgradeChecker) flushBatch(ctx context.Context) {
+	uc.mu.Lock()
+	pending := uc.pendingBatch
+	uc.pendingBatch = nil
+	if len(pending) == 0 {
+		uc.mu.Unlock()
+		return
+	}
+
+	// Group the upgrades by channel, keeping channels and upgrades in the order they were queued
+	var channels []*notifications.SlackService
+	batches := make(map[*notifications.SlackService][]*types.UpgradeInfo)
+	for _, upgradeInfo := range pending {
+		for _, slack := range uc.recipients(upgradeInfo.ChainName) {
+			if _, ok := batches[slack]; !ok {
+				channels = append(channels, slack)
+			}
+			batches[slack] = append(batches[slack], upgradeInfo)
+		}
+	}
+	uc.mu.Unlock()
+	if len(channels) == 0 {
+		uc.logger.Debug("Slack service not configured, dropping upgrade batch")
+		return
+	}
+
+	for _, slack := range channels {
+		if err := slack.SendUpgradeBatch(ctx, batches[slack]); err != nil {
+			uc.logger.WithFields(logrus.Fields{
+				"upgrades": len(batches[slack]),
+				"error":    err,
+			}).Error("Failed to send upgrade batch")
+			continue
+		}
+		uc.logger.WithField("upgrades", len(batches[slack])).Info("Upgrade batch sent successfully")
+	}
+}
@@ -1,49 +1,99 @@
 package cron
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"regexp"
 	"sync"
 	"time"
 
+	"github.com/0xPuncker/cosmos-watcher/internal/events"
 	"github.com/0xPuncker/cosmos-watcher/pkg/types"
 	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	// ErrJobNotFound is returned for a job that is not scheduled
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobDisabled is returned when running a disabled job
+	ErrJobDisabled = errors.New("job is disabled")
+	// ErrJobRunning is returned when running a job that is still running and does not allow overlapping runs
+	ErrJobRunning = errors.New("job is already running")
+	// ErrMaxConcurrent is returned when running a job while jobs.max_concurrent jobs are running
+	ErrMaxConcurrent = errors.New("max concurrent jobs reached")
+	// ErrInvalidJob is returned when adding a job whose name, schedule or task is invalid
+	ErrInvalidJob = errors.New("invalid job")
+	// ErrJobExists is returned when adding a job under the name of another one
+	ErrJobExists = errors.New("job already exists")
+	// ErrPredefinedJob is returned when removing a job of jobs.predefined without forcing it
+	ErrPredefinedJob = errors.New("job is predefined")
+)
+
+// Origins of the jobs, telling the ones of jobs.predefined from the ones added with AddJob
+const (
+	OriginPredefined = "predefined"
+	OriginAPI        = "api"
+)
+
+// jobSchedule parses job schedules as the scheduler's cron does, with an optional seconds field
+var jobSchedule = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// jobName is what job names may be made of, as they are part of API paths
+var jobName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
 type Scheduler struct {
-	cron   *cron.Cron
-	logger *logrus.Logger
-	jobs   map[string]struct {
-		id          cron.EntryID
-		schedule    string
-		taskName    string
-		enabled     bool
-		description string
-	}
+	cron           *cron.Cron
+	logger         *logrus.Logger
+	jobs           map[string]scheduledJob
 	mu             sync.RWMutex
 	started        bool
 	tasks          map[string]func() error
 	maxConcurrent  int
 	activeJobs     int
 	activeJobsLock sync.Mutex
+	// running counts the running runs of each job, guarded by activeJobsLock
+	running map[string]int
+	// historySize is how many runs of each job are kept
+	historySize int
+	// events receives a job_started event before every job run and job_succeeded or job_failed after
+	events *events.Bus
 }
 
 func NewScheduler(logger *logrus.Logger, config types.JobConfig) *Scheduler {
+	historySize := config.HistorySize
+	if historySize == 0 {
+		historySize = DefaultJobHistorySize
+	}
 	return &Scheduler{
 		cron:          cron.New(cron.WithSeconds()),
 		logger:        logger,
 		maxConcurrent: config.MaxConcurrent,
-		jobs: make(map[string]struct {
-			id          cron.EntryID
-			schedule    string
-			taskName    string
-			enabled     bool
-			description string
-		}),
-		tasks: make(map[string]func() error),
+		jobs:          make(map[string]scheduledJob),
+		tasks:         make(map[string]func() error),
+		running:       make(map[string]int),
+		historySize:   historySize,
 	}
 }
 
+// scheduledJob is a job, its cron entry, which disabled jobs have none of, its latest runs and where it
+// comes from
+type scheduledJob struct {
+	id      cron.EntryID
+	job     types.Job
+	history *runHistory
+	origin  string
+}
+
+// SetEventBus makes the scheduler publish the runs of its jobs on bus
+func (s *Scheduler) SetEventBus(bus *events.Bus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = bus
+}
+
 func (s *Scheduler) RegisterTask(name string, task func() error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -63,83 +113,280 @@ func (s *Scheduler) LoadPredefinedJobs(jobs []types.Job) error {
 	// Load predefined jobs
 	for _, job := range jobs {
 		if !job.Enabled {
+			// Kept unscheduled, so that it can be enabled at runtime
+			s.jobs[job.Name] = scheduledJob{job: job, history: newRunHistory(s.historySize), origin: OriginPredefined}
 			s.logger.Infof("Skipping disabled job: %s", job.Name)
 			continue
 		}
 
-		task, exists := s.tasks[job.TaskName]
-		if !exists {
-			return fmt.Errorf("task %s not registered", job.TaskName)
+		id, err := s.scheduleLocked(job)
+		if err != nil {
+			return err
 		}
+		s.jobs[job.Name] = scheduledJob{id: id, job: job, history: newRunHistory(s.historySize), origin: OriginPredefined}
+
+		s.logger.WithFields(logrus.Fields{
+			"job_name":    job.Name,
+			"schedule":    job.Schedule,
+			"task":        job.TaskName,
+			"enabled":     job.Enabled,
+			"description": job.Description,
+		}).Info("Job scheduled successfully")
+	}
+
+	return nil
+}
+
+// scheduleLocked adds the runs of job to the cron schedule. Callers must hold s.mu.
+func (s *Scheduler) scheduleLocked(job types.Job) (cron.EntryID, error) {
+	task, exists := s.tasks[job.TaskName]
+	if !exists {
+		return 0, fmt.Errorf("task %s not registered", job.TaskName)
+	}
 
-		wrapper := func() {
-			s.activeJobsLock.Lock()
-			if s.activeJobs >= s.maxConcurrent {
-				s.activeJobsLock.Unlock()
+	wrapper := func() {
+		if err := s.begin(job); err != nil {
+			if errors.Is(err, ErrMaxConcurrent) {
 				s.logger.Warnf("Max concurrent jobs reached, skipping job: %s", job.Name)
-				return
-			}
-			s.activeJobs++
-			s.activeJobsLock.Unlock()
-
-			s.logger.WithFields(logrus.Fields{
-				"job_name":    job.Name,
-				"schedule":    job.Schedule,
-				"task":        job.TaskName,
-				"active_jobs": s.activeJobs,
-			}).Info("Starting job execution")
-
-			start := time.Now()
-
-			if err := task(); err != nil {
-				s.logger.WithFields(logrus.Fields{
-					"job_name": job.Name,
-					"error":    err.Error(),
-					"duration": formatDuration(time.Since(start)),
-				}).Error("Job execution failed")
 			} else {
-				s.logger.WithFields(logrus.Fields{
-					"job_name": job.Name,
-					"duration": formatDuration(time.Since(start)),
-				}).Info("Job execution completed successfully")
+				s.logger.Warnf("Previous run still running, skipping job: %s", job.Name)
 			}
-
-			s.activeJobsLock.Lock()
-			s.activeJobs--
-			s.activeJobsLock.Unlock()
+			return
 		}
+		s.execute(job, task, newRunID())
+	}
+
+	id, err := s.cron.AddFunc(job.Schedule, wrapper)
+	if err != nil {
+		return 0, fmt.Errorf("failed to schedule job %s: %w", job.Name, err)
+	}
+	return id, nil
+}
+
+// AddJob adds a job at runtime, scheduled right away when enabled, even while the scheduler is
+// running. The job's name, schedule and task are validated first, failing with ErrInvalidJob, and a
+// job of the same name fails with ErrJobExists. Added jobs are kept in memory only.
+func (s *Scheduler) AddJob(job types.Job) error {
+	if !jobName.MatchString(job.Name) {
+		return fmt.Errorf("%w: name %q must be letters, digits, '.', '_' or '-'", ErrInvalidJob, job.Name)
+	}
+	if _, err := jobSchedule.Parse(job.Schedule); err != nil {
+		return fmt.Errorf("%w: schedule %q: %v", ErrInvalidJob, job.Schedule, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tasks[job.TaskName]; !exists {
+		return fmt.Errorf("%w: task %q not registered", ErrInvalidJob, job.TaskName)
+	}
+	if _, exists := s.jobs[job.Name]; exists {
+		return fmt.Errorf("job %s: %w", job.Name, ErrJobExists)
+	}
 
-		id, err := s.cron.AddFunc(job.Schedule, wrapper)
+	scheduled := scheduledJob{job: job, history: newRunHistory(s.historySize), origin: OriginAPI}
+	if job.Enabled {
+		id, err := s.scheduleLocked(job)
 		if err != nil {
-			return fmt.Errorf("failed to schedule job %s: %w", job.Name, err)
+			return err
 		}
+		scheduled.id = id
+	}
+	s.jobs[job.Name] = scheduled
+
+	s.logger.WithFields(logrus.Fields{
+		"job_name": job.Name,
+		"schedule": job.Schedule,
+		"task":     job.TaskName,
+		"enabled":  job.Enabled,
+	}).Info("Job added")
+	return nil
+}
 
-		s.jobs[job.Name] = struct {
-			id          cron.EntryID
-			schedule    string
-			taskName    string
-			enabled     bool
-			description string
-		}{
-			id:          id,
-			schedule:    job.Schedule,
-			taskName:    job.TaskName,
-			enabled:     job.Enabled,
-			description: job.Description,
-		}
+// RemoveJob removes a job from the schedule along with its history. A run in progress is not
+// interrupted. Jobs of jobs.predefined are only removed with force, failing with ErrPredefinedJob
+// otherwise, and come back when the predefined jobs are loaded again.
+func (s *Scheduler) RemoveJob(name string, force bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		s.logger.WithFields(logrus.Fields{
-			"job_name":    job.Name,
-			"schedule":    job.Schedule,
-			"task":        job.TaskName,
-			"enabled":     job.Enabled,
-			"description": job.Description,
-		}).Info("Job scheduled successfully")
+	scheduled, exists := s.jobs[name]
+	if !exists {
+		return fmt.Errorf("job %s: %w", name, ErrJobNotFound)
 	}
+	if scheduled.origin == OriginPredefined && !force {
+		return fmt.Errorf("job %s: %w, removing it must be forced", name, ErrPredefinedJob)
+	}
+
+	if scheduled.job.Enabled {
+		s.cron.Remove(scheduled.id)
+	}
+	delete(s.jobs, name)
+	s.logger.WithFields(logrus.Fields{
+		"job_name": name,
+		"origin":   scheduled.origin,
+	}).Info("Job removed")
+	return nil
+}
+
+// EnableJob schedules a disabled job again with its original schedule. Enabling an enabled job does
+// nothing.
+func (s *Scheduler) EnableJob(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	scheduled, exists := s.jobs[name]
+	if !exists {
+		return fmt.Errorf("job %s: %w", name, ErrJobNotFound)
+	}
+	if scheduled.job.Enabled {
+		return nil
+	}
+
+	id, err := s.scheduleLocked(scheduled.job)
+	if err != nil {
+		return err
+	}
+	scheduled.id = id
+	scheduled.job.Enabled = true
+	s.jobs[name] = scheduled
+	s.logger.WithField("job_name", name).Info("Job enabled")
+	return nil
+}
+
+// DisableJob removes a job from the cron schedule, keeping its definition so that it can be enabled
+// again. A run in progress is not interrupted, but no further run starts. Disabling a disabled job
+// does nothing.
+func (s *Scheduler) DisableJob(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scheduled, exists := s.jobs[name]
+	if !exists {
+		return fmt.Errorf("job %s: %w", name, ErrJobNotFound)
+	}
+	if !scheduled.job.Enabled {
+		return nil
+	}
+
+	s.cron.Remove(scheduled.id)
+	scheduled.id = 0
+	scheduled.job.Enabled = false
+	s.jobs[name] = scheduled
+	s.logger.WithField("job_name", name).Info("Job disabled")
+	return nil
+}
+
+// begin reserves a run of job, failing when max concurrent jobs are running or when the job is still
+// running and does not allow overlapping runs. A reserved run must be executed.
+func (s *Scheduler) begin(job types.Job) error {
+	s.activeJobsLock.Lock()
+	defer s.activeJobsLock.Unlock()
+
+	if !job.AllowOverlap && s.running[job.Name] > 0 {
+		return ErrJobRunning
+	}
+	if s.activeJobs >= s.maxConcurrent {
+		return ErrMaxConcurrent
+	}
+	s.activeJobs++
+	s.running[job.Name]++
 	return nil
 }
 
+// execute runs the task of a run reserved with begin, logging it and publishing it on the event bus
+func (s *Scheduler) execute(job types.Job, task func() error, runID string) {
+	defer func() {
+		s.activeJobsLock.Lock()
+		s.activeJobs--
+		if s.running[job.Name]--; s.running[job.Name] == 0 {
+			delete(s.running, job.Name)
+		}
+		s.activeJobsLock.Unlock()
+	}()
+
+	s.logger.WithFields(logrus.Fields{
+		"job_name":    job.Name,
+		"run_id":      runID,
+		"schedule":    job.Schedule,
+		"task":        job.TaskName,
+		"active_jobs": s.activeJobs,
+	}).Info("Starting job execution")
+
+	start := time.Now()
+	s.mu.RLock()
+	bus := s.events
+	history := s.jobs[job.Name].history
+	s.mu.RUnlock()
+	bus.Publish(events.Event{Type: events.JobStarted, Job: &events.JobRun{Name: job.Name, RunID: runID, Task: job.TaskName, StartedAt: start}})
+
+	finished := &events.JobRun{Name: job.Name, RunID: runID, Task: job.TaskName, StartedAt: start}
+	outcome := events.JobSucceeded
+	if err := task(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_name": job.Name,
+			"run_id":   runID,
+			"error":    err.Error(),
+			"duration": formatDuration(time.Since(start)),
+		}).Error("Job execution failed")
+		finished.Error = err.Error()
+		outcome = events.JobFailed
+	} else {
+		s.logger.WithFields(logrus.Fields{
+			"job_name": job.Name,
+			"run_id":   runID,
+			"duration": formatDuration(time.Since(start)),
+		}).Info("Job execution completed successfully")
+	}
+	finished.DurationMS = time.Since(start).Milliseconds()
+	bus.Publish(events.Event{Type: outcome, Job: finished})
+
+	run := JobRun{RunID: runID, StartedAt: start, DurationMS: finished.DurationMS, Status: RunSucceeded, Error: finished.Error}
+	if outcome == events.JobFailed {
+		run.Status = RunFailed
+	}
+	history.add(run)
+}
+
+// RunJobNow starts a run of a scheduled job right away, outside of its schedule, and returns the ID of
+// the run. The run goes through the same limits as scheduled runs: it fails with ErrMaxConcurrent when
+// jobs.max_concurrent jobs are running and with ErrJobRunning when the job is still running and does not
+// allow overlapping runs. Unknown jobs fail with ErrJobNotFound and disabled ones with ErrJobDisabled.
+func (s *Scheduler) RunJobNow(name string) (string, error) {
+	s.mu.RLock()
+	scheduled, exists := s.jobs[name]
+	task, registered := s.tasks[scheduled.job.TaskName]
+	s.mu.RUnlock()
+
+	if !exists {
+		return "", fmt.Errorf("job %s: %w", name, ErrJobNotFound)
+	}
+	if !scheduled.job.Enabled {
+		return "", fmt.Errorf("job %s: %w", name, ErrJobDisabled)
+	}
+	if !registered {
+		return "", fmt.Errorf("task %s not registered", scheduled.job.TaskName)
+	}
+	if err := s.begin(scheduled.job); err != nil {
+		return "", fmt.Errorf("job %s: %w", name, err)
+	}
+
+	runID := newRunID()
+	s.logger.WithFields(logrus.Fields{
+		"job_name": name,
+		"run_id":   runID,
+	}).Info("Job triggered manually")
+	go s.execute(scheduled.job, task, runID)
+	return runID, nil
+}
+
+// newRunID returns a random ID for a job run
+func newRunID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
 func formatDuration(d time.Duration) string {
 	if d < time.Millisecond {
 		return fmt.Sprintf("%.2fµs", float64(d.Microseconds()))
@@ -163,22 +410,17 @@ func (s *Scheduler) GetJobStatus(name string) (bool, string, error) {
 		return false, "", fmt.Errorf("job %s not found", name)
 	}
 
-	return job.enabled, job.description, nil
+	return job.job.Enabled, job.job.Description, nil
 }
 
-func (s *Scheduler) ListJobs() []types.Job {
+// ListJobs returns the jobs with their latest run and next scheduled run
+func (s *Scheduler) ListJobs() []JobState {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	jobs := make([]types.Job, 0, len(s.jobs))
-	for name, job := range s.jobs {
-		jobs = append(jobs, types.Job{
-			Name:        name,
-			Schedule:    job.schedule,
-			TaskName:    job.taskName,
-			Enabled:     job.enabled,
-			Description: job.description,
-		})
+	jobs := make([]JobState, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, s.stateLocked(job))
 	}
 
 	return jobs
@@ -199,17 +441,19 @@ func (s *Scheduler) Start() error {
 	return nil
 }
 
+// Stop stops scheduling runs and waits for the running ones to finish. It waits without holding s.mu,
+// as runs read the scheduler's state while they start and finish.
 func (s *Scheduler) Stop() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.started {
+		s.mu.Unlock()
 		return
 	}
-
 	ctx := s.cron.Stop()
-	<-ctx.Done()
 	s.started = false
+	s.mu.Unlock()
+
+	<-ctx.Done()
 	s.logger.Info("Scheduler stopped")
 }
 
@@ -0,0 +1,125 @@
+package cron
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// DefaultJobHistorySize is how many runs of each job are kept unless jobs.history_size says otherwise
+const DefaultJobHistorySize = 50
+
+// JobRun is a finished run of a job
+type JobRun struct {
+	RunID      string    `json:"run_id"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	// Status is succeeded or failed, with Error telling why
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// JobState is a job with where it comes from, OriginPredefined or OriginAPI, its latest finished run
+// and its next scheduled run, which disabled jobs and jobs of a stopped scheduler have none of
+type JobState struct {
+	types.Job
+	Origin       string    `json:"origin"`
+	LastRun      time.Time `json:"last_run,omitzero"`
+	LastStatus   string    `json:"last_status,omitempty"`
+	LastDuration string    `json:"last_duration,omitempty"`
+	NextRun      time.Time `json:"next_run,omitzero"`
+}
+
+// runHistory is a ring buffer of the latest runs of a job. A nil runHistory keeps nothing.
+type runHistory struct {
+	mu   sync.Mutex
+	runs []JobRun
+	// next is where the next run is written once the buffer is full
+	next int
+	size int
+}
+
+// newRunHistory keeps up to size runs, none when size is not positive
+func newRunHistory(size int) *runHistory {
+	if size <= 0 {
+		return nil
+	}
+	return &runHistory{size: size}
+}
+
+func (h *runHistory) add(run JobRun) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.runs) < h.size {
+		h.runs = append(h.runs, run)
+		return
+	}
+	h.runs[h.next] = run
+	h.next = (h.next + 1) % h.size
+}
+
+// latest returns up to limit runs, newest first, every kept run when limit is not positive
+func (h *runHistory) latest(limit int) []JobRun {
+	runs := []JobRun{}
+	if h == nil {
+		return runs
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if limit <= 0 || limit > len(h.runs) {
+		limit = len(h.runs)
+	}
+	// The newest run is right before next
+	for i := 1; i <= limit; i++ {
+		runs = append(runs, h.runs[(h.next-i+len(h.runs))%len(h.runs)])
+	}
+	return runs
+}
+
+// JobHistory returns up to limit of the job's latest runs, newest first, every kept run when limit is
+// not positive. Unknown jobs fail with ErrJobNotFound.
+func (s *Scheduler) JobHistory(name string, limit int) ([]JobRun, error) {
+	s.mu.RLock()
+	scheduled, exists := s.jobs[name]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("job %s: %w", name, ErrJobNotFound)
+	}
+	return scheduled.history.latest(limit), nil
+}
+
+// JobState returns the job with its latest run and next scheduled run. Unknown jobs fail with
+// ErrJobNotFound.
+func (s *Scheduler) JobState(name string) (JobState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	scheduled, exists := s.jobs[name]
+	if !exists {
+		return JobState{}, fmt.Errorf("job %s: %w", name, ErrJobNotFound)
+	}
+	return s.stateLocked(scheduled), nil
+}
+
+// stateLocked returns the job with its latest run and next scheduled run. Callers must hold s.mu.
+func (s *Scheduler) stateLocked(scheduled scheduledJob) JobState {
+	state := JobState{Job: scheduled.job, Origin: scheduled.origin}
+	if last := scheduled.history.latest(1); len(last) == 1 {
+		state.LastRun = last[0].StartedAt
+		state.LastStatus = last[0].Status
+		state.LastDuration = (time.Duration(last[0].DurationMS) * time.Millisecond).String()
+	}
+	if scheduled.job.Enabled && s.started {
+		state.NextRun = s.cron.Entry(scheduled.id).Next
+	}
+	return state
+}
@@ -2,13 +2,18 @@ package cron
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/0xPuncker/cosmos-watcher/internal/events"
 	"github.com/0xPuncker/cosmos-watcher/pkg/types"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestScheduler(t *testing.T) {
@@ -212,3 +217,323 @@ func TestSchedulerState(t *testing.T) {
 	scheduler.Stop()
 	assert.False(t, scheduler.IsRunning())
 }
+
+func TestJobEvents(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	jobs := []types.Job{{Name: "failing-job", Schedule: "* * * * * *", TaskName: "failing-task", Enabled: true}}
+
+	scheduler := NewScheduler(logger, types.JobConfig{MaxConcurrent: 1, Predefined: jobs})
+	bus := events.NewBus(0)
+	subscription := bus.Subscribe()
+	defer subscription.Close()
+	scheduler.SetEventBus(bus)
+	scheduler.RegisterTask("failing-task", func() error { return errors.New("registry unreachable") })
+	require.NoError(t, scheduler.LoadPredefinedJobs(jobs))
+	require.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+
+	next := func() events.Event {
+		select {
+		case event := <-subscription.Events():
+			return event
+		case <-time.After(3 * time.Second):
+			require.FailNow(t, "no job event published")
+			return events.Event{}
+		}
+	}
+
+	started := next()
+	assert.Equal(t, events.JobStarted, started.Type)
+	assert.Equal(t, "failing-job", started.Job.Name)
+	assert.Equal(t, "failing-task", started.Job.Task)
+	assert.Empty(t, started.Job.Error)
+
+	failed := next()
+	assert.Equal(t, events.JobFailed, failed.Type)
+	assert.Equal(t, "registry unreachable", failed.Job.Error)
+	assert.Equal(t, started.Job.StartedAt, failed.Job.StartedAt)
+}
+
+func TestRunJobNow(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	jobs := []types.Job{
+		{Name: "hourly-job", Schedule: "0 0 * * * *", TaskName: "recording-task", Enabled: true},
+		{Name: "overlapping-job", Schedule: "0 0 * * * *", TaskName: "recording-task", Enabled: true, AllowOverlap: true},
+	}
+
+	scheduler := NewScheduler(logger, types.JobConfig{MaxConcurrent: 2, Predefined: jobs})
+	bus := events.NewBus(0)
+	subscription := bus.Subscribe()
+	defer subscription.Close()
+	scheduler.SetEventBus(bus)
+
+	var invocations atomic.Int32
+	release := make(chan struct{})
+	scheduler.RegisterTask("recording-task", func() error {
+		invocations.Add(1)
+		<-release
+		return nil
+	})
+	require.NoError(t, scheduler.LoadPredefinedJobs(jobs))
+
+	runID, err := scheduler.RunJobNow("hourly-job")
+	require.NoError(t, err)
+	assert.NotEmpty(t, runID)
+	started := <-subscription.Events()
+	assert.Equal(t, events.JobStarted, started.Type)
+	assert.Equal(t, "hourly-job", started.Job.Name)
+	assert.Equal(t, runID, started.Job.RunID)
+	assert.Eventually(t, func() bool { return invocations.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	_, err = scheduler.RunJobNow("hourly-job")
+	assert.ErrorIs(t, err, ErrJobRunning, "a running job is not run again")
+	_, err = scheduler.RunJobNow("unknown-job")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	// Overlapping runs are allowed, up to the maximum of concurrent jobs
+	_, err = scheduler.RunJobNow("overlapping-job")
+	require.NoError(t, err)
+	<-subscription.Events()
+	_, err = scheduler.RunJobNow("overlapping-job")
+	assert.ErrorIs(t, err, ErrMaxConcurrent)
+
+	close(release)
+	finished := map[string]events.Event{}
+	for len(finished) < 2 {
+		event := <-subscription.Events()
+		finished[event.Job.Name] = event
+	}
+	assert.Equal(t, events.JobSucceeded, finished["hourly-job"].Type)
+	assert.Equal(t, runID, finished["hourly-job"].Job.RunID)
+	assert.Equal(t, int32(2), invocations.Load())
+
+	assert.Eventually(t, func() bool {
+		_, err := scheduler.RunJobNow("hourly-job")
+		return err == nil
+	}, time.Second, 10*time.Millisecond, "a finished job can be run again")
+}
+
+func TestEnableDisableJob(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	jobs := []types.Job{
+		{Name: "ticking-job", Schedule: "* * * * * *", TaskName: "counting-task", Enabled: true},
+		{Name: "off-job", Schedule: "* * * * * *", TaskName: "counting-task", Enabled: false},
+	}
+
+	scheduler := NewScheduler(logger, types.JobConfig{MaxConcurrent: 10, Predefined: jobs})
+	var runs atomic.Int32
+	scheduler.RegisterTask("counting-task", func() error {
+		runs.Add(1)
+		return nil
+	})
+	require.NoError(t, scheduler.LoadPredefinedJobs(jobs))
+	require.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+
+	require.Eventually(t, func() bool { return runs.Load() > 0 }, 3*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, scheduler.DisableJob("ticking-job"))
+	enabled, _, err := scheduler.GetJobStatus("ticking-job")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	// A run dispatched right before disabling may still be finishing
+	time.Sleep(100 * time.Millisecond)
+	stopped := runs.Load()
+	time.Sleep(2500 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load(), "a disabled job stops firing")
+	_, err = scheduler.RunJobNow("ticking-job")
+	assert.ErrorIs(t, err, ErrJobDisabled)
+
+	require.NoError(t, scheduler.EnableJob("ticking-job"))
+	assert.Eventually(t, func() bool { return runs.Load() > stopped }, 3*time.Second, 50*time.Millisecond,
+		"an enabled job resumes on its schedule")
+
+	listed := map[string]JobState{}
+	for _, job := range scheduler.ListJobs() {
+		listed[job.Name] = job
+	}
+	assert.True(t, listed["ticking-job"].Enabled)
+	assert.False(t, listed["off-job"].Enabled, "disabled jobs are listed")
+	assert.Equal(t, "* * * * * *", listed["ticking-job"].Schedule)
+
+	assert.NoError(t, scheduler.DisableJob("off-job"), "disabling a disabled job does nothing")
+	assert.ErrorIs(t, scheduler.EnableJob("unknown-job"), ErrJobNotFound)
+	assert.ErrorIs(t, scheduler.DisableJob("unknown-job"), ErrJobNotFound)
+}
+
+func TestJobHistory(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	jobs := []types.Job{{Name: "flaky-job", Schedule: "@every 1h", TaskName: "flaky-task", Enabled: true}}
+
+	scheduler := NewScheduler(logger, types.JobConfig{MaxConcurrent: 1, Predefined: jobs, HistorySize: 3})
+
+	// Odd runs succeed, even runs fail
+	var calls atomic.Int32
+	scheduler.RegisterTask("flaky-task", func() error {
+		if calls.Add(1)%2 == 0 {
+			return fmt.Errorf("run %d failed", calls.Load())
+		}
+		return nil
+	})
+	require.NoError(t, scheduler.LoadPredefinedJobs(jobs))
+
+	runs, err := scheduler.JobHistory("flaky-job", 0)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	var runIDs []string
+	for i := 0; i < 4; i++ {
+		// The previous run is released right after it is recorded
+		var runID string
+		require.Eventually(t, func() bool {
+			var err error
+			runID, err = scheduler.RunJobNow("flaky-job")
+			return err == nil
+		}, time.Second, 5*time.Millisecond)
+		runIDs = append(runIDs, runID)
+		require.Eventually(t, func() bool {
+			runs, _ := scheduler.JobHistory("flaky-job", 1)
+			return len(runs) == 1 && runs[0].RunID == runID
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	// The oldest run fell out of the buffer, the newest comes first
+	runs, err = scheduler.JobHistory("flaky-job", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+	for i, run := range runs {
+		assert.Equal(t, runIDs[3-i], run.RunID)
+	}
+	assert.Equal(t, RunFailed, runs[0].Status)
+	assert.Equal(t, "run 4 failed", runs[0].Error)
+	assert.Equal(t, RunSucceeded, runs[1].Status)
+	assert.Empty(t, runs[1].Error)
+	assert.Equal(t, RunFailed, runs[2].Status)
+	assert.False(t, runs[0].StartedAt.Before(runs[1].StartedAt))
+
+	runs, err = scheduler.JobHistory("flaky-job", 2)
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+	_, err = scheduler.JobHistory("unknown-job", 0)
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	listed := scheduler.ListJobs()
+	require.Len(t, listed, 1)
+	assert.Equal(t, RunFailed, listed[0].LastStatus)
+	assert.NotEmpty(t, listed[0].LastDuration)
+	assert.Equal(t, runs[0].StartedAt, listed[0].LastRun)
+	assert.True(t, listed[0].NextRun.IsZero(), "a stopped scheduler runs nothing next")
+
+	require.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+	next := scheduler.ListJobs()[0].NextRun
+	assert.WithinDuration(t, time.Now().Add(time.Hour), next, time.Minute)
+}
+
+func TestAddRemoveJob(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	jobs := []types.Job{{Name: "config-job", Schedule: "@every 1h", TaskName: "counting-task", Enabled: true}}
+
+	scheduler := NewScheduler(logger, types.JobConfig{MaxConcurrent: 10, Predefined: jobs})
+	var runs atomic.Int32
+	scheduler.RegisterTask("counting-task", func() error {
+		runs.Add(1)
+		return nil
+	})
+	require.NoError(t, scheduler.LoadPredefinedJobs(jobs))
+	require.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+
+	// Added while the scheduler runs, the job fires without a restart
+	require.NoError(t, scheduler.AddJob(types.Job{Name: "api-job", Schedule: "* * * * * *", TaskName: "counting-task", Enabled: true}))
+	require.Eventually(t, func() bool { return runs.Load() > 0 }, 3*time.Second, 50*time.Millisecond)
+
+	state, err := scheduler.JobState("api-job")
+	require.NoError(t, err)
+	assert.Equal(t, OriginAPI, state.Origin)
+	assert.False(t, state.NextRun.IsZero())
+	listed := map[string]JobState{}
+	for _, job := range scheduler.ListJobs() {
+		listed[job.Name] = job
+	}
+	assert.Equal(t, OriginPredefined, listed["config-job"].Origin)
+	assert.Equal(t, OriginAPI, listed["api-job"].Origin)
+
+	assert.ErrorIs(t, scheduler.AddJob(types.Job{Name: "api-job", Schedule: "@every 1h", TaskName: "counting-task"}), ErrJobExists)
+	for name, job := range map[string]types.Job{
+		"invalid schedule": {Name: "bad-schedule", Schedule: "every minute", TaskName: "counting-task", Enabled: true},
+		"unknown task":     {Name: "bad-task", Schedule: "@every 1h", TaskName: "unknown-task", Enabled: true},
+		"unknown disabled": {Name: "bad-task", Schedule: "@every 1h", TaskName: "unknown-task"},
+		"empty name":       {Schedule: "@every 1h", TaskName: "counting-task"},
+		"name with slash":  {Name: "a/b", Schedule: "@every 1h", TaskName: "counting-task"},
+	} {
+		assert.ErrorIs(t, scheduler.AddJob(job), ErrInvalidJob, name)
+	}
+	assert.Len(t, scheduler.ListJobs(), 2, "invalid jobs are not added")
+
+	// A disabled job is added unscheduled and can be enabled later
+	require.NoError(t, scheduler.AddJob(types.Job{Name: "api-off", Schedule: "@every 1h", TaskName: "counting-task"}))
+	require.NoError(t, scheduler.EnableJob("api-off"))
+
+	require.NoError(t, scheduler.RemoveJob("api-job", false))
+	// A run dispatched right before removing may still be finishing
+	time.Sleep(100 * time.Millisecond)
+	stopped := runs.Load()
+	time.Sleep(1500 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load(), "a removed job stops firing")
+	_, err = scheduler.JobState("api-job")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+	assert.ErrorIs(t, scheduler.RemoveJob("api-job", false), ErrJobNotFound)
+
+	assert.ErrorIs(t, scheduler.RemoveJob("config-job", false), ErrPredefinedJob)
+	require.NoError(t, scheduler.RemoveJob("config-job", true))
+	require.NoError(t, scheduler.RemoveJob("api-off", false))
+	assert.Empty(t, scheduler.ListJobs())
+}
+
+func TestSchedulerStopWaitsForRunningJob(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	jobs := []types.Job{{Name: "busy-job", Schedule: "* * * * * *", TaskName: "busy-task", Enabled: true}}
+
+	scheduler := NewScheduler(logger, types.JobConfig{MaxConcurrent: 1, Predefined: jobs})
+	var once sync.Once
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+	var listed atomic.Bool
+	scheduler.RegisterTask("busy-task", func() error {
+		once.Do(func() { close(started) })
+		<-proceed
+		// Reading the scheduler's state while it stops, as runs do when they finish
+		listed.Store(len(scheduler.ListJobs()) == 1)
+		return nil
+	})
+	require.NoError(t, scheduler.LoadPredefinedJobs(jobs))
+	require.NoError(t, scheduler.Start())
+
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		require.FailNow(t, "job did not run")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		scheduler.Stop()
+	}()
+	assert.Eventually(t, func() bool { return !scheduler.IsRunning() }, time.Second, 10*time.Millisecond)
+	close(proceed)
+
+	select {
+	case <-stopped:
+	case <-time.After(3 * time.Second):
+		require.FailNow(t, "Stop did not return once the running job finished")
+	}
+	assert.True(t, listed.Load())
+}
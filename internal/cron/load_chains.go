@@ -1,6 +1,7 @@
 package cron
 
 import (
+	"context"
 	"strings"
 	"sync"
 
@@ -10,11 +11,11 @@ import (
 )
 
 type LoadChainsJob struct {
-	registry *chain.ChainRegistry
+	registry Registry
 	logger   *logrus.Logger
 }
 
-func NewLoadChainsJob(registry *chain.ChainRegistry, logger *logrus.Logger) *LoadChainsJob {
+func NewLoadChainsJob(registry Registry, logger *logrus.Logger) *LoadChainsJob {
 	return &LoadChainsJob{
 		registry: registry,
 		logger:   logger,
@@ -28,6 +29,11 @@ func (j *LoadChainsJob) Run() error {
 		return err
 	}
 
+	if chainConfig.Embedded {
+		j.logger.Warn("!!! No chains.yaml found, falling back to the chain list embedded in the binary !!!")
+		j.logger.Warn("!!! Only a minimal set of hub chains is monitored; provide config/chains.yaml to monitor your chains !!!")
+	}
+
 	var chainNames []string
 
 	j.logger.Infof("Loading chains from config file...")
@@ -57,19 +63,43 @@ func (j *LoadChainsJob) Run() error {
 		len(chainConfig.Mainnet),
 		len(chainConfig.Testnet))
 
+	// Set before pre-fetching so endpoint overrides and registry aliases already apply to it
+	if err := j.registry.SetChainConfigs(chainConfig.ChainConfigs()); err != nil {
+		j.logger.Errorf("Invalid chain config: %v", err)
+		return err
+	}
+
 	type chainError struct {
 		name string
 		err  error
 	}
 
 	var (
+		ctx          = context.Background()
 		wg           sync.WaitGroup
 		mu           sync.Mutex
 		semaphore    = make(chan struct{}, 5)
 		failedChains []chainError
 	)
 
-	for _, chainName := range chainNames {
+	// Chains missing from the chain-registry are still monitored, so they are picked up once they are
+	// listed, but there is nothing to pre-fetch for them
+	existingChains, checkFailures := j.registry.FilterExistingChains(ctx, chainNames)
+	checked := make(map[string]bool, len(chainNames))
+	for _, name := range existingChains {
+		checked[name] = true
+	}
+	for _, failure := range checkFailures {
+		checked[failure.Chain] = true
+		failedChains = append(failedChains, chainError{name: failure.Chain, err: failure.Err})
+	}
+	for _, name := range chainNames {
+		if !checked[name] {
+			failedChains = append(failedChains, chainError{name: name, err: &chain.ErrChainNotFound{Chain: name}})
+		}
+	}
+
+	for _, chainName := range existingChains {
 		wg.Add(1)
 		go func(name string) {
 			defer wg.Done()
@@ -77,7 +107,7 @@ func (j *LoadChainsJob) Run() error {
 			defer func() { <-semaphore }()
 
 			j.logger.Debugf("Pre-fetching chain info for %s", name)
-			_, err := j.registry.GetChainInfo(name, true)
+			_, err := j.registry.GetChainInfo(ctx, name, true)
 			if err != nil {
 				mu.Lock()
 				failedChains = append(failedChains, chainError{name: name, err: err})
@@ -85,7 +115,7 @@ func (j *LoadChainsJob) Run() error {
 				return
 			}
 
-			_, err = j.registry.GetUpgradeInfo(name, true)
+			_, err = j.registry.GetUpgradeInfo(ctx, name, true)
 			if err != nil {
 				j.logger.Debugf("No upgrade info available for %s: %v", name, err)
 			}
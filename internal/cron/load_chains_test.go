@@ -1,6 +1,8 @@
 package cron
 
 import (
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -8,6 +10,7 @@ import (
 
 	"github.com/0xPuncker/cosmos-watcher/internal/chain"
 	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/0xPuncker/cosmos-watcher/internal/testutil"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -118,3 +121,35 @@ func TestLoadChainsJob(t *testing.T) {
 	os.Unsetenv("CHAIN_REGISTRY_BASE_URL")
 	os.Unsetenv("LOG_LEVEL")
 }
+
+func TestLoadChainsJob_PrefetchesExistingChains(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "config"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config", "chains.yaml"), []byte(`mainnet:
+  - name: cosmoshub
+    network: mainnet
+  - name: newchain
+    network: mainnet
+  - name: osmosis
+    network: mainnet
+`), 0o644))
+	t.Chdir(dir)
+
+	registry := testutil.NewFakeRegistry(
+		&chain.ChainInfo{Name: "cosmoshub", Network: "mainnet"},
+		&chain.ChainInfo{Name: "osmosis", Network: "mainnet"},
+	)
+	registry.ChainErrors["osmosis"] = &chain.ErrNetwork{Err: errors.New("connection refused")}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	require.NoError(t, NewLoadChainsJob(registry, logger).Run())
+
+	monitored, err := registry.GetMonitoredChains()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"cosmoshub", "newchain", "osmosis"}, monitored,
+		"chains that are missing or could not be checked are still monitored")
+	assert.Equal(t, 1, registry.UpgradeLookups("cosmoshub"))
+	assert.Zero(t, registry.UpgradeLookups("newchain"), "a chain missing from the chain-registry is not pre-fetched")
+	assert.Zero(t, registry.UpgradeLookups("osmosis"), "a chain whose check failed is not pre-fetched")
+}
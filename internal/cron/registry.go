@@ -0,0 +1,62 @@
+package cron
+
+import (
+	"context"
+	"errors"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// Registry is the part of the chain registry the upgrade checker, the load-chains job and the
+// auto-discover job depend on
+type Registry interface {
+	GetChainInfo(ctx context.Context, chainName string, forceRefresh bool) (*chain.ChainInfo, error)
+	GetUpgradeInfo(ctx context.Context, chainName string, forceRefresh bool) (*types.UpgradeInfo, error)
+	GetMonitoredChains() ([]string, error)
+	SetMonitoredChains(chains []string)
+	GetChainConfig(chainName string) (types.ChainConfig, bool)
+	SetChainConfigs(configs []types.ChainConfig) error
+	ChainExists(ctx context.Context, chainName string) bool
+	FilterExistingChains(ctx context.Context, chains []string) ([]string, []chain.ChainCheckFailure)
+	GetCurrentHeight(ctx context.Context, chainName string) (int64, error)
+	GetNodeVersion(ctx context.Context, chainName string) (string, error)
+	GetVoteTally(ctx context.Context, chainName, proposal string) (*types.VoteTally, error)
+	GetUpgradeBinaries(ctx context.Context, chainName string) (map[string]chain.UpgradeBinary, error)
+	ProbeEndpoints(ctx context.Context, chainName string) (chain.EndpointHealth, error)
+	UpgradeStatus(chainName string, upgrade *types.UpgradeInfo) string
+	UpgradeFetchFailed(chainName string) bool
+	InMaintenance() bool
+	IsDegraded() bool
+	Links() *chain.LinkBuilder
+	PendingPolkachuChains(ctx context.Context) ([]string, error)
+	AutoDiscoveredChains() []string
+	SetAutoDiscoveredChains(chains []string)
+	ChainSource(chainName string) string
+}
+
+var _ Registry = (*chain.ChainRegistry)(nil)
+
+// linuxBinaryURL returns the linux/amd64 download URL among an upgrade's binaries, empty when the
+// chain-registry does not list it
+func linuxBinaryURL(binaries map[string]chain.UpgradeBinary) string {
+	return binaries[chain.PlatformLinuxAMD64].URL
+}
+
+// isChainNotFound reports whether err is the registry missing the chain
+func isChainNotFound(err error) bool {
+	var notFound *chain.ErrChainNotFound
+	return errors.As(err, &notFound)
+}
+
+// isNetworkError reports whether err is a request that got no response
+func isNetworkError(err error) bool {
+	var network *chain.ErrNetwork
+	return errors.As(err, &network)
+}
+
+// isBadResponse reports whether err is a response that was not the JSON asked for
+func isBadResponse(err error) bool {
+	var bad *chain.ErrBadResponse
+	return errors.As(err, &bad)
+}
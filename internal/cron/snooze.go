@@ -0,0 +1,61 @@
+package cron
+
+import (
+	"sort"
+	"time"
+)
+
+// SnoozeStatus describes a chain whose notifications are currently suppressed
+type SnoozeStatus struct {
+	Chain string    `json:"chain"`
+	Until time.Time `json:"until"`
+}
+
+// Snooze suppresses notifications for a chain for the given duration. The chain keeps being checked,
+// so upgrades found while snoozed are not announced again once the snooze expires.
+func (uc *UpgradeChecker) Snooze(chain string, duration time.Duration) time.Time {
+	uc.snoozeMu.Lock()
+	defer uc.snoozeMu.Unlock()
+
+	until := uc.now().Add(duration)
+	uc.snoozes[chain] = until
+	uc.logger.WithField("chain", chain).Infof("Snoozed notifications until %s", until.Format(time.RFC3339))
+	return until
+}
+
+// SnoozedUntil reports whether notifications for a chain are snoozed and until when
+func (uc *UpgradeChecker) SnoozedUntil(chain string) (time.Time, bool) {
+	uc.snoozeMu.RLock()
+	defer uc.snoozeMu.RUnlock()
+
+	until, ok := uc.snoozes[chain]
+	if !ok || !uc.now().Before(until) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// ActiveSnoozes lists the chains that are currently snoozed, soonest expiry first. Expired snoozes
+// are dropped.
+func (uc *UpgradeChecker) ActiveSnoozes() []SnoozeStatus {
+	uc.snoozeMu.Lock()
+	defer uc.snoozeMu.Unlock()
+
+	now := uc.now()
+	snoozes := make([]SnoozeStatus, 0, len(uc.snoozes))
+	for chain, until := range uc.snoozes {
+		if !now.Before(until) {
+			delete(uc.snoozes, chain)
+			continue
+		}
+		snoozes = append(snoozes, SnoozeStatus{Chain: chain, Until: until})
+	}
+
+	sort.Slice(snoozes, func(i, j int) bool {
+		if snoozes[i].Until.Equal(snoozes[j].Until) {
+			return snoozes[i].Chain < snoozes[j].Chain
+		}
+		return snoozes[i].Until.Before(snoozes[j].Until)
+	})
+	return snoozes
+}
@@ -1,35 +1,249 @@
 package cron
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"maps"
+	"strconv"
 	"sync"
 	"time"
 
-	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/0xPuncker/cosmos-watcher/internal/events"
 	"github.com/0xPuncker/cosmos-watcher/internal/notifications"
 	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/0xPuncker/cosmos-watcher/pkg/utils"
+	"github.com/0xPuncker/cosmos-watcher/pkg/version"
 	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
 )
 
+// defaultDedupHeightTolerance is how many blocks apart two reports of an upgrade may be and still be
+// treated as the same upgrade
+const defaultDedupHeightTolerance = 50
+
+// DefaultUrgentWindow is how close an upgrade has to be before dead endpoints raise a critical alert
+const DefaultUrgentWindow = 6 * time.Hour
+
 type UpgradeChecker struct {
-	registry   *chain.ChainRegistry
+	registry   Registry
 	logger     *logrus.Logger
 	slack      *notifications.SlackService
 	cron       *cron.Cron
 	lastChecks map[string]time.Time
 	mu         sync.RWMutex
+	settings   config.NotificationsConfig
+	// ctx is passed to notification sends and cancelled by Stop so shutdown never waits on Slack
+	ctx    context.Context
+	cancel context.CancelFunc
+	// unresolved holds chains that failed to resolve at least once, resolved those that have
+	// resolved since; together they make the "now monitoring" notification fire only once
+	unresolved map[string]bool
+	resolved   map[string]bool
+	// inProgressNotified maps a chain to the scheduled time of the upgrade announced as in progress
+	inProgressNotified map[string]time.Time
+	// executed maps a chain to the scheduled time of the upgrade it was seen to have passed
+	executed map[string]time.Time
+	// noHealthyEndpointsNotified maps a chain to the scheduled time of the upgrade it was alerted for
+	noHealthyEndpointsNotified map[string]time.Time
+	urgentWindow               time.Duration
+	// lastUpgrades keeps the last reported upgrade per chain to recognise it when another source reports it
+	lastUpgrades         map[string]*types.UpgradeInfo
+	dedupHeightTolerance int64
+	// groupSlack holds a Slack service per chain group that routes to its own channel. Both are
+	// replaced under routesMu as well as mu, so stall alerts find their recipients during a check.
+	routesMu   sync.RWMutex
+	groups     config.GroupsConfig
+	groupSlack map[string]*notifications.SlackService
+	// snoozes is guarded by its own lock so the API can snooze chains while a check is running
+	snoozeMu sync.RWMutex
+	snoozes  map[string]time.Time
+	now      func() time.Time
+	// deadLetters records sends that failed so they can be replayed after a restart
+	deadLetters *notifications.DeadLetterStore
+	// history keeps the notifications that were delivered, for the audit trail
+	history *notifications.NotificationHistory
+	// pendingBatch holds the upgrades detected in the current batch window, oldest first
+	batchWindow  time.Duration
+	pendingBatch []*types.UpgradeInfo
+	// events receives the changes to the pending upgrades, published keeps the last upgrade published
+	// per chain to tell them
+	events    *events.Bus
+	published map[string]*types.UpgradeInfo
 }
 
-func NewUpgradeChecker(registry *chain.ChainRegistry, logger *logrus.Logger, slack *notifications.SlackService) *UpgradeChecker {
+func NewUpgradeChecker(registry Registry, logger *logrus.Logger, slack *notifications.SlackService) *UpgradeChecker {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &UpgradeChecker{
-		registry:   registry,
-		logger:     logger,
-		slack:      slack,
-		cron:       cron.New(),
-		lastChecks: make(map[string]time.Time),
+		registry:                   registry,
+		logger:                     logger,
+		slack:                      slack,
+		cron:                       cron.New(),
+		lastChecks:                 make(map[string]time.Time),
+		lastUpgrades:               make(map[string]*types.UpgradeInfo),
+		dedupHeightTolerance:       defaultDedupHeightTolerance,
+		unresolved:                 make(map[string]bool),
+		resolved:                   make(map[string]bool),
+		inProgressNotified:         make(map[string]time.Time),
+		executed:                   make(map[string]time.Time),
+		noHealthyEndpointsNotified: make(map[string]time.Time),
+		urgentWindow:               DefaultUrgentWindow,
+		snoozes:                    make(map[string]time.Time),
+		published:                  make(map[string]*types.UpgradeInfo),
+		now:                        time.Now,
+		ctx:                        ctx,
+		cancel:                     cancel,
+	}
+}
+
+func (uc *UpgradeChecker) SetNotificationsConfig(settings config.NotificationsConfig) {
+	locale := uc.parseLocale(settings.Language, notifications.DefaultLocale)
+	urgentWindow := DefaultUrgentWindow
+	if settings.UrgentWindow != "" {
+		d, err := time.ParseDuration(settings.UrgentWindow)
+		if err != nil || d <= 0 {
+			uc.logger.Warnf("Invalid urgent_window %q, using %s", settings.UrgentWindow, DefaultUrgentWindow)
+		} else {
+			urgentWindow = d
+		}
+	}
+
+	var batchWindow time.Duration
+	if settings.BatchWindow != "" {
+		d, err := time.ParseDuration(settings.BatchWindow)
+		if err != nil || d <= 0 {
+			uc.logger.Warnf("Invalid batch_window %q, sending upgrades right away", settings.BatchWindow)
+		} else {
+			batchWindow = d
+		}
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.settings = settings
+	uc.urgentWindow = urgentWindow
+	uc.batchWindow = batchWindow
+	if uc.slack != nil {
+		uc.slack.SetLocale(locale)
+	}
+}
+
+// parseLocale resolves a configured language, logging and using fallback when it is not supported
+func (uc *UpgradeChecker) parseLocale(language string, fallback notifications.Locale) notifications.Locale {
+	if language == "" {
+		return fallback
+	}
+	locale, err := notifications.ParseLocale(language)
+	if err != nil {
+		uc.logger.Warnf("%v, using %s", err, fallback)
+		return fallback
+	}
+	return locale
+}
+
+// SetGroups configures chain groups; notifications for chains in a group with a Slack webhook are
+// routed to that group's channel instead of the default one, in the group's language if it sets one.
+// Call it after SetNotificationsConfig so groups inherit the default language.
+func (uc *UpgradeChecker) SetGroups(groups config.GroupsConfig) {
+	uc.mu.RLock()
+	defaultLocale := uc.parseLocale(uc.settings.Language, notifications.DefaultLocale)
+	uc.mu.RUnlock()
+
+	groupSlack := make(map[string]*notifications.SlackService)
+	for name, group := range groups {
+		if group.SlackWebhookURL == "" {
+			continue
+		}
+		slack, err := notifications.NewSlackServiceForWebhook(uc.logger, group.SlackWebhookURL)
+		if err != nil {
+			uc.logger.Warnf("Failed to initialize Slack service for group %s: %v", name, err)
+			continue
+		}
+		slack.SetLocale(uc.parseLocale(group.Language, defaultLocale))
+		groupSlack[name] = slack
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if uc.deadLetters != nil {
+		for _, slack := range groupSlack {
+			slack.SetDeadLetterStore(uc.deadLetters)
+		}
+	}
+	if uc.history != nil {
+		for _, slack := range groupSlack {
+			slack.SetHistory(uc.history)
+		}
+	}
+	uc.routesMu.Lock()
+	uc.groups = groups
+	uc.groupSlack = groupSlack
+	uc.routesMu.Unlock()
+}
+
+// SetDeadLetterStore makes notification sends that fail land in store, for the default channel and
+// every group channel
+func (uc *UpgradeChecker) SetDeadLetterStore(store *notifications.DeadLetterStore) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.deadLetters = store
+	if uc.slack != nil {
+		uc.slack.SetDeadLetterStore(store)
+	}
+	for _, slack := range uc.groupSlack {
+		slack.SetDeadLetterStore(store)
+	}
+}
+
+// SetNotificationHistory records the notifications delivered to the default channel and every group
+// channel in history
+func (uc *UpgradeChecker) SetNotificationHistory(history *notifications.NotificationHistory) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.history = history
+	if uc.slack != nil {
+		uc.slack.SetHistory(history)
+	}
+	for _, slack := range uc.groupSlack {
+		slack.SetHistory(history)
+	}
+}
+
+// ReplayDeadLetters re-sends notifications that were still undelivered when the service last stopped
+func (uc *UpgradeChecker) ReplayDeadLetters(maxAge time.Duration, limit int) {
+	uc.mu.RLock()
+	store := uc.deadLetters
+	uc.mu.RUnlock()
+	if store == nil {
+		return
+	}
+
+	result, err := store.Replay(uc.ctx, uc.logger, maxAge, limit)
+	if err != nil {
+		uc.logger.Errorf("Failed to replay dead-lettered notifications: %v", err)
+		return
+	}
+	if result.Replayed+result.Skipped+result.Failed > 0 {
+		uc.logger.WithFields(logrus.Fields{
+			"replayed": result.Replayed,
+			"skipped":  result.Skipped,
+			"failed":   result.Failed,
+		}).Info("Replayed dead-lettered notifications")
+	}
+}
+
+// SetDedupHeightTolerance sets how many blocks apart reports of the same upgrade may be. Zero keeps
+// the default and a negative value requires identical heights.
+func (uc *UpgradeChecker) SetDedupHeightTolerance(blocks int64) {
+	if blocks == 0 {
+		blocks = defaultDedupHeightTolerance
+	} else if blocks < 0 {
+		blocks = 0
 	}
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.dedupHeightTolerance = blocks
 }
 
 func (uc *UpgradeChecker) Start() error {
@@ -46,13 +260,27 @@ func (uc *UpgradeChecker) Start() error {
 	return nil
 }
 
+// Stop cancels in-flight notification sends and waits for a running check to finish. Upgrades still
+// waiting for the end of the batch window are then sent, each send bounded by the Slack send timeout.
 func (uc *UpgradeChecker) Stop() {
+	uc.cancel()
 	ctx := uc.cron.Stop()
 	<-ctx.Done()
+	uc.flushBatch(context.WithoutCancel(uc.ctx))
 	uc.logger.Info("Upgrade checker cron job stopped")
 }
 
 func (uc *UpgradeChecker) CheckUpgrades() {
+	if uc.registry.InMaintenance() {
+		uc.logger.Info("Maintenance mode enabled, skipping upgrade check")
+		return
+	}
+	if uc.registry.IsDegraded() {
+		uc.logger.Warn("Registry is in degraded mode, skipping upgrade check")
+		return
+	}
+	gateHeights := uc.blockGateHeights()
+
 	uc.mu.Lock()
 	defer uc.mu.Unlock()
 
@@ -67,25 +295,31 @@ func (uc *UpgradeChecker) CheckUpgrades() {
 	for _, chain := range chains {
 		uc.logger.WithField("chain", chain).Debug("Processing chain")
 
-		if !uc.registry.ChainExists(chain) {
+		if !uc.registry.ChainExists(uc.ctx, chain) {
 			uc.logger.WithField("chain", chain).Debug("Chain not found in registry, skipping")
+			// ChainExists also reports false when the registry could not be asked, only a
+			// definite not-found may announce the chain once it resolves
+			if _, err := uc.registry.GetChainInfo(uc.ctx, chain, false); isChainNotFound(err) {
+				uc.unresolved[chain] = true
+			}
 			continue
 		}
 
-		info, err := uc.registry.GetChainInfo(chain, false)
+		info, err := uc.registry.GetChainInfo(uc.ctx, chain, false)
 		if err != nil {
-			if strings.Contains(err.Error(), "invalid URL scheme") {
+			if isChainNotFound(err) {
+				uc.unresolved[chain] = true
 				uc.logger.WithFields(logrus.Fields{
 					"chain": chain,
 					"error": err,
-				}).Debug("Invalid chain URL format, skipping")
+				}).Debug("Chain info not found, skipping")
 				continue
 			}
-			if strings.Contains(err.Error(), "404 Not Found") {
+			if isNetworkError(err) {
 				uc.logger.WithFields(logrus.Fields{
 					"chain": chain,
 					"error": err,
-				}).Debug("Chain info not found, skipping")
+				}).Warn("Chain-registry unreachable, skipping")
 				continue
 			}
 			uc.logger.WithFields(logrus.Fields{
@@ -95,6 +329,12 @@ func (uc *UpgradeChecker) CheckUpgrades() {
 			continue
 		}
 
+		if info.Stale {
+			// Snapshot data only keeps the API useful during an outage, it is never announced
+			uc.logger.WithField("chain", chain).Warn("Only snapshot data available, skipping")
+			continue
+		}
+
 		uc.logger.WithFields(logrus.Fields{
 			"chain":   chain,
 			"network": info.Network,
@@ -102,6 +342,8 @@ func (uc *UpgradeChecker) CheckUpgrades() {
 			"height":  info.Height,
 		}).Debug("Retrieved chain info")
 
+		uc.markResolved(chain, info.Network)
+
 		if info.Network != "mainnet" && info.Network != "testnet" {
 			uc.logger.WithFields(logrus.Fields{
 				"chain":   chain,
@@ -110,9 +352,17 @@ func (uc *UpgradeChecker) CheckUpgrades() {
 			continue
 		}
 
-		upgradeInfo, err := uc.registry.GetUpgradeInfo(chain, false)
+		upgradeInfo, err := uc.registry.GetUpgradeInfo(uc.ctx, chain, false)
+		if errors.Is(err, types.ErrNoUpgrade) {
+			uc.logger.WithField("chain", chain).Debug("No upgrade info found")
+			uc.detectCancellation(chain)
+			if !uc.registry.UpgradeFetchFailed(chain) {
+				uc.publishUpgrade(chain, nil)
+			}
+			continue
+		}
 		if err != nil {
-			if strings.Contains(err.Error(), "received HTML response") {
+			if isBadResponse(err) {
 				uc.logger.WithFields(logrus.Fields{
 					"chain": chain,
 					"error": err,
@@ -126,11 +376,20 @@ func (uc *UpgradeChecker) CheckUpgrades() {
 			continue
 		}
 
-		if upgradeInfo == nil {
-			uc.logger.WithField("chain", chain).Debug("No upgrade info found")
+		if upgradeInfo.Stale {
+			uc.logger.WithField("chain", chain).Warn("Only snapshot upgrade info available, skipping")
 			continue
 		}
 
+		if uc.upgradeExecuted(chain, upgradeInfo) {
+			// Sources keep listing an upgrade for a while after it happened, it is never announced again
+			uc.lastChecks[chain] = upgradeInfo.Time
+			uc.lastUpgrades[chain] = upgradeInfo
+			uc.publishUpgrade(chain, nil)
+			continue
+		}
+		uc.publishUpgrade(chain, upgradeInfo)
+
 		uc.logger.WithFields(logrus.Fields{
 			"chain":   chain,
 			"name":    upgradeInfo.Name,
@@ -150,7 +409,10 @@ func (uc *UpgradeChecker) CheckUpgrades() {
 			}).Debug("Previous check found")
 		}
 
-		if !exists || lastCheck != upgradeInfo.Time {
+		isNew := uc.isNewUpgrade(chain, upgradeInfo)
+		// A held upgrade is left unrecorded so it is evaluated again on the next check
+		held := isNew && uc.heldByBlockGate(chain, upgradeInfo, gateHeights)
+		if isNew && !held {
 			typesUpgradeInfo := &types.UpgradeInfo{
 				Name:             upgradeInfo.Name,
 				ChainName:        chain,
@@ -160,9 +422,10 @@ func (uc *UpgradeChecker) CheckUpgrades() {
 				Version:          upgradeInfo.Version,
 				Estimated:        true,
 				Network:          upgradeInfo.Network,
-				ProposalLink:     upgradeInfo.Proposal,
+				Proposal:         upgradeInfo.Proposal,
+				ProposalLink:     upgradeInfo.ProposalLink,
 				Guide:            upgradeInfo.Guide,
-				BlockLink:        fmt.Sprintf("https://www.mintscan.io/%s/blocks/%d", chain, upgradeInfo.Height),
+				BlockLink:        upgradeInfo.BlockLink,
 				CosmovisorFolder: fmt.Sprintf("upgrades/%s", upgradeInfo.Version),
 				GitHash:          upgradeInfo.GitHash,
 				Repo:             upgradeInfo.Repo,
@@ -170,6 +433,83 @@ func (uc *UpgradeChecker) CheckUpgrades() {
 				API:              upgradeInfo.API,
 			}
 
+			// Links the upgrade's source left out point to the chain's own explorers, and stay empty
+			// for chains listing none
+			links := uc.registry.Links()
+			if typesUpgradeInfo.BlockLink == "" {
+				typesUpgradeInfo.BlockLink = links.BlockLink(info, upgradeInfo.Height)
+			}
+			if typesUpgradeInfo.ProposalLink == "" {
+				typesUpgradeInfo.ProposalLink = upgradeInfo.Proposal
+				if _, err := strconv.ParseUint(upgradeInfo.Proposal, 10, 64); err == nil {
+					typesUpgradeInfo.ProposalLink = links.ProposalLink(info, upgradeInfo.Proposal)
+				}
+			}
+
+			if binaries, err := uc.registry.GetUpgradeBinaries(uc.ctx, chain); err == nil {
+				typesUpgradeInfo.BinaryURL = linuxBinaryURL(binaries)
+			} else {
+				uc.logger.WithFields(logrus.Fields{
+					"chain": chain,
+					"error": err,
+				}).Debug("Upgrade binaries not available")
+			}
+
+			typesUpgradeInfo.DisplayName = info.PrettyName
+			if chainConfig, ok := uc.registry.GetChainConfig(chain); ok {
+				typesUpgradeInfo.MainnetChain = chainConfig.Mainnet
+				if typesUpgradeInfo.DisplayName == "" {
+					typesUpgradeInfo.DisplayName = chainConfig.DisplayName
+				}
+			}
+
+			runningVersion := info.RunningVersion()
+			if nodeVersion, err := uc.registry.GetNodeVersion(uc.ctx, chain); err == nil {
+				typesUpgradeInfo.CurrentVersion = nodeVersion
+				runningVersion = nodeVersion
+			} else {
+				uc.logger.WithFields(logrus.Fields{
+					"chain": chain,
+					"error": err,
+				}).Debug("Current version not available from node_info")
+			}
+			typesUpgradeInfo.ChangeType = string(version.Classify(runningVersion, upgradeInfo.Version))
+
+			if uc.settings.MajorVersionWarning && utils.IsMajorVersionJump(runningVersion, upgradeInfo.Version) {
+				typesUpgradeInfo.MajorUpgrade = true
+				uc.logger.WithFields(logrus.Fields{
+					"chain":   chain,
+					"current": runningVersion,
+					"target":  upgradeInfo.Version,
+				}).Info("Upgrade crosses a major version")
+			}
+
+			downgrade := uc.settings.DowngradeWarning && utils.IsVersionDowngrade(runningVersion, upgradeInfo.Version)
+			if downgrade {
+				uc.logger.WithFields(logrus.Fields{
+					"chain":   chain,
+					"current": runningVersion,
+					"target":  upgradeInfo.Version,
+				}).Warn("Upgrade targets an older version than the chain runs")
+			}
+
+			proposal := upgradeInfo.ProposalLink
+			if proposal == "" {
+				proposal = upgradeInfo.Proposal
+			}
+			if uc.settings.IncludeVoteTally && proposal != "" {
+				tally, err := uc.registry.GetVoteTally(uc.ctx, chain, proposal)
+				if err == nil {
+					typesUpgradeInfo.VoteTally = tally
+				} else {
+					uc.logger.WithFields(logrus.Fields{
+						"chain":    chain,
+						"proposal": proposal,
+						"error":    err,
+					}).Debug("Vote tally not available")
+				}
+			}
+
 			uc.logger.WithFields(logrus.Fields{
 				"chain":   chain,
 				"name":    typesUpgradeInfo.Name,
@@ -178,35 +518,348 @@ func (uc *UpgradeChecker) CheckUpgrades() {
 				"time":    typesUpgradeInfo.Time.Format(time.RFC3339),
 			}).Info("New upgrade found")
 
-			if uc.slack != nil {
-				if err := uc.slack.SendUpgradeNotification(chain, typesUpgradeInfo); err != nil {
-					uc.logger.WithFields(logrus.Fields{
-						"chain": chain,
-						"error": err,
-					}).Error("Failed to send Slack notification")
-				} else {
-					uc.logger.WithField("chain", chain).Info("Slack notification sent successfully")
-				}
+			if uc.batchWindow > 0 {
+				uc.queueUpgrade(chain, typesUpgradeInfo)
 			} else {
-				uc.logger.WithField("chain", chain).Debug("Slack service not configured, skipping notification")
+				uc.notify(chain, "upgrade", func(slack *notifications.SlackService) error {
+					return slack.SendUpgradeNotification(uc.ctx, chain, typesUpgradeInfo)
+				})
+			}
+			if downgrade {
+				uc.notify(chain, "downgrade", func(slack *notifications.SlackService) error {
+					return slack.SendDowngradeAlert(uc.ctx, chain, typesUpgradeInfo, runningVersion)
+				})
 			}
 
 			uc.lastChecks[chain] = upgradeInfo.Time
+			uc.lastUpgrades[chain] = upgradeInfo
 			uc.logger.WithFields(logrus.Fields{
 				"chain": chain,
 				"time":  upgradeInfo.Time.Format(time.RFC3339),
 			}).Debug("Updated last check time")
-		} else {
+		} else if !isNew {
+			uc.lastChecks[chain] = upgradeInfo.Time
+			uc.lastUpgrades[chain] = upgradeInfo
+
 			uc.logger.WithFields(logrus.Fields{
 				"chain": chain,
 				"time":  upgradeInfo.Time.Format(time.RFC3339),
 			}).Debug("No new upgrades found")
 		}
+
+		uc.notifyInProgress(chain, upgradeInfo)
+		uc.notifyNoHealthyEndpoints(chain, upgradeInfo)
 	}
 
 	uc.logger.Info("Completed checking all chains")
 }
 
+// isNewUpgrade reports whether upgradeInfo differs from the last upgrade reported for the chain.
+// Sources disagree slightly on versions and heights, so an upgrade within the height tolerance and
+// with a similar version counts as already reported. Callers must hold uc.mu.
+func (uc *UpgradeChecker) isNewUpgrade(chain string, upgradeInfo *types.UpgradeInfo) bool {
+	previous, ok := uc.lastUpgrades[chain]
+	if !ok {
+		return true
+	}
+	if previous.Time.Equal(upgradeInfo.Time) || sameUpgrade(previous, upgradeInfo, uc.dedupHeightTolerance) {
+		uc.logger.WithFields(logrus.Fields{
+			"chain":    chain,
+			"previous": previous.Version,
+			"current":  upgradeInfo.Version,
+		}).Debug("Upgrade already reported")
+		return false
+	}
+	return true
+}
+
+func sameUpgrade(a, b *types.UpgradeInfo, heightTolerance int64) bool {
+	if a.Height > 0 && b.Height > 0 {
+		diff := a.Height - b.Height
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > heightTolerance {
+			return false
+		}
+	}
+	// Names are not compared: Polkachu names every upgrade after the chain
+	return utils.SimilarVersions(a.Version, b.Version)
+}
+
+// blockGateHeights queries the current height of every chain with a blocks_remaining gate. It runs
+// before CheckUpgrades takes uc.mu so the node queries do not block the checker. Chains whose height
+// is not available are left out.
+func (uc *UpgradeChecker) blockGateHeights() map[string]int64 {
+	uc.mu.RLock()
+	gates := maps.Clone(uc.settings.BlocksRemainingGate)
+	uc.mu.RUnlock()
+
+	heights := make(map[string]int64, len(gates))
+	for chain, gate := range gates {
+		if gate <= 0 {
+			continue
+		}
+		height, err := uc.registry.GetCurrentHeight(uc.ctx, chain)
+		if err != nil {
+			uc.logger.WithFields(logrus.Fields{
+				"chain": chain,
+				"error": err,
+			}).Debug("Current height not available, not applying blocks remaining gate")
+			continue
+		}
+		heights[chain] = height
+	}
+	return heights
+}
+
+// heldByBlockGate reports whether the upgrade is still further away than the chain's configured
+// blocks_remaining gate, given the heights of blockGateHeights. Without a known current height the
+// notification is not held back.
+func (uc *UpgradeChecker) heldByBlockGate(chain string, upgradeInfo *types.UpgradeInfo, heights map[string]int64) bool {
+	gate := uc.settings.BlocksRemainingGate[chain]
+	if gate <= 0 || upgradeInfo.Height <= 0 {
+		return false
+	}
+
+	height, ok := heights[chain]
+	if !ok {
+		return false
+	}
+
+	remaining := upgradeInfo.Height - height
+	if remaining <= gate {
+		return false
+	}
+	uc.logger.WithFields(logrus.Fields{
+		"chain":     chain,
+		"height":    upgradeInfo.Height,
+		"remaining": remaining,
+		"gate":      gate,
+	}).Debug("Upgrade not yet within blocks remaining gate")
+	return true
+}
+
+// detectCancellation announces that the chain's last reported upgrade is gone when the sources no
+// longer report it before its scheduled time. Finding nothing only proves a cancellation when every
+// upgrade source answered, so an outage is never mistaken for one. Callers must hold uc.mu.
+func (uc *UpgradeChecker) detectCancellation(chain string) {
+	previous, ok := uc.lastUpgrades[chain]
+	if !uc.settings.NotifyOnCancellation || !ok || !uc.now().Before(previous.Time) {
+		return
+	}
+	if uc.registry.UpgradeFetchFailed(chain) {
+		uc.logger.WithFields(logrus.Fields{
+			"chain":   chain,
+			"version": previous.Version,
+		}).Warn("Upgrade not reported but a source failed, not treating it as cancelled")
+		return
+	}
+
+	delete(uc.lastUpgrades, chain)
+	delete(uc.lastChecks, chain)
+	uc.logger.WithFields(logrus.Fields{
+		"chain":   chain,
+		"version": previous.Version,
+		"time":    previous.Time.Format(time.RFC3339),
+	}).Info("Upgrade no longer scheduled")
+	uc.notify(chain, "upgrade cancelled", func(slack *notifications.SlackService) error {
+		return slack.SendUpgradeCancelledNotification(uc.ctx, chain, previous)
+	})
+}
+
+// upgradeExecuted reports whether the chain has passed the upgrade, reading its latest height from its
+// nodes first, and logs this once per upgrade. Without a height the upgrade counts as executed once its
+// in-progress window has passed. Callers must hold uc.mu.
+func (uc *UpgradeChecker) upgradeExecuted(chain string, upgradeInfo *types.UpgradeInfo) bool {
+	if upgradeInfo.Height > 0 {
+		if _, err := uc.registry.GetCurrentHeight(uc.ctx, chain); err != nil {
+			uc.logger.WithFields(logrus.Fields{
+				"chain": chain,
+				"error": err,
+			}).Debug("Current height not available, judging the upgrade by its time")
+		}
+	}
+	if uc.registry.UpgradeStatus(chain, upgradeInfo) != types.UpgradeStatusCompleted {
+		return false
+	}
+	if executed, ok := uc.executed[chain]; ok && executed.Equal(upgradeInfo.Time) {
+		return true
+	}
+	uc.executed[chain] = upgradeInfo.Time
+
+	uc.logger.WithFields(logrus.Fields{
+		"chain":   chain,
+		"name":    upgradeInfo.Name,
+		"version": upgradeInfo.Version,
+		"height":  upgradeInfo.Height,
+	}).Info("Upgrade executed")
+	return true
+}
+
+// notifyInProgress announces once per upgrade that it entered the in-progress window. Callers must
+// hold uc.mu.
+func (uc *UpgradeChecker) notifyInProgress(chain string, upgradeInfo *types.UpgradeInfo) {
+	if uc.registry.UpgradeStatus(chain, upgradeInfo) != types.UpgradeStatusInProgress {
+		return
+	}
+	if notified, ok := uc.inProgressNotified[chain]; ok && notified.Equal(upgradeInfo.Time) {
+		return
+	}
+	uc.inProgressNotified[chain] = upgradeInfo.Time
+
+	uc.logger.WithFields(logrus.Fields{
+		"chain":  chain,
+		"name":   upgradeInfo.Name,
+		"height": upgradeInfo.Height,
+	}).Info("Upgrade in progress")
+	uc.notify(chain, "upgrade in progress", func(slack *notifications.SlackService) error {
+		return slack.SendUpgradeInProgressNotification(uc.ctx, chain, upgradeInfo)
+	})
+}
+
+// notifyNoHealthyEndpoints probes the chain's public endpoints while the upgrade is within the urgent
+// window and raises a critical alert, once per upgrade, when none of them respond. Nothing is probed
+// unless NotifyNoHealthyEndpoints is enabled, and a chain without endpoints raises no alert. Callers
+// must hold uc.mu.
+func (uc *UpgradeChecker) notifyNoHealthyEndpoints(chain string, upgradeInfo *types.UpgradeInfo) {
+	if !uc.settings.NotifyNoHealthyEndpoints {
+		return
+	}
+	until := upgradeInfo.Time.Sub(uc.now())
+	if until < 0 || until > uc.urgentWindow {
+		return
+	}
+	if notified, ok := uc.noHealthyEndpointsNotified[chain]; ok && notified.Equal(upgradeInfo.Time) {
+		return
+	}
+
+	health, err := uc.registry.ProbeEndpoints(uc.ctx, chain)
+	if err != nil {
+		uc.logger.WithFields(logrus.Fields{
+			"chain": chain,
+			"error": err,
+		}).Debug("Endpoints could not be probed")
+		return
+	}
+	if health.Total == 0 || health.Healthy > 0 {
+		return
+	}
+	uc.noHealthyEndpointsNotified[chain] = upgradeInfo.Time
+
+	uc.logger.WithFields(logrus.Fields{
+		"chain":  chain,
+		"name":   upgradeInfo.Name,
+		"height": upgradeInfo.Height,
+		"probed": health.Total,
+	}).Error("Imminent upgrade on a chain with no healthy endpoints")
+	uc.notify(chain, "no healthy endpoints", func(slack *notifications.SlackService) error {
+		return slack.SendNoHealthyEndpointsAlert(uc.ctx, chain, upgradeInfo, health.Total)
+	})
+}
+
+// NotifyChainStall announces a chain that stopped producing blocks or produces them again. It lets
+// the checker serve as the poller's StallNotifier. It does not take uc.mu, which a running check
+// holds until it is done, so the poller is never kept waiting on one.
+func (uc *UpgradeChecker) NotifyChainStall(event *types.ChainStallEvent) {
+	kind := "chain stalled"
+	if event.Type == types.ChainResumed {
+		kind = "chain resumed"
+	}
+
+	uc.notify(event.Chain, kind, func(slack *notifications.SlackService) error {
+		return slack.SendChainStallEvent(uc.ctx, event)
+	})
+}
+
+// markResolved records that a chain resolved and, the first time a previously unresolvable chain
+// does so, announces that it is now being monitored. Callers must hold uc.mu.
+func (uc *UpgradeChecker) markResolved(chain, network string) {
+	if uc.resolved[chain] {
+		return
+	}
+	uc.resolved[chain] = true
+
+	if !uc.unresolved[chain] {
+		return
+	}
+	delete(uc.unresolved, chain)
+
+	uc.logger.WithField("chain", chain).Info("Previously unresolvable chain now resolves in the chain-registry")
+	if !uc.settings.NotifyOnChainResolved {
+		return
+	}
+	uc.notify(chain, "chain resolved", func(slack *notifications.SlackService) error {
+		return slack.SendChainResolvedNotification(uc.ctx, chain, network)
+	})
+}
+
+// notify delivers a notification about chain to the channels of its groups, or to the default
+// channel when none of its groups has one. It only reads state with locks of its own, so callers
+// need not hold uc.mu.
+func (uc *UpgradeChecker) notify(chain, kind string, send func(*notifications.SlackService) error) {
+	if uc.suppressed(chain, kind) {
+		return
+	}
+
+	recipients := uc.recipients(chain)
+	if len(recipients) == 0 {
+		uc.logger.WithField("chain", chain).Debug("Slack service not configured, skipping notification")
+		return
+	}
+
+	for _, slack := range recipients {
+		if err := send(slack); err != nil {
+			uc.logger.WithFields(logrus.Fields{
+				"chain": chain,
+				"kind":  kind,
+				"error": err,
+			}).Error("Failed to send Slack notification")
+			continue
+		}
+		uc.logger.WithFields(logrus.Fields{
+			"chain": chain,
+			"kind":  kind,
+		}).Info("Slack notification sent successfully")
+	}
+}
+
+// suppressed reports whether notifications about chain are held back by maintenance mode or a snooze
+func (uc *UpgradeChecker) suppressed(chain, kind string) bool {
+	if uc.registry != nil && uc.registry.InMaintenance() {
+		uc.logger.WithFields(logrus.Fields{
+			"chain": chain,
+			"kind":  kind,
+		}).Info("Maintenance mode enabled, suppressing notification")
+		return true
+	}
+	if until, snoozed := uc.SnoozedUntil(chain); snoozed {
+		uc.logger.WithFields(logrus.Fields{
+			"chain": chain,
+			"kind":  kind,
+			"until": until.Format(time.RFC3339),
+		}).Info("Chain is snoozed, suppressing notification")
+		return true
+	}
+	return false
+}
+
+func (uc *UpgradeChecker) recipients(chain string) []*notifications.SlackService {
+	uc.routesMu.RLock()
+	defer uc.routesMu.RUnlock()
+
+	var recipients []*notifications.SlackService
+	for _, group := range uc.groups.GroupsOf(chain) {
+		if slack, ok := uc.groupSlack[group]; ok {
+			recipients = append(recipients, slack)
+		}
+	}
+	if len(recipients) == 0 && uc.slack != nil {
+		recipients = append(recipients, uc.slack)
+	}
+	return recipients
+}
+
 func (uc *UpgradeChecker) checkUpgrades() {
 	uc.logger.Info("Starting upgrade check cycle")
 	uc.CheckUpgrades()
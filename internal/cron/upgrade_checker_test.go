@@ -0,0 +1,1173 @@
+package cron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/internal/config"
+	"github.com/0xPuncker/cosmos-watcher/internal/events"
+	"github.com/0xPuncker/cosmos-watcher/internal/notifications"
+	"github.com/0xPuncker/cosmos-watcher/internal/testutil"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeChecker_ChainResolvedNotification(t *testing.T) {
+	var listed atomic.Bool
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !listed.Load() || !strings.HasSuffix(r.URL.Path, "/master/newchain/chain.json") {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(chain.ChainInfo{Name: "newchain", ChainID: "newchain-1"})
+	}))
+	defer registryServer.Close()
+
+	var resolvedMessages atomic.Int32
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "Now actively monitoring newchain") {
+			resolvedMessages.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slackServer.Close()
+	t.Setenv("SLACK_WEBHOOK_URL", slackServer.URL)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		expected int32
+	}{
+		{"enabled", true, 1},
+		{"disabled", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listed.Store(false)
+			resolvedMessages.Store(0)
+
+			registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetMonitoredChains([]string{"newchain"})
+			registry.SetCacheTTLs(0, 20*time.Millisecond)
+
+			slack, err := notifications.NewSlackService(logger)
+			require.NoError(t, err)
+
+			checker := NewUpgradeChecker(registry, logger, slack)
+			checker.SetNotificationsConfig(config.NotificationsConfig{NotifyOnChainResolved: tt.enabled})
+
+			checker.CheckUpgrades()
+			assert.Equal(t, int32(0), resolvedMessages.Load(), "unresolved chain should not be announced")
+
+			listed.Store(true)
+			// The missing chain is remembered for the negative cache TTL
+			time.Sleep(30 * time.Millisecond)
+			checker.CheckUpgrades()
+			checker.CheckUpgrades()
+			assert.Equal(t, tt.expected, resolvedMessages.Load())
+		})
+	}
+}
+
+func TestUpgradeChecker_ChainResolvedOnFirstCheck(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/master/knownchain/chain.json") {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(chain.ChainInfo{Name: "knownchain", ChainID: "knownchain-1"})
+	}))
+	defer registryServer.Close()
+
+	var messages atomic.Int32
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "Now actively monitoring") {
+			messages.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slackServer.Close()
+	t.Setenv("SLACK_WEBHOOK_URL", slackServer.URL)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"knownchain"})
+
+	slack, err := notifications.NewSlackService(logger)
+	require.NoError(t, err)
+
+	checker := NewUpgradeChecker(registry, logger, slack)
+	checker.SetNotificationsConfig(config.NotificationsConfig{NotifyOnChainResolved: true})
+
+	checker.CheckUpgrades()
+	assert.Equal(t, int32(0), messages.Load(), "chains resolving from the start are not announced")
+}
+
+func TestUpgradeChecker_ChainNotResolvedAfterOutage(t *testing.T) {
+	var messages atomic.Int32
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "Now actively monitoring") {
+			messages.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slackServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := testutil.NewFakeRegistry(&chain.ChainInfo{Name: "knownchain", ChainID: "knownchain-1", Network: "mainnet"})
+	registry.SetMonitoredChains([]string{"knownchain"})
+	registry.ChainErrors["knownchain"] = &chain.ErrNetwork{Err: errors.New("connection refused")}
+
+	slack, err := notifications.NewSlackServiceForWebhook(logger, slackServer.URL)
+	require.NoError(t, err)
+
+	checker := NewUpgradeChecker(registry, logger, slack)
+	checker.SetNotificationsConfig(config.NotificationsConfig{NotifyOnChainResolved: true})
+
+	checker.CheckUpgrades()
+	delete(registry.ChainErrors, "knownchain")
+	checker.CheckUpgrades()
+	assert.Equal(t, int32(0), messages.Load(), "a chain the registry could not be asked about is not announced")
+}
+
+func TestUpgradeChecker_MajorVersionWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		running  string
+		target   string
+		enabled  bool
+		expected bool
+	}{
+		{"major jump", "v24.0.2", "v25.0.0", true, true},
+		{"minor jump", "v24.0.2", "v24.1.0", true, false},
+		{"major jump with warning disabled", "v24.0.2", "v25.0.0", false, false},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json"):
+					json.NewEncoder(w).Encode(chain.ChainInfo{
+						Name:     "testchain",
+						ChainID:  "testchain-1",
+						Codebase: chain.Codebase{RecommendedVersion: tt.running},
+					})
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json"):
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"name":   tt.target,
+						"height": 1000000,
+						"time":   time.Now().Add(48 * time.Hour),
+					})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer registryServer.Close()
+
+			var messages []string
+			var mu sync.Mutex
+			slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				mu.Lock()
+				messages = append(messages, string(body))
+				mu.Unlock()
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer slackServer.Close()
+			t.Setenv("SLACK_WEBHOOK_URL", slackServer.URL)
+
+			registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetMonitoredChains([]string{"testchain"})
+
+			slack, err := notifications.NewSlackService(logger)
+			require.NoError(t, err)
+
+			checker := NewUpgradeChecker(registry, logger, slack)
+			checker.SetNotificationsConfig(config.NotificationsConfig{MajorVersionWarning: tt.enabled})
+			checker.CheckUpgrades()
+
+			mu.Lock()
+			defer mu.Unlock()
+			require.Len(t, messages, 1)
+			assert.Equal(t, tt.expected, strings.Contains(messages[0], "Major upgrade"))
+		})
+	}
+}
+
+func TestUpgradeChecker_DeduplicatesAcrossSources(t *testing.T) {
+	scheduled := time.Now().Add(48 * time.Hour)
+	// The chain-registry names the upgrade after its plan while Polkachu reports the node version,
+	// with a slightly different height and estimated time
+	registryReport := &types.UpgradeInfo{Name: "v25", Version: "v25", Height: 1000000, Time: scheduled}
+	polkachuReport := &types.UpgradeInfo{Name: "v25.0.0", Version: "v25.0.0", Height: 1000003, Time: scheduled.Add(20 * time.Second)}
+	nextUpgrade := &types.UpgradeInfo{Name: "v26", Version: "v26.0.0", Height: 1500000, Time: scheduled.Add(30 * 24 * time.Hour)}
+	// Polkachu names every upgrade after the chain
+	polkachuCurrent := &types.UpgradeInfo{Name: "testchain", Version: "v25.0.0", Height: 1000000, Time: scheduled}
+	polkachuNext := &types.UpgradeInfo{Name: "testchain", Version: "v26.0.0", Height: 1000002, Time: scheduled.Add(time.Hour)}
+
+	tests := []struct {
+		name      string
+		tolerance int64
+		reports   []*types.UpgradeInfo
+		expected  int
+	}{
+		{"near-identical reports", 0, []*types.UpgradeInfo{registryReport, polkachuReport, registryReport}, 1},
+		{"distinct upgrades", 0, []*types.UpgradeInfo{registryReport, polkachuReport, nextUpgrade}, 2},
+		{"heights outside tolerance", 2, []*types.UpgradeInfo{registryReport, polkachuReport}, 2},
+		{"exact heights required", -1, []*types.UpgradeInfo{registryReport, polkachuReport}, 2},
+		{"same name, other version", 3, []*types.UpgradeInfo{polkachuCurrent, polkachuNext}, 2},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewUpgradeChecker(nil, logger, nil)
+			checker.SetDedupHeightTolerance(tt.tolerance)
+
+			detected := 0
+			for _, report := range tt.reports {
+				if checker.isNewUpgrade("testchain", report) {
+					detected++
+				}
+				checker.lastUpgrades["testchain"] = report
+			}
+			assert.Equal(t, tt.expected, detected)
+		})
+	}
+}
+
+func TestUpgradeChecker_GroupRouting(t *testing.T) {
+	registryServer := testutil.NewRegistryServer(t)
+
+	recorder := func(received *[]string, mu *sync.Mutex) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var message notifications.SlackMessage
+			json.NewDecoder(r.Body).Decode(&message)
+			mu.Lock()
+			*received = append(*received, message.Text)
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	var (
+		mu                     sync.Mutex
+		defaultMsgs, groupMsgs []string
+	)
+	defaultServer := recorder(&defaultMsgs, &mu)
+	defer defaultServer.Close()
+	groupServer := recorder(&groupMsgs, &mu)
+	defer groupServer.Close()
+	t.Setenv("SLACK_WEBHOOK_URL", defaultServer.URL)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"akash", "cosmoshub"})
+
+	slack, err := notifications.NewSlackService(logger)
+	require.NoError(t, err)
+
+	checker := NewUpgradeChecker(registry, logger, slack)
+	checker.SetGroups(config.GroupsConfig{
+		"p2p-validators": {Chains: []string{"cosmoshub"}, SlackWebhookURL: groupServer.URL},
+		"watch-only":     {Chains: []string{"akash"}},
+	})
+	checker.CheckUpgrades()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, groupMsgs, 1)
+	assert.Contains(t, groupMsgs[0], "Cosmoshub")
+	require.Len(t, defaultMsgs, 1, "chains whose groups have no channel use the default one")
+	assert.Contains(t, defaultMsgs[0], "Akash")
+}
+
+func TestUpgradeChecker_GroupLanguage(t *testing.T) {
+	registryServer := testutil.NewRegistryServer(t)
+
+	var mu sync.Mutex
+	received := make(map[string]string)
+	recorder := func(channel string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var message notifications.SlackMessage
+			json.NewDecoder(r.Body).Decode(&message)
+			mu.Lock()
+			received[channel] = message.Text
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	defaultServer := recorder("default")
+	defer defaultServer.Close()
+	brazilServer := recorder("brazil")
+	defer brazilServer.Close()
+	t.Setenv("SLACK_WEBHOOK_URL", defaultServer.URL)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"akash", "cosmoshub"})
+
+	slack, err := notifications.NewSlackService(logger)
+	require.NoError(t, err)
+
+	checker := NewUpgradeChecker(registry, logger, slack)
+	checker.SetNotificationsConfig(config.NotificationsConfig{Language: "en"})
+	checker.SetGroups(config.GroupsConfig{
+		"brazil": {Chains: []string{"cosmoshub"}, SlackWebhookURL: brazilServer.URL, Language: "pt-BR"},
+	})
+	checker.CheckUpgrades()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.True(t, strings.HasPrefix(received["default"], "🚀 New Upgrade Scheduled for Akash"), received["default"])
+	assert.True(t, strings.HasPrefix(received["brazil"], "🚀 Nova atualização agendada para Cosmoshub"), received["brazil"])
+}
+
+func TestUpgradeChecker_BlocksRemainingGate(t *testing.T) {
+	tests := []struct {
+		name          string
+		gate          map[string]int64
+		currentHeight int64
+		expected      int
+	}{
+		{"no gate", nil, 900000, 1},
+		{"outside gate", map[string]int64{"testchain": 5000}, 990000, 0},
+		{"within gate", map[string]int64{"testchain": 5000}, 996000, 1},
+		{"other chain gated", map[string]int64{"otherchain": 5000}, 900000, 1},
+		{"current height unavailable", map[string]int64{"testchain": 5000}, 0, 1},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := testutil.NewFakeRegistry(&chain.ChainInfo{Name: "testchain", ChainID: "testchain-1", Network: "mainnet"})
+			registry.SetMonitoredChains([]string{"testchain"})
+			registry.Upgrades["testchain"] = &types.UpgradeInfo{
+				ChainName: "testchain",
+				Name:      "v2.0.0",
+				Version:   "v2.0.0",
+				Height:    1000000,
+				Time:      time.Now().Add(48 * time.Hour),
+			}
+			if tt.currentHeight > 0 {
+				registry.Blocks["testchain"] = chain.LatestBlock{Height: tt.currentHeight}
+			}
+
+			var messages atomic.Int32
+			slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				messages.Add(1)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer slackServer.Close()
+
+			slack, err := notifications.NewSlackServiceForWebhook(logger, slackServer.URL)
+			require.NoError(t, err)
+
+			checker := NewUpgradeChecker(registry, logger, slack)
+			checker.SetNotificationsConfig(config.NotificationsConfig{BlocksRemainingGate: tt.gate})
+			checker.CheckUpgrades()
+
+			assert.Equal(t, int32(tt.expected), messages.Load())
+			_, recorded := checker.lastUpgrades["testchain"]
+			assert.Equal(t, tt.expected == 1, recorded, "held upgrades are re-evaluated on the next check")
+		})
+	}
+}
+
+func TestUpgradeChecker_TestnetCanary(t *testing.T) {
+	// The testnet lives in the testnets directory, the mainnet at the root
+	registryServer := testutil.NewRegistryServer(t)
+
+	var (
+		mu       sync.Mutex
+		messages = make(map[string]string)
+	)
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message notifications.SlackMessage
+		json.NewDecoder(r.Body).Decode(&message)
+		mu.Lock()
+		for _, name := range []string{"Osmosis Testnet", "Osmosis"} {
+			if strings.Contains(message.Text, "Scheduled for "+name+"\n") {
+				messages[name] = message.Text
+			}
+		}
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slackServer.Close()
+	t.Setenv("SLACK_WEBHOOK_URL", slackServer.URL)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetChainConfigs([]types.ChainConfig{
+		{Name: "osmosis", DisplayName: "Osmosis", Network: "mainnet"},
+		{Name: "osmosistestnet", DisplayName: "Osmosis Testnet", Network: "testnet", Mainnet: "osmosis"},
+	})
+	registry.SetMonitoredChains([]string{"osmosis", "osmosistestnet"})
+
+	slack, err := notifications.NewSlackService(logger)
+	require.NoError(t, err)
+
+	checker := NewUpgradeChecker(registry, logger, slack)
+	checker.CheckUpgrades()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Contains(t, messages, "Osmosis Testnet")
+	assert.Contains(t, messages["Osmosis Testnet"], "Osmosis mainnet likely to follow")
+	require.Contains(t, messages, "Osmosis")
+	assert.NotContains(t, messages["Osmosis"], "likely to follow")
+}
+
+func TestUpgradeChecker_TestnetCanaryFromChainsYAML(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "config"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config", "chains.yaml"), []byte(`mainnet:
+  - name: osmosis
+    display_name: Osmosis
+testnet:
+  - name: osmosistestnet
+    display_name: Osmosis Testnet
+    mainnet: osmosis
+`), 0o644))
+	t.Chdir(dir)
+
+	registry := testutil.NewFakeRegistry(
+		&chain.ChainInfo{Name: "osmosis", ChainID: "osmosis-1", Network: "mainnet"},
+		&chain.ChainInfo{Name: "osmosistestnet", ChainID: "osmo-test-5", Network: "testnet"},
+	)
+	registry.Upgrades["osmosistestnet"] = &types.UpgradeInfo{
+		ChainName: "osmosistestnet",
+		Name:      "v26",
+		Version:   "v26.0.0",
+		Network:   "testnet",
+		Height:    1000,
+		Time:      time.Now().Add(48 * time.Hour),
+	}
+
+	var (
+		mu       sync.Mutex
+		messages []string
+	)
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message notifications.SlackMessage
+		json.NewDecoder(r.Body).Decode(&message)
+		mu.Lock()
+		messages = append(messages, message.Text)
+		mu.Unlock()
+	}))
+	defer slackServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	slack, err := notifications.NewSlackServiceForWebhook(logger, slackServer.URL)
+	require.NoError(t, err)
+
+	require.NoError(t, NewLoadChainsJob(registry, logger).Run())
+	NewUpgradeChecker(registry, logger, slack).CheckUpgrades()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0], "Scheduled for Osmosis Testnet\n")
+	assert.Contains(t, messages[0], "Osmosis mainnet likely to follow", "the link in chains.yaml reaches the notification")
+}
+
+func TestUpgradeChecker_ChainTitle(t *testing.T) {
+	prettyNames := map[string]string{"cosmoshub": "Cosmos Hub"}
+	registryServer := testutil.NewRegistryServer(t, testutil.WithChainInfo(func(info *chain.ChainInfo) {
+		info.PrettyName = prettyNames[info.Name]
+	}))
+
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message notifications.SlackMessage
+		json.NewDecoder(r.Body).Decode(&message)
+		mu.Lock()
+		received = append(received, strings.SplitN(message.Text, "\n", 2)[0])
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slackServer.Close()
+	t.Setenv("SLACK_WEBHOOK_URL", slackServer.URL)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetChainConfigs([]types.ChainConfig{
+		{Name: "cosmoshub", DisplayName: "Cosmos", Network: "mainnet"},
+		{Name: "akash", DisplayName: "Akash Network", Network: "mainnet"},
+		{Name: "juno", Network: "mainnet"},
+	})
+	registry.SetMonitoredChains([]string{"cosmoshub", "akash", "juno"})
+
+	slack, err := notifications.NewSlackService(logger)
+	require.NoError(t, err)
+
+	checker := NewUpgradeChecker(registry, logger, slack)
+	checker.CheckUpgrades()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch(t, []string{
+		"🚀 New Upgrade Scheduled for Cosmos Hub",
+		"🚀 New Upgrade Scheduled for Akash Network",
+		"🚀 New Upgrade Scheduled for Juno",
+	}, received, "the pretty name wins over the configured display name, the title-cased name comes last")
+}
+
+func TestUpgradeChecker_NoHealthyEndpointsAlert(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer healthy.Close()
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	deadURL := dead.URL
+	dead.Close()
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		untilTime time.Duration
+		apis      chain.APIs
+		expected  int
+	}{
+		{
+			name: "imminent upgrade with dead endpoints", enabled: true, untilTime: 2 * time.Hour,
+			apis:     chain.APIs{RPC: []chain.Endpoint{{Address: deadURL}}, REST: []chain.Endpoint{{Address: deadURL}}},
+			expected: 1,
+		},
+		{
+			name: "imminent upgrade with a healthy endpoint", enabled: true, untilTime: 2 * time.Hour,
+			apis: chain.APIs{RPC: []chain.Endpoint{{Address: deadURL}, {Address: healthy.URL}}},
+		},
+		{
+			name: "upgrade outside urgent window", enabled: true, untilTime: 48 * time.Hour,
+			apis: chain.APIs{RPC: []chain.Endpoint{{Address: deadURL}}},
+		},
+		{
+			name: "disabled", untilTime: 2 * time.Hour,
+			apis: chain.APIs{RPC: []chain.Endpoint{{Address: deadURL}}},
+		},
+		{name: "no endpoints listed", enabled: true, untilTime: 2 * time.Hour},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json"):
+					json.NewEncoder(w).Encode(chain.ChainInfo{Name: "testchain", ChainID: "testchain-1", APIs: tt.apis})
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json"):
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"name":   "v2.0.0",
+						"height": 1000000,
+						"time":   time.Now().Add(tt.untilTime),
+					})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer registryServer.Close()
+
+			var (
+				mu     sync.Mutex
+				alerts []notifications.SlackMessage
+			)
+			slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var message notifications.SlackMessage
+				json.NewDecoder(r.Body).Decode(&message)
+				if strings.HasPrefix(message.Text, "🚨 Critical") {
+					mu.Lock()
+					alerts = append(alerts, message)
+					mu.Unlock()
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer slackServer.Close()
+
+			registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetMonitoredChains([]string{"testchain"})
+
+			slack, err := notifications.NewSlackServiceForWebhook(logger, slackServer.URL)
+			require.NoError(t, err)
+
+			checker := NewUpgradeChecker(registry, logger, slack)
+			checker.SetNotificationsConfig(config.NotificationsConfig{NotifyNoHealthyEndpoints: tt.enabled})
+			checker.CheckUpgrades()
+			// The alert is raised once per upgrade
+			checker.CheckUpgrades()
+
+			_, probed := registry.LastEndpointHealth("testchain")
+			assert.Equal(t, tt.enabled && tt.untilTime < 6*time.Hour, probed, "endpoints are probed only for the alert")
+
+			mu.Lock()
+			defer mu.Unlock()
+			require.Len(t, alerts, tt.expected)
+			if tt.expected > 0 {
+				assert.Contains(t, alerts[0].Text, "Testchain upgrades in")
+				assert.Equal(t, "danger", alerts[0].Attachments[0].Color)
+			}
+		})
+	}
+}
+
+func TestUpgradeChecker_DowngradeAlert(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		running  string
+		target   string
+		expected int
+	}{
+		{name: "target older than running version", enabled: true, running: "v25.1.0", target: "v24.0.0", expected: 1},
+		{name: "regular upgrade", enabled: true, running: "v24.0.2", target: "v25.0.0"},
+		{name: "disabled", running: "v25.1.0", target: "v24.0.0"},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json"):
+					json.NewEncoder(w).Encode(chain.ChainInfo{
+						Name:     "testchain",
+						ChainID:  "testchain-1",
+						Codebase: chain.Codebase{RecommendedVersion: tt.running},
+					})
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json"):
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"name":    tt.target,
+						"version": tt.target,
+						"height":  1000000,
+						"time":    time.Now().Add(48 * time.Hour),
+					})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer registryServer.Close()
+
+			var (
+				mu       sync.Mutex
+				alerts   []notifications.SlackMessage
+				upgrades int
+			)
+			slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var message notifications.SlackMessage
+				json.NewDecoder(r.Body).Decode(&message)
+				mu.Lock()
+				if strings.HasPrefix(message.Text, "↩️ Possible rollback/downgrade") {
+					alerts = append(alerts, message)
+				} else {
+					upgrades++
+				}
+				mu.Unlock()
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer slackServer.Close()
+
+			registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetMonitoredChains([]string{"testchain"})
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{chain.ProviderChainRegistry}))
+
+			slack, err := notifications.NewSlackServiceForWebhook(logger, slackServer.URL)
+			require.NoError(t, err)
+
+			checker := NewUpgradeChecker(registry, logger, slack)
+			checker.SetNotificationsConfig(config.NotificationsConfig{DowngradeWarning: tt.enabled})
+			checker.CheckUpgrades()
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, 1, upgrades, "the upgrade itself is still notified")
+			require.Len(t, alerts, tt.expected)
+			if tt.expected > 0 {
+				assert.Contains(t, alerts[0].Text, "Testchain\nUpgrade: "+tt.target)
+				assert.Equal(t, "warning", alerts[0].Attachments[0].Color)
+				assert.Equal(t, tt.running, alerts[0].Attachments[0].Fields[0].Value)
+			}
+		})
+	}
+}
+
+func TestUpgradeChecker_BatchWindow(t *testing.T) {
+	registryServer := testutil.NewRegistryServer(t, testutil.WithUpgrade(func(chainName string) map[string]interface{} {
+		upgrade := testutil.StubUpgrade(chainName)
+		upgrade["name"], upgrade["height"] = "v2.0.0", 1000000
+		return upgrade
+	}))
+
+	var (
+		mu       sync.Mutex
+		messages []notifications.SlackMessage
+	)
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message notifications.SlackMessage
+		json.NewDecoder(r.Body).Decode(&message)
+		mu.Lock()
+		messages = append(messages, message)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slackServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"akash", "juno", "osmosis"})
+
+	slack, err := notifications.NewSlackServiceForWebhook(logger, slackServer.URL)
+	require.NoError(t, err)
+
+	window := 100 * time.Millisecond
+	checker := NewUpgradeChecker(registry, logger, slack)
+	checker.SetNotificationsConfig(config.NotificationsConfig{BatchWindow: window.String()})
+	checker.CheckUpgrades()
+	checker.CheckUpgrades()
+
+	mu.Lock()
+	assert.Empty(t, messages, "upgrades are held until the end of the window")
+	mu.Unlock()
+
+	go checker.RunBatches()
+	defer checker.Stop()
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(messages) > 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Later windows are empty and send nothing
+	time.Sleep(3 * window)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "🚀 3 new upgrades scheduled", messages[0].Text)
+	var texts []string
+	for _, attachment := range messages[0].Attachments {
+		texts = append(texts, attachment.Text)
+	}
+	assert.Equal(t, []string{"*Akash*: v2.0.0", "*Juno*: v2.0.0", "*Osmosis*: v2.0.0"}, texts)
+}
+
+func TestUpgradeChecker_StallAlertDuringCheck(t *testing.T) {
+	registry := testutil.NewFakeRegistry(&chain.ChainInfo{Name: "cosmoshub", ChainID: "cosmoshub-4", Network: "mainnet"})
+	registry.Upgrades["cosmoshub"] = &types.UpgradeInfo{
+		ChainName: "cosmoshub",
+		Name:      "v2.0.0",
+		Version:   "v2.0.0",
+		Height:    1000000,
+		Time:      time.Now().Add(48 * time.Hour),
+	}
+	registry.SetMonitoredChains([]string{"cosmoshub"})
+
+	checking := make(chan struct{})
+	release := make(chan struct{})
+	stalls := make(chan struct{}, 1)
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "stopped producing blocks") {
+			stalls <- struct{}{}
+			return
+		}
+		// The upgrade announcement keeps the check, and the checker's lock, busy
+		close(checking)
+		<-release
+	}))
+	defer slackServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	slack, err := notifications.NewSlackServiceForWebhook(logger, slackServer.URL)
+	require.NoError(t, err)
+	checker := NewUpgradeChecker(registry, logger, slack)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		checker.CheckUpgrades()
+	}()
+	<-checking
+	defer func() {
+		close(release)
+		<-done
+	}()
+
+	go checker.NotifyChainStall(&types.ChainStallEvent{
+		Type:         types.ChainStalled,
+		Chain:        "cosmoshub",
+		Height:       999999,
+		StalledSince: time.Now().Add(-time.Minute),
+		Duration:     time.Minute,
+	})
+	select {
+	case <-stalls:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stall alert waited for the running check")
+	}
+}
+
+func TestUpgradeChecker_StopFlushesBatch(t *testing.T) {
+	registry := testutil.NewFakeRegistry(&chain.ChainInfo{Name: "cosmoshub", ChainID: "cosmoshub-4", Network: "mainnet"})
+	registry.Upgrades["cosmoshub"] = &types.UpgradeInfo{
+		ChainName: "cosmoshub",
+		Name:      "v2.0.0",
+		Version:   "v2.0.0",
+		Height:    1000000,
+		Time:      time.Now().Add(48 * time.Hour),
+	}
+	registry.SetMonitoredChains([]string{"cosmoshub"})
+
+	var sent atomic.Int32
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message notifications.SlackMessage
+		json.NewDecoder(r.Body).Decode(&message)
+		if message.Text == "🚀 1 new upgrades scheduled" {
+			sent.Add(1)
+		}
+	}))
+	defer slackServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	slack, err := notifications.NewSlackServiceForWebhook(logger, slackServer.URL)
+	require.NoError(t, err)
+
+	checker := NewUpgradeChecker(registry, logger, slack)
+	checker.SetNotificationsConfig(config.NotificationsConfig{BatchWindow: "1h"})
+	checker.CheckUpgrades()
+	assert.Zero(t, sent.Load(), "the upgrade is held until the end of the window")
+
+	checker.Stop()
+	assert.Equal(t, int32(1), sent.Load(), "stopping sends the pending batch")
+}
+
+func TestUpgradeChecker_SnoozeExpiry(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var sent atomic.Int32
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent.Add(1)
+	}))
+	defer slackServer.Close()
+
+	slack, err := notifications.NewSlackServiceForWebhook(logger, slackServer.URL)
+	require.NoError(t, err)
+
+	now := time.Now()
+	checker := NewUpgradeChecker(nil, logger, slack)
+	checker.now = func() time.Time { return now }
+
+	send := func(s *notifications.SlackService) error {
+		return s.SendSlackMessage(context.Background(), &notifications.SlackMessage{Text: "test"})
+	}
+
+	checker.Snooze("cosmoshub", 4*time.Hour)
+	checker.notify("cosmoshub", "test", send)
+	checker.notify("osmosis", "test", send)
+	assert.Equal(t, int32(1), sent.Load(), "snoozed chain should not be notified")
+
+	now = now.Add(4*time.Hour - time.Second)
+	checker.notify("cosmoshub", "test", send)
+	assert.Equal(t, int32(1), sent.Load(), "snooze is still active just before expiry")
+
+	now = now.Add(time.Second)
+	checker.notify("cosmoshub", "test", send)
+	assert.Equal(t, int32(2), sent.Load(), "notifications resume once the snooze expires")
+	assert.Empty(t, checker.ActiveSnoozes())
+}
+
+func TestUpgradeChecker_CancellationRequiresSuccessfulFetch(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		status   int
+		expected int
+	}{
+		{name: "source outage", enabled: true, status: http.StatusInternalServerError},
+		{name: "upgrade removed", enabled: true, status: http.StatusNotFound, expected: 1},
+		{name: "disabled", status: http.StatusNotFound},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu            sync.Mutex
+				upgradeStatus = http.StatusOK
+				cancellations []notifications.SlackMessage
+			)
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				status := upgradeStatus
+				mu.Unlock()
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json"):
+					json.NewEncoder(w).Encode(chain.ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json") && status == http.StatusOK:
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"name":    "v2",
+						"version": "v2.0.0",
+						"height":  1000000,
+						"time":    time.Now().Add(48 * time.Hour),
+					})
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json"):
+					w.WriteHeader(status)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer registryServer.Close()
+
+			slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var message notifications.SlackMessage
+				json.NewDecoder(r.Body).Decode(&message)
+				if strings.HasPrefix(message.Text, "🛑 Upgrade no longer scheduled") {
+					mu.Lock()
+					cancellations = append(cancellations, message)
+					mu.Unlock()
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer slackServer.Close()
+
+			registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetMonitoredChains([]string{"testchain"})
+			registry.SetInfoTTLs(0, 100*time.Millisecond)
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{chain.ProviderChainRegistry}))
+
+			slack, err := notifications.NewSlackServiceForWebhook(logger, slackServer.URL)
+			require.NoError(t, err)
+
+			checker := NewUpgradeChecker(registry, logger, slack)
+			checker.SetNotificationsConfig(config.NotificationsConfig{NotifyOnCancellation: tt.enabled})
+			checker.CheckUpgrades()
+
+			// A forced refresh keeps the cached upgrade when the source fails, an expired one does not
+			mu.Lock()
+			upgradeStatus = tt.status
+			mu.Unlock()
+			time.Sleep(150 * time.Millisecond)
+			_, err = registry.GetUpgradeInfo(context.Background(), "testchain", false)
+			require.ErrorIs(t, err, chain.ErrNoUpgrade)
+			checker.CheckUpgrades()
+
+			mu.Lock()
+			defer mu.Unlock()
+			require.Len(t, cancellations, tt.expected)
+			if tt.expected > 0 {
+				assert.Contains(t, cancellations[0].Text, "Testchain\nUpgrade: v2.0.0")
+				assert.Equal(t, "warning", cancellations[0].Attachments[0].Color)
+			}
+		})
+	}
+}
+
+func TestUpgradeChecker_CompletedUpgradeNotAnnounced(t *testing.T) {
+	tests := []struct {
+		name          string
+		currentHeight int64
+		messages      int
+		executed      bool
+	}{
+		{name: "height below the upgrade", currentHeight: 999000, messages: 1},
+		{name: "height crossed the upgrade", currentHeight: 1000500, executed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"block":{"header":{"height":"%d"}}}`, tt.currentHeight)
+			}))
+			defer node.Close()
+
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json"):
+					json.NewEncoder(w).Encode(chain.ChainInfo{
+						Name:    "testchain",
+						ChainID: "testchain-1",
+						APIs:    chain.APIs{REST: []chain.Endpoint{{Address: node.URL}}},
+					})
+				case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json"):
+					// The estimate lags behind a chain that produced blocks faster than expected
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"name":   "v2.0.0",
+						"height": 1000000,
+						"time":   time.Now().Add(6 * time.Hour),
+					})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer registryServer.Close()
+
+			var messages atomic.Int32
+			slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				messages.Add(1)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer slackServer.Close()
+
+			var logs bytes.Buffer
+			logger := logrus.New()
+			logger.SetOutput(&logs)
+
+			registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+			registry.SetMonitoredChains([]string{"testchain"})
+			registry.SetETADriftThreshold(-1)
+			require.NoError(t, registry.SetUpgradeProviderOrder([]string{chain.ProviderChainRegistry}))
+
+			slack, err := notifications.NewSlackServiceForWebhook(logger, slackServer.URL)
+			require.NoError(t, err)
+
+			checker := NewUpgradeChecker(registry, logger, slack)
+			checker.SetNotificationsConfig(config.NotificationsConfig{})
+			checker.CheckUpgrades()
+			checker.CheckUpgrades()
+
+			assert.Equal(t, int32(tt.messages), messages.Load())
+			executedLogs := strings.Count(logs.String(), `msg="Upgrade executed"`)
+			if tt.executed {
+				assert.Equal(t, 1, executedLogs, "the executed upgrade is logged once")
+			} else {
+				assert.Zero(t, executedLogs)
+			}
+		})
+	}
+}
+
+func TestUpgradeChecker_PublishesUpgradeEvents(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		status  = http.StatusOK
+		upgrade = map[string]interface{}{"name": "v2", "version": "v2.0.0", "height": 1000000}
+	)
+	upgradeTime := time.Now().Add(48 * time.Hour)
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/chain.json"):
+			json.NewEncoder(w).Encode(chain.ChainInfo{Name: "testchain", ChainID: "testchain-1"})
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json") && status == http.StatusOK:
+			upgrade["time"] = upgradeTime
+			json.NewEncoder(w).Encode(upgrade)
+		case strings.HasSuffix(r.URL.Path, "/master/testchain/upgrades.json"):
+			w.WriteHeader(status)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetMonitoredChains([]string{"testchain"})
+	registry.SetRetryPolicy(-1, 0)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{chain.ProviderChainRegistry}))
+
+	bus := events.NewBus(0)
+	subscription := bus.Subscribe()
+	defer subscription.Close()
+	checker := NewUpgradeChecker(registry, logger, nil)
+	checker.SetEventBus(bus)
+
+	check := func(update func()) []events.Event {
+		mu.Lock()
+		update()
+		mu.Unlock()
+		registry.GetUpgradeInfo(context.Background(), "testchain", true)
+		checker.CheckUpgrades()
+
+		var published []events.Event
+		for {
+			select {
+			case event := <-subscription.Events():
+				published = append(published, event)
+			default:
+				return published
+			}
+		}
+	}
+
+	published := check(func() {})
+	require.Len(t, published, 1)
+	assert.Equal(t, events.UpgradeAdded, published[0].Type)
+	assert.Equal(t, "testchain", published[0].Chain)
+	assert.Equal(t, "v2.0.0", published[0].Upgrade.Version)
+
+	assert.Empty(t, check(func() { upgradeTime = upgradeTime.Add(time.Minute) }), "estimates drifting by a minute are no change")
+
+	published = check(func() { upgrade["height"] = 1001000 })
+	require.Len(t, published, 1)
+	assert.Equal(t, events.UpgradeChanged, published[0].Type)
+	assert.Equal(t, int64(1001000), published[0].Upgrade.Height)
+
+	assert.Empty(t, check(func() { status = http.StatusInternalServerError }), "an outage is not a removal")
+
+	published = check(func() { status = http.StatusNotFound })
+	require.Len(t, published, 1)
+	assert.Equal(t, events.UpgradeRemoved, published[0].Type)
+	assert.Equal(t, int64(1001000), published[0].Upgrade.Height, "the removed upgrade is the last one published")
+}
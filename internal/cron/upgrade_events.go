@@ -0,0 +1,52 @@
+package cron
+
+import (
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/events"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// upgradeTimeTolerance is how far the estimated time of an upgrade may move before the move is
+// published as a change, so estimates drifting with the block time do not flood subscribers
+const upgradeTimeTolerance = 5 * time.Minute
+
+// SetEventBus makes the checker publish the upgrades it sees appear, change and go away on bus
+func (uc *UpgradeChecker) SetEventBus(bus *events.Bus) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.events = bus
+}
+
+// publishUpgrade publishes how the chain's pending upgrade differs from the one last published for
+// it. A nil upgrade means the chain has none pending, or it was executed. Callers must hold uc.mu.
+func (uc *UpgradeChecker) publishUpgrade(chain string, upgrade *types.UpgradeInfo) {
+	previous, ok := uc.published[chain]
+	var eventType events.Type
+	switch {
+	case upgrade == nil && !ok:
+		return
+	case upgrade == nil:
+		delete(uc.published, chain)
+		uc.events.Publish(events.Event{Type: events.UpgradeRemoved, Chain: chain, Upgrade: previous})
+		return
+	case !ok:
+		eventType = events.UpgradeAdded
+	case upgradeChanged(previous, upgrade):
+		eventType = events.UpgradeChanged
+	default:
+		return
+	}
+	uc.published[chain] = upgrade
+	uc.events.Publish(events.Event{Type: eventType, Chain: chain, Upgrade: upgrade})
+}
+
+// upgradeChanged reports whether b differs from a in what subscribers show of an upgrade
+func upgradeChanged(a, b *types.UpgradeInfo) bool {
+	if a.Name != b.Name || a.Version != b.Version || a.Height != b.Height || a.Network != b.Network ||
+		a.ProposalLink != b.ProposalLink || a.Guide != b.Guide {
+		return true
+	}
+	moved := a.Time.Sub(b.Time)
+	return moved > upgradeTimeTolerance || moved < -upgradeTimeTolerance
+}
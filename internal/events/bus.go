@@ -0,0 +1,194 @@
+// Package events carries the changes the upgrade checker detects to the upgrades of the monitored
+// chains, and the runs of scheduled jobs, to in-process subscribers such as the API's event streams.
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// DefaultBufferSize is how many events a subscriber may fall behind before it is dropped
+const DefaultBufferSize = 64
+
+// Topic is what events are about, which subscribers filter on
+type Topic string
+
+const (
+	TopicUpgrades Topic = "upgrades"
+	TopicJobs     Topic = "jobs"
+)
+
+// Type is the kind of event, named after its topic and what happened, such as upgrade_changed
+type Type string
+
+const (
+	UpgradeAdded   Type = "upgrade_added"
+	UpgradeChanged Type = "upgrade_changed"
+	UpgradeRemoved Type = "upgrade_removed"
+	JobStarted     Type = "job_started"
+	JobSucceeded   Type = "job_succeeded"
+	JobFailed      Type = "job_failed"
+)
+
+var typeTopics = map[Type]Topic{
+	UpgradeAdded:   TopicUpgrades,
+	UpgradeChanged: TopicUpgrades,
+	UpgradeRemoved: TopicUpgrades,
+	JobStarted:     TopicJobs,
+	JobSucceeded:   TopicJobs,
+	JobFailed:      TopicJobs,
+}
+
+// Topic returns the topic of events of type t
+func (t Type) Topic() Topic {
+	return typeTopics[t]
+}
+
+// Kind returns what happened within the topic, such as "changed" for UpgradeChanged
+func (t Type) Kind() string {
+	_, kind, _ := strings.Cut(string(t), "_")
+	return kind
+}
+
+// Event is a change to the upgrade of a chain, or a run of a job. For the upgrades topic Upgrade is the
+// upgrade as it is now, or the one that went away for UpgradeRemoved; for the jobs topic Job is the run.
+type Event struct {
+	// ID increases with every event published on the bus
+	ID      uint64             `json:"-"`
+	Type    Type               `json:"type"`
+	Chain   string             `json:"chain,omitempty"`
+	Upgrade *types.UpgradeInfo `json:"upgrade,omitempty"`
+	Job     *JobRun            `json:"job,omitempty"`
+}
+
+// JobRun is a run of a scheduled job. Duration and Error are set once it finished.
+type JobRun struct {
+	Name string `json:"name"`
+	// RunID identifies the run, as returned when it is triggered manually
+	RunID      string    `json:"run_id"`
+	Task       string    `json:"task"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Bus fans the published events out to its subscribers. Publishing never blocks: a subscriber whose
+// buffer is full is dropped and its channel closed, so a slow client cannot hold up the publisher. A
+// nil Bus discards events.
+type Bus struct {
+	mu          sync.Mutex
+	bufferSize  int
+	lastID      uint64
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewBus returns a bus buffering up to bufferSize events per subscriber. Zero or less uses the default.
+func NewBus(bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Bus{bufferSize: bufferSize, subscribers: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives the events published after it was made
+type Subscription struct {
+	bus    *Bus
+	events chan Event
+}
+
+// Subscribe returns a subscription to the events published from now on. Subscribing to a closed bus
+// gives a subscription whose channel is already closed.
+func (b *Bus) Subscribe() *Subscription {
+	if b == nil {
+		s := &Subscription{events: make(chan Event)}
+		close(s.events)
+		return s
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := &Subscription{bus: b, events: make(chan Event, b.bufferSize)}
+	if b.closed {
+		close(s.events)
+		return s
+	}
+	b.subscribers[s] = struct{}{}
+	return s
+}
+
+// Events returns the channel the events are delivered on. It is closed when the subscription is
+// closed, when the subscriber fell too far behind and was dropped, and when the bus is closed.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close ends the subscription. It is safe to call more than once and after the bus dropped it.
+func (s *Subscription) Close() {
+	if s.bus == nil {
+		return
+	}
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.removeLocked(s)
+}
+
+// Publish delivers event, numbered with the next ID, to every subscriber. Callers must not modify
+// event.Upgrade or event.Job afterwards, subscribers share them.
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.lastID++
+	event.ID = b.lastID
+	for s := range b.subscribers {
+		select {
+		case s.events <- event:
+		default:
+			b.removeLocked(s)
+		}
+	}
+}
+
+// Close ends every subscription, for shutting down. Later events are discarded.
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subscribers {
+		b.removeLocked(s)
+	}
+}
+
+// Subscribers returns the number of active subscriptions
+func (b *Bus) Subscribers() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// removeLocked drops the subscription and closes its channel if it is still subscribed. Callers must
+// hold b.mu.
+func (b *Bus) removeLocked(s *Subscription) {
+	if _, ok := b.subscribers[s]; !ok {
+		return
+	}
+	delete(b.subscribers, s)
+	close(s.events)
+}
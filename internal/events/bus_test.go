@@ -0,0 +1,87 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus_Publish(t *testing.T) {
+	bus := NewBus(2)
+	first := bus.Subscribe()
+	second := bus.Subscribe()
+	defer first.Close()
+
+	upgrade := &types.UpgradeInfo{ChainName: "cosmoshub", Version: "v25.0.0"}
+	bus.Publish(Event{Type: UpgradeAdded, Chain: "cosmoshub", Upgrade: upgrade})
+	bus.Publish(Event{Type: UpgradeRemoved, Chain: "cosmoshub", Upgrade: upgrade})
+
+	for _, s := range []*Subscription{first, second} {
+		event := <-s.Events()
+		assert.Equal(t, Event{ID: 1, Type: UpgradeAdded, Chain: "cosmoshub", Upgrade: upgrade}, event)
+		event = <-s.Events()
+		assert.Equal(t, uint64(2), event.ID)
+		assert.Equal(t, UpgradeRemoved, event.Type)
+	}
+
+	second.Close()
+	second.Close()
+	_, open := <-second.Events()
+	assert.False(t, open, "a closed subscription's channel is closed")
+	assert.Equal(t, 1, bus.Subscribers())
+}
+
+func TestBus_DropsSlowSubscribers(t *testing.T) {
+	bus := NewBus(1)
+	slow := bus.Subscribe()
+	fast := bus.Subscribe()
+	defer fast.Close()
+
+	bus.Publish(Event{Type: UpgradeAdded, Chain: "osmosis"})
+	<-fast.Events()
+	// The slow subscriber still holds the first event, the second one does not fit and must not block
+	bus.Publish(Event{Type: UpgradeChanged, Chain: "osmosis"})
+	assert.Equal(t, UpgradeChanged, (<-fast.Events()).Type)
+
+	event, open := <-slow.Events()
+	require.True(t, open, "buffered events are still delivered")
+	assert.Equal(t, UpgradeAdded, event.Type)
+	_, open = <-slow.Events()
+	assert.False(t, open, "the slow subscriber was dropped")
+	assert.Equal(t, 1, bus.Subscribers())
+	slow.Close()
+}
+
+func TestBus_Close(t *testing.T) {
+	bus := NewBus(0)
+	s := bus.Subscribe()
+	bus.Close()
+	bus.Publish(Event{Type: UpgradeAdded})
+
+	_, open := <-s.Events()
+	assert.False(t, open)
+	_, open = <-bus.Subscribe().Events()
+	assert.False(t, open, "subscribing to a closed bus gives a closed subscription")
+	assert.Zero(t, bus.Subscribers())
+}
+
+func TestBus_Nil(t *testing.T) {
+	var bus *Bus
+	bus.Publish(Event{Type: UpgradeAdded})
+	s := bus.Subscribe()
+	_, open := <-s.Events()
+	assert.False(t, open)
+	s.Close()
+	bus.Close()
+	assert.Zero(t, bus.Subscribers())
+}
+
+func TestType(t *testing.T) {
+	assert.Equal(t, TopicUpgrades, UpgradeChanged.Topic())
+	assert.Equal(t, "changed", UpgradeChanged.Kind())
+	assert.Equal(t, TopicJobs, JobFailed.Topic())
+	assert.Equal(t, "failed", JobFailed.Kind())
+	assert.Empty(t, Type("unknown").Topic())
+}
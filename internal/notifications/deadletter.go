@@ -0,0 +1,181 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultDeadLetterMaxAge      = 24 * time.Hour
+	DefaultDeadLetterReplayLimit = 100
+	// maxDeadLetterAttempts bounds how many failed replays an entry survives
+	maxDeadLetterAttempts = 3
+)
+
+// DeadLetter is a notification that could not be delivered
+type DeadLetter struct {
+	WebhookURL string        `json:"webhook_url"`
+	Message    *SlackMessage `json:"message"`
+	FailedAt   time.Time     `json:"failed_at"`
+	Attempts   int           `json:"attempts"`
+	Error      string        `json:"error"`
+}
+
+// DeadLetterStore persists undelivered notifications to a JSON file so they survive restarts
+type DeadLetterStore struct {
+	path string
+	mu   sync.Mutex
+	// replayMu serializes replays, which send without holding mu
+	replayMu sync.Mutex
+}
+
+func NewDeadLetterStore(path string) *DeadLetterStore {
+	return &DeadLetterStore{path: path}
+}
+
+// Add appends an undelivered notification to the store
+func (d *DeadLetterStore) Add(entry DeadLetter) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entries, err := d.load()
+	if err != nil {
+		return err
+	}
+	return d.save(append(entries, entry))
+}
+
+// Entries returns the notifications currently in the store
+func (d *DeadLetterStore) Entries() ([]DeadLetter, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.load()
+}
+
+// ReplayResult summarizes a dead-letter replay
+type ReplayResult struct {
+	Replayed int
+	Skipped  int
+	Failed   int
+}
+
+// Replay re-sends stored notifications, oldest first. Entries older than maxAge are dropped without
+// being sent and at most limit entries are attempted; the rest stay for the next replay. Entries that
+// fail again are kept until they have been attempted maxDeadLetterAttempts times. The entries are
+// sent without holding the store's lock, so Add is not held up by a slow webhook.
+func (d *DeadLetterStore) Replay(ctx context.Context, logger *logrus.Logger, maxAge time.Duration, limit int) (ReplayResult, error) {
+	if maxAge <= 0 {
+		maxAge = DefaultDeadLetterMaxAge
+	}
+	if limit <= 0 {
+		limit = DefaultDeadLetterReplayLimit
+	}
+
+	d.replayMu.Lock()
+	defer d.replayMu.Unlock()
+
+	var result ReplayResult
+	d.mu.Lock()
+	entries, err := d.load()
+	d.mu.Unlock()
+	if err != nil || len(entries) == 0 {
+		return result, err
+	}
+
+	var remaining []DeadLetter
+	for _, entry := range entries {
+		if time.Since(entry.FailedAt) > maxAge {
+			result.Skipped++
+			logger.Warnf("Dropping dead-lettered notification from %s, older than %s", entry.FailedAt.Format(time.RFC3339), maxAge)
+			continue
+		}
+		if result.Replayed+result.Failed >= limit || ctx.Err() != nil {
+			remaining = append(remaining, entry)
+			continue
+		}
+
+		slack, err := NewSlackServiceForWebhook(logger, entry.WebhookURL)
+		if err == nil {
+			err = slack.SendSlackMessage(ctx, entry.Message)
+		}
+		if err != nil {
+			result.Failed++
+			entry.Attempts++
+			entry.Error = err.Error()
+			if entry.Attempts < maxDeadLetterAttempts {
+				remaining = append(remaining, entry)
+			} else {
+				logger.Warnf("Giving up on dead-lettered notification after %d attempts: %v", entry.Attempts, err)
+			}
+			continue
+		}
+		result.Replayed++
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// Only replays remove entries, so the ones added while sending follow the snapshot
+	current, err := d.load()
+	if err != nil {
+		return result, err
+	}
+	if len(current) > len(entries) {
+		remaining = append(remaining, current[len(entries):]...)
+	}
+	return result, d.save(remaining)
+}
+
+func (d *DeadLetterStore) load() ([]DeadLetter, error) {
+	data, err := os.ReadFile(d.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter store: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var entries []DeadLetter
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse dead-letter store: %w", err)
+	}
+	return entries, nil
+}
+
+// save rewrites the store atomically so a crash mid-write never loses entries
+func (d *DeadLetterStore) save(entries []DeadLetter) error {
+	if len(entries) == 0 {
+		if err := os.Remove(d.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to clear dead-letter store: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dead-letter store: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(d.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create dead-letter directory: %w", err)
+	}
+
+	tmp := d.path + ".tmp"
+	// Webhook URLs are credentials, keep the file private
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write dead-letter store: %w", err)
+	}
+	if err := os.Rename(tmp, d.path); err != nil {
+		return fmt.Errorf("failed to write dead-letter store: %w", err)
+	}
+	return nil
+}
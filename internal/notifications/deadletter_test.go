@@ -0,0 +1,187 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterStore_Replay(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg SlackMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		mu.Lock()
+		received = append(received, msg.Text)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name         string
+		text         string
+		failedAt     time.Time
+		wantReceived []string
+		wantResult   ReplayResult
+	}{
+		{
+			name:         "recent entry is replayed",
+			text:         "recent",
+			failedAt:     time.Now().Add(-time.Hour),
+			wantReceived: []string{"recent"},
+			wantResult:   ReplayResult{Replayed: 1},
+		},
+		{
+			name:       "old entry is skipped",
+			text:       "old",
+			failedAt:   time.Now().Add(-48 * time.Hour),
+			wantResult: ReplayResult{Skipped: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			store := NewDeadLetterStore(filepath.Join(t.TempDir(), "dead_letters.json"))
+			require.NoError(t, store.Add(DeadLetter{
+				WebhookURL: ts.URL,
+				Message:    &SlackMessage{Text: tt.text},
+				FailedAt:   tt.failedAt,
+			}))
+
+			result, err := store.Replay(context.Background(), logger, 24*time.Hour, 10)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantResult, result)
+			assert.Equal(t, tt.wantReceived, received)
+
+			entries, err := store.Entries()
+			require.NoError(t, err)
+			assert.Empty(t, entries, "replayed and expired entries should be removed")
+		})
+	}
+}
+
+func TestDeadLetterStore_ReplayLimits(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	t.Run("limit keeps the rest for later", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ts.Close()
+
+		store := NewDeadLetterStore(filepath.Join(t.TempDir(), "dead_letters.json"))
+		for range 3 {
+			require.NoError(t, store.Add(DeadLetter{WebhookURL: ts.URL, Message: &SlackMessage{Text: "x"}, FailedAt: time.Now()}))
+		}
+
+		result, err := store.Replay(context.Background(), logger, time.Hour, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Replayed)
+
+		entries, err := store.Entries()
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("failed entries are retried a bounded number of times", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		store := NewDeadLetterStore(filepath.Join(t.TempDir(), "dead_letters.json"))
+		require.NoError(t, store.Add(DeadLetter{WebhookURL: ts.URL, Message: &SlackMessage{Text: "x"}, FailedAt: time.Now()}))
+
+		for attempt := 1; attempt <= maxDeadLetterAttempts; attempt++ {
+			result, err := store.Replay(context.Background(), logger, time.Hour, 10)
+			require.NoError(t, err)
+			assert.Equal(t, 1, result.Failed)
+		}
+
+		entries, err := store.Entries()
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestSlackService_DeadLettersFailedSends(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	slack, err := NewSlackServiceForWebhook(logger, ts.URL)
+	require.NoError(t, err)
+	store := NewDeadLetterStore(filepath.Join(t.TempDir(), "dead_letters.json"))
+	slack.SetDeadLetterStore(store)
+
+	require.Error(t, slack.SendSlackMessage(context.Background(), &SlackMessage{Text: "undelivered"}))
+
+	entries, err := store.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ts.URL, entries[0].WebhookURL)
+	assert.Equal(t, "undelivered", entries[0].Message.Text)
+	assert.Contains(t, entries[0].Error, "503")
+}
+
+func TestDeadLetterStore_AddDuringReplay(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	store := NewDeadLetterStore(filepath.Join(t.TempDir(), "dead_letters.json"))
+	added := make(chan error, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A send failing elsewhere while the replay waits on the webhook
+		go func() {
+			added <- store.Add(DeadLetter{
+				WebhookURL: "http://127.0.0.1:1",
+				Message:    &SlackMessage{Text: "new"},
+				FailedAt:   time.Now(),
+			})
+		}()
+		select {
+		case err := <-added:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Error("Add blocked while the replay was sending")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	require.NoError(t, store.Add(DeadLetter{
+		WebhookURL: ts.URL,
+		Message:    &SlackMessage{Text: "old"},
+		FailedAt:   time.Now().Add(-time.Hour),
+	}))
+
+	result, err := store.Replay(context.Background(), logger, 24*time.Hour, 10)
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Replayed: 1}, result)
+
+	entries, err := store.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "entries added during the replay are kept")
+	assert.Equal(t, "new", entries[0].Message.Text)
+}
@@ -0,0 +1,125 @@
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHistorySize is how many sent notifications are kept unless configured otherwise
+	DefaultHistorySize = 100
+	BackendSlack       = "slack"
+)
+
+// SentNotification is a notification that was delivered
+type SentNotification struct {
+	Chain   string    `json:"chain,omitempty"`
+	Backend string    `json:"backend"`
+	SentAt  time.Time `json:"sent_at"`
+	Text    string    `json:"text"`
+}
+
+// NotificationHistory keeps the most recent sent notifications, optionally persisted to a JSON file
+// so the audit trail survives restarts
+type NotificationHistory struct {
+	size    int
+	path    string
+	mu      sync.Mutex
+	entries []SentNotification
+}
+
+// NewNotificationHistory keeps up to size notifications, DefaultHistorySize when size is not
+// positive. With a path, entries persisted by an earlier run are loaded back.
+func NewNotificationHistory(size int, path string) (*NotificationHistory, error) {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	h := &NotificationHistory{size: size, path: path}
+	if path == "" {
+		return h, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(data) == 0) {
+		return h, nil
+	}
+	if err != nil {
+		return h, fmt.Errorf("failed to read notification history: %w", err)
+	}
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		return h, fmt.Errorf("failed to parse notification history: %w", err)
+	}
+	h.trim()
+	return h, nil
+}
+
+// Add records a sent notification, dropping the oldest one once the history is full
+func (h *NotificationHistory) Add(entry SentNotification) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = append(h.entries, entry)
+	h.trim()
+	if h.path == "" {
+		return nil
+	}
+	return h.save()
+}
+
+// Recent returns the kept notifications, newest first
+func (h *NotificationHistory) Recent() []SentNotification {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	recent := make([]SentNotification, len(h.entries))
+	for i, entry := range h.entries {
+		recent[len(h.entries)-1-i] = entry
+	}
+	return recent
+}
+
+func (h *NotificationHistory) trim() {
+	if extra := len(h.entries) - h.size; extra > 0 {
+		h.entries = append([]SentNotification(nil), h.entries[extra:]...)
+	}
+}
+
+// save rewrites the history file atomically, callers must hold h.mu
+func (h *NotificationHistory) save() error {
+	data, err := json.MarshalIndent(h.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode notification history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create notification history directory: %w", err)
+	}
+
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write notification history: %w", err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return fmt.Errorf("failed to write notification history: %w", err)
+	}
+	return nil
+}
+
+// renderText flattens a Slack message into the plain text kept in the history
+func renderText(message *SlackMessage) string {
+	lines := []string{message.Text}
+	for _, attachment := range message.Attachments {
+		if attachment.Text != "" {
+			lines = append(lines, attachment.Text)
+		}
+		for _, field := range attachment.Fields {
+			lines = append(lines, field.Title+": "+field.Value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
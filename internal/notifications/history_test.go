@@ -0,0 +1,75 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationHistory_Cap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	history, err := NewNotificationHistory(3, path)
+	require.NoError(t, err)
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, history.Add(SentNotification{Chain: fmt.Sprintf("chain-%d", i), Backend: BackendSlack}))
+	}
+
+	var chains []string
+	for _, entry := range history.Recent() {
+		chains = append(chains, entry.Chain)
+	}
+	assert.Equal(t, []string{"chain-5", "chain-4", "chain-3"}, chains, "newest first, oldest dropped")
+
+	reloaded, err := NewNotificationHistory(2, path)
+	require.NoError(t, err)
+	recent := reloaded.Recent()
+	require.Len(t, recent, 2, "a smaller size trims the persisted history")
+	assert.Equal(t, "chain-5", recent[0].Chain)
+}
+
+func TestSlackService_RecordsSentNotifications(t *testing.T) {
+	failing := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	slack, err := NewSlackServiceForWebhook(logger, ts.URL)
+	require.NoError(t, err)
+	history, err := NewNotificationHistory(0, "")
+	require.NoError(t, err)
+	slack.SetHistory(history)
+
+	upgrade := &types.UpgradeInfo{Name: "v2", Version: "v2.0.0", Height: 1000, Network: "mainnet", Time: time.Now().Add(time.Hour)}
+	require.NoError(t, slack.SendUpgradeNotification(context.Background(), "osmosis", upgrade))
+	require.NoError(t, slack.SendSlackMessage(context.Background(), &SlackMessage{Text: "plain message"}))
+	failing = true
+	assert.Error(t, slack.SendSlackMessage(context.Background(), &SlackMessage{Text: "undelivered"}))
+
+	recent := history.Recent()
+	require.Len(t, recent, 2, "failed sends are not recorded")
+	assert.Equal(t, "", recent[0].Chain)
+	assert.Equal(t, "plain message", recent[0].Text)
+	assert.Equal(t, "osmosis", recent[1].Chain)
+	assert.Equal(t, BackendSlack, recent[1].Backend)
+	assert.Contains(t, recent[1].Text, "New Upgrade Scheduled for Osmosis")
+	assert.Contains(t, recent[1].Text, "Height: 1000")
+}
@@ -0,0 +1,131 @@
+package notifications
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Locale selects the language notifications are rendered in
+type Locale string
+
+const (
+	LocaleEnglish      Locale = "en"
+	LocalePortugueseBR Locale = "pt-BR"
+
+	DefaultLocale = LocaleEnglish
+)
+
+// translations maps the English text of every notification string to its translation. English is
+// the source language, so strings missing from a catalog are rendered in English.
+var translations = map[Locale]map[string]string{
+	LocalePortugueseBR: {
+		// Upgrade notifications
+		"🚀 New Upgrade Scheduled for %s\nUpgrade: %s":   "🚀 Nova atualização agendada para %s\nAtualização: %s",
+		"⚠️ Major upgrade — review migration steps":     "⚠️ Atualização major — revise os passos de migração",
+		"🐤 Testnet canary: %s mainnet likely to follow": "🐤 Canário da testnet: a mainnet de %s deve vir em seguida",
+		"Network Type":                 "Tipo de rede",
+		"Height":                       "Altura",
+		"Estimated Time":               "Horário estimado",
+		"Time Until Upgrade":           "Tempo até a atualização",
+		"Current Version":              "Versão atual",
+		"Change Type":                  "Tipo de mudança",
+		"Vote Tally (proposal %s, %s)": "Apuração de votos (proposta %s, %s)",
+		"Yes %.2f%% · No %.2f%% · Abstain %.2f%% · Veto %.2f%%": "Sim %.2f%% · Não %.2f%% · Abstenção %.2f%% · Veto %.2f%%",
+		"Cosmovisor Folder":            "Pasta do Cosmovisor",
+		"View Proposal":                "Ver proposta",
+		"View Guide":                   "Ver guia",
+		"View Block":                   "Ver bloco",
+		"View Code":                    "Ver código",
+		"Binary":                       "Binário",
+		"Links":                        "Links",
+		"Chain: %s | Last Updated: %s": "Chain: %s | Última atualização: %s",
+
+		// Batched upgrades
+		"🚀 %d new upgrades scheduled": "🚀 %d novas atualizações agendadas",
+
+		// Upgrade in progress and chain resolved
+		"⏳ Upgrade in progress for %s\nUpgrade: %s":                                                "⏳ Atualização em andamento para %s\nAtualização: %s",
+		"The scheduled upgrade time has passed and the upgrade height has not been confirmed yet.": "O horário agendado da atualização já passou e a altura da atualização ainda não foi confirmada.",
+		"Scheduled Time":               "Horário agendado",
+		"✅ Now actively monitoring %s": "✅ Monitorando ativamente %s",
+		"The chain now resolves in the chain-registry and its upgrades are being tracked.": "A chain agora é encontrada no chain-registry e suas atualizações estão sendo acompanhadas.",
+		"Chain": "Chain",
+
+		// Cancelled upgrades
+		"🛑 Upgrade no longer scheduled for %s\nUpgrade: %s":                                                                       "🛑 Atualização não está mais agendada para %s\nAtualização: %s",
+		"The upgrade disappeared from every upgrade source before its scheduled time. It may have been cancelled or rescheduled.": "A atualização sumiu de todas as fontes de atualização antes do horário agendado. Ela pode ter sido cancelada ou reagendada.",
+
+		// Endpoint health alerts
+		"🚨 Critical: %s upgrades in %s with no healthy endpoints\nUpgrade: %s":                   "🚨 Crítico: %s atualiza em %s sem endpoints saudáveis\nAtualização: %s",
+		"None of the chain's RPC and REST endpoints respond, so the upgrade cannot be verified.": "Nenhum endpoint RPC ou REST da chain responde, então a atualização não pode ser verificada.",
+		"Endpoints Probed": "Endpoints verificados",
+
+		// Stalled chains
+		"⛔ %s has stopped producing blocks": "⛔ %s parou de produzir blocos",
+		"The chain's height has not advanced in a while. It may have halted, for instance at an upgrade height.": "A altura da chain não avança há algum tempo. Ela pode ter parado, por exemplo na altura de uma atualização.",
+		"▶️ %s is producing blocks again":    "▶️ %s voltou a produzir blocos",
+		"The chain's height advances again.": "A altura da chain voltou a avançar.",
+		"Last Height":                        "Última altura",
+		"Stuck For":                          "Parada há",
+		"Stalled For":                        "Ficou parada por",
+		"Stalled Since":                      "Parada desde",
+
+		// Downgrade alerts
+		"↩️ Possible rollback/downgrade for %s\nUpgrade: %s":                                                                               "↩️ Possível rollback/downgrade para %s\nAtualização: %s",
+		"The upgrade targets an older version than the chain currently runs. Check whether this is a planned rollback or bad source data.": "A atualização aponta para uma versão mais antiga do que a chain executa atualmente. Verifique se é um rollback planejado ou dados incorretos da fonte.",
+		"Target Version": "Versão alvo",
+
+		// Job, API and detected upgrade notifications
+		"Job Name":                 "Nome do job",
+		"Status":                   "Status",
+		"Duration":                 "Duração",
+		"Details":                  "Detalhes",
+		"%s Job Status Update":     "%s Atualização de status do job",
+		"Endpoint":                 "Endpoint",
+		"%s API Event":             "%s Evento da API",
+		"Name":                     "Nome",
+		"Time":                     "Horário",
+		"Info":                     "Informações",
+		"Network":                  "Rede",
+		"Today at %s":              "Hoje às %s",
+		"🌐 Chain Upgrade Detected": "🌐 Atualização de chain detectada",
+	},
+}
+
+// ParseLocale validates a configured language. An empty value selects the default locale.
+func ParseLocale(value string) (Locale, error) {
+	if value == "" {
+		return DefaultLocale, nil
+	}
+	if strings.EqualFold(value, string(LocaleEnglish)) {
+		return LocaleEnglish, nil
+	}
+	for locale := range translations {
+		if strings.EqualFold(value, string(locale)) {
+			return locale, nil
+		}
+	}
+	return DefaultLocale, fmt.Errorf("unsupported language %q, expected one of %s", value, strings.Join(SupportedLocales(), ", "))
+}
+
+// SupportedLocales lists the languages notifications can be rendered in
+func SupportedLocales() []string {
+	locales := []string{string(LocaleEnglish)}
+	for locale := range translations {
+		locales = append(locales, string(locale))
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// T renders an English notification string in the locale, formatting it with args when given
+func (l Locale) T(text string, args ...interface{}) string {
+	if translated, ok := translations[l][text]; ok {
+		text = translated
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
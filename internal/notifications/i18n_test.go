@@ -0,0 +1,132 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackService_Locale(t *testing.T) {
+	var received SlackMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	upgrade := &types.UpgradeInfo{
+		Name:         "v25",
+		Version:      "v25.0.0",
+		Network:      "mainnet",
+		Height:       1000000,
+		Time:         time.Now().Add(48 * time.Hour),
+		ProposalLink: "https://www.mintscan.io/cosmos/proposals/924",
+		ChangeType:   "major",
+		BinaryURL:    "https://github.com/cosmos/gaia/releases/download/v25.0.0/gaiad-v25.0.0-linux-amd64",
+	}
+
+	tests := []struct {
+		locale   Locale
+		text     string
+		titles   []string
+		links    string
+		binary   string
+		footer   string
+		detected string
+	}{
+		{
+			locale:   LocaleEnglish,
+			text:     "🚀 New Upgrade Scheduled for Cosmoshub\nUpgrade: v25.0.0",
+			titles:   []string{"Network Type", "Height", "Estimated Time", "Time Until Upgrade", "Change Type", "Links"},
+			links:    "View Proposal",
+			binary:   "⬇ <https://github.com/cosmos/gaia/releases/download/v25.0.0/gaiad-v25.0.0-linux-amd64|Binary>",
+			footer:   "Today at ",
+			detected: "🌐 Chain Upgrade Detected",
+		},
+		{
+			locale:   LocalePortugueseBR,
+			text:     "🚀 Nova atualização agendada para Cosmoshub\nAtualização: v25.0.0",
+			titles:   []string{"Tipo de rede", "Altura", "Horário estimado", "Tempo até a atualização", "Tipo de mudança", "Links"},
+			links:    "Ver proposta",
+			binary:   "⬇ <https://github.com/cosmos/gaia/releases/download/v25.0.0/gaiad-v25.0.0-linux-amd64|Binário>",
+			footer:   "Hoje às ",
+			detected: "🌐 Atualização de chain detectada",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.locale), func(t *testing.T) {
+			slack, err := NewSlackServiceForWebhook(logger, ts.URL)
+			require.NoError(t, err)
+			slack.SetLocale(tt.locale)
+
+			require.NoError(t, slack.SendUpgradeNotification(context.Background(), "cosmoshub", upgrade))
+			assert.Equal(t, tt.text, received.Text)
+			require.Len(t, received.Attachments, 1)
+			var titles []string
+			for _, field := range received.Attachments[0].Fields {
+				titles = append(titles, field.Title)
+			}
+			assert.Equal(t, tt.titles, titles)
+			assert.Contains(t, received.Attachments[0].Fields[len(titles)-1].Value, tt.links)
+			assert.Contains(t, received.Attachments[0].Fields[len(titles)-1].Value, tt.binary)
+
+			detected := NewNotificationService(slack).formatUpgradeNotification("cosmoshub", upgrade)
+			assert.Equal(t, tt.detected, detected.Text)
+			assert.True(t, strings.HasPrefix(detected.Attachments[0].Footer, tt.footer), detected.Attachments[0].Footer)
+		})
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Locale
+		wantErr  bool
+	}{
+		{"", DefaultLocale, false},
+		{"en", LocaleEnglish, false},
+		{"pt-BR", LocalePortugueseBR, false},
+		{"pt-br", LocalePortugueseBR, false},
+		{"de", DefaultLocale, true},
+	}
+
+	for _, tt := range tests {
+		locale, err := ParseLocale(tt.value)
+		assert.Equal(t, tt.expected, locale, tt.value)
+		assert.Equal(t, tt.wantErr, err != nil, tt.value)
+	}
+}
+
+// Translations must keep the format verbs of the English text so arguments render in the same order
+func TestTranslations_FormatVerbs(t *testing.T) {
+	verbs := func(text string) []string {
+		var found []string
+		for i := 0; i < len(text)-1; i++ {
+			if text[i] == '%' {
+				found = append(found, text[i:i+2])
+				i++
+			}
+		}
+		return found
+	}
+
+	for locale, catalog := range translations {
+		for english, translated := range catalog {
+			assert.Equal(t, verbs(english), verbs(translated), "%s: %q", locale, english)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package notifications
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -54,7 +55,7 @@ func TestNotificationServiceManual(t *testing.T) {
 		BlockLink:    "https://www.mintscan.io/osmosis/blocks/1000000",
 	}
 
-	err = notificationService.SendUpgradeNotification("osmosis", upgradeInfo)
+	err = notificationService.SendUpgradeNotification(context.Background(), "osmosis", upgradeInfo)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1,6 +1,7 @@
 package notifications
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -26,7 +27,16 @@ func NewNotificationService(slackService *SlackService) *NotificationService {
 	}
 }
 
+// locale is the language of the underlying Slack service
+func (s *NotificationService) locale() Locale {
+	if s.slackService == nil {
+		return DefaultLocale
+	}
+	return s.slackService.locale
+}
+
 func (s *NotificationService) formatJobNotification(jobName string, status string, duration time.Duration, details string) *SlackMessage {
+	locale := s.locale()
 	var color string
 	var icon string
 
@@ -47,12 +57,12 @@ func (s *NotificationService) formatJobNotification(jobName string, status strin
 
 	fields := []Field{
 		{
-			Title: "Job Name",
+			Title: locale.T("Job Name"),
 			Value: jobName,
 			Short: true,
 		},
 		{
-			Title: "Status",
+			Title: locale.T("Status"),
 			Value: status,
 			Short: true,
 		},
@@ -60,7 +70,7 @@ func (s *NotificationService) formatJobNotification(jobName string, status strin
 
 	if duration > 0 {
 		fields = append(fields, Field{
-			Title: "Duration",
+			Title: locale.T("Duration"),
 			Value: duration.String(),
 			Short: true,
 		})
@@ -68,14 +78,14 @@ func (s *NotificationService) formatJobNotification(jobName string, status strin
 
 	if details != "" {
 		fields = append(fields, Field{
-			Title: "Details",
+			Title: locale.T("Details"),
 			Value: details,
 			Short: false,
 		})
 	}
 
 	return &SlackMessage{
-		Text: fmt.Sprintf("%s Job Status Update", icon),
+		Text: locale.T("%s Job Status Update", icon),
 		Attachments: []Attachment{
 			{
 				Color:  color,
@@ -87,6 +97,7 @@ func (s *NotificationService) formatJobNotification(jobName string, status strin
 }
 
 func (s *NotificationService) formatAPINotification(endpoint string, status string, details string) *SlackMessage {
+	locale := s.locale()
 	var color string
 	var icon string
 
@@ -104,12 +115,12 @@ func (s *NotificationService) formatAPINotification(endpoint string, status stri
 
 	fields := []Field{
 		{
-			Title: "Endpoint",
+			Title: locale.T("Endpoint"),
 			Value: endpoint,
 			Short: true,
 		},
 		{
-			Title: "Status",
+			Title: locale.T("Status"),
 			Value: status,
 			Short: true,
 		},
@@ -117,14 +128,14 @@ func (s *NotificationService) formatAPINotification(endpoint string, status stri
 
 	if details != "" {
 		fields = append(fields, Field{
-			Title: "Details",
+			Title: locale.T("Details"),
 			Value: details,
 			Short: false,
 		})
 	}
 
 	return &SlackMessage{
-		Text: fmt.Sprintf("%s API Event", icon),
+		Text: locale.T("%s API Event", icon),
 		Attachments: []Attachment{
 			{
 				Color:  color,
@@ -135,22 +146,24 @@ func (s *NotificationService) formatAPINotification(endpoint string, status stri
 	}
 }
 
-func (s *NotificationService) SendJobNotification(jobName string, status string, duration time.Duration, details string) error {
+func (s *NotificationService) SendJobNotification(ctx context.Context, jobName string, status string, duration time.Duration, details string) error {
 	message := s.formatJobNotification(jobName, status, duration, details)
-	return s.slackService.SendSlackMessage(message)
+	return s.slackService.SendSlackMessage(ctx, message)
 }
 
-func (s *NotificationService) SendAPINotification(endpoint string, status string, details string) error {
+func (s *NotificationService) SendAPINotification(ctx context.Context, endpoint string, status string, details string) error {
 	message := s.formatAPINotification(endpoint, status, details)
-	return s.slackService.SendSlackMessage(message)
+	return s.slackService.SendSlackMessage(ctx, message)
 }
 
-func (s *NotificationService) SendUpgradeNotification(chainName string, upgrade *types.UpgradeInfo) error {
+func (s *NotificationService) SendUpgradeNotification(ctx context.Context, chainName string, upgrade *types.UpgradeInfo) error {
 	message := s.formatUpgradeNotification(chainName, upgrade)
-	return s.slackService.SendSlackMessage(message)
+	return s.slackService.post(ctx, chainName, message)
 }
 
 func (s *NotificationService) formatUpgradeNotification(chainName string, upgrade *types.UpgradeInfo) *SlackMessage {
+	locale := s.locale()
+
 	// Format time in the exact format shown: YYYY-MM-DD HH:mm:ss.SSSSSS +ZZZZ CEST m=+NNNNN.NNNNNN
 	timeStr := fmt.Sprintf("%s CEST m=+%f",
 		upgrade.Time.Format("2006-01-02 15:04:05.000000 -0700"),
@@ -158,22 +171,22 @@ func (s *NotificationService) formatUpgradeNotification(chainName string, upgrad
 
 	fields := []Field{
 		{
-			Title: "Chain",
+			Title: locale.T("Chain"),
 			Value: chainName,
 			Short: true,
 		},
 		{
-			Title: "Name",
+			Title: locale.T("Name"),
 			Value: upgrade.Name,
 			Short: true,
 		},
 		{
-			Title: "Height",
+			Title: locale.T("Height"),
 			Value: fmt.Sprintf("%d", upgrade.Height),
 			Short: true,
 		},
 		{
-			Title: "Time",
+			Title: locale.T("Time"),
 			Value: timeStr,
 			Short: true,
 		},
@@ -181,7 +194,7 @@ func (s *NotificationService) formatUpgradeNotification(chainName string, upgrad
 
 	if upgrade.Info != "" {
 		fields = append(fields, Field{
-			Title: "Info",
+			Title: locale.T("Info"),
 			Value: upgrade.Info,
 			Short: false,
 		})
@@ -191,16 +204,16 @@ func (s *NotificationService) formatUpgradeNotification(chainName string, upgrad
 	if upgrade.ProposalLink != "" || upgrade.Guide != "" || upgrade.BlockLink != "" {
 		var links []string
 		if upgrade.ProposalLink != "" {
-			links = append(links, "📄 View Proposal")
+			links = append(links, "📄 "+locale.T("View Proposal"))
 		}
 		if upgrade.Guide != "" {
-			links = append(links, "📚 View Guide")
+			links = append(links, "📚 "+locale.T("View Guide"))
 		}
 		if upgrade.BlockLink != "" {
-			links = append(links, "🔍 View Block")
+			links = append(links, "🔍 "+locale.T("View Block"))
 		}
 		fields = append(fields, Field{
-			Title: "Links",
+			Title: locale.T("Links"),
 			Value: strings.Join(links, " | "),
 			Short: false,
 		})
@@ -208,17 +221,16 @@ func (s *NotificationService) formatUpgradeNotification(chainName string, upgrad
 
 	if upgrade.Network != "" {
 		fields = append(fields, Field{
-			Title: "Network",
+			Title: locale.T("Network"),
 			Value: upgrade.Network,
 			Short: true,
 		})
 	}
 
-	// Add footer with current time in format "Hoje às HH:mm"
-	footer := fmt.Sprintf("Hoje às %s", time.Now().Format("15:04"))
+	footer := locale.T("Today at %s", time.Now().Format("15:04"))
 
 	return &SlackMessage{
-		Text: "🌐 Chain Upgrade Detected",
+		Text: locale.T("🌐 Chain Upgrade Detected"),
 		Attachments: []Attachment{
 			{
 				Color:  "warning",
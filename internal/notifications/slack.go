@@ -2,6 +2,8 @@ package notifications
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,15 +13,23 @@ import (
 
 	"github.com/0xPuncker/cosmos-watcher/pkg/types"
 	"github.com/0xPuncker/cosmos-watcher/pkg/utils"
+	"github.com/0xPuncker/cosmos-watcher/pkg/version"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
+// DefaultSendTimeout bounds a single Slack send unless SLACK_SEND_TIMEOUT overrides it
+const DefaultSendTimeout = 10 * time.Second
+
 type SlackService struct {
-	logger     *logrus.Logger
-	webhookURL string
-	client     *http.Client
+	logger      *logrus.Logger
+	webhookURL  string
+	client      *http.Client
+	sendTimeout time.Duration
+	deadLetters *DeadLetterStore
+	history     *NotificationHistory
+	locale      Locale
 }
 
 type SlackMessage struct {
@@ -47,56 +57,143 @@ func NewSlackService(logger *logrus.Logger) (*SlackService, error) {
 		return nil, fmt.Errorf("SLACK_WEBHOOK_URL environment variable is not set")
 	}
 
+	return NewSlackServiceForWebhook(logger, webhookURL)
+}
+
+// NewSlackServiceForWebhook creates a service posting to the given webhook instead of SLACK_WEBHOOK_URL,
+// e.g. for the channel of a chain group
+func NewSlackServiceForWebhook(logger *logrus.Logger, webhookURL string) (*SlackService, error) {
+	if webhookURL == "" {
+		return nil, fmt.Errorf("slack webhook URL not configured")
+	}
+
+	minTLSVersion, err := utils.MinTLSVersion()
+	if err != nil {
+		logger.Warnf("%v, falling back to TLS 1.2", err)
+	}
+
+	sendTimeout := DefaultSendTimeout
+	if value := os.Getenv("SLACK_SEND_TIMEOUT"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			sendTimeout = d
+		} else {
+			logger.Warnf("Invalid SLACK_SEND_TIMEOUT %q, falling back to %s", value, DefaultSendTimeout)
+		}
+	}
+
 	return &SlackService{
 		logger:     logger,
 		webhookURL: webhookURL,
-		client:     &http.Client{Timeout: 10 * time.Second},
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{MinVersion: minTLSVersion},
+			},
+		},
+		sendTimeout: sendTimeout,
+		locale:      DefaultLocale,
 	}, nil
 }
 
-func (s *SlackService) SendUpgradeNotification(chainName string, upgradeInfo *types.UpgradeInfo) error {
+// SetSendTimeout overrides the deadline applied to each send; non-positive values are ignored
+func (s *SlackService) SetSendTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		s.sendTimeout = timeout
+	}
+}
+
+// SetLocale selects the language messages are rendered in
+func (s *SlackService) SetLocale(locale Locale) {
+	s.locale = locale
+}
+
+// SetDeadLetterStore records messages that fail to send so they can be replayed on the next startup
+func (s *SlackService) SetDeadLetterStore(store *DeadLetterStore) {
+	s.deadLetters = store
+}
+
+// SetHistory records every message delivered by the service in history
+func (s *SlackService) SetHistory(history *NotificationHistory) {
+	s.history = history
+}
+
+// chainTitle names the chain of upgradeInfo in message titles: its display name, or the title-cased
+// chainName when it has none
+func chainTitle(chainName string, upgradeInfo *types.UpgradeInfo) string {
+	if upgradeInfo != nil && upgradeInfo.DisplayName != "" {
+		return upgradeInfo.DisplayName
+	}
+	return cases.Title(language.English).String(chainName)
+}
+
+func (s *SlackService) SendUpgradeNotification(ctx context.Context, chainName string, upgradeInfo *types.UpgradeInfo) error {
 	timeUntilUpgrade := time.Until(upgradeInfo.Time)
 	timeUntilStr := utils.FormatDuration(timeUntilUpgrade)
 
-	color := "#36a64f"
-	if timeUntilUpgrade < 24*time.Hour {
-		color = "#ffcc00"
-	}
-	if timeUntilUpgrade < 1*time.Hour {
-		color = "#ff0000"
-	}
+	color := upgradeColor(timeUntilUpgrade)
 
-	mainMessage := fmt.Sprintf("🚀 New Upgrade Scheduled for %s\nUpgrade: %s",
-		cases.Title(language.English).String(chainName),
+	mainMessage := s.locale.T("🚀 New Upgrade Scheduled for %s\nUpgrade: %s",
+		chainTitle(chainName, upgradeInfo),
 		upgradeInfo.Version)
+	if upgradeInfo.MajorUpgrade {
+		mainMessage += "\n" + s.locale.T("⚠️ Major upgrade — review migration steps")
+	}
+	if upgradeInfo.MainnetChain != "" {
+		mainMessage += "\n" + s.locale.T("🐤 Testnet canary: %s mainnet likely to follow",
+			cases.Title(language.English).String(upgradeInfo.MainnetChain))
+	}
 
 	fields := []Field{
 		{
-			Title: "Network Type",
+			Title: s.locale.T("Network Type"),
 			Value: upgradeInfo.Network,
 			Short: true,
 		},
 		{
-			Title: "Height",
+			Title: s.locale.T("Height"),
 			Value: fmt.Sprintf("%d", upgradeInfo.Height),
 			Short: true,
 		},
 		{
-			Title: "Estimated Time",
+			Title: s.locale.T("Estimated Time"),
 			Value: upgradeInfo.Time.Format(time.RFC1123),
 			Short: true,
 		},
 		{
-			Title: "Time Until Upgrade",
+			Title: s.locale.T("Time Until Upgrade"),
 			Value: timeUntilStr,
 			Short: true,
 		},
 	}
 
+	if upgradeInfo.CurrentVersion != "" {
+		fields = append(fields, Field{
+			Title: s.locale.T("Current Version"),
+			Value: fmt.Sprintf("%s → %s", upgradeInfo.CurrentVersion, upgradeInfo.Version),
+			Short: true,
+		})
+	}
+
+	if upgradeInfo.ChangeType != "" && upgradeInfo.ChangeType != string(version.Unknown) {
+		fields = append(fields, Field{
+			Title: s.locale.T("Change Type"),
+			Value: upgradeInfo.ChangeType,
+			Short: true,
+		})
+	}
+
+	if tally := upgradeInfo.VoteTally; tally != nil {
+		fields = append(fields, Field{
+			Title: s.locale.T("Vote Tally (proposal %s, %s)", tally.ProposalID, strings.ReplaceAll(tally.Status, "_", " ")),
+			Value: s.locale.T("Yes %.2f%% · No %.2f%% · Abstain %.2f%% · Veto %.2f%%", tally.Yes, tally.No, tally.Abstain, tally.NoWithVeto),
+			Short: false,
+		})
+	}
+
 	// Add Cosmovisor folder if available
 	if upgradeInfo.CosmovisorFolder != "" {
 		fields = append(fields, Field{
-			Title: "Cosmovisor Folder",
+			Title: s.locale.T("Cosmovisor Folder"),
 			Value: upgradeInfo.CosmovisorFolder,
 			Short: true,
 		})
@@ -105,24 +202,28 @@ func (s *SlackService) SendUpgradeNotification(chainName string, upgradeInfo *ty
 	var links []string
 
 	if upgradeInfo.ProposalLink != "" {
-		links = append(links, fmt.Sprintf("📋 <%s|View Proposal>", upgradeInfo.ProposalLink))
+		links = append(links, fmt.Sprintf("📋 <%s|%s>", upgradeInfo.ProposalLink, s.locale.T("View Proposal")))
 	}
 
 	if upgradeInfo.Guide != "" {
-		links = append(links, fmt.Sprintf("📚 <%s|View Guide>", upgradeInfo.Guide))
+		links = append(links, fmt.Sprintf("📚 <%s|%s>", upgradeInfo.Guide, s.locale.T("View Guide")))
 	}
 
 	if upgradeInfo.BlockLink != "" {
-		links = append(links, fmt.Sprintf("🔍 <%s|View Block>", upgradeInfo.BlockLink))
+		links = append(links, fmt.Sprintf("🔍 <%s|%s>", upgradeInfo.BlockLink, s.locale.T("View Block")))
 	}
 
 	if upgradeInfo.Repo != "" {
-		links = append(links, fmt.Sprintf("📦 <%s|View Code>", upgradeInfo.Repo))
+		links = append(links, fmt.Sprintf("📦 <%s|%s>", upgradeInfo.Repo, s.locale.T("View Code")))
+	}
+
+	if upgradeInfo.BinaryURL != "" {
+		links = append(links, fmt.Sprintf("⬇ <%s|%s>", upgradeInfo.BinaryURL, s.locale.T("Binary")))
 	}
 
 	if len(links) > 0 {
 		fields = append(fields, Field{
-			Title: "Links",
+			Title: s.locale.T("Links"),
 			Value: strings.Join(links, " | "),
 			Short: false,
 		})
@@ -134,7 +235,7 @@ func (s *SlackService) SendUpgradeNotification(chainName string, upgradeInfo *ty
 			{
 				Color:  color,
 				Fields: fields,
-				Footer: fmt.Sprintf("Chain: %s | Last Updated: %s",
+				Footer: s.locale.T("Chain: %s | Last Updated: %s",
 					chainName,
 					time.Now().Format("Mon, 02 Jan 2006 15:04:05 MST")),
 				Ts: time.Now().Unix(),
@@ -146,10 +247,333 @@ func (s *SlackService) SendUpgradeNotification(chainName string, upgradeInfo *ty
 		message.Attachments[0].Text = upgradeInfo.Info
 	}
 
-	return s.SendSlackMessage(&message)
+	return s.post(ctx, chainName, &message)
 }
 
-func (s *SlackService) SendSlackMessage(message *SlackMessage) error {
+// SendUpgradeBatch announces the upgrades detected during a batching window in a single message,
+// with one attachment per upgrade
+func (s *SlackService) SendUpgradeBatch(ctx context.Context, upgrades []*types.UpgradeInfo) error {
+	if len(upgrades) == 0 {
+		return nil
+	}
+
+	attachments := make([]Attachment, 0, len(upgrades))
+	for _, upgradeInfo := range upgrades {
+		timeUntilUpgrade := time.Until(upgradeInfo.Time)
+		attachments = append(attachments, Attachment{
+			Color: upgradeColor(timeUntilUpgrade),
+			Text: fmt.Sprintf("*%s*: %s",
+				chainTitle(upgradeInfo.ChainName, upgradeInfo),
+				upgradeInfo.Version),
+			Fields: []Field{
+				{
+					Title: s.locale.T("Network Type"),
+					Value: upgradeInfo.Network,
+					Short: true,
+				},
+				{
+					Title: s.locale.T("Height"),
+					Value: fmt.Sprintf("%d", upgradeInfo.Height),
+					Short: true,
+				},
+				{
+					Title: s.locale.T("Estimated Time"),
+					Value: upgradeInfo.Time.Format(time.RFC1123),
+					Short: true,
+				},
+				{
+					Title: s.locale.T("Time Until Upgrade"),
+					Value: utils.FormatDuration(timeUntilUpgrade),
+					Short: true,
+				},
+			},
+			Ts: time.Now().Unix(),
+		})
+	}
+
+	message := SlackMessage{
+		Text:        s.locale.T("🚀 %d new upgrades scheduled", len(upgrades)),
+		Attachments: attachments,
+	}
+	return s.SendSlackMessage(ctx, &message)
+}
+
+// upgradeColor turns from green to yellow to red as an upgrade gets closer
+func upgradeColor(timeUntilUpgrade time.Duration) string {
+	switch {
+	case timeUntilUpgrade < 1*time.Hour:
+		return "#ff0000"
+	case timeUntilUpgrade < 24*time.Hour:
+		return "#ffcc00"
+	default:
+		return "#36a64f"
+	}
+}
+
+// SendUpgradeInProgressNotification announces that an upgrade's scheduled time has passed and the
+// chain is expected to be halting for it right now
+func (s *SlackService) SendUpgradeInProgressNotification(ctx context.Context, chainName string, upgradeInfo *types.UpgradeInfo) error {
+	message := SlackMessage{
+		Text: s.locale.T("⏳ Upgrade in progress for %s\nUpgrade: %s",
+			chainTitle(chainName, upgradeInfo),
+			upgradeInfo.Version),
+		Attachments: []Attachment{
+			{
+				Color: "#ff9900",
+				Text:  s.locale.T("The scheduled upgrade time has passed and the upgrade height has not been confirmed yet."),
+				Fields: []Field{
+					{
+						Title: s.locale.T("Network Type"),
+						Value: upgradeInfo.Network,
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Height"),
+						Value: fmt.Sprintf("%d", upgradeInfo.Height),
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Scheduled Time"),
+						Value: upgradeInfo.Time.Format(time.RFC1123),
+						Short: true,
+					},
+				},
+				Ts: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.post(ctx, chainName, &message)
+}
+
+// SendUpgradeCancelledNotification announces that a previously reported upgrade is no longer
+// scheduled by any upgrade source
+func (s *SlackService) SendUpgradeCancelledNotification(ctx context.Context, chainName string, upgradeInfo *types.UpgradeInfo) error {
+	message := SlackMessage{
+		Text: s.locale.T("🛑 Upgrade no longer scheduled for %s\nUpgrade: %s",
+			chainTitle(chainName, upgradeInfo),
+			upgradeInfo.Version),
+		Attachments: []Attachment{
+			{
+				Color: "warning",
+				Text:  s.locale.T("The upgrade disappeared from every upgrade source before its scheduled time. It may have been cancelled or rescheduled."),
+				Fields: []Field{
+					{
+						Title: s.locale.T("Network Type"),
+						Value: upgradeInfo.Network,
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Height"),
+						Value: fmt.Sprintf("%d", upgradeInfo.Height),
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Scheduled Time"),
+						Value: upgradeInfo.Time.Format(time.RFC1123),
+						Short: true,
+					},
+				},
+				Ts: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.post(ctx, chainName, &message)
+}
+
+// SendNoHealthyEndpointsAlert raises a critical alert for an imminent upgrade on a chain none of
+// whose probed public endpoints respond, leaving operators unable to follow the upgrade
+func (s *SlackService) SendNoHealthyEndpointsAlert(ctx context.Context, chainName string, upgradeInfo *types.UpgradeInfo, probed int) error {
+	message := SlackMessage{
+		Text: s.locale.T("🚨 Critical: %s upgrades in %s with no healthy endpoints\nUpgrade: %s",
+			chainTitle(chainName, upgradeInfo),
+			utils.FormatDuration(time.Until(upgradeInfo.Time)),
+			upgradeInfo.Version),
+		Attachments: []Attachment{
+			{
+				Color: "danger",
+				Text:  s.locale.T("None of the chain's RPC and REST endpoints respond, so the upgrade cannot be verified."),
+				Fields: []Field{
+					{
+						Title: s.locale.T("Network Type"),
+						Value: upgradeInfo.Network,
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Height"),
+						Value: fmt.Sprintf("%d", upgradeInfo.Height),
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Scheduled Time"),
+						Value: upgradeInfo.Time.Format(time.RFC1123),
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Endpoints Probed"),
+						Value: fmt.Sprintf("%d", probed),
+						Short: true,
+					},
+				},
+				Ts: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.post(ctx, chainName, &message)
+}
+
+// SendDowngradeAlert flags an upgrade whose target version is older than the version the chain runs,
+// which points at a rollback or at bad data from the upgrade source
+func (s *SlackService) SendDowngradeAlert(ctx context.Context, chainName string, upgradeInfo *types.UpgradeInfo, currentVersion string) error {
+	message := SlackMessage{
+		Text: s.locale.T("↩️ Possible rollback/downgrade for %s\nUpgrade: %s",
+			chainTitle(chainName, upgradeInfo),
+			upgradeInfo.Version),
+		Attachments: []Attachment{
+			{
+				Color: "warning",
+				Text:  s.locale.T("The upgrade targets an older version than the chain currently runs. Check whether this is a planned rollback or bad source data."),
+				Fields: []Field{
+					{
+						Title: s.locale.T("Current Version"),
+						Value: currentVersion,
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Target Version"),
+						Value: upgradeInfo.Version,
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Height"),
+						Value: fmt.Sprintf("%d", upgradeInfo.Height),
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Estimated Time"),
+						Value: upgradeInfo.Time.Format(time.RFC1123),
+						Short: true,
+					},
+				},
+				Ts: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.post(ctx, chainName, &message)
+}
+
+// SendChainResolvedNotification announces that a configured chain which previously could not be
+// resolved in the chain-registry is now being monitored
+func (s *SlackService) SendChainResolvedNotification(ctx context.Context, chainName, network string) error {
+	message := SlackMessage{
+		Text: s.locale.T("✅ Now actively monitoring %s", chainName),
+		Attachments: []Attachment{
+			{
+				Color: "good",
+				Text:  s.locale.T("The chain now resolves in the chain-registry and its upgrades are being tracked."),
+				Fields: []Field{
+					{
+						Title: s.locale.T("Chain"),
+						Value: chainName,
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Network Type"),
+						Value: network,
+						Short: true,
+					},
+				},
+				Ts: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.post(ctx, chainName, &message)
+}
+
+// SendChainStallEvent warns that a chain has stopped producing blocks, or tells that it produces them
+// again
+func (s *SlackService) SendChainStallEvent(ctx context.Context, event *types.ChainStallEvent) error {
+	text := s.locale.T("⛔ %s has stopped producing blocks", chainTitle(event.Chain, nil))
+	color := "danger"
+	details := s.locale.T("The chain's height has not advanced in a while. It may have halted, for instance at an upgrade height.")
+	heightTitle, durationTitle := s.locale.T("Last Height"), s.locale.T("Stuck For")
+	if event.Type == types.ChainResumed {
+		text = s.locale.T("▶️ %s is producing blocks again", chainTitle(event.Chain, nil))
+		color = "good"
+		details = s.locale.T("The chain's height advances again.")
+		heightTitle, durationTitle = s.locale.T("Height"), s.locale.T("Stalled For")
+	}
+
+	message := SlackMessage{
+		Text: text,
+		Attachments: []Attachment{
+			{
+				Color: color,
+				Text:  details,
+				Fields: []Field{
+					{
+						Title: heightTitle,
+						Value: fmt.Sprintf("%d", event.Height),
+						Short: true,
+					},
+					{
+						Title: durationTitle,
+						Value: utils.FormatDuration(event.Duration),
+						Short: true,
+					},
+					{
+						Title: s.locale.T("Stalled Since"),
+						Value: event.StalledSince.Format(time.RFC1123),
+						Short: true,
+					},
+				},
+				Ts: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.post(ctx, event.Chain, &message)
+}
+
+// SendSlackMessage posts a message to the webhook. The send is abandoned when ctx is cancelled or
+// the per-send timeout elapses, whichever comes first.
+func (s *SlackService) SendSlackMessage(ctx context.Context, message *SlackMessage) error {
+	return s.post(ctx, "", message)
+}
+
+// post sends a message about chainName, which is empty for messages not tied to a chain
+func (s *SlackService) post(ctx context.Context, chainName string, message *SlackMessage) error {
+	err := s.send(ctx, message)
+	if err == nil && s.history != nil {
+		entry := SentNotification{
+			Chain:   chainName,
+			Backend: BackendSlack,
+			SentAt:  time.Now(),
+			Text:    renderText(message),
+		}
+		if historyErr := s.history.Add(entry); historyErr != nil {
+			s.logger.Errorf("Failed to record sent notification: %v", historyErr)
+		}
+	}
+	if err != nil && s.deadLetters != nil && s.webhookURL != "" {
+		entry := DeadLetter{
+			WebhookURL: s.webhookURL,
+			Message:    message,
+			FailedAt:   time.Now(),
+			Error:      err.Error(),
+		}
+		if storeErr := s.deadLetters.Add(entry); storeErr != nil {
+			s.logger.Errorf("Failed to dead-letter Slack message: %v", storeErr)
+		}
+	}
+	return err
+}
+
+func (s *SlackService) send(ctx context.Context, message *SlackMessage) error {
 	if s.webhookURL == "" {
 		return fmt.Errorf("slack webhook URL not configured")
 	}
@@ -159,7 +583,16 @@ func (s *SlackService) SendSlackMessage(message *SlackMessage) error {
 		return fmt.Errorf("error marshaling slack message: %w", err)
 	}
 
-	resp, err := http.Post(s.webhookURL, "application/json", bytes.NewBuffer(jsonMessage))
+	ctx, cancel := context.WithTimeout(ctx, s.sendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewBuffer(jsonMessage))
+	if err != nil {
+		return fmt.Errorf("error creating slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error sending slack message: %w", err)
 	}
@@ -1,6 +1,7 @@
 package notifications
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -116,7 +117,7 @@ func TestSlackNotificationManual(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = slackService.SendUpgradeNotification(chain.Name, upgradeInfo)
+	err = slackService.SendUpgradeNotification(context.Background(), chain.Name, upgradeInfo)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,148 @@
+package notifications
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackService_MinTLSVersion(t *testing.T) {
+	t.Setenv("SLACK_WEBHOOK_URL", "https://hooks.slack.com/services/test")
+
+	t.Run("default", func(t *testing.T) {
+		t.Setenv("TLS_MIN_VERSION", "")
+
+		slack, err := NewSlackService(logrus.New())
+		require.NoError(t, err)
+
+		transport, ok := slack.client.Transport.(*http.Transport)
+		require.True(t, ok, "expected *http.Transport")
+		assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		t.Setenv("TLS_MIN_VERSION", "TLS1.3")
+
+		slack, err := NewSlackService(logrus.New())
+		require.NoError(t, err)
+
+		transport, ok := slack.client.Transport.(*http.Transport)
+		require.True(t, ok, "expected *http.Transport")
+		assert.Equal(t, uint16(tls.VersionTLS13), transport.TLSClientConfig.MinVersion)
+	})
+}
+
+func TestSlackService_SendCancellation(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	t.Setenv("SLACK_WEBHOOK_URL", ts.URL)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	t.Run("cancelled mid-flight", func(t *testing.T) {
+		slack, err := NewSlackService(logger)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		err = slack.SendSlackMessage(ctx, &SlackMessage{Text: "test"})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(start), time.Second, "send should return promptly once cancelled")
+	})
+
+	t.Run("per-send timeout", func(t *testing.T) {
+		slack, err := NewSlackService(logger)
+		require.NoError(t, err)
+		slack.SetSendTimeout(50 * time.Millisecond)
+
+		start := time.Now()
+		err = slack.SendSlackMessage(context.Background(), &SlackMessage{Text: "test"})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("timeout from environment", func(t *testing.T) {
+		t.Setenv("SLACK_SEND_TIMEOUT", "2s")
+		slack, err := NewSlackService(logger)
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Second, slack.sendTimeout)
+
+		t.Setenv("SLACK_SEND_TIMEOUT", "soon")
+		slack, err = NewSlackService(logger)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultSendTimeout, slack.sendTimeout)
+	})
+}
+
+func TestSlackService_SendChainStallEvent(t *testing.T) {
+	var received SlackMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	slack, err := NewSlackServiceForWebhook(logger, ts.URL)
+	require.NoError(t, err)
+
+	since := time.Date(2024, 3, 20, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		event  types.ChainStallEvent
+		text   string
+		color  string
+		titles []string
+	}{
+		{
+			event:  types.ChainStallEvent{Type: types.ChainStalled, Chain: "osmosis", Height: 17500000, StalledSince: since, Duration: 12 * time.Minute},
+			text:   "⛔ Osmosis has stopped producing blocks",
+			color:  "danger",
+			titles: []string{"Last Height", "Stuck For", "Stalled Since"},
+		},
+		{
+			event:  types.ChainStallEvent{Type: types.ChainResumed, Chain: "osmosis", Height: 17500001, StalledSince: since, Duration: 40 * time.Minute},
+			text:   "▶️ Osmosis is producing blocks again",
+			color:  "good",
+			titles: []string{"Height", "Stalled For", "Stalled Since"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event.Type, func(t *testing.T) {
+			require.NoError(t, slack.SendChainStallEvent(context.Background(), &tt.event))
+			assert.Equal(t, tt.text, received.Text)
+			require.Len(t, received.Attachments, 1)
+			assert.Equal(t, tt.color, received.Attachments[0].Color)
+			var titles []string
+			for _, field := range received.Attachments[0].Fields {
+				titles = append(titles, field.Title)
+			}
+			assert.Equal(t, tt.titles, titles)
+			assert.Equal(t, strconv.FormatInt(tt.event.Height, 10), received.Attachments[0].Fields[0].Value)
+			assert.Equal(t, since.Format(time.RFC1123), received.Attachments[0].Fields[2].Value)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package notifications
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -35,6 +37,7 @@ func (n *StartupNotifier) NotifyStartup() error {
 	}
 
 	var (
+		ctx       = context.Background()
 		wg        sync.WaitGroup
 		semaphore = make(chan struct{}, 5)
 	)
@@ -51,15 +54,17 @@ func (n *StartupNotifier) NotifyStartup() error {
 				return
 			}
 
-			info, err := n.registry.GetUpgradeInfo(chain, false)
+			info, err := n.registry.GetUpgradeInfo(ctx, chain, false)
+			if errors.Is(err, types.ErrNoUpgrade) {
+				n.logger.Debugf("No upgrade scheduled for %s", chain)
+				return
+			}
 			if err != nil {
 				n.logger.Errorf("Failed to get upgrade info for %s: %v", chain, err)
 				return
 			}
-			if info != nil {
-				n.logger.Infof("Found upgrade info for %s: version=%s height=%d time=%s",
-					chain, info.Version, info.Height, info.Time)
-			}
+			n.logger.Infof("Found upgrade info for %s: version=%s height=%d time=%s",
+				chain, info.Version, info.Height, info.Time)
 		}(chainName)
 	}
 
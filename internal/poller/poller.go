@@ -1,6 +1,8 @@
 package poller
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -14,14 +16,26 @@ type Poller struct {
 	interval time.Duration
 	stop     chan struct{}
 	wg       sync.WaitGroup
+
+	// heights keeps the latest height seen per chain to detect stalls, see checkStall
+	stallMu          sync.Mutex
+	heights          map[string]*chainHeight
+	stallBlocks      int
+	stallMinDuration time.Duration
+	stallNotifier    StallNotifier
+	now              func() time.Time
 }
 
 func New(registry *chain.ChainRegistry, logger *logrus.Logger, interval time.Duration) *Poller {
 	return &Poller{
-		registry: registry,
-		logger:   logger,
-		interval: interval,
-		stop:     make(chan struct{}),
+		registry:         registry,
+		logger:           logger,
+		interval:         interval,
+		stop:             make(chan struct{}),
+		heights:          make(map[string]*chainHeight),
+		stallBlocks:      DefaultStallBlocks,
+		stallMinDuration: DefaultStallMinDuration,
+		now:              time.Now,
 	}
 }
 
@@ -48,6 +62,15 @@ func (p *Poller) Stop() {
 }
 
 func (p *Poller) update() {
+	if p.registry.InMaintenance() {
+		p.logger.Debug("Maintenance mode enabled, skipping poller update cycle")
+		return
+	}
+	if p.registry.IsDegraded() && !p.registry.ProbeSources(context.Background()) {
+		p.logger.Warn("Registry is in degraded mode, skipping poller update cycle")
+		return
+	}
+
 	p.logger.Debug("Starting poller update cycle")
 	chains, err := p.registry.GetMonitoredChains()
 	if err != nil {
@@ -56,33 +79,48 @@ func (p *Poller) update() {
 	}
 
 	p.logger.Debugf("Checking updates for %d chains", len(chains))
+	before := p.registry.ConditionalFetchStats()
 	for _, chainName := range chains {
 		p.logger.Debugf("Checking chain: %s", chainName)
-		if err := p.updateChain(chainName); err != nil {
+		if err := p.updateChain(context.Background(), chainName); err != nil {
+			var notFound *chain.ErrChainNotFound
+			if errors.As(err, &notFound) {
+				p.logger.Warnf("Chain %s is not in the chain-registry, check its chains.yaml entry", chainName)
+				continue
+			}
 			p.logger.Errorf("Failed to update chain %s: %v", chainName, err)
 		}
+		p.checkStall(context.Background(), chainName)
+	}
+	p.forgetChains(chains)
+	if err := p.registry.WriteSnapshot(); err != nil {
+		p.logger.Errorf("Failed to write snapshot: %v", err)
 	}
-	p.logger.Debug("Completed poller update cycle")
+	fetches := p.registry.ConditionalFetchStats().Since(before)
+	p.logger.Debugf("Completed poller update cycle, %d of %d conditional fetches answered with 304 Not Modified",
+		fetches.NotModified, fetches.Sent)
 }
 
-func (p *Poller) updateChain(chainName string) error {
-	chainInfo, err := p.registry.GetChainInfo(chainName, false)
+func (p *Poller) updateChain(ctx context.Context, chainName string) error {
+	chainInfo, err := p.registry.GetChainInfo(ctx, chainName, false)
 	if err != nil {
 		return err
 	}
 	p.logger.Debugf("Chain %s info updated: %+v", chainName, chainInfo)
 
-	upgradeInfo, err := p.registry.GetUpgradeInfo(chainName, true)
+	upgradeInfo, err := p.registry.GetUpgradeInfo(ctx, chainName, true)
+	if errors.Is(err, chain.ErrNoUpgrade) {
+		p.logger.Debugf("No upgrade scheduled for %s", chainName)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	if upgradeInfo != nil {
-		p.logger.Infof("Chain %s upgrade info: Version=%s, Height=%d, Time=%s",
-			chainName,
-			upgradeInfo.Version,
-			upgradeInfo.Height,
-			upgradeInfo.Time.Format(time.RFC3339),
-		)
-	}
+	p.logger.Infof("Chain %s upgrade info: Version=%s, Height=%d, Time=%s",
+		chainName,
+		upgradeInfo.Version,
+		upgradeInfo.Height,
+		upgradeInfo.Time.Format(time.RFC3339),
+	)
 	return nil
 }
@@ -1,6 +1,7 @@
 package poller
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -67,7 +68,7 @@ func TestPollerUpdateCycle(t *testing.T) {
 
 	poller.Stop()
 
-	chainInfo, err := registry.GetChainInfo("testchain", false)
+	chainInfo, err := registry.GetChainInfo(context.Background(), "testchain", false)
 	assert.NoError(t, err)
 	assert.NotNil(t, chainInfo)
 	assert.Equal(t, "testchain", chainInfo.Name)
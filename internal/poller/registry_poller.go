@@ -2,6 +2,7 @@ package poller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -54,6 +55,15 @@ func (p *RegistryPoller) Stop() {
 }
 
 func (p *RegistryPoller) update() {
+	if p.registry.InMaintenance() {
+		p.logger.Debug("Maintenance mode enabled, skipping registry poller update cycle")
+		return
+	}
+	if p.registry.IsDegraded() && !p.registry.ProbeSources(context.Background()) {
+		p.logger.Warn("Registry is in degraded mode, skipping registry poller update cycle")
+		return
+	}
+
 	chains, err := p.registry.GetMonitoredChains()
 	if err != nil {
 		p.logger.Errorf("Failed to get monitored chains: %v", err)
@@ -61,24 +71,38 @@ func (p *RegistryPoller) update() {
 	}
 
 	for _, chainName := range chains {
-		if err := p.updateChain(chainName); err != nil {
+		if err := p.updateChain(context.Background(), chainName); err != nil {
+			var notFound *chain.ErrChainNotFound
+			if errors.As(err, &notFound) {
+				p.logger.Warnf("Chain %s is not in the chain-registry, check its chains.yaml entry", chainName)
+				continue
+			}
 			p.logger.Errorf("Failed to update chain %s: %v", chainName, err)
 		}
 	}
 }
 
-func (p *RegistryPoller) updateChain(chainName string) error {
-	chainInfo, err := p.registry.GetChainInfo(chainName, false)
+func (p *RegistryPoller) updateChain(ctx context.Context, chainName string) error {
+	chainInfo, err := p.registry.GetChainInfo(ctx, chainName, false)
 	if err != nil {
 		return fmt.Errorf("failed to get chain info: %w", err)
 	}
 
-	upgradeInfo, err := p.registry.GetUpgradeInfo(chainName, true)
-	if err != nil {
+	// Ranking the endpoints first lets the upgrade lookup below query the healthiest ones
+	if _, err := p.registry.ProbeEndpoints(ctx, chainName); err != nil {
+		p.logger.Debugf("Failed to probe endpoints of %s: %v", chainName, err)
+	}
+
+	upgradeInfo, err := p.registry.GetUpgradeInfo(ctx, chainName, true)
+	if err != nil && !errors.Is(err, chain.ErrNoUpgrade) {
 		return fmt.Errorf("failed to get upgrade info: %w", err)
 	}
 
 	p.logger.Infof("Updated chain %s: version %s, height %d", chainName, chainInfo.Version, chainInfo.Height)
+	if err != nil {
+		p.logger.Debugf("No upgrade scheduled for %s", chainName)
+		return nil
+	}
 	p.logger.Infof("Updated upgrade info for %s: %s at height %d", chainName, upgradeInfo.Version, upgradeInfo.Height)
 
 	return nil
@@ -1,6 +1,8 @@
 package poller
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,6 +11,7 @@ import (
 	"github.com/0xPuncker/cosmos-watcher/internal/chain"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewRegistryPoller(t *testing.T) {
@@ -82,10 +85,30 @@ func TestUpdateChain(t *testing.T) {
 
 	poller := NewRegistryPoller(registry, logger, 5*time.Minute)
 
-	err := poller.updateChain("chain1")
+	err := poller.updateChain(context.Background(), "chain1")
 	assert.NoError(t, err)
 }
 
+func TestUpdateChain_NoUpgrade(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cosmos/chain-registry/master/chain1/chain.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"name": "chain1", "chain_id": "chain1-1"}`))
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master")
+	registry.SetRetryPolicy(-1, 0)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{chain.ProviderChainRegistry}))
+
+	poller := NewRegistryPoller(registry, logger, 5*time.Minute)
+	assert.NoError(t, poller.updateChain(context.Background(), "chain1"), "a chain without upgrade is no failure")
+}
+
 func TestUpdate(t *testing.T) {
 	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/_list" {
@@ -0,0 +1,146 @@
+package poller
+
+import (
+	"context"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+const (
+	// DefaultStallBlocks is how many of its average block times a chain may go without a new block
+	// before it is reported stalled
+	DefaultStallBlocks = 20
+	// DefaultStallMinDuration is the shortest a chain may go without a new block before it is reported
+	// stalled, so a fast chain sampled once per poller cycle is not reported between two samples
+	DefaultStallMinDuration = 5 * time.Minute
+)
+
+// StallNotifier is told when a chain stops producing blocks and when it resumes
+type StallNotifier interface {
+	NotifyChainStall(event *types.ChainStallEvent)
+}
+
+// chainHeight is the latest height seen for a chain and when the chain was first seen at it
+type chainHeight struct {
+	height  int64
+	since   time.Time
+	stalled bool
+}
+
+// SetStallDetection sets how long a chain's height may stay put before it is reported stalled: blocks
+// times its average block time, or minDuration when that is longer. Zero values keep the defaults of
+// 20 blocks and 5 minutes, negative blocks disables stall detection.
+func (p *Poller) SetStallDetection(blocks int, minDuration time.Duration) {
+	if blocks == 0 {
+		blocks = DefaultStallBlocks
+	}
+	if minDuration <= 0 {
+		minDuration = DefaultStallMinDuration
+	}
+	p.stallMu.Lock()
+	defer p.stallMu.Unlock()
+	p.stallBlocks = blocks
+	p.stallMinDuration = minDuration
+}
+
+// SetStallNotifier sets who is told about stalled and resumed chains
+func (p *Poller) SetStallNotifier(notifier StallNotifier) {
+	p.stallMu.Lock()
+	defer p.stallMu.Unlock()
+	p.stallNotifier = notifier
+}
+
+// checkStall samples the chain's latest height, reporting the chain once when its height has not
+// advanced for longer than the stall threshold and again when it advances. A chain whose nodes do not
+// answer is left as it was; the endpoint alerts cover it.
+func (p *Poller) checkStall(ctx context.Context, chainName string) {
+	p.stallMu.Lock()
+	disabled := p.stallBlocks < 0
+	p.stallMu.Unlock()
+	if disabled {
+		return
+	}
+
+	block, err := p.registry.GetLatestBlock(ctx, chainName)
+	if err != nil {
+		p.logger.Debugf("Latest block of %s not available, skipping stall check: %v", chainName, err)
+		return
+	}
+	blockTime, err := p.registry.AverageBlockTime(ctx, chainName)
+	if err != nil {
+		p.logger.Debugf("Average block time of %s not available, using the minimum stall duration: %v", chainName, err)
+	}
+
+	event := p.observeHeight(chainName, block.Height, blockTime)
+	if event == nil {
+		return
+	}
+	if event.Type == types.ChainStalled {
+		p.logger.Warnf("Chain %s has been stuck at height %d for %s", chainName, event.Height, event.Duration.Round(time.Second))
+	} else {
+		p.logger.Infof("Chain %s resumed at height %d after %s", chainName, event.Height, event.Duration.Round(time.Second))
+	}
+
+	p.stallMu.Lock()
+	notifier := p.stallNotifier
+	p.stallMu.Unlock()
+	if notifier != nil {
+		notifier.NotifyChainStall(event)
+	}
+}
+
+// observeHeight records height as the chain's latest and returns the stall event it triggers, if any.
+// A height below the one recorded comes from a lagging node and is ignored.
+func (p *Poller) observeHeight(chainName string, height int64, blockTime time.Duration) *types.ChainStallEvent {
+	now := p.now()
+	p.stallMu.Lock()
+	defer p.stallMu.Unlock()
+
+	state, ok := p.heights[chainName]
+	switch {
+	case !ok || height > state.height:
+		p.heights[chainName] = &chainHeight{height: height, since: now}
+		if ok && state.stalled {
+			return &types.ChainStallEvent{
+				Type:         types.ChainResumed,
+				Chain:        chainName,
+				Height:       height,
+				StalledSince: state.since,
+				Duration:     now.Sub(state.since),
+			}
+		}
+		return nil
+	case height < state.height || state.stalled:
+		return nil
+	}
+
+	threshold := max(time.Duration(p.stallBlocks)*blockTime, p.stallMinDuration)
+	stuck := now.Sub(state.since)
+	if stuck <= threshold {
+		return nil
+	}
+	state.stalled = true
+	return &types.ChainStallEvent{
+		Type:         types.ChainStalled,
+		Chain:        chainName,
+		Height:       height,
+		StalledSince: state.since,
+		Duration:     stuck,
+	}
+}
+
+// forgetChains drops the heights of chains that are no longer monitored
+func (p *Poller) forgetChains(monitored []string) {
+	keep := make(map[string]bool, len(monitored))
+	for _, chainName := range monitored {
+		keep[chainName] = true
+	}
+	p.stallMu.Lock()
+	defer p.stallMu.Unlock()
+	for chainName := range p.heights {
+		if !keep[chainName] {
+			delete(p.heights, chainName)
+		}
+	}
+}
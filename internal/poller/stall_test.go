@@ -0,0 +1,190 @@
+package poller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/patrickmn/go-cache"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingStallNotifier struct {
+	mu     sync.Mutex
+	events []types.ChainStallEvent
+}
+
+func (n *recordingStallNotifier) NotifyChainStall(event *types.ChainStallEvent) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, *event)
+}
+
+func (n *recordingStallNotifier) Events() []types.ChainStallEvent {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]types.ChainStallEvent(nil), n.events...)
+}
+
+func TestPoller_StallDetection(t *testing.T) {
+	const blockTime = 30 * time.Second
+	genesis := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+	blockAt := func(height int64) time.Time { return genesis.Add(time.Duration(height) * blockTime) }
+
+	// The node reports height until it is frozen, with blocks 30s apart
+	var height atomic.Int64
+	height.Store(5000)
+	rpcNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status":
+			h := height.Load()
+			fmt.Fprintf(w, `{"result":{"sync_info":{"latest_block_height":"%d","latest_block_time":%q}}}`,
+				h, blockAt(h).Format(time.RFC3339))
+		case "/block":
+			h, err := strconv.ParseInt(r.URL.Query().Get("height"), 10, 64)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"result":{"block":{"header":{"height":"%d","time":%q}}}}`, h, blockAt(h).Format(time.RFC3339))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer rpcNode.Close()
+
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cosmos/chain-registry/master/testchain/chain.json" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(chain.ChainInfo{
+			Name:    "testchain",
+			ChainID: "testchain-1",
+			APIs:    chain.APIs{RPC: []chain.Endpoint{{Address: rpcNode.URL}}},
+		})
+	}))
+	defer registryServer.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	registryCache := cache.New(time.Minute, 0)
+	registry := chain.NewChainRegistry(logger, registryServer.URL, "/cosmos/chain-registry/master",
+		chain.WithCacheBackend(registryCache))
+	registry.SetRetryPolicy(-1, 0)
+	registry.SetDegradedThreshold(-1)
+	require.NoError(t, registry.SetUpgradeProviderOrder([]string{chain.ProviderChainRegistry}))
+	registry.SetMonitoredChains([]string{"testchain"})
+
+	notifier := &recordingStallNotifier{}
+	p := New(registry, logger, time.Minute)
+	p.SetStallNotifier(notifier)
+	now := genesis
+	p.now = func() time.Time { return now }
+	poll := func(after time.Duration) {
+		now = now.Add(after)
+		// Heights are cached for 30s, the next cycle must see the node's current one
+		registryCache.Flush()
+		p.update()
+	}
+
+	poll(0)
+	height.Add(10)
+	poll(5 * time.Minute)
+	assert.Empty(t, notifier.Events(), "an advancing chain is not stalled")
+
+	// 20 blocks of 30s are 10m, longer than the 5m minimum
+	poll(6 * time.Minute)
+	assert.Empty(t, notifier.Events())
+	poll(5 * time.Minute)
+	events := notifier.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, types.ChainStallEvent{
+		Type:         types.ChainStalled,
+		Chain:        "testchain",
+		Height:       5010,
+		StalledSince: genesis.Add(5 * time.Minute),
+		Duration:     11 * time.Minute,
+	}, events[0])
+
+	poll(10 * time.Minute)
+	assert.Len(t, notifier.Events(), 1, "a stall is reported once")
+
+	height.Add(1)
+	poll(time.Minute)
+	events = notifier.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, types.ChainStallEvent{
+		Type:         types.ChainResumed,
+		Chain:        "testchain",
+		Height:       5011,
+		StalledSince: genesis.Add(5 * time.Minute),
+		Duration:     22 * time.Minute,
+	}, events[1])
+
+	// The resume cleared the stall, a new one is reported again
+	poll(11 * time.Minute)
+	events = notifier.Events()
+	require.Len(t, events, 3)
+	assert.Equal(t, types.ChainStalled, events[2].Type)
+	assert.Equal(t, int64(5011), events[2].Height)
+}
+
+func TestPoller_ObserveHeight(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	p := New(chain.NewChainRegistry(logger, "https://example.com", "/test"), logger, time.Minute)
+	start := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+	now := start
+	p.now = func() time.Time { return now }
+
+	t.Run("minimum duration without a block time", func(t *testing.T) {
+		now = start
+		assert.Nil(t, p.observeHeight("unknown", 100, 0))
+		now = start.Add(5 * time.Minute)
+		assert.Nil(t, p.observeHeight("unknown", 100, 0), "stuck for exactly the threshold")
+		now = start.Add(5*time.Minute + time.Second)
+		event := p.observeHeight("unknown", 100, 0)
+		require.NotNil(t, event)
+		assert.Equal(t, types.ChainStalled, event.Type)
+	})
+
+	t.Run("lagging node", func(t *testing.T) {
+		now = start
+		assert.Nil(t, p.observeHeight("lagging", 100, time.Second))
+		now = start.Add(time.Minute)
+		assert.Nil(t, p.observeHeight("lagging", 90, time.Second))
+		now = start.Add(6 * time.Minute)
+		event := p.observeHeight("lagging", 100, time.Second)
+		require.NotNil(t, event, "a lower height does not count as progress")
+		assert.Equal(t, start, event.StalledSince)
+	})
+
+	t.Run("configured threshold", func(t *testing.T) {
+		p.SetStallDetection(4, time.Minute)
+		defer p.SetStallDetection(0, 0)
+		now = start
+		assert.Nil(t, p.observeHeight("configured", 100, 30*time.Second))
+		now = start.Add(2*time.Minute + time.Second)
+		assert.NotNil(t, p.observeHeight("configured", 100, 30*time.Second))
+	})
+
+	t.Run("forgotten chains", func(t *testing.T) {
+		p.forgetChains([]string{"lagging"})
+		p.stallMu.Lock()
+		defer p.stallMu.Unlock()
+		assert.Len(t, p.heights, 1)
+		assert.Contains(t, p.heights, "lagging")
+	})
+}
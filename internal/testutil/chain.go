@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"context"
 	"github.com/0xPuncker/cosmos-watcher/internal/chain"
 	"github.com/0xPuncker/cosmos-watcher/pkg/types"
 	"github.com/sirupsen/logrus"
@@ -11,6 +12,6 @@ func GetTestUpgradeInfo() *types.UpgradeInfo {
 	logger.SetOutput(nil)
 
 	registry := chain.NewChainRegistry(logger, "https://raw.githubusercontent.com", "/cosmos/chain-registry/master")
-	info, _ := registry.GetUpgradeInfo("neutron", false)
+	info, _ := registry.GetUpgradeInfo(context.Background(), "neutron", false)
 	return info
 }
@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -129,7 +130,7 @@ func testChainUpgrade(t *testing.T, logger *logrus.Logger, chainConfig *config.C
 		os.Getenv("CHAIN_REGISTRY_BASE_URL"),
 	)
 
-	chainUpgradeInfo, err := registry.GetUpgradeInfo(chainConfig.Name, true)
+	chainUpgradeInfo, err := registry.GetUpgradeInfo(context.Background(), chainConfig.Name, true)
 	if err != nil {
 
 		if strings.Contains(err.Error(), "proposals endpoint returned") ||
@@ -161,7 +162,7 @@ func testChainUpgrade(t *testing.T, logger *logrus.Logger, chainConfig *config.C
 		t.Fatalf("Failed to create Slack service: %v", err)
 	}
 
-	err = slackService.SendUpgradeNotification(chainConfig.Name, chainUpgradeInfo)
+	err = slackService.SendUpgradeNotification(context.Background(), chainConfig.Name, chainUpgradeInfo)
 	if err != nil {
 		t.Fatalf("Failed to send upgrade notification: %v", err)
 	}
@@ -0,0 +1,439 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+// FakeRegistry is an in-memory chain registry for handler and job tests. It serves the chains,
+// upgrades and blocks it is given and never reaches the network. Chains missing from Chains are
+// reported as not found.
+type FakeRegistry struct {
+	mu sync.RWMutex
+
+	Chains       map[string]*chain.ChainInfo
+	Upgrades     map[string]*types.UpgradeInfo
+	Blocks       map[string]chain.LatestBlock
+	NodeVersions map[string]string
+	VoteTallies  map[string]*types.VoteTally
+	AssetLists   map[string]*chain.AssetList
+	Versions     map[string]*chain.VersionHistory
+	Binaries     map[string]map[string]chain.UpgradeBinary
+	Endpoints    map[string]*chain.EndpointReport
+	Discovered   chain.DiscoveredChains
+	Errors       []chain.ChainError
+	// ChainErrors holds the error GetChainInfo returns for a chain instead of its info
+	ChainErrors map[string]error
+	// FailedUpgrades marks chains whose last upgrade fetch failed
+	FailedUpgrades map[string]bool
+
+	// LastFetch is what LastChainInfoFetch returns
+	LastFetch time.Time
+
+	// PendingChains is what PendingPolkachuChains returns, or PendingChainsErr when set
+	PendingChains    []string
+	PendingChainsErr error
+
+	// upgradeLookups counts the GetUpgradeInfo calls per chain
+	upgradeLookups map[string]int
+
+	monitored   []string
+	auto        []string
+	configs     map[string]types.ChainConfig
+	degraded    chain.DegradedStatus
+	maintenance chain.MaintenanceStatus
+}
+
+// NewFakeRegistry returns a FakeRegistry serving the given chains, all of them monitored
+func NewFakeRegistry(chains ...*chain.ChainInfo) *FakeRegistry {
+	f := &FakeRegistry{
+		Chains:         make(map[string]*chain.ChainInfo),
+		Upgrades:       make(map[string]*types.UpgradeInfo),
+		Blocks:         make(map[string]chain.LatestBlock),
+		NodeVersions:   make(map[string]string),
+		VoteTallies:    make(map[string]*types.VoteTally),
+		AssetLists:     make(map[string]*chain.AssetList),
+		Versions:       make(map[string]*chain.VersionHistory),
+		Binaries:       make(map[string]map[string]chain.UpgradeBinary),
+		Endpoints:      make(map[string]*chain.EndpointReport),
+		ChainErrors:    make(map[string]error),
+		FailedUpgrades: make(map[string]bool),
+		configs:        make(map[string]types.ChainConfig),
+		upgradeLookups: make(map[string]int),
+	}
+	for _, info := range chains {
+		f.Chains[info.Name] = info
+		f.monitored = append(f.monitored, info.Name)
+	}
+	return f
+}
+
+func notFound(chainName string) error {
+	return &chain.ErrChainNotFound{Chain: chainName}
+}
+
+func (f *FakeRegistry) GetChainInfo(ctx context.Context, chainName string, forceRefresh bool) (*chain.ChainInfo, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if err := f.ChainErrors[chainName]; err != nil {
+		return nil, err
+	}
+	info, ok := f.Chains[chainName]
+	if !ok {
+		return nil, notFound(chainName)
+	}
+	return info, nil
+}
+
+// GetUpgradeInfo returns the chain's upgrade, or chain.ErrNoUpgrade when none is scheduled
+func (f *FakeRegistry) GetUpgradeInfo(ctx context.Context, chainName string, forceRefresh bool) (*types.UpgradeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.upgradeLookups[chainName]++
+	if _, ok := f.Chains[chainName]; !ok {
+		return nil, notFound(chainName)
+	}
+	upgrade, ok := f.Upgrades[chainName]
+	if !ok || upgrade == nil {
+		return nil, fmt.Errorf("chain %s: %w", chainName, chain.ErrNoUpgrade)
+	}
+	return upgrade, nil
+}
+
+// UpgradeLookups returns how many times the upgrade of the chain was asked for
+func (f *FakeRegistry) UpgradeLookups(chainName string) int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.upgradeLookups[chainName]
+}
+
+// GetUpgrades returns the upgrades of the monitored chains on the network, with their status
+func (f *FakeRegistry) GetUpgrades(ctx context.Context, network string) ([]chain.Upgrade, error) {
+	chains, _ := f.GetMonitoredChains()
+	var upgrades []chain.Upgrade
+	for _, chainName := range chains {
+		info, err := f.GetChainInfo(ctx, chainName, false)
+		if err != nil || info.Network != network {
+			continue
+		}
+		upgrade, err := f.GetUpgradeInfo(ctx, chainName, false)
+		if err != nil {
+			continue
+		}
+		withStatus := *upgrade
+		withStatus.Status = f.UpgradeStatus(chainName, upgrade)
+		upgrades = append(upgrades, &withStatus)
+	}
+	return upgrades, nil
+}
+
+// GetMonitoredChains returns the monitored chains followed by the auto-discovered ones
+func (f *FakeRegistry) GetMonitoredChains() ([]string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	chains := append([]string(nil), f.monitored...)
+	for _, name := range f.auto {
+		if !slices.Contains(f.monitored, name) {
+			chains = append(chains, name)
+		}
+	}
+	return chains, nil
+}
+
+func (f *FakeRegistry) SetMonitoredChains(chains []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.monitored = chains
+}
+
+// AddMonitoredChain monitors the chain of cfg, reporting false when it is already monitored
+func (f *FakeRegistry) AddMonitoredChain(cfg types.ChainConfig) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if slices.Contains(f.monitored, cfg.Name) {
+		return false, nil
+	}
+	f.monitored = append(slices.Clone(f.monitored), cfg.Name)
+	f.configs[cfg.Name] = cfg
+	return true, nil
+}
+
+// RegistryPaths returns the mainnet and testnets directories of the chain
+func (f *FakeRegistry) RegistryPaths(chainName string) []string {
+	return []string{chainName, "testnets/" + chainName}
+}
+
+func (f *FakeRegistry) PendingPolkachuChains(ctx context.Context) ([]string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.PendingChainsErr != nil {
+		return nil, f.PendingChainsErr
+	}
+	return append([]string(nil), f.PendingChains...), nil
+}
+
+func (f *FakeRegistry) SetAutoDiscoveredChains(chains []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auto = append([]string(nil), chains...)
+}
+
+func (f *FakeRegistry) AutoDiscoveredChains() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]string(nil), f.auto...)
+}
+
+func (f *FakeRegistry) ChainSource(chainName string) string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	switch {
+	case slices.Contains(f.monitored, chainName):
+		return chain.ChainSourceConfig
+	case slices.Contains(f.auto, chainName):
+		return chain.ChainSourceAuto
+	}
+	return ""
+}
+
+func (f *FakeRegistry) GetChainConfig(chainName string) (types.ChainConfig, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	config, ok := f.configs[chainName]
+	return config, ok
+}
+
+func (f *FakeRegistry) SetChainConfigs(configs []types.ChainConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.configs = make(map[string]types.ChainConfig, len(configs))
+	for _, config := range configs {
+		f.configs[config.Name] = config
+	}
+	return nil
+}
+
+func (f *FakeRegistry) ChainExists(ctx context.Context, chainName string) bool {
+	_, err := f.GetChainInfo(ctx, chainName, false)
+	return err == nil
+}
+
+// FilterExistingChains returns the chains GetChainInfo serves, and those it fails for with another
+// error than chain.ErrChainNotFound as failures
+func (f *FakeRegistry) FilterExistingChains(ctx context.Context, chains []string) ([]string, []chain.ChainCheckFailure) {
+	var (
+		existing []string
+		failures []chain.ChainCheckFailure
+	)
+	for _, name := range chains {
+		_, err := f.GetChainInfo(ctx, name, false)
+		var missing *chain.ErrChainNotFound
+		switch {
+		case err == nil:
+			existing = append(existing, name)
+		case !errors.As(err, &missing):
+			failures = append(failures, chain.ChainCheckFailure{Chain: name, Err: err})
+		}
+	}
+	return existing, failures
+}
+
+func (f *FakeRegistry) GetLatestBlock(ctx context.Context, chainName string) (chain.LatestBlock, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	block, ok := f.Blocks[chainName]
+	if !ok {
+		return chain.LatestBlock{}, chain.ErrHeightUnavailable
+	}
+	return block, nil
+}
+
+func (f *FakeRegistry) GetCurrentHeight(ctx context.Context, chainName string) (int64, error) {
+	block, err := f.GetLatestBlock(ctx, chainName)
+	return block.Height, err
+}
+
+func (f *FakeRegistry) GetNodeVersion(ctx context.Context, chainName string) (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	version, ok := f.NodeVersions[chainName]
+	if !ok {
+		return "", chain.ErrNodeInfoUnavailable
+	}
+	return version, nil
+}
+
+func (f *FakeRegistry) GetVoteTally(ctx context.Context, chainName, proposal string) (*types.VoteTally, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	tally, ok := f.VoteTallies[chainName]
+	if !ok {
+		return nil, chain.ErrTallyUnavailable
+	}
+	return tally, nil
+}
+
+func (f *FakeRegistry) GetAssetList(ctx context.Context, chainName string) (*chain.AssetList, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	assetList, ok := f.AssetLists[chainName]
+	if !ok {
+		return nil, chain.ErrAssetListNotFound
+	}
+	return assetList, nil
+}
+
+func (f *FakeRegistry) GetVersions(ctx context.Context, chainName string) (*chain.VersionHistory, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	history, ok := f.Versions[chainName]
+	if !ok {
+		return nil, chain.ErrVersionsNotFound
+	}
+	return history, nil
+}
+
+// GetUpgradeBinaries returns the chain's Binaries, an empty map for chains with an upgrade but none
+func (f *FakeRegistry) GetUpgradeBinaries(ctx context.Context, chainName string) (map[string]chain.UpgradeBinary, error) {
+	if _, err := f.GetUpgradeInfo(ctx, chainName, false); err != nil {
+		return nil, err
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if binaries, ok := f.Binaries[chainName]; ok {
+		return binaries, nil
+	}
+	return map[string]chain.UpgradeBinary{}, nil
+}
+
+func (f *FakeRegistry) GetEndpointReport(ctx context.Context, chainName string) (*chain.EndpointReport, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	report, ok := f.Endpoints[chainName]
+	if !ok {
+		return nil, notFound(chainName)
+	}
+	return report, nil
+}
+
+// ProbeEndpoints returns the health of the chain's endpoint report without probing anything
+func (f *FakeRegistry) ProbeEndpoints(ctx context.Context, chainName string) (chain.EndpointHealth, error) {
+	report, err := f.GetEndpointReport(ctx, chainName)
+	if err != nil {
+		return chain.EndpointHealth{}, err
+	}
+	return report.EndpointHealth, nil
+}
+
+func (f *FakeRegistry) LastEndpointHealth(chainName string) (chain.EndpointHealth, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	report, ok := f.Endpoints[chainName]
+	if !ok {
+		return chain.EndpointHealth{}, false
+	}
+	return report.EndpointHealth, true
+}
+
+func (f *FakeRegistry) DiscoverChains(ctx context.Context) (*chain.DiscoveredChains, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	discovered := f.Discovered
+	return &discovered, nil
+}
+
+// RefreshAll reports every monitored chain refreshed, except those with an entry in ChainErrors.
+// Like the registry it refuses to refresh while degraded.
+func (f *FakeRegistry) RefreshAll(ctx context.Context) (chain.RefreshReport, error) {
+	report := chain.RefreshReport{StartedAt: time.Now()}
+	if f.IsDegraded() {
+		return report, chain.ErrDegraded
+	}
+	chains, _ := f.GetMonitoredChains()
+	for _, chainName := range chains {
+		refresh := chain.ChainRefresh{Chain: chainName, OK: true}
+		if _, err := f.GetChainInfo(ctx, chainName, true); err != nil {
+			refresh.OK, refresh.Error = false, err.Error()
+			report.Failed++
+		} else {
+			report.Refreshed++
+		}
+		report.Chains = append(report.Chains, refresh)
+	}
+	return report, ctx.Err()
+}
+
+// UpgradeStatus is the upgrade's status at the chain's latest block, as the registry computes it
+func (f *FakeRegistry) UpgradeStatus(chainName string, upgrade *types.UpgradeInfo) string {
+	f.mu.RLock()
+	height := f.Blocks[chainName].Height
+	f.mu.RUnlock()
+	return upgrade.StatusAt(time.Now(), height, 0)
+}
+
+func (f *FakeRegistry) UpgradeFetchFailed(chainName string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.FailedUpgrades[chainName]
+}
+
+func (f *FakeRegistry) LastErrors() []chain.ChainError {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]chain.ChainError(nil), f.Errors...)
+}
+
+func (f *FakeRegistry) DegradedStatus() chain.DegradedStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.degraded
+}
+
+func (f *FakeRegistry) LastChainInfoFetch() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.LastFetch
+}
+
+func (f *FakeRegistry) IsDegraded() bool {
+	return f.DegradedStatus().Degraded
+}
+
+func (f *FakeRegistry) SetDegraded(enabled bool, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.degraded = chain.DegradedStatus{Degraded: enabled, Manual: enabled}
+	if enabled {
+		f.degraded.Reason, f.degraded.Since = reason, time.Now()
+	}
+}
+
+func (f *FakeRegistry) MaintenanceStatus() chain.MaintenanceStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.maintenance
+}
+
+func (f *FakeRegistry) InMaintenance() bool {
+	return f.MaintenanceStatus().Enabled
+}
+
+func (f *FakeRegistry) SetMaintenance(enabled bool, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.maintenance = chain.MaintenanceStatus{Enabled: enabled}
+	if enabled {
+		f.maintenance.Reason, f.maintenance.Since = reason, time.Now()
+	}
+}
+
+func (f *FakeRegistry) Links() *chain.LinkBuilder {
+	return chain.NewLinkBuilder(nil)
+}
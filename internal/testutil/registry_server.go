@@ -0,0 +1,89 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/internal/chain"
+)
+
+// RegistryPath is the path NewRegistryServer serves the chain-registry under, to pass to
+// chain.NewChainRegistry with the server's URL
+const RegistryPath = "/cosmos/chain-registry/master"
+
+// registryServer is what NewRegistryServer serves
+type registryServer struct {
+	upgrade   func(chainName string) map[string]interface{}
+	chainInfo func(info *chain.ChainInfo)
+}
+
+// RegistryServerOption adjusts what NewRegistryServer serves
+type RegistryServerOption func(*registryServer)
+
+// WithUpgrade serves upgrade(chainName) as the upgrades.json of each chain instead of StubUpgrade
+func WithUpgrade(upgrade func(chainName string) map[string]interface{}) RegistryServerOption {
+	return func(s *registryServer) {
+		s.upgrade = upgrade
+	}
+}
+
+// WithChainInfo lets edit adjust the chain.json of each chain before it is served
+func WithChainInfo(edit func(info *chain.ChainInfo)) RegistryServerOption {
+	return func(s *registryServer) {
+		s.chainInfo = edit
+	}
+}
+
+// StubUpgrade is the upgrades.json NewRegistryServer serves by default: v2 at height 1000, a day out
+func StubUpgrade(chainName string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":   "v2",
+		"height": 1000,
+		"time":   time.Now().Add(24 * time.Hour),
+	}
+}
+
+// NewRegistryServer starts a chain-registry for tests of the real chain.ChainRegistry. Every chain
+// exists: its chain.json names it, with the chain ID <chain>-1, and its upgrades.json is StubUpgrade.
+// Chains named *testnet are only found in the testnets directory, the others only at the root. The
+// server is closed when the test ends.
+func NewRegistryServer(t testing.TB, opts ...RegistryServerOption) *httptest.Server {
+	t.Helper()
+	s := &registryServer{upgrade: StubUpgrade}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, ok := strings.CutPrefix(r.URL.Path, RegistryPath+"/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		path, testnet := strings.CutPrefix(path, "testnets/")
+		name, file, ok := strings.Cut(path, "/")
+		if !ok || strings.Contains(file, "/") || testnet != strings.HasSuffix(name, "testnet") {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch file {
+		case "chain.json":
+			info := chain.ChainInfo{Name: name, ChainID: name + "-1"}
+			if s.chainInfo != nil {
+				s.chainInfo(&info)
+			}
+			json.NewEncoder(w).Encode(info)
+		case "upgrades.json":
+			json.NewEncoder(w).Encode(s.upgrade(name))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
@@ -0,0 +1,101 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+)
+
+const (
+	icsTimeFormat = "20060102T150405Z"
+	// icsLineLimit is the maximum line length in octets before a line must be folded (RFC 5545 3.1)
+	icsLineLimit = 75
+)
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// GenerateICS renders upgrades as an iCalendar feed with a one-hour event per upgrade. Event UIDs are
+// derived from the chain, plan name and height, so calendar clients update events on refetch instead
+// of duplicating them.
+func GenerateICS(upgrades []*types.UpgradeInfo) string {
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//cosmos-watcher//upgrades//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+
+	stamp := time.Now().UTC().Format(icsTimeFormat)
+	for _, upgrade := range upgrades {
+		if upgrade == nil || upgrade.Time.IsZero() {
+			continue
+		}
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+eventUID(upgrade))
+		writeICSLine(&b, "DTSTAMP:"+stamp)
+		writeICSLine(&b, "DTSTART:"+upgrade.Time.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, "DURATION:PT1H")
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(fmt.Sprintf("%s upgrade %s", upgrade.ChainName, upgrade.Version)))
+		writeICSLine(&b, "DESCRIPTION:"+escapeICSText(eventDescription(upgrade)))
+		if upgrade.ProposalLink != "" {
+			writeICSLine(&b, "URL:"+upgrade.ProposalLink)
+		}
+		writeICSLine(&b, "END:VEVENT")
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+func eventUID(upgrade *types.UpgradeInfo) string {
+	name := upgrade.Name
+	if name == "" {
+		name = upgrade.Version
+	}
+	uid := fmt.Sprintf("%s-%s-%d", upgrade.ChainName, name, upgrade.Height)
+	return strings.ToLower(strings.Join(strings.Fields(uid), "-")) + "@cosmos-watcher"
+}
+
+func eventDescription(upgrade *types.UpgradeInfo) string {
+	lines := []string{
+		fmt.Sprintf("Chain: %s", upgrade.ChainName),
+		fmt.Sprintf("Version: %s", upgrade.Version),
+		fmt.Sprintf("Height: %d", upgrade.Height),
+	}
+	if upgrade.Network != "" {
+		lines = append(lines, fmt.Sprintf("Network: %s", upgrade.Network))
+	}
+	if upgrade.ProposalLink != "" {
+		lines = append(lines, fmt.Sprintf("Proposal: %s", upgrade.ProposalLink))
+	}
+	if upgrade.Guide != "" {
+		lines = append(lines, fmt.Sprintf("Guide: %s", upgrade.Guide))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func escapeICSText(text string) string {
+	return icsEscaper.Replace(text)
+}
+
+// writeICSLine writes a content line terminated by CRLF, folding it at icsLineLimit octets without
+// splitting multi-byte characters
+func writeICSLine(b *strings.Builder, line string) {
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards their length
+		limit = icsLineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,235 @@
+package calendar
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateICS(t *testing.T) {
+	upgradeTime := time.Date(2026, 3, 1, 15, 0, 0, 0, time.UTC)
+	ics := GenerateICS([]*types.UpgradeInfo{
+		{
+			ChainName:    "cosmoshub",
+			Name:         "v25",
+			Version:      "v25.0.0",
+			Height:       1000000,
+			Time:         upgradeTime,
+			ProposalLink: "https://www.mintscan.io/cosmos/proposals/924",
+			Guide:        "Stop the node; then swap binaries, and restart",
+		},
+		{ChainName: "undated", Version: "v1"},
+	})
+
+	lines := strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n")
+	assert.Equal(t, "BEGIN:VCALENDAR", lines[0])
+	assert.Equal(t, "END:VCALENDAR", lines[len(lines)-1])
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), icsLineLimit, "line %q is not folded", line)
+	}
+
+	// Unfold continuation lines before looking at properties
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	assert.Equal(t, 1, strings.Count(unfolded, "BEGIN:VEVENT"), "upgrades without a time are skipped")
+	assert.Contains(t, unfolded, "UID:cosmoshub-v25-1000000@cosmos-watcher\r\n")
+	assert.Contains(t, unfolded, "DTSTART:20260301T150000Z\r\n")
+	assert.Contains(t, unfolded, "DURATION:PT1H\r\n")
+	assert.Contains(t, unfolded, "SUMMARY:cosmoshub upgrade v25.0.0\r\n")
+	assert.Contains(t, unfolded, `Guide: Stop the node\; then swap binaries\, and restart`)
+	assert.Contains(t, unfolded, `Height: 1000000\nProposal: https://www.mintscan.io/cosmos/proposals/924`)
+}
+
+func TestWriteICSLine_FoldsMultiByteSafely(t *testing.T) {
+	var b strings.Builder
+	writeICSLine(&b, "SUMMARY:"+strings.Repeat("ã", 80))
+
+	folded := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	require.Greater(t, len(folded), 1)
+	for i, line := range folded {
+		assert.LessOrEqual(t, len(line), icsLineLimit)
+		assert.True(t, strings.ToValidUTF8(line, "") == line, "line %d splits a character", i)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "))
+		}
+	}
+	assert.Equal(t, "SUMMARY:"+strings.Repeat("ã", 80), strings.ReplaceAll(strings.TrimSuffix(b.String(), "\r\n"), "\r\n ", ""))
+}
+
+func TestGenerateICS_Parses(t *testing.T) {
+	upgrades := []*types.UpgradeInfo{
+		{
+			ChainName:    "cosmoshub",
+			Network:      "mainnet",
+			Name:         "v25",
+			Version:      "v25.0.0",
+			Height:       1000000,
+			Time:         time.Date(2026, 3, 1, 15, 0, 0, 0, time.FixedZone("CET", 3600)),
+			ProposalLink: "https://www.mintscan.io/cosmos/proposals/924",
+			Guide:        `Halt at 1000000; back up C:\data, then "swap" the binary — ` + strings.Repeat("ação ", 20),
+		},
+		{ChainName: "osmosis", Name: "v26", Version: "v26.0.0", Height: 2000000, Time: time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)},
+	}
+
+	calendar, err := parseICS(GenerateICS(upgrades))
+	require.NoError(t, err)
+	assert.Equal(t, "VCALENDAR", calendar.name)
+	assert.Equal(t, "2.0", calendar.properties["VERSION"])
+	require.Len(t, calendar.components, 2)
+
+	event := calendar.components[0]
+	assert.Equal(t, "VEVENT", event.name)
+	assert.Equal(t, "cosmoshub-v25-1000000@cosmos-watcher", event.properties["UID"])
+	start, err := time.Parse(icsTimeFormat, event.properties["DTSTART"])
+	require.NoError(t, err)
+	assert.True(t, upgrades[0].Time.Equal(start), "DTSTART is the upgrade time in UTC")
+	assert.Equal(t, "PT1H", event.properties["DURATION"])
+	assert.Equal(t, "cosmoshub upgrade v25.0.0", event.properties["SUMMARY"])
+	assert.Equal(t, strings.Join([]string{
+		"Chain: cosmoshub",
+		"Version: v25.0.0",
+		"Height: 1000000",
+		"Network: mainnet",
+		"Proposal: https://www.mintscan.io/cosmos/proposals/924",
+		"Guide: " + upgrades[0].Guide,
+	}, "\n"), event.properties["DESCRIPTION"])
+
+	assert.Equal(t, "osmosis-v26-2000000@cosmos-watcher", calendar.components[1].properties["UID"])
+	again, err := parseICS(GenerateICS(upgrades[:1]))
+	require.NoError(t, err)
+	assert.Equal(t, event.properties["UID"], again.components[0].properties["UID"], "UIDs are stable across renders")
+}
+
+// icsComponent is a parsed iCalendar component with its properties, values unescaped
+type icsComponent struct {
+	name       string
+	properties map[string]string
+	components []*icsComponent
+}
+
+var icsNamePattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// icsTextProperties are the properties whose values are TEXT, where commas and semicolons must be escaped
+var icsTextProperties = map[string]bool{"SUMMARY": true, "DESCRIPTION": true}
+
+// icsRequired lists the properties RFC 5545 requires of each component
+var icsRequired = map[string][]string{
+	"VCALENDAR": {"VERSION", "PRODID"},
+	"VEVENT":    {"UID", "DTSTAMP", "DTSTART"},
+}
+
+// parseICS is a strict parser of the subset of RFC 5545 the feed uses: CRLF line endings, lines of at
+// most 75 octets folded with a leading space, nested BEGIN/END components, required properties and
+// TEXT escaping
+func parseICS(ics string) (*icsComponent, error) {
+	if !strings.HasSuffix(ics, "\r\n") {
+		return nil, fmt.Errorf("feed does not end with CRLF")
+	}
+	var lines []string
+	for i, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		if strings.Contains(line, "\n") || strings.Contains(line, "\r") {
+			return nil, fmt.Errorf("line %d has a bare line break", i+1)
+		}
+		if len(line) > icsLineLimit {
+			return nil, fmt.Errorf("line %d is %d octets long", i+1, len(line))
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if len(lines) == 0 {
+				return nil, fmt.Errorf("line %d continues nothing", i+1)
+			}
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var (
+		root  *icsComponent
+		stack []*icsComponent
+	)
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("content line %q has no value", line)
+		}
+		name, _, _ = strings.Cut(name, ";")
+		if !icsNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid property name %q", name)
+		}
+
+		switch name {
+		case "BEGIN":
+			component := &icsComponent{name: value, properties: make(map[string]string)}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("more than one top-level component")
+				}
+				root = component
+			} else {
+				parent := stack[len(stack)-1]
+				parent.components = append(parent.components, component)
+			}
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].name != value {
+				return nil, fmt.Errorf("END:%s does not close the open component", value)
+			}
+			component := stack[len(stack)-1]
+			for _, required := range icsRequired[component.name] {
+				if _, ok := component.properties[required]; !ok {
+					return nil, fmt.Errorf("%s lacks %s", component.name, required)
+				}
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("property %s outside of a component", name)
+			}
+			if icsTextProperties[name] {
+				unescaped, err := unescapeICSText(value)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
+				}
+				value = unescaped
+			}
+			stack[len(stack)-1].properties[name] = value
+		}
+	}
+	if root == nil || len(stack) != 0 {
+		return nil, fmt.Errorf("unterminated calendar")
+	}
+	return root, nil
+}
+
+// unescapeICSText decodes a TEXT value and rejects the commas and semicolons that would split it into
+// several values
+func unescapeICSText(value string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case ',', ';':
+			return "", fmt.Errorf("unescaped %q in %q", c, value)
+		case '\\':
+			if i+1 == len(value) {
+				return "", fmt.Errorf("dangling escape in %q", value)
+			}
+			i++
+			switch value[i] {
+			case '\\', ';', ',':
+				b.WriteByte(value[i])
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				return "", fmt.Errorf("invalid escape \\%c in %q", value[i], value)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
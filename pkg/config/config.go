@@ -13,6 +13,7 @@ type Chain struct {
 	Name        string `yaml:"name"`
 	DisplayName string `yaml:"display_name"`
 	Network     string `yaml:"network"`
+	Mainnet     string `yaml:"mainnet,omitempty"`
 }
 
 type Config struct {
@@ -82,6 +83,7 @@ func (c *Chain) ToChainConfig() *types.ChainConfig {
 		Name:        c.Name,
 		DisplayName: c.DisplayName,
 		Network:     c.Network,
+		Mainnet:     c.Mainnet,
 	}
 }
 
@@ -1,12 +1,17 @@
 package types
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrNoUpgrade is returned by ChainRegistry.GetUpgradeInfo for chains without an upcoming upgrade
+var ErrNoUpgrade = errors.New("no upgrade scheduled")
+
 type ChainRegistry interface {
-	GetUpgradeInfo(chainName string, includeTestnet bool) (*UpgradeInfo, error)
+	GetUpgradeInfo(ctx context.Context, chainName string, includeTestnet bool) (*UpgradeInfo, error)
 	GetAllChains() ([]string, error)
 	GetMonitoredChains() ([]string, error)
 	IsUpgradeCached(chainName string) bool
@@ -35,6 +40,78 @@ type UpgradeInfo struct {
 	Repo             string    `json:"repo"`
 	RPC              string    `json:"rpc"`
 	API              string    `json:"api"`
+	// CurrentVersion is the version the chain runs today, as reported by its node_info endpoint
+	CurrentVersion string `json:"current_version,omitempty"`
+	// MajorUpgrade is set when the upgrade crosses a major version of the running binary
+	MajorUpgrade bool `json:"major_upgrade,omitempty"`
+	// ChangeType classifies the upgrade against the version the chain runs: "major", "minor", "patch",
+	// "none" or "unknown" when either version is not semver, see version.Classify
+	ChangeType string `json:"change_type,omitempty"`
+	// Status is computed when the upgrade is served, see StatusAt
+	Status string `json:"status,omitempty"`
+	// VoteTally is the vote on the upgrade's governance proposal, when requested
+	VoteTally *VoteTally `json:"vote_tally,omitempty"`
+	// DisplayName is the chain's name in notifications: the pretty_name of its chain.json, or the
+	// display_name configured for it
+	DisplayName string `json:"display_name,omitempty"`
+	// MainnetChain is set on testnet upgrades linked to a mainnet chain that is likely to upgrade next
+	MainnetChain string `json:"mainnet_chain,omitempty"`
+	// Stale is set when the upgrade is served from the last snapshot because no fresh data was available
+	Stale bool `json:"stale,omitempty"`
+	// SourceTime is the time reported by the upgrade's provider and ComputedTime the one estimated from
+	// the chain's height and average block time. Time is the computed one when the two drift apart.
+	SourceTime   time.Time `json:"source_time,omitzero"`
+	ComputedTime time.Time `json:"computed_time,omitzero"`
+	// Source names the upgrade provider that reported the upgrade, e.g. "polkachu"
+	Source string `json:"source,omitempty"`
+	// Sources lists every provider whose data was merged into the upgrade, Source first
+	Sources []string `json:"sources,omitempty"`
+	// BinaryURL is where the linux/amd64 binary of the upgrade is downloaded from, when the
+	// chain-registry lists it
+	BinaryURL string `json:"binary_url,omitempty"`
+}
+
+// VoteTally is the share of each vote option on a governance proposal, in percent of all votes cast
+type VoteTally struct {
+	ProposalID string `json:"proposal_id"`
+	// Status is the proposal status without its PROPOSAL_STATUS_ prefix, e.g. "voting_period" or "passed"
+	Status     string  `json:"status"`
+	Yes        float64 `json:"yes"`
+	No         float64 `json:"no"`
+	Abstain    float64 `json:"abstain"`
+	NoWithVeto float64 `json:"no_with_veto"`
+}
+
+const (
+	UpgradeStatusScheduled  = "scheduled"
+	UpgradeStatusInProgress = "in_progress"
+	UpgradeStatusCompleted  = "completed"
+	// UpgradeStatusUnknown is an upgrade past its in-progress window on a chain whose height is unknown
+	UpgradeStatusUnknown = "unknown"
+)
+
+// StatusAt reports the upgrade status at now. An upgrade is only completed once currentHeight (zero
+// when unknown) reaches the upgrade height. Past the scheduled time it is in progress, and stays so
+// after window while the chain is known to be below the height; without a known height it becomes
+// unknown. Upgrades without a height cannot be confirmed and are completed once window has passed.
+func (u *UpgradeInfo) StatusAt(now time.Time, currentHeight int64, window time.Duration) string {
+	if u.Height > 0 && currentHeight >= u.Height {
+		return UpgradeStatusCompleted
+	}
+	if u.Time.IsZero() || now.Before(u.Time) {
+		return UpgradeStatusScheduled
+	}
+	if now.Before(u.Time.Add(window)) {
+		return UpgradeStatusInProgress
+	}
+	switch {
+	case u.Height == 0:
+		return UpgradeStatusCompleted
+	case currentHeight > 0:
+		return UpgradeStatusInProgress
+	default:
+		return UpgradeStatusUnknown
+	}
 }
 
 func (u *UpgradeInfo) GetChainName() string {
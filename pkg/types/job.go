@@ -7,10 +7,15 @@ type Job struct {
 	TaskName    string `json:"task"`
 	Enabled     bool   `json:"enabled"`
 	Description string `json:"description"`
+	// AllowOverlap lets a run start while the previous one is still running, runs are skipped otherwise
+	AllowOverlap bool `json:"allow_overlap,omitempty"`
 }
 
 // JobConfig represents the job scheduler configuration
 type JobConfig struct {
 	MaxConcurrent int   `json:"max_concurrent"`
 	Predefined    []Job `json:"predefined"`
+	// HistorySize is how many runs of each job are kept for the API. Zero uses the default of 50, a
+	// negative value keeps none.
+	HistorySize int `json:"history_size"`
 }
@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// Kinds of ChainStallEvent
+const (
+	ChainStalled = "chain_stalled"
+	ChainResumed = "chain_resumed"
+)
+
+// ChainStallEvent reports a chain whose height stopped advancing, or that produces blocks again
+// after such a stall
+type ChainStallEvent struct {
+	// Type is ChainStalled or ChainResumed
+	Type  string `json:"type"`
+	Chain string `json:"chain"`
+	// Height is the height the chain is stuck at, or the one it resumed at
+	Height int64 `json:"height"`
+	// StalledSince is when the chain was first seen at the height it got stuck at
+	StalledSince time.Time `json:"stalled_since"`
+	// Duration is how long the chain has been stuck, or was stuck until it resumed
+	Duration time.Duration `json:"duration"`
+}
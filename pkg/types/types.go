@@ -5,6 +5,17 @@ type ChainConfig struct {
 	Name        string `yaml:"name"`
 	DisplayName string `yaml:"display_name"`
 	Network     string `yaml:"network"`
+	// Mainnet links a testnet entry to the mainnet chain whose upgrades usually follow it
+	Mainnet string `yaml:"mainnet,omitempty"`
+	// RPC and REST override the chain-registry endpoints for on-chain queries, e.g. to use our own nodes
+	RPC  []string `yaml:"rpc,omitempty"`
+	REST []string `yaml:"rest,omitempty"`
+	// Sources lists the upgrade providers asked for this chain, in order, instead of upgrades.providers
+	Sources []string `yaml:"sources,omitempty"`
+	// RegistryName and PolkachuName are the chain's names in the chain-registry and on Polkachu when
+	// they differ from Name
+	RegistryName string `yaml:"registry_name,omitempty"`
+	PolkachuName string `yaml:"polkachu_name,omitempty"`
 }
 
 // ChainsConfig represents the configuration for mainnet and testnet chains
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultMinTLSVersion is the minimum TLS version enforced on outbound connections
+const DefaultMinTLSVersion uint16 = tls.VersionTLS12
+
+// ParseTLSVersion converts a version string such as "1.2" or "TLS1.3" into its crypto/tls constant.
+// Versions below DefaultMinTLSVersion are rejected.
+func ParseTLSVersion(version string) (uint16, error) {
+	v := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(version)), "TLS")
+	switch strings.TrimSpace(v) {
+	case "1.0", "1.1":
+		return 0, fmt.Errorf("TLS version %q is insecure, the minimum is 1.2", version)
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q", version)
+	}
+}
+
+// MinTLSVersion returns the version configured via TLS_MIN_VERSION, or DefaultMinTLSVersion when
+// the variable is unset. An invalid value also yields the default along with an error to log.
+func MinTLSVersion() (uint16, error) {
+	value := os.Getenv("TLS_MIN_VERSION")
+	if value == "" {
+		return DefaultMinTLSVersion, nil
+	}
+
+	version, err := ParseTLSVersion(value)
+	if err != nil {
+		return DefaultMinTLSVersion, fmt.Errorf("invalid TLS_MIN_VERSION: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/0xPuncker/cosmos-watcher/pkg/version"
+)
+
+// ParseVersion extracts the numeric major, minor and patch components from a version string such as
+// "v0.50.1", "25.0.0-rc1" or "v2", as parsed by version.Parse. Missing minor or patch components are
+// reported as zero.
+func ParseVersion(tag string) (major, minor, patch int, err error) {
+	v, err := version.Parse(tag)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return v.Major, v.Minor, v.Patch, nil
+}
+
+// IsMajorVersionJump reports whether moving from current to target crosses a major version. Following
+// semver, a minor bump below 1.0 (e.g. v0.47 to v0.50) counts as major too. Versions that cannot be
+// parsed never count as a jump.
+func IsMajorVersionJump(current, target string) bool {
+	curMajor, curMinor, _, err := ParseVersion(current)
+	if err != nil {
+		return false
+	}
+	targetMajor, targetMinor, _, err := ParseVersion(target)
+	if err != nil {
+		return false
+	}
+
+	if targetMajor != curMajor {
+		return targetMajor > curMajor
+	}
+	return curMajor == 0 && targetMinor > curMinor
+}
+
+// IsVersionDowngrade reports whether target is an older version than current, as happens for a
+// rollback or when a source publishes bad data. Versions that cannot be parsed never count.
+func IsVersionDowngrade(current, target string) bool {
+	curMajor, curMinor, curPatch, err := ParseVersion(current)
+	if err != nil {
+		return false
+	}
+	targetMajor, targetMinor, targetPatch, err := ParseVersion(target)
+	if err != nil {
+		return false
+	}
+
+	if targetMajor != curMajor {
+		return targetMajor < curMajor
+	}
+	if targetMinor != curMinor {
+		return targetMinor < curMinor
+	}
+	return targetPatch < curPatch
+}
+
+// SimilarVersions reports whether two version strings likely name the same release, as happens when
+// sources disagree on patch level or formatting ("v25" vs "v25.0.1"). Parsable versions match on major
+// and minor; anything else has to be equal apart from case and a leading "v".
+func SimilarVersions(a, b string) bool {
+	aMajor, aMinor, _, aErr := ParseVersion(a)
+	bMajor, bMinor, _, bErr := ParseVersion(b)
+	if aErr == nil && bErr == nil {
+		return aMajor == bMajor && aMinor == bMinor
+	}
+
+	normalize := func(v string) string {
+		return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(v)), "v")
+	}
+	return normalize(a) != "" && normalize(a) == normalize(b)
+}
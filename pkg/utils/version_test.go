@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		major   int
+		minor   int
+		patch   int
+		wantErr bool
+	}{
+		{"v0.50.1", 0, 50, 1, false},
+		{"25.0.0-rc1", 25, 0, 0, false},
+		{"v2", 2, 0, 0, false},
+		{" V17.1 ", 17, 1, 0, false},
+		{"v1.2.3+build", 1, 2, 3, false},
+		{"", 0, 0, 0, true},
+		{"latest", 0, 0, 0, true},
+		{"v1.2.3.4", 0, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			major, minor, patch, err := ParseVersion(tt.version)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []int{tt.major, tt.minor, tt.patch}, []int{major, minor, patch})
+		})
+	}
+}
+
+func TestIsMajorVersionJump(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		target   string
+		expected bool
+	}{
+		{"major bump", "v24.0.2", "v25.0.0", true},
+		{"multiple majors", "v15.1.0", "v18.0.0", true},
+		{"minor bump", "v24.0.2", "v24.1.0", false},
+		{"patch bump", "v24.0.2", "v24.0.3", false},
+		{"pre-1.0 minor bump", "v0.47.5", "v0.50.1", true},
+		{"pre-1.0 patch bump", "v0.50.1", "v0.50.2", false},
+		{"leaving 0.x", "v0.50.1", "v1.0.0", true},
+		{"downgrade", "v25.0.0", "v24.0.0", false},
+		{"unparseable current", "", "v25.0.0", false},
+		{"unparseable target", "v24.0.0", "main", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsMajorVersionJump(tt.current, tt.target))
+		})
+	}
+}
+
+func TestIsVersionDowngrade(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		target   string
+		expected bool
+	}{
+		{"major downgrade", "v25.0.0", "v24.0.0", true},
+		{"minor downgrade", "v0.50.1", "v0.47.5", true},
+		{"patch downgrade", "v24.0.3", "v24.0.2", true},
+		{"same version", "v24.0.2", "24.0.2", false},
+		{"upgrade", "v24.0.2", "v25.0.0", false},
+		{"pre-release of same version", "v25.0.0", "v25.0.0-rc1", false},
+		{"unparseable current", "", "v24.0.0", false},
+		{"unparseable target", "v25.0.0", "main", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsVersionDowngrade(tt.current, tt.target))
+		})
+	}
+}
+
+func TestSimilarVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"v25", "v25.0.0", true},
+		{"v25.0.0", "v25.0.1", true},
+		{"v25.0.0-rc1", "25.0.0", true},
+		{"v25.0.0", "v25.1.0", false},
+		{"v25.0.0", "v26.0.0", false},
+		{"Nebula", "nebula", true},
+		{"Nebula", "v3.0.0", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SimilarVersions(tt.a, tt.b))
+		})
+	}
+}
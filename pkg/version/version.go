@@ -0,0 +1,98 @@
+// Package version parses the release tags of chain binaries and classifies the change between two of
+// them, telling a patch operators can fast-follow from a consensus-breaking major bump.
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version. Build metadata is dropped.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// ChangeType classifies the difference between two versions
+type ChangeType string
+
+const (
+	Major ChangeType = "major"
+	Minor ChangeType = "minor"
+	Patch ChangeType = "patch"
+	// None is the change between two tags of the same version
+	None ChangeType = "none"
+	// Unknown is the change involving a version that is not semver, such as a git hash or a date tag
+	Unknown ChangeType = "unknown"
+)
+
+// Parse reads tags such as "v15.1.0", "15.1", "v2" or "v0.50.0-rc1"; missing minor or patch
+// components are zero. A bare number without a leading "v", numeric components with leading zeros
+// and more than three components are rejected, since those are dates, block heights or hashes rather
+// than versions.
+func Parse(tag string) (Version, error) {
+	s := strings.ToLower(strings.TrimSpace(tag))
+	prefixed := strings.HasPrefix(s, "v")
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+	core, prerelease, hasPrerelease := strings.Cut(s, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 || (len(parts) == 1 && !prefixed) || (hasPrerelease && !validPrerelease(prerelease)) {
+		return Version{}, fmt.Errorf("invalid version %q", tag)
+	}
+	nums := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || (len(part) > 1 && part[0] == '0') {
+			return Version{}, fmt.Errorf("invalid version %q", tag)
+		}
+		nums[i] = n
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2], Prerelease: prerelease}, nil
+}
+
+// validPrerelease reports whether s is made of dot-separated alphanumeric identifiers, hyphens allowed
+func validPrerelease(s string) bool {
+	for _, identifier := range strings.Split(s, ".") {
+		if identifier == "" {
+			return false
+		}
+		for _, c := range identifier {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Classify returns the change from current to target: the most significant component that differs,
+// Patch when they only differ by pre-release and None when they are the same version. Following
+// semver, a minor change below 1.0 (e.g. v0.47 to v0.50) is Major. Downgrades are classified like
+// upgrades. Either version not parsing gives Unknown.
+func Classify(current, target string) ChangeType {
+	from, err := Parse(current)
+	if err != nil {
+		return Unknown
+	}
+	to, err := Parse(target)
+	if err != nil {
+		return Unknown
+	}
+
+	switch {
+	case from.Major != to.Major:
+		return Major
+	case from.Minor != to.Minor && from.Major == 0:
+		return Major
+	case from.Minor != to.Minor:
+		return Minor
+	case from.Patch != to.Patch, from.Prerelease != to.Prerelease:
+		return Patch
+	}
+	return None
+}
@@ -0,0 +1,91 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    Version
+		wantErr bool
+	}{
+		{tag: "v15.1.0", want: Version{Major: 15, Minor: 1}},
+		{tag: "15.1", want: Version{Major: 15, Minor: 1}},
+		{tag: "15.1.2", want: Version{Major: 15, Minor: 1, Patch: 2}},
+		{tag: "v2", want: Version{Major: 2}},
+		{tag: "v0.50.0-rc1", want: Version{Minor: 50, Prerelease: "rc1"}},
+		{tag: "v0.50.0-rc.1", want: Version{Minor: 50, Prerelease: "rc.1"}},
+		{tag: "v25.0.0-beta-2", want: Version{Major: 25, Prerelease: "beta-2"}},
+		{tag: "v1.2.3+build.5", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{tag: "v1.2.3-rc1+linux", want: Version{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc1"}},
+		{tag: " V17.1 ", want: Version{Major: 17, Minor: 1}},
+		{tag: "v0.0.0", want: Version{}},
+		{tag: "v10.20.30", want: Version{Major: 10, Minor: 20, Patch: 30}},
+		{tag: "", wantErr: true},
+		{tag: "v", wantErr: true},
+		{tag: "latest", wantErr: true},
+		{tag: "main", wantErr: true},
+		{tag: "6c0f9b4a", wantErr: true},
+		{tag: "1234567", wantErr: true},
+		{tag: "2024-03-20", wantErr: true},
+		{tag: "20240320", wantErr: true},
+		{tag: "2024.03.20", wantErr: true},
+		{tag: "v1.2.3.4", wantErr: true},
+		{tag: "v1..2", wantErr: true},
+		{tag: "v1.2.", wantErr: true},
+		{tag: "v1.-2", wantErr: true},
+		{tag: "v1.2.x", wantErr: true},
+		{tag: "v1.2.3-", wantErr: true},
+		{tag: "v1.2.3-rc..1", wantErr: true},
+		{tag: "v1.2.3-rc_1", wantErr: true},
+		{tag: "vv1.2.3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := Parse(tt.tag)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		target  string
+		want    ChangeType
+	}{
+		{name: "major bump", current: "v24.0.2", target: "v25.0.0", want: Major},
+		{name: "multiple majors", current: "v15.1.0", target: "v18.0.0", want: Major},
+		{name: "minor bump", current: "v24.0.2", target: "v24.1.0", want: Minor},
+		{name: "patch bump", current: "v24.0.2", target: "v24.0.3", want: Patch},
+		{name: "short tags", current: "15.1", target: "v15.2", want: Minor},
+		{name: "pre-1.0 minor bump", current: "v0.47.5", target: "v0.50.0-rc1", want: Major},
+		{name: "pre-1.0 patch bump", current: "v0.50.1", target: "v0.50.2", want: Patch},
+		{name: "leaving 0.x", current: "v0.50.1", target: "v1.0.0", want: Major},
+		{name: "release candidate to release", current: "v25.0.0-rc1", target: "v25.0.0", want: Patch},
+		{name: "same version", current: "v25.0.0", target: "25.0.0", want: None},
+		{name: "build metadata only", current: "v25.0.0", target: "v25.0.0+linux", want: None},
+		{name: "major downgrade", current: "v25.0.0", target: "v24.0.0", want: Major},
+		{name: "patch downgrade", current: "v24.0.3", target: "v24.0.2", want: Patch},
+		{name: "unknown current", current: "", target: "v25.0.0", want: Unknown},
+		{name: "git hash current", current: "6c0f9b4a", target: "v25.0.0", want: Unknown},
+		{name: "date tag target", current: "v24.0.0", target: "2024-03-20", want: Unknown},
+		{name: "branch target", current: "v24.0.0", target: "main", want: Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Classify(tt.current, tt.target))
+		})
+	}
+}